@@ -5,13 +5,22 @@
 package main
 
 import (
+	"errors"
+	"os"
+
 	"github.com/gardener/network-problem-detector/pkg/agent"
 	"github.com/gardener/network-problem-detector/pkg/aggregate"
 	"github.com/gardener/network-problem-detector/pkg/collect"
+	"github.com/gardener/network-problem-detector/pkg/common"
 	"github.com/gardener/network-problem-detector/pkg/controller"
 	"github.com/gardener/network-problem-detector/pkg/deploy"
+	"github.com/gardener/network-problem-detector/pkg/export"
+	"github.com/gardener/network-problem-detector/pkg/jobs"
 	"github.com/gardener/network-problem-detector/pkg/list"
 	"github.com/gardener/network-problem-detector/pkg/query"
+	"github.com/gardener/network-problem-detector/pkg/report"
+	"github.com/gardener/network-problem-detector/pkg/tail"
+	"github.com/gardener/network-problem-detector/pkg/trigger"
 
 	"github.com/spf13/cobra"
 )
@@ -37,7 +46,17 @@ func main() {
 	rootCmd.AddCommand(aggregate.CreateAggregateCmd())
 	rootCmd.AddCommand(query.CreateQueryCmd())
 	rootCmd.AddCommand(list.CreateListCmd())
+	rootCmd.AddCommand(export.CreateExportCmd())
+	rootCmd.AddCommand(tail.CreateTailCmd())
+	rootCmd.AddCommand(trigger.CreateTriggerCmd())
+	rootCmd.AddCommand(jobs.CreateJobsCmd())
+	rootCmd.AddCommand(report.CreateReportCmd())
 	err := rootCmd.Execute()
+	var exitErr *common.ExitError
+	if errors.As(err, &exitErr) {
+		// the error has already been printed by the command
+		os.Exit(exitErr.Code)
+	}
 	if err != nil {
 		panic(err)
 	}
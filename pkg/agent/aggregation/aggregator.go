@@ -6,6 +6,7 @@ package aggregation
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"sort"
@@ -32,10 +33,36 @@ type ObsAggregationOptions struct {
 	TimeWindow time.Duration
 	// LogDirectory is an optional parameter to write the report to (in addition to log output)
 	LogDirectory string
+	// ReportWriter is an optional writer the report is written to instead of the files in the log directory, e.g. stdout
+	ReportWriter io.Writer
 	// HostNetwork is true if agent runs on host network
 	HostNetwork bool
 	// K8sExporterConfig configuration for patching conditions in node status and creating events
 	K8sExporterConfig config.K8sExporterConfig
+	// IncidentFile is an optional file to persist the incidents across restarts
+	IncidentFile string
+	// ReportResultFields are the names of the result fields of the last observation included in the report
+	ReportResultFields []string
+	// IncidentMinFailures is the number of consecutive failures of an edge for opening an incident (0 for default)
+	IncidentMinFailures int
+	// IncidentMinRecoveries is the number of consecutive successful observations for closing an incident (0 for default)
+	IncidentMinRecoveries int
+	// IncidentObserver is an optional observer notified about opened and closed incidents
+	IncidentObserver IncidentObserver
+	// ReportFormat is the format of the report file in the log directory (`text` or `json`, default `text`)
+	ReportFormat string
+	// ReportLogJSON if true and the report format is `json`, the edge reports are logged with structured fields
+	ReportLogJSON bool
+	// ZoneObserver is an optional observer notified about the zone edges of each report
+	ZoneObserver ZoneObserver
+	// TopFailingEdges is the number of edges listed in the top failing edges summary of the report (0 for default)
+	TopFailingEdges int
+	// TopFailingEdgesMinChecks is the minimum number of checks of an edge to be included in the summary (0 for default)
+	TopFailingEdgesMinChecks int
+	// ReportRetention is the time the rotated report files are kept (0 for default)
+	ReportRetention time.Duration
+	// ReportMaxBytes is the maximum total size of the current and the rotated report files (0 for default)
+	ReportMaxBytes int64
 }
 
 type obsAggr struct {
@@ -47,9 +74,26 @@ type obsAggr struct {
 	reportPeriod      time.Duration
 	timeWindow        time.Duration
 	logDirectory      string
+	reportWriter      io.Writer
 	hostNetwork       bool
 	validEdges        ValidEdges
+	validEdgesSince   map[hostEdge]time.Time
 	lastReport        time.Time
+	incidents         *incidentTracker
+	resultFields      []string
+	reportFormat      string
+	reportLogJSON     bool
+	zoneObserver      ZoneObserver
+	topFailingEdges   int
+	topMinChecks      int
+	// lastSummary is the top failing edges summary of the last report
+	lastSummary *FailingEdgesSummary
+	rotation    reportRotation
+}
+
+type hostEdge struct {
+	srcHost  string
+	destHost string
 }
 
 type jobEdge struct {
@@ -65,10 +109,45 @@ type ValidEdges struct {
 	PeerNodeCount int
 }
 
+// ValidEdge is a currently valid edge between two hosts.
+type ValidEdge struct {
+	SrcHost  string
+	DestHost string
+	// ValidSince is the time since the edge is known as valid.
+	ValidSince time.Time
+}
+
 type ObservationListenerExtended interface {
 	nwpd.ObservationListener
 
 	UpdateValidEdges(edges ValidEdges)
+	GetValidEdges() []ValidEdge
+	// Reconfigure changes report period and time window at runtime.
+	Reconfigure(reportPeriod, timeWindow time.Duration)
+	// ListIncidents returns the open and the recently closed incidents sorted by start time.
+	ListIncidents() []*nwpd.Incident
+	// SetReportResultFields changes the result fields included in the report at runtime.
+	SetReportResultFields(names []string)
+	// SetIncidentThresholds changes the number of consecutive failures for opening and of consecutive successes
+	// for closing an incident at runtime (0 for default).
+	SetIncidentThresholds(minFailures, minRecoveries int)
+	// SetReportFormat changes the format of the report file and the structured logging of the edge reports at runtime.
+	SetReportFormat(format string, logJSON bool)
+	// SetTopFailingEdges changes the number of edges and the minimum number of checks of an edge
+	// for the top failing edges summary at runtime (0 for default).
+	SetTopFailingEdges(k, minChecks int)
+	// SetReportRotation changes the retention and the maximum total size of the report files at runtime (0 for default).
+	SetReportRotation(retention time.Duration, maxBytes int64)
+	// SetReportOutput changes the sink of the report at runtime: the writer if set, otherwise the files in the
+	// log directory. If both are empty, the report is only logged.
+	SetReportOutput(logDirectory string, w io.Writer)
+	// SetIncidentFile changes the file the incidents are persisted to at runtime (empty for not persisting them).
+	// The current incidents are kept and written to the new file.
+	SetIncidentFile(filename string)
+	// GetFailingEdgesSummary returns the top failing edges summary of the last report.
+	GetFailingEdgesSummary() *FailingEdgesSummary
+	// Flush writes the report of the observations since the last report, e.g. on shutdown.
+	Flush()
 }
 
 func (je jobEdge) String() string {
@@ -76,18 +155,23 @@ func (je jobEdge) String() string {
 }
 
 type jobEdgeAggregation struct {
-	firstTime          time.Time
-	totalCount         int
-	reportStart        time.Time
-	reportOkCount      int
-	reportFailureCount int
-	okLast             time.Time
-	okStrikeFirst      time.Time
-	okStrike           int
-	failedLast         time.Time
-	failedStrikeFirst  time.Time
-	failedStrike       int
-	lastObs            *nwpd.Observation
+	firstTime               time.Time
+	totalCount              int
+	reportStart             time.Time
+	reportOkCount           int
+	reportFailureCount      int
+	okLast                  time.Time
+	okStrikeFirst           time.Time
+	okStrike                int
+	failedLast              time.Time
+	failedStrikeFirst       time.Time
+	failedStrikeFirstResult string
+	failedStrike            int
+	lastObs                 *nwpd.Observation
+	// incidentID is the ID of the open incident of the edge
+	incidentID string
+	// latency counts the durations of the successful observations within the time window
+	latency latencyWindow
 }
 
 func (jea *jobEdgeAggregation) IsOKSinceLastReport() bool {
@@ -106,7 +190,20 @@ func (jea *jobEdgeAggregation) IsOverdue() bool {
 	return true
 }
 
-func (jea *jobEdgeAggregation) Report(je jobEdge, start time.Time) string {
+func (jea *jobEdgeAggregation) Report(je jobEdge, start time.Time, resultFields []string) string {
+	return jea.report(je, start) + jea.latencyPercentiles(time.Now()) + jea.lastResultFields(resultFields)
+}
+
+// latencyPercentiles formats the percentiles of the durations of the successful observations within the time window.
+func (jea *jobEdgeAggregation) latencyPercentiles(now time.Time) string {
+	h := jea.latency.histogram(now)
+	if h.Count() == 0 {
+		return ""
+	}
+	return " " + h.Percentiles().String()
+}
+
+func (jea *jobEdgeAggregation) report(je jobEdge, start time.Time) string {
 	if jea.IsOKSinceLastReport() {
 		msg := fmt.Sprintf("%s: OK", je)
 		if jea.lastObs != nil && jea.lastObs.Duration != nil {
@@ -119,15 +216,36 @@ func (jea *jobEdgeAggregation) Report(je jobEdge, start time.Time) string {
 		return msg
 	}
 	seconds := int(time.Since(start).Seconds())
-	return fmt.Sprintf("%s: %d/%d checks failed in last %ds (last ok: %s)", je,
+	msg := fmt.Sprintf("%s: %d/%d checks failed in last %ds (last ok: %s)", je,
 		jea.reportFailureCount, jea.reportFailureCount+jea.reportOkCount, seconds, common.FormatAsUTC(jea.okLast))
+	if jea.incidentID != "" {
+		msg += fmt.Sprintf(" incident: %s", jea.incidentID)
+	}
+	return msg
+}
+
+// lastResultFields formats the given result fields of the last observation if available.
+func (jea *jobEdgeAggregation) lastResultFields(names []string) string {
+	if jea.lastObs == nil || len(jea.lastObs.ResultFields) == 0 {
+		return ""
+	}
+	var values []string
+	for _, name := range names {
+		if value, ok := jea.lastObs.ResultFields[name]; ok {
+			values = append(values, name+"="+value)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(values, " ") + "]"
 }
 
 func (jea *jobEdgeAggregation) add(obs *nwpd.Observation) {
 	jea.totalCount++
 	jea.lastObs = obs
 	if obs.Ok {
-		if jea.okLast.Before(jea.failedLast) {
+		if jea.okStrike == 0 || jea.okLast.Before(jea.failedLast) {
 			jea.okStrike = 0
 			jea.okStrikeFirst = obs.Timestamp.AsTime()
 		}
@@ -135,9 +253,10 @@ func (jea *jobEdgeAggregation) add(obs *nwpd.Observation) {
 		jea.okStrike++
 		jea.reportOkCount++
 	} else {
-		if jea.failedLast.Before(jea.okLast) {
+		if jea.failedStrike == 0 || jea.failedLast.Before(jea.okLast) {
 			jea.failedStrike = 0
 			jea.failedStrikeFirst = obs.Timestamp.AsTime()
+			jea.failedStrikeFirstResult = obs.Result
 		}
 		jea.failedLast = obs.Timestamp.AsTime()
 		jea.failedStrike++
@@ -334,17 +453,29 @@ func NewObsAggregator(options *ObsAggregationOptions) (ObservationListenerExtend
 		}
 	}
 
-	return &obsAggr{
+	aggr := &obsAggr{
 		log:               options.Log,
 		aggregations:      map[jobEdge]*jobEdgeAggregation{},
+		validEdgesSince:   map[hostEdge]time.Time{},
 		lastReport:        time.Now(),
 		reportPeriod:      options.ReportPeriod,
 		timeWindow:        options.TimeWindow,
 		logDirectory:      options.LogDirectory,
+		reportWriter:      options.ReportWriter,
 		hostNetwork:       options.HostNetwork,
 		k8sExporter:       k8sExporter,
 		k8sExporterConfig: options.K8sExporterConfig,
-	}, nil
+		incidents: newIncidentTracker(options.Log, options.IncidentFile, options.IncidentObserver,
+			options.IncidentMinFailures, options.IncidentMinRecoveries),
+		resultFields:  options.ReportResultFields,
+		reportFormat:  options.ReportFormat,
+		reportLogJSON: options.ReportLogJSON,
+		zoneObserver:  options.ZoneObserver,
+		lastSummary:   &FailingEdgesSummary{},
+		rotation:      newReportRotation(options.ReportRetention, options.ReportMaxBytes),
+	}
+	aggr.setTopFailingEdges(options.TopFailingEdges, options.TopFailingEdgesMinChecks)
+	return aggr, nil
 }
 
 func (a *obsAggr) UpdateValidEdges(edges ValidEdges) {
@@ -352,9 +483,149 @@ func (a *obsAggr) UpdateValidEdges(edges ValidEdges) {
 	defer a.lock.Unlock()
 
 	a.validEdges = edges
+
+	now := time.Now()
+	validEdgesSince := map[hostEdge]time.Time{}
+	for src := range edges.SrcHosts {
+		for dest := range edges.DestHosts {
+			he := hostEdge{srcHost: src, destHost: dest}
+			if since, ok := a.validEdgesSince[he]; ok {
+				validEdgesSince[he] = since
+			} else {
+				validEdgesSince[he] = now
+			}
+		}
+	}
+	a.validEdgesSince = validEdgesSince
+}
+
+func (a *obsAggr) Reconfigure(reportPeriod, timeWindow time.Duration) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if reportPeriod != a.reportPeriod {
+		a.log.Infof("aggregation report period changed from %s to %s", a.reportPeriod, reportPeriod)
+		a.reportPeriod = reportPeriod
+	}
+	if timeWindow != a.timeWindow {
+		a.log.Infof("aggregation time window changed from %s to %s", a.timeWindow, timeWindow)
+		if timeWindow < a.timeWindow {
+			// drop state outside the new time window, aggregations within it are kept
+			outdated := time.Now().Add(-1 * timeWindow)
+			for je, aggr := range a.aggregations {
+				if aggr.lastTimestamp().Before(outdated) {
+					delete(a.aggregations, je)
+				}
+			}
+		}
+		// the durations are kept, so that the latency percentiles are not reset
+		now := time.Now()
+		for _, aggr := range a.aggregations {
+			aggr.latency.resize(timeWindow, now)
+		}
+		a.timeWindow = timeWindow
+	}
+}
+
+func (a *obsAggr) SetReportResultFields(names []string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.resultFields = names
+}
+
+func (a *obsAggr) SetIncidentThresholds(minFailures, minRecoveries int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.incidents.setThresholds(minFailures, minRecoveries)
+}
+
+func (a *obsAggr) SetReportFormat(format string, logJSON bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.reportFormat = format
+	a.reportLogJSON = logJSON
+}
+
+func (a *obsAggr) SetReportOutput(logDirectory string, w io.Writer) {
+	if logDirectory != "" {
+		if err := os.MkdirAll(logDirectory, 0o750); err != nil { //  #nosec G302 -- no sensitive data
+			a.log.Warnf("cannot create log directory %s: %s", logDirectory, err)
+		}
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.logDirectory = logDirectory
+	a.reportWriter = w
+}
+
+func (a *obsAggr) SetTopFailingEdges(k, minChecks int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.setTopFailingEdges(k, minChecks)
+}
+
+func (a *obsAggr) setTopFailingEdges(k, minChecks int) {
+	a.topFailingEdges = DefaultTopFailingEdges
+	if k > 0 {
+		a.topFailingEdges = k
+	}
+	a.topMinChecks = DefaultTopFailingEdgesMinChecks
+	if minChecks > 0 {
+		a.topMinChecks = minChecks
+	}
+}
+
+func (a *obsAggr) SetIncidentFile(filename string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.incidents.setFilename(filename)
+}
+
+func (a *obsAggr) SetReportRotation(retention time.Duration, maxBytes int64) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.rotation = newReportRotation(retention, maxBytes)
+}
+
+// GetFailingEdgesSummary returns the top failing edges summary of the last report.
+func (a *obsAggr) GetFailingEdgesSummary() *FailingEdgesSummary {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.lastSummary
+}
+
+func (a *obsAggr) GetValidEdges() []ValidEdge {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	edges := make([]ValidEdge, 0, len(a.validEdgesSince))
+	for he, since := range a.validEdgesSince {
+		edges = append(edges, ValidEdge{SrcHost: he.srcHost, DestHost: he.destHost, ValidSince: since})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].SrcHost != edges[j].SrcHost {
+			return edges[i].SrcHost < edges[j].SrcHost
+		}
+		return edges[i].DestHost < edges[j].DestHost
+	})
+	return edges
 }
 
 func (a *obsAggr) Add(obs *nwpd.Observation) {
+	if obs.StaleEndpoint {
+		// outdated destination, not a network problem
+		return
+	}
+
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
@@ -369,6 +640,10 @@ func (a *obsAggr) Add(obs *nwpd.Observation) {
 	}
 
 	jea.add(obs)
+	if obs.Ok && obs.Duration != nil {
+		jea.latency.add(obs.Timestamp.AsTime(), obs.Duration.AsDuration(), a.timeWindow)
+	}
+	a.incidents.observe(je, jea, obs)
 
 	if a.lastReport.Add(a.reportPeriod).Before(time.Now()) {
 		go a.report()
@@ -382,6 +657,13 @@ type reportOptions struct {
 	conditionMinFailureCount int
 	conditionMinTimeWindow   time.Duration
 	minFailingPeerNodeShare  float64
+	resultFields             []string
+	// edgeReports if true, the machine-readable reports of all edges are calculated
+	edgeReports bool
+	// topFailingEdges is the number of edges of the top failing edges summary
+	topFailingEdges int
+	// topMinChecks is the minimum number of checks of an edge to be included in the summary
+	topMinChecks int
 }
 
 type reportData struct {
@@ -391,8 +673,12 @@ type reportData struct {
 	jobCounter  *groupCounter
 	srcCounter  *groupCounter
 	destCounter *groupCounter
+	zoneCounter zoneCounter
+	edgeCounter edgeFailureCounter
+	topFailing  *FailingEdgesSummary
 	noissues    []string
 	issues      []string
+	edges       []EdgeReport
 	status      *conditionStatus
 }
 
@@ -404,6 +690,8 @@ func newReportData(start, end time.Time, options *reportOptions) *reportData {
 		jobCounter:  newGroupCounter(),
 		srcCounter:  newGroupCounter(),
 		destCounter: newGroupCounter(),
+		zoneCounter: zoneCounter{},
+		edgeCounter: edgeFailureCounter{},
 		status:      newConditionStatus(options.hostNetwork, options.minFailingPeerNodeShare),
 	}
 }
@@ -418,11 +706,13 @@ func (r *reportData) add(je jobEdge, aggr *jobEdgeAggregation) {
 	r.jobCounter.inc(je.jobID, ok)
 	r.srcCounter.inc(je.srcHost, ok)
 	r.destCounter.inc(je.destHost, ok)
+	r.zoneCounter.add(aggr)
+	r.edgeCounter.add(je, aggr)
 	if ok != nil && !*ok {
-		r.issues = append(r.issues, aggr.Report(je, r.start))
+		r.issues = append(r.issues, aggr.Report(je, r.start, r.options.resultFields))
 	} else if r.options.fullReport || ok == nil {
 		if r.options.fullReport || aggr.IsOverdue() {
-			r.noissues = append(r.noissues, aggr.Report(je, r.start))
+			r.noissues = append(r.noissues, aggr.Report(je, r.start, r.options.resultFields))
 		}
 	}
 }
@@ -437,87 +727,220 @@ func (r *reportData) updateStatus(je jobEdge, aggr *jobEdgeAggregation) {
 func (r *reportData) sort() {
 	sort.Strings(r.issues)
 	sort.Strings(r.noissues)
+	sort.Slice(r.edges, func(i, j int) bool {
+		a, b := r.edges[i], r.edges[j]
+		if a.SrcHost != b.SrcHost {
+			return a.SrcHost < b.SrcHost
+		}
+		if a.DestHost != b.DestHost {
+			return a.DestHost < b.DestHost
+		}
+		return a.JobID < b.JobID
+	})
 }
 
 func (r *reportData) summary() []string {
-	return []string{
+	lines := []string{
 		fmt.Sprintf("Jobs: %s", r.jobCounter.summary()),
 		fmt.Sprintf("SourceHost: %s", r.srcCounter.summary()),
 		fmt.Sprintf("DestHost: %s", r.destCounter.summary()),
 	}
+	if len(r.zoneCounter) > 0 {
+		lines = append(lines, fmt.Sprintf("Zones: %s", r.zoneCounter.summary()))
+	}
+	return lines
+}
+
+func (a *obsAggr) Flush() {
+	a.lock.Lock()
+	a.lastReport = time.Now()
+	a.lock.Unlock()
+	a.report()
 }
 
 func (a *obsAggr) report() {
+	a.lock.Lock()
+	resultFields := a.resultFields
+	jsonFormat := a.reportFormat == config.ReportFormatJSON
+	logJSON := jsonFormat && a.reportLogJSON
+	topFailingEdges, topMinChecks := a.topFailingEdges, a.topMinChecks
+	logDirectory, reportWriter := a.logDirectory, a.reportWriter
+	a.lock.Unlock()
 	options := &reportOptions{
 		fullReport:               false,
 		hostNetwork:              a.hostNetwork,
 		conditionMinFailureCount: 2,
 		conditionMinTimeWindow:   3 * time.Minute,
 		minFailingPeerNodeShare:  a.k8sExporterConfig.MinFailingPeerNodeShare,
+		resultFields:             resultFields,
+		edgeReports:              jsonFormat,
+		topFailingEdges:          topFailingEdges,
+		topMinChecks:             topMinChecks,
 	}
 	report := a.calcReport(options, true)
+	a.lock.Lock()
+	a.lastSummary = report.topFailing
+	a.lock.Unlock()
+	a.saveIncidents()
 	report.sort()
-	a.reportToLog(report)
-	a.reportToFilesystem(report)
+	a.reportToLog(report, logJSON)
+	switch {
+	case reportWriter != nil:
+		a.reportToWriter(reportWriter, report, jsonFormat)
+	case jsonFormat:
+		a.reportToJSONFile(logDirectory, report)
+	default:
+		a.reportToFilesystem(logDirectory, report)
+	}
+	a.cleanupReportFiles(logDirectory)
 	a.reportToK8sExporter(report)
+	if a.zoneObserver != nil {
+		a.zoneObserver.ZoneEdgesReported(report.zoneCounter.reports())
+	}
 }
 
-func (a *obsAggr) reportToLog(report *reportData) {
+func (a *obsAggr) reportToLog(report *reportData, logJSON bool) {
 	prefix := "Report: "
-	for _, s := range report.noissues {
-		a.log.Info(prefix + s)
-	}
-	for _, s := range report.issues {
+	for _, s := range report.topFailing.lines() {
 		a.log.Warn(prefix + s)
 	}
+	if logJSON {
+		for i := range report.edges {
+			edge := &report.edges[i]
+			entry := a.log.WithFields(edge.logFields())
+			if edge.Status == EdgeStatusFailed {
+				entry.Warn("edge report")
+			} else {
+				entry.Info("edge report")
+			}
+		}
+	} else {
+		for _, s := range report.noissues {
+			a.log.Info(prefix + s)
+		}
+		for _, s := range report.issues {
+			a.log.Warn(prefix + s)
+		}
+	}
 	for _, s := range report.summary() {
 		a.log.Info(prefix + s)
 	}
 }
 
-func (a *obsAggr) reportToFilesystem(report *reportData) {
-	if a.logDirectory == "" {
-		return
-	}
-
+func (a *obsAggr) reportFileName(ext string) string {
 	name := common.NameDaemonSetAgentPodNet
-	if report.options.hostNetwork {
+	if a.hostNetwork {
 		name = common.NameDaemonSetAgentHostNet
 	}
-	filename := path.Join(a.logDirectory, name+".log")
+	return name + ext
+}
+
+// openReportFile opens the report file with the given extension in the log directory for appending.
+// If the file has exceeded the rotation size, it is rotated first.
+func (a *obsAggr) openReportFile(logDirectory, ext string) *os.File {
+	a.lock.Lock()
+	rotation := a.rotation
+	a.lock.Unlock()
+
+	filename := path.Join(logDirectory, a.reportFileName(ext))
 	info, err := os.Stat(filename)
 	if err != nil && !os.IsNotExist(err) {
 		a.log.Warnf("cannot write log to %s: %s", filename, err)
-		return
+		return nil
 	}
-	if err == nil && info.Size() > common.MaxLogfileSize {
-		old := filename + ".old"
-		_ = os.Remove(old)
-		err := os.Rename(filename, old)
-		if err != nil {
-			a.log.Warnf("cannot rename %s to %s: %s", filename, old, err)
+	if err == nil && info.Size() > rotation.rotateSize() {
+		if _, err := rotateReportFile(filename, time.Now()); err != nil {
+			a.log.Warnf("cannot rotate %s: %s", filename, err)
 		}
 	}
 	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640) //  #nosec G302 G304 -- no sensitive data
 	if err != nil {
 		a.log.Warnf("cannot open %s: %s", filename, err)
+		return nil
+	}
+	return f
+}
+
+// cleanupReportFiles deletes the outdated rotated report files of both report formats.
+func (a *obsAggr) cleanupReportFiles(logDirectory string) {
+	if logDirectory == "" {
+		return
+	}
+	a.lock.Lock()
+	rotation := a.rotation
+	a.lock.Unlock()
+
+	names := []string{a.reportFileName(".log"), a.reportFileName(".jsonl")}
+	deleted, err := cleanupReportFiles(logDirectory, names, rotation, time.Now())
+	if err != nil {
+		a.log.Warnf("cannot clean up report files: %s", err)
+	}
+	if len(deleted) > 0 {
+		a.log.Infof("deleted rotated report files: %s", strings.Join(deleted, ", "))
+	}
+}
+
+// reportToJSONFile writes the edge reports as JSON lines to the report file in the log directory.
+func (a *obsAggr) reportToJSONFile(logDirectory string, report *reportData) {
+	if logDirectory == "" {
+		return
+	}
+
+	f := a.openReportFile(logDirectory, ".jsonl")
+	if f == nil {
 		return
 	}
 	defer f.Close()
 
-	prefix := time.Now().UTC().Format("2006-01-02T15:04:05Z ")
-	for _, s := range report.issues {
-		_, _ = f.WriteString(prefix)
-		_, _ = f.WriteString(s)
-		_, _ = f.WriteString("\n")
+	if err := WriteEdgeReports(f, report.edges); err != nil {
+		a.log.Warnf("cannot write report to %s: %s", f.Name(), err)
 	}
-	for _, s := range report.summary() {
-		_, _ = f.WriteString(prefix)
-		_, _ = f.WriteString(s)
-		_, _ = f.WriteString("\n")
+}
+
+func (a *obsAggr) reportToFilesystem(logDirectory string, report *reportData) {
+	if logDirectory == "" {
+		return
+	}
+
+	f := a.openReportFile(logDirectory, ".log")
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	if err := writeReportLines(f, report); err != nil {
+		a.log.Warnf("cannot write report to %s: %s", f.Name(), err)
+	}
+}
+
+// reportToWriter writes the report in the same format as to the report file, e.g. to stdout for log collection.
+func (a *obsAggr) reportToWriter(w io.Writer, report *reportData, jsonFormat bool) {
+	var err error
+	if jsonFormat {
+		err = WriteEdgeReports(w, report.edges)
+	} else {
+		err = writeReportLines(w, report)
+	}
+	if err != nil {
+		a.log.Warnf("cannot write report: %s", err)
 	}
 }
 
+// writeReportLines writes the top failing edges, the issues and the summary of the text report with a timestamp per line.
+func writeReportLines(w io.Writer, report *reportData) error {
+	prefix := time.Now().UTC().Format("2006-01-02T15:04:05Z ")
+	var sb strings.Builder
+	for _, lines := range [][]string{report.topFailing.lines(), report.issues, report.summary()} {
+		for _, s := range lines {
+			sb.WriteString(prefix)
+			sb.WriteString(s)
+			sb.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
 func (a *obsAggr) reportToK8sExporter(report *reportData) {
 	if a.k8sExporter == nil {
 		return
@@ -546,14 +969,41 @@ func (a *obsAggr) calcReport(options *reportOptions, resetCount bool) *reportDat
 			delete(a.aggregations, je)
 		}
 		report.add(je, aggr)
+		if options.edgeReports {
+			report.edges = append(report.edges, aggr.edgeReport(je, start, end, options.resultFields, a.incidents.open[je]))
+		}
 		if resetCount {
 			aggr.reportOkCount = 0
 			aggr.reportFailureCount = 0
 		}
 	}
+	report.topFailing = &FailingEdgesSummary{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		MinChecks:   options.topMinChecks,
+		Edges:       report.edgeCounter.top(options.topFailingEdges, options.topMinChecks),
+	}
+	a.incidents.closeOrphaned(a.aggregations, outdated)
+	report.issues = append(report.issues, a.incidents.openSummaries(end)...)
 	return report
 }
 
+// ListIncidents returns the open and the recently closed incidents sorted by start time.
+func (a *obsAggr) ListIncidents() []*nwpd.Incident {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.incidents.list()
+}
+
+// saveIncidents persists the updated counters of the open incidents.
+func (a *obsAggr) saveIncidents() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.incidents.save()
+}
+
 func (a *obsAggr) isValidEdge(je jobEdge) bool {
 	if a.validEdges.JobIDs.Len() == 0 &&
 		a.validEdges.SrcHosts.Len() == 0 &&
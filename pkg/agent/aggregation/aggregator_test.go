@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("aggregator", func() {
+	var aggr *obsAggr
+
+	newObs := func(destHost string, age time.Duration) *nwpd.Observation {
+		return &nwpd.Observation{
+			JobID:     "job1",
+			SrcHost:   "node1",
+			DestHost:  destHost,
+			Timestamp: timestamppb.New(time.Now().Add(-age)),
+			Period:    durationpb.New(10 * time.Second),
+			Ok:        false,
+		}
+	}
+
+	reportedIssues := func() []string {
+		report := aggr.calcReport(&reportOptions{}, false)
+		report.sort()
+		return report.issues
+	}
+
+	BeforeEach(func() {
+		listener, err := NewObsAggregator(&ObsAggregationOptions{
+			Log:          logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:     "node1",
+			ReportPeriod: 1 * time.Hour,
+			TimeWindow:   30 * time.Minute,
+		})
+		Expect(err).To(BeNil())
+		aggr = listener.(*obsAggr)
+	})
+
+	It("drops state outside a shrunk time window", func() {
+		aggr.Add(newObs("node2", 20*time.Minute))
+		aggr.Add(newObs("node3", 1*time.Minute))
+		aggr.Add(newObs("node3", 0))
+		Expect(reportedIssues()).To(HaveLen(2))
+
+		aggr.Reconfigure(2*time.Minute, 10*time.Minute)
+		Expect(aggr.reportPeriod).To(Equal(2 * time.Minute))
+		issues := reportedIssues()
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0]).To(ContainSubstring("node1->node3[job1]: 2/2 checks failed"))
+	})
+
+	It("extends retention for a grown time window", func() {
+		aggr.Reconfigure(1*time.Hour, 60*time.Minute)
+		aggr.Add(newObs("node2", 40*time.Minute))
+		aggr.Add(newObs("node3", 1*time.Minute))
+		issues := reportedIssues()
+		Expect(issues).To(HaveLen(2))
+		Expect(issues[0]).To(ContainSubstring("node1->node2[job1]: 1/1 checks failed"))
+		Expect(issues[1]).To(ContainSubstring("node1->node3[job1]: 1/1 checks failed"))
+
+		// still retained on next report
+		Expect(reportedIssues()).To(HaveLen(2))
+	})
+
+	It("includes the selected result fields of the last observation", func() {
+		obs := newObs("node2", 0)
+		obs.ResultFields = map[string]string{"httpStatus": "503", "redirects": "1", "attempts": "2"}
+		aggr.Add(obs)
+		aggr.SetReportResultFields([]string{"httpStatus", "attempts", "certDaysRemaining"})
+		report := aggr.calcReport(&reportOptions{resultFields: aggr.resultFields}, false)
+		Expect(report.issues).To(HaveLen(1))
+		Expect(report.issues[0]).To(HaveSuffix(" [httpStatus=503 attempts=2]"))
+	})
+
+	It("rolls up the checks per zone pair", func() {
+		add := func(destHost, destZone string, ok bool) {
+			obs := newObs(destHost, 0)
+			obs.Ok = ok
+			if destZone != "" {
+				obs.SrcZone = "zone-a"
+				obs.DestZone = destZone
+			}
+			aggr.Add(obs)
+		}
+		add("node2", "zone-b", false)
+		add("node2", "zone-b", true)
+		add("node3", "zone-b", true)
+		add("node4", "unknown", true)
+		add("api.example.com", "", false)
+
+		observer := &fakeZoneObserver{}
+		aggr.zoneObserver = observer
+		aggr.report()
+		Expect(observer.reports).To(Equal([]ZoneEdgeReport{
+			{SrcZone: "zone-a", DestZone: "unknown", Checks: 1},
+			{SrcZone: "zone-a", DestZone: "zone-b", Checks: 3, Failures: 1},
+		}))
+
+		// the counts have been reset by the report
+		Expect(aggr.calcReport(&reportOptions{}, false).summary()).NotTo(ContainElement(HavePrefix("Zones:")))
+		add("node2", "zone-b", false)
+		Expect(aggr.calcReport(&reportOptions{}, false).summary()).To(ContainElement("Zones: zone-a->zone-b 1/1 failed (100.0%)"))
+	})
+
+	It("summarises the top failing edges of the report period", func() {
+		add := func(jobID, destHost string, ok ...bool) {
+			for _, v := range ok {
+				obs := newObs(destHost, 0)
+				obs.JobID = jobID
+				obs.Ok = v
+				aggr.Add(obs)
+			}
+		}
+		add("job1", "node2", false, false, true, true, true)
+		add("job2", "node2", true, true, false)
+		add("job1", "node3", false, false, false, true, true, true, true, true, true)
+		add("job1", "node4", false, false, false, false, false)
+		add("job1", "node5", false, false)
+		add("job1", "node6", true, true, true, true, true)
+		aggr.SetTopFailingEdges(2, 0)
+
+		aggr.report()
+		summary := aggr.GetFailingEdgesSummary()
+		Expect(summary.MinChecks).To(Equal(DefaultTopFailingEdgesMinChecks))
+		Expect(summary.PeriodEnd).NotTo(BeZero())
+		Expect(summary.Edges).To(Equal([]FailingEdge{
+			{SrcHost: "node1", DestHost: "node4", Checks: 5, Failures: 5, JobIDs: []string{"job1"}, Ongoing: true},
+			{SrcHost: "node1", DestHost: "node2", Checks: 8, Failures: 3, JobIDs: []string{"job1", "job2"}, Ongoing: true},
+		}))
+		Expect(summary.lines()).To(Equal([]string{
+			"Top 2 failing edges (min 5 checks):",
+			"  1. node1->node4 5/5 failed (100.0%) jobs: job1 ongoing",
+			"  2. node1->node2 3/8 failed (37.5%) jobs: job1,job2 ongoing",
+		}))
+
+		aggr.SetTopFailingEdges(10, 6)
+		add("job1", "node3", false, false, false, true, true, true)
+		aggr.report()
+		Expect(aggr.GetFailingEdgesSummary().Edges).To(Equal([]FailingEdge{
+			{SrcHost: "node1", DestHost: "node3", Checks: 6, Failures: 3, JobIDs: []string{"job1"}},
+		}))
+	})
+
+	It("flushes the report before the end of the report period", func() {
+		for i := 0; i < 5; i++ {
+			obs := newObs("node2", 0)
+			obs.Ok = false
+			aggr.Add(obs)
+		}
+		Expect(aggr.GetFailingEdgesSummary().Edges).To(BeEmpty())
+		before := time.Now()
+		aggr.Flush()
+		Expect(aggr.lastReport).To(BeTemporally(">=", before))
+		Expect(aggr.GetFailingEdgesSummary().Edges).To(HaveLen(1))
+	})
+
+	It("removes outdated edges with the original time window", func() {
+		aggr.Add(newObs("node2", 40*time.Minute))
+		Expect(reportedIssues()).To(HaveLen(1))
+		Expect(reportedIssues()).To(BeEmpty())
+	})
+})
+
+type fakeZoneObserver struct {
+	reports []ZoneEdgeReport
+}
+
+func (o *fakeZoneObserver) ZoneEdgesReported(reports []ZoneEdgeReport) {
+	o.reports = reports
+}
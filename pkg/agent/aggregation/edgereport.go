@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EdgeStatusOK is the status of an edge without failed checks in the report period.
+	EdgeStatusOK = "ok"
+	// EdgeStatusFailed is the status of an edge with at least one failed check in the report period.
+	EdgeStatusFailed = "failed"
+	// EdgeStatusNoData is the status of an edge without observations in the report period.
+	EdgeStatusNoData = "noData"
+)
+
+// EdgeReport is the machine-readable aggregation report of a job edge for one report period.
+// In the json report format, it is written as a single line of a JSON lines file.
+type EdgeReport struct {
+	// Time is the end of the report period.
+	Time time.Time `json:"time"`
+	// PeriodStart is the start of the report period.
+	PeriodStart time.Time `json:"periodStart"`
+	JobID       string    `json:"jobID"`
+	SrcHost     string    `json:"srcHost"`
+	DestHost    string    `json:"destHost"`
+	// SrcZone and DestZone are the zones of the nodes if the destination is a node.
+	SrcZone  string `json:"srcZone,omitempty"`
+	DestZone string `json:"destZone,omitempty"`
+	// Status is one of `ok`, `failed`, or `noData`.
+	Status string `json:"status"`
+	// OkCount is the number of successful checks in the report period.
+	OkCount int `json:"okCount"`
+	// FailedCount is the number of failed checks in the report period.
+	FailedCount int `json:"failedCount"`
+	// LastOk is the time of the last successful check within the time window if any.
+	LastOk *time.Time `json:"lastOk,omitempty"`
+	// LastObserved is the time of the last observation.
+	LastObserved *time.Time `json:"lastObserved,omitempty"`
+	// Latency are the statistics of the durations of the successful checks within the time window.
+	Latency *EdgeLatency `json:"latency,omitempty"`
+	// Incident is the open incident of the edge if any.
+	Incident *EdgeIncident `json:"incident,omitempty"`
+	// ResultFields are the selected result fields of the last observation.
+	ResultFields map[string]string `json:"resultFields,omitempty"`
+}
+
+// EdgeLatency are the statistics of the durations of the successful checks of an edge in milliseconds.
+type EdgeLatency struct {
+	Count      int     `json:"count"`
+	MeanMillis float64 `json:"meanMillis"`
+	P50Millis  float64 `json:"p50Millis"`
+	P95Millis  float64 `json:"p95Millis"`
+	P99Millis  float64 `json:"p99Millis"`
+}
+
+// EdgeIncident is the state of the open incident of an edge.
+type EdgeIncident struct {
+	IncidentID  string    `json:"incidentID"`
+	Start       time.Time `json:"start"`
+	FailedCount int32     `json:"failedCount"`
+	OkCount     int32     `json:"okCount"`
+}
+
+// edgeReport returns the machine-readable report of the edge for the report period.
+func (jea *jobEdgeAggregation) edgeReport(je jobEdge, start, end time.Time, resultFields []string, inc *nwpd.Incident) EdgeReport {
+	r := EdgeReport{
+		Time:        end.UTC(),
+		PeriodStart: start.UTC(),
+		JobID:       je.jobID,
+		SrcHost:     je.srcHost,
+		DestHost:    je.destHost,
+		OkCount:     jea.reportOkCount,
+		FailedCount: jea.reportFailureCount,
+	}
+	switch {
+	case jea.reportFailureCount > 0:
+		r.Status = EdgeStatusFailed
+	case jea.reportOkCount > 0:
+		r.Status = EdgeStatusOK
+	default:
+		r.Status = EdgeStatusNoData
+	}
+	if !jea.okLast.IsZero() {
+		lastOk := jea.okLast.UTC()
+		r.LastOk = &lastOk
+	}
+	if jea.lastObs != nil {
+		lastObserved := jea.lastObs.Timestamp.AsTime().UTC()
+		r.LastObserved = &lastObserved
+		r.SrcZone = jea.lastObs.SrcZone
+		r.DestZone = jea.lastObs.DestZone
+		for _, name := range resultFields {
+			if value, ok := jea.lastObs.ResultFields[name]; ok {
+				if r.ResultFields == nil {
+					r.ResultFields = map[string]string{}
+				}
+				r.ResultFields[name] = value
+			}
+		}
+	}
+	if h := jea.latency.histogram(end); h.Count() > 0 {
+		p := h.Percentiles()
+		r.Latency = &EdgeLatency{
+			Count:      h.Count(),
+			MeanMillis: millisOf(h.Mean()),
+			P50Millis:  millisOf(p.P50),
+			P95Millis:  millisOf(p.P95),
+			P99Millis:  millisOf(p.P99),
+		}
+	}
+	if inc != nil {
+		r.Incident = &EdgeIncident{
+			IncidentID:  inc.IncidentID,
+			Start:       inc.Start.AsTime().UTC(),
+			FailedCount: inc.FailedCount,
+			OkCount:     inc.OkCount,
+		}
+	}
+	return r
+}
+
+func millisOf(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// logFields returns the report as structured log fields.
+func (r *EdgeReport) logFields() logrus.Fields {
+	fields := logrus.Fields{
+		"jobID":       r.JobID,
+		"srcHost":     r.SrcHost,
+		"destHost":    r.DestHost,
+		"status":      r.Status,
+		"okCount":     r.OkCount,
+		"failedCount": r.FailedCount,
+	}
+	if r.Latency != nil {
+		fields["meanMillis"] = r.Latency.MeanMillis
+		fields["p50Millis"] = r.Latency.P50Millis
+		fields["p95Millis"] = r.Latency.P95Millis
+		fields["p99Millis"] = r.Latency.P99Millis
+	}
+	if r.Incident != nil {
+		fields["incidentID"] = r.Incident.IncidentID
+	}
+	return fields
+}
+
+// WriteEdgeReports writes the reports as JSON lines.
+func WriteEdgeReports(w io.Writer, reports []EdgeReport) error {
+	enc := json.NewEncoder(w)
+	for i := range reports {
+		if err := enc.Encode(&reports[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadEdgeReports reads reports written as JSON lines.
+func ReadEdgeReports(r io.Reader) ([]EdgeReport, error) {
+	var reports []EdgeReport
+	dec := json.NewDecoder(r)
+	for {
+		var report EdgeReport
+		if err := dec.Decode(&report); err != nil {
+			if errors.Is(err, io.EOF) {
+				return reports, nil
+			}
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("edge reports", func() {
+	var (
+		aggr   *obsAggr
+		logDir string
+		start  time.Time
+	)
+
+	add := func(destHost string, i int, ok bool, d time.Duration) {
+		aggr.Add(&nwpd.Observation{
+			JobID:        "job1",
+			SrcHost:      "node1",
+			DestHost:     destHost,
+			Timestamp:    timestamppb.New(start.Add(time.Duration(i) * 10 * time.Second)),
+			Period:       durationpb.New(10 * time.Second),
+			Ok:           ok,
+			Duration:     durationpb.New(d),
+			ResultFields: map[string]string{"httpStatus": "200"},
+		})
+	}
+
+	BeforeEach(func() {
+		logDir = GinkgoT().TempDir()
+		start = time.Now().Add(-5 * time.Minute)
+		listener, err := NewObsAggregator(&ObsAggregationOptions{
+			Log:                   logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:              "node1",
+			ReportPeriod:          1 * time.Hour,
+			TimeWindow:            30 * time.Minute,
+			LogDirectory:          logDir,
+			ReportResultFields:    []string{"httpStatus"},
+			ReportFormat:          config.ReportFormatJSON,
+			IncidentMinFailures:   2,
+			IncidentMinRecoveries: 2,
+		})
+		Expect(err).To(BeNil())
+		aggr = listener.(*obsAggr)
+	})
+
+	It("writes a JSON line per edge which can be read back", func() {
+		add("node2", 1, true, 10*time.Millisecond)
+		add("node2", 2, true, 30*time.Millisecond)
+		add("node3", 1, true, 5*time.Millisecond)
+		add("node3", 2, false, 0)
+		add("node3", 3, false, 0)
+		aggr.report()
+
+		Expect(path.Join(logDir, common.NameDaemonSetAgentPodNet+".log")).NotTo(BeAnExistingFile())
+		data, err := os.ReadFile(path.Join(logDir, common.NameDaemonSetAgentPodNet+".jsonl"))
+		Expect(err).To(BeNil())
+		Expect(strings.Count(string(data), "\n")).To(Equal(2))
+
+		reports, err := ReadEdgeReports(bytes.NewReader(data))
+		Expect(err).To(BeNil())
+		Expect(reports).To(HaveLen(2))
+
+		ok := reports[0]
+		Expect(ok.JobID).To(Equal("job1"))
+		Expect(ok.SrcHost).To(Equal("node1"))
+		Expect(ok.DestHost).To(Equal("node2"))
+		Expect(ok.Status).To(Equal(EdgeStatusOK))
+		Expect(ok.OkCount).To(Equal(2))
+		Expect(ok.FailedCount).To(Equal(0))
+		Expect(ok.Time.Sub(ok.PeriodStart)).To(Equal(1 * time.Hour))
+		Expect(*ok.LastObserved).To(BeTemporally("==", start.Add(20*time.Second)))
+		Expect(ok.Latency.Count).To(Equal(2))
+		Expect(ok.Latency.MeanMillis).To(BeNumerically("~", 20, 0.001))
+		Expect(ok.Latency.P99Millis).To(BeNumerically("~", 30, 0.001))
+		Expect(ok.Incident).To(BeNil())
+		Expect(ok.ResultFields).To(Equal(map[string]string{"httpStatus": "200"}))
+
+		failed := reports[1]
+		Expect(failed.DestHost).To(Equal("node3"))
+		Expect(failed.Status).To(Equal(EdgeStatusFailed))
+		Expect(failed.OkCount).To(Equal(1))
+		Expect(failed.FailedCount).To(Equal(2))
+		Expect(*failed.LastOk).To(BeTemporally("==", start.Add(10*time.Second)))
+		Expect(failed.Incident).NotTo(BeNil())
+		Expect(failed.Incident.IncidentID).NotTo(BeEmpty())
+		Expect(failed.Incident.Start).To(BeTemporally("==", start.Add(20*time.Second)))
+		Expect(failed.Incident.FailedCount).To(Equal(int32(2)))
+
+		// the counters are reset for the next report period
+		aggr.report()
+		data, err = os.ReadFile(path.Join(logDir, common.NameDaemonSetAgentPodNet+".jsonl"))
+		Expect(err).To(BeNil())
+		reports, err = ReadEdgeReports(bytes.NewReader(data))
+		Expect(err).To(BeNil())
+		Expect(reports).To(HaveLen(4))
+		Expect(reports[2].Status).To(Equal(EdgeStatusNoData))
+		Expect(reports[3].Incident).NotTo(BeNil())
+	})
+
+	It("round-trips the reports", func() {
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		lastOk := now.Add(-time.Minute)
+		reports := []EdgeReport{
+			{
+				Time:        now,
+				PeriodStart: now.Add(-time.Minute),
+				JobID:       "job1",
+				SrcHost:     "node1",
+				DestHost:    "node2",
+				Status:      EdgeStatusFailed,
+				OkCount:     3,
+				FailedCount: 1,
+				LastOk:      &lastOk,
+				Latency:     &EdgeLatency{Count: 3, MeanMillis: 1.5, P50Millis: 1.2, P95Millis: 2, P99Millis: 2.4},
+				Incident:    &EdgeIncident{IncidentID: "01H", Start: now.Add(-time.Hour), FailedCount: 7, OkCount: 1},
+			},
+			{Time: now, PeriodStart: now.Add(-time.Minute), JobID: "job2", SrcHost: "node1", DestHost: "node3", Status: EdgeStatusNoData},
+		}
+		buf := &bytes.Buffer{}
+		Expect(WriteEdgeReports(buf, reports)).To(Succeed())
+		read, err := ReadEdgeReports(buf)
+		Expect(err).To(BeNil())
+		Expect(read).To(Equal(reports))
+
+		_, err = ReadEdgeReports(strings.NewReader("{\"jobID\": 1}\n"))
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("keeps the text format by default", func() {
+		aggr.SetReportFormat("", false)
+		add("node3", 1, false, 0)
+		aggr.report()
+		Expect(path.Join(logDir, common.NameDaemonSetAgentPodNet+".log")).To(BeARegularFile())
+		Expect(path.Join(logDir, common.NameDaemonSetAgentPodNet+".jsonl")).NotTo(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// DefaultIncidentMinFailures is the default number of consecutive failures for opening an incident.
+	DefaultIncidentMinFailures = 3
+	// DefaultIncidentMinRecoveries is the default number of consecutive successful observations for closing an incident.
+	DefaultIncidentMinRecoveries = 2
+	// maxClosedIncidents is the number of closed incidents kept in the snapshot.
+	maxClosedIncidents = 1000
+)
+
+// IncidentObserver is notified about opened and closed incidents, e.g. for exporting metrics.
+// It is called with the lock of the aggregator held.
+type IncidentObserver interface {
+	// IncidentOpened is called for a new incident and for each open incident restored on start.
+	IncidentOpened(inc *nwpd.Incident)
+	// IncidentClosed is called if the incident is closed.
+	IncidentClosed(inc *nwpd.Incident)
+}
+
+// incidentTracker correlates the consecutive failures of an edge to a single incident.
+// It is protected by the lock of the aggregator.
+type incidentTracker struct {
+	log           logrus.FieldLogger
+	filename      string
+	observer      IncidentObserver
+	minFailures   int
+	minRecoveries int
+	open          map[jobEdge]*nwpd.Incident
+	closed        []*nwpd.Incident
+	dirty         bool
+}
+
+func newIncidentTracker(log logrus.FieldLogger, filename string, observer IncidentObserver, minFailures, minRecoveries int) *incidentTracker {
+	t := &incidentTracker{
+		log:      log,
+		filename: filename,
+		observer: observer,
+		open:     map[jobEdge]*nwpd.Incident{},
+	}
+	t.setThresholds(minFailures, minRecoveries)
+	if filename != "" {
+		if err := t.load(); err != nil {
+			t.log.Warnf("cannot restore incidents: %s", err)
+		}
+	}
+	if t.observer != nil {
+		for _, inc := range t.open {
+			t.observer.IncidentOpened(inc)
+		}
+	}
+	return t
+}
+
+// setThresholds sets the number of consecutive failures for opening and of consecutive successes for closing an incident.
+// A value of 0 selects the default.
+func (t *incidentTracker) setThresholds(minFailures, minRecoveries int) {
+	t.minFailures = DefaultIncidentMinFailures
+	if minFailures > 0 {
+		t.minFailures = minFailures
+	}
+	t.minRecoveries = DefaultIncidentMinRecoveries
+	if minRecoveries > 0 {
+		t.minRecoveries = minRecoveries
+	}
+}
+
+// observe updates the incident of the edge with the observation already added to the aggregation.
+// An incident is opened after minFailures consecutive failures and closed after minRecoveries
+// consecutive successful observations, so that a flapping edge keeps its incident.
+// The incident ID is attached to the failed observations of an open incident.
+func (t *incidentTracker) observe(je jobEdge, jea *jobEdgeAggregation, obs *nwpd.Observation) {
+	inc := t.open[je]
+	if !obs.Ok {
+		opened := false
+		if inc == nil {
+			if jea.failedStrike < t.minFailures {
+				return
+			}
+			inc = &nwpd.Incident{
+				IncidentID:         common.NewULID(jea.failedStrikeFirst),
+				JobID:              je.jobID,
+				SrcHost:            je.srcHost,
+				DestHost:           je.destHost,
+				Start:              timestamppb.New(jea.failedStrikeFirst),
+				FailedCount:        int32(jea.failedStrike - 1), // #nosec G115 -- limited by the number of observations
+				FirstFailureReason: jea.failedStrikeFirstResult,
+			}
+			t.open[je] = inc
+			opened = true
+			t.log.Warnf("incident %s started for %s after %d consecutive failures: %s", inc.IncidentID, je, jea.failedStrike, inc.FirstFailureReason)
+			if t.observer != nil {
+				t.observer.IncidentOpened(inc)
+			}
+		}
+		inc.FailedCount++
+		inc.LastFailure = obs.Timestamp
+		inc.LastFailureReason = obs.Result
+		obs.IncidentID = inc.IncidentID
+		jea.incidentID = inc.IncidentID
+		t.dirty = true
+		if opened {
+			// persisted immediately, so that the incident ID survives a restart
+			t.save()
+		}
+		return
+	}
+	if inc == nil {
+		return
+	}
+	inc.OkCount++
+	t.dirty = true
+	if jea.okStrike >= t.minRecoveries {
+		jea.incidentID = ""
+		t.close(je, inc, obs.Timestamp.AsTime())
+		t.save()
+	}
+}
+
+func (t *incidentTracker) close(je jobEdge, inc *nwpd.Incident, end time.Time) {
+	inc.End = timestamppb.New(end)
+	delete(t.open, je)
+	t.closed = append(t.closed, inc)
+	if n := len(t.closed) - maxClosedIncidents; n > 0 {
+		t.closed = t.closed[n:]
+	}
+	t.dirty = true
+	if t.observer != nil {
+		t.observer.IncidentClosed(inc)
+	}
+	t.log.Infof("incident %s closed for %s after %s: %d failed and %d ok observations, last failure: %s",
+		inc.IncidentID, je, end.Sub(inc.Start.AsTime()).Round(time.Second), inc.FailedCount, inc.OkCount, inc.LastFailureReason)
+}
+
+// closeOrphaned closes the open incidents of edges without aggregation and without failures since the outdated time.
+// This happens for edges which are not probed anymore, or for incidents restored after a restart.
+func (t *incidentTracker) closeOrphaned(aggregations map[jobEdge]*jobEdgeAggregation, outdated time.Time) {
+	for je, inc := range t.open {
+		if _, ok := aggregations[je]; ok || !inc.LastFailure.AsTime().Before(outdated) {
+			continue
+		}
+		t.close(je, inc, inc.LastFailure.AsTime())
+	}
+}
+
+// openSummaries returns a line for each open incident with its start time and duration.
+func (t *incidentTracker) openSummaries(now time.Time) []string {
+	var lines []string
+	for je, inc := range t.open {
+		start := inc.Start.AsTime()
+		lines = append(lines, fmt.Sprintf("%s: incident %s open since %s (%s), %d failed and %d ok observations",
+			je, inc.IncidentID, common.FormatAsUTC(start), now.Sub(start).Round(time.Second), inc.FailedCount, inc.OkCount))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// list returns copies of the open and the closed incidents sorted by start time.
+func (t *incidentTracker) list() []*nwpd.Incident {
+	result := make([]*nwpd.Incident, 0, len(t.open)+len(t.closed))
+	for _, inc := range t.open {
+		result = append(result, proto.Clone(inc).(*nwpd.Incident))
+	}
+	for _, inc := range t.closed {
+		result = append(result, proto.Clone(inc).(*nwpd.Incident))
+	}
+	db.SortIncidents(result)
+	return result
+}
+
+// setFilename changes the file the incidents are persisted to. The incidents are saved to the new file with the next save.
+func (t *incidentTracker) setFilename(filename string) {
+	if filename == t.filename {
+		return
+	}
+	t.filename = filename
+	t.dirty = true
+}
+
+// save persists the incidents if they have been changed.
+func (t *incidentTracker) save() {
+	if t.filename == "" || !t.dirty {
+		return
+	}
+	snapshot := &nwpd.IncidentSnapshot{Closed: t.closed}
+	for _, inc := range t.open {
+		snapshot.Open = append(snapshot.Open, inc)
+	}
+	db.SortIncidents(snapshot.Open)
+	if err := db.WriteIncidentSnapshot(t.filename, snapshot); err != nil {
+		t.log.Warnf("cannot persist incidents: %s", err)
+		return
+	}
+	t.dirty = false
+}
+
+func (t *incidentTracker) load() error {
+	snapshot, err := db.ReadIncidentSnapshot(t.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, inc := range snapshot.Open {
+		t.open[jobEdge{jobID: inc.JobID, srcHost: inc.SrcHost, destHost: inc.DestHost}] = inc
+	}
+	t.closed = snapshot.Closed
+	if len(t.open) > 0 {
+		t.log.Infof("restored %d open incidents", len(t.open))
+	}
+	return nil
+}
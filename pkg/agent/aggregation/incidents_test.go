@@ -0,0 +1,226 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"path"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// recordingIncidentObserver records the IDs of the opened and closed incidents.
+type recordingIncidentObserver struct {
+	opened []string
+	closed []string
+}
+
+func (o *recordingIncidentObserver) IncidentOpened(inc *nwpd.Incident) {
+	o.opened = append(o.opened, inc.IncidentID)
+}
+
+func (o *recordingIncidentObserver) IncidentClosed(inc *nwpd.Incident) {
+	o.closed = append(o.closed, inc.IncidentID)
+}
+
+var _ = Describe("incidents", func() {
+	var (
+		filename string
+		start    time.Time
+		count    int
+		observer *recordingIncidentObserver
+	)
+
+	newAggregatorWithThresholds := func(minFailures, minRecoveries int) *obsAggr {
+		listener, err := NewObsAggregator(&ObsAggregationOptions{
+			Log:                   logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:              "node1",
+			ReportPeriod:          1 * time.Hour,
+			TimeWindow:            30 * time.Minute,
+			IncidentFile:          filename,
+			IncidentMinFailures:   minFailures,
+			IncidentMinRecoveries: minRecoveries,
+			IncidentObserver:      observer,
+		})
+		Expect(err).To(BeNil())
+		return listener.(*obsAggr)
+	}
+	newAggregator := func() *obsAggr {
+		return newAggregatorWithThresholds(2, 2)
+	}
+
+	add := func(aggr *obsAggr, ok bool, result string) *nwpd.Observation {
+		count++
+		obs := &nwpd.Observation{
+			JobID:     "job1",
+			SrcHost:   "node1",
+			DestHost:  "node2",
+			Timestamp: timestamppb.New(start.Add(time.Duration(count) * 10 * time.Second)),
+			Period:    durationpb.New(10 * time.Second),
+			Ok:        ok,
+			Result:    result,
+		}
+		aggr.Add(obs)
+		return obs
+	}
+
+	BeforeEach(func() {
+		filename = path.Join(GinkgoT().TempDir(), "agent"+db.IncidentFileSuffix)
+		start = time.Now().Add(-10 * time.Minute)
+		count = 0
+		observer = &recordingIncidentObserver{}
+	})
+
+	It("generates sortable ULIDs", func() {
+		now := time.Now()
+		id1 := common.NewULID(now)
+		id2 := common.NewULID(now.Add(time.Millisecond))
+		Expect(id1).To(MatchRegexp("^[0-9A-HJKMNP-TV-Z]{26}$"))
+		Expect(id1 < id2).To(BeTrue())
+		Expect(common.NewULID(now)).NotTo(Equal(id1))
+	})
+
+	It("correlates consecutive failures until the recovery", func() {
+		aggr := newAggregator()
+		add(aggr, true, "ok")
+		first := add(aggr, false, "timeout")
+		Expect(first.IncidentID).To(BeEmpty())
+		Expect(aggr.ListIncidents()).To(BeEmpty())
+
+		second := add(aggr, false, "timeout")
+		Expect(second.IncidentID).NotTo(BeEmpty())
+		// a single successful observation does not close the incident
+		Expect(add(aggr, true, "ok").IncidentID).To(BeEmpty())
+		Expect(add(aggr, false, "connection refused").IncidentID).To(Equal(second.IncidentID))
+		Expect(aggr.calcReport(&reportOptions{}, false).issues[0]).To(ContainSubstring("incident: " + second.IncidentID))
+
+		add(aggr, true, "ok")
+		add(aggr, true, "ok")
+		incidents := aggr.ListIncidents()
+		Expect(incidents).To(HaveLen(1))
+		inc := incidents[0]
+		Expect(inc.IncidentID).To(Equal(second.IncidentID))
+		Expect(inc.Start.AsTime()).To(Equal(first.Timestamp.AsTime()))
+		Expect(inc.End).NotTo(BeNil())
+		Expect(inc.FailedCount).To(Equal(int32(3)))
+		Expect(inc.OkCount).To(Equal(int32(3)))
+		Expect(inc.FirstFailureReason).To(Equal("timeout"))
+		Expect(inc.LastFailureReason).To(Equal("connection refused"))
+
+		// a new outage gets a new incident
+		add(aggr, false, "timeout")
+		obs := add(aggr, false, "timeout")
+		Expect(obs.IncidentID).NotTo(BeEmpty())
+		Expect(obs.IncidentID).NotTo(Equal(inc.IncidentID))
+	})
+
+	It("keeps the incident ID across restarts", func() {
+		aggr := newAggregator()
+		add(aggr, false, "timeout")
+		obs := add(aggr, false, "timeout")
+		Expect(obs.IncidentID).NotTo(BeEmpty())
+
+		restarted := newAggregator()
+		Expect(add(restarted, false, "timeout").IncidentID).To(Equal(obs.IncidentID))
+		add(restarted, true, "ok")
+		add(restarted, true, "ok")
+		restarted.report()
+
+		snapshot, err := db.ReadIncidentSnapshot(filename)
+		Expect(err).To(BeNil())
+		Expect(snapshot.Open).To(BeEmpty())
+		Expect(snapshot.Closed).To(HaveLen(1))
+		Expect(snapshot.Closed[0].IncidentID).To(Equal(obs.IncidentID))
+		Expect(snapshot.Closed[0].FailedCount).To(Equal(int32(3)))
+	})
+
+	It("moves the incidents to a changed file", func() {
+		aggr := newAggregator()
+		add(aggr, false, "timeout")
+		obs := add(aggr, false, "timeout")
+
+		moved := path.Join(GinkgoT().TempDir(), "other"+db.IncidentFileSuffix)
+		aggr.SetIncidentFile(moved)
+		aggr.report()
+		snapshot, err := db.ReadIncidentSnapshot(moved)
+		Expect(err).To(BeNil())
+		Expect(snapshot.Open).To(HaveLen(1))
+		Expect(snapshot.Open[0].IncidentID).To(Equal(obs.IncidentID))
+	})
+
+	It("closes incidents of edges not observed anymore", func() {
+		aggr := newAggregator()
+		start = time.Now().Add(-1 * time.Hour)
+		add(aggr, false, "timeout")
+		add(aggr, false, "timeout")
+		Expect(aggr.ListIncidents()[0].End).To(BeNil())
+
+		aggr.calcReport(&reportOptions{}, false)
+		incidents := aggr.ListIncidents()
+		Expect(incidents).To(HaveLen(1))
+		Expect(incidents[0].End.AsTime()).To(Equal(incidents[0].LastFailure.AsTime()))
+	})
+
+	It("starts an incident after the default number of consecutive failures", func() {
+		aggr := newAggregatorWithThresholds(0, 0)
+		Expect(add(aggr, false, "timeout").IncidentID).To(BeEmpty())
+		Expect(add(aggr, false, "timeout").IncidentID).To(BeEmpty())
+		obs := add(aggr, false, "timeout")
+		Expect(obs.IncidentID).NotTo(BeEmpty())
+		Expect(observer.opened).To(Equal([]string{obs.IncidentID}))
+		Expect(aggr.ListIncidents()[0].FailedCount).To(Equal(int32(DefaultIncidentMinFailures)))
+	})
+
+	It("requires the configured number of successes to close an incident", func() {
+		aggr := newAggregatorWithThresholds(1, 3)
+		obs := add(aggr, false, "timeout")
+		Expect(obs.IncidentID).NotTo(BeEmpty())
+		add(aggr, true, "ok")
+		add(aggr, true, "ok")
+		Expect(observer.closed).To(BeEmpty())
+		add(aggr, true, "ok")
+		Expect(observer.closed).To(Equal([]string{obs.IncidentID}))
+
+		// changed at runtime
+		aggr.SetIncidentThresholds(2, 1)
+		Expect(add(aggr, false, "timeout").IncidentID).To(BeEmpty())
+		obs = add(aggr, false, "timeout")
+		Expect(obs.IncidentID).NotTo(BeEmpty())
+		add(aggr, true, "ok")
+		Expect(observer.closed).To(HaveLen(2))
+	})
+
+	It("reports open incidents with start time and duration", func() {
+		aggr := newAggregator()
+		add(aggr, false, "timeout")
+		obs := add(aggr, false, "timeout")
+		issues := aggr.calcReport(&reportOptions{}, true).issues
+		Expect(issues).To(ContainElement(MatchRegexp(`^node1->node2\[job1\]: incident %s open since %s \(\d+m\d+s\), 2 failed and 0 ok observations$`,
+			obs.IncidentID, common.FormatAsUTC(start.Add(10*time.Second)))))
+
+		// the incident survives the rollover of the report period without new observations
+		issues = aggr.calcReport(&reportOptions{}, true).issues
+		Expect(issues).To(ContainElement(ContainSubstring("incident " + obs.IncidentID + " open since")))
+	})
+
+	It("notifies the observer about restored open incidents", func() {
+		aggr := newAggregator()
+		add(aggr, false, "timeout")
+		obs := add(aggr, false, "timeout")
+		aggr.report()
+
+		observer = &recordingIncidentObserver{}
+		newAggregator()
+		Expect(observer.opened).To(Equal([]string{obs.IncidentID}))
+	})
+})
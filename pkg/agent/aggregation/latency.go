@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+)
+
+// latencySlots is the number of histograms per window, which are rotated to expire the old durations.
+const latencySlots = 4
+
+// latencyWindow keeps the durations of a sliding time window in rotating histograms.
+// The memory is bounded by latencySlots histograms (about 1.4 KiB per job edge).
+type latencyWindow struct {
+	slotPeriod time.Duration
+	slotStarts [latencySlots]time.Time
+	slots      [latencySlots]db.LatencyHistogram
+}
+
+// add counts the duration of an observation with the given timestamp.
+// If the time window has changed, the old durations are moved to the slots of the new time window.
+func (w *latencyWindow) add(timestamp time.Time, d time.Duration, timeWindow time.Duration) {
+	w.resize(timeWindow, timestamp)
+	if slot := w.slotOf(timestamp); slot != nil {
+		slot.Add(d)
+	}
+}
+
+// resize adapts the slots to a changed time window. Each old slot is merged into the new slot containing its end
+// (at most now), so that the recent durations are kept. With a shrunk time window, the durations of a merged slot
+// may be up to one old slot period older than the new time window.
+func (w *latencyWindow) resize(timeWindow time.Duration, now time.Time) {
+	slotPeriod := max(timeWindow/latencySlots, time.Second)
+	if slotPeriod == w.slotPeriod {
+		return
+	}
+	old := *w
+	*w = latencyWindow{slotPeriod: slotPeriod}
+	for i := range old.slots {
+		if old.slotStarts[i].IsZero() {
+			continue
+		}
+		end := old.slotStarts[i].Add(old.slotPeriod - 1)
+		if end.After(now) {
+			end = now
+		}
+		if slot := w.slotOf(end); slot != nil {
+			slot.Merge(&old.slots[i])
+		}
+	}
+}
+
+// slotOf returns the histogram of the slot containing the timestamp, nil if the timestamp is older than the slot.
+// A slot of an earlier period is reset.
+func (w *latencyWindow) slotOf(timestamp time.Time) *db.LatencyHistogram {
+	start := timestamp.Truncate(w.slotPeriod)
+	i := int((start.UnixNano() / int64(w.slotPeriod)) % latencySlots)
+	if !w.slotStarts[i].Equal(start) {
+		if w.slotStarts[i].After(start) {
+			// too old
+			return nil
+		}
+		w.slotStarts[i] = start
+		w.slots[i] = db.LatencyHistogram{}
+	}
+	return &w.slots[i]
+}
+
+// histogram returns the merged histogram of the durations within the time window before now.
+// As whole slots are merged, the effective window may be up to one slot period longer.
+func (w *latencyWindow) histogram(now time.Time) *db.LatencyHistogram {
+	h := &db.LatencyHistogram{}
+	outdated := now.Add(-time.Duration(latencySlots) * w.slotPeriod)
+	for i := range w.slots {
+		if w.slotStarts[i].After(outdated) {
+			h.Merge(&w.slots[i])
+		}
+	}
+	return h
+}
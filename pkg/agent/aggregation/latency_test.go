@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("latency percentiles", func() {
+	It("expires the durations outside the time window", func() {
+		w := &latencyWindow{}
+		now := time.Now()
+		w.add(now.Add(-50*time.Minute), 900*time.Millisecond, 20*time.Minute)
+		w.add(now.Add(-1*time.Minute), 1*time.Millisecond, 20*time.Minute)
+		h := w.histogram(now)
+		Expect(h.Count()).To(Equal(1))
+		Expect(h.Quantile(0.99)).To(Equal(1 * time.Millisecond))
+
+		// a shrunk time window keeps the durations within it
+		w.add(now, 2*time.Millisecond, 10*time.Minute)
+		Expect(w.histogram(now).Count()).To(Equal(2))
+	})
+
+	It("keeps the durations on a changed time window", func() {
+		w := &latencyWindow{}
+		// aligned to the slot periods
+		now := time.Unix(0, 0).Add(1000 * time.Hour)
+		for _, age := range []time.Duration{20 * time.Minute, 12 * time.Minute, 7 * time.Minute, 1 * time.Minute} {
+			w.add(now.Add(-age), age, 30*time.Minute)
+		}
+		Expect(w.histogram(now).Count()).To(Equal(4))
+
+		w.resize(60*time.Minute, now)
+		Expect(w.slotPeriod).To(Equal(15 * time.Minute))
+		Expect(w.histogram(now).Count()).To(Equal(4))
+
+		// the durations of a merged slot are kept if the slot ends within the new time window
+		w.resize(8*time.Minute, now)
+		h := w.histogram(now)
+		Expect(h.Count()).To(Equal(3))
+		expected := &db.LatencyHistogram{}
+		expected.Add(1 * time.Minute)
+		expected.Add(12 * time.Minute)
+		Expect(h.Quantile(0.01)).To(Equal(expected.Quantile(0.01)))
+
+		w.add(now, 2*time.Millisecond, 8*time.Minute)
+		Expect(w.histogram(now).Count()).To(Equal(4))
+	})
+
+	It("reports the percentiles of the edge", func() {
+		listener, err := NewObsAggregator(&ObsAggregationOptions{
+			Log:          logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:     "node1",
+			ReportPeriod: 1 * time.Hour,
+			TimeWindow:   30 * time.Minute,
+		})
+		Expect(err).To(BeNil())
+		aggr := listener.(*obsAggr)
+		for i := 0; i < 100; i++ {
+			d := 1 * time.Millisecond
+			if i%50 == 0 {
+				d = 800 * time.Millisecond
+			}
+			aggr.Add(&nwpd.Observation{
+				JobID:     "job1",
+				SrcHost:   "node1",
+				DestHost:  "node2",
+				Timestamp: timestamppb.New(time.Now().Add(-time.Duration(100-i) * time.Second)),
+				Period:    durationpb.New(10 * time.Second),
+				Duration:  durationpb.New(d),
+				Ok:        true,
+			})
+		}
+		report := aggr.calcReport(&reportOptions{fullReport: true}, false)
+		Expect(report.noissues).To(HaveLen(1))
+		Expect(report.noissues[0]).To(HaveSuffix("OK (1 ms) p50=1.1ms p95=1.1ms p99=800.0ms"))
+
+		// a reconfigured time window keeps the percentiles
+		aggr.Reconfigure(1*time.Hour, 60*time.Minute)
+		report = aggr.calcReport(&reportOptions{fullReport: true}, false)
+		Expect(report.noissues[0]).To(HaveSuffix("p50=1.1ms p95=1.1ms p99=800.0ms"))
+	})
+})
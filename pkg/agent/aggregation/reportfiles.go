@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+)
+
+const (
+	// DefaultReportRetention is the default time the rotated report files are kept.
+	DefaultReportRetention = 48 * time.Hour
+	// DefaultReportMaxBytes is the default maximum total size of the current and the rotated report files.
+	DefaultReportMaxBytes = 20 * 1000 * 1000
+	// rotatedTimeFormat is the format of the rotation time in the names of the rotated report files.
+	rotatedTimeFormat = "20060102-150405.000"
+)
+
+// reportRotation defines the rotation of the report files in the log directory.
+type reportRotation struct {
+	retention time.Duration
+	maxBytes  int64
+}
+
+func newReportRotation(retention time.Duration, maxBytes int64) reportRotation {
+	if retention <= 0 {
+		retention = DefaultReportRetention
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultReportMaxBytes
+	}
+	return reportRotation{retention: retention, maxBytes: maxBytes}
+}
+
+// rotateSize returns the size of the current report file which triggers its rotation.
+// It is at most half of the maximum total size, so that the current file and a rotated one fit.
+func (r reportRotation) rotateSize() int64 {
+	size := int64(common.MaxLogfileSize)
+	if half := r.maxBytes / 2; half < size {
+		size = half
+	}
+	return size
+}
+
+// rotateReportFile renames the report file and compresses the renamed file.
+// As the file is renamed and not truncated, readers see either the complete old file or the new one.
+func rotateReportFile(filename string, now time.Time) (string, error) {
+	rotated := filename + "." + now.UTC().Format(rotatedTimeFormat)
+	if err := os.Rename(filename, rotated); err != nil {
+		return "", err
+	}
+	compressed := rotated + ".gz"
+	if err := gzipFile(rotated, compressed); err != nil {
+		// keep the uncompressed file, it is removed by the cleanup
+		return rotated, fmt.Errorf("cannot compress %s: %w", rotated, err)
+	}
+	if err := os.Remove(rotated); err != nil {
+		return compressed, err
+	}
+	return compressed, nil
+}
+
+// gzipFile compresses the source file to the target file, which only appears if complete.
+func gzipFile(source, target string) error {
+	in, err := os.Open(source) //  #nosec G304 -- report file in the log directory
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := target + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //  #nosec G302 G304 -- no sensitive data
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+type reportFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// cleanupReportFiles deletes the rotated report files of the given current file names in the directory,
+// which are older than the retention or exceed the maximum total size, oldest first.
+// The current files are never deleted, but their size is included in the total size.
+// It returns the names of the deleted files.
+func cleanupReportFiles(directory string, currentNames []string, r reportRotation, now time.Time) ([]string, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		total   int64
+		rotated []reportFile
+	)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		for _, current := range currentNames {
+			if entry.Name() != current && !strings.HasPrefix(entry.Name(), current+".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				// removed in the meantime
+				break
+			}
+			if entry.Name() == current {
+				total += info.Size()
+			} else {
+				rotated = append(rotated, reportFile{name: entry.Name(), size: info.Size(), modTime: info.ModTime()})
+			}
+			break
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool {
+		if !rotated[i].modTime.Equal(rotated[j].modTime) {
+			return rotated[i].modTime.Before(rotated[j].modTime)
+		}
+		return rotated[i].name < rotated[j].name
+	})
+	for _, f := range rotated {
+		total += f.size
+	}
+
+	var deleted []string
+	outdated := now.Add(-r.retention)
+	for _, f := range rotated {
+		if !f.modTime.Before(outdated) && total <= r.maxBytes {
+			break
+		}
+		if err := os.Remove(path.Join(directory, f.name)); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		total -= f.size
+		deleted = append(deleted, f.name)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("report files", func() {
+	var (
+		dir     string
+		now     time.Time
+		current = common.NameDaemonSetAgentPodNet + ".log"
+	)
+
+	writeFile := func(name string, size int, age time.Duration) {
+		filename := path.Join(dir, name)
+		Expect(os.WriteFile(filename, []byte(strings.Repeat("x", size)), 0o600)).To(Succeed())
+		Expect(os.Chtimes(filename, now.Add(-age), now.Add(-age))).To(Succeed())
+	}
+	fileNames := func() []string {
+		entries, err := os.ReadDir(dir)
+		Expect(err).To(BeNil())
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		now = time.Now()
+	})
+
+	It("rotates the report file by renaming and compresses it", func() {
+		content := strings.Repeat("2022-01-23T23:49:11Z report line\n", 100)
+		filename := path.Join(dir, current)
+		Expect(os.WriteFile(filename, []byte(content), 0o600)).To(Succeed())
+		// a reader of the current file keeps the complete content
+		reader, err := os.Open(filename)
+		Expect(err).To(BeNil())
+		defer reader.Close()
+
+		rotated, err := rotateReportFile(filename, time.Date(2022, 1, 23, 23, 49, 11, 0, time.UTC))
+		Expect(err).To(BeNil())
+		Expect(rotated).To(Equal(filename + ".20220123-234911.000.gz"))
+		Expect(fileNames()).To(Equal([]string{current + ".20220123-234911.000.gz"}))
+
+		data, err := io.ReadAll(reader)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(Equal(content))
+
+		f, err := os.Open(rotated)
+		Expect(err).To(BeNil())
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		Expect(err).To(BeNil())
+		data, err = io.ReadAll(zr)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("deletes the oldest rotated files exceeding the maximum size first", func() {
+		writeFile(current, 300, 0)
+		writeFile(current+".4.gz", 400, 10*time.Minute)
+		writeFile(current+".1.gz", 400, 40*time.Minute)
+		writeFile(current+".3.gz", 400, 20*time.Minute)
+		writeFile(current+".old", 400, 30*time.Minute)
+		writeFile("other.log.0", 5000, 50*time.Minute)
+
+		deleted, err := cleanupReportFiles(dir, []string{current}, reportRotation{retention: time.Hour, maxBytes: 1000}, now)
+		Expect(err).To(BeNil())
+		Expect(deleted).To(Equal([]string{current + ".1.gz", current + ".old", current + ".3.gz"}))
+		Expect(fileNames()).To(ConsistOf(current, current+".4.gz", "other.log.0"))
+
+		deleted, err = cleanupReportFiles(dir, []string{current}, reportRotation{retention: time.Hour, maxBytes: 1000}, now)
+		Expect(err).To(BeNil())
+		Expect(deleted).To(BeEmpty())
+	})
+
+	It("deletes the rotated files outside the retention", func() {
+		jsonl := common.NameDaemonSetAgentPodNet + ".jsonl"
+		writeFile(current, 300, 0)
+		writeFile(current+".2.gz", 100, 3*time.Hour)
+		writeFile(current+".3.gz", 100, 1*time.Hour)
+		writeFile(jsonl+".1.gz", 100, 4*time.Hour)
+
+		deleted, err := cleanupReportFiles(dir, []string{current, jsonl}, reportRotation{retention: 2 * time.Hour, maxBytes: 1000}, now)
+		Expect(err).To(BeNil())
+		Expect(deleted).To(Equal([]string{jsonl + ".1.gz", current + ".2.gz"}))
+		Expect(fileNames()).To(ConsistOf(current, current+".3.gz"))
+	})
+
+	It("rotates oversized report files and cleans up on the report tick", func() {
+		listener, err := NewObsAggregator(&ObsAggregationOptions{
+			Log:            logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:       "node1",
+			ReportPeriod:   1 * time.Hour,
+			TimeWindow:     30 * time.Minute,
+			LogDirectory:   dir,
+			ReportMaxBytes: 2000,
+		})
+		Expect(err).To(BeNil())
+		aggr := listener.(*obsAggr)
+		Expect(aggr.rotation.rotateSize()).To(Equal(int64(1000)))
+		writeFile(current+".20220123-000000.000.gz", 1900, 2*time.Hour)
+		writeFile(current, 1200, 0)
+
+		aggr.report()
+		names := fileNames()
+		Expect(names).To(HaveLen(2))
+		Expect(names).To(ContainElement(current))
+		Expect(names).NotTo(ContainElement(current + ".20220123-000000.000.gz"))
+		info, err := os.Stat(path.Join(dir, current))
+		Expect(err).To(BeNil())
+		Expect(info.Size()).To(BeNumerically("<", 1000))
+
+		aggr.SetReportRotation(time.Minute, 0)
+		Expect(aggr.rotation).To(Equal(reportRotation{retention: time.Minute, maxBytes: DefaultReportMaxBytes}))
+	})
+
+	It("writes the report to the writer or the log directory set at runtime", func() {
+		out := &bytes.Buffer{}
+		listener, err := NewObsAggregator(&ObsAggregationOptions{
+			Log:          logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:     "node1",
+			ReportPeriod: 1 * time.Hour,
+			TimeWindow:   30 * time.Minute,
+			ReportWriter: out,
+		})
+		Expect(err).To(BeNil())
+		aggr := listener.(*obsAggr)
+		aggr.Add(&nwpd.Observation{JobID: "job1", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.Now(),
+			Period: durationpb.New(10 * time.Second), Ok: true})
+		aggr.report()
+		Expect(out.String()).To(MatchRegexp(`^\S+Z Jobs: `))
+		Expect(fileNames()).To(BeEmpty())
+
+		out.Reset()
+		aggr.SetReportFormat(config.ReportFormatJSON, false)
+		aggr.Add(&nwpd.Observation{JobID: "job1", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.Now(),
+			Period: durationpb.New(10 * time.Second), Ok: true})
+		aggr.report()
+		reports, err := ReadEdgeReports(out)
+		Expect(err).To(BeNil())
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].DestHost).To(Equal("node2"))
+
+		// no output besides the log
+		aggr.SetReportOutput("", nil)
+		aggr.report()
+		Expect(out.Len()).To(BeZero())
+		Expect(fileNames()).To(BeEmpty())
+
+		logDir := path.Join(dir, "log")
+		aggr.SetReportOutput(logDir, nil)
+		aggr.report()
+		Expect(path.Join(logDir, common.NameDaemonSetAgentPodNet+".jsonl")).To(BeAnExistingFile())
+		Expect(out.Len()).To(BeZero())
+	})
+})
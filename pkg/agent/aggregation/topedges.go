@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTopFailingEdges is the default number of edges listed in the top failing edges summary.
+	DefaultTopFailingEdges = 10
+	// DefaultTopFailingEdgesMinChecks is the default minimum number of checks of an edge to be included in the summary.
+	DefaultTopFailingEdgesMinChecks = 5
+)
+
+// FailingEdge summarises the failed checks of all jobs between two hosts in a report period.
+type FailingEdge struct {
+	SrcHost  string
+	DestHost string
+	// Checks is the number of checks of all jobs of the edge.
+	Checks int
+	// Failures is the number of failed checks.
+	Failures int
+	// JobIDs are the sorted IDs of the jobs with failed checks.
+	JobIDs []string
+	// Ongoing is true if the last observation of any job of the edge has failed.
+	Ongoing bool
+}
+
+// FailureRatio returns the share of failed checks in the range [0.0,1.0].
+func (e FailingEdge) FailureRatio() float64 {
+	if e.Checks == 0 {
+		return 0
+	}
+	return float64(e.Failures) / float64(e.Checks)
+}
+
+func (e FailingEdge) String() string {
+	state := "recovered"
+	if e.Ongoing {
+		state = "ongoing"
+	}
+	return fmt.Sprintf("%s->%s %d/%d failed (%.1f%%) jobs: %s %s", e.SrcHost, e.DestHost, e.Failures, e.Checks,
+		100*e.FailureRatio(), strings.Join(e.JobIDs, ","), state)
+}
+
+// FailingEdgesSummary lists the edges with the highest failure ratio of a report period.
+type FailingEdgesSummary struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	// MinChecks is the minimum number of checks of an edge to be included.
+	MinChecks int
+	// Edges are sorted by failure ratio descending.
+	Edges []FailingEdge
+}
+
+// lines returns the summary as report lines, starting with a header line.
+func (s *FailingEdgesSummary) lines() []string {
+	if len(s.Edges) == 0 {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("Top %d failing edges (min %d checks):", len(s.Edges), s.MinChecks)}
+	for i, e := range s.Edges {
+		lines = append(lines, fmt.Sprintf("  %d. %s", i+1, e))
+	}
+	return lines
+}
+
+// edgeFailureCounter counts the checks of all jobs per pair of hosts.
+type edgeFailureCounter map[hostEdge]*FailingEdge
+
+// add counts the checks of the report period of the job edge.
+func (c edgeFailureCounter) add(je jobEdge, aggr *jobEdgeAggregation) {
+	checks := aggr.reportOkCount + aggr.reportFailureCount
+	if checks == 0 {
+		return
+	}
+	he := hostEdge{srcHost: je.srcHost, destHost: je.destHost}
+	e := c[he]
+	if e == nil {
+		e = &FailingEdge{SrcHost: je.srcHost, DestHost: je.destHost}
+		c[he] = e
+	}
+	e.Checks += checks
+	if aggr.reportFailureCount > 0 {
+		e.Failures += aggr.reportFailureCount
+		e.JobIDs = append(e.JobIDs, je.jobID)
+	}
+	if aggr.lastObs != nil && !aggr.lastObs.Ok {
+		e.Ongoing = true
+	}
+}
+
+// top returns up to k edges with failures and at least minChecks checks sorted by failure ratio descending.
+func (c edgeFailureCounter) top(k, minChecks int) []FailingEdge {
+	var edges []FailingEdge
+	for _, e := range c {
+		if e.Failures == 0 || e.Checks < minChecks {
+			continue
+		}
+		item := *e
+		item.JobIDs = append([]string(nil), e.JobIDs...)
+		sort.Strings(item.JobIDs)
+		edges = append(edges, item)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if ra, rb := a.FailureRatio(), b.FailureRatio(); ra != rb {
+			return ra > rb
+		}
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		if a.SrcHost != b.SrcHost {
+			return a.SrcHost < b.SrcHost
+		}
+		return a.DestHost < b.DestHost
+	})
+	if len(edges) > k {
+		edges = edges[:k]
+	}
+	return edges
+}
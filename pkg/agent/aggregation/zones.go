@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ZoneEdgeReport summarises the checks between the nodes of two zones in a report period.
+type ZoneEdgeReport struct {
+	SrcZone  string `json:"srcZone"`
+	DestZone string `json:"destZone"`
+	// Checks is the number of checks of all job edges between the zones.
+	Checks int `json:"checks"`
+	// Failures is the number of failed checks.
+	Failures int `json:"failures"`
+}
+
+// FailureRatio returns the share of failed checks in the range [0.0,1.0].
+func (r ZoneEdgeReport) FailureRatio() float64 {
+	if r.Checks == 0 {
+		return 0
+	}
+	return float64(r.Failures) / float64(r.Checks)
+}
+
+func (r ZoneEdgeReport) String() string {
+	return fmt.Sprintf("%s->%s %d/%d failed (%.1f%%)", r.SrcZone, r.DestZone, r.Failures, r.Checks, 100*r.FailureRatio())
+}
+
+// ZoneObserver is notified about the zone edges of each periodic report.
+type ZoneObserver interface {
+	// ZoneEdgesReported is called with the reports of all zone edges with checks in the report period.
+	ZoneEdgesReported(reports []ZoneEdgeReport)
+}
+
+type zoneEdge struct {
+	srcZone  string
+	destZone string
+}
+
+// zoneCounter counts the checks per pair of zones.
+type zoneCounter map[zoneEdge]*ZoneEdgeReport
+
+// add counts the checks of the report period of the job edge if the destination is a node.
+func (c zoneCounter) add(aggr *jobEdgeAggregation) {
+	if aggr.lastObs == nil || aggr.lastObs.DestZone == "" {
+		return
+	}
+	checks := aggr.reportOkCount + aggr.reportFailureCount
+	if checks == 0 {
+		return
+	}
+	ze := zoneEdge{srcZone: aggr.lastObs.SrcZone, destZone: aggr.lastObs.DestZone}
+	r := c[ze]
+	if r == nil {
+		r = &ZoneEdgeReport{SrcZone: ze.srcZone, DestZone: ze.destZone}
+		c[ze] = r
+	}
+	r.Checks += checks
+	r.Failures += aggr.reportFailureCount
+}
+
+// reports returns the zone edge reports sorted by source and destination zone.
+func (c zoneCounter) reports() []ZoneEdgeReport {
+	reports := make([]ZoneEdgeReport, 0, len(c))
+	for _, r := range c {
+		reports = append(reports, *r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].SrcZone != reports[j].SrcZone {
+			return reports[i].SrcZone < reports[j].SrcZone
+		}
+		return reports[i].DestZone < reports[j].DestZone
+	})
+	return reports
+}
+
+func (c zoneCounter) summary() string {
+	reports := c.reports()
+	items := make([]string, len(reports))
+	for i, r := range reports {
+		items[i] = r.String()
+	}
+	return strings.Join(items, ", ")
+}
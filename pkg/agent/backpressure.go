@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+)
+
+// backpressureWarningPeriod is the minimum period between two warnings about a full observation buffer.
+const backpressureWarningPeriod = 1 * time.Minute
+
+// backpressureReporter counts and logs the observations of job runs hitting a full observation buffer.
+type backpressureReporter struct {
+	log        logrus.FieldLogger
+	bufferSize int
+
+	lock        sync.Mutex
+	lastWarning time.Time
+	full        int
+	dropped     int
+}
+
+func newBackpressureReporter(log logrus.FieldLogger, bufferSize int) *backpressureReporter {
+	return &backpressureReporter{log: log, bufferSize: bufferSize}
+}
+
+// backpressure returns the handling of a full observation buffer for the given wait timeout.
+func (r *backpressureReporter) backpressure(timeout time.Duration) *runners.Backpressure {
+	return &runners.Backpressure{
+		Timeout: timeout,
+		OnFull: func(_ *nwpd.Observation) {
+			ObservationBufferFull.Inc()
+			r.report(false)
+		},
+		OnDrop: func(obs *nwpd.Observation, reason string) {
+			DroppedObservations.WithLabelValues(obs.JobID, reason).Inc()
+			r.report(true)
+		},
+	}
+}
+
+// report logs a warning at most once per backpressureWarningPeriod with the counts since the last warning.
+func (r *backpressureReporter) report(dropped bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if dropped {
+		r.dropped++
+	} else {
+		r.full++
+	}
+	now := time.Now()
+	if now.Sub(r.lastWarning) < backpressureWarningPeriod {
+		return
+	}
+	r.log.Warnf("observation buffer (size %d) full for %d observations, %d observations dropped, consider increasing timing observationBufferSize",
+		r.bufferSize, r.full, r.dropped)
+	r.lastWarning = now
+	r.full = 0
+	r.dropped = 0
+}
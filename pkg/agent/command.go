@@ -6,58 +6,80 @@ package agent
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/gardener/network-problem-detector/pkg/agent/version"
+	"github.com/gardener/network-problem-detector/pkg/common"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-var (
+type runAgentCommand struct {
 	agentConfigFile   string
 	clusterConfigFile string
 	hostNetwork       bool
-)
+	environment       string
+	once              bool
+}
 
 func CreateRunAgentCmd(injectedVersion string) *cobra.Command {
 	version.Version = injectedVersion
+	rc := &runAgentCommand{}
 	cmd := &cobra.Command{
 		Use:   "run-agent",
 		Short: "runs agent server",
-		Long:  `The agent runs in a pod either on the host network or the pod network`,
+		Long:  `The agent runs in a pod either on the host network or the pod network, or standalone outside of Kubernetes`,
+		RunE:  rc.runAgent,
 	}
-	cmd.Flags().StringVar(&agentConfigFile, "config", "agent.config", "file configuration of agent server.")
-	cmd.Flags().StringVar(&clusterConfigFile, "cluster-config", "cluster.config", "file configuration of cluster nodes and agent pods.")
-	cmd.Flags().BoolVar(&hostNetwork, "hostNetwork", false, "if agent runs on host network.")
-	cmd.RunE = runAgent
+	cmd.Flags().StringVar(&rc.agentConfigFile, "config", "agent.config", "file configuration of agent server.")
+	cmd.Flags().StringVar(&rc.clusterConfigFile, "cluster-config", "cluster.config", "file configuration of cluster nodes and agent pods.")
+	cmd.Flags().BoolVar(&rc.hostNetwork, "hostNetwork", false, "if agent runs on host network.")
+	cmd.Flags().StringVar(&rc.environment, "environment", "", "'kubernetes' or 'standalone' (overrides agent configuration and detection).")
+	cmd.Flags().BoolVar(&rc.once, "once", false, "run each job a single time, print the observations and exit with code 1 if any observation failed or 2 if a job could not be run.")
 	return cmd
 }
 
-func runAgent(_ *cobra.Command, _ []string) error {
+func (rc *runAgentCommand) runAgent(cmd *cobra.Command, _ []string) error {
 	log := logrus.WithField("cmd", "agent")
 
-	if agentConfigFile == "" {
+	if rc.agentConfigFile == "" {
 		return fmt.Errorf("missing --config option")
 	}
-	if clusterConfigFile == "" {
+	if rc.clusterConfigFile == "" {
 		return fmt.Errorf("missing --cluster-config option")
 	}
 
-	srv, err := startAgentServer(log, agentConfigFile, clusterConfigFile, hostNetwork)
+	// failed checks are reported by the exit code, not by the usage
+	cmd.SilenceUsage = rc.once
+	srv, err := startAgentServer(log, rc.agentConfigFile, rc.clusterConfigFile, rc.hostNetwork, rc.environment)
 	if err != nil {
-		return fmt.Errorf("cannot start server: %w", err)
+		err = fmt.Errorf("cannot start server: %w", err)
+		if rc.once {
+			return &common.ExitError{Code: ExitCodeError, Err: err}
+		}
+		return err
+	}
+
+	if rc.once {
+		result, err := srv.runOnce(os.Stdout)
+		if err != nil {
+			return &common.ExitError{Code: ExitCodeError, Err: err}
+		}
+		return result.err()
 	}
 
 	log.Info("running...")
-	srv.run()
-	return nil
+	return srv.run()
 }
 
-func startAgentServer(log logrus.FieldLogger, agentConfigFile, clusterConfigFile string, hostNetwork bool) (*server, error) {
-	agentServer, err := newServer(log, agentConfigFile, clusterConfigFile, hostNetwork)
+func startAgentServer(log logrus.FieldLogger, agentConfigFile, clusterConfigFile string, hostNetwork bool, environment string) (*server, error) {
+	agentServer, err := newServer(log, agentConfigFile, clusterConfigFile, hostNetwork, environment)
 	if err != nil {
 		return nil, err
 	}
+	// the resolved secret values must not appear in any log entry
+	logrus.AddHook(redactionHook{secrets: agentServer.secrets})
 
 	err = agentServer.setup()
 	if err != nil {
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// configStatus tracks the revision of the applied agent configuration and the result of the last reload.
+type configStatus struct {
+	lock            sync.Mutex
+	revision        int64
+	lastApplied     time.Time
+	lastReload      time.Time
+	lastReloadError string
+}
+
+// applied increments the revision after the agent configuration has been applied successfully.
+func (c *configStatus) applied(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.revision++
+	c.lastApplied = now
+}
+
+// currentRevision returns the revision of the applied agent configuration.
+func (c *configStatus) currentRevision() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.revision
+}
+
+// reloaded records the result of a reload of the configuration files.
+func (c *configStatus) reloaded(now time.Time, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lastReload = now
+	c.lastReloadError = ""
+	if err != nil {
+		c.lastReloadError = err.Error()
+	}
+}
+
+// GetConfigStatus returns the revision of the applied agent configuration and the result of the last reload,
+// so that a rollout can wait until the agents have applied a new configuration.
+func (s *server) GetConfigStatus(_ context.Context, _ *nwpd.GetConfigStatusRequest) (*nwpd.GetConfigStatusResponse, error) {
+	s.configStatus.lock.Lock()
+	defer s.configStatus.lock.Unlock()
+
+	resp := &nwpd.GetConfigStatusResponse{
+		Revision:                  s.configStatus.revision,
+		LastReloadError:           s.secrets.redact(s.configStatus.lastReloadError),
+		ConsecutiveReloadFailures: s.reloadFailures.Load(),
+	}
+	if !s.configStatus.lastApplied.IsZero() {
+		resp.LastApplied = timestamppb.New(s.configStatus.lastApplied)
+	}
+	if !s.configStatus.lastReload.IsZero() {
+		resp.LastReload = timestamppb.New(s.configStatus.lastReload)
+	}
+	return resp, nil
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("config status", func() {
+	var (
+		s               *server
+		agentConfigFile string
+	)
+
+	writeAgentConfig := func(cfg *config.AgentConfig) {
+		data, err := yaml.Marshal(cfg)
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+	}
+
+	status := func() *nwpd.GetConfigStatusResponse {
+		resp, err := s.GetConfigStatus(context.Background(), &nwpd.GetConfigStatusRequest{})
+		Expect(err).To(BeNil())
+		return resp
+	}
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		agentConfigFile = filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		writeAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{}})
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		var err error
+		s, err = newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+	})
+
+	It("increments the revision on each applied configuration", func() {
+		resp := status()
+		Expect(resp.Revision).To(BeZero())
+		Expect(resp.LastApplied).To(BeNil())
+		Expect(resp.LastReload).To(BeNil())
+
+		s.reloadConfig()
+		resp = status()
+		Expect(resp.Revision).To(Equal(int64(1)))
+		Expect(resp.LastApplied).NotTo(BeNil())
+		Expect(resp.LastReload).NotTo(BeNil())
+		Expect(resp.LastReloadError).To(BeEmpty())
+
+		// unchanged files are not applied again
+		s.reloadConfig()
+		Expect(status().Revision).To(Equal(int64(1)))
+
+		writeAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{Jitter: 0.1}})
+		s.reloadConfig()
+		Expect(status().Revision).To(Equal(int64(2)))
+	})
+
+	It("reports the error of the last reload", func() {
+		s.reloadConfig()
+		writeAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{Jitter: 1.5}})
+		s.reloadConfig()
+		s.reloadConfig()
+		resp := status()
+		Expect(resp.Revision).To(Equal(int64(1)))
+		Expect(resp.LastReloadError).To(ContainSubstring("invalid jitter"))
+		Expect(resp.ConsecutiveReloadFailures).To(Equal(int32(2)))
+
+		Expect(os.WriteFile(agentConfigFile, []byte("podNetwork: ["), 0o600)).To(Succeed())
+		s.reloadConfig()
+		Expect(status().LastReloadError).To(ContainSubstring("cannot load agent configuration"))
+
+		writeAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{Jitter: 0.1}})
+		s.reloadConfig()
+		resp = status()
+		Expect(resp.Revision).To(Equal(int64(2)))
+		Expect(resp.LastReloadError).To(BeEmpty())
+		Expect(resp.ConsecutiveReloadFailures).To(BeZero())
+	})
+})
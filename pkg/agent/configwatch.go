@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// configMapDataDir is the symbolic link to the current data directory of a mounted ConfigMap.
+// It is replaced atomically by renaming a new symbolic link on each update.
+const configMapDataDir = "..data"
+
+// configWatcher watches the configuration files for changes. Besides the directories of the files, the directories the
+// files are resolved to via symbolic links are watched, as mounted ConfigMaps are updated by swapping the `..data` link.
+type configWatcher struct {
+	log     logrus.FieldLogger
+	watcher *fsnotify.Watcher
+	files   []string
+	dirs    map[string]bool
+	// targets are the watched directories of the resolved files
+	targets map[string]string
+}
+
+func newConfigWatcher(log logrus.FieldLogger, files ...string) (*configWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &configWatcher{log: log, watcher: watcher, dirs: map[string]bool{}, targets: map[string]string{}}
+	for _, file := range files {
+		file = filepath.Clean(file)
+		w.files = append(w.files, file)
+		dir := filepath.Dir(file)
+		if w.dirs[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+		w.dirs[dir] = true
+	}
+	w.follow()
+	return w, nil
+}
+
+// follow watches the directories the files are currently resolved to and stops watching the previous ones.
+// The watches removed by fsnotify because a watched directory has been removed or renamed are added again.
+func (w *configWatcher) follow() {
+	watched := map[string]bool{}
+	for _, dir := range w.watcher.WatchList() {
+		watched[dir] = true
+	}
+	for dir := range w.dirs {
+		if !watched[dir] {
+			if err := w.watcher.Add(dir); err == nil {
+				w.log.Infof("watching directory %s again", dir)
+			}
+		}
+	}
+	for file, dir := range w.targets {
+		if !watched[dir] && !w.dirs[dir] {
+			// the data directory has been removed, the file is resolved again
+			delete(w.targets, file)
+		}
+	}
+	for _, file := range w.files {
+		target, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			// the file may be missing during an update, it is followed again on the next event
+			continue
+		}
+		dir := filepath.Dir(target)
+		old := w.targets[file]
+		if dir == old {
+			continue
+		}
+		if old != "" && !w.dirs[old] && !w.isTarget(old, file) {
+			// the previous data directory is usually deleted already, which removes the watch
+			_ = w.watcher.Remove(old)
+		}
+		delete(w.targets, file)
+		if !w.dirs[dir] && !w.isTarget(dir, file) {
+			if err := w.watcher.Add(dir); err != nil {
+				w.log.Warnf("cannot watch directory %s of %s: %s", dir, file, err)
+				continue
+			}
+		}
+		w.targets[file] = dir
+		w.log.Debugf("watching %s resolved to %s", file, target)
+	}
+}
+
+// isTarget returns true if the directory is watched as target of another file.
+func (w *configWatcher) isTarget(dir, file string) bool {
+	for f, target := range w.targets {
+		if f != file && target == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// handle returns true if the event may have changed a configuration file. The watched targets are updated in this case.
+func (w *configWatcher) handle(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	relevant := filepath.Base(name) == configMapDataDir || w.dirs[name]
+	for _, file := range w.files {
+		if name == file || filepath.Dir(name) == w.targets[file] {
+			relevant = true
+		}
+	}
+	if !relevant {
+		return false
+	}
+	w.follow()
+	return true
+}
+
+func (w *configWatcher) close() error {
+	return w.watcher.Close()
+}
+
+// configFilesHash returns the hash of the contents of the files.
+func configFilesHash(files ...string) (string, error) {
+	h := sha256.New()
+	for _, file := range files {
+		data, err := os.ReadFile(file) // #nosec G304 -- configuration files of the agent
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(data)
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordConfigFilesHash stores the hash of the configuration files at the time they are loaded.
+func (s *server) recordConfigFilesHash() {
+	hash, err := configFilesHash(s.agentConfigFile, s.clusterConfigFile)
+	if err != nil {
+		s.loadedConfigHash.Store(nil)
+		return
+	}
+	s.loadedConfigHash.Store(&hash)
+}
+
+// recheckConfigIfDue re-reads the configuration files after the recheck period and schedules a reload if their contents
+// differ from the loaded ones, so that a missed file event does not keep a stale configuration.
+func (s *server) recheckConfigIfDue(now time.Time) {
+	period := s.getTiming().configRecheckPeriod
+	if period == 0 || now.Sub(s.lastConfigRecheck) < period {
+		return
+	}
+	s.lastConfigRecheck = now
+	hash, err := configFilesHash(s.agentConfigFile, s.clusterConfigFile)
+	if err != nil {
+		s.log.Warnf("cannot recheck configuration files: %s", err)
+		return
+	}
+	if loaded := s.loadedConfigHash.Load(); loaded != nil && *loaded == hash {
+		return
+	}
+	s.log.Infof("configuration files changed without file event, reloading")
+	ConfigRecheckReloads.Inc()
+	s.scheduleReload()
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("config watcher", func() {
+	var (
+		dir     string
+		version int
+	)
+
+	// update writes the files into a new data directory and swaps the `..data` link atomically like the kubelet does.
+	update := func(content string) string {
+		version++
+		dataDir := filepath.Join(dir, fmt.Sprintf("..2024_01_01_00_00_%02d", version))
+		Expect(os.Mkdir(dataDir, 0o755)).To(Succeed())
+		for _, name := range []string{"agent-config.yaml", "cluster-config.yaml"} {
+			Expect(os.WriteFile(filepath.Join(dataDir, name), []byte(content), 0o600)).To(Succeed())
+		}
+		old, _ := os.Readlink(filepath.Join(dir, configMapDataDir))
+		tmp := filepath.Join(dir, "..data_tmp")
+		Expect(os.Symlink(filepath.Base(dataDir), tmp)).To(Succeed())
+		Expect(os.Rename(tmp, filepath.Join(dir, configMapDataDir))).To(Succeed())
+		if old != "" {
+			Expect(os.RemoveAll(filepath.Join(dir, old))).To(Succeed())
+		}
+		return dataDir
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		version = 0
+		update("v1")
+		for _, name := range []string{"agent-config.yaml", "cluster-config.yaml"} {
+			Expect(os.Symlink(filepath.Join(configMapDataDir, name), filepath.Join(dir, name))).To(Succeed())
+		}
+	})
+
+	newWatcher := func() *configWatcher {
+		w, err := newConfigWatcher(logrus.NewEntry(logrus.StandardLogger()),
+			filepath.Join(dir, "agent-config.yaml"), filepath.Join(dir, "cluster-config.yaml"))
+		Expect(err).To(BeNil())
+		DeferCleanup(w.close)
+		return w
+	}
+
+	// changed returns true if a relevant event is received within the timeout.
+	changed := func(w *configWatcher, timeout time.Duration) bool {
+		deadline := time.After(timeout)
+		for {
+			select {
+			case event := <-w.watcher.Events:
+				if w.handle(event) {
+					return true
+				}
+			case err := <-w.watcher.Errors:
+				Fail(err.Error())
+			case <-deadline:
+				return false
+			}
+		}
+	}
+
+	It("follows the data directory on each ConfigMap update", func() {
+		w := newWatcher()
+		resolved, err := filepath.EvalSymlinks(dir)
+		Expect(err).To(BeNil())
+		Expect(w.targets).To(HaveKeyWithValue(filepath.Join(dir, "agent-config.yaml"), filepath.Join(resolved, "..2024_01_01_00_00_01")))
+
+		for _, content := range []string{"v2", "v3"} {
+			dataDir := update(content)
+			Expect(changed(w, 5*time.Second)).To(BeTrue(), content)
+			// drain the events of the update
+			for changed(w, 100*time.Millisecond) {
+			}
+			Expect(w.targets).To(HaveKeyWithValue(filepath.Join(dir, "cluster-config.yaml"), filepath.Join(resolved, filepath.Base(dataDir))))
+			Expect(w.watcher.WatchList()).To(ContainElement(filepath.Join(resolved, filepath.Base(dataDir))))
+		}
+	})
+
+	It("ignores unrelated files in the watched directory", func() {
+		w := newWatcher()
+		Expect(os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("x"), 0o600)).To(Succeed())
+		Expect(changed(w, 300*time.Millisecond)).To(BeFalse())
+	})
+
+	It("watches plain files", func() {
+		plain := GinkgoT().TempDir()
+		file := filepath.Join(plain, "agent-config.yaml")
+		Expect(os.WriteFile(file, []byte("v1"), 0o600)).To(Succeed())
+		w, err := newConfigWatcher(logrus.NewEntry(logrus.StandardLogger()), file)
+		Expect(err).To(BeNil())
+		defer w.close()
+		Expect(os.WriteFile(file, []byte("v2"), 0o600)).To(Succeed())
+		Expect(changed(w, 5*time.Second)).To(BeTrue())
+	})
+
+	It("watches a replaced directory again", func() {
+		parent := GinkgoT().TempDir()
+		plain := filepath.Join(parent, "config")
+		Expect(os.Mkdir(plain, 0o755)).To(Succeed())
+		file := filepath.Join(plain, "agent-config.yaml")
+		Expect(os.WriteFile(file, []byte("v1"), 0o600)).To(Succeed())
+		w, err := newConfigWatcher(logrus.NewEntry(logrus.StandardLogger()), file)
+		Expect(err).To(BeNil())
+		defer w.close()
+
+		Expect(os.RemoveAll(plain)).To(Succeed())
+		Expect(changed(w, 5*time.Second)).To(BeTrue())
+		Expect(os.Mkdir(plain, 0o755)).To(Succeed())
+		for changed(w, 100*time.Millisecond) {
+		}
+		w.follow()
+		Expect(w.watcher.WatchList()).To(ContainElement(plain))
+		Expect(os.WriteFile(file, []byte("v2"), 0o600)).To(Succeed())
+		Expect(changed(w, 5*time.Second)).To(BeTrue())
+	})
+})
+
+var _ = Describe("config recheck", func() {
+	It("reloads the configuration files if they have changed without file event", func() {
+		dir := GinkgoT().TempDir()
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		Expect(os.WriteFile(agentConfigFile, []byte("podNetwork: {}\ntiming: {reloadDebounce: 0s}"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+		s.reloadConfig()
+		revision := func() int64 {
+			resp, err := s.GetConfigStatus(context.Background(), &nwpd.GetConfigStatusRequest{})
+			Expect(err).To(BeNil())
+			return resp.Revision
+		}
+		Expect(revision()).To(Equal(int64(1)))
+
+		before := testutil.ToFloat64(ConfigRecheckReloads)
+		now := time.Now()
+		s.recheckConfigIfDue(now)
+		Expect(testutil.ToFloat64(ConfigRecheckReloads)).To(Equal(before))
+
+		Expect(os.WriteFile(agentConfigFile, []byte("podNetwork: {jitter: 0.1}\ntiming: {reloadDebounce: 0s}"), 0o600)).To(Succeed())
+		s.recheckConfigIfDue(now.Add(defaultConfigRecheckPeriod / 2))
+		Expect(testutil.ToFloat64(ConfigRecheckReloads)).To(Equal(before))
+		s.recheckConfigIfDue(now.Add(defaultConfigRecheckPeriod))
+		Expect(testutil.ToFloat64(ConfigRecheckReloads)).To(Equal(before + 1))
+		Eventually(revision).Should(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ObsWriterOption is an optional setting of the observation writer.
+type ObsWriterOption func(w *obsWriter)
+
+// CompressRotatedFiles gzip compresses the uncompressed record files of past hours after they have been rotated.
+// The record file of the current hour is kept uncompressed, so that the writer can append to it after a restart.
+func CompressRotatedFiles() ObsWriterOption {
+	return func(w *obsWriter) {
+		w.compressRotated = true
+	}
+}
+
+// compressRotatedFiles compresses the uncompressed record files of the hours before the current file.
+// An hour with both an uncompressed and a compressed file is skipped, as lists would contain the observations twice
+// until the uncompressed file has been removed.
+func (w *obsWriter) compressRotatedFiles() {
+	if !w.compressing.CompareAndSwap(false, true) {
+		// the files are compressed by the previous rotation
+		return
+	}
+	defer w.compressing.Store(false)
+	files, err := w.recordFiles()
+	if err != nil {
+		w.log.Warnf("cannot read directory %s: %s", w.directory, err)
+		return
+	}
+	currentHour := recordFileInfo{name: path.Base(recordFilename(w.directory, w.prefix, startOfHourUTC(time.Now()), false))}.hour()
+	compressed := map[string]bool{}
+	for _, f := range files {
+		if strings.HasSuffix(f.name, CompressedRecordFileSuffix) {
+			compressed[f.hour()] = true
+		}
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.name, RecordFileSuffix) || f.hour() >= currentHour || compressed[f.hour()] {
+			continue
+		}
+		filename := path.Join(w.directory, f.name)
+		if err := w.compressRecordFile(filename, f.modTime); err != nil {
+			w.log.Warnf("cannot compress file %s: %s", filename, err)
+			continue
+		}
+		w.log.Infof("compressed file %s", filename)
+	}
+	w.refreshDiskUsage()
+}
+
+// compressRecordFile replaces the uncompressed record file by a compressed one with the same modification time,
+// so that the retention of the file is not extended.
+func (w *obsWriter) compressRecordFile(filename string, modTime time.Time) error {
+	target := filename + ".gz"
+	tmp := target + ".tmp"
+	if err := gzipFile(filename, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, modTime, modTime); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	var idx *fileIndex
+	if plain := w.indexOf(filename); plain != nil {
+		idx = plain.compressed()
+	}
+
+	// the files are replaced while no list query collects the record files
+	w.filesLock.Lock()
+	defer w.filesLock.Unlock()
+	if _, err := os.Stat(filename); err != nil {
+		// deleted in the meantime, e.g. because of the maximum disk usage
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	w.forgetIndex(filename)
+	if err := os.Remove(filename + IndexFileSuffix); err != nil && !os.IsNotExist(err) {
+		w.log.Warnf("cannot delete file %s: %s", filename+IndexFileSuffix, err)
+	}
+	if err := os.Remove(filename); err != nil {
+		return fmt.Errorf("cannot delete uncompressed file: %s", err)
+	}
+	if idx != nil {
+		if info, err := os.Stat(target); err == nil {
+			idx.fileSize = info.Size()
+			if err := writeIndexFile(target, idx); err != nil {
+				w.log.Debugf("cannot write index file of %s: %s", target, err)
+			}
+		}
+	}
+	return nil
+}
+
+// gzipFile writes the gzip compressed content of the source file to the target file and syncs it.
+func gzipFile(source, target string) error {
+	in, err := os.Open(source) // #nosec G304 -- record file in the output directory
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //  #nosec G302 G304 -- no sensitive data
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
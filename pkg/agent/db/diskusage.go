@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// diskUsageGauge is the gauge updated with the total size of the record files or nil.
+var diskUsageGauge atomic.Pointer[prometheus.Gauge]
+
+// SetDiskUsageGauge sets the gauge updated with the total size of the record files of the observation writer.
+func SetDiskUsageGauge(g prometheus.Gauge) {
+	diskUsageGauge.Store(&g)
+}
+
+// countingWriter counts the bytes written to the file.
+type countingWriter struct {
+	w    io.Writer
+	size *atomic.Int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.size.Add(int64(n))
+	return n, err
+}
+
+type recordFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// hour returns the part of the file name with the hour of the records.
+func (f recordFileInfo) hour() string {
+	return strings.TrimSuffix(strings.TrimSuffix(f.name, CompressedRecordFileSuffix), RecordFileSuffix)
+}
+
+// SetMaxDiskUsage sets the maximum total size of the record files in bytes (0 for no limit).
+// If the limit is exceeded, the oldest record files are deleted before the next records are written.
+func (w *obsWriter) SetMaxDiskUsage(maxBytes int64) {
+	w.maxDiskUsage.Store(maxBytes)
+}
+
+// recordFiles returns the record files of the writer sorted by hour, oldest first.
+func (w *obsWriter) recordFiles() ([]recordFileInfo, error) {
+	entries, err := os.ReadDir(w.directory)
+	if err != nil {
+		return nil, err
+	}
+	var files []recordFileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), w.prefix+"-") || !IsRecordFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed in the meantime
+			continue
+		}
+		files = append(files, recordFileInfo{name: entry.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if hi, hj := files[i].hour(), files[j].hour(); hi != hj {
+			return hi < hj
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
+
+// currentFilename returns the name of the file currently written or an empty string.
+func (w *obsWriter) currentFilename() string {
+	if file, ok := w.currentFile.Load().(*writeFile); ok && file != nil {
+		return path.Base(file.filename)
+	}
+	return ""
+}
+
+// currentFileSize returns the size of the file currently written.
+func (w *obsWriter) currentFileSize() int64 {
+	if file, ok := w.currentFile.Load().(*writeFile); ok && file != nil {
+		return file.size.Load()
+	}
+	return 0
+}
+
+// diskUsage returns the total size of the record files.
+func (w *obsWriter) diskUsage() int64 {
+	return w.otherFilesSize.Load() + w.currentFileSize()
+}
+
+func (w *obsWriter) updateDiskUsageGauge() {
+	if g := diskUsageGauge.Load(); g != nil {
+		(*g).Set(float64(w.diskUsage()))
+	}
+}
+
+// refreshDiskUsage reads the sizes of the record files except the current file from the directory.
+func (w *obsWriter) refreshDiskUsage() {
+	files, err := w.recordFiles()
+	if err != nil {
+		w.log.Warnf("cannot read directory %s: %s", w.directory, err)
+		return
+	}
+	current := w.currentFilename()
+	var others int64
+	for _, f := range files {
+		if f.name != current {
+			others += f.size
+		}
+	}
+	w.otherFilesSize.Store(others)
+	w.updateDiskUsageGauge()
+}
+
+// enforceMaxDiskUsage deletes the oldest record files until the total size is within the limit.
+// The current file is never deleted, even if it exceeds the limit on its own.
+func (w *obsWriter) enforceMaxDiskUsage() {
+	defer w.updateDiskUsageGauge()
+	maxBytes := w.maxDiskUsage.Load()
+	if maxBytes <= 0 || w.otherFilesSize.Load() == 0 || w.diskUsage() <= maxBytes {
+		return
+	}
+	current := w.currentFilename()
+	if current == "" {
+		// the file to append to is not known before the first write
+		return
+	}
+	files, err := w.recordFiles()
+	if err != nil {
+		w.log.Warnf("cannot read directory %s: %s", w.directory, err)
+		return
+	}
+	var others int64
+	for _, f := range files {
+		if f.name != current {
+			others += f.size
+		}
+	}
+	total := others + w.currentFileSize()
+	for _, f := range files {
+		if total <= maxBytes {
+			break
+		}
+		if f.name == current {
+			continue
+		}
+		filename := path.Join(w.directory, f.name)
+		if err := w.removeRecordFile(filename); err != nil && !os.IsNotExist(err) {
+			w.log.Warnf("cannot delete file %s: %s", filename, err)
+			continue
+		}
+		w.log.Infof("deleted file %s as the record files exceed the maximum disk usage of %d bytes", filename, maxBytes)
+		total -= f.size
+		others -= f.size
+	}
+	w.otherFilesSize.Store(others)
+	if total > maxBytes {
+		w.log.Warnf("current record file %s exceeds the maximum disk usage of %d bytes", current, maxBytes)
+	}
+}
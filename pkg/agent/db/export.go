@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// ExportFormatJSON exports the observations as newline-delimited JSON.
+	ExportFormatJSON = "json"
+	// ExportFormatCSV exports the observations as CSV with a header line.
+	ExportFormatCSV = "csv"
+)
+
+// ExportFormats are the supported export formats.
+var ExportFormats = []string{ExportFormatJSON, ExportFormatCSV}
+
+// exportColumns are the leading CSV columns. They are followed by a column per result field, `otherResultFields` and `labels`.
+var exportColumns = []string{"timestamp", "jobID", "srcHost", "srcZone", "destHost", "destZone", "ok", "durationMillis",
+	"periodMillis", "staleEndpoint", "incidentID", "result"}
+
+// ExportOptions are the options to export the observations of a directory.
+type ExportOptions struct {
+	nwpd.ListObservationsOptions
+	// Directory is the directory of the record files. The subdirectories per node created by `nwpdcli collect` are exported, too.
+	Directory string
+	// ResultFields are the result fields exported as separate CSV columns in this order.
+	ResultFields []string
+	// Log is the logger for skipped corrupt record files (standard logger if not set).
+	Log logrus.FieldLogger
+}
+
+// Encoder writes observations in an export format.
+type Encoder struct {
+	format       string
+	marshaller   protojson.MarshalOptions
+	bw           *bufio.Writer
+	cw           *csv.Writer
+	resultFields []string
+	columns      map[string]bool
+}
+
+// NewEncoder creates an encoder of the export format. For CSV, the result fields are exported as separate columns in the given order,
+// all other result fields are exported as JSON object in the column `otherResultFields`.
+// The output is buffered, Flush must be called after the last observation.
+func NewEncoder(w io.Writer, format string, resultFields []string) (*Encoder, error) {
+	e := &Encoder{format: format}
+	switch format {
+	case ExportFormatJSON:
+		e.marshaller = protojson.MarshalOptions{EmitUnpopulated: true}
+		e.bw = bufio.NewWriter(w)
+	case ExportFormatCSV:
+		e.cw = csv.NewWriter(w)
+		e.resultFields = resultFields
+		e.columns = map[string]bool{}
+		for _, name := range resultFields {
+			e.columns[name] = true
+		}
+		header := append(append([]string{}, exportColumns...), resultFields...)
+		if err := e.cw.Write(append(header, "otherResultFields", "labels")); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid export format %q, must be one of %v", format, ExportFormats)
+	}
+	return e, nil
+}
+
+// ContentType returns the HTTP content type of the export format.
+func (e *Encoder) ContentType() string {
+	if e.format == ExportFormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+// Encode writes the observation.
+func (e *Encoder) Encode(obs *nwpd.Observation) error {
+	if e.cw != nil {
+		return e.cw.Write(e.csvRecord(obs))
+	}
+	line, err := e.marshaller.Marshal(obs)
+	if err != nil {
+		return err
+	}
+	if _, err := e.bw.Write(line); err != nil {
+		return err
+	}
+	return e.bw.WriteByte('\n')
+}
+
+// Flush writes the buffered data.
+func (e *Encoder) Flush() error {
+	if e.cw != nil {
+		e.cw.Flush()
+		return e.cw.Error()
+	}
+	return e.bw.Flush()
+}
+
+func (e *Encoder) csvRecord(obs *nwpd.Observation) []string {
+	var timestamp, duration, period string
+	if obs.Timestamp != nil {
+		timestamp = obs.Timestamp.AsTime().UTC().Format(time.RFC3339Nano)
+	}
+	if obs.Duration != nil {
+		duration = millis(obs.Duration.AsDuration())
+	}
+	if obs.Period != nil {
+		period = millis(obs.Period.AsDuration())
+	}
+	record := []string{timestamp, obs.JobID, obs.SrcHost, obs.SrcZone, obs.DestHost, obs.DestZone, strconv.FormatBool(obs.Ok),
+		duration, period, strconv.FormatBool(obs.StaleEndpoint), obs.IncidentID, obs.Result}
+	others := map[string]string{}
+	for name, value := range obs.ResultFields {
+		if !e.columns[name] {
+			others[name] = value
+		}
+	}
+	for _, name := range e.resultFields {
+		record = append(record, obs.ResultFields[name])
+	}
+	return append(record, jsonObject(others), jsonObject(obs.Labels))
+}
+
+// millis formats the duration in milliseconds.
+func millis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
+
+// jsonObject returns the map as JSON object with sorted keys or an empty string for an empty map.
+func jsonObject(m map[string]string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(m) // #nosec G104 -- a string map is always marshalled
+	return string(data)
+}
+
+// recordSource are the record files of a prefix in a directory.
+type recordSource struct {
+	directory string
+	prefix    string
+	// oldest is the hour of the oldest record file.
+	oldest time.Time
+}
+
+// recordFilePrefix returns the prefix and the hour of a record file name.
+func recordFilePrefix(name string) (string, time.Time, bool) {
+	const layout = "2006-01-02-15"
+	hour := recordFileInfo{name: name}.hour()
+	if len(hour) <= len(layout)+1 || hour[len(hour)-len(layout)-1] != '-' {
+		return "", time.Time{}, false
+	}
+	t, err := time.Parse(layout, hour[len(hour)-len(layout):])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return hour[:len(hour)-len(layout)-1], t, true
+}
+
+// recordSources returns the record file prefixes of the directory and its subdirectories sorted by directory and prefix.
+func recordSources(directory string) ([]recordSource, error) {
+	filenames, err := GetAnyRecordFiles(directory, true)
+	if err != nil {
+		return nil, err
+	}
+	sources := map[recordSource]time.Time{}
+	for _, filename := range filenames {
+		prefix, hour, ok := recordFilePrefix(path.Base(filename))
+		if !ok {
+			continue
+		}
+		key := recordSource{directory: path.Dir(filename), prefix: prefix}
+		if oldest, ok := sources[key]; !ok || hour.Before(oldest) {
+			sources[key] = hour
+		}
+	}
+	var result []recordSource
+	for source, oldest := range sources {
+		source.oldest = oldest
+		result = append(result, source)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].directory != result[j].directory {
+			return result[i].directory < result[j].directory
+		}
+		return result[i].prefix < result[j].prefix
+	})
+	return result, nil
+}
+
+// Export writes the observations of the record files in the directory of the options and returns the number of exported observations.
+// The observations are streamed ordered by timestamp per directory and record file prefix. In contrast to the agent, the time range
+// is not limited to the last 24 hours.
+func Export(w io.Writer, format string, options ExportOptions) (int, error) {
+	if !options.IsDefaultOrder() {
+		return 0, &nwpd.InvalidFilterError{Field: "sortBy", Err: fmt.Errorf("only %s ascending supported for exporting", nwpd.SortByTimestamp)}
+	}
+	enc, err := NewEncoder(w, format, options.ResultFields)
+	if err != nil {
+		return 0, err
+	}
+	sources, err := recordSources(options.Directory)
+	if err != nil {
+		return 0, err
+	}
+	log := options.Log
+	if log == nil {
+		log = logrus.StandardLogger()
+	}
+	count := 0
+	for _, source := range sources {
+		if options.Limit > 0 && count >= options.Limit {
+			break
+		}
+		reader := &obsWriter{log: log, directory: source.directory, prefix: source.prefix, listStartLimit: source.oldest}
+		sourceOptions := options.ListObservationsOptions
+		if options.Limit > 0 {
+			sourceOptions.Limit = options.Limit - count
+		}
+		err := reader.IterateObservations(sourceOptions, func(obs *nwpd.Observation) (bool, error) {
+			count++
+			return false, enc.Encode(obs)
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, enc.Flush()
+}
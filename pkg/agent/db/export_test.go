@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("export", func() {
+	var (
+		dir  string
+		base time.Time
+	)
+
+	// writeRecords writes the observations to the record file of the hour of base.
+	writeRecords := func(directory, prefix string, observations ...*nwpd.Observation) {
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), directory, prefix, 24*7, false)
+		Expect(err).To(BeNil())
+		for _, obs := range observations {
+			writer.Add(obs)
+		}
+		go writer.Run()
+		writer.Stop()
+		filenames, err := GetRecordFiles(directory, prefix, time.Now(), time.Now())
+		Expect(err).To(BeNil())
+		Expect(filenames).To(HaveLen(1))
+		Expect(os.Rename(filenames[0], recordFilename(directory, prefix, base, false))).To(Succeed())
+	}
+	export := func(format string, options ExportOptions) (string, int) {
+		var buf bytes.Buffer
+		options.Directory = dir
+		n, err := Export(&buf, format, options)
+		Expect(err).To(BeNil())
+		return buf.String(), n
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		// older than the 24 hours listed by the agent
+		base = startOfHourUTC(time.Now()).Add(-72 * time.Hour)
+		writeRecords(path.Join(dir, "node-a"), "nwpd-agent-pod-net",
+			&nwpd.Observation{JobID: "https-n2api", SrcHost: "node-a", SrcZone: "z1", DestHost: "api", Ok: true,
+				Timestamp: timestamppb.New(base.Add(time.Second)), Duration: durationpb.New(2 * time.Millisecond),
+				ResultFields: map[string]string{"httpStatus": "200", "certDaysRemaining": "42"}, Labels: map[string]string{"port": "443"}},
+			&nwpd.Observation{JobID: "ping-n2n", SrcHost: "node-a", DestHost: "node-b",
+				Timestamp: timestamppb.New(base.Add(3 * time.Second)), ResultFields: map[string]string{"attempts": "3"}})
+		writeRecords(path.Join(dir, "node-b"), "nwpd-agent-node-net",
+			&nwpd.Observation{JobID: "ping-n2n", SrcHost: "node-b", DestHost: "node-a", Ok: true,
+				Timestamp: timestamppb.New(base.Add(2 * time.Second))})
+	})
+
+	It("exports CSV with the result fields as columns", func() {
+		out, n := export(ExportFormatCSV, ExportOptions{ResultFields: []string{"attempts", "httpStatus"}})
+		Expect(n).To(Equal(3))
+		records, err := csv.NewReader(bytes.NewBufferString(out)).ReadAll()
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(4))
+		Expect(records[0]).To(Equal([]string{"timestamp", "jobID", "srcHost", "srcZone", "destHost", "destZone", "ok", "durationMillis",
+			"periodMillis", "staleEndpoint", "incidentID", "result", "attempts", "httpStatus", "otherResultFields", "labels"}))
+		Expect(records[1]).To(Equal([]string{base.Add(time.Second).Format(time.RFC3339Nano), "https-n2api", "node-a", "z1", "api", "", "true", "2",
+			"", "false", "", "", "", "200", `{"certDaysRemaining":"42"}`, `{"port":"443"}`}))
+		// ordered by timestamp per directory
+		Expect(records[2][0:3]).To(Equal([]string{base.Add(3 * time.Second).Format(time.RFC3339Nano), "ping-n2n", "node-a"}))
+		Expect(records[2][12:]).To(Equal([]string{"3", "", "", ""}))
+		Expect(records[3][2]).To(Equal("node-b"))
+	})
+
+	It("exports newline-delimited JSON with filters and limit", func() {
+		out, n := export(ExportFormatJSON, ExportOptions{ListObservationsOptions: nwpd.ListObservationsOptions{FilterJobIDs: []string{"ping-n2n"}}})
+		Expect(n).To(Equal(2))
+		var lines []map[string]any
+		scanner := bufio.NewScanner(bytes.NewBufferString(out))
+		for scanner.Scan() {
+			line := map[string]any{}
+			Expect(json.Unmarshal(scanner.Bytes(), &line)).To(Succeed())
+			lines = append(lines, line)
+		}
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(HaveKeyWithValue("srcHost", "node-a"))
+		Expect(lines[0]).To(HaveKeyWithValue("resultFields", map[string]any{"attempts": "3"}))
+		Expect(lines[1]).To(HaveKeyWithValue("srcHost", "node-b"))
+
+		_, n = export(ExportFormatJSON, ExportOptions{ListObservationsOptions: nwpd.ListObservationsOptions{Limit: 2}})
+		Expect(n).To(Equal(2))
+		_, n = export(ExportFormatJSON, ExportOptions{ListObservationsOptions: nwpd.ListObservationsOptions{Start: base.Add(2 * time.Second)}})
+		Expect(n).To(Equal(2))
+	})
+
+	It("writes the CSV header without observations", func() {
+		out, n := export(ExportFormatCSV, ExportOptions{ListObservationsOptions: nwpd.ListObservationsOptions{Start: time.Now()}})
+		Expect(n).To(Equal(0))
+		Expect(out).To(HavePrefix("timestamp,jobID,"))
+		Expect(bytes.Count([]byte(out), []byte("\n"))).To(Equal(1))
+	})
+
+	It("rejects invalid formats and orders", func() {
+		_, err := Export(&bytes.Buffer{}, "xml", ExportOptions{Directory: dir})
+		Expect(err).To(MatchError(ContainSubstring("invalid export format")))
+		_, err = Export(&bytes.Buffer{}, ExportFormatJSON, ExportOptions{Directory: dir,
+			ListObservationsOptions: nwpd.ListObservationsOptions{SortBy: nwpd.SortByDuration}})
+		Expect(err).To(MatchError(ContainSubstring("sortBy")))
+	})
+})
@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// IndexFileSuffix is appended to the name of a record file for the file with its index.
+const IndexFileSuffix = ".idx"
+
+// indexInterval is the number of records between two seek positions in the index of an uncompressed record file.
+const indexInterval = 256
+
+const (
+	// markerIndexHeader is the first record of an index file with the size of the record file and the time range.
+	markerIndexHeader = 5
+	// markerIndexEntry is a record of an index file with a seek position.
+	markerIndexEntry = 6
+)
+
+// indexEntry is a position in an uncompressed record file to start reading from.
+type indexEntry struct {
+	// offset is the position of the record in the file.
+	offset int64
+	// records is the number of records before the position.
+	records int64
+	// maxBefore is the latest timestamp in Unix millis of the observations before the position.
+	// The observations are written in the order they are received, so that their timestamps are not strictly ascending.
+	maxBefore int64
+}
+
+// fileIndex is the index of a record file with the time range of its observations. The index of an uncompressed file additionally
+// contains seek positions and all strings of the string ID records, so that reading can start within the file.
+type fileIndex struct {
+	lock sync.Mutex
+	// fileSize is the size of the record file when the index has been created, used to detect outdated indexes.
+	fileSize int64
+	seekable bool
+	records  int64
+	// minMillis and maxMillis are the time range of the observations in Unix millis, 0 if there is no observation.
+	minMillis int64
+	maxMillis int64
+	entries   []indexEntry
+	strings   []*IntString
+}
+
+// add adds the record at the offset. The timestamp millis are only set for an observation, str only for a string ID record.
+func (x *fileIndex) add(offset, millis int64, str *IntString) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.seekable && x.records > 0 && x.records%indexInterval == 0 {
+		x.entries = append(x.entries, indexEntry{offset: offset, records: x.records, maxBefore: x.maxMillis})
+	}
+	x.records++
+	if millis != 0 {
+		if x.minMillis == 0 || millis < x.minMillis {
+			x.minMillis = millis
+		}
+		if millis > x.maxMillis {
+			x.maxMillis = millis
+		}
+	}
+	if str != nil {
+		x.strings = append(x.strings, str)
+	}
+}
+
+// overlaps returns true if the file may contain observations in the time range given in Unix millis.
+func (x *fileIndex) overlaps(startMillis, endMillis int64) bool {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.minMillis != 0 && x.maxMillis >= startMillis && x.minMillis <= endMillis
+}
+
+// seek returns the last position before the first observation at or after startMillis and the strings of the file.
+// It returns false for a nil index, if the file cannot be read from a position, or if there is no position to skip records.
+func (x *fileIndex) seek(startMillis int64) (indexEntry, []*IntString, bool) {
+	if x == nil {
+		return indexEntry{}, nil, false
+	}
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if !x.seekable {
+		return indexEntry{}, nil, false
+	}
+	i := sort.Search(len(x.entries), func(i int) bool { return x.entries[i].maxBefore >= startMillis })
+	if i == 0 {
+		return indexEntry{}, nil, false
+	}
+	// the strings are only appended, so that the slice is a consistent snapshot
+	return x.entries[i-1], x.strings[:len(x.strings):len(x.strings)], true
+}
+
+// compressed returns the index of the file after compressing it, which only contains the time range.
+func (x *fileIndex) compressed() *fileIndex {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return &fileIndex{records: x.records, minMillis: x.minMillis, maxMillis: x.maxMillis}
+}
+
+// indexValues returns the timestamp of an observation record or the string of a string ID record.
+func indexValues(marker byte, value []byte) (int64, *IntString, error) {
+	switch marker {
+	case markerObservation:
+		intobs, err := IntObsFromBytes(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: error on unmarshalling: %s", errInvalidRecord, err)
+		}
+		return intobs.TimeMillis, nil, nil
+	case markerStringID:
+		raw := &nwpd.IntString{}
+		if err := proto.Unmarshal(value, raw); err != nil {
+			return 0, nil, fmt.Errorf("%w: error on reading StringIDMap: %s", errInvalidRecord, err)
+		}
+		return 0, NewVarint2String(raw.Key, raw.Value), nil
+	}
+	return 0, nil, nil
+}
+
+// scanFileIndex reads the record file and returns its index. An incomplete record at the end of the file is ignored.
+func scanFileIndex(filename string) (*fileIndex, error) {
+	f, err := os.Open(filename) // #nosec G304 -- record file in the output directory
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRecordReader(f)
+	if err != nil {
+		return nil, err
+	}
+	_, compressed := r.(*gzip.Reader)
+	idx := &fileIndex{fileSize: info.Size(), seekable: !compressed}
+	cr := &countingReader{r: r}
+	for {
+		offset := cr.n
+		marker, value, err := readRecord(cr)
+		if errors.Is(err, io.ErrUnexpectedEOF) || (err == nil && value == nil) {
+			return idx, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		millis, str, err := indexValues(marker, value)
+		if err != nil {
+			return nil, err
+		}
+		idx.add(offset, millis, str)
+	}
+}
+
+// writeIndexFile writes the index to the index file of the record file.
+func writeIndexFile(filename string, idx *fileIndex) error {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	var buf bytes.Buffer
+	header := binary.LittleEndian.AppendUint64(nil, uint64(idx.fileSize)) // #nosec G115 -- file sizes are not negative
+	for _, v := range []int64{idx.records, idx.minMillis, idx.maxMillis} {
+		header = binary.AppendVarint(header, v)
+	}
+	if idx.seekable {
+		header = append(header, 1)
+	} else {
+		header = append(header, 0)
+	}
+	if err := writeRecord(&buf, markerIndexHeader, header); err != nil {
+		return err
+	}
+	for _, e := range idx.entries {
+		var value []byte
+		for _, v := range []int64{e.offset, e.records, e.maxBefore} {
+			value = binary.AppendVarint(value, v)
+		}
+		if err := writeRecord(&buf, markerIndexEntry, value); err != nil {
+			return err
+		}
+	}
+	for _, s := range idx.strings {
+		value, err := proto.Marshal(&nwpd.IntString{Key: s.Key(), Value: s.Value()})
+		if err != nil {
+			return err
+		}
+		if err := writeRecord(&buf, markerStringID, value); err != nil {
+			return err
+		}
+	}
+	tmp := filename + IndexFileSuffix + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o640); err != nil { //  #nosec G306 -- no sensitive data
+		return err
+	}
+	return os.Rename(tmp, filename+IndexFileSuffix)
+}
+
+// readIndexFile reads the index file of the record file.
+func readIndexFile(filename string) (*fileIndex, error) {
+	var idx *fileIndex
+	complete, err := readRecordFile(filename+IndexFileSuffix, func(marker byte, value []byte) error {
+		if marker != markerIndexHeader && idx == nil {
+			return fmt.Errorf("%w: missing index header", errInvalidRecord)
+		}
+		switch marker {
+		case markerIndexHeader:
+			if len(value) < 8 {
+				return fmt.Errorf("%w: invalid index header", errInvalidRecord)
+			}
+			values, rest, err := readVarints(value[8:], 3)
+			if err != nil || len(rest) != 1 {
+				return fmt.Errorf("%w: invalid index header", errInvalidRecord)
+			}
+			idx = &fileIndex{
+				fileSize:  int64(binary.LittleEndian.Uint64(value)), // #nosec G115 -- written from a file size
+				records:   values[0],
+				minMillis: values[1],
+				maxMillis: values[2],
+				seekable:  rest[0] == 1,
+			}
+		case markerIndexEntry:
+			values, rest, err := readVarints(value, 3)
+			if err != nil || len(rest) != 0 {
+				return fmt.Errorf("%w: invalid index entry", errInvalidRecord)
+			}
+			idx.entries = append(idx.entries, indexEntry{offset: values[0], records: values[1], maxBefore: values[2]})
+		case markerStringID:
+			_, str, err := indexValues(marker, value)
+			if err != nil {
+				return err
+			}
+			idx.strings = append(idx.strings, str)
+		default:
+			return unknownMarkerError(marker)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !complete || idx == nil {
+		return nil, fmt.Errorf("incomplete index file %s", filename+IndexFileSuffix)
+	}
+	return idx, nil
+}
+
+// readVarints reads n varints and returns the remaining bytes.
+func readVarints(data []byte, n int) ([]int64, []byte, error) {
+	values := make([]int64, n)
+	for i := range values {
+		v, k := binary.Varint(data)
+		if k <= 0 {
+			return nil, nil, fmt.Errorf("invalid varint")
+		}
+		values[i] = v
+		data = data[k:]
+	}
+	return values, data, nil
+}
+
+// indexOf returns the index of the record file or nil if the file cannot be indexed. The index of the file currently written is
+// updated on each write. For other files, the index is read from the index file, or created by reading the record file if the
+// index file is missing or outdated.
+func (w *obsWriter) indexOf(filename string) *fileIndex {
+	if file, ok := w.currentFile.Load().(*writeFile); ok && file != nil && file.filename == filename {
+		return file.index
+	}
+	info, err := os.Stat(filename)
+	w.indexLock.Lock()
+	defer w.indexLock.Unlock()
+	if err != nil {
+		delete(w.indexes, filename)
+		return nil
+	}
+	if idx := w.indexes[filename]; idx != nil && idx.fileSize == info.Size() {
+		return idx
+	}
+	idx, err := readIndexFile(filename)
+	if err != nil || idx.fileSize != info.Size() {
+		if idx, err = scanFileIndex(filename); err != nil {
+			w.log.Debugf("cannot index file %s: %s", filename, err)
+			delete(w.indexes, filename)
+			return nil
+		}
+		if err := writeIndexFile(filename, idx); err != nil {
+			// e.g. read-only directory
+			w.log.Debugf("cannot write index file of %s: %s", filename, err)
+		}
+	}
+	if w.indexes == nil {
+		w.indexes = map[string]*fileIndex{}
+	}
+	w.indexes[filename] = idx
+	return idx
+}
+
+// removeRecordFile deletes the record file and its index file.
+func (w *obsWriter) removeRecordFile(filename string) error {
+	w.forgetIndex(filename)
+	if err := os.Remove(filename + IndexFileSuffix); err != nil && !os.IsNotExist(err) {
+		w.log.Warnf("cannot delete file %s: %s", filename+IndexFileSuffix, err)
+	}
+	w.filesLock.Lock()
+	defer w.filesLock.Unlock()
+	return os.Remove(filename)
+}
+
+// forgetIndex removes the cached index of the record file.
+func (w *obsWriter) forgetIndex(filename string) {
+	w.indexLock.Lock()
+	defer w.indexLock.Unlock()
+	delete(w.indexes, filename)
+}
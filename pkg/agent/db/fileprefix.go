@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+)
+
+const (
+	// PlaceholderNodeName is replaced by the node name in the prefix of the record files.
+	PlaceholderNodeName = "{nodename}"
+	// PlaceholderPod is replaced by the pod name in the prefix of the record files.
+	PlaceholderPod = "{pod}"
+)
+
+// placeholderPattern matches the placeholders in a file prefix.
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ExpandFilePrefix replaces the placeholders `{nodename}` and `{pod}` in the prefix of the record files, so that agents
+// writing to a shared volume use distinct files. The node name is taken from the environment variable NODE_NAME and the
+// pod name from POD_NAME, both falling back to the host name. A prefix without placeholders is returned unchanged.
+func ExpandFilePrefix(prefix string) (string, error) {
+	return expandFilePrefix(prefix, os.Getenv, os.Hostname)
+}
+
+func expandFilePrefix(prefix string, getenv func(key string) string, hostname func() (string, error)) (string, error) {
+	if !strings.ContainsAny(prefix, "{}") {
+		return prefix, nil
+	}
+	valueOf := func(env string) (string, error) {
+		if value := getenv(env); value != "" {
+			return value, nil
+		}
+		host, err := hostname()
+		if err != nil {
+			return "", fmt.Errorf("cannot get host name: %s", err)
+		}
+		return host, nil
+	}
+	var err error
+	expanded := placeholderPattern.ReplaceAllStringFunc(prefix, func(placeholder string) string {
+		var value string
+		var verr error
+		switch placeholder {
+		case PlaceholderNodeName:
+			value, verr = valueOf(common.EnvNodeName)
+		case PlaceholderPod:
+			value, verr = valueOf(common.EnvPodName)
+		default:
+			verr = fmt.Errorf("unknown placeholder %s, must be one of %s, %s", placeholder, PlaceholderNodeName, PlaceholderPod)
+		}
+		if verr != nil && err == nil {
+			err = verr
+		}
+		return value
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid dataFilePrefix %q: %s", prefix, err)
+	}
+	if strings.ContainsAny(expanded, "{}/") || expanded == "" {
+		return "", fmt.Errorf("invalid dataFilePrefix %q: expanded to invalid file name prefix %q", prefix, expanded)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("file prefix", func() {
+	getenv := func(env map[string]string) func(string) string {
+		return func(key string) string { return env[key] }
+	}
+	hostname := func() (string, error) { return "host-1", nil }
+
+	DescribeTable("expands the placeholders",
+		func(prefix string, env map[string]string, expected string) {
+			expanded, err := expandFilePrefix(prefix, getenv(env), hostname)
+			Expect(err).To(BeNil())
+			Expect(expanded).To(Equal(expected))
+		},
+		Entry("literal prefix", "nwpd-agent-pod-net", nil, "nwpd-agent-pod-net"),
+		Entry("node name", "nwpd-{nodename}", map[string]string{common.EnvNodeName: "node-a"}, "nwpd-node-a"),
+		Entry("node and pod name", "{nodename}-{pod}", map[string]string{common.EnvNodeName: "node-a", common.EnvPodName: "nwpd-pod-x1"},
+			"node-a-nwpd-pod-x1"),
+		Entry("host name without downward API", "nwpd-{pod}", nil, "nwpd-host-1"),
+	)
+
+	DescribeTable("rejects invalid prefixes",
+		func(prefix string, env map[string]string, expectedErr string) {
+			_, err := expandFilePrefix(prefix, getenv(env), hostname)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("unknown placeholder", "nwpd-{namespace}", nil, "unknown placeholder {namespace}"),
+		Entry("unbalanced braces", "nwpd-{nodename", nil, "expanded to invalid file name prefix"),
+		Entry("path separator", "{nodename}", map[string]string{common.EnvNodeName: "a/b"}, "expanded to invalid file name prefix"),
+	)
+
+	It("fails if the host name is unknown", func() {
+		_, err := expandFilePrefix("{nodename}", getenv(nil), func() (string, error) { return "", fmt.Errorf("no host") })
+		Expect(err).To(MatchError(ContainSubstring("cannot get host name: no host")))
+	})
+
+	It("writes the record files with the expanded prefix", func() {
+		GinkgoT().Setenv(common.EnvNodeName, "node-a")
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "nwpd-{nodename}", 24, false)
+		Expect(err).To(BeNil())
+		Expect(writer.(*obsWriter).prefix).To(Equal("nwpd-node-a"))
+	})
+})
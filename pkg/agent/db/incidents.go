@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// IncidentFileSuffix is the suffix of the incident snapshot files.
+const IncidentFileSuffix = ".incidents"
+
+// IncidentFilename returns the name of the incident snapshot file in the records directory.
+func IncidentFilename(directory, prefix string) string {
+	return path.Join(directory, prefix+IncidentFileSuffix)
+}
+
+// SortIncidents sorts incidents by start time and ID.
+func SortIncidents(incidents []*nwpd.Incident) {
+	sort.Slice(incidents, func(i, j int) bool {
+		si, sj := incidents[i].Start.AsTime(), incidents[j].Start.AsTime()
+		if !si.Equal(sj) {
+			return si.Before(sj)
+		}
+		return incidents[i].IncidentID < incidents[j].IncidentID
+	})
+}
+
+// MatchIncident returns true if the incident matches the restrictions of the request.
+func MatchIncident(request *nwpd.ListIncidentsRequest, inc *nwpd.Incident) bool {
+	if request.OpenOnly && inc.End != nil {
+		return false
+	}
+	if request.Start != nil && inc.End != nil && inc.End.AsTime().Before(request.Start.AsTime()) {
+		return false
+	}
+	if len(request.RestrictToJobIDs) > 0 && !slices.Contains(request.RestrictToJobIDs, inc.JobID) {
+		return false
+	}
+	if len(request.RestrictToDestHosts) > 0 && !slices.Contains(request.RestrictToDestHosts, inc.DestHost) {
+		return false
+	}
+	return true
+}
+
+// WriteIncidentSnapshot writes the incidents to the file, replacing it atomically.
+func WriteIncidentSnapshot(filename string, snapshot *nwpd.IncidentSnapshot) error {
+	data, err := proto.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil { //  #nosec G306 -- no sensitive data
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// ReadIncidentSnapshot reads the incidents from the file.
+func ReadIncidentSnapshot(filename string) (*nwpd.IncidentSnapshot, error) {
+	data, err := os.ReadFile(filename) //  #nosec G304 -- no sensitive data
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &nwpd.IncidentSnapshot{}
+	if err := proto.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid incident file %s: %s", filename, err)
+	}
+	return snapshot, nil
+}
@@ -27,6 +27,45 @@ func ToIntObservation(obs *nwpd.Observation, idMap *StringIDMap, persistor IntSt
 	if err != nil {
 		return nil, err
 	}
+	var labels map[int64]int64
+	if len(obs.Labels) > 0 {
+		labels = make(map[int64]int64, len(obs.Labels))
+		for key, value := range obs.Labels {
+			ik, err := idMap.GetKey(persistor, key)
+			if err != nil {
+				return nil, err
+			}
+			iv, err := idMap.GetKey(persistor, value)
+			if err != nil {
+				return nil, err
+			}
+			labels[ik] = iv
+		}
+	}
+	var resultFields map[int64]string
+	if len(obs.ResultFields) > 0 {
+		resultFields = make(map[int64]string, len(obs.ResultFields))
+		for key, value := range obs.ResultFields {
+			ik, err := idMap.GetKey(persistor, key)
+			if err != nil {
+				return nil, err
+			}
+			// values are not mapped, as they may have a high cardinality
+			resultFields[ik] = value
+		}
+	}
+	iincident, err := idMap.GetKey(persistor, obs.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+	isz, err := idMap.GetKey(persistor, obs.SrcZone)
+	if err != nil {
+		return nil, err
+	}
+	idz, err := idMap.GetKey(persistor, obs.DestZone)
+	if err != nil {
+		return nil, err
+	}
 	return &nwpd.IntObservation{
 		SrcHost:        is,
 		DestHost:       id,
@@ -35,6 +74,12 @@ func ToIntObservation(obs *nwpd.Observation, idMap *StringIDMap, persistor IntSt
 		TimeMillis:     obs.Timestamp.AsTime().UnixMilli(),
 		DurationMillis: int32(obs.Duration.AsDuration().Milliseconds()),
 		PeriodMillis:   int32(obs.Period.AsDuration().Milliseconds()),
+		Labels:         labels,
+		StaleEndpoint:  obs.StaleEndpoint,
+		IncidentID:     iincident,
+		ResultFields:   resultFields,
+		SrcZone:        isz,
+		DestZone:       idz,
 	}, nil
 }
 
@@ -58,14 +103,58 @@ func IntObsToObservation(o *nwpd.IntObservation, idMap *StringIDMap) (*nwpd.Obse
 	if o.PeriodMillis > 0 {
 		period = durationpb.New(time.Millisecond * time.Duration(o.PeriodMillis))
 	}
+	var labels map[string]string
+	if len(o.Labels) > 0 {
+		labels = make(map[string]string, len(o.Labels))
+		for ik, iv := range o.Labels {
+			key, err := idMap.GetValue(ik)
+			if err != nil {
+				return nil, err
+			}
+			value, err := idMap.GetValue(iv)
+			if err != nil {
+				return nil, err
+			}
+			labels[key] = value
+		}
+	}
+	var resultFields map[string]string
+	if len(o.ResultFields) > 0 {
+		resultFields = make(map[string]string, len(o.ResultFields))
+		for ik, value := range o.ResultFields {
+			key, err := idMap.GetValue(ik)
+			if err != nil {
+				return nil, err
+			}
+			resultFields[key] = value
+		}
+	}
+	incidentID, err := idMap.GetValue(o.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+	srcZone, err := idMap.GetValue(o.SrcZone)
+	if err != nil {
+		return nil, err
+	}
+	destZone, err := idMap.GetValue(o.DestZone)
+	if err != nil {
+		return nil, err
+	}
 	return &nwpd.Observation{
-		JobID:     sj,
-		SrcHost:   ss,
-		DestHost:  sd,
-		Timestamp: timestamppb.New(time.UnixMilli(o.TimeMillis)),
-		Duration:  duration,
-		Ok:        o.Ok,
-		Period:    period,
+		JobID:         sj,
+		SrcHost:       ss,
+		DestHost:      sd,
+		Timestamp:     timestamppb.New(time.UnixMilli(o.TimeMillis)),
+		Duration:      duration,
+		Ok:            o.Ok,
+		Period:        period,
+		Labels:        labels,
+		StaleEndpoint: o.StaleEndpoint,
+		IncidentID:    incidentID,
+		ResultFields:  resultFields,
+		SrcZone:       srcZone,
+		DestZone:      destZone,
 	}, nil
 }
 
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// latencyMinBound is the upper bound of the first latency bucket.
+	latencyMinBound = 100 * time.Microsecond
+	// latencyBucketFactor is the growth factor of the bucket bounds. It limits the relative error of a percentile to 20%.
+	latencyBucketFactor = 1.2
+	// latencyBuckets is the number of buckets. The last regular bound is about 3 minutes, larger durations are counted in the last bucket.
+	latencyBuckets = 80
+)
+
+var latencyBucketBounds = func() [latencyBuckets]time.Duration {
+	var bounds [latencyBuckets]time.Duration
+	bound := float64(latencyMinBound)
+	for i := range bounds {
+		bounds[i] = time.Duration(bound)
+		bound *= latencyBucketFactor
+	}
+	return bounds
+}()
+
+// LatencyHistogram counts durations in exponential buckets, so that percentiles can be estimated with bounded memory.
+// The size is fixed (about 340 bytes) and independent of the number of counted durations.
+type LatencyHistogram struct {
+	counts [latencyBuckets]uint32
+	total  uint32
+	max    time.Duration
+	sum    time.Duration
+}
+
+func latencyBucketOf(d time.Duration) int {
+	if d <= latencyMinBound {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(d)/float64(latencyMinBound)) / math.Log(latencyBucketFactor)))
+	i = max(0, min(i, latencyBuckets-1))
+	// correct rounding errors of the logarithm
+	for i > 0 && latencyBucketBounds[i-1] >= d {
+		i--
+	}
+	for i < latencyBuckets-1 && latencyBucketBounds[i] < d {
+		i++
+	}
+	return i
+}
+
+// Add counts the duration.
+func (h *LatencyHistogram) Add(d time.Duration) {
+	if h.total == math.MaxUint32 {
+		return
+	}
+	h.counts[latencyBucketOf(d)]++
+	h.total++
+	h.max = max(h.max, d)
+	h.sum += d
+}
+
+// Merge adds the counts of the other histogram.
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	if uint64(h.total)+uint64(other.total) > math.MaxUint32 {
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	h.max = max(h.max, other.max)
+	h.sum += other.sum
+}
+
+// Count returns the number of counted durations.
+func (h *LatencyHistogram) Count() int {
+	return int(h.total)
+}
+
+// Mean returns the mean of the counted durations or 0 for an empty histogram.
+func (h *LatencyHistogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.total)
+}
+
+// Quantile returns the estimated q-quantile (0 < q <= 1), i.e. the upper bound of the bucket containing it,
+// limited by the maximum counted duration. It returns 0 for an empty histogram.
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint32(math.Ceil(q * float64(h.total)))
+	var cumulative uint32
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative >= rank {
+			if i == latencyBuckets-1 {
+				return h.max
+			}
+			return min(latencyBucketBounds[i], h.max)
+		}
+	}
+	return h.max
+}
+
+// LatencyPercentiles are the estimated percentiles of the durations of the successful observations.
+type LatencyPercentiles struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// Percentiles returns the estimated p50, p95 and p99 of the histogram.
+func (h *LatencyHistogram) Percentiles() LatencyPercentiles {
+	return LatencyPercentiles{
+		P50: h.Quantile(0.50),
+		P95: h.Quantile(0.95),
+		P99: h.Quantile(0.99),
+	}
+}
+
+func (p LatencyPercentiles) String() string {
+	return fmt.Sprintf("p50=%s p95=%s p99=%s", formatMillis(p.P50), formatMillis(p.P95), formatMillis(p.P99))
+}
+
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
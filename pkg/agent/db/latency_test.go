@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("latency histogram", func() {
+	withinError := func(actual, expected time.Duration) {
+		Expect(actual).To(BeNumerically(">=", expected))
+		Expect(float64(actual)).To(BeNumerically("<=", float64(expected)*latencyBucketFactor))
+	}
+
+	It("estimates the percentiles within the bucket error", func() {
+		h := &LatencyHistogram{}
+		for i := 1; i <= 1000; i++ {
+			h.Add(time.Duration(i) * time.Millisecond)
+		}
+		Expect(h.Count()).To(Equal(1000))
+		p := h.Percentiles()
+		withinError(p.P50, 500*time.Millisecond)
+		withinError(p.P95, 950*time.Millisecond)
+		withinError(p.P99, 990*time.Millisecond)
+		Expect(h.Quantile(1)).To(Equal(1000 * time.Millisecond))
+	})
+
+	It("reveals the tail latency hidden by the mean", func() {
+		h := &LatencyHistogram{}
+		for i := 0; i < 980; i++ {
+			h.Add(1 * time.Millisecond)
+		}
+		for i := 0; i < 20; i++ {
+			h.Add(800 * time.Millisecond)
+		}
+		p := h.Percentiles()
+		withinError(p.P50, 1*time.Millisecond)
+		withinError(p.P95, 1*time.Millisecond)
+		Expect(p.P99).To(Equal(800 * time.Millisecond))
+		// (980*1ms + 20*800ms) / 1000
+		Expect(h.Mean()).To(Equal(16980 * time.Microsecond))
+	})
+
+	It("assigns the durations to the buckets", func() {
+		Expect(latencyBucketOf(0)).To(Equal(0))
+		Expect(latencyBucketOf(latencyMinBound)).To(Equal(0))
+		for i := 1; i < latencyBuckets; i++ {
+			Expect(latencyBucketOf(latencyBucketBounds[i])).To(Equal(i))
+			Expect(latencyBucketOf(latencyBucketBounds[i-1] + 1)).To(Equal(i))
+		}
+		Expect(latencyBucketOf(1 * time.Hour)).To(Equal(latencyBuckets - 1))
+	})
+})
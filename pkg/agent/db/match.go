@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+// observationMatcher selects observations by the filters of the list options, independent of time range, cursor and order.
+type observationMatcher struct {
+	// edge selects by the values of the edge, i.e. job ID, hosts and job labels
+	edge func(obs *nwpd.Observation) bool
+	// observation selects by the status, duration and result of the observation
+	observation func(obs *nwpd.Observation) bool
+}
+
+func newObservationMatcher(options nwpd.ListObservationsOptions) (*observationMatcher, error) {
+	if options.MinDuration < 0 {
+		return nil, &nwpd.InvalidFilterError{Field: "minDuration", Err: fmt.Errorf("must not be negative")}
+	}
+	jobIDFilter := createFilter(options.FilterJobIDs)
+	srcHostFilter := createFilter(options.FilterSrcHosts)
+	descHostFilter := createFilter(options.FilterDestHosts)
+	jobIDRegexFilter, err := createRegexFilter("jobIDRegex", options.FilterJobIDRegex)
+	if err != nil {
+		return nil, err
+	}
+	srcHostRegexFilter, err := createRegexFilter("srcHostRegex", options.FilterSrcHostRegex)
+	if err != nil {
+		return nil, err
+	}
+	destHostRegexFilter, err := createRegexFilter("destHostRegex", options.FilterDestHostRegex)
+	if err != nil {
+		return nil, err
+	}
+	resultFilter, err := createResultFilter(options.FilterResultRegex)
+	if err != nil {
+		return nil, err
+	}
+	edge := func(obs *nwpd.Observation) bool {
+		if !jobIDFilter(obs.JobID) || !srcHostFilter(obs.SrcHost) || !descHostFilter(obs.DestHost) {
+			return false
+		}
+		if !jobIDRegexFilter(obs.JobID) || !srcHostRegexFilter(obs.SrcHost) || !destHostRegexFilter(obs.DestHost) {
+			return false
+		}
+		for key, value := range options.FilterLabels {
+			if v, ok := obs.Labels[key]; !ok || v != value {
+				return false
+			}
+		}
+		return true
+	}
+	observation := func(obs *nwpd.Observation) bool {
+		if obs.Ok && options.FailuresOnly {
+			return false
+		}
+		if options.MinDuration > 0 && obs.Duration.AsDuration() <= options.MinDuration {
+			return false
+		}
+		if !resultFilter(obs) {
+			return false
+		}
+		for key, value := range options.FilterResultFields {
+			if v, ok := obs.ResultFields[key]; !ok || v != value {
+				return false
+			}
+		}
+		if options.Filter != nil && !options.Filter(obs) {
+			return false
+		}
+		return true
+	}
+	return &observationMatcher{edge: edge, observation: observation}, nil
+}
+
+// NewObservationMatcher returns a function selecting observations by the filters of the list options, e.g. for
+// observations not read from the record files. The time range, cursor, order, limit and failure streaks are ignored.
+func NewObservationMatcher(options nwpd.ListObservationsOptions) (func(obs *nwpd.Observation) bool, error) {
+	m, err := newObservationMatcher(options)
+	if err != nil {
+		return nil, err
+	}
+	return func(obs *nwpd.Observation) bool {
+		return m.edge(obs) && m.observation(obs)
+	}, nil
+}
+
+type filterFunc func(key string) bool
+
+func all(_ string) bool { return true }
+
+func createFilter(keys []string) filterFunc {
+	if keys == nil {
+		return all
+	}
+	m := common.StringSet{}
+	m.AddAll(keys...)
+	return m.Contains
+}
+
+func createRegexFilter(field, expr string) (filterFunc, error) {
+	if expr == "" {
+		return all, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, &nwpd.InvalidFilterError{Field: field, Err: err}
+	}
+	return re.MatchString, nil
+}
+
+func createResultFilter(expr string) (func(obs *nwpd.Observation) bool, error) {
+	if expr == "" {
+		return func(_ *nwpd.Observation) bool { return true }, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, &nwpd.InvalidFilterError{Field: "resultPattern", Err: err}
+	}
+	return func(obs *nwpd.Observation) bool {
+		return re.MatchString(resultTextOf(obs))
+	}, nil
+}
+
+// resultTextOf returns the result of the observation. As the result is not persisted, the result fields formatted
+// as `key=value` pairs sorted by key are returned for observations read from the record files.
+func resultTextOf(obs *nwpd.Observation) string {
+	if obs.Result != "" || len(obs.ResultFields) == 0 {
+		return obs.Result
+	}
+	keys := make([]string, 0, len(obs.ResultFields))
+	for key := range obs.ResultFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(key + "=" + obs.ResultFields[key])
+	}
+	return sb.String()
+}
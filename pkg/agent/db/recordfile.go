@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+const (
+	// RecordFileSuffix is the suffix of the uncompressed observation record files.
+	RecordFileSuffix = ".records"
+	// CompressedRecordFileSuffix is the suffix of the gzip compressed observation record files.
+	CompressedRecordFileSuffix = ".records.gz"
+)
+
+// CorruptFileSuffix is appended to the name of a record file which cannot be appended to because of a corrupt record.
+const CorruptFileSuffix = ".corrupt"
+
+var (
+	// errInvalidRecord is wrapped by the errors of records which cannot be decoded.
+	errInvalidRecord = errors.New("invalid record")
+	// errChecksumMismatch is returned for a record with a checksum not matching its data.
+	errChecksumMismatch = errors.New("checksum mismatch")
+	// errStopCopy stops copying the records of a file.
+	errStopCopy = errors.New("stop copy")
+)
+
+// CorruptRecordError is returned if a record of a record file is corrupt. The records before have been read successfully,
+// the records after cannot be read, as the start of the next record is unknown.
+type CorruptRecordError struct {
+	Filename string
+	// Index is the number of records before the corrupt record.
+	Index int
+	// Offset is the position of the corrupt record in the uncompressed data of the file.
+	Offset int64
+	Err    error
+}
+
+func (e *CorruptRecordError) Error() string {
+	return fmt.Sprintf("corrupt record %d in file %s at offset %d: %s", e.Index, e.Filename, e.Offset, e.Err)
+}
+
+func (e *CorruptRecordError) Unwrap() error {
+	return e.Err
+}
+
+// IsCorruptRecordError returns true if the error is or wraps a CorruptRecordError.
+func IsCorruptRecordError(err error) bool {
+	var corruptErr *CorruptRecordError
+	return errors.As(err, &corruptErr)
+}
+
+func unknownMarkerError(marker byte) error {
+	return fmt.Errorf("%w: unknown marker %d", errInvalidRecord, marker)
+}
+
+// isCorruptData returns true if the error is caused by the data read instead of the file access.
+func isCorruptData(err error) bool {
+	var flateErr flate.CorruptInputError
+	return errors.Is(err, errInvalidRecord) || errors.Is(err, errChecksumMismatch) ||
+		errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) || errors.As(err, &flateErr)
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// gzipMagic are the first bytes of a gzip compressed file. An uncompressed record file starts with a record marker instead.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsRecordFile returns true if the file name has the suffix of an uncompressed or compressed record file.
+func IsRecordFile(name string) bool {
+	return strings.HasSuffix(name, RecordFileSuffix) || strings.HasSuffix(name, CompressedRecordFileSuffix)
+}
+
+// recordFilename returns the name of the record file of the hour.
+func recordFilename(directory, prefix string, hour time.Time, compressed bool) string {
+	suffix := RecordFileSuffix
+	if compressed {
+		suffix = CompressedRecordFileSuffix
+	}
+	return fmt.Sprintf("%s/%s-%s%s", directory, prefix, hour.Format("2006-01-02-15"), suffix)
+}
+
+// recordVisitor is called for each complete record of a record file.
+type recordVisitor func(marker byte, value []byte) error
+
+// readRecordFile calls the visitor for the records of the file, which is decompressed if it starts with the gzip magic bytes.
+// An incomplete record at the end of the file is ignored, as it is either still being written or the writer has been
+// stopped abruptly. In this case, complete is false.
+// A *CorruptRecordError is returned for a record with a checksum mismatch or if the visitor fails with errInvalidRecord.
+func readRecordFile(filename string, visitor recordVisitor) (complete bool, err error) {
+	return readRecordFileFrom(filename, indexEntry{}, 0, visitor)
+}
+
+// readRecordFileFrom calls the visitor for the records of the file starting at the position, see readRecordFile.
+// Positions other than the start of the file are only supported for uncompressed files. If limit is set, the data of the file
+// after this size is ignored.
+func readRecordFileFrom(filename string, from indexEntry, limit int64, visitor recordVisitor) (complete bool, err error) {
+	f, err := os.Open(filename) // #nosec G304 -- record file in the output directory
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var src io.Reader = f
+	if limit > 0 {
+		src = io.LimitReader(f, limit-from.offset)
+	}
+	var r io.Reader
+	if from.offset > 0 {
+		if _, err := f.Seek(from.offset, io.SeekStart); err != nil {
+			return false, err
+		}
+		r = bufio.NewReader(src)
+	} else if r, err = newRecordReader(src); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// gzip header not written completely
+			return false, nil
+		}
+		if isCorruptData(err) {
+			return false, &CorruptRecordError{Filename: filename, Err: err}
+		}
+		return false, err
+	}
+	cr := &countingReader{r: r, n: from.offset}
+	for index := int(from.records); ; index++ {
+		offset := cr.n
+		marker, value, err := readRecord(cr)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		if err == nil && value != nil {
+			if err = visitor(marker, value); err != nil && !isCorruptData(err) {
+				// error of the caller
+				return false, err
+			}
+		}
+		if err != nil {
+			if isCorruptData(err) {
+				return false, &CorruptRecordError{Filename: filename, Index: index, Offset: offset, Err: err}
+			}
+			return false, err
+		}
+		if value == nil {
+			return true, nil
+		}
+	}
+}
+
+// newRecordReader returns a buffered reader of the records, which decompresses gzip compressed files.
+// Compressed files may consist of multiple gzip members, one for each time the file has been opened by the writer.
+func newRecordReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil || !bytes.Equal(magic, gzipMagic) {
+		// uncompressed file written by older agents or with compression disabled
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// rewriteRecordFile replaces the record file by a copy without the incomplete record at its end,
+// so that further records can be appended.
+func rewriteRecordFile(filename string, compressed bool) error {
+	_, err := copyRecordFile(filename, filename, compressed, -1)
+	return err
+}
+
+// copyRecordFile writes the readable records of the source file to the destination file and returns the number of records.
+// The records after a corrupt record are skipped. If limit is not negative, at most limit records are copied.
+func copyRecordFile(src, dst string, compressed bool, limit int) (int, error) {
+	tmp := dst + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640) //  #nosec G302 G304 -- no sensitive data
+	if err != nil {
+		return 0, err
+	}
+	var out io.Writer = f
+	var zw *gzip.Writer
+	if compressed {
+		zw = gzip.NewWriter(f)
+		out = zw
+	}
+	count := 0
+	_, err = readRecordFile(src, func(marker byte, value []byte) error {
+		if limit >= 0 && count >= limit {
+			return errStopCopy
+		}
+		count++
+		return writeRecord(out, marker, value)
+	})
+	if IsCorruptRecordError(err) || err == errStopCopy {
+		err = nil
+	}
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	return count, os.Rename(tmp, dst)
+}
+
+// repairCorruptFile keeps the record file with a corrupt record as *.corrupt for inspection and replaces it by a copy of the
+// records before the corrupt one. A file without any readable record is irreparable and only renamed.
+func (w *obsWriter) repairCorruptFile(filename string, cause *CorruptRecordError) error {
+	corrupt := filename + CorruptFileSuffix
+	if err := os.Rename(filename, corrupt); err != nil {
+		return fmt.Errorf("renaming corrupt file %s failed: %s", filename, err)
+	}
+	// records without checksum written by older versions are only detected as corrupt on decoding
+	count, err := copyRecordFile(corrupt, filename, strings.HasSuffix(filename, CompressedRecordFileSuffix), cause.Index)
+	if err != nil {
+		return fmt.Errorf("repairing corrupt file %s failed: %s", filename, err)
+	}
+	if count == 0 {
+		_ = os.Remove(filename)
+		w.log.Warnf("renamed irreparable record file to %s: %s", path.Base(corrupt), cause)
+		return nil
+	}
+	w.log.Warnf("repaired record file %s with %d readable records, original kept as %s: %s", path.Base(filename), count, path.Base(corrupt), cause)
+	return nil
+}
+
+// validateNewestFile checks the records of the newest record file, which may be damaged by a crash of the node.
+// The file is repaired if it has a corrupt record. An incomplete record at its end is removed on appending.
+func (w *obsWriter) validateNewestFile() {
+	files, err := w.recordFiles()
+	if err != nil || len(files) == 0 {
+		return
+	}
+	filename := path.Join(w.directory, files[len(files)-1].name)
+	noop := func(_ *nwpd.Observation) error { return nil }
+	err = IterateRecordFileWithJobRuns(filename, noop, func(_ *nwpd.JobRunRecord) error { return nil })
+	var corruptErr *CorruptRecordError
+	switch {
+	case err == nil:
+	case errors.As(err, &corruptErr):
+		if err := w.repairCorruptFile(filename, corruptErr); err != nil {
+			w.log.Warn(err)
+		}
+	default:
+		w.log.Warnf("validating file %s failed: %s", filename, err)
+	}
+}
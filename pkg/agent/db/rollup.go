@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// DefaultRollupRetentionDays is the default number of days to keep daily rollups.
+	DefaultRollupRetentionDays = 400
+
+	rollupSubdirectory = "rollups"
+	rollupDateFormat   = "2006-01-02"
+)
+
+// DestClassifier maps a destination host to its destination class.
+type DestClassifier func(destHost string) string
+
+// RollupStore computes and stores daily rollups of the observation record files.
+// It is safe for concurrent use: updates are serialized and the rollup files are replaced atomically.
+type RollupStore struct {
+	// lock serializes the updates.
+	lock           sync.Mutex
+	log            logrus.FieldLogger
+	recordsDir     string
+	directory      string
+	prefix         string
+	srcHost        string
+	retentionHours int
+	retentionDays  int
+}
+
+// NewRollupStore creates a store for daily rollups in a subdirectory of the records directory.
+func NewRollupStore(log logrus.FieldLogger, recordsDir, prefix, srcHost string, retentionHours, retentionDays int) (*RollupStore, error) {
+	if retentionDays <= 0 {
+		retentionDays = DefaultRollupRetentionDays
+	}
+	directory := path.Join(recordsDir, rollupSubdirectory)
+	if err := os.MkdirAll(directory, 0o750); err != nil { //  #nosec G302 -- no sensitive data
+		return nil, err
+	}
+	return &RollupStore{
+		log:            log,
+		recordsDir:     recordsDir,
+		directory:      directory,
+		prefix:         prefix,
+		srcHost:        srcHost,
+		retentionHours: retentionHours,
+		retentionDays:  retentionDays,
+	}, nil
+}
+
+func startOfDayUTC(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *RollupStore) filename(day time.Time) string {
+	return fmt.Sprintf("%s/%s-%s.rollup", s.directory, s.prefix, day.Format(rollupDateFormat))
+}
+
+// Update computes the rollups of all completed days which are fully covered by the retained record files and
+// not yet stored. A day is fully covered if the oldest record file starts at or before its begin, i.e. the rollup
+// of a day with record files already deleted by the retention or with the agent started during it is never stored.
+// Outdated rollups are deleted.
+func (s *RollupStore) Update(now time.Time, classify DestClassifier) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	today := startOfDayUTC(now)
+	days := (s.retentionHours + 23) / 24
+	if days < 1 {
+		days = 1
+	}
+	oldest, ok, err := s.oldestRecordHour()
+	if err != nil {
+		return err
+	}
+	for day := today.AddDate(0, 0, -days); day.Before(today); day = day.AddDate(0, 0, 1) {
+		if !ok || oldest.After(day) {
+			continue
+		}
+		filename := s.filename(day)
+		if _, err := os.Stat(filename); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		rollup, err := ComputeDailyRollup(s.recordsDir, s.prefix, s.srcHost, day, classify)
+		if err != nil {
+			return fmt.Errorf("computing rollup for %s failed: %s", day.Format(rollupDateFormat), err)
+		}
+		if err := writeRollup(filename, rollup); err != nil {
+			return err
+		}
+		s.log.Infof("stored daily rollup %s", filename)
+	}
+	s.cleanOldRollups(today)
+	return nil
+}
+
+// oldestRecordHour returns the hour of the oldest record file of the store prefix, false if there is none.
+func (s *RollupStore) oldestRecordHour() (time.Time, bool, error) {
+	filenames, err := GetAnyRecordFiles(s.recordsDir, false)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	var oldest time.Time
+	found := false
+	for _, filename := range filenames {
+		prefix, hour, ok := recordFilePrefix(path.Base(filename))
+		if !ok || prefix != s.prefix {
+			continue
+		}
+		if !found || hour.Before(oldest) {
+			oldest, found = hour, true
+		}
+	}
+	return oldest, found, nil
+}
+
+func (s *RollupStore) cleanOldRollups(today time.Time) {
+	limit := today.AddDate(0, 0, -s.retentionDays).Format(rollupDateFormat)
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		s.log.Warnf("cannot read directory %s: %s", s.directory, err)
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, s.prefix+"-") || !strings.HasSuffix(name, ".rollup") {
+			continue
+		}
+		date := strings.TrimSuffix(strings.TrimPrefix(name, s.prefix+"-"), ".rollup")
+		if date < limit {
+			filename := path.Join(s.directory, name)
+			if err := os.Remove(filename); err != nil {
+				s.log.Warnf("cannot delete file %s: %s", filename, err)
+			}
+		}
+	}
+}
+
+// ListDailyRollups returns the rollups for all days in the given range.
+// Days without stored rollup are returned with `missing` flag.
+func (s *RollupStore) ListDailyRollups(start, end time.Time) ([]*nwpd.DailyRollup, error) {
+	var result []*nwpd.DailyRollup
+	for day := startOfDayUTC(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+		rollup, err := readRollup(s.filename(day))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			rollup = &nwpd.DailyRollup{
+				Date:    day.Format(rollupDateFormat),
+				SrcHost: s.srcHost,
+				Missing: true,
+			}
+		}
+		result = append(result, rollup)
+	}
+	return result, nil
+}
+
+type rollupKey struct {
+	jobID     string
+	destClass string
+}
+
+type rollupCounter struct {
+	okCount    int32
+	notOkCount int32
+	latency    LatencyHistogram
+}
+
+// ComputeDailyRollup computes the rollup for the given day from the record files.
+func ComputeDailyRollup(directory, prefix, srcHost string, day time.Time, classify DestClassifier) (*nwpd.DailyRollup, error) {
+	start := startOfDayUTC(day)
+	end := start.AddDate(0, 0, 1)
+	files, err := GetRecordFiles(directory, prefix, start, end.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+
+	counters := map[rollupKey]*rollupCounter{}
+	for _, file := range files {
+		err := IterateRecordFile(file, func(obs *nwpd.Observation) error {
+			if t := obs.Timestamp.AsTime(); t.Before(start) || !t.Before(end) {
+				return nil
+			}
+			key := rollupKey{jobID: obs.JobID, destClass: classify(obs.DestHost)}
+			counter := counters[key]
+			if counter == nil {
+				counter = &rollupCounter{}
+				counters[key] = counter
+			}
+			if obs.Ok {
+				counter.okCount++
+				if obs.Duration != nil {
+					counter.latency.Add(obs.Duration.AsDuration())
+				}
+			} else {
+				counter.notOkCount++
+			}
+			return nil
+		})
+		if err != nil && !IsCorruptRecordError(err) {
+			// the observations before a corrupt record are counted
+			return nil, err
+		}
+	}
+
+	rollup := &nwpd.DailyRollup{
+		Date:    start.Format(rollupDateFormat),
+		SrcHost: srcHost,
+		Missing: len(counters) == 0,
+	}
+	for key, counter := range counters {
+		entry := &nwpd.RollupEntry{
+			JobID:      key.jobID,
+			DestClass:  key.destClass,
+			OkCount:    counter.okCount,
+			NotOkCount: counter.notOkCount,
+		}
+		if counter.latency.Count() > 0 {
+			entry.P50Duration = durationpb.New(counter.latency.Quantile(0.50))
+			entry.P90Duration = durationpb.New(counter.latency.Quantile(0.90))
+			entry.P99Duration = durationpb.New(counter.latency.Quantile(0.99))
+		}
+		rollup.Entries = append(rollup.Entries, entry)
+	}
+	sort.Slice(rollup.Entries, func(i, j int) bool {
+		if rollup.Entries[i].JobID != rollup.Entries[j].JobID {
+			return rollup.Entries[i].JobID < rollup.Entries[j].JobID
+		}
+		return rollup.Entries[i].DestClass < rollup.Entries[j].DestClass
+	})
+	return rollup, nil
+}
+
+func writeRollup(filename string, rollup *nwpd.DailyRollup) error {
+	data, err := proto.Marshal(rollup)
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil { //  #nosec G306 -- no sensitive data
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func readRollup(filename string) (*nwpd.DailyRollup, error) {
+	data, err := os.ReadFile(filename) //  #nosec G304 -- no sensitive data
+	if err != nil {
+		return nil, err
+	}
+	rollup := &nwpd.DailyRollup{}
+	if err := proto.Unmarshal(data, rollup); err != nil {
+		return nil, fmt.Errorf("invalid rollup file %s: %s", filename, err)
+	}
+	return rollup, nil
+}
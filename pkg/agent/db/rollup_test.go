@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("rollup", func() {
+	var (
+		dir      string
+		log      = logrus.NewEntry(logrus.StandardLogger())
+		classify = func(destHost string) string {
+			if destHost == "api" {
+				return "kube-apiserver"
+			}
+			return "node"
+		}
+	)
+
+	writeObservations := func(observations ...*nwpd.Observation) {
+		writer, err := NewObsWriter(log, dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		for _, obs := range observations {
+			writer.Add(obs)
+		}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(nwpd.ListObservationsOptions{Start: time.Now().Add(-time.Hour)})
+		}).Should(HaveLen(len(observations)))
+		// writes the batched observations
+		writer.Stop()
+	}
+
+	newObs := func(destHost string, ok bool, millis int) *nwpd.Observation {
+		return &nwpd.Observation{
+			JobID:     "job1",
+			SrcHost:   "node1",
+			DestHost:  destHost,
+			Timestamp: timestamppb.Now(),
+			Duration:  durationpb.New(time.Duration(millis) * time.Millisecond),
+			Ok:        ok,
+			Period:    durationpb.New(time.Second),
+		}
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("computes counts and percentiles per job and destination class", func() {
+		var observations []*nwpd.Observation
+		for i := 1; i <= 10; i++ {
+			observations = append(observations, newObs("node2", true, i*10))
+		}
+		observations = append(observations, newObs("node3", false, 0), newObs("api", true, 5))
+		writeObservations(observations...)
+
+		rollup, err := ComputeDailyRollup(dir, "test", "node1", time.Now(), classify)
+		Expect(err).To(BeNil())
+		Expect(rollup.Missing).To(BeFalse())
+		Expect(rollup.Date).To(Equal(time.Now().UTC().Format("2006-01-02")))
+		Expect(rollup.Entries).To(HaveLen(2))
+		Expect(rollup.Entries[0].DestClass).To(Equal("kube-apiserver"))
+		node := rollup.Entries[1]
+		Expect(node.DestClass).To(Equal("node"))
+		Expect(node.OkCount).To(Equal(int32(10)))
+		Expect(node.NotOkCount).To(Equal(int32(1)))
+		withinError := func(actual *durationpb.Duration, expected time.Duration) {
+			Expect(actual.AsDuration()).To(BeNumerically(">=", expected))
+			Expect(float64(actual.AsDuration())).To(BeNumerically("<=", float64(expected)*latencyBucketFactor))
+		}
+		withinError(node.P50Duration, 50*time.Millisecond)
+		withinError(node.P90Duration, 90*time.Millisecond)
+		Expect(node.P99Duration.AsDuration()).To(Equal(100 * time.Millisecond))
+	})
+
+	It("stores the completed days covered by the record files and reports missing days explicitly", func() {
+		store, err := NewRollupStore(log, dir, "test", "node1", 96, 0)
+		Expect(err).To(BeNil())
+		now := time.Now()
+		today := startOfDayUTC(now)
+		// the agent has been started during the day before yesterday
+		Expect(os.WriteFile(recordFilename(dir, "test", today.AddDate(0, 0, -2).Add(12*time.Hour), false), nil, 0o640)).To(Succeed())
+		Expect(store.Update(now, classify)).To(Succeed())
+
+		rollups, err := store.ListDailyRollups(now.AddDate(0, 0, -4), now)
+		Expect(err).To(BeNil())
+		Expect(rollups).To(HaveLen(5))
+		for _, rollup := range rollups {
+			Expect(rollup.Missing).To(BeTrue())
+			Expect(rollup.SrcHost).To(Equal("node1"))
+		}
+		Expect(store.filename(today.AddDate(0, 0, -3))).NotTo(BeAnExistingFile())
+		Expect(store.filename(today.AddDate(0, 0, -2))).NotTo(BeAnExistingFile())
+		Expect(store.filename(today.AddDate(0, 0, -1))).To(BeAnExistingFile())
+		Expect(store.filename(today)).NotTo(BeAnExistingFile())
+	})
+
+	It("stores no rollups without record files", func() {
+		store, err := NewRollupStore(log, dir, "test", "node1", 48, 0)
+		Expect(err).To(BeNil())
+		now := time.Now()
+		Expect(store.Update(now, classify)).To(Succeed())
+		Expect(store.filename(startOfDayUTC(now.AddDate(0, 0, -1)))).NotTo(BeAnExistingFile())
+	})
+
+	It("serializes concurrent updates", func() {
+		store, err := NewRollupStore(log, dir, "test", "node1", 96, 0)
+		Expect(err).To(BeNil())
+		now := time.Now()
+		today := startOfDayUTC(now)
+		Expect(os.WriteFile(recordFilename(dir, "test", today.AddDate(0, 0, -4), false), nil, 0o640)).To(Succeed())
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- store.Update(now, classify)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			Expect(err).To(BeNil())
+		}
+		entries, err := os.ReadDir(store.directory)
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(4))
+		for _, entry := range entries {
+			Expect(entry.Name()).To(HaveSuffix(".rollup"))
+		}
+	})
+})
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+// streakEdge identifies the observations of a job for a source and destination.
+type streakEdge struct {
+	jobID    string
+	srcHost  string
+	destHost string
+}
+
+// failureStreak is a run of consecutive failures of an edge.
+type failureStreak struct {
+	length int
+	// ended is true if the streak has been ended by a successful observation or the end of the listing
+	ended bool
+}
+
+type streakEntry struct {
+	obs    *nwpd.Observation
+	streak *failureStreak
+}
+
+// failureStreakFilter passes the failed observations being part of a run of at least minLength consecutive failures
+// of the same edge. The observations must be added ordered by timestamp. They are passed in the same order, so a failure
+// is held back until its streak is long enough or has ended, together with all failures added after it.
+type failureStreakFilter struct {
+	minLength int
+	current   map[streakEdge]*failureStreak
+	pending   []streakEntry
+}
+
+func newFailureStreakFilter(minLength int) *failureStreakFilter {
+	return &failureStreakFilter{minLength: minLength, current: map[streakEdge]*failureStreak{}}
+}
+
+// add adds the next observation and returns the failures passing the filter now.
+func (f *failureStreakFilter) add(obs *nwpd.Observation) nwpd.Observations {
+	edge := streakEdge{jobID: obs.JobID, srcHost: obs.SrcHost, destHost: obs.DestHost}
+	streak := f.current[edge]
+	if obs.Ok {
+		if streak != nil {
+			streak.ended = true
+			delete(f.current, edge)
+		}
+		return f.release()
+	}
+	if streak == nil {
+		streak = &failureStreak{}
+		f.current[edge] = streak
+	}
+	streak.length++
+	f.pending = append(f.pending, streakEntry{obs: obs, streak: streak})
+	return f.release()
+}
+
+// flush ends all streaks and returns the remaining failures passing the filter.
+func (f *failureStreakFilter) flush() nwpd.Observations {
+	for edge, streak := range f.current {
+		streak.ended = true
+		delete(f.current, edge)
+	}
+	return f.release()
+}
+
+// release removes the leading failures with known outcome from the pending ones. It returns those of long enough streaks.
+func (f *failureStreakFilter) release() nwpd.Observations {
+	var result nwpd.Observations
+	n := 0
+	for _, entry := range f.pending {
+		long := entry.streak.length >= f.minLength
+		if !long && !entry.streak.ended {
+			break
+		}
+		if long {
+			result = append(result, entry.obs)
+		}
+		n++
+	}
+	f.pending = f.pending[n:]
+	return result
+}
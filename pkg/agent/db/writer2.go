@@ -5,19 +5,26 @@
 package db
 
 import (
+	"bufio"
+	"compress/gzip"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"io/fs"
+	"math"
 	"os"
 	"path"
-	"sort"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/gardener/network-problem-detector/pkg/common"
 	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
 )
@@ -27,25 +34,106 @@ type obsWriter struct {
 	directory      string
 	prefix         string
 	retentionHours int
+	compress       bool
+	maxDiskUsage   atomic.Int64
+	// otherFilesSize is the total size of the record files except the current file.
+	otherFilesSize atomic.Int64
 	currentFile    atomic.Value
 	obsChan        chan *nwpd.Observation
+	runChan        chan *nwpd.JobRunRecord
 	done           chan struct{}
+	flushed        chan struct{}
 	ticker         *time.Ticker
+	// stalled is set if a record has been dropped because the buffer has stayed full for addTimeout.
+	stalled atomic.Bool
+	// batchSize is the number of batched records which are written without waiting for the flush interval.
+	batchSize atomic.Int64
+	// batchLock protects the batch and its writing, so that listing sees each record either in the batch or in the file.
+	batchLock sync.Mutex
+	// batch are the records received but not written yet.
+	batch []batchRecord
+	// compressRotated if set, the uncompressed record files of past hours are compressed, see CompressRotatedFiles.
+	compressRotated bool
+	// compressing is set while the rotated files are compressed.
+	compressing atomic.Bool
+	// filesLock protects collecting the record files of a list query from the replacement of files by their compressed version.
+	filesLock sync.Mutex
+	// listStartLimit is the start time limit of listed observations (last 24 hours if not set).
+	listStartLimit time.Time
+	indexLock      sync.Mutex
+	// indexes are the indexes of the record files except the current file
+	indexes map[string]*fileIndex
 }
 
 var _ nwpd.ObservationWriter = &obsWriter{}
 
+const (
+	// DefaultWriteFlushInterval is the default interval for writing the batched records and syncing the current file.
+	DefaultWriteFlushInterval = 5 * time.Second
+	// DefaultWriteBatchSize is the default number of batched records which are written before the flush interval has elapsed.
+	DefaultWriteBatchSize = 500
+	// maxRecordDelay is the maximum time between the timestamp of an observation and writing it. An observation is written to
+	// the record file of the hour it is written, so that the files of the hours up to this delay after the end of a query are read.
+	maxRecordDelay = 5 * time.Minute
+	// addTimeout is the maximum time Add and AddJobRun wait for free buffer space, so that a stalled writer cannot block
+	// the processing of the observations.
+	addTimeout = 1 * time.Second
+)
+
+// batchRecord is either an observation or a job run record.
+type batchRecord struct {
+	obs *nwpd.Observation
+	run *nwpd.JobRunRecord
+}
+
+// WriteCounters are the counters of the records batched by the observation writer.
+type WriteCounters struct {
+	// Buffered counts the records added to the batch.
+	Buffered prometheus.Counter
+	// Flushed counts the records written to the record files.
+	Flushed prometheus.Counter
+	// Dropped counts the records which could not be written.
+	Dropped prometheus.Counter
+}
+
+// writeCounters are the counters updated by the observation writer or nil.
+var writeCounters atomic.Pointer[WriteCounters]
+
+// SetWriteCounters sets the counters updated with the number of buffered, flushed and dropped records of the observation writer.
+func SetWriteCounters(c WriteCounters) {
+	writeCounters.Store(&c)
+}
+
+func countWrite(buffered, flushed, dropped int) {
+	if c := writeCounters.Load(); c != nil {
+		c.Buffered.Add(float64(buffered))
+		c.Flushed.Add(float64(flushed))
+		c.Dropped.Add(float64(dropped))
+	}
+}
+
 const (
 	markerStringID    = 1
 	markerObservation = 2
+	markerJobRun      = 3
 	markerOpen        = 127
+	// markerChecksum is set in the marker of records followed by the CRC32 checksum of marker, length and value.
+	markerChecksum = 0x80
 )
 
 type writeFile struct {
 	filename string
 	end      time.Time
 	file     *os.File
-	idMap    *StringIDMap
+	// out is the buffered writer of the file or the gzip writer on top of it
+	out   io.Writer
+	buf   *bufio.Writer
+	gz    *gzip.Writer
+	idMap *StringIDMap
+	// size is the size of the file including the data written since opening it, except the data still buffered.
+	size *atomic.Int64
+	// index is the index of the file updated on each write, nil if the existing records of the file could not be indexed
+	index *fileIndex
 }
 
 var _ IntStringPersistor = &writeFile{}
@@ -59,13 +147,77 @@ func (wf *writeFile) Persist(obj *IntString) error {
 	if err != nil {
 		return err
 	}
-	return writeRecord(wf.file, markerStringID, bytes)
+	return wf.writeRecord(markerStringID, bytes, 0, obj)
+}
+
+// writeRecord writes the record and adds it to the index. The timestamp millis are only set for an observation,
+// str only for a string ID record.
+func (wf *writeFile) writeRecord(marker byte, value []byte, millis int64, str *IntString) error {
+	// only used for the index of uncompressed files
+	offset := wf.size.Load() + int64(wf.buf.Buffered())
+	if err := writeRecord(wf.out, marker, value); err != nil {
+		return err
+	}
+	if wf.index != nil {
+		wf.index.add(offset, millis, str)
+	}
+	return nil
+}
+
+// flush writes the data buffered so far, so that readers of the file see all records written before.
+func (wf *writeFile) flush() error {
+	if wf.gz != nil {
+		if err := wf.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return wf.buf.Flush()
+}
+
+// close completes the compressed data, syncs and closes the file.
+func (wf *writeFile) close() error {
+	if wf.gz != nil {
+		if err := wf.gz.Close(); err != nil {
+			_ = wf.file.Close()
+			return err
+		}
+	}
+	if err := wf.buf.Flush(); err != nil {
+		_ = wf.file.Close()
+		return err
+	}
+	if err := wf.file.Sync(); err != nil {
+		_ = wf.file.Close()
+		return err
+	}
+	if err := wf.file.Close(); err != nil {
+		return err
+	}
+	if wf.index == nil {
+		return nil
+	}
+	wf.index.lock.Lock()
+	wf.index.fileSize = wf.size.Load()
+	wf.index.lock.Unlock()
+	if err := writeIndexFile(wf.filename, wf.index); err != nil {
+		return fmt.Errorf("writing index file failed: %s", err)
+	}
+	return nil
 }
 
 var _ nwpd.ObservationWriter = &obsWriter{}
 
-func NewObsWriter(log logrus.FieldLogger, directory, prefix string, retentionHours int) (nwpd.ObservationWriter, error) {
-	err := os.MkdirAll(directory, 0o750) //  #nosec G302 -- no sensitive data
+// NewObsWriter creates a writer of hourly record files in the directory. If compress is set, new record files are gzip compressed.
+// Uncompressed files are still read, so that switching the compression keeps the observations written before.
+// The placeholders of the prefix are expanded, see ExpandFilePrefix.
+func NewObsWriter(log logrus.FieldLogger, directory, prefix string, retentionHours int, compress bool,
+	options ...ObsWriterOption,
+) (nwpd.ObservationWriter, error) {
+	prefix, err := ExpandFilePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(directory, 0o750) //  #nosec G302 -- no sensitive data
 	if err != nil {
 		return nil, err
 	}
@@ -74,27 +226,73 @@ func NewObsWriter(log logrus.FieldLogger, directory, prefix string, retentionHou
 		directory:      directory,
 		prefix:         prefix,
 		retentionHours: retentionHours,
+		compress:       compress,
 		obsChan:        make(chan *nwpd.Observation, 100),
+		runChan:        make(chan *nwpd.JobRunRecord, 100),
 		done:           make(chan struct{}),
-		ticker:         time.NewTicker(5 * time.Second),
+		flushed:        make(chan struct{}),
+		ticker:         time.NewTicker(DefaultWriteFlushInterval),
+	}
+	for _, option := range options {
+		option(writer)
 	}
+	writer.batchSize.Store(DefaultWriteBatchSize)
+	writer.validateNewestFile()
 
 	return writer, nil
 }
 
+// Add buffers the observation for writing. If the buffer is full, it waits at most addTimeout for free space and
+// drops the observation afterwards. While the writer is stalled, records are dropped without waiting.
 func (w *obsWriter) Add(obs *nwpd.Observation) {
-	w.obsChan <- obs
+	enqueue(w, w.obsChan, obs)
 }
 
-func (w *obsWriter) Stop() {
-	if w.ticker != nil {
-		w.ticker.Stop()
-		w.ticker = nil
+// AddJobRun buffers the job run record for writing like Add.
+func (w *obsWriter) AddJobRun(record *nwpd.JobRunRecord) {
+	enqueue(w, w.runChan, record)
+}
+
+// enqueue sends the record to the buffer channel of the writer as described for Add.
+func enqueue[T any](w *obsWriter, ch chan T, record T) {
+	select {
+	case ch <- record:
+		w.stalled.Store(false)
+		return
+	default:
+	}
+	if !w.stalled.Load() {
+		timer := time.NewTimer(addTimeout)
+		defer timer.Stop()
+		select {
+		case ch <- record:
+			return
+		case <-timer.C:
+		}
+		w.stalled.Store(true)
+		w.log.Warnf("observation writer has not accepted records for %s, dropping records while its buffer is full", addTimeout)
 	}
+	countWrite(0, 0, 1)
+}
+
+// SetWriteBatching sets the interval for writing the batched records and syncing the current file, and the number of batched
+// records which are written before the interval has elapsed.
+func (w *obsWriter) SetWriteBatching(flushInterval time.Duration, batchSize int) {
+	w.batchSize.Store(int64(batchSize))
+	w.ticker.Reset(flushInterval)
+}
+
+// Stop stops the writer after the buffered observations have been written. It must only be called if the writer is running.
+func (w *obsWriter) Stop() {
+	// the ticker is not reset, as the run loop may still select on it until it receives the done signal
+	w.ticker.Stop()
 	w.done <- struct{}{}
-	file := w.currentFile.Load().(*writeFile)
+	<-w.flushed
+	file, _ := w.currentFile.Load().(*writeFile)
 	if file != nil {
-		_ = file.file.Close()
+		if err := file.close(); err != nil {
+			w.log.Warnf("closing file %s failed: %s", file.filename, err)
+		}
 	}
 }
 
@@ -102,112 +300,234 @@ func (w *obsWriter) Run() {
 	for {
 		select {
 		case <-w.done:
+			w.drain()
+			w.flushBatch(false)
+			w.flushed <- struct{}{}
 			return
 		case <-w.ticker.C:
-			file, err := w.getFile()
-			if err != nil {
-				w.log.Warnf("sync failed: getFile: %s", err)
-				continue
-			}
-			err = file.file.Sync()
-			if err != nil {
-				w.log.Warnf("sync failed: %s", err)
-				continue
-			}
+			w.flushBatch(true)
 		case obs := <-w.obsChan:
-			file, err := w.getFile()
-			if err != nil {
-				w.log.Warnf("write failed: getFile: %s", err)
-				continue
-			}
-			intobs, err := ToIntObservation(obs, file.idMap, file)
-			if err != nil {
-				w.log.Warnf("write failed: ToIntObservation: %s", err)
-				continue
-			}
-			value, err := IntObsToBytes(intobs)
-			if err != nil {
-				w.log.Warnf("write failed: IntObsToBytes: %s", err)
-				continue
-			}
-			if err := writeRecord(file.file, markerObservation, value); err != nil {
-				w.log.Warnf("write failed: %s", err)
-				continue
-			}
+			w.addToBatch(batchRecord{obs: obs})
+		case record := <-w.runChan:
+			w.addToBatch(batchRecord{run: record})
 		}
 	}
 }
 
-func writeRecord(w io.Writer, marker byte, value []byte) error {
-	if _, err := w.Write([]byte{marker}); err != nil {
-		return err
+// addToBatch adds the record to the batch and writes the batch if it is full.
+func (w *obsWriter) addToBatch(record batchRecord) {
+	w.batchLock.Lock()
+	w.batch = append(w.batch, record)
+	n := len(w.batch)
+	w.batchLock.Unlock()
+	countWrite(1, 0, 0)
+	if int64(n) >= w.batchSize.Load() {
+		w.flushBatch(false)
 	}
+}
 
-	if err := binary.Write(w, binary.LittleEndian, uint16(len(value))); err != nil {
-		return err
+// drain adds the records still buffered in the channels to the batch.
+func (w *obsWriter) drain() {
+	for {
+		select {
+		case obs := <-w.obsChan:
+			w.addToBatch(batchRecord{obs: obs})
+		case record := <-w.runChan:
+			w.addToBatch(batchRecord{run: record})
+		default:
+			return
+		}
+	}
+}
+
+// flushBatch writes the batched records to the current file and flushes it, so that readers of the file see all records.
+// If sync is set, the current file is synced to disk and created if needed, so that the file is rotated even without records.
+func (w *obsWriter) flushBatch(sync bool) {
+	w.enforceMaxDiskUsage()
+	w.batchLock.Lock()
+	records := w.batch
+	w.batch = nil
+	if len(records) == 0 && !sync {
+		w.batchLock.Unlock()
+		return
+	}
+	file, err := w.getFile()
+	if err != nil {
+		w.batchLock.Unlock()
+		w.log.Warnf("write failed: getFile: %s", err)
+		countWrite(0, 0, len(records))
+		return
+	}
+	dropped := 0
+	for _, record := range records {
+		if record.obs != nil {
+			err = w.write(file, record.obs)
+		} else {
+			err = w.writeJobRun(file, record.run)
+		}
+		if err != nil {
+			w.log.Warnf("write failed: %s", err)
+			dropped++
+		}
+	}
+	err = file.flush()
+	w.batchLock.Unlock()
+	countWrite(0, len(records)-dropped, dropped)
+	if err == nil && sync {
+		err = file.file.Sync()
 	}
+	if err != nil {
+		w.log.Warnf("flush failed: %s", err)
+	}
+	w.updateDiskUsageGauge()
+}
 
-	if _, err := w.Write(value); err != nil {
+// bufferedObservations returns the batched observations, the name of the current file and its size without the batch.
+// Before the first write, it returns the file which will be written.
+func (w *obsWriter) bufferedObservations() (nwpd.Observations, string, int64) {
+	w.batchLock.Lock()
+	defer w.batchLock.Unlock()
+	var result nwpd.Observations
+	for _, record := range w.batch {
+		if record.obs != nil {
+			result = append(result, record.obs)
+		}
+	}
+	if file, _ := w.currentFile.Load().(*writeFile); file != nil {
+		return result, file.filename, file.size.Load()
+	}
+	filename := recordFilename(w.directory, w.prefix, startOfHourUTC(time.Now()), w.compress)
+	var size int64
+	if info, err := os.Stat(filename); err == nil {
+		size = info.Size()
+	}
+	return result, filename, size
+}
+
+func (w *obsWriter) write(file *writeFile, obs *nwpd.Observation) error {
+	intobs, err := ToIntObservation(obs, file.idMap, file)
+	if err != nil {
+		return fmt.Errorf("ToIntObservation: %s", err)
+	}
+	value, err := IntObsToBytes(intobs)
+	if err != nil {
+		return fmt.Errorf("IntObsToBytes: %s", err)
+	}
+	return file.writeRecord(markerObservation, value, intobs.TimeMillis, nil)
+}
+
+func (w *obsWriter) writeJobRun(file *writeFile, record *nwpd.JobRunRecord) error {
+	value, err := jobRunToBytes(record)
+	if err != nil {
 		return err
 	}
-	return nil
+	return file.writeRecord(markerJobRun, value, 0, nil)
 }
 
+// jobRunToBytes marshals the job run record. The destinations are omitted if the record exceeds the maximum record size.
+func jobRunToBytes(record *nwpd.JobRunRecord) ([]byte, error) {
+	value, err := proto.Marshal(record)
+	if err != nil || len(value) <= math.MaxUint16 {
+		return value, err
+	}
+	omitted := proto.Clone(record).(*nwpd.JobRunRecord)
+	omitted.DestHosts = nil
+	omitted.DestHostsOmitted = true
+	return proto.Marshal(omitted)
+}
+
+// writeRecord writes the record with the CRC32 checksum of the marker, length and value.
+func writeRecord(w io.Writer, marker byte, value []byte) error {
+	buf := make([]byte, 3, 3+len(value)+4)
+	buf[0] = marker | markerChecksum
+	binary.LittleEndian.PutUint16(buf[1:], uint16(len(value))) // #nosec G115 -- records are limited to math.MaxUint16
+	buf = append(buf, value...)
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+	_, err := w.Write(buf)
+	return err
+}
+
+// readRecord reads the next record. It returns a nil value at the end of the file, io.ErrUnexpectedEOF for an incomplete record,
+// and errChecksumMismatch if the checksum of the record does not match.
 func readRecord(r io.Reader) (byte, []byte, error) {
-	marker := make([]byte, 1)
-	if n, err := r.Read(marker); err == io.EOF {
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(r, header[:1]); err == io.EOF {
 		return 0, nil, nil
 	} else if err != nil {
 		return 0, nil, err
-	} else if n != 1 {
-		return 0, nil, fmt.Errorf("missing marker")
 	}
-
-	var length uint16
-	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
-		return 0, nil, err
+	if _, err := io.ReadFull(r, header[1:]); err != nil {
+		return 0, nil, unexpectedEOF(err)
 	}
-	value := make([]byte, length)
-	if n, err := r.Read(value); err != nil {
-		return 0, nil, err
-	} else if n != int(length) {
-		return 0, nil, fmt.Errorf("incomplete block: %d != %d", n, int(length))
+	value := make([]byte, binary.LittleEndian.Uint16(header[1:]))
+	if _, err := io.ReadFull(r, value); err != nil {
+		return 0, nil, unexpectedEOF(err)
 	}
-	return marker[0], value, nil
+	marker := header[0]
+	if marker&markerChecksum == 0 {
+		// written by an older version without checksum
+		return marker, value, nil
+	}
+	var sum uint32
+	if err := binary.Read(r, binary.LittleEndian, &sum); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write(header)
+	_, _ = crc.Write(value)
+	if crc.Sum32() != sum {
+		return 0, nil, errChecksumMismatch
+	}
+	return marker &^ markerChecksum, value, nil
 }
 
-func (w *obsWriter) loadStringIDMap(filename string) (*StringIDMap, error) {
-	f, err := os.OpenFile(filename, os.O_RDONLY, 0o640) //  #nosec G302 G304 -- no sensitive data
-	if err != nil {
-		if os.IsNotExist(err) {
-			return NewStringIDMap(), nil
-		}
-		return nil, err
+// unexpectedEOF replaces io.EOF, as the end of the file within a record is unexpected.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
 	}
+	return err
+}
 
+func (w *obsWriter) loadStringIDMap(filename string) (*StringIDMap, error) {
 	var objects []*IntString
-	for {
-		marker, value, err := readRecord(f)
-		if err != nil {
-			return nil, fmt.Errorf("reading StringIDMap failed: %s", err)
-		}
-		if value == nil {
-			break
-		}
+	complete, err := readRecordFile(filename, func(marker byte, value []byte) error {
 		switch marker {
 		case markerStringID:
 			raw := &nwpd.IntString{}
 			if err := proto.Unmarshal(value, raw); err != nil {
-				return nil, fmt.Errorf("reading StringIDMap from file %s failed: %s", filename, err)
+				return fmt.Errorf("%w: reading StringIDMap failed: %s", errInvalidRecord, err)
 			}
 			obj := NewVarint2String(raw.Key, raw.Value)
 			objects = append(objects, obj)
-		case markerObservation:
+		case markerObservation, markerJobRun:
 			// ignore
 		case markerOpen:
 			// ignore
 		default:
-			return nil, fmt.Errorf("invalid file format")
+			return unknownMarkerError(marker)
+		}
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewStringIDMap(), nil
+		}
+		var corruptErr *CorruptRecordError
+		if errors.As(err, &corruptErr) {
+			// the records after the corrupt one cannot be read, the repaired file only contains the records before
+			if err := w.repairCorruptFile(filename, corruptErr); err != nil {
+				return nil, err
+			}
+			return w.loadStringIDMap(filename)
+		}
+		return nil, fmt.Errorf("reading StringIDMap failed: %s", err)
+	}
+	if !complete {
+		// the writer has been stopped abruptly, the incomplete end must be removed before appending
+		w.log.Warnf("removing incomplete record at the end of file %s", filename)
+		if err := rewriteRecordFile(filename, w.compress); err != nil {
+			return nil, fmt.Errorf("rewriting incomplete file %s failed: %s", filename, err)
 		}
 	}
 	idMap := NewStringIDMapFromData(objects)
@@ -226,14 +546,17 @@ func (w *obsWriter) getFile() (*writeFile, error) {
 		}()
 		// rotate output file
 		if file != nil {
-			if err := file.file.Close(); err != nil {
+			if err := file.close(); err != nil {
 				w.log.Warnf("closing file %s failed: %s", file.filename, err)
 			}
 		}
+		if w.compressRotated {
+			go w.compressRotatedFiles()
+		}
 		currentUTC := startOfHourUTC(now)
 		next := now.Add(61 * time.Minute)
 		nextUTC := startOfHourUTC(next)
-		filename := fmt.Sprintf("%s/%s-%s.records", w.directory, w.prefix, currentUTC.Format("2006-01-02-15"))
+		filename := recordFilename(w.directory, w.prefix, currentUTC, w.compress)
 		idMap, err := w.loadStringIDMap(filename)
 		if err != nil {
 			return nil, err
@@ -242,17 +565,39 @@ func (w *obsWriter) getFile() (*writeFile, error) {
 		if err != nil {
 			return nil, err
 		}
-		err = writeRecord(f, markerOpen, []byte(now.UTC().Format("15:04:05")))
-		if err != nil {
-			return nil, err
-		}
 		file = &writeFile{
 			filename: filename,
 			end:      nextUTC,
 			idMap:    idMap,
 			file:     f,
+			size:     &atomic.Int64{},
+		}
+		if info, err := f.Stat(); err == nil {
+			file.size.Store(info.Size())
+			file.index = &fileIndex{seekable: !w.compress}
+			if info.Size() > 0 {
+				// appending to the file of the hour after a restart
+				if file.index, err = scanFileIndex(filename); err != nil {
+					w.log.Warnf("cannot index file %s: %s", filename, err)
+					file.index = nil
+				}
+			}
+		}
+		w.forgetIndex(filename)
+		file.buf = bufio.NewWriterSize(countingWriter{w: f, size: file.size}, 64*1024)
+		file.out = file.buf
+		if w.compress {
+			// a new gzip member is appended if the agent has been restarted within the hour
+			file.gz = gzip.NewWriter(file.out)
+			file.out = file.gz
+		}
+		err = file.writeRecord(markerOpen, []byte(now.UTC().Format("15:04:05")), 0, nil)
+		if err != nil {
+			_ = file.close()
+			return nil, err
 		}
 		w.currentFile.Store(file)
+		w.refreshDiskUsage()
 	}
 	return file, nil
 }
@@ -272,13 +617,18 @@ func (w *obsWriter) cleanOldFiles() {
 	for _, f := range files {
 		if !f.IsDir() && strings.HasPrefix(f.Name(), w.prefix) && isBefore(f, limitUTC) {
 			filename := path.Join(w.directory, f.Name())
-			if err := os.Remove(filename); err != nil {
+			w.forgetIndex(filename)
+			w.filesLock.Lock()
+			err := os.Remove(filename)
+			w.filesLock.Unlock()
+			if err != nil {
 				w.log.Warnf("cannot delete file %s: %s", filename, err)
 			} else {
 				w.log.Infof("deleted file %s", filename)
 			}
 		}
 	}
+	w.refreshDiskUsage()
 }
 
 func isBefore(entry os.DirEntry, limitUTC time.Time) bool {
@@ -289,25 +639,36 @@ func isBefore(entry os.DirEntry, limitUTC time.Time) bool {
 	return fileInfo.ModTime().Before(limitUTC)
 }
 
-type filterFunc func(key string) bool
-
-func all(_ string) bool { return true }
-
-func createFilter(keys []string) filterFunc {
-	if keys == nil {
-		return all
-	}
-	m := common.StringSet{}
-	m.AddAll(keys...)
-	return m.Contains
+// listQuery is the validated time range, order and filter of the observations to list.
+type listQuery struct {
+	log   logrus.FieldLogger
+	files []string
+	order func(a, b *nwpd.Observation) int
+	match func(obs *nwpd.Observation) bool
+	// streaks and matchStreak are set for listing failure streaks only. The observations of the edges are selected by
+	// match, the failures of the streaks by matchStreak.
+	streaks     *failureStreakFilter
+	matchStreak func(obs *nwpd.Observation) bool
+	// indexOf returns the index of a record file or nil
+	indexOf func(filename string) *fileIndex
+	// startMillis and endMillis are the time range in Unix millis
+	startMillis int64
+	endMillis   int64
+	// buffered are the observations of the batch not written when the query has been created
+	buffered nwpd.Observations
+	// currentFile is the file written when the query has been created and currentSize its size at that time
+	currentFile string
+	currentSize int64
 }
 
-func (w *obsWriter) ListObservations(options nwpd.ListObservationsOptions) (nwpd.Observations, error) {
-	var result nwpd.Observations
-
+// newListQuery validates the options. It returns nil if no observations can match the time range.
+func (w *obsWriter) newListQuery(options nwpd.ListObservationsOptions) (*listQuery, error) {
 	var empty time.Time
 	now := time.Now()
-	startLimit := now.Add(-24 * time.Hour)
+	startLimit := w.listStartLimit
+	if startLimit.IsZero() {
+		startLimit = now.Add(-24 * time.Hour)
+	}
 	start := options.Start
 	if start.After(now) {
 		start = now
@@ -317,48 +678,319 @@ func (w *obsWriter) ListObservations(options nwpd.ListObservationsOptions) (nwpd
 	end := options.End
 	if end == empty {
 		end = now
-	} else if end.After(start) || end.Before(startLimit) {
+	} else if end.Before(start) || end.Before(startLimit) {
 		return nil, nil
 	}
+	if options.MinFailureStreak < 0 {
+		return nil, &nwpd.InvalidFilterError{Field: "onlyFailureStreaksOfAtLeast", Err: fmt.Errorf("must not be negative")}
+	}
+	// the streaks at the cursor position start before it
+	if after := options.After; after != nil && options.MinFailureStreak == 0 {
+		if t := time.Unix(0, after.TimeNanos); t.After(start) {
+			start = t
+		}
+	}
 
-	limit := options.Limit
-	if limit == 0 {
-		limit = 10000
+	matcher, err := newObservationMatcher(options)
+	if err != nil {
+		return nil, err
+	}
+	order, err := nwpd.ObservationOrder(options.SortBy, options.SortDescending)
+	if err != nil {
+		return nil, &nwpd.InvalidFilterError{Field: "sortBy", Err: err}
+	}
+	if options.After != nil && !options.IsDefaultOrder() {
+		return nil, &nwpd.InvalidFilterError{Field: "pageToken", Err: fmt.Errorf("only supported for sorting by %s ascending", nwpd.SortByTimestamp)}
 	}
-	jobIDFilter := createFilter(options.FilterJobIDs)
-	srcHostFilter := createFilter(options.FilterSrcHosts)
-	descHostFilter := createFilter(options.FilterDestHosts)
 
-	files, err := GetRecordFiles(w.directory, w.prefix, start, end)
+	// the batch is listed before the files, as records are moved from the batch to the files in the meantime
+	buffered, currentFile, currentSize := w.bufferedObservations()
+	w.filesLock.Lock()
+	// observations at the end of the time range may have been written to the file of the next hour
+	files, err := GetRecordFiles(w.directory, w.prefix, start, end.Add(maxRecordDelay))
+	w.filesLock.Unlock()
 	if err != nil {
 		return nil, err
 	}
-	for _, file := range files {
-		if len(result) == limit {
-			break
+	currentHour := recordFileInfo{name: currentFile}.hour()
+	files = slices.DeleteFunc(files, func(file string) bool {
+		// created afterwards by rotating the file
+		return recordFileInfo{name: file}.hour() > currentHour
+	})
+	// matchEdge selects by the time range and the values of the edge
+	matchEdge := func(obs *nwpd.Observation) bool {
+		if t := obs.Timestamp.AsTime(); t.Before(start) || t.After(end) {
+			return false
+		}
+		return matcher.edge(obs)
+	}
+	// atCursor counts the observations at the position of the cursor
+	atCursor := 0
+	matchObservation := func(obs *nwpd.Observation) bool {
+		if options.After != nil && options.After.Compare(obs) < 0 {
+			return false
+		}
+		if !matcher.observation(obs) {
+			return false
+		}
+		if options.After != nil && options.After.Compare(obs) == 0 {
+			// skip the observations at the cursor position listed on previous pages
+			atCursor++
+			if atCursor <= options.After.Skip {
+				return false
+			}
+		}
+		return true
+	}
+	q := &listQuery{log: w.log, files: files, order: order, indexOf: w.indexOf,
+		startMillis: start.UnixMilli(), endMillis: end.UnixMilli(),
+		buffered: buffered, currentFile: currentFile, currentSize: currentSize}
+	if options.MinFailureStreak > 0 {
+		// the streaks are detected on all observations of the edges ordered by timestamp
+		q.match = matchEdge
+		q.streaks = newFailureStreakFilter(options.MinFailureStreak)
+		q.matchStreak = matchObservation
+	} else {
+		q.match = func(obs *nwpd.Observation) bool {
+			return matchEdge(obs) && matchObservation(obs)
+		}
+	}
+	return q, nil
+}
+
+// visitFile calls the visitor for the matching observations of the record file in the order they have been written.
+// The rest of a file is skipped after a corrupt record, so that the valid observations are still listed.
+// With the index of the file, files without observations in the time range are skipped and reading starts close to the start.
+func (q *listQuery) visitFile(filename string, visitor func(obs *nwpd.Observation)) error {
+	visit := func(obs *nwpd.Observation) error {
+		if q.match(obs) {
+			visitor(obs)
+		}
+		return nil
+	}
+	var limit int64
+	if filename == q.currentFile {
+		if q.currentSize == 0 {
+			return nil
+		}
+		// the records written after listing the batch are listed from the batch
+		limit = q.currentSize
+	}
+	err := q.visitIndexedFile(filename, limit, visit)
+	if errors.Is(err, fs.ErrNotExist) && strings.HasSuffix(filename, RecordFileSuffix) {
+		// replaced by the compressed file in the meantime
+		err = q.visitIndexedFile(filename+".gz", 0, visit)
+	}
+	if IsCorruptRecordError(err) {
+		q.log.Warnf("skipping rest of file: %s", err)
+		return nil
+	}
+	return err
+}
+
+// visitIndexedFile calls the visitor for the observations of the record file up to the limit if set.
+// The file is skipped or read from a position after its start if possible according to its index.
+func (q *listQuery) visitIndexedFile(filename string, limit int64, visit ObservationVisitor) error {
+	idx := q.indexOf(filename)
+	if idx != nil && !idx.overlaps(q.startMillis, q.endMillis) {
+		return nil
+	}
+	from, strings, _ := idx.seek(q.startMillis)
+	return iterateRecordFileFrom(filename, from, strings, limit, visit)
+}
+
+// hourGroups groups the record files by their hour. An hour may have an uncompressed and a compressed file.
+func (q *listQuery) hourGroups() [][]string {
+	var (
+		groups   [][]string
+		lastHour string
+	)
+	for _, file := range q.files {
+		hour := recordFileInfo{name: file}.hour()
+		if len(groups) == 0 || hour != lastHour {
+			groups = append(groups, nil)
+			lastHour = hour
 		}
-		err := IterateRecordFile(file, func(obs *nwpd.Observation) error {
-			if len(result) == limit {
-				return nil
+		groups[len(groups)-1] = append(groups[len(groups)-1], file)
+	}
+	return groups
+}
+
+// IterateObservations calls the visitor for the matching observations ordered by timestamp ascending until the visitor
+// returns stop or an error, or Limit observations have been visited if set. Only the default order is supported.
+// The record files are read hour by hour, so that only the matching observations of about an hour are kept in memory.
+func (w *obsWriter) IterateObservations(options nwpd.ListObservationsOptions, visitor func(obs *nwpd.Observation) (stop bool, err error)) error {
+	if !options.IsDefaultOrder() {
+		return &nwpd.InvalidFilterError{Field: "sortBy", Err: fmt.Errorf("only %s ascending supported for iterating", nwpd.SortByTimestamp)}
+	}
+	q, err := w.newListQuery(options)
+	if err != nil || q == nil {
+		return err
+	}
+	count := 0
+	// visit returns true if the iteration is stopped
+	visit := func(observations nwpd.Observations) (bool, error) {
+		for _, obs := range observations {
+			if q.streaks != nil && !q.matchStreak(obs) {
+				continue
 			}
-			if t := obs.Timestamp.AsTime(); t.Before(start) || t.After(end) {
-				return nil
+			stop, err := visitor(obs)
+			count++
+			if err != nil || stop || (options.Limit > 0 && count >= options.Limit) {
+				return true, err
 			}
-			if obs.Ok && options.FailuresOnly {
-				return nil
+		}
+		return false, nil
+	}
+	// emit returns true if the iteration is stopped
+	emit := func(observations nwpd.Observations) (bool, error) {
+		if q.streaks == nil {
+			return visit(observations)
+		}
+		for _, obs := range observations {
+			if stop, err := visit(q.streaks.add(obs)); stop {
+				return true, err
 			}
-			if !jobIDFilter(obs.JobID) || !srcHostFilter(obs.SrcHost) || !descHostFilter(obs.DestHost) {
-				return nil
+		}
+		return false, nil
+	}
+	// pending are the sorted observations of the previous hour not emitted yet
+	var pending nwpd.Observations
+	for _, group := range q.hourGroups() {
+		var current nwpd.Observations
+		for _, file := range group {
+			if err := q.visitFile(file, func(obs *nwpd.Observation) { current = append(current, obs) }); err != nil {
+				return err
 			}
+		}
+		slices.SortStableFunc(current, q.order)
+		// observations at the end of an hour may have been written to the file of the next hour
+		n := len(pending)
+		if len(current) > 0 {
+			n, _ = slices.BinarySearchFunc(pending, current[0], func(a, b *nwpd.Observation) int {
+				if q.order(a, b) < 0 {
+					return -1
+				}
+				return 1
+			})
+		}
+		if stop, err := emit(pending[:n]); stop {
+			return err
+		}
+		pending = mergeSorted(pending[n:], current, q.order)
+	}
+	// the batched observations will be written to the current file
+	buffered := q.matchingBuffered()
+	slices.SortStableFunc(buffered, q.order)
+	if stop, err := emit(mergeSorted(pending, buffered, q.order)); stop || q.streaks == nil {
+		return err
+	}
+	_, err = visit(q.streaks.flush())
+	return err
+}
+
+// matchingBuffered returns the matching observations of the batch.
+func (q *listQuery) matchingBuffered() nwpd.Observations {
+	var result nwpd.Observations
+	for _, obs := range q.buffered {
+		if q.match(obs) {
 			result = append(result, obs)
-			return nil
+		}
+	}
+	return result
+}
+
+// mergeSorted merges the sorted observations. Observations at the same position keep their order, a before b.
+func mergeSorted(a, b nwpd.Observations, order func(a, b *nwpd.Observation) int) nwpd.Observations {
+	if len(a) == 0 {
+		return b
+	}
+	result := make(nwpd.Observations, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if order(b[0], a[0]) < 0 {
+			result = append(result, b[0])
+			b = b[1:]
+		} else {
+			result = append(result, a[0])
+			a = a[1:]
+		}
+	}
+	result = append(result, a...)
+	return append(result, b...)
+}
+
+func (w *obsWriter) ListObservations(options nwpd.ListObservationsOptions) (nwpd.Observations, error) {
+	limit := options.Limit
+	if limit == 0 {
+		limit = 10000
+	}
+	if options.IsDefaultOrder() {
+		// the limit is applied while iterating
+		var result nwpd.Observations
+		options.Limit = limit
+		err := w.IterateObservations(options, func(obs *nwpd.Observation) (bool, error) {
+			result = append(result, obs)
+			return false, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	order, err := nwpd.ObservationOrder(options.SortBy, options.SortDescending)
+	if err != nil {
+		return nil, &nwpd.InvalidFilterError{Field: "sortBy", Err: err}
+	}
+	if options.MinFailureStreak > 0 {
+		if options.After != nil {
+			return nil, &nwpd.InvalidFilterError{Field: "pageToken", Err: fmt.Errorf("only supported for sorting by %s ascending", nwpd.SortByTimestamp)}
+		}
+		// the streaks are detected while iterating in the default order
+		iterateOptions := options
+		iterateOptions.SortBy, iterateOptions.SortDescending, iterateOptions.Limit = "", false, 0
+		var result nwpd.Observations
+		err := w.IterateObservations(iterateOptions, func(obs *nwpd.Observation) (bool, error) {
+			result = append(result, obs)
+			if len(result) >= 2*limit {
+				result = firstOf(result, limit, order)
+			}
+			return false, nil
 		})
 		if err != nil {
 			return nil, err
 		}
+		return firstOf(result, limit, order), nil
 	}
-	sort.Sort(result)
-	return result, nil
+
+	q, err := w.newListQuery(options)
+	if err != nil || q == nil {
+		return nil, err
+	}
+	var result nwpd.Observations
+	for _, file := range q.files {
+		err := q.visitFile(file, func(obs *nwpd.Observation) {
+			result = append(result, obs)
+			if len(result) >= 2*limit {
+				result = firstOf(result, limit, q.order)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	result = append(result, q.matchingBuffered()...)
+	return firstOf(result, limit, q.order), nil
+}
+
+// firstOf sorts the observations in the given order and returns the first ones up to the limit.
+// The sort is stable, so that observations at the same position keep the order of the record files.
+func firstOf(observations nwpd.Observations, limit int, order func(a, b *nwpd.Observation) int) nwpd.Observations {
+	slices.SortStableFunc(observations, order)
+	if len(observations) > limit {
+		return observations[:limit]
+	}
+	return observations
 }
 
 func startOfHourUTC(t time.Time) time.Time {
@@ -367,29 +999,41 @@ func startOfHourUTC(t time.Time) time.Time {
 }
 
 // GetRecordFiles gets all observation record files.
+// If there are both an uncompressed and a compressed file for an hour, the uncompressed one is returned first.
 func GetRecordFiles(directory, prefix string, start, end time.Time) ([]string, error) {
 	startHour := startOfHourUTC(start)
 	endHour := startOfHourUTC(end)
 	var files []string
 	for hour := startHour; !hour.After(endHour); hour = hour.Add(time.Hour) {
-		filename := fmt.Sprintf("%s/%s-%s.records", directory, prefix, hour.Format("2006-01-02-15"))
-		stat, err := os.Stat(filename)
-		if err != nil {
-			if os.IsNotExist(err) {
-				continue
+		for _, compressed := range []bool{false, true} {
+			filename := recordFilename(directory, prefix, hour, compressed)
+			stat, err := os.Stat(filename)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
 			}
-			return nil, err
-		}
-		if stat.IsDir() {
-			return nil, fmt.Errorf("%s is not a file", filename)
+			if stat.IsDir() {
+				return nil, fmt.Errorf("%s is not a file", filename)
+			}
+			files = append(files, filename)
 		}
-		files = append(files, filename)
 	}
 	return files, nil
 }
 
-// GetAnyRecordFiles gets all observation record files.
+// GetAnyRecordFiles gets all uncompressed and compressed observation record files.
 func GetAnyRecordFiles(directory string, subdir bool) ([]string, error) {
+	return getAnyFiles(directory, IsRecordFile, subdir)
+}
+
+// GetAnyIncidentFiles gets all incident snapshot files.
+func GetAnyIncidentFiles(directory string, subdir bool) ([]string, error) {
+	return getAnyFiles(directory, func(name string) bool { return strings.HasSuffix(name, IncidentFileSuffix) }, subdir)
+}
+
+func getAnyFiles(directory string, match func(name string) bool, subdir bool) ([]string, error) {
 	entries, err := os.ReadDir(directory)
 	if err != nil {
 		return nil, err
@@ -399,7 +1043,7 @@ func GetAnyRecordFiles(directory string, subdir bool) ([]string, error) {
 	for _, entry := range entries {
 		if entry.IsDir() {
 			if subdir {
-				subfiles, err := GetAnyRecordFiles(path.Join(directory, entry.Name()), false)
+				subfiles, err := getAnyFiles(path.Join(directory, entry.Name()), match, false)
 				if err != nil {
 					return nil, err
 				}
@@ -407,7 +1051,7 @@ func GetAnyRecordFiles(directory string, subdir bool) ([]string, error) {
 			}
 			continue
 		}
-		if !strings.HasSuffix(entry.Name(), ".records") {
+		if !match(entry.Name()) {
 			continue
 		}
 		files = append(files, path.Join(directory, entry.Name()))
@@ -417,48 +1061,75 @@ func GetAnyRecordFiles(directory string, subdir bool) ([]string, error) {
 
 type ObservationVisitor func(obs *nwpd.Observation) error
 
+// JobRunVisitor is called for the job run records of a record file.
+type JobRunVisitor func(record *nwpd.JobRunRecord) error
+
 func IterateRecordFile(filename string, visitor ObservationVisitor) error {
-	f, err := os.OpenFile(filename, os.O_RDONLY, 0o640) //  #nosec G302 G304 -- no sensitive data
-	if err != nil {
-		return err
-	}
+	return IterateRecordFileWithJobRuns(filename, visitor, nil)
+}
 
-	idMap := NewStringIDMap()
-	for {
-		marker, value, err := readRecord(f)
+// IterateRecordFileWithJobRuns calls the visitors for the observations and the job run records of the record file in the order
+// they have been written. The job run records are skipped if runVisitor is nil. Compressed record files are decompressed.
+func IterateRecordFileWithJobRuns(filename string, visitor ObservationVisitor, runVisitor JobRunVisitor) error {
+	d := &recordDecoder{idMap: NewStringIDMap(), visitor: visitor, runVisitor: runVisitor}
+	_, err := readRecordFile(filename, d.decode)
+	return err
+}
+
+// iterateRecordFileFrom calls the visitor for the observations of a record file starting at the position up to the limit if set.
+// The position must be the start for compressed files. The strings must contain at least the strings of the string ID records
+// before the position.
+func iterateRecordFileFrom(filename string, from indexEntry, strings []*IntString, limit int64, visitor ObservationVisitor) error {
+	d := &recordDecoder{idMap: NewStringIDMapFromData(strings), visitor: visitor}
+	_, err := readRecordFileFrom(filename, from, limit, d.decode)
+	return err
+}
+
+// recordDecoder decodes the records of a record file and calls the visitors.
+type recordDecoder struct {
+	idMap      *StringIDMap
+	visitor    ObservationVisitor
+	runVisitor JobRunVisitor
+}
+
+func (d *recordDecoder) decode(marker byte, value []byte) error {
+	switch marker {
+	case markerStringID:
+		raw := &nwpd.IntString{}
+		if err := proto.Unmarshal(value, raw); err != nil {
+			return fmt.Errorf("%w: error on reading StringIDMap: %s", errInvalidRecord, err)
+		}
+		if s, err := d.idMap.GetValue(raw.Key); err == nil && s == raw.Value {
+			// already known from the index if reading has started within the file
+			return nil
+		}
+		obj := NewVarint2String(raw.Key, raw.Value)
+		if err := d.idMap.Append(obj); err != nil {
+			return fmt.Errorf("%w: error on appending to StringIDMap: %s", errInvalidRecord, err)
+		}
+	case markerObservation:
+		intobs, err := IntObsFromBytes(value)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: error on unmarshalling: %s", errInvalidRecord, err)
 		}
-		if value == nil {
-			break
+		obs, err := IntObsToObservation(intobs, d.idMap)
+		if err != nil {
+			return fmt.Errorf("%w: error on converting observation: %s", errInvalidRecord, err)
 		}
-		switch marker {
-		case markerStringID:
-			raw := &nwpd.IntString{}
-			if err := proto.Unmarshal(value, raw); err != nil {
-				return fmt.Errorf("error on reading StringIDMap: %s", err)
-			}
-			obj := NewVarint2String(raw.Key, raw.Value)
-			if err := idMap.Append(obj); err != nil {
-				return fmt.Errorf("error on appending to StringIDMap: %s", err)
-			}
-		case markerObservation:
-			intobs, err := IntObsFromBytes(value)
-			if err != nil {
-				return fmt.Errorf("error on unmarshalling: %s", err)
-			}
-			obs, err := IntObsToObservation(intobs, idMap)
-			if err != nil {
-				return fmt.Errorf("error on converting observation: %s", err)
-			}
-			if err := visitor(obs); err != nil {
-				return err
-			}
-		case markerOpen:
-			// ignore
-		default:
-			return fmt.Errorf("invalid file format")
+		return d.visitor(obs)
+	case markerJobRun:
+		if d.runVisitor == nil {
+			return nil
+		}
+		record := &nwpd.JobRunRecord{}
+		if err := proto.Unmarshal(value, record); err != nil {
+			return fmt.Errorf("%w: error on unmarshalling job run: %s", errInvalidRecord, err)
 		}
+		return d.runVisitor(record)
+	case markerOpen:
+		// ignore
+	default:
+		return unknownMarkerError(marker)
 	}
 	return nil
 }
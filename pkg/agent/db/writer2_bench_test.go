@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// BenchmarkObsWriterWrite compares the write throughput of uncompressed and compressed record files.
+// The size of the record file per observation is reported additionally.
+func BenchmarkObsWriterWrite(b *testing.B) {
+	now := time.Now()
+	rnd := rand.New(rand.NewSource(1)) // #nosec G404 -- reproducible test data
+	observations := make([]*nwpd.Observation, 1000)
+	for i := range observations {
+		observations[i] = &nwpd.Observation{
+			JobID:    fmt.Sprintf("tcp-n2n-%d", i%8),
+			SrcHost:  "shoot--foo--bar-worker-z1-5d6f7-abcde",
+			DestHost: fmt.Sprintf("shoot--foo--bar-worker-z1-5d6f7-%05d", i%100),
+			Duration: durationpb.New(time.Duration(500+rnd.Intn(5000)) * time.Microsecond),
+			Ok:       i%50 != 0,
+		}
+	}
+
+	for _, compress := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compress=%t", compress), func(b *testing.B) {
+			log := logrus.NewEntry(logrus.StandardLogger())
+			log.Logger.SetLevel(logrus.ErrorLevel)
+			writer, err := NewObsWriter(log, b.TempDir(), "bench", 24, compress)
+			if err != nil {
+				b.Fatal(err)
+			}
+			w := writer.(*obsWriter)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				obs := observations[i%len(observations)]
+				obs.Timestamp = timestamppb.New(now.Add(time.Duration(i) * 7 * time.Millisecond))
+				w.addToBatch(batchRecord{obs: obs})
+			}
+			w.flushBatch(false)
+			b.StopTimer()
+			file, _ := w.currentFile.Load().(*writeFile)
+			if err := file.close(); err != nil {
+				b.Fatal(err)
+			}
+			info, err := os.Stat(file.filename)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(info.Size())/float64(b.N), "bytes/obs")
+		})
+	}
+}
+
+// BenchmarkListRecentObservations compares listing the observations of the last two minutes of a record file using its index
+// with reading the whole file.
+func BenchmarkListRecentObservations(b *testing.B) {
+	log := logrus.NewEntry(logrus.StandardLogger())
+	log.Logger.SetLevel(logrus.ErrorLevel)
+	dir := b.TempDir()
+	writer, err := NewObsWriter(log, dir, "bench", 24, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	w := writer.(*obsWriter)
+	const count = 100000
+	now := time.Now()
+	start := now.Add(-count * 20 * time.Millisecond)
+	for i := 0; i < count; i++ {
+		w.addToBatch(batchRecord{obs: &nwpd.Observation{
+			JobID:     fmt.Sprintf("tcp-n2n-%d", i%8),
+			SrcHost:   "shoot--foo--bar-worker-z1-5d6f7-abcde",
+			DestHost:  fmt.Sprintf("shoot--foo--bar-worker-z1-5d6f7-%05d", i%100),
+			Timestamp: timestamppb.New(start.Add(time.Duration(i) * 20 * time.Millisecond)),
+			Ok:        true,
+		}})
+	}
+	w.flushBatch(false)
+	file, _ := w.currentFile.Load().(*writeFile)
+	if err := file.close(); err != nil {
+		b.Fatal(err)
+	}
+	options := nwpd.ListObservationsOptions{Start: now.Add(-2 * time.Minute), End: now}
+	expected := 2 * 60 * 50
+
+	b.Run("index", func(b *testing.B) {
+		reader, err := NewObsWriter(log, dir, "bench", 24, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			result, err := reader.ListObservations(options)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(result) < expected-1 || len(result) > expected+1 {
+				b.Fatalf("unexpected number of observations %d", len(result))
+			}
+		}
+	})
+	b.Run("fullScan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var result nwpd.Observations
+			err := IterateRecordFile(file.filename, func(obs *nwpd.Observation) error {
+				if t := obs.Timestamp.AsTime(); !t.Before(options.Start) && !t.After(options.End) {
+					result = append(result, obs)
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
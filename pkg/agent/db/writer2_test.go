@@ -0,0 +1,1197 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("obsWriter", func() {
+	It("persists job labels and filters by labels", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+
+		now := time.Now()
+		writer.Add(&nwpd.Observation{JobID: "tcp1", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true,
+			Labels: map[string]string{"port": "10250", "pool": "canary"}})
+		writer.Add(&nwpd.Observation{JobID: "tcp2", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true,
+			Labels: map[string]string{"port": "443"}})
+		writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true})
+
+		options := nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(options)
+		}).Should(HaveLen(3))
+
+		options.FilterLabels = map[string]string{"port": "10250"}
+		result, err := writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].JobID).To(Equal("tcp1"))
+		Expect(result[0].Labels).To(Equal(map[string]string{"port": "10250", "pool": "canary"}))
+	})
+
+	It("persists result fields and filters by them", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+
+		now := time.Now()
+		writer.Add(&nwpd.Observation{JobID: "https", SrcHost: "node1", DestHost: "api", Timestamp: timestamppb.New(now), Ok: true,
+			ResultFields: map[string]string{"httpStatus": "200", "certDaysRemaining": "42"}})
+		writer.Add(&nwpd.Observation{JobID: "https", SrcHost: "node1", DestHost: "api", Timestamp: timestamppb.New(now),
+			ResultFields: map[string]string{"httpStatus": "503"}})
+		// observations without result fields, as written by older versions
+		writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true})
+
+		options := nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(options)
+		}).Should(HaveLen(3))
+
+		options.FilterResultFields = map[string]string{"httpStatus": "200"}
+		result, err := writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].ResultFields).To(Equal(map[string]string{"httpStatus": "200", "certDaysRemaining": "42"}))
+	})
+
+	It("filters by regular expressions in addition to exact matches", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+
+		now := time.Now()
+		writer.Add(&nwpd.Observation{JobID: "tcp-n2n", SrcHost: "node1", DestHost: "10.0.1.2", Timestamp: timestamppb.New(now), Ok: true})
+		writer.Add(&nwpd.Observation{JobID: "tcp-n2api", SrcHost: "node1", DestHost: "10.0.2.1", Timestamp: timestamppb.New(now), Ok: true})
+		writer.Add(&nwpd.Observation{JobID: "tcp-n2n", SrcHost: "node2", DestHost: "110.0.1.1", Timestamp: timestamppb.New(now), Ok: true})
+		writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node2", DestHost: "10.0.1.1", Timestamp: timestamppb.New(now), Ok: true})
+
+		options := nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(options)
+		}).Should(HaveLen(4))
+
+		options.FilterJobIDRegex = "^tcp-"
+		options.FilterDestHostRegex = `^10\.0\.`
+		result, err := writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(result).To(HaveLen(2))
+
+		options.FilterJobIDs = []string{"tcp-n2n"}
+		options.FilterSrcHostRegex = "1$"
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].DestHost).To(Equal("10.0.1.2"))
+
+		options.FilterSrcHostRegex = "node("
+		_, err = writer.ListObservations(options)
+		var filterErr *nwpd.InvalidFilterError
+		Expect(errors.As(err, &filterErr)).To(BeTrue())
+		Expect(filterErr.Field).To(Equal("srcHostRegex"))
+	})
+
+	It("filters by result pattern, minimum duration and failure streaks", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+
+		now := time.Now().Truncate(time.Millisecond).Add(-time.Second)
+		add := func(i int, jobID, destHost string, ok bool, result string) {
+			writer.Add(&nwpd.Observation{JobID: jobID, SrcHost: "node1", DestHost: destHost, Ok: ok,
+				Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Millisecond)),
+				Duration:  durationpb.New(time.Duration(i) * time.Millisecond), ResultFields: map[string]string{"state": result}})
+		}
+		// failures of tcp to node2 at 0, 1, 2, 6, 7, of tcp to node3 at 3, 8, of ping to node2 at 4, 5, 9, 10
+		add(0, "tcp", "node2", false, "refused")
+		add(1, "tcp", "node2", false, "refused")
+		add(2, "tcp", "node2", false, "timeout")
+		add(3, "tcp", "node3", false, "refused")
+		add(4, "ping", "node2", false, "timeout")
+		add(5, "ping", "node2", false, "timeout")
+		add(6, "tcp", "node2", false, "refused")
+		add(7, "tcp", "node2", false, "refused")
+		add(8, "tcp", "node3", false, "refused")
+		add(9, "ping", "node2", false, "timeout")
+		add(10, "ping", "node2", false, "timeout")
+		add(11, "tcp", "node2", true, "connected")
+		add(12, "tcp", "node3", true, "connected")
+
+		durations := func(result nwpd.Observations) []int {
+			var ms []int
+			for _, obs := range result {
+				ms = append(ms, int(obs.Duration.AsDuration().Milliseconds()))
+			}
+			return ms
+		}
+		options := nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(options)
+		}).Should(HaveLen(13))
+
+		options.MinFailureStreak = 3
+		result, err := writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(durations(result)).To(Equal([]int{0, 1, 2, 4, 5, 6, 7, 9, 10}))
+
+		options.FilterJobIDs = []string{"tcp"}
+		options.MinDuration = time.Millisecond
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(durations(result)).To(Equal([]int{2, 6, 7}), "the streak is detected before filtering by duration")
+
+		options.FilterResultRegex = "^state=ref"
+		options.SortBy = nwpd.SortByDuration
+		options.SortDescending = true
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(durations(result)).To(Equal([]int{7, 6}))
+
+		options = nwpd.ListObservationsOptions{Start: now.Add(-time.Minute), FilterResultRegex: "timeout", MinDuration: 4 * time.Millisecond}
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(durations(result)).To(Equal([]int{5, 9, 10}))
+
+		var filterErr *nwpd.InvalidFilterError
+		options.FilterResultRegex = "state=("
+		_, err = writer.ListObservations(options)
+		Expect(errors.As(err, &filterErr)).To(BeTrue())
+		Expect(filterErr.Field).To(Equal("resultPattern"))
+
+		options = nwpd.ListObservationsOptions{Start: now.Add(-time.Minute), MinFailureStreak: -1}
+		_, err = writer.ListObservations(options)
+		Expect(errors.As(err, &filterErr)).To(BeTrue())
+		Expect(filterErr.Field).To(Equal("onlyFailureStreaksOfAtLeast"))
+	})
+
+	DescribeTable("passes failures of long enough streaks in order",
+		func(minLength int, outcomes string, expected []int) {
+			f := newFailureStreakFilter(minLength)
+			var passed []int
+			collect := func(observations nwpd.Observations) {
+				for _, obs := range observations {
+					passed = append(passed, int(obs.Timestamp.AsTime().UnixMilli()))
+				}
+			}
+			// each outcome is the edge (a or b) in upper case for a failure
+			for i, c := range outcomes {
+				collect(f.add(&nwpd.Observation{JobID: "job", SrcHost: "node1", DestHost: strings.ToLower(string(c)),
+					Ok: c >= 'a', Timestamp: timestamppb.New(time.UnixMilli(int64(i)))}))
+			}
+			collect(f.flush())
+			if expected == nil {
+				Expect(passed).To(BeEmpty())
+				return
+			}
+			Expect(passed).To(Equal(expected))
+		},
+		Entry("single failures", 1, "AaBA", []int{0, 2, 3}),
+		Entry("streak ended by success", 2, "AaAAa", []int{2, 3}),
+		Entry("interleaved edges", 2, "ABAbA", []int{0, 2, 4}),
+		Entry("streak held back behind pending failure", 3, "BAAAbB", []int{1, 2, 3}),
+		Entry("streak at end", 2, "aAA", []int{1, 2}),
+		Entry("short streak at end", 3, "AAaBB", nil),
+	)
+
+	It("lists pages after a cursor", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+
+		now := time.Now().Truncate(time.Millisecond)
+		for i := 0; i < 3; i++ {
+			writer.Add(&nwpd.Observation{JobID: "j1", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now.Add(-time.Duration(i) * time.Second)), Ok: true})
+		}
+		// observations at the same position
+		for i := 0; i < 3; i++ {
+			writer.Add(&nwpd.Observation{JobID: "j2", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now.Add(-time.Second)), Ok: i != 1})
+		}
+
+		options := nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(options)
+		}).Should(HaveLen(6))
+		all, err := writer.ListObservations(options)
+		Expect(err).To(BeNil())
+
+		var paged nwpd.Observations
+		options.Limit = 2
+		for range 4 {
+			page, err := writer.ListObservations(options)
+			Expect(err).To(BeNil())
+			if len(page) == 0 {
+				break
+			}
+			paged = append(paged, page...)
+			cursor, err := nwpd.ParsePageToken(nwpd.NextCursor(page, options.After).Token())
+			Expect(err).To(BeNil())
+			options.After = cursor
+		}
+		Expect(paged).To(HaveLen(6))
+		for i := range all {
+			Expect(paged[i].JobID).To(Equal(all[i].JobID))
+			Expect(paged[i].Timestamp.AsTime()).To(Equal(all[i].Timestamp.AsTime()))
+			Expect(paged[i].Ok).To(Equal(all[i].Ok))
+		}
+	})
+
+	It("sorts before applying the limit", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+
+		now := time.Now().Truncate(time.Millisecond)
+		jobIDs := []string{"tcp", "https", "ping"}
+		for i := 0; i < 30; i++ {
+			// durations 1ms to 30ms in scrambled order
+			duration := time.Duration(1+(i*7)%30) * time.Millisecond
+			writer.Add(&nwpd.Observation{JobID: jobIDs[i%3], SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now.Add(-time.Duration(30-i) * time.Second)),
+				Duration: durationpb.New(duration), Ok: true})
+		}
+		// observation without duration
+		writer.Add(&nwpd.Observation{JobID: "tcp", SrcHost: "node1", DestHost: "node3", Timestamp: timestamppb.New(now)})
+
+		options := nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)}
+		Eventually(func() (nwpd.Observations, error) {
+			return writer.ListObservations(options)
+		}).Should(HaveLen(31))
+
+		durationsOf := func(result nwpd.Observations) []time.Duration {
+			var durations []time.Duration
+			for _, obs := range result {
+				durations = append(durations, obs.Duration.AsDuration())
+			}
+			return durations
+		}
+		options.Limit = 4
+		options.SortBy = nwpd.SortByDuration
+		options.SortDescending = true
+		result, err := writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(durationsOf(result)).To(Equal([]time.Duration{30 * time.Millisecond, 29 * time.Millisecond, 28 * time.Millisecond, 27 * time.Millisecond}))
+
+		options.SortDescending = false
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(durationsOf(result)).To(Equal([]time.Duration{0, 1 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}))
+
+		// equal job IDs are ordered by timestamp
+		options.SortBy = nwpd.SortByJobID
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		for i, obs := range result {
+			Expect(obs.JobID).To(Equal("https"))
+			Expect(obs.Timestamp.AsTime()).To(BeTemporally("==", now.Add(-time.Duration(29-3*i)*time.Second)))
+		}
+
+		options.SortBy = nwpd.SortByTimestamp
+		options.SortDescending = true
+		result, err = writer.ListObservations(options)
+		Expect(err).To(BeNil())
+		Expect(result[0].DestHost).To(Equal("node3"))
+		Expect(result[1].Timestamp.AsTime()).To(BeTemporally("==", now.Add(-time.Second)))
+
+		options.After = &nwpd.Cursor{TimeNanos: now.Add(-time.Minute).UnixNano()}
+		_, err = writer.ListObservations(options)
+		var filterErr *nwpd.InvalidFilterError
+		Expect(errors.As(err, &filterErr)).To(BeTrue())
+		Expect(filterErr.Field).To(Equal("pageToken"))
+
+		options.After = nil
+		options.SortBy = "destHost"
+		_, err = writer.ListObservations(options)
+		Expect(errors.As(err, &filterErr)).To(BeTrue())
+		Expect(filterErr.Field).To(Equal("sortBy"))
+	})
+
+	It("writes the buffered observations on stop", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+
+		now := time.Now()
+		for i := 0; i < 50; i++ {
+			writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true})
+		}
+		writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), IncidentID: "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+			SrcZone: "zone-a", DestZone: "unknown"})
+		go writer.Run()
+		writer.Stop()
+
+		result, err := writer.ListObservations(nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)})
+		Expect(err).To(BeNil())
+		Expect(result).To(HaveLen(51))
+		Expect(result[50].IncidentID).To(Equal("01ARZ3NDEKTSV4RRFFQ69G5FAV"))
+		Expect(result[50].SrcZone).To(Equal("zone-a"))
+		Expect(result[50].DestZone).To(Equal("unknown"))
+		Expect(result[0].DestZone).To(BeEmpty())
+	})
+
+	It("persists job run records along with the observations", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+
+		now := time.Now()
+		writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true})
+		writer.AddJobRun(&nwpd.JobRunRecord{Kind: nwpd.JobRunKindRun, JobID: "ping", SrcHost: "node1", Start: timestamppb.New(now),
+			End: timestamppb.New(now.Add(time.Second)), Period: durationpb.New(10 * time.Second), Delay: durationpb.New(2 * time.Second),
+			DestHosts: []string{"node2", "node3"}, DroppedObservations: 1})
+		writer.AddJobRun(&nwpd.JobRunRecord{Kind: nwpd.JobRunKindCancelled, JobID: "ping", SrcHost: "node1", Start: timestamppb.New(now), Reason: "disabled"})
+		writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node3", Timestamp: timestamppb.New(now), Ok: true})
+		go writer.Run()
+		writer.Stop()
+
+		result, err := writer.ListObservations(nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)})
+		Expect(err).To(BeNil())
+		Expect(result).To(HaveLen(2))
+
+		filenames, err := GetAnyRecordFiles(dir, false)
+		Expect(err).To(BeNil())
+		Expect(filenames).To(HaveLen(1))
+		var runs []*nwpd.JobRunRecord
+		count := 0
+		err = IterateRecordFileWithJobRuns(filenames[0], func(_ *nwpd.Observation) error {
+			count++
+			return nil
+		}, func(record *nwpd.JobRunRecord) error {
+			runs = append(runs, record)
+			return nil
+		})
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(2))
+		Expect(runs).To(HaveLen(2))
+		Expect(runs[0].Kind).To(Equal(nwpd.JobRunKindRun))
+		Expect(runs[0].DestHosts).To(Equal([]string{"node2", "node3"}))
+		Expect(runs[0].Delay.AsDuration()).To(Equal(2 * time.Second))
+		Expect(runs[0].DroppedObservations).To(Equal(int32(1)))
+		Expect(runs[1].Kind).To(Equal(nwpd.JobRunKindCancelled))
+		Expect(runs[1].Reason).To(Equal("disabled"))
+
+		// the job run records are skipped by the observation iteration
+		count = 0
+		Expect(IterateRecordFile(filenames[0], func(_ *nwpd.Observation) error {
+			count++
+			return nil
+		})).To(Succeed())
+		Expect(count).To(Equal(2))
+	})
+
+	Describe("iteration", func() {
+		var (
+			dir     string
+			base    time.Time
+			options nwpd.ListObservationsOptions
+		)
+
+		writeObservations := func(offsets ...int) {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			for _, offset := range offsets {
+				writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: fmt.Sprintf("node%d", offset),
+					Timestamp: timestamppb.New(base.Add(time.Duration(offset) * time.Second)), Ok: true})
+			}
+			go writer.Run()
+			writer.Stop()
+		}
+		iterate := func(options nwpd.ListObservationsOptions, stopAfter int) ([]int, error) {
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			var offsets []int
+			err = reader.IterateObservations(options, func(obs *nwpd.Observation) (bool, error) {
+				offsets = append(offsets, int(obs.Timestamp.AsTime().Sub(base)/time.Second))
+				return len(offsets) == stopAfter, nil
+			})
+			return offsets, err
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			// the observations with offsets 0 to 2 are at the end of the previous hour
+			base = startOfHourUTC(time.Now()).Add(-3 * time.Second)
+			options = nwpd.ListObservationsOptions{Start: base.Add(-time.Hour), End: base.Add(time.Hour)}
+			writeObservations(0, 2)
+			// the file of the previous hour
+			filenames, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			Expect(filenames).To(HaveLen(1))
+			previous := recordFilename(dir, "test", startOfHourUTC(time.Now()).Add(-time.Hour), false)
+			Expect(os.Rename(filenames[0], previous)).To(Succeed())
+			// includes observations at the end of the previous hour written to the file of the next hour
+			writeObservations(5, 1, 3, 4)
+		})
+
+		It("visits the observations of all files ordered by timestamp", func() {
+			offsets, err := iterate(options, 0)
+			Expect(err).To(BeNil())
+			Expect(offsets).To(Equal([]int{0, 1, 2, 3, 4, 5}))
+
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			options.Limit = 3
+			result, err := reader.ListObservations(options)
+			Expect(err).To(BeNil())
+			Expect(result).To(HaveLen(3))
+			Expect(result[2].DestHost).To(Equal("node2"))
+		})
+
+		It("stops on request of the visitor or at the limit", func() {
+			offsets, err := iterate(options, 2)
+			Expect(err).To(BeNil())
+			Expect(offsets).To(Equal([]int{0, 1}))
+
+			options.Limit = 4
+			offsets, err = iterate(options, 0)
+			Expect(err).To(BeNil())
+			Expect(offsets).To(Equal([]int{0, 1, 2, 3}))
+
+			options.Limit = 0
+			options.Start = base.Add(2500 * time.Millisecond)
+			offsets, err = iterate(options, 0)
+			Expect(err).To(BeNil())
+			Expect(offsets).To(Equal([]int{3, 4, 5}))
+		})
+
+		It("reads the file of the next hour for a time range ending before the rotation", func() {
+			options.End = base.Add(2500 * time.Millisecond)
+			offsets, err := iterate(options, 0)
+			Expect(err).To(BeNil())
+			Expect(offsets).To(Equal([]int{0, 1, 2}))
+
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			options.SortBy = nwpd.SortByTimestamp
+			options.SortDescending = true
+			result, err := reader.ListObservations(options)
+			Expect(err).To(BeNil())
+			Expect(result).To(HaveLen(3))
+			Expect(result[0].DestHost).To(Equal("node2"))
+			Expect(result[1].DestHost).To(Equal("node1"))
+		})
+
+		It("returns the error of the visitor", func() {
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			visitErr := errors.New("send failed")
+			count := 0
+			err = reader.IterateObservations(options, func(_ *nwpd.Observation) (bool, error) {
+				count++
+				return false, visitErr
+			})
+			Expect(err).To(Equal(visitErr))
+			Expect(count).To(Equal(1))
+		})
+
+		It("rejects other orders", func() {
+			_, err := iterate(nwpd.ListObservationsOptions{SortBy: nwpd.SortByDuration}, 0)
+			var filterErr *nwpd.InvalidFilterError
+			Expect(errors.As(err, &filterErr)).To(BeTrue())
+			Expect(filterErr.Field).To(Equal("sortBy"))
+		})
+	})
+
+	Describe("corruption", func() {
+		var (
+			dir string
+			now time.Time
+		)
+
+		type recordPos struct {
+			offset int
+			marker byte
+			size   int
+		}
+
+		writeObservations := func(compress bool, count int) string {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, compress)
+			Expect(err).To(BeNil())
+			for i := 0; i < count; i++ {
+				writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: fmt.Sprintf("node%d", i%5),
+					Timestamp: timestamppb.New(now), Ok: true})
+			}
+			go writer.Run()
+			writer.Stop()
+			filenames, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			Expect(filenames).To(HaveLen(1))
+			return filenames[0]
+		}
+		records := func(data []byte) []recordPos {
+			var result []recordPos
+			r := bytes.NewReader(data)
+			for {
+				offset := len(data) - r.Len()
+				marker, value, err := readRecord(r)
+				Expect(err).To(BeNil())
+				if value == nil {
+					return result
+				}
+				result = append(result, recordPos{offset: offset, marker: marker, size: len(data) - r.Len() - offset})
+			}
+		}
+		list := func() (nwpd.Observations, error) {
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			return reader.ListObservations(nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)})
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			now = time.Now()
+		})
+
+		DescribeTable("lists the observations before a corrupt record",
+			func(recordIndex func(n int) int, posInRecord func(size int) int) {
+				filename := writeObservations(false, 20)
+				data, err := os.ReadFile(filename)
+				Expect(err).To(BeNil())
+				positions := records(data)
+				k := recordIndex(len(positions))
+				expected := 0
+				for _, pos := range positions[:k] {
+					if pos.marker == markerObservation {
+						expected++
+					}
+				}
+				data[positions[k].offset+posInRecord(positions[k].size)] ^= 0xff
+				Expect(os.WriteFile(filename, data, 0o600)).To(Succeed())
+
+				result, err := list()
+				Expect(err).To(BeNil())
+				Expect(result).To(HaveLen(expected))
+				// the newest file is repaired on start
+				_, err = os.Stat(filename + CorruptFileSuffix)
+				Expect(err).To(BeNil())
+				_, err = os.Stat(filename)
+				Expect(os.IsNotExist(err)).To(Equal(k == 0))
+			},
+			Entry("marker of the first record", func(_ int) int { return 0 }, func(_ int) int { return 0 }),
+			Entry("length of a record in the middle", func(n int) int { return n / 2 }, func(_ int) int { return 1 }),
+			Entry("value of a record in the middle", func(n int) int { return n / 2 }, func(size int) int { return size / 2 }),
+			Entry("checksum of the last record", func(n int) int { return n - 1 }, func(size int) int { return size - 1 }),
+		)
+
+		It("reports the offset of the corrupt record", func() {
+			filename := writeObservations(false, 5)
+			data, err := os.ReadFile(filename)
+			Expect(err).To(BeNil())
+			positions := records(data)
+			last := positions[len(positions)-1]
+			data[last.offset+3] ^= 0xff
+			Expect(os.WriteFile(filename, data, 0o600)).To(Succeed())
+
+			count := 0
+			err = IterateRecordFile(filename, func(_ *nwpd.Observation) error {
+				count++
+				return nil
+			})
+			var corruptErr *CorruptRecordError
+			Expect(errors.As(err, &corruptErr)).To(BeTrue())
+			Expect(corruptErr.Offset).To(Equal(int64(last.offset)))
+			Expect(errors.Is(err, errChecksumMismatch)).To(BeTrue())
+			Expect(count).To(Equal(4))
+		})
+
+		It("skips the rest of a corrupt compressed file", func() {
+			filename := writeObservations(true, 90)
+			data, err := os.ReadFile(filename)
+			Expect(err).To(BeNil())
+			data[len(data)/2] ^= 0xff
+			Expect(os.WriteFile(filename, data, 0o600)).To(Succeed())
+
+			result, err := list()
+			Expect(err).To(BeNil())
+			Expect(len(result)).To(BeNumerically("<", 90))
+		})
+
+		It("reads records without checksum written by older versions", func() {
+			filename := writeObservations(false, 5)
+			data, err := os.ReadFile(filename)
+			Expect(err).To(BeNil())
+			var legacy bytes.Buffer
+			var lastOffset int
+			r := bytes.NewReader(data)
+			for {
+				marker, value, err := readRecord(r)
+				Expect(err).To(BeNil())
+				if value == nil {
+					break
+				}
+				lastOffset = legacy.Len()
+				legacy.WriteByte(marker)
+				Expect(binary.Write(&legacy, binary.LittleEndian, uint16(len(value)))).To(Succeed())
+				legacy.Write(value)
+			}
+			Expect(os.WriteFile(filename, legacy.Bytes(), 0o600)).To(Succeed())
+			Expect(list()).To(HaveLen(5))
+
+			// without checksum, the corruption is only detected on decoding
+			data = legacy.Bytes()
+			data[lastOffset+3] = 0xff
+			Expect(os.WriteFile(filename, data, 0o600)).To(Succeed())
+			Expect(list()).To(HaveLen(4))
+		})
+
+		It("repairs a corrupt newest file on start and appends to it", func() {
+			filename := writeObservations(false, 5)
+			data, err := os.ReadFile(filename)
+			Expect(err).To(BeNil())
+			positions := records(data)
+			data[positions[1].offset+3] ^= 0xff
+			Expect(os.WriteFile(filename, data, 0o600)).To(Succeed())
+
+			writeObservations(false, 3)
+			_, err = os.Stat(filename + CorruptFileSuffix)
+			Expect(err).To(BeNil())
+			Expect(list()).To(HaveLen(3))
+		})
+	})
+
+	Describe("compression", func() {
+		var (
+			dir string
+			now time.Time
+		)
+
+		writeObservations := func(compress bool, count int, jobID string) {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, compress)
+			Expect(err).To(BeNil())
+			for i := 0; i < count; i++ {
+				writer.Add(&nwpd.Observation{JobID: jobID, SrcHost: "node1", DestHost: fmt.Sprintf("node%d", i%5),
+					Timestamp: timestamppb.New(now), Ok: true})
+			}
+			go writer.Run()
+			writer.Stop()
+		}
+		countByJobID := func() map[string]int {
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			result, err := reader.ListObservations(nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)})
+			Expect(err).To(BeNil())
+			counts := map[string]int{}
+			for _, obs := range result {
+				counts[obs.JobID]++
+			}
+			return counts
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			now = time.Now()
+		})
+
+		It("writes compressed record files with the gzip magic bytes", func() {
+			writeObservations(true, 50, "ping")
+
+			filenames, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			Expect(filenames).To(HaveLen(1))
+			Expect(filenames[0]).To(HaveSuffix(CompressedRecordFileSuffix))
+			data, err := os.ReadFile(filenames[0])
+			Expect(err).To(BeNil())
+			Expect(data[:2]).To(Equal(gzipMagic))
+			Expect(countByJobID()).To(Equal(map[string]int{"ping": 50}))
+		})
+
+		It("appends to the compressed file of the hour after a restart", func() {
+			writeObservations(true, 10, "ping")
+			writeObservations(true, 5, "tcp")
+			filenames, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			Expect(filenames).To(HaveLen(1))
+			Expect(countByJobID()).To(Equal(map[string]int{"ping": 10, "tcp": 5}))
+		})
+
+		It("reads uncompressed and compressed files of the same hour", func() {
+			writeObservations(false, 10, "ping")
+			writeObservations(true, 5, "tcp")
+			filenames, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			Expect(filenames).To(HaveLen(2))
+			Expect(countByJobID()).To(Equal(map[string]int{"ping": 10, "tcp": 5}))
+
+			files, err := GetRecordFiles(dir, "test", now.Add(-time.Minute), now)
+			Expect(err).To(BeNil())
+			Expect(files).To(HaveLen(2))
+			Expect(files[0]).To(HaveSuffix(RecordFileSuffix))
+			Expect(files[1]).To(HaveSuffix(CompressedRecordFileSuffix))
+		})
+
+		It("lists the observations of the file being written", func() {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, true)
+			Expect(err).To(BeNil())
+			go writer.Run()
+			defer writer.Stop()
+
+			for i := 0; i < 3; i++ {
+				writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true})
+			}
+			Eventually(func() (nwpd.Observations, error) {
+				return writer.ListObservations(nwpd.ListObservationsOptions{Start: now.Add(-time.Minute)})
+			}).Should(HaveLen(3))
+		})
+
+		// moveToHour renames the record file of the current hour and its index file to the hour.
+		moveToHour := func(hour time.Time) string {
+			current := recordFilename(dir, "test", startOfHourUTC(time.Now()), false)
+			filename := recordFilename(dir, "test", hour, false)
+			Expect(os.Rename(current, filename)).To(Succeed())
+			Expect(os.Rename(current+IndexFileSuffix, filename+IndexFileSuffix)).To(Succeed())
+			return filename
+		}
+
+		It("compresses the files of past hours after rotating", func() {
+			writeObservations(false, 10, "ping")
+			previous := moveToHour(startOfHourUTC(now).Add(-time.Hour))
+			info, err := os.Stat(previous)
+			Expect(err).To(BeNil())
+			// same hour after switching the compression on
+			writeObservations(false, 3, "https")
+			older := moveToHour(startOfHourUTC(now).Add(-2 * time.Hour))
+			Expect(os.WriteFile(older+".gz", nil, 0o600)).To(Succeed())
+
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false, CompressRotatedFiles())
+			Expect(err).To(BeNil())
+			go writer.Run()
+			writer.Add(&nwpd.Observation{JobID: "tcp", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(now), Ok: true})
+			writer.SetWriteBatching(time.Minute, 1)
+			Eventually(func() string { return previous + ".gz" }).Should(BeAnExistingFile())
+			writer.Stop()
+
+			Expect(previous).NotTo(BeAnExistingFile())
+			Expect(previous + IndexFileSuffix).NotTo(BeAnExistingFile())
+			compressed, err := os.Stat(previous + ".gz")
+			Expect(err).To(BeNil())
+			Expect(compressed.Size()).To(BeNumerically("<", info.Size()))
+			Expect(compressed.ModTime()).To(BeTemporally("==", info.ModTime()))
+			idx, err := readIndexFile(previous + ".gz")
+			Expect(err).To(BeNil())
+			Expect(idx.fileSize).To(Equal(compressed.Size()))
+			Expect(idx.seekable).To(BeFalse())
+			// skipped hour with a compressed file and the current hour
+			Expect(older).To(BeAnExistingFile())
+			Expect(recordFilename(dir, "test", startOfHourUTC(time.Now()), false)).To(BeAnExistingFile())
+			now = now.Add(-2 * time.Hour)
+			Expect(countByJobID()).To(Equal(map[string]int{"ping": 10, "https": 3, "tcp": 1}))
+		})
+
+		It("reads the compressed file if the listed file has been compressed in the meantime", func() {
+			now = startOfHourUTC(now).Add(-2 * time.Hour)
+			writeObservations(false, 2, "ping")
+			moveToHour(now)
+			now = now.Add(time.Hour)
+			writeObservations(false, 3, "tcp")
+			moveToHour(now)
+
+			w := &obsWriter{log: logrus.NewEntry(logrus.StandardLogger()), directory: dir, prefix: "test", compressRotated: true}
+			var jobIDs []string
+			err := w.IterateObservations(nwpd.ListObservationsOptions{Start: now.Add(-time.Hour)}, func(obs *nwpd.Observation) (bool, error) {
+				if len(jobIDs) == 0 {
+					w.compressRotatedFiles()
+				}
+				jobIDs = append(jobIDs, obs.JobID)
+				return false, nil
+			})
+			Expect(err).To(BeNil())
+			Expect(jobIDs).To(Equal([]string{"ping", "ping", "tcp", "tcp", "tcp"}))
+			files, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			Expect(files).To(HaveLen(2))
+			Expect(files[0]).To(HaveSuffix(CompressedRecordFileSuffix))
+		})
+
+		DescribeTable("removes an incomplete record at the end of the file before appending",
+			func(compress bool) {
+				writeObservations(compress, 20, "ping")
+				filenames, err := GetAnyRecordFiles(dir, false)
+				Expect(err).To(BeNil())
+				info, err := os.Stat(filenames[0])
+				Expect(err).To(BeNil())
+				// simulates a writer stopped abruptly
+				Expect(os.Truncate(filenames[0], info.Size()-10)).To(Succeed())
+				complete, err := readRecordFile(filenames[0], func(_ byte, _ []byte) error { return nil })
+				Expect(err).To(BeNil())
+				Expect(complete).To(BeFalse())
+				pings := countByJobID()["ping"]
+				Expect(pings).To(BeNumerically(">", 0))
+
+				writeObservations(compress, 5, "tcp")
+				Expect(countByJobID()).To(Equal(map[string]int{"ping": pings, "tcp": 5}))
+				complete, err = readRecordFile(filenames[0], func(_ byte, _ []byte) error { return nil })
+				Expect(err).To(BeNil())
+				Expect(complete).To(BeTrue())
+			},
+			Entry("uncompressed", false),
+			Entry("compressed", true),
+		)
+	})
+
+	Describe("batching", func() {
+		var (
+			dir                        string
+			buffered, flushed, dropped prometheus.Counter
+		)
+
+		// written counts the observations in the record files.
+		written := func() int {
+			files, err := GetAnyRecordFiles(dir, false)
+			Expect(err).To(BeNil())
+			count := 0
+			for _, file := range files {
+				Expect(IterateRecordFile(file, func(_ *nwpd.Observation) error {
+					count++
+					return nil
+				})).To(Succeed())
+			}
+			return count
+		}
+		newObs := func(i int) *nwpd.Observation {
+			return &nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: fmt.Sprintf("node%02d", i), Timestamp: timestamppb.Now(), Ok: true}
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			buffered = prometheus.NewCounter(prometheus.CounterOpts{Name: "test_buffered"})
+			flushed = prometheus.NewCounter(prometheus.CounterOpts{Name: "test_flushed"})
+			dropped = prometheus.NewCounter(prometheus.CounterOpts{Name: "test_dropped"})
+			SetWriteCounters(WriteCounters{Buffered: buffered, Flushed: flushed, Dropped: dropped})
+		})
+
+		It("lists the batched observations before they are written", func() {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			writer.SetWriteBatching(time.Minute, 100)
+			go writer.Run()
+			for i := 0; i < 10; i++ {
+				writer.Add(newObs(i))
+			}
+			options := nwpd.ListObservationsOptions{Start: time.Now().Add(-time.Minute)}
+			Eventually(func() (nwpd.Observations, error) { return writer.ListObservations(options) }).Should(HaveLen(10))
+			Expect(written()).To(Equal(0))
+			options.SortBy = nwpd.SortByTimestamp
+			options.SortDescending = true
+			result, err := writer.ListObservations(options)
+			Expect(err).To(BeNil())
+			Expect(result).To(HaveLen(10))
+			Expect(result[0].DestHost).To(Equal("node09"))
+
+			writer.Stop()
+			Expect(written()).To(Equal(10))
+			Expect(testutil.ToFloat64(buffered)).To(Equal(10.0))
+			Expect(testutil.ToFloat64(flushed)).To(Equal(10.0))
+			Expect(testutil.ToFloat64(dropped)).To(Equal(0.0))
+		})
+
+		It("writes full batches without waiting for the flush interval", func() {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, true)
+			Expect(err).To(BeNil())
+			writer.SetWriteBatching(time.Minute, 5)
+			go writer.Run()
+			defer writer.Stop()
+			for i := 0; i < 12; i++ {
+				writer.Add(newObs(i))
+			}
+			Eventually(written).Should(Equal(10))
+			Eventually(func() float64 { return testutil.ToFloat64(buffered) }).Should(Equal(12.0))
+			Expect(testutil.ToFloat64(flushed)).To(Equal(10.0))
+			// each observation is listed once from either the files or the batch
+			result, err := writer.ListObservations(nwpd.ListObservationsOptions{Start: time.Now().Add(-time.Minute)})
+			Expect(err).To(BeNil())
+			Expect(result).To(HaveLen(12))
+			destHosts := map[string]bool{}
+			for _, obs := range result {
+				destHosts[obs.DestHost] = true
+			}
+			Expect(destHosts).To(HaveLen(12))
+		})
+
+		It("counts the records which cannot be written", func() {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			w := writer.(*obsWriter)
+			Expect(os.RemoveAll(dir)).To(Succeed())
+			Expect(os.WriteFile(dir, nil, 0o600)).To(Succeed())
+			w.addToBatch(batchRecord{obs: newObs(1)})
+			w.addToBatch(batchRecord{run: &nwpd.JobRunRecord{JobID: "ping"}})
+			w.flushBatch(false)
+			Expect(testutil.ToFloat64(buffered)).To(Equal(2.0))
+			Expect(testutil.ToFloat64(flushed)).To(Equal(0.0))
+			Expect(testutil.ToFloat64(dropped)).To(Equal(2.0))
+			Expect(os.Remove(dir)).To(Succeed())
+		})
+
+		It("drops the records without waiting while the writer is stalled", func() {
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			w := writer.(*obsWriter)
+			for i := 0; i < cap(w.obsChan); i++ {
+				writer.Add(newObs(i))
+			}
+			start := time.Now()
+			writer.Add(newObs(100))
+			Expect(time.Since(start)).To(BeNumerically(">=", addTimeout))
+			Expect(w.stalled.Load()).To(BeTrue())
+			start = time.Now()
+			writer.Add(newObs(101))
+			writer.AddJobRun(&nwpd.JobRunRecord{JobID: "ping"})
+			Expect(time.Since(start)).To(BeNumerically("<", addTimeout))
+			Expect(testutil.ToFloat64(dropped)).To(Equal(2.0))
+
+			go writer.Run()
+			Eventually(func() int { return len(w.obsChan) }).Should(BeZero())
+			writer.Add(newObs(102))
+			Expect(w.stalled.Load()).To(BeFalse())
+			writer.Stop()
+			Expect(written()).To(Equal(cap(w.obsChan) + 1))
+		})
+	})
+
+	Describe("index", func() {
+		var (
+			dir  string
+			base time.Time
+		)
+
+		// moveFile renames the record file and its index file if they exist.
+		moveFile := func(from, to string) {
+			for _, suffix := range []string{"", IndexFileSuffix} {
+				if err := os.Rename(from+suffix, to+suffix); err != nil {
+					Expect(os.IsNotExist(err)).To(BeTrue())
+				}
+			}
+		}
+		// writeObservations writes the observations with timestamps 100ms apart starting at base and closes the file.
+		// The file is moved to the hour of base, so that the observations are not written to the file of a later hour.
+		writeObservations := func(compress bool, count int, late ...time.Time) string {
+			filename := recordFilename(dir, "test", startOfHourUTC(base), compress)
+			current := recordFilename(dir, "test", startOfHourUTC(time.Now()), compress)
+			// the writer appends to the file of the current hour
+			moveFile(filename, current)
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, compress)
+			Expect(err).To(BeNil())
+			w := writer.(*obsWriter)
+			for i := 0; i < count; i++ {
+				w.addToBatch(batchRecord{obs: &nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: fmt.Sprintf("node%d", i),
+					Timestamp: timestamppb.New(base.Add(time.Duration(i) * 100 * time.Millisecond)), Ok: true}})
+			}
+			for _, t := range late {
+				w.addToBatch(batchRecord{obs: &nwpd.Observation{JobID: "late", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.New(t), Ok: true}})
+			}
+			w.flushBatch(false)
+			file, _ := w.currentFile.Load().(*writeFile)
+			Expect(file.close()).To(Succeed())
+			Expect(file.filename).To(Equal(current))
+			moveFile(current, filename)
+			return filename
+		}
+		list := func(start, end time.Time) nwpd.Observations {
+			reader, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			result, err := reader.ListObservations(nwpd.ListObservationsOptions{Start: start, End: end})
+			Expect(err).To(BeNil())
+			return result
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			// all observations are in the previous hour
+			base = startOfHourUTC(time.Now()).Add(-50 * time.Minute)
+		})
+
+		It("starts reading within the file with the same results as reading the whole file", func() {
+			filename := writeObservations(false, 2000, base.Add(-5*time.Minute))
+			Expect(filename + IndexFileSuffix).To(BeAnExistingFile())
+
+			idx, err := readIndexFile(filename)
+			Expect(err).To(BeNil())
+			Expect(idx.records).To(BeNumerically(">", 2000))
+			Expect(idx.entries).To(HaveLen(int(idx.records-1) / indexInterval))
+			Expect(idx.minMillis).To(Equal(base.Add(-5 * time.Minute).UnixMilli()))
+			Expect(idx.maxMillis).To(Equal(base.Add(1999 * 100 * time.Millisecond).UnixMilli()))
+			from, strings, ok := idx.seek(base.Add(150 * time.Second).UnixMilli())
+			Expect(ok).To(BeTrue())
+			Expect(from.records).To(BeNumerically(">", 1000))
+			Expect(strings).NotTo(BeEmpty())
+
+			result := list(base.Add(150*time.Second), base.Add(160*time.Second))
+			Expect(result).To(HaveLen(101))
+			Expect(result[0].DestHost).To(Equal("node1500"))
+			Expect(result[100].DestHost).To(Equal("node1600"))
+
+			// the observation written last is older than all others
+			result = list(base.Add(-6*time.Minute), base.Add(-4*time.Minute))
+			Expect(result).To(HaveLen(1))
+			Expect(result[0].JobID).To(Equal("late"))
+			Expect(list(base.Add(-time.Hour), time.Now())).To(HaveLen(2001))
+		})
+
+		It("skips files without observations in the time range", func() {
+			filename := writeObservations(true, 300)
+			idx, err := readIndexFile(filename)
+			Expect(err).To(BeNil())
+			Expect(idx.seekable).To(BeFalse())
+			Expect(idx.entries).To(BeEmpty())
+			Expect(idx.overlaps(base.Add(-time.Hour).UnixMilli(), base.Add(-time.Second).UnixMilli())).To(BeFalse())
+			Expect(idx.overlaps(base.Add(-time.Hour).UnixMilli(), base.UnixMilli())).To(BeTrue())
+
+			Expect(list(base.Add(-time.Hour), base.Add(-time.Second))).To(BeEmpty())
+			Expect(list(base.Add(10*time.Second), base.Add(20*time.Second))).To(HaveLen(101))
+		})
+
+		It("rebuilds outdated or invalid index files", func() {
+			filename := writeObservations(false, 300)
+			outdated, err := os.ReadFile(filename + IndexFileSuffix)
+			Expect(err).To(BeNil())
+			// appended after a restart
+			base = base.Add(time.Minute)
+			writeObservations(false, 300)
+			Expect(list(base.Add(-time.Hour), time.Now())).To(HaveLen(600))
+
+			Expect(os.WriteFile(filename+IndexFileSuffix, outdated, 0o600)).To(Succeed())
+			Expect(list(base.Add(10*time.Second), time.Now())).To(HaveLen(200))
+			Expect(os.WriteFile(filename+IndexFileSuffix, []byte("invalid"), 0o600)).To(Succeed())
+			Expect(list(base.Add(-time.Hour), time.Now())).To(HaveLen(600))
+			idx, err := readIndexFile(filename)
+			Expect(err).To(BeNil())
+			info, err := os.Stat(filename)
+			Expect(err).To(BeNil())
+			Expect(idx.fileSize).To(Equal(info.Size()))
+			Expect(idx.records).To(BeNumerically(">", 600))
+		})
+	})
+
+	Describe("disk usage", func() {
+		var (
+			dir   string
+			w     *obsWriter
+			gauge prometheus.Gauge
+		)
+
+		createFile := func(name string, size int, age time.Duration) {
+			filename := path.Join(dir, name)
+			// a single valid record of the given size
+			var buf bytes.Buffer
+			Expect(writeRecord(&buf, markerOpen, make([]byte, size-7))).To(Succeed())
+			Expect(os.WriteFile(filename, buf.Bytes(), 0o600)).To(Succeed())
+			modTime := time.Now().Add(-age)
+			Expect(os.Chtimes(filename, modTime, modTime)).To(Succeed())
+		}
+		existing := func() []string {
+			entries, err := os.ReadDir(dir)
+			Expect(err).To(BeNil())
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			createFile("test-2022-01-01-00.records", 1000, 5*time.Minute)
+			createFile("test-2022-01-01-01.records.gz", 1000, 3*time.Minute)
+			// same hour after switching the compression off
+			createFile("test-2022-01-01-01.records", 1000, 2*time.Minute)
+			createFile("test-2022-01-01-02.records", 1000, time.Minute)
+			createFile("other-2022-01-01-00.records", 1000, 5*time.Minute)
+			gauge = prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_disk_usage"})
+			SetDiskUsageGauge(gauge)
+			writer, err := NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+			Expect(err).To(BeNil())
+			w = writer.(*obsWriter)
+			DeferCleanup(func() {
+				if file, _ := w.currentFile.Load().(*writeFile); file != nil {
+					Expect(file.close()).To(Succeed())
+				}
+			})
+		})
+
+		write := func() {
+			w.addToBatch(batchRecord{obs: &nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.Now(), Ok: true}})
+			w.flushBatch(false)
+		}
+
+		It("tracks the total size of the record files", func() {
+			write()
+			current := w.currentFileSize()
+			Expect(current).To(BeNumerically(">", 0))
+			Expect(w.diskUsage()).To(Equal(4000 + current))
+			write()
+			Expect(w.currentFileSize()).To(BeNumerically(">", current))
+			Expect(testutil.ToFloat64(gauge)).To(Equal(float64(w.diskUsage())))
+		})
+
+		It("deletes the oldest files exceeding the limit before writing", func() {
+			write()
+			current := w.currentFilename()
+			w.SetMaxDiskUsage(2500)
+			write()
+			Expect(existing()).To(ConsistOf(current, "test-2022-01-01-01.records", "test-2022-01-01-02.records", "other-2022-01-01-00.records"))
+			Expect(w.diskUsage()).To(BeNumerically("<=", 2500))
+
+			// the current file is never deleted
+			w.SetMaxDiskUsage(1)
+			write()
+			Expect(existing()).To(ConsistOf(current, "other-2022-01-01-00.records"))
+			Expect(w.diskUsage()).To(Equal(w.currentFileSize()))
+			Expect(testutil.ToFloat64(gauge)).To(Equal(float64(w.currentFileSize())))
+		})
+
+		It("keeps all files without limit", func() {
+			write()
+			w.SetMaxDiskUsage(0)
+			write()
+			Expect(existing()).To(HaveLen(6))
+		})
+	})
+
+	It("omits the destinations of oversized job run records", func() {
+		record := &nwpd.JobRunRecord{Kind: nwpd.JobRunKindRun, JobID: "ping", SrcHost: "node1"}
+		for i := 0; i < 10000; i++ {
+			record.DestHosts = append(record.DestHosts, fmt.Sprintf("node-%05d", i))
+		}
+		value, err := jobRunToBytes(record)
+		Expect(err).To(BeNil())
+		Expect(len(value)).To(BeNumerically("<=", math.MaxUint16))
+		decoded := &nwpd.JobRunRecord{}
+		Expect(proto.Unmarshal(value, decoded)).To(Succeed())
+		Expect(decoded.DestHosts).To(BeEmpty())
+		Expect(decoded.DestHostsOmitted).To(BeTrue())
+		Expect(record.DestHosts).To(HaveLen(10000))
+	})
+})
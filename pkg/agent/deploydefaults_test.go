@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/deploy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("default deployment", func() {
+	It("schedules the pod discovery and kube-apiserver jobs without in-cluster access", func() {
+		deployConfig := &deploy.AgentDeployConfig{Image: "image:tag", DefaultPeriod: 16 * time.Second}
+		cfg, err := deployConfig.BuildAgentConfig()
+		Expect(err).To(BeNil())
+		Expect(cfg.Environment).To(Equal(config.EnvironmentKubernetes))
+		Expect(cfg.K8sExporter).To(BeNil())
+		Expect(cfg.SecretRefs()).To(BeEmpty())
+
+		dir := GinkgoT().TempDir()
+		cfg.OutputDir = filepath.Join(dir, "records")
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		data, err := yaml.Marshal(cfg)
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+		var (
+			nodes []*corev1.Node
+			pods  []*corev1.Pod
+		)
+		for i, name := range []string{"node-a", "node-b"} {
+			nodes = append(nodes, &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status:     corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: fmt.Sprintf("10.250.0.%d", i+1)}}},
+			})
+			pods = append(pods, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "nwpd-agent-pod-net-" + name, UID: types.UID("uid-" + name)},
+				Spec:       corev1.PodSpec{NodeName: name},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					PodIP:      fmt.Sprintf("100.96.%d.2", i+1),
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				},
+			})
+		}
+		clusterConfig, err := deploy.BuildClusterConfig(logrus.NewEntry(logrus.StandardLogger()), nodes, pods,
+			&config.Endpoint{Hostname: "kubernetes.default.svc.cluster.local.", IP: "100.64.0.1", Port: 443},
+			&config.Endpoint{Hostname: "api.example.com", IP: "192.0.2.1", Port: 443}, nil)
+		Expect(err).To(BeNil())
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		data, err = yaml.Marshal(clusterConfig)
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(clusterConfigFile, data, 0o600)).To(Succeed())
+
+		for hostNetwork, jobIDs := range map[bool][]string{
+			true:  {"tcp-n2api-int", "tcp-n2p", "tcp-n2api-ext", "https-n2api-ext"},
+			false: {"tcp-p2api-int", "https-p2api-int", "tcp-p2p", "nslookup-p"},
+		} {
+			status, err := agent.JobStatusOfConfigFiles(agentConfigFile, clusterConfigFile, filepath.Join(dir, "log"), hostNetwork)
+			Expect(err).To(BeNil())
+			Expect(status.Environment).To(Equal(config.EnvironmentKubernetes))
+			for _, jobID := range jobIDs {
+				Expect(status.Jobs).To(ContainElement(And(HaveField("JobID", jobID), HaveField("Skipped", false))), jobID)
+			}
+		}
+	})
+})
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+)
+
+// envKubernetesServiceHost is set by the kubelet in all containers of a Kubernetes pod.
+const envKubernetesServiceHost = "KUBERNETES_SERVICE_HOST"
+
+// kubernetesOnlyJobFlags are the job flags selecting destinations which only exist within a Kubernetes cluster.
+var kubernetesOnlyJobFlags = map[string]string{
+	"--endpoint-internal-kube-apiserver": "internal kube-apiserver endpoint",
+	"--name-internal-kube-apiserver":     "internal kube-apiserver DNS name",
+	"--endpoints-of-pod-ds":              "pod discovery of the agent daemon set",
+}
+
+// detectEnvironment returns `kubernetes` if the agent runs in a pod, i.e. the Kubernetes service host or the pod UID
+// of the downward API is set, otherwise `standalone`. The service account token is not required, as it is only mounted
+// for the k8s exporter and secret references, but the pod discovery and the kube-apiserver checks use the cluster config.
+func detectEnvironment(getenv func(string) string) string {
+	if getenv(envKubernetesServiceHost) != "" || getenv(common.EnvPodUID) != "" {
+		return config.EnvironmentKubernetes
+	}
+	return config.EnvironmentStandalone
+}
+
+// environmentOf returns the environment given by the command line option, the agent configuration, or the detected one, in this order.
+func environmentOf(override string, cfg *config.AgentConfig) (env string, detected bool, err error) {
+	env = override
+	if env == "" {
+		env = cfg.Environment
+	}
+	switch env {
+	case config.EnvironmentKubernetes, config.EnvironmentStandalone:
+		return env, false, nil
+	case "":
+		return detectEnvironment(os.Getenv), true, nil
+	default:
+		return "", false, fmt.Errorf("invalid environment %q, must be %s or %s", env, config.EnvironmentKubernetes, config.EnvironmentStandalone)
+	}
+}
+
+// disabledFeaturesOf returns the features disabled in the standalone environment with the reason.
+func disabledFeaturesOf(env string, cfg *config.AgentConfig) []string {
+	if env != config.EnvironmentStandalone {
+		return nil
+	}
+	features := []string{
+		"pod discovery: the cluster configuration is static",
+		"kube-apiserver checks of the cluster: no in-cluster access",
+	}
+	if len(cfg.SecretRefs()) > 0 {
+		features = append(features, "secret references (jobs and remote write): no in-cluster access")
+	}
+	if cfg.K8sExporter != nil && cfg.K8sExporter.Enabled {
+		features = append(features, "k8sExporter (node conditions and events): no in-cluster access")
+	}
+	if os.Getenv(common.EnvNodeName) == "" {
+		features = append(features, fmt.Sprintf("node name from downward API: using host name %s", getNodeName()))
+	}
+	if os.Getenv(common.EnvPodUID) == "" {
+		features = append(features, "pod identity: no pod UID")
+	}
+	return features
+}
+
+// kubernetesOnlyReasonOf returns the reason why the job cannot run in the standalone environment, or an empty string.
+func kubernetesOnlyReasonOf(args []string) string {
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if what, ok := kubernetesOnlyJobFlags[name]; ok {
+			return fmt.Sprintf("%s requires the %s environment", what, config.EnvironmentKubernetes)
+		}
+		if strings.Contains(arg, config.SecretRefPrefix) {
+			return errNoSecretAccess.Error()
+		}
+	}
+	return ""
+}
+
+// logEnvironment logs a single summary of the environment and the features disabled because of it.
+func (s *server) logEnvironment(detected bool) {
+	source := "configured"
+	if detected {
+		source = "detected"
+	}
+	if len(s.disabledFeatures) == 0 {
+		s.log.Infof("environment %s (%s)", s.environment, source)
+		return
+	}
+	s.log.Infof("environment %s (%s), disabled features: %s", s.environment, source, strings.Join(s.disabledFeatures, "; "))
+}
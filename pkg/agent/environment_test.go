@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("environment", func() {
+	It("detects kubernetes by the service host or the downward API without a service account token", func() {
+		envOf := func(name, value string) func(string) string {
+			return func(key string) string {
+				if key == name {
+					return value
+				}
+				return ""
+			}
+		}
+
+		Expect(detectEnvironment(envOf("", ""))).To(Equal(config.EnvironmentStandalone))
+		Expect(detectEnvironment(envOf(common.EnvNodeName, "vm"))).To(Equal(config.EnvironmentStandalone))
+		Expect(detectEnvironment(envOf(envKubernetesServiceHost, "10.0.0.1"))).To(Equal(config.EnvironmentKubernetes))
+		Expect(detectEnvironment(envOf(common.EnvPodUID, "8a7e1c2d"))).To(Equal(config.EnvironmentKubernetes))
+	})
+
+	It("prefers the command line option over the configuration", func() {
+		cfg := &config.AgentConfig{Environment: config.EnvironmentKubernetes}
+		env, detected, err := environmentOf(config.EnvironmentStandalone, cfg)
+		Expect(err).To(BeNil())
+		Expect(detected).To(BeFalse())
+		Expect(env).To(Equal(config.EnvironmentStandalone))
+
+		env, _, err = environmentOf("", cfg)
+		Expect(err).To(BeNil())
+		Expect(env).To(Equal(config.EnvironmentKubernetes))
+
+		_, detected, err = environmentOf("", &config.AgentConfig{})
+		Expect(err).To(BeNil())
+		Expect(detected).To(BeTrue())
+
+		_, _, err = environmentOf("vm", cfg)
+		Expect(err).To(MatchError(ContainSubstring("invalid environment")))
+	})
+
+	It("identifies jobs which require kubernetes", func() {
+		Expect(kubernetesOnlyReasonOf([]string{"checkTCPPort", "--endpoint-internal-kube-apiserver", "--scale-period"})).To(ContainSubstring("internal kube-apiserver"))
+		Expect(kubernetesOnlyReasonOf([]string{"nslookup", "--name-internal-kube-apiserver=true"})).To(ContainSubstring("internal kube-apiserver"))
+		Expect(kubernetesOnlyReasonOf([]string{"checkTCPPort", "--endpoints-of-pod-ds"})).To(ContainSubstring("pod discovery"))
+		Expect(kubernetesOnlyReasonOf([]string{"checkHTTPSGet", "--endpoints", "example.com"})).To(BeEmpty())
+		Expect(kubernetesOnlyReasonOf([]string{"checkTCPPort", "--node-port", "10250"})).To(BeEmpty())
+		Expect(kubernetesOnlyReasonOf([]string{"checkHTTPSGet", "--header=X-Token: secretRef:ns/name#key"})).To(ContainSubstring("secret references"))
+	})
+
+	It("runs standalone against a static cluster config with only external targets", func() {
+		target, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer target.Close()
+		go func() {
+			for {
+				conn, err := target.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.Close()
+			}
+		}()
+
+		dir := GinkgoT().TempDir()
+		networkCfg := &config.NetworkConfig{
+			DefaultPeriod: metav1.Duration{Duration: 1 * time.Second},
+			Jobs: []config.Job{
+				{JobID: "tcp-ext", Args: []string{"checkTCPPort", "--endpoints", fmt.Sprintf("external:127.0.0.1:%d", target.Addr().(*net.TCPAddr).Port)}},
+				{JobID: "tcp-api-int", Args: []string{"checkTCPPort", "--endpoint-internal-kube-apiserver"}},
+				{JobID: "tcp-p2p", Args: []string{"checkTCPPort", "--endpoints-of-pod-ds"}},
+			},
+		}
+		agentCfg := &config.AgentConfig{
+			OutputDir: filepath.Join(dir, "records"),
+			// must not be started, as there is no in-cluster access
+			K8sExporter: &config.K8sExporterConfig{Enabled: true},
+			HostNetwork: networkCfg,
+			PodNetwork:  networkCfg,
+		}
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		data, err := yaml.Marshal(agentCfg)
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+		data, err = yaml.Marshal(&config.ClusterConfig{})
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(clusterConfigFile, data, 0o600)).To(Succeed())
+
+		s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+		s.logDirectory = filepath.Join(dir, "log")
+		Expect(s.setup()).To(Succeed())
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			defer GinkgoRecover()
+			Expect(s.run()).To(Succeed())
+		}()
+		defer func() {
+			close(s.done)
+			Eventually(stopped, 10*time.Second).Should(BeClosed())
+		}()
+
+		status := func() *nwpd.GetJobStatusResponse {
+			resp, err := s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+			Expect(err).To(BeNil())
+			return resp
+		}
+		Eventually(func() int32 {
+			for _, job := range status().Jobs {
+				if job.JobID == "tcp-ext" {
+					return job.LastRunOk
+				}
+			}
+			return 0
+		}, 10*time.Second).Should(Equal(int32(1)))
+
+		resp := status()
+		Expect(resp.Environment).To(Equal(config.EnvironmentStandalone))
+		Expect(resp.DisabledFeatures).To(ContainElement(ContainSubstring("k8sExporter")))
+		Expect(resp.Jobs).To(HaveLen(3))
+		for _, job := range resp.Jobs {
+			if job.JobID != "tcp-ext" {
+				Expect(job.Skipped).To(BeTrue())
+				Expect(job.SkipReason).To(ContainSubstring("requires the kubernetes environment"))
+			}
+		}
+	})
+})
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxExportRequestSize is the maximum size of the JSON encoded export request.
+const maxExportRequestSize = 1 << 20
+
+// exportFlushInterval is the number of streamed observations after which the response is flushed.
+const exportFlushInterval = 1000
+
+// writeExportError writes the error as response with the HTTP status of the twirp error code.
+func writeExportError(w http.ResponseWriter, err error) {
+	status, msg := http.StatusInternalServerError, err.Error()
+	var twerr twirp.Error
+	if errors.As(err, &twerr) {
+		status, msg = twirp.ServerHTTPStatusFromErrorCode(twerr.Code()), twerr.Msg()
+	}
+	http.Error(w, msg, status)
+}
+
+// readObservationsRequest reads the optional JSON encoded `GetObservationsRequest` from the request body.
+func readObservationsRequest(r *http.Request) (*nwpd.GetObservationsRequest, error) {
+	request := &nwpd.GetObservationsRequest{}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxExportRequestSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading request failed: %s", err)
+	}
+	if len(data) > 0 {
+		if err := protojson.Unmarshal(data, request); err != nil {
+			return nil, fmt.Errorf("invalid request: %s", err)
+		}
+	}
+	return request, nil
+}
+
+// handleExportObservations serves the stored observations as newline-delimited JSON or as CSV with the query parameter `format=csv`.
+// The optional request body is a JSON encoded `GetObservationsRequest` to filter the observations.
+func (s *server) handleExportObservations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = db.ExportFormatJSON
+	}
+	enc, err := db.NewEncoder(w, format, runners.ResultFieldNames)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request, err := readObservationsRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.getWriter() == nil {
+		http.Error(w, "no observations stored", http.StatusNotFound)
+		return
+	}
+
+	if (request.SortBy != "" && request.SortBy != nwpd.SortByTimestamp) || request.SortDescending {
+		// sorting needs all observations in memory
+		resp, err := s.GetObservations(r.Context(), request)
+		if err != nil {
+			writeExportError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", enc.ContentType())
+		for _, obs := range resp.Observations {
+			if err = enc.Encode(obs); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = enc.Flush()
+		}
+		if err != nil {
+			s.log.Warnf("export of observations failed: %s", err)
+		}
+		return
+	}
+
+	// the observations are streamed, the response status is only known before the first observation is written
+	count := 0
+	err = s.StreamObservations(r.Context(), request, func(obs *nwpd.Observation) error {
+		if count == 0 {
+			w.Header().Set("Content-Type", enc.ContentType())
+		}
+		count++
+		if err := enc.Encode(obs); err != nil {
+			return err
+		}
+		if count%exportFlushInterval == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil && count == 0 {
+		writeExportError(w, err)
+		return
+	}
+	if err != nil {
+		s.log.Warnf("export of observations failed after %d observations: %s", count, err)
+	}
+	if count == 0 {
+		w.Header().Set("Content-Type", enc.ContentType())
+	}
+	if err := enc.Flush(); err != nil {
+		s.log.Warnf("export of observations failed: %s", err)
+	}
+}
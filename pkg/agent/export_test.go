@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type fakeWriter struct {
+	observations nwpd.Observations
+	options      nwpd.ListObservationsOptions
+	runs         []*nwpd.JobRunRecord
+	maxDiskUsage int64
+}
+
+var _ nwpd.ObservationWriter = &fakeWriter{}
+
+func (w *fakeWriter) Add(obs *nwpd.Observation) { w.observations = append(w.observations, obs) }
+func (w *fakeWriter) Run()                      {}
+func (w *fakeWriter) Stop()                     {}
+func (w *fakeWriter) AddJobRun(record *nwpd.JobRunRecord) {
+	w.runs = append(w.runs, record)
+}
+func (w *fakeWriter) SetMaxDiskUsage(maxBytes int64)          { w.maxDiskUsage = maxBytes }
+func (w *fakeWriter) SetWriteBatching(_ time.Duration, _ int) {}
+
+func (w *fakeWriter) ListObservations(options nwpd.ListObservationsOptions) (nwpd.Observations, error) {
+	w.options = options
+	var result nwpd.Observations
+	for _, obs := range w.observations {
+		if len(options.FilterJobIDs) > 0 && options.FilterJobIDs[0] != obs.JobID {
+			continue
+		}
+		if options.Filter != nil && !options.Filter(obs) {
+			continue
+		}
+		result = append(result, obs)
+	}
+	return result, nil
+}
+
+func (w *fakeWriter) IterateObservations(options nwpd.ListObservationsOptions, visitor func(obs *nwpd.Observation) (bool, error)) error {
+	result, err := w.ListObservations(options)
+	if err != nil {
+		return err
+	}
+	for i, obs := range result {
+		if options.Limit > 0 && i >= options.Limit {
+			break
+		}
+		if stop, err := visitor(obs); stop || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ = Describe("export", func() {
+	var (
+		writer *fakeWriter
+		s      *server
+	)
+
+	BeforeEach(func() {
+		writer = &fakeWriter{}
+		writer.Add(&nwpd.Observation{SrcHost: "node1", DestHost: "node2", JobID: "tcp", Ok: true,
+			Timestamp: timestamppb.New(time.Unix(1000, 0)), Duration: durationpb.New(5 * time.Millisecond), Result: "connected"})
+		writer.Add(&nwpd.Observation{SrcHost: "node1", DestHost: "node3", JobID: "ping",
+			Timestamp: timestamppb.New(time.Unix(1001, 0)), Result: "timeout"})
+		s = &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+	})
+
+	export := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleExportObservations(rec, httptest.NewRequest(method, common.PathExportObservations, strings.NewReader(body)))
+		return rec
+	}
+
+	It("writes one JSON object per line", func() {
+		rec := export(http.MethodGet, "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+		var lines []map[string]any
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			line := map[string]any{}
+			Expect(json.Unmarshal(scanner.Bytes(), &line)).To(Succeed())
+			lines = append(lines, line)
+		}
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(HaveKeyWithValue("srcHost", "node1"))
+		Expect(lines[0]).To(HaveKeyWithValue("destHost", "node2"))
+		Expect(lines[0]).To(HaveKeyWithValue("jobID", "tcp"))
+		Expect(lines[0]).To(HaveKeyWithValue("ok", true))
+		Expect(lines[0]).To(HaveKeyWithValue("duration", "0.005s"))
+		Expect(lines[0]).To(HaveKeyWithValue("result", "connected"))
+		Expect(lines[0]).To(HaveKeyWithValue("timestamp", "1970-01-01T00:16:40Z"))
+		Expect(lines[1]).To(HaveKeyWithValue("ok", false))
+	})
+
+	It("writes CSV with the format parameter", func() {
+		rec := httptest.NewRecorder()
+		s.handleExportObservations(rec, httptest.NewRequest(http.MethodGet, common.PathExportObservations+"?format=csv", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("text/csv"))
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(3))
+		Expect(records[0][:3]).To(Equal([]string{"timestamp", "jobID", "srcHost"}))
+		Expect(records[0]).To(ContainElement(runners.ResultFieldHTTPStatus))
+		Expect(records[1][:3]).To(Equal([]string{"1970-01-01T00:16:40Z", "tcp", "node1"}))
+
+		rec = httptest.NewRecorder()
+		s.handleExportObservations(rec, httptest.NewRequest(http.MethodGet, common.PathExportObservations+"?format=xml", nil))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("honors the request filters", func() {
+		rec := export(http.MethodPost, `{"restrictToJobIDs":["ping"],"restrictToLabels":{"port":"443"},"failuresOnly":true}`)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(strings.Count(rec.Body.String(), "\n")).To(Equal(1))
+		line := map[string]any{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &line)).To(Succeed())
+		Expect(line).To(HaveKeyWithValue("jobID", "ping"))
+		Expect(writer.options.FilterLabels).To(Equal(map[string]string{"port": "443"}))
+		Expect(writer.options.FailuresOnly).To(BeTrue())
+	})
+
+	It("evaluates the filter expression", func() {
+		rec := export(http.MethodPost, `{"filter":"ok && duration > 1ms"}`)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		line := map[string]any{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &line)).To(Succeed())
+		Expect(line).To(HaveKeyWithValue("jobID", "tcp"))
+
+		rec = export(http.MethodPost, `{"filter":"duration > fast"}`)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring("invalid duration"))
+	})
+
+	It("streams the observations up to the limit", func() {
+		for i := 0; i < 2*exportFlushInterval; i++ {
+			writer.Add(&nwpd.Observation{SrcHost: "node1", DestHost: "node2", JobID: "tcp", Ok: true,
+				Timestamp: timestamppb.New(time.Unix(int64(2000+i), 0))})
+		}
+		rec := export(http.MethodGet, "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(strings.Count(rec.Body.String(), "\n")).To(Equal(2*exportFlushInterval + 2))
+		Expect(rec.Flushed).To(BeTrue())
+
+		rec = export(http.MethodPost, `{"limit":3}`)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(strings.Count(rec.Body.String(), "\n")).To(Equal(3))
+	})
+
+	It("sorts by other fields without streaming", func() {
+		rec := export(http.MethodPost, `{"sortBy":"duration","sortDescending":true}`)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(strings.Count(rec.Body.String(), "\n")).To(Equal(2))
+		Expect(writer.options.SortBy).To(Equal(nwpd.SortByDuration))
+	})
+
+	It("rejects invalid requests", func() {
+		Expect(export(http.MethodPost, `{"limit":"x"}`).Code).To(Equal(http.StatusBadRequest))
+		Expect(export(http.MethodDelete, "").Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("fails without stored observations", func() {
+		s.writer = nil
+		Expect(export(http.MethodGet, "").Code).To(Equal(http.StatusNotFound))
+	})
+})
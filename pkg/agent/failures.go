@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultFailuresWindow is the time window of GetFailuresSince if the request has no start.
+	defaultFailuresWindow = 5 * time.Minute
+	// maxFailureObservations is the maximum number of failed observations counted by GetFailuresSince.
+	maxFailureObservations = 50000
+)
+
+// GetFailuresSince returns the number and the last failure per edge and job since the start of the time window.
+func (s *server) GetFailuresSince(_ context.Context, request *nwpd.GetFailuresSinceRequest) (*nwpd.GetFailuresSinceResponse, error) {
+	writer := s.getWriter()
+	if writer == nil {
+		return nil, fmt.Errorf("failures not available without output directory")
+	}
+	now := time.Now()
+	since := now.Add(-defaultFailuresWindow)
+	if request.Since != nil {
+		since = request.Since.AsTime()
+		if since.After(now) {
+			return nil, twirp.InvalidArgumentError("since", "must not be in the future")
+		}
+	}
+	result, err := writer.ListObservations(nwpd.ListObservationsOptions{
+		Start: since,
+		// one more to detect truncation, the most recent failures are kept
+		Limit:           maxFailureObservations + 1,
+		FilterJobIDs:    request.RestrictToJobIDs,
+		FilterSrcHosts:  request.RestrictToSrcHosts,
+		FilterDestHosts: request.RestrictToDestHosts,
+		FailuresOnly:    true,
+		// outdated destinations are no network problems
+		Filter:         func(obs *nwpd.Observation) bool { return !obs.StaleEndpoint },
+		SortBy:         nwpd.SortByTimestamp,
+		SortDescending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &nwpd.GetFailuresSinceResponse{Since: timestamppb.New(since)}
+	if len(result) > maxFailureObservations {
+		result = result[:maxFailureObservations]
+		resp.Truncated = true
+	}
+	resp.Edges = failuresByEdge(result)
+	return resp, nil
+}
+
+// failuresByEdge counts the failed observations per edge and job.
+// The result is sorted by the time of the last failure descending.
+func failuresByEdge(observations nwpd.Observations) []*nwpd.EdgeFailures {
+	type key struct {
+		jobID    string
+		srcHost  string
+		destHost string
+	}
+	edges := map[key]*nwpd.EdgeFailures{}
+	var result []*nwpd.EdgeFailures
+	for _, obs := range observations {
+		k := key{jobID: obs.JobID, srcHost: obs.SrcHost, destHost: obs.DestHost}
+		ef := edges[k]
+		if ef == nil {
+			ef = &nwpd.EdgeFailures{JobID: obs.JobID, SrcHost: obs.SrcHost, DestHost: obs.DestHost}
+			edges[k] = ef
+			result = append(result, ef)
+		}
+		ef.Failures++
+		if ef.LastFailure == nil || obs.Timestamp.AsTime().After(ef.LastFailure.AsTime()) {
+			ef.LastFailure = obs.Timestamp
+			ef.LastFailureReason = obs.Result
+			ef.IncidentID = obs.IncidentID
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if ta, tb := a.LastFailure.AsTime(), b.LastFailure.AsTime(); !ta.Equal(tb) {
+			return ta.After(tb)
+		}
+		if a.SrcHost != b.SrcHost {
+			return a.SrcHost < b.SrcHost
+		}
+		if a.DestHost != b.DestHost {
+			return a.DestHost < b.DestHost
+		}
+		return a.JobID < b.JobID
+	})
+	return result
+}
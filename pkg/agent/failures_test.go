@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("failures since", func() {
+	var (
+		writer *fakeWriter
+		s      *server
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		writer = &fakeWriter{}
+		s = &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+		now = time.Now()
+	})
+
+	failure := func(jobID, src, dest string, age time.Duration, result string) *nwpd.Observation {
+		return &nwpd.Observation{JobID: jobID, SrcHost: src, DestHost: dest,
+			Timestamp: timestamppb.New(now.Add(-age)), Result: result}
+	}
+
+	It("counts the failures per edge and job", func() {
+		stale := failure("ping", "node1", "node3", time.Second, "stale")
+		stale.StaleEndpoint = true
+		latest := failure("ping", "node1", "node2", 10*time.Second, "timeout")
+		latest.IncidentID = "inc-1"
+		writer.observations = nwpd.Observations{
+			failure("ping", "node1", "node2", 30*time.Second, "refused"),
+			latest,
+			failure("ping", "node1", "node2", 20*time.Second, "refused"),
+			failure("https", "node1", "node2", time.Minute, "tls"),
+			failure("ping", "node2", "node1", time.Minute, "timeout"),
+			stale,
+		}
+
+		since := now.Add(-2 * time.Minute)
+		resp, err := s.GetFailuresSince(context.Background(), &nwpd.GetFailuresSinceRequest{
+			Since:            timestamppb.New(since),
+			RestrictToJobIDs: []string{"ping"},
+		})
+		Expect(err).To(BeNil())
+		Expect(writer.options.Start).To(BeTemporally("==", since))
+		Expect(writer.options.FailuresOnly).To(BeTrue())
+		Expect(writer.options.FilterJobIDs).To(Equal([]string{"ping"}))
+		Expect(resp.Since.AsTime()).To(BeTemporally("==", since))
+		Expect(resp.Truncated).To(BeFalse())
+		Expect(resp.Edges).To(HaveLen(2))
+
+		edge := resp.Edges[0]
+		Expect(edge.JobID).To(Equal("ping"))
+		Expect(edge.SrcHost).To(Equal("node1"))
+		Expect(edge.DestHost).To(Equal("node2"))
+		Expect(edge.Failures).To(Equal(int32(3)))
+		Expect(edge.LastFailure.AsTime()).To(BeTemporally("==", latest.Timestamp.AsTime()))
+		Expect(edge.LastFailureReason).To(Equal("timeout"))
+		Expect(edge.IncidentID).To(Equal("inc-1"))
+		Expect(resp.Edges[1].SrcHost).To(Equal("node2"))
+		Expect(resp.Edges[1].Failures).To(Equal(int32(1)))
+	})
+
+	It("sorts edges with the same last failure by hosts and job", func() {
+		edges := failuresByEdge(nwpd.Observations{
+			failure("ping", "node2", "node1", time.Minute, ""),
+			failure("https", "node1", "node2", time.Minute, ""),
+			failure("ping", "node1", "node2", time.Minute, ""),
+			failure("ping", "node1", "node3", 2*time.Minute, ""),
+		})
+		var names []string
+		for _, edge := range edges {
+			names = append(names, edge.SrcHost+"->"+edge.DestHost+"["+edge.JobID+"]")
+		}
+		Expect(names).To(Equal([]string{"node1->node2[https]", "node1->node2[ping]", "node2->node1[ping]", "node1->node3[ping]"}))
+	})
+
+	It("uses the default window without start", func() {
+		resp, err := s.GetFailuresSince(context.Background(), &nwpd.GetFailuresSinceRequest{})
+		Expect(err).To(BeNil())
+		Expect(resp.Edges).To(BeEmpty())
+		Expect(resp.Since.AsTime()).To(BeTemporally("~", now.Add(-defaultFailuresWindow), time.Second))
+	})
+
+	It("rejects a start in the future", func() {
+		_, err := s.GetFailuresSince(context.Background(), &nwpd.GetFailuresSinceRequest{
+			Since: timestamppb.New(now.Add(time.Hour)),
+		})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("fails without writer", func() {
+		s.writer = nil
+		_, err := s.GetFailuresSince(context.Background(), &nwpd.GetFailuresSinceRequest{})
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/gaps"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// gapRetention is the time the job run records and the last observations of the edges are kept for the gap detection.
+	// Longer gaps are not detected.
+	gapRetention = 1 * time.Hour
+	// gapClassifyPeriod is the period for classifying the detected gaps.
+	gapClassifyPeriod = 10 * time.Second
+	// minGapFactor is the minimum multiple of the job period for a gap.
+	minGapFactor = 1.5
+	// jobRunBufferSize is the size of the buffer for the job run records.
+	jobRunBufferSize = 100
+)
+
+// gapDetectionOptionsOf returns the options of the gap detection.
+func gapDetectionOptionsOf(cfg *config.AgentConfig) (gaps.Options, error) {
+	options := gaps.Options{Retention: gapRetention}
+	gd := cfg.GapDetection
+	if gd == nil {
+		return options, nil
+	}
+	if gd.Factor != 0 {
+		if gd.Factor < minGapFactor {
+			return options, fmt.Errorf("invalid GapDetection factor, must be >= %.1f", minGapFactor)
+		}
+		options.Factor = gd.Factor
+	}
+	if gd.GracePeriod != nil {
+		if gd.GracePeriod.Duration < 0 {
+			return options, fmt.Errorf("invalid GapDetection gracePeriod, must be >= 0")
+		}
+		options.GracePeriod = gd.GracePeriod.Duration
+	}
+	for i, w := range gd.MaintenanceWindows {
+		if !w.End.After(w.Start.Time) {
+			return options, fmt.Errorf("invalid GapDetection maintenanceWindows[%d], end must be after start", i)
+		}
+		options.MaintenanceWindows = append(options.MaintenanceWindows, gaps.Window{Start: w.Start.Time, End: w.End.Time, Reason: w.Reason})
+	}
+	return options, nil
+}
+
+// gapMonitor detects the gaps between the observations of the edges of the agent and counts them by classification.
+// A nil monitor ignores all calls.
+type gapMonitor struct {
+	lock         sync.Mutex
+	log          logrus.FieldLogger
+	detector     *gaps.Detector
+	lastClassify time.Time
+}
+
+func newGapMonitor(log logrus.FieldLogger) *gapMonitor {
+	return &gapMonitor{
+		log:          log,
+		detector:     gaps.NewDetector(gaps.Options{Retention: gapRetention}),
+		lastClassify: time.Now(),
+	}
+}
+
+func (m *gapMonitor) configure(options gaps.Options) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.detector.Reconfigure(options)
+}
+
+func (m *gapMonitor) observe(obs *nwpd.Observation) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.detector.Add(obs)
+}
+
+func (m *gapMonitor) addJobRun(record *nwpd.JobRunRecord) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.detector.AddJobRun(record)
+}
+
+// classifyIfDue classifies the settled gaps if the classify period has elapsed and updates the gap metric.
+func (m *gapMonitor) classifyIfDue(now time.Time) []*gaps.Gap {
+	if m == nil {
+		return nil
+	}
+	m.lock.Lock()
+	if now.Sub(m.lastClassify) < gapClassifyPeriod {
+		m.lock.Unlock()
+		return nil
+	}
+	m.lastClassify = now
+	detected := m.detector.Classify(now)
+	m.lock.Unlock()
+
+	for _, g := range detected {
+		ObservationGaps.WithLabelValues(g.JobID, g.Category).Inc()
+		if g.Explained() {
+			m.log.Debugf("detected %s", g)
+		} else {
+			m.log.Infof("detected %s", g)
+		}
+	}
+	return detected
+}
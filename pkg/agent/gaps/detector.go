@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gaps
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+const (
+	// CategoryMaintenance is the category of gaps overlapping a maintenance window (explained).
+	CategoryMaintenance = "maintenance"
+	// CategoryGracePeriod is the category of gaps ending within the grace period after the start of the agent (explained).
+	CategoryGracePeriod = "gracePeriod"
+	// CategoryCancelled is the category of gaps while the scheduled runs of the job were stopped, e.g. as it was disabled.
+	CategoryCancelled = "cancelled"
+	// CategoryLoadShedding is the category of gaps caused by runs deferred by the limit of concurrent jobs
+	// or by observations dropped because the observation buffer was full.
+	CategoryLoadShedding = "loadShedding"
+	// CategorySampling is the category of gaps with runs of the job not probing the destination,
+	// e.g. because of a changed sampling rotation, the failure backoff, or as the destination was dropped from the targets.
+	CategorySampling = "sampling"
+	// CategoryUnknown is the category of gaps without explanation, e.g. because the agent was stalled.
+	CategoryUnknown = "unknown"
+)
+
+// Categories are all gap categories, starting with the explained ones.
+var Categories = []string{CategoryMaintenance, CategoryGracePeriod, CategoryCancelled, CategoryLoadShedding, CategorySampling, CategoryUnknown}
+
+const (
+	// DefaultFactor is the default multiple of the period of an edge a gap must exceed.
+	DefaultFactor = 3.0
+	// DefaultGracePeriod is the default grace period after the start of the agent.
+	DefaultGracePeriod = 2 * time.Minute
+	// DefaultSettleTime is the default time after the end of a gap before it is classified.
+	DefaultSettleTime = 1 * time.Minute
+)
+
+// IsExplained returns true if gaps of the category are expected, i.e. they should not be counted as unexplained.
+func IsExplained(category string) bool {
+	return category == CategoryMaintenance || category == CategoryGracePeriod
+}
+
+// Window is a maintenance window.
+type Window struct {
+	Start  time.Time
+	End    time.Time
+	Reason string
+}
+
+// Options configures the gap detection.
+type Options struct {
+	// Factor is the multiple of the period of an edge the time between two consecutive observations must exceed (0 for default).
+	Factor float64
+	// GracePeriod is the time after the start of the agent in which ending gaps are explained (0 for default).
+	GracePeriod time.Duration
+	// MaintenanceWindows explain the gaps overlapping them.
+	MaintenanceWindows []Window
+	// Retention is the time the job run records and the last observations of the edges are kept (0 for unlimited).
+	Retention time.Duration
+	// SettleTime is the time after the end of a gap before it is classified, so that the records of
+	// the runs in progress are available (0 for default).
+	SettleTime time.Duration
+}
+
+// Gap is a time between two consecutive observations of an edge exceeding the factor of its period.
+type Gap struct {
+	JobID    string
+	SrcHost  string
+	DestHost string
+	// Start is the time of the last observation before the gap.
+	Start time.Time
+	// End is the time of the first observation after the gap.
+	End time.Time
+	// Period is the expected time between two observations of the edge.
+	Period time.Duration
+	// Category is the classification of the gap.
+	Category string
+	// Reason details the classification, e.g. the reason of the maintenance window.
+	Reason string
+}
+
+// Duration returns the time between the two observations.
+func (g *Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// Explained returns true if the gap is expected.
+func (g *Gap) Explained() bool {
+	return IsExplained(g.Category)
+}
+
+func (g *Gap) String() string {
+	s := fmt.Sprintf("%s->%s[%s] gap of %s (period %s) from %s: %s", g.SrcHost, g.DestHost, g.JobID,
+		g.Duration().Round(time.Millisecond), g.Period, g.Start.UTC().Format(time.RFC3339), g.Category)
+	if g.Reason != "" {
+		s += " (" + g.Reason + ")"
+	}
+	return s
+}
+
+type edge struct {
+	jobID    string
+	srcHost  string
+	destHost string
+}
+
+type runKey struct {
+	jobID   string
+	srcHost string
+}
+
+// Detector identifies the gaps between the observations of the edges and classifies them using the job run records.
+// Observations must be added per edge in time order and the job run records before the gaps are classified.
+// It is not safe for concurrent use.
+type Detector struct {
+	options Options
+	last    map[edge]time.Time
+	runs    map[runKey][]*nwpd.JobRunRecord
+	starts  map[string][]time.Time
+	pending []*Gap
+}
+
+// NewDetector creates a detector with the given options.
+func NewDetector(options Options) *Detector {
+	return &Detector{
+		options: withDefaults(options),
+		last:    map[edge]time.Time{},
+		runs:    map[runKey][]*nwpd.JobRunRecord{},
+		starts:  map[string][]time.Time{},
+	}
+}
+
+func withDefaults(options Options) Options {
+	if options.Factor <= 0 {
+		options.Factor = DefaultFactor
+	}
+	if options.GracePeriod <= 0 {
+		options.GracePeriod = DefaultGracePeriod
+	}
+	if options.SettleTime <= 0 {
+		options.SettleTime = DefaultSettleTime
+	}
+	return options
+}
+
+// Reconfigure replaces the options. The detected gaps and the added job run records are kept.
+func (d *Detector) Reconfigure(options Options) {
+	d.options = withDefaults(options)
+}
+
+// AddJobRun adds a job run record.
+func (d *Detector) AddJobRun(record *nwpd.JobRunRecord) {
+	if record.Kind == nwpd.JobRunKindAgentStart {
+		d.starts[record.SrcHost] = append(d.starts[record.SrcHost], record.Start.AsTime())
+		return
+	}
+	key := runKey{jobID: record.JobID, srcHost: record.SrcHost}
+	d.runs[key] = append(d.runs[key], record)
+}
+
+// Add adds an observation and returns true if it ends a gap of its edge.
+func (d *Detector) Add(obs *nwpd.Observation) bool {
+	e := edge{jobID: obs.JobID, srcHost: obs.SrcHost, destHost: obs.DestHost}
+	t := obs.Timestamp.AsTime()
+	last, ok := d.last[e]
+	if ok && !t.After(last) {
+		return false
+	}
+	d.last[e] = t
+	period := obs.Period.AsDuration()
+	if !ok || period <= 0 || float64(t.Sub(last)) <= d.options.Factor*float64(period) {
+		return false
+	}
+	d.pending = append(d.pending, &Gap{
+		JobID:    obs.JobID,
+		SrcHost:  obs.SrcHost,
+		DestHost: obs.DestHost,
+		Start:    last,
+		End:      t,
+		Period:   period,
+	})
+	return true
+}
+
+// Classify classifies and returns the detected gaps which have ended at least the settle time before now.
+// Afterwards, the job run records and edges outside the retention are removed.
+func (d *Detector) Classify(now time.Time) []*Gap {
+	gaps := d.classifyPending(func(g *Gap) bool {
+		return !g.End.Add(d.options.SettleTime).After(now)
+	})
+	if d.options.Retention > 0 {
+		d.prune(now.Add(-d.options.Retention))
+	}
+	return gaps
+}
+
+// ClassifyAll classifies and returns all detected gaps independent of the settle time.
+func (d *Detector) ClassifyAll() []*Gap {
+	return d.classifyPending(func(_ *Gap) bool { return true })
+}
+
+func (d *Detector) classifyPending(ready func(g *Gap) bool) []*Gap {
+	var (
+		gaps    []*Gap
+		pending []*Gap
+	)
+	for _, g := range d.pending {
+		if !ready(g) {
+			pending = append(pending, g)
+			continue
+		}
+		g.Category, g.Reason = d.classify(g)
+		gaps = append(gaps, g)
+	}
+	d.pending = pending
+	return gaps
+}
+
+func (d *Detector) classify(g *Gap) (category, reason string) {
+	for _, w := range d.options.MaintenanceWindows {
+		if w.Start.Before(g.End) && w.End.After(g.Start) {
+			return CategoryMaintenance, w.Reason
+		}
+	}
+	for _, start := range d.starts[g.SrcHost] {
+		if start.After(g.Start) && !start.After(g.End) && g.End.Sub(start) <= d.options.GracePeriod {
+			return CategoryGracePeriod, "agent start"
+		}
+	}
+
+	var (
+		between   int
+		probing   int
+		deferred  time.Duration
+		dropped   bool
+		cancelled string
+	)
+	// the runs of mesh jobs are recorded for the job, not per peer
+	jobID, _ := nwpd.SplitMeshJobID(g.JobID)
+	for _, r := range d.runs[runKey{jobID: jobID, srcHost: g.SrcHost}] {
+		start := r.Start.AsTime()
+		if !start.After(g.Start) || start.After(g.End) {
+			continue
+		}
+		if r.Kind == nwpd.JobRunKindCancelled {
+			if cancelled == "" {
+				cancelled = r.Reason
+				if cancelled == "" {
+					cancelled = "cancelled"
+				}
+			}
+			continue
+		}
+		// the run of the observation ending the gap may have been deferred, too
+		if delay := r.Delay.AsDuration(); delay >= g.Period && delay > deferred {
+			deferred = delay
+		}
+		if end := r.End.AsTime(); end.Before(g.End) {
+			between++
+			if r.DestHostsOmitted || slices.Contains(r.DestHosts, g.DestHost) {
+				probing++
+				if r.DroppedObservations > 0 {
+					dropped = true
+				}
+			}
+		}
+	}
+	switch {
+	case cancelled != "":
+		return CategoryCancelled, cancelled
+	case deferred > 0:
+		return CategoryLoadShedding, fmt.Sprintf("run deferred by %s", deferred.Round(time.Millisecond))
+	case dropped:
+		return CategoryLoadShedding, "observations dropped"
+	case between > 0 && probing == 0:
+		return CategorySampling, fmt.Sprintf("%d runs without destination", between)
+	case between == 0:
+		return CategoryUnknown, "no runs"
+	default:
+		return CategoryUnknown, fmt.Sprintf("%d runs probing destination without observation", probing)
+	}
+}
+
+func (d *Detector) prune(outdated time.Time) {
+	for e, last := range d.last {
+		if last.Before(outdated) {
+			delete(d.last, e)
+		}
+	}
+	for key, runs := range d.runs {
+		kept := slices.DeleteFunc(runs, func(r *nwpd.JobRunRecord) bool {
+			end := r.End.AsTime()
+			if r.End == nil {
+				end = r.Start.AsTime()
+			}
+			return end.Before(outdated)
+		})
+		if len(kept) == 0 {
+			delete(d.runs, key)
+		} else {
+			d.runs[key] = kept
+		}
+	}
+	for src, starts := range d.starts {
+		kept := slices.DeleteFunc(starts, func(t time.Time) bool { return t.Before(outdated) })
+		if len(kept) == 0 {
+			delete(d.starts, src)
+		} else {
+			d.starts[src] = kept
+		}
+	}
+}
+
+// SortByDuration sorts the gaps by duration descending.
+func SortByDuration(gaps []*Gap) {
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].Duration() > gaps[j].Duration()
+	})
+}
@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gaps
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("detector", func() {
+	var (
+		base     = time.Date(2022, 1, 23, 10, 0, 0, 0, time.UTC)
+		period   = 10 * time.Second
+		detector *Detector
+	)
+
+	at := func(seconds int) time.Time {
+		return base.Add(time.Duration(seconds) * time.Second)
+	}
+	observe := func(seconds int, dest string) bool {
+		return detector.Add(&nwpd.Observation{
+			JobID:     "ping",
+			SrcHost:   "node1",
+			DestHost:  dest,
+			Timestamp: timestamppb.New(at(seconds)),
+			Period:    durationpb.New(period),
+			Ok:        true,
+		})
+	}
+	run := func(start int, dests ...string) *nwpd.JobRunRecord {
+		return &nwpd.JobRunRecord{
+			Kind:      nwpd.JobRunKindRun,
+			JobID:     "ping",
+			SrcHost:   "node1",
+			Start:     timestamppb.New(at(start)),
+			End:       timestamppb.New(at(start).Add(time.Second)),
+			Period:    durationpb.New(period),
+			DestHosts: dests,
+		}
+	}
+	classifyGap := func(records ...*nwpd.JobRunRecord) *Gap {
+		for _, record := range records {
+			detector.AddJobRun(record)
+		}
+		Expect(observe(0, "node2")).To(BeFalse())
+		Expect(observe(60, "node2")).To(BeTrue())
+		gaps := detector.ClassifyAll()
+		Expect(gaps).To(HaveLen(1))
+		return gaps[0]
+	}
+
+	BeforeEach(func() {
+		detector = NewDetector(Options{})
+	})
+
+	It("ignores regular observations", func() {
+		for i := 0; i < 10; i++ {
+			Expect(observe(i*10, "node2")).To(BeFalse())
+			Expect(observe(i*25, "node3")).To(BeFalse())
+		}
+		// out of order and duplicate observations
+		Expect(observe(0, "node2")).To(BeFalse())
+		Expect(detector.ClassifyAll()).To(BeEmpty())
+	})
+
+	It("detects gaps per edge", func() {
+		Expect(observe(0, "node2")).To(BeFalse())
+		Expect(observe(0, "node3")).To(BeFalse())
+		Expect(observe(10, "node3")).To(BeFalse())
+		Expect(observe(45, "node2")).To(BeTrue())
+		Expect(observe(20, "node3")).To(BeFalse())
+
+		gaps := detector.ClassifyAll()
+		Expect(gaps).To(HaveLen(1))
+		g := gaps[0]
+		Expect(g.JobID).To(Equal("ping"))
+		Expect(g.SrcHost).To(Equal("node1"))
+		Expect(g.DestHost).To(Equal("node2"))
+		Expect(g.Start).To(BeTemporally("==", at(0)))
+		Expect(g.End).To(BeTemporally("==", at(45)))
+		Expect(g.Period).To(Equal(period))
+		Expect(g.Duration()).To(Equal(45 * time.Second))
+		Expect(g.String()).To(Equal("node1->node2[ping] gap of 45s (period 10s) from 2022-01-23T10:00:00Z: unknown (no runs)"))
+	})
+
+	It("uses the configured factor", func() {
+		detector = NewDetector(Options{Factor: 5})
+		Expect(observe(0, "node2")).To(BeFalse())
+		Expect(observe(45, "node2")).To(BeFalse())
+		Expect(observe(100, "node2")).To(BeTrue())
+	})
+
+	It("classifies gaps without runs as unknown", func() {
+		g := classifyGap()
+		Expect(g.Category).To(Equal(CategoryUnknown))
+		Expect(g.Reason).To(Equal("no runs"))
+		Expect(g.Explained()).To(BeFalse())
+	})
+
+	It("classifies gaps with runs probing the destination as unknown", func() {
+		g := classifyGap(run(10, "node2"), run(20, "node2", "node3"), run(30, "node3"))
+		Expect(g.Category).To(Equal(CategoryUnknown))
+		Expect(g.Reason).To(Equal("2 runs probing destination without observation"))
+	})
+
+	It("classifies gaps with runs not probing the destination as sampling", func() {
+		g := classifyGap(run(-10, "node2"), run(10, "node3"), run(20, "node4"), run(70, "node2"))
+		Expect(g.Category).To(Equal(CategorySampling))
+		Expect(g.Reason).To(Equal("2 runs without destination"))
+	})
+
+	It("uses the runs of mesh jobs for the gaps of their peers", func() {
+		r := run(10, "node2")
+		r.JobID = "tcp-mesh"
+		detector.AddJobRun(r)
+		for _, seconds := range []int{0, 60} {
+			detector.Add(&nwpd.Observation{JobID: nwpd.MeshJobID("tcp-mesh", "node2"), SrcHost: "node1", DestHost: "node2",
+				Timestamp: timestamppb.New(at(seconds)), Period: durationpb.New(period), Ok: true})
+		}
+		gaps := detector.ClassifyAll()
+		Expect(gaps).To(HaveLen(1))
+		Expect(gaps[0].JobID).To(Equal("tcp-mesh/node2"))
+		Expect(gaps[0].Reason).To(Equal("1 runs probing destination without observation"))
+	})
+
+	It("classifies gaps with omitted destinations as probing the destination", func() {
+		r := run(10)
+		r.DestHostsOmitted = true
+		g := classifyGap(r)
+		Expect(g.Category).To(Equal(CategoryUnknown))
+	})
+
+	It("classifies gaps with deferred runs as load shedding", func() {
+		r := run(59, "node2")
+		r.Delay = durationpb.New(49 * time.Second)
+		g := classifyGap(r)
+		Expect(g.Category).To(Equal(CategoryLoadShedding))
+		Expect(g.Reason).To(Equal("run deferred by 49s"))
+	})
+
+	It("classifies gaps with dropped observations as load shedding", func() {
+		r := run(10, "node2")
+		r.DroppedObservations = 1
+		g := classifyGap(r, run(20, "node3"))
+		Expect(g.Category).To(Equal(CategoryLoadShedding))
+		Expect(g.Reason).To(Equal("observations dropped"))
+	})
+
+	It("classifies gaps with cancelled runs", func() {
+		g := classifyGap(run(10, "node2"), &nwpd.JobRunRecord{
+			Kind:    nwpd.JobRunKindCancelled,
+			JobID:   "ping",
+			SrcHost: "node1",
+			Start:   timestamppb.New(at(15)),
+			Reason:  "disabled",
+		})
+		Expect(g.Category).To(Equal(CategoryCancelled))
+		Expect(g.Reason).To(Equal("disabled"))
+	})
+
+	It("ignores run records of other jobs and sources", func() {
+		other := run(10, "node2")
+		other.JobID = "other"
+		remote := run(20, "node2")
+		remote.SrcHost = "node3"
+		g := classifyGap(other, remote)
+		Expect(g.Reason).To(Equal("no runs"))
+	})
+
+	It("explains gaps overlapping a maintenance window", func() {
+		detector.Reconfigure(Options{MaintenanceWindows: []Window{
+			{Start: at(-100), End: at(-10), Reason: "before"},
+			{Start: at(50), End: at(200), Reason: "upgrade"},
+		}})
+		g := classifyGap(run(10, "node2"))
+		Expect(g.Category).To(Equal(CategoryMaintenance))
+		Expect(g.Reason).To(Equal("upgrade"))
+		Expect(g.Explained()).To(BeTrue())
+	})
+
+	It("explains gaps ending in the grace period after an agent start", func() {
+		start := &nwpd.JobRunRecord{Kind: nwpd.JobRunKindAgentStart, SrcHost: "node1", Start: timestamppb.New(at(30))}
+		g := classifyGap(start)
+		Expect(g.Category).To(Equal(CategoryGracePeriod))
+		Expect(g.Reason).To(Equal("agent start"))
+
+		detector = NewDetector(Options{GracePeriod: 20 * time.Second})
+		g = classifyGap(start)
+		Expect(g.Category).To(Equal(CategoryUnknown))
+	})
+
+	It("classifies settled gaps only", func() {
+		detector = NewDetector(Options{SettleTime: 30 * time.Second})
+		Expect(observe(0, "node2")).To(BeFalse())
+		Expect(observe(60, "node2")).To(BeTrue())
+		Expect(detector.Classify(at(89))).To(BeEmpty())
+		// the record of the run is only available after the end of the run
+		detector.AddJobRun(run(50, "node3"))
+		gaps := detector.Classify(at(90))
+		Expect(gaps).To(HaveLen(1))
+		Expect(gaps[0].Category).To(Equal(CategorySampling))
+		Expect(detector.Classify(at(120))).To(BeEmpty())
+	})
+
+	It("removes records and edges outside the retention", func() {
+		detector = NewDetector(Options{Retention: 100 * time.Second})
+		detector.AddJobRun(run(10, "node2"))
+		detector.AddJobRun(run(150, "node2"))
+		detector.AddJobRun(&nwpd.JobRunRecord{Kind: nwpd.JobRunKindCancelled, JobID: "ping", SrcHost: "node1", Start: timestamppb.New(at(20))})
+		detector.AddJobRun(&nwpd.JobRunRecord{Kind: nwpd.JobRunKindAgentStart, SrcHost: "node1", Start: timestamppb.New(at(0))})
+		Expect(observe(0, "node2")).To(BeFalse())
+		Expect(observe(0, "node3")).To(BeFalse())
+		Expect(observe(150, "node3")).To(BeTrue())
+
+		Expect(detector.Classify(at(210))).To(HaveLen(1))
+		Expect(detector.last).To(HaveLen(1))
+		Expect(detector.runs[runKey{jobID: "ping", srcHost: "node1"}]).To(HaveLen(1))
+		Expect(detector.starts).To(BeEmpty())
+
+		// the edge is unknown again, so that no gap is detected
+		Expect(observe(200, "node2")).To(BeFalse())
+	})
+
+	It("sorts gaps by duration", func() {
+		gaps := []*Gap{
+			{DestHost: "a", Start: at(0), End: at(40)},
+			{DestHost: "b", Start: at(0), End: at(90)},
+			{DestHost: "c", Start: at(10), End: at(50)},
+		}
+		SortByDuration(gaps)
+		Expect([]string{gaps[0].DestHost, gaps[1].DestHost, gaps[2].DestHost}).To(Equal([]string{"b", "a", "c"}))
+	})
+})
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/gaps"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("gap detection", func() {
+	It("validates the gap detection options", func() {
+		options, err := gapDetectionOptionsOf(&config.AgentConfig{})
+		Expect(err).To(BeNil())
+		Expect(options).To(Equal(gaps.Options{Retention: gapRetention}))
+
+		start := time.Date(2022, 1, 23, 23, 0, 0, 0, time.UTC)
+		options, err = gapDetectionOptionsOf(&config.AgentConfig{GapDetection: &config.GapDetectionConfig{
+			Factor:      5,
+			GracePeriod: &metav1.Duration{Duration: 5 * time.Minute},
+			MaintenanceWindows: []config.MaintenanceWindow{
+				{Start: metav1.NewTime(start), End: metav1.NewTime(start.Add(time.Hour)), Reason: "upgrade"},
+			},
+		}})
+		Expect(err).To(BeNil())
+		Expect(options.Factor).To(Equal(5.0))
+		Expect(options.GracePeriod).To(Equal(5 * time.Minute))
+		Expect(options.MaintenanceWindows).To(HaveLen(1))
+		Expect(options.MaintenanceWindows[0].Start).To(BeTemporally("==", start))
+		Expect(options.MaintenanceWindows[0].Reason).To(Equal("upgrade"))
+
+		for _, gd := range []*config.GapDetectionConfig{
+			{Factor: 1.2},
+			{GracePeriod: &metav1.Duration{Duration: -time.Second}},
+			{MaintenanceWindows: []config.MaintenanceWindow{{Start: metav1.NewTime(start), End: metav1.NewTime(start)}}},
+		} {
+			_, err = gapDetectionOptionsOf(&config.AgentConfig{GapDetection: gd})
+			Expect(err).NotTo(BeNil())
+		}
+	})
+
+	It("counts the classified gaps of the observations", func() {
+		writer := &fakeWriter{}
+		s := &server{
+			log:     logrus.NewEntry(logrus.StandardLogger()),
+			runChan: make(chan *nwpd.JobRunRecord, jobRunBufferSize),
+			gaps:    newGapMonitor(logrus.NewEntry(logrus.StandardLogger())),
+			writer:  writer,
+		}
+		now := time.Now()
+		observe := func(t time.Time) {
+			s.processObservation(&nwpd.Observation{JobID: "gap-test", SrcHost: "node1", DestHost: "node2",
+				Timestamp: timestamppb.New(t), Period: durationpb.New(10 * time.Second), Ok: true})
+		}
+		s.recordJobRun(&nwpd.JobRunRecord{Kind: nwpd.JobRunKindRun, JobID: "gap-test", SrcHost: "node1",
+			Start: timestamppb.New(now.Add(-4 * time.Minute)), End: timestamppb.New(now.Add(-4 * time.Minute)),
+			DestHosts: []string{"node3"}})
+		Expect(s.runChan).To(HaveLen(1))
+		s.processJobRun(<-s.runChan)
+		Expect(writer.runs).To(HaveLen(1))
+
+		observe(now.Add(-5 * time.Minute))
+		observe(now.Add(-3 * time.Minute))
+		observe(now.Add(-3*time.Minute + 10*time.Second))
+		Expect(s.gaps.classifyIfDue(now)).To(BeEmpty())
+		detected := s.gaps.classifyIfDue(now.Add(gapClassifyPeriod))
+		Expect(detected).To(HaveLen(1))
+		Expect(detected[0].Category).To(Equal(gaps.CategorySampling))
+		Expect(testutil.ToFloat64(ObservationGaps.WithLabelValues("gap-test", gaps.CategorySampling))).To(Equal(1.0))
+		Expect(s.gaps.classifyIfDue(now.Add(2 * gapClassifyPeriod))).To(BeEmpty())
+	})
+
+	It("records the cancellation of deleted jobs", func() {
+		s := &server{
+			log:      logrus.NewEntry(logrus.StandardLogger()),
+			nodeName: "node1",
+			jobs:     map[jobid]*runners.InternalJob{},
+			runChan:  make(chan *nwpd.JobRunRecord, jobRunBufferSize),
+		}
+		agentConfig := &config.AgentConfig{PodNetwork: &config.NetworkConfig{
+			Jobs: []config.Job{{JobID: "lookup", Args: []string{"nslookup", "--names", "foo.bar"}}},
+		}}
+		Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+		Expect(s.jobs).To(HaveKey("lookup"))
+		Expect(s.runChan).To(BeEmpty())
+
+		agentConfig.PodNetwork.Jobs[0].Enabled = ptr.To(false)
+		Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+		Expect(s.jobs).To(BeEmpty())
+		Expect(s.runChan).To(HaveLen(1))
+		record := <-s.runChan
+		Expect(record.Kind).To(Equal(nwpd.JobRunKindCancelled))
+		Expect(record.JobID).To(Equal("lookup"))
+		Expect(record.SrcHost).To(Equal("node1"))
+		Expect(record.Reason).To(Equal("disabled"))
+
+		// no record for jobs not running
+		Expect(s.applyAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{}})).To(Succeed())
+		Expect(s.runChan).To(BeEmpty())
+
+		agentConfig.PodNetwork.Jobs[0].Enabled = nil
+		Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+		Expect(s.applyAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{}})).To(Succeed())
+		Expect(s.runChan).To(HaveLen(1))
+		Expect((<-s.runChan).Reason).To(Equal("removed"))
+	})
+})
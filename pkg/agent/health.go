@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxReloadFailures is the number of consecutive failed configuration reloads after which the agent is not ready.
+	maxReloadFailures = 3
+	// maxMissedTicks is the number of tick periods without a tick after which the agent is not ready.
+	maxMissedTicks = 10
+)
+
+// handleHealthz reports that the process is alive.
+func (s *server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports if the agent is ready, i.e. the configuration is loaded, the writer is running, jobs are scheduled
+// and the main loop is ticking.
+func (s *server) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	if problems := s.readinessProblems(); len(problems) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(strings.Join(problems, "\n") + "\n"))
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+func (s *server) readinessProblems() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var problems []string
+	if s.currentAgentConfig == nil {
+		problems = append(problems, "configuration not loaded")
+	} else if s.currentAgentConfig.OutputDir != "" && !s.writerRunning.Load() {
+		problems = append(problems, "writer not running")
+	}
+	if s.currentClusterConfig == nil {
+		problems = append(problems, "cluster configuration not loaded")
+	}
+	// a configuration without jobs is valid, e.g. for a network without checks
+	if len(s.jobs) == 0 && (s.currentAgentConfig == nil || len(s.getNetworkCfg().Jobs) > 0) {
+		problems = append(problems, "no jobs scheduled")
+	}
+	if last := s.lastTick.Load(); last != 0 {
+		tickPeriod := s.timing.tickPeriod
+		if age := time.Since(time.Unix(0, last)); age > maxMissedTicks*tickPeriod {
+			problems = append(problems, fmt.Sprintf("last tick %s ago, expected every %s", age.Round(time.Second), tickPeriod))
+		}
+	}
+	if msg := s.httpListenError.Load(); msg != nil {
+		problems = append(problems, *msg)
+	}
+	if failures := s.reloadFailures.Load(); failures >= maxReloadFailures {
+		problems = append(problems, fmt.Sprintf("configuration reload failed %d times", failures))
+	}
+	return problems
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("health", func() {
+	var s *server
+
+	BeforeEach(func() {
+		s = &server{
+			log:               logrus.NewEntry(logrus.StandardLogger()),
+			nodeName:          "node-a",
+			agentConfigFile:   "/nonexisting/agent-config.yaml",
+			clusterConfigFile: "/nonexisting/cluster-config.yaml",
+			jobs:              map[jobid]*runners.InternalJob{},
+		}
+	})
+
+	readyz := func() (int, string) {
+		rec := httptest.NewRecorder()
+		s.handleReadyz(rec, httptest.NewRequest(http.MethodGet, common.PathReadyz, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	addJob := func() {
+		rconfig := runners.RunnerConfig{Job: config.Job{JobID: "nslookup", Args: []string{"nslookup", "--names", "foo.bar"}}, Period: 10 * time.Second}
+		job, err := runners.Parse(config.ClusterConfig{}, rconfig, rconfig.Args, &config.SampleConfig{})
+		Expect(err).To(BeNil())
+		s.addOrReplaceJob(job)
+	}
+
+	It("is always alive", func() {
+		rec := httptest.NewRecorder()
+		s.handleHealthz(rec, httptest.NewRequest(http.MethodGet, common.PathHealthz, nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("is ready if configuration is loaded, writer is running and jobs are scheduled", func() {
+		code, body := readyz()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(ContainSubstring("configuration not loaded"))
+		Expect(body).To(ContainSubstring("cluster configuration not loaded"))
+		Expect(body).To(ContainSubstring("no jobs scheduled"))
+
+		s.currentAgentConfig = &config.AgentConfig{OutputDir: "/tmp"}
+		s.currentClusterConfig = &config.ClusterConfig{}
+		addJob()
+		code, body = readyz()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(Equal("writer not running\n"))
+
+		s.writerRunning.Store(true)
+		code, _ = readyz()
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("is ready without jobs if none are configured", func() {
+		s.currentAgentConfig = &config.AgentConfig{PodNetwork: &config.NetworkConfig{Jobs: []config.Job{{JobID: "ping"}}}}
+		s.currentClusterConfig = &config.ClusterConfig{}
+		code, body := readyz()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(Equal("no jobs scheduled\n"))
+
+		s.currentAgentConfig = &config.AgentConfig{PodNetwork: &config.NetworkConfig{}}
+		code, _ = readyz()
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("is not ready if the main loop stopped ticking", func() {
+		s.currentAgentConfig = &config.AgentConfig{}
+		s.currentClusterConfig = &config.ClusterConfig{}
+		s.timing = defaultTiming()
+		addJob()
+		s.lastTick.Store(time.Now().Add(-maxMissedTicks * s.timing.tickPeriod / 2).UnixNano())
+		code, _ := readyz()
+		Expect(code).To(Equal(http.StatusOK))
+
+		s.lastTick.Store(time.Now().Add(-2 * maxMissedTicks * s.timing.tickPeriod).UnixNano())
+		code, body := readyz()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(ContainSubstring("last tick"))
+	})
+
+	It("is not ready after repeated reload failures", func() {
+		s.currentAgentConfig = &config.AgentConfig{}
+		s.currentClusterConfig = &config.ClusterConfig{}
+		addJob()
+		for i := 0; i < maxReloadFailures-1; i++ {
+			s.reloadConfig()
+		}
+		code, _ := readyz()
+		Expect(code).To(Equal(http.StatusOK))
+
+		s.reloadConfig()
+		code, body := readyz()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(ContainSubstring("configuration reload failed 3 times"))
+
+		s.reloadFailures.Store(0)
+		code, _ = readyz()
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
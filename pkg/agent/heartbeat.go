@@ -0,0 +1,382 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultHeartbeatPeriod = 30 * time.Second
+	minHeartbeatPeriod     = 10 * time.Second
+	defaultHeartbeatPeers  = 2
+	maxHeartbeatPeers      = 5
+	// minHeartbeatKeyLength is the minimum length of the shared key in bytes.
+	minHeartbeatKeyLength = 16
+	// heartbeatTimeout is the timeout for sending a heartbeat to a single peer.
+	heartbeatTimeout = 2 * time.Second
+	// maxHeartbeatClockSkew is the maximum accepted difference between the timestamp of a heartbeat and the local time.
+	maxHeartbeatClockSkew = 1 * time.Minute
+	// maxHeartbeatSize is the maximum size of a heartbeat request body.
+	maxHeartbeatSize = 4096
+)
+
+var peerHeartbeatAgeDesc = prometheus.NewDesc(
+	"nwpd_peer_heartbeat_age_seconds",
+	"Seconds since the last heartbeat of the agent on the node",
+	[]string{"node"}, nil,
+)
+
+// heartbeat is the payload sent to the peer agents.
+type heartbeat struct {
+	Node      string    `json:"node"`
+	Timestamp time.Time `json:"timestamp"`
+	// Jobs is the number of scheduled jobs.
+	Jobs int `json:"jobs"`
+	// OkObservations is the number of successful observations since the last heartbeat.
+	OkObservations int64 `json:"okObservations"`
+	// FailedObservations is the number of failed observations since the last heartbeat.
+	FailedObservations int64 `json:"failedObservations"`
+}
+
+type heartbeatPeer struct {
+	node string
+	url  string
+}
+
+// heartbeatSettings is the applied peer heartbeat configuration.
+type heartbeatSettings struct {
+	period time.Duration
+	key    []byte
+	// targets are the peers receiving the heartbeats of this agent.
+	targets []heartbeatPeer
+	// senders are the nodes sending their heartbeats to this agent.
+	senders common.StringSet
+}
+
+// heartbeatSettingsOf validates the peer heartbeat configuration and selects the peers from the current cluster configuration.
+// It returns nil if the peer heartbeat is disabled.
+func (s *server) heartbeatSettingsOf(cfg *config.AgentConfig) (*heartbeatSettings, error) {
+	hbCfg := cfg.PeerHeartbeat
+	if hbCfg == nil || !hbCfg.Enabled {
+		return nil, nil
+	}
+	settings := &heartbeatSettings{period: defaultHeartbeatPeriod}
+	if hbCfg.Period != nil {
+		settings.period = hbCfg.Period.Duration
+		if settings.period < minHeartbeatPeriod {
+			return nil, fmt.Errorf("invalid PeerHeartbeat period, must be >= %s", minHeartbeatPeriod)
+		}
+	}
+	peers := defaultHeartbeatPeers
+	if hbCfg.Peers != 0 {
+		peers = hbCfg.Peers
+		if peers < 1 || peers > maxHeartbeatPeers {
+			return nil, fmt.Errorf("invalid PeerHeartbeat peers, must be in range [1,%d]", maxHeartbeatPeers)
+		}
+	}
+	key, err := loadHeartbeatKey(hbCfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	settings.key = key
+	port := s.getNetworkCfgOf(cfg).HTTPPort
+	if port == 0 {
+		return nil, fmt.Errorf("PeerHeartbeat requires the http server, but httpPort is not set")
+	}
+	var ring []heartbeatPeer
+	if s.currentClusterConfig != nil {
+		ring = heartbeatRing(s.currentClusterConfig, s.hostNetwork, port)
+	}
+	settings.targets, settings.senders = heartbeatPeersOf(ring, s.nodeName, peers)
+	return settings, nil
+}
+
+func loadHeartbeatKey(filename string) ([]byte, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("PeerHeartbeat requires a keyFile")
+	}
+	data, err := os.ReadFile(filename) // #nosec G304 -- file provided by configuration
+	if err != nil {
+		return nil, fmt.Errorf("cannot read PeerHeartbeat key: %s", err)
+	}
+	key := bytes.TrimSpace(data)
+	if len(key) < minHeartbeatKeyLength {
+		return nil, fmt.Errorf("PeerHeartbeat key too short, must have at least %d bytes", minHeartbeatKeyLength)
+	}
+	return key, nil
+}
+
+// heartbeatRing returns the agents of the same daemon set from the cluster configuration sorted by node name.
+// Agents in the host network are reached on the node IP and the given port, agents in the pod network on the pod endpoint.
+func heartbeatRing(clusterCfg *config.ClusterConfig, hostNetwork bool, port int) []heartbeatPeer {
+	seen := common.StringSet{}
+	var ring []heartbeatPeer
+	add := func(node, ip string, port int) {
+		if node == "" || ip == "" || seen.Contains(node) {
+			return
+		}
+		seen.Add(node)
+		ring = append(ring, heartbeatPeer{
+			node: node,
+			url:  "http://" + net.JoinHostPort(ip, strconv.Itoa(port)) + common.PathHeartbeat,
+		})
+	}
+	if hostNetwork {
+		for _, n := range clusterCfg.Nodes {
+			add(n.Hostname, n.InternalIP, port)
+		}
+	} else {
+		for _, pe := range clusterCfg.PodEndpoints {
+			add(pe.Nodename, pe.PodIP, int(pe.Port))
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].node < ring[j].node
+	})
+	return ring
+}
+
+// heartbeatPeersOf returns the peers receiving the heartbeats of the node and the nodes sending their heartbeats to it.
+// Each node of the ring sends its heartbeats to the next n nodes, so the selection is the same on all agents.
+// A node not contained in the ring neither sends nor receives heartbeats.
+func heartbeatPeersOf(ring []heartbeatPeer, nodeName string, n int) ([]heartbeatPeer, common.StringSet) {
+	senders := common.StringSet{}
+	var others []heartbeatPeer
+	found := false
+	for _, p := range ring {
+		if p.node == nodeName {
+			found = true
+			continue
+		}
+		others = append(others, p)
+	}
+	if !found || len(others) == 0 {
+		return nil, senders
+	}
+	n = min(n, len(others))
+	pos := sort.Search(len(others), func(i int) bool {
+		return others[i].node > nodeName
+	})
+	var targets []heartbeatPeer
+	for i := 0; i < n; i++ {
+		targets = append(targets, others[(pos+i)%len(others)])
+		senders.Add(others[(pos-1-i+len(others))%len(others)].node)
+	}
+	return targets, senders
+}
+
+func signHeartbeat(key, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// sendHeartbeatIfDue sends the heartbeat to the peer agents if the heartbeat period has elapsed.
+// At most one heartbeat is in flight.
+func (s *server) sendHeartbeatIfDue(now time.Time) {
+	s.lock.Lock()
+	settings := s.heartbeat
+	if settings == nil || len(settings.targets) == 0 || s.heartbeatInFlight || now.Sub(s.lastHeartbeat) < settings.period {
+		s.lock.Unlock()
+		return
+	}
+	s.heartbeatInFlight = true
+	s.lastHeartbeat = now
+	hb := &heartbeat{
+		Node:               s.nodeName,
+		Timestamp:          now.UTC(),
+		Jobs:               len(s.jobs),
+		OkObservations:     s.okObservations.Swap(0),
+		FailedObservations: s.failedObservations.Swap(0),
+	}
+	s.lock.Unlock()
+
+	go func() {
+		defer func() {
+			s.lock.Lock()
+			s.heartbeatInFlight = false
+			s.lock.Unlock()
+		}()
+		s.sendHeartbeat(settings, hb)
+	}()
+}
+
+// sendHeartbeat sends the heartbeat to all target peers in parallel.
+func (s *server) sendHeartbeat(settings *heartbeatSettings, hb *heartbeat) {
+	body, err := json.Marshal(hb)
+	if err != nil {
+		s.log.Warnf("cannot marshal heartbeat: %s", err)
+		return
+	}
+	signature := hex.EncodeToString(signHeartbeat(settings.key, body))
+	client := &http.Client{Timeout: heartbeatTimeout}
+	wg := sync.WaitGroup{}
+	for _, peer := range settings.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := postHeartbeat(client, peer.url, body, signature); err != nil {
+				s.log.Debugf("sending heartbeat to %s failed: %s", peer.node, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func postHeartbeat(client *http.Client, url string, body []byte, signature string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(common.HeaderHeartbeatSignature, signature)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHeartbeatSize))
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type receivedHeartbeat struct {
+	// timestamp is the timestamp of the last heartbeat (zero if none has been received yet).
+	timestamp time.Time
+	// received is the local time the last heartbeat has been received or the sender has been added.
+	received time.Time
+}
+
+// heartbeatTracker verifies the heartbeats received from the peer agents and exposes their age as metric.
+// Only the nodes selected as senders for this agent are tracked, so the number of series is bounded by the number of peers.
+type heartbeatTracker struct {
+	lock     sync.Mutex
+	key      []byte
+	received map[string]receivedHeartbeat
+	now      func() time.Time
+}
+
+var _ prometheus.Collector = &heartbeatTracker{}
+
+func newHeartbeatTracker() *heartbeatTracker {
+	return &heartbeatTracker{
+		received: map[string]receivedHeartbeat{},
+		now:      time.Now,
+	}
+}
+
+// configure sets the key and the expected senders. Departed senders are evicted.
+// The age of a new sender is measured from now, so that an agent which never sends a heartbeat becomes visible too.
+func (t *heartbeatTracker) configure(settings *heartbeatSettings) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	senders := common.StringSet{}
+	t.key = nil
+	if settings != nil {
+		t.key = settings.key
+		senders = settings.senders
+	}
+	for node := range t.received {
+		if !senders.Contains(node) {
+			delete(t.received, node)
+		}
+	}
+	now := t.now()
+	for node := range senders {
+		if _, ok := t.received[node]; !ok {
+			t.received[node] = receivedHeartbeat{received: now}
+		}
+	}
+}
+
+func (t *heartbeatTracker) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t.lock.Lock()
+	key := t.key
+	t.lock.Unlock()
+	if key == nil {
+		http.Error(w, "peer heartbeat not enabled", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHeartbeatSize))
+	if err != nil {
+		http.Error(w, "heartbeat too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	signature, err := hex.DecodeString(r.Header.Get(common.HeaderHeartbeatSignature))
+	if err != nil || !hmac.Equal(signature, signHeartbeat(key, body)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	hb := &heartbeat{}
+	if err := json.Unmarshal(body, hb); err != nil {
+		http.Error(w, "invalid heartbeat", http.StatusBadRequest)
+		return
+	}
+	if code, err := t.record(hb); err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// record stores a verified heartbeat. It returns the HTTP status code if the heartbeat is rejected.
+func (t *heartbeatTracker) record(hb *heartbeat) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	last, ok := t.received[hb.Node]
+	if !ok {
+		return http.StatusForbidden, fmt.Errorf("unexpected heartbeat from node %q", hb.Node)
+	}
+	now := t.now()
+	if skew := now.Sub(hb.Timestamp); skew > maxHeartbeatClockSkew || skew < -maxHeartbeatClockSkew {
+		return http.StatusBadRequest, fmt.Errorf("heartbeat timestamp out of range")
+	}
+	if !hb.Timestamp.After(last.timestamp) {
+		return http.StatusConflict, fmt.Errorf("outdated heartbeat")
+	}
+	t.received[hb.Node] = receivedHeartbeat{timestamp: hb.Timestamp, received: now}
+	return 0, nil
+}
+
+func (t *heartbeatTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- peerHeartbeatAgeDesc
+}
+
+func (t *heartbeatTracker) Collect(ch chan<- prometheus.Metric) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	for node, hb := range t.received {
+		ch <- prometheus.MustNewConstMetric(peerHeartbeatAgeDesc, prometheus.GaugeValue, now.Sub(hb.received).Seconds(), node)
+	}
+}
@@ -0,0 +1,277 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("peer heartbeat", func() {
+	key := []byte("0123456789abcdef")
+
+	ringOf := func(nodes ...string) []heartbeatPeer {
+		var ring []heartbeatPeer
+		for _, n := range nodes {
+			ring = append(ring, heartbeatPeer{node: n, url: "http://" + n})
+		}
+		return ring
+	}
+	nodesOf := func(peers []heartbeatPeer) []string {
+		var nodes []string
+		for _, p := range peers {
+			nodes = append(nodes, p.node)
+		}
+		return nodes
+	}
+
+	Describe("peer selection", func() {
+		It("selects the next nodes of the ring", func() {
+			ring := ringOf("a", "b", "c", "d", "e")
+			targets, senders := heartbeatPeersOf(ring, "d", 2)
+			Expect(nodesOf(targets)).To(Equal([]string{"e", "a"}))
+			Expect(senders.ToSortedArray()).To(Equal([]string{"b", "c"}))
+		})
+
+		It("is consistent between senders and receivers", func() {
+			ring := ringOf("n1", "n2", "n3", "n4", "n5", "n6", "n7")
+			for _, sender := range ring {
+				targets, _ := heartbeatPeersOf(ring, sender.node, 3)
+				Expect(targets).To(HaveLen(3))
+				for _, target := range targets {
+					_, senders := heartbeatPeersOf(ring, target.node, 3)
+					Expect(senders.Contains(sender.node)).To(BeTrue(), "%s -> %s", sender.node, target.node)
+				}
+			}
+		})
+
+		It("is bounded by the ring size", func() {
+			targets, senders := heartbeatPeersOf(ringOf("a", "b"), "a", 5)
+			Expect(nodesOf(targets)).To(Equal([]string{"b"}))
+			Expect(senders.ToSortedArray()).To(Equal([]string{"b"}))
+
+			targets, senders = heartbeatPeersOf(ringOf("a"), "a", 2)
+			Expect(targets).To(BeEmpty())
+			Expect(senders.Len()).To(Equal(0))
+		})
+
+		It("ignores nodes not contained in the ring", func() {
+			targets, senders := heartbeatPeersOf(ringOf("a", "b", "c"), "x", 2)
+			Expect(targets).To(BeEmpty())
+			Expect(senders.Len()).To(Equal(0))
+		})
+
+		It("builds the ring from the cluster configuration", func() {
+			clusterCfg := &config.ClusterConfig{
+				Nodes: []config.Node{
+					{Hostname: "node-b", InternalIP: "10.0.0.2"},
+					{Hostname: "node-a", InternalIP: "10.0.0.1"},
+					{Hostname: "node-c"},
+				},
+				PodEndpoints: []config.PodEndpoint{
+					{Nodename: "node-b", PodIP: "100.64.0.2", Port: 8881},
+					{Nodename: "node-b", PodIP: "100.64.0.3", Port: 8881},
+				},
+			}
+			Expect(heartbeatRing(clusterCfg, true, 12996)).To(Equal([]heartbeatPeer{
+				{node: "node-a", url: "http://10.0.0.1:12996/heartbeat"},
+				{node: "node-b", url: "http://10.0.0.2:12996/heartbeat"},
+			}))
+			Expect(heartbeatRing(clusterCfg, false, 12996)).To(Equal([]heartbeatPeer{
+				{node: "node-b", url: "http://100.64.0.2:8881/heartbeat"},
+			}))
+		})
+	})
+
+	Describe("configuration", func() {
+		var (
+			s       *server
+			keyFile string
+		)
+
+		BeforeEach(func() {
+			keyFile = filepath.Join(GinkgoT().TempDir(), "key")
+			Expect(os.WriteFile(keyFile, append(key, '\n'), 0o600)).To(Succeed())
+			s = &server{
+				log:         logrus.NewEntry(logrus.StandardLogger()),
+				nodeName:    "node-a",
+				hostNetwork: true,
+				currentClusterConfig: &config.ClusterConfig{
+					Nodes: []config.Node{
+						{Hostname: "node-a", InternalIP: "10.0.0.1"},
+						{Hostname: "node-b", InternalIP: "10.0.0.2"},
+						{Hostname: "node-c", InternalIP: "10.0.0.3"},
+					},
+				},
+			}
+		})
+
+		agentConfigOf := func(hbCfg *config.PeerHeartbeatConfig) *config.AgentConfig {
+			return &config.AgentConfig{
+				PeerHeartbeat: hbCfg,
+				HostNetwork:   &config.NetworkConfig{HTTPPort: 12996},
+				PodNetwork:    &config.NetworkConfig{HTTPPort: 8881},
+			}
+		}
+
+		It("is disabled by default", func() {
+			settings, err := s.heartbeatSettingsOf(agentConfigOf(nil))
+			Expect(err).To(BeNil())
+			Expect(settings).To(BeNil())
+		})
+
+		It("applies defaults", func() {
+			settings, err := s.heartbeatSettingsOf(agentConfigOf(&config.PeerHeartbeatConfig{Enabled: true, KeyFile: keyFile}))
+			Expect(err).To(BeNil())
+			Expect(settings.period).To(Equal(defaultHeartbeatPeriod))
+			Expect(settings.key).To(Equal(key))
+			Expect(nodesOf(settings.targets)).To(Equal([]string{"node-b", "node-c"}))
+			Expect(settings.senders.ToSortedArray()).To(Equal([]string{"node-b", "node-c"}))
+		})
+
+		DescribeTable("rejects invalid configurations",
+			func(hbCfg *config.PeerHeartbeatConfig, expectedErr string) {
+				if hbCfg.KeyFile == "key" {
+					hbCfg.KeyFile = keyFile
+				}
+				_, err := s.heartbeatSettingsOf(agentConfigOf(hbCfg))
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			},
+			Entry("period too short", &config.PeerHeartbeatConfig{Enabled: true, KeyFile: "key", Period: &metav1.Duration{Duration: time.Second}}, "period"),
+			Entry("too many peers", &config.PeerHeartbeatConfig{Enabled: true, KeyFile: "key", Peers: 6}, "peers"),
+			Entry("missing key file", &config.PeerHeartbeatConfig{Enabled: true}, "keyFile"),
+			Entry("unreadable key file", &config.PeerHeartbeatConfig{Enabled: true, KeyFile: "/nonexisting"}, "cannot read"),
+		)
+
+		It("rejects short keys", func() {
+			Expect(os.WriteFile(keyFile, []byte("short"), 0o600)).To(Succeed())
+			_, err := s.heartbeatSettingsOf(agentConfigOf(&config.PeerHeartbeatConfig{Enabled: true, KeyFile: keyFile}))
+			Expect(err).To(MatchError(ContainSubstring("too short")))
+		})
+	})
+
+	Describe("tracker", func() {
+		var (
+			tracker *heartbeatTracker
+			now     time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+			tracker = newHeartbeatTracker()
+			tracker.now = func() time.Time { return now }
+			tracker.configure(&heartbeatSettings{key: key, senders: common.StringSet{"node-b": {}, "node-c": {}}})
+		})
+
+		post := func(hb *heartbeat, signingKey []byte) int {
+			body, err := json.Marshal(hb)
+			Expect(err).To(BeNil())
+			req := httptest.NewRequest(http.MethodPost, common.PathHeartbeat, bytes.NewReader(body))
+			req.Header.Set(common.HeaderHeartbeatSignature, hex.EncodeToString(signHeartbeat(signingKey, body)))
+			rec := httptest.NewRecorder()
+			tracker.handleHeartbeat(rec, req)
+			return rec.Code
+		}
+
+		It("measures the age of the last heartbeat per sender", func() {
+			Expect(testutil.CollectAndCount(tracker)).To(Equal(2))
+			now = now.Add(30 * time.Second)
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now}, key)).To(Equal(http.StatusNoContent))
+			now = now.Add(10 * time.Second)
+
+			tracker.configure(&heartbeatSettings{key: key, senders: common.StringSet{"node-b": {}}})
+			Expect(testutil.ToFloat64(tracker)).To(Equal(10.0))
+		})
+
+		It("measures the age of senders without heartbeat since they are expected", func() {
+			now = now.Add(60 * time.Second)
+			tracker.configure(&heartbeatSettings{key: key, senders: common.StringSet{"node-c": {}}})
+			Expect(testutil.ToFloat64(tracker)).To(Equal(60.0))
+		})
+
+		It("evicts departed senders", func() {
+			tracker.configure(&heartbeatSettings{key: key, senders: common.StringSet{"node-d": {}}})
+			Expect(testutil.CollectAndCount(tracker)).To(Equal(1))
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now}, key)).To(Equal(http.StatusForbidden))
+
+			tracker.configure(nil)
+			Expect(testutil.CollectAndCount(tracker)).To(Equal(0))
+			Expect(post(&heartbeat{Node: "node-d", Timestamp: now}, key)).To(Equal(http.StatusNotFound))
+		})
+
+		It("verifies the heartbeats", func() {
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now}, []byte("another-key-0123"))).To(Equal(http.StatusUnauthorized))
+			Expect(post(&heartbeat{Node: "node-x", Timestamp: now}, key)).To(Equal(http.StatusForbidden))
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now.Add(-2 * maxHeartbeatClockSkew)}, key)).To(Equal(http.StatusBadRequest))
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now.Add(2 * maxHeartbeatClockSkew)}, key)).To(Equal(http.StatusBadRequest))
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now}, key)).To(Equal(http.StatusNoContent))
+			// replayed heartbeat
+			Expect(post(&heartbeat{Node: "node-b", Timestamp: now}, key)).To(Equal(http.StatusConflict))
+
+			req := httptest.NewRequest(http.MethodPost, common.PathHeartbeat, bytes.NewReader(make([]byte, maxHeartbeatSize+1)))
+			rec := httptest.NewRecorder()
+			tracker.handleHeartbeat(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusRequestEntityTooLarge))
+
+			rec = httptest.NewRecorder()
+			tracker.handleHeartbeat(rec, httptest.NewRequest(http.MethodGet, common.PathHeartbeat, nil))
+			Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	It("sends the heartbeat to the target peers", func() {
+		receivers := map[string]*heartbeatTracker{}
+		var targets []heartbeatPeer
+		for _, node := range []string{"node-b", "node-c"} {
+			tracker := newHeartbeatTracker()
+			tracker.configure(&heartbeatSettings{key: key, senders: common.StringSet{"node-a": {}}})
+			receivers[node] = tracker
+			peer := httptest.NewServer(http.HandlerFunc(tracker.handleHeartbeat))
+			defer peer.Close()
+			targets = append(targets, heartbeatPeer{node: node, url: peer.URL + common.PathHeartbeat})
+		}
+		s := &server{
+			log:       logrus.NewEntry(logrus.StandardLogger()),
+			nodeName:  "node-a",
+			heartbeat: &heartbeatSettings{period: time.Minute, key: key, targets: targets},
+		}
+		s.failedObservations.Add(3)
+
+		now := time.Now()
+		s.sendHeartbeatIfDue(now)
+		for _, tracker := range receivers {
+			Eventually(func() time.Time {
+				tracker.lock.Lock()
+				defer tracker.lock.Unlock()
+				return tracker.received["node-a"].timestamp
+			}).Should(BeTemporally("==", now))
+		}
+		Expect(s.failedObservations.Load()).To(Equal(int64(0)))
+
+		// not due yet
+		Eventually(func() bool {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			return s.heartbeatInFlight
+		}).Should(BeFalse())
+		s.sendHeartbeatIfDue(now.Add(time.Second))
+		Expect(s.lastHeartbeat).To(Equal(now))
+	})
+})
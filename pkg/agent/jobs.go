@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// skippedJob is a job of the configuration which has been skipped at parse time.
+type skippedJob struct {
+	args     []string
+	reason   string
+	disabled bool
+	// degraded is true if a secret referenced by the job cannot be resolved.
+	degraded bool
+}
+
+// jobStatus is the compact view of a scheduled job listed at `/jobs`.
+type jobStatus struct {
+	JobID       string     `json:"jobID"`
+	Args        []string   `json:"args"`
+	Period      string     `json:"period"`
+	Description string     `json:"description"`
+	Running     bool       `json:"running"`
+	LastRun     *time.Time `json:"lastRun,omitempty"`
+	NextRun     *time.Time `json:"nextRun,omitempty"`
+}
+
+// handleJobs lists the scheduled jobs with their schedule as JSON. It is a compact view of the job status.
+func (s *server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp, err := s.GetJobStatus(r.Context(), &nwpd.GetJobStatusRequest{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jobs := make([]jobStatus, 0, len(resp.Jobs))
+	for _, status := range resp.Jobs {
+		if !status.Skipped {
+			jobs = append(jobs, jobStatusOf(status))
+		}
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func jobStatusOf(status *nwpd.JobStatus) jobStatus {
+	result := jobStatus{
+		JobID:       status.JobID,
+		Args:        status.Args,
+		Period:      status.Period.AsDuration().String(),
+		Description: status.Description,
+		Running:     status.Running,
+	}
+	if status.LastRun != nil {
+		lastRun := status.LastRun.AsTime()
+		result.LastRun = &lastRun
+	}
+	if status.NextRun != nil {
+		nextRun := status.NextRun.AsTime()
+		result.NextRun = &nextRun
+	}
+	return result
+}
+
+// GetJobStatus returns the status of the scheduled jobs and the jobs skipped at parse time sorted by job ID.
+func (s *server) GetJobStatus(_ context.Context, _ *nwpd.GetJobStatusRequest) (*nwpd.GetJobStatusResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	resp := &nwpd.GetJobStatusResponse{
+		Environment:      s.environment,
+		DisabledFeatures: append([]string{}, s.disabledFeatures...),
+		LocalBlock:       s.localBlock.getStatus(),
+	}
+	for _, job := range s.jobs {
+		status := &nwpd.JobStatus{
+			JobID:       job.JobID(),
+			Args:        append([]string{}, job.Config().Args...),
+			Period:      durationpb.New(job.Period()),
+			Description: s.secrets.redact(job.Description()),
+			Running:     job.Running(),
+			SkipReason:  s.secrets.redact(s.skippedJobs[job.JobID()].reason),
+			Degraded:    s.skippedJobs[job.JobID()].degraded,
+		}
+		if lastRun := job.GetLastRun(); lastRun != nil {
+			status.LastRun = timestamppb.New(*lastRun)
+			status.NextRun = timestamppb.New(job.NextRun())
+		}
+		if result, failures := job.LastResult(); result != nil {
+			status.LastRunOk = int32(result.Ok)         // #nosec G115 -- bounded by number of destinations
+			status.LastRunFailed = int32(result.Failed) // #nosec G115 -- bounded by number of destinations
+			status.LastFailure = s.secrets.redact(result.LastFailure)
+			status.ConsecutiveFailures = int32(failures) // #nosec G115 -- bounded by number of runs
+		}
+		for _, b := range job.BackoffStates() {
+			status.Backoffs = append(status.Backoffs, &nwpd.DestinationBackoff{
+				DestHost: b.Key,
+				Failures: int32(b.Failures), // #nosec G115 -- bounded by number of runs
+				Until:    timestamppb.New(b.Until),
+			})
+		}
+		resp.Jobs = append(resp.Jobs, status)
+	}
+	for jobID, skipped := range s.skippedJobs {
+		if s.jobs[jobID] != nil {
+			continue
+		}
+		resp.Jobs = append(resp.Jobs, &nwpd.JobStatus{
+			JobID:      jobID,
+			Args:       append([]string{}, skipped.args...),
+			Skipped:    true,
+			SkipReason: s.secrets.redact(skipped.reason),
+			Disabled:   skipped.disabled,
+			Degraded:   skipped.degraded,
+		})
+	}
+	sort.Slice(resp.Jobs, func(i, j int) bool {
+		return resp.Jobs[i].JobID < resp.Jobs[j].JobID
+	})
+	return resp, nil
+}
+
+// handleStatus returns the job status as JSON.
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp, err := s.GetJobStatus(r.Context(), &nwpd.GetJobStatusRequest{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var _ = Describe("jobs", func() {
+	It("lists the current jobs with their schedule", func() {
+		s := &server{
+			log:                logrus.NewEntry(logrus.StandardLogger()),
+			nodeName:           "node-a",
+			jobs:               map[jobid]*runners.InternalJob{},
+			currentAgentConfig: &config.AgentConfig{},
+		}
+		for _, id := range []string{"nslookup2", "nslookup1"} {
+			rconfig := runners.RunnerConfig{Job: config.Job{JobID: id, Args: []string{"nslookup", "--names", "foo.bar"}}, Period: 10 * time.Second}
+			job, err := runners.Parse(config.ClusterConfig{}, rconfig, rconfig.Args, &config.SampleConfig{})
+			Expect(err).To(BeNil())
+			s.addOrReplaceJob(job)
+		}
+
+		rec := httptest.NewRecorder()
+		s.handleJobs(rec, httptest.NewRequest(http.MethodGet, common.PathJobs, nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var jobs []jobStatus
+		Expect(json.Unmarshal(rec.Body.Bytes(), &jobs)).To(Succeed())
+		Expect(jobs).To(HaveLen(2))
+		Expect(jobs[0].JobID).To(Equal("nslookup1"))
+		Expect(jobs[1].JobID).To(Equal("nslookup2"))
+		Expect(jobs[0].Args).To(Equal([]string{"nslookup", "--names", "foo.bar"}))
+		Expect(jobs[0].Period).To(Equal("10s"))
+		Expect(jobs[0].Description).NotTo(BeEmpty())
+		Expect(jobs[0].Running).To(BeFalse())
+		Expect(jobs[0].LastRun).NotTo(BeNil())
+		Expect(jobs[0].NextRun.Sub(*jobs[0].LastRun)).To(BeNumerically(">=", 10*time.Second))
+
+		rec = httptest.NewRecorder()
+		s.handleJobs(rec, httptest.NewRequest(http.MethodPost, common.PathJobs, nil))
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+	It("returns the status of scheduled and skipped jobs", func() {
+		s := &server{
+			log:                logrus.NewEntry(logrus.StandardLogger()),
+			nodeName:           "node-a",
+			jobs:               map[jobid]*runners.InternalJob{},
+			currentAgentConfig: &config.AgentConfig{},
+		}
+		agentConfig := &config.AgentConfig{PodNetwork: &config.NetworkConfig{
+			Jobs: []config.Job{
+				{JobID: "good", Args: []string{"nslookup", "--names", "foo.bar"}},
+				{JobID: "bad", Args: []string{"checkTCPPort", "--endpoints", "server:10.0.0.9:-1"}},
+				{JobID: "other-node", Args: []string{"nslookup", "--names", "foo.bar"}, NodeNamePattern: "node-b"},
+			},
+		}}
+		Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+
+		resp, err := s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+		Expect(err).To(BeNil())
+		Expect(resp.Jobs).To(HaveLen(3))
+		bad, good, other := resp.Jobs[0], resp.Jobs[1], resp.Jobs[2]
+		Expect(bad.JobID).To(Equal("bad"))
+		Expect(bad.Skipped).To(BeTrue())
+		Expect(bad.SkipReason).To(ContainSubstring("invalid endpoint port"))
+		Expect(good.JobID).To(Equal("good"))
+		Expect(good.Skipped).To(BeFalse())
+		Expect(good.SkipReason).To(BeEmpty())
+		Expect(good.Args).To(Equal([]string{"nslookup", "--names", "foo.bar"}))
+		Expect(good.LastRun).NotTo(BeNil())
+		Expect(other.JobID).To(Equal("other-node"))
+		Expect(other.Skipped).To(BeTrue())
+		Expect(other.SkipReason).To(Equal("not selected for node node-a"))
+
+		// a running job keeps its previous configuration if the new one is rejected
+		agentConfig.PodNetwork.Jobs[0].Args = []string{"nslookup", "--unknown"}
+		Expect(s.applyAgentConfig(agentConfig)).To(MatchError(ContainSubstring("invalid job good")))
+		resp, err = s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+		Expect(err).To(BeNil())
+		good = resp.Jobs[1]
+		Expect(good.Skipped).To(BeFalse())
+		Expect(good.Args).To(Equal([]string{"nslookup", "--names", "foo.bar"}))
+
+		rec := httptest.NewRecorder()
+		s.handleStatus(rec, httptest.NewRequest(http.MethodGet, common.PathStatus, nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		status := &nwpd.GetJobStatusResponse{}
+		Expect(protojson.Unmarshal(rec.Body.Bytes(), status)).To(Succeed())
+		Expect(status.Jobs).To(HaveLen(3))
+
+		// the jobs list is the compact view of the scheduled jobs of the status
+		rec = httptest.NewRecorder()
+		s.handleJobs(rec, httptest.NewRequest(http.MethodGet, common.PathJobs, nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var jobs []jobStatus
+		Expect(json.Unmarshal(rec.Body.Bytes(), &jobs)).To(Succeed())
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].JobID).To(Equal("good"))
+		Expect(jobs[0].Args).To(Equal(status.Jobs[1].Args))
+		Expect(jobs[0].Description).To(Equal(status.Jobs[1].Description))
+		Expect(jobs[0].LastRun.Equal(status.Jobs[1].LastRun.AsTime())).To(BeTrue())
+	})
+})
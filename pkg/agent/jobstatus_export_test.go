@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JobStatusOfConfigFiles sets up an agent with the configuration files in the environment given by them or detected,
+// and returns its job status. It is used by the tests importing the deploy package, which depends on this package.
+func JobStatusOfConfigFiles(agentConfigFile, clusterConfigFile, logDirectory string, hostNetwork bool) (*nwpd.GetJobStatusResponse, error) {
+	s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, hostNetwork, "")
+	if err != nil {
+		return nil, err
+	}
+	s.logDirectory = logDirectory
+	if err := s.setup(); err != nil {
+		return nil, err
+	}
+	defer s.stop()
+	return s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+}
@@ -0,0 +1,522 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultLocalBlockMinFailingPeers     = 3
+	defaultLocalBlockMinFailingPeerRatio = 0.5
+	defaultLocalBlockWindow              = 1 * time.Minute
+	defaultLocalBlockInterval            = 10 * time.Minute
+	minLocalBlockInterval                = 1 * time.Minute
+	// localBlockDialTimeout is the timeout for connecting to a port of the agent.
+	localBlockDialTimeout = 2 * time.Second
+	// localBlockCommandTimeout is the timeout for listing the firewall rules.
+	localBlockCommandTimeout = 5 * time.Second
+
+	// LocalBlockDiagnosisJobID is the job ID of the observations of the local block diagnosis.
+	LocalBlockDiagnosisJobID = "local-block-diagnosis"
+	// ResultFieldSeverity is the result field of the local block diagnosis observations with the severity.
+	ResultFieldSeverity = "severity"
+	// SeverityLocalBlockSuspected is the severity of a diagnosis finding the ports of the agent blocked on the node.
+	SeverityLocalBlockSuspected = "LOCAL_BLOCK_SUSPECTED"
+)
+
+// localBlockSettings is the applied local block detection configuration.
+type localBlockSettings struct {
+	minFailingPeers     int
+	minFailingPeerRatio float64
+	window              time.Duration
+	interval            time.Duration
+	inspectFirewall     bool
+	// ports are the TCP ports served by the agent.
+	ports []int
+	// addresses are the pod IP and, in the host network, the node IP the peers connect to.
+	addresses []string
+	// self is the hostname of the node of the agent.
+	self string
+	// peers are the hostnames of the other nodes of the cluster. The other destinations are outbound targets.
+	peers common.StringSet
+}
+
+// localBlockSettingsOf validates the local block detection configuration.
+// It returns nil if the local block detection is disabled.
+func (s *server) localBlockSettingsOf(cfg *config.AgentConfig) (*localBlockSettings, error) {
+	lbCfg := cfg.LocalBlockDetection
+	if lbCfg == nil || !lbCfg.Enabled {
+		return nil, nil
+	}
+	settings := &localBlockSettings{
+		minFailingPeers:     defaultLocalBlockMinFailingPeers,
+		minFailingPeerRatio: defaultLocalBlockMinFailingPeerRatio,
+		window:              defaultLocalBlockWindow,
+		interval:            defaultLocalBlockInterval,
+		inspectFirewall:     lbCfg.InspectFirewall && s.hostNetwork,
+		self:                s.nodeName,
+		peers:               common.StringSet{},
+	}
+	if lbCfg.MinFailingPeers != 0 {
+		if lbCfg.MinFailingPeers < 1 {
+			return nil, fmt.Errorf("invalid LocalBlockDetection minFailingPeers, must be >= 1")
+		}
+		settings.minFailingPeers = lbCfg.MinFailingPeers
+	}
+	if lbCfg.MinFailingPeerRatio != 0 {
+		if lbCfg.MinFailingPeerRatio < 0 || lbCfg.MinFailingPeerRatio > 1 {
+			return nil, fmt.Errorf("invalid LocalBlockDetection minFailingPeerRatio, must be in range (0,1]")
+		}
+		settings.minFailingPeerRatio = lbCfg.MinFailingPeerRatio
+	}
+	if lbCfg.Window != nil {
+		if lbCfg.Window.Duration <= 0 {
+			return nil, fmt.Errorf("invalid LocalBlockDetection window, must be > 0")
+		}
+		settings.window = lbCfg.Window.Duration
+	}
+	if lbCfg.Interval != nil {
+		if lbCfg.Interval.Duration < minLocalBlockInterval {
+			return nil, fmt.Errorf("invalid LocalBlockDetection interval, must be >= %s", minLocalBlockInterval)
+		}
+		settings.interval = lbCfg.Interval.Duration
+	}
+	port := s.getNetworkCfgOf(cfg).HTTPPort
+	if port == 0 {
+		return nil, fmt.Errorf("LocalBlockDetection requires the http server, but httpPort is not set")
+	}
+	settings.ports = []int{port}
+	addresses := common.StringSet{}
+	if ip := os.Getenv(common.EnvPodIP); ip != "" {
+		addresses.Add(ip)
+	}
+	if ip := os.Getenv(common.EnvNodeIP); ip != "" && s.hostNetwork {
+		addresses.Add(ip)
+	}
+	settings.addresses = addresses.ToSortedArray()
+	if s.currentClusterConfig != nil {
+		for _, n := range s.currentClusterConfig.Nodes {
+			settings.peers.Add(n.Hostname)
+		}
+		for _, pe := range s.currentClusterConfig.PodEndpoints {
+			settings.peers.Add(pe.Nodename)
+		}
+		settings.peers.Delete(s.nodeName)
+	}
+	return settings, nil
+}
+
+// localDiagnoser checks the ports of the agent on the node.
+type localDiagnoser struct {
+	dial func(address string, timeout time.Duration) error
+	// listening returns true if a TCP listener is bound to the port.
+	listening func(port int) (bool, error)
+	// firewallRules returns the firewall rules with their counters.
+	firewallRules func() (string, error)
+}
+
+func newLocalDiagnoser() *localDiagnoser {
+	return &localDiagnoser{
+		dial: func(address string, timeout time.Duration) error {
+			conn, err := net.DialTimeout("tcp", address, timeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+		listening:     listeningOnPort,
+		firewallRules: listFirewallRules,
+	}
+}
+
+// localDiagnosis is the result of the local block diagnosis.
+type localDiagnosis struct {
+	suspected bool
+	// listenerDown is true if a port of the agent is not bound anymore.
+	listenerDown bool
+	findings     []string
+}
+
+func (d *localDiagnosis) reason() string {
+	if len(d.findings) == 0 {
+		return "all ports reachable locally"
+	}
+	return strings.Join(d.findings, "; ")
+}
+
+// run checks that the listeners of the ports are bound and reachable via the loopback and the peer facing addresses.
+// A local block is suspected if a bound port is reachable via loopback, but not via a peer facing address, or if a
+// firewall rule with non-zero counters drops or rejects packets to the port.
+func (d *localDiagnoser) run(settings *localBlockSettings) *localDiagnosis {
+	result := &localDiagnosis{}
+	for _, port := range settings.ports {
+		bound, err := d.listening(port)
+		switch {
+		case err != nil:
+			result.findings = append(result.findings, fmt.Sprintf("cannot check listener of port %d: %s", port, err))
+		case !bound:
+			result.listenerDown = true
+			result.findings = append(result.findings, fmt.Sprintf("no listener bound to port %d", port))
+			continue
+		}
+		if err := d.dial(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), localBlockDialTimeout); err != nil {
+			result.findings = append(result.findings, fmt.Sprintf("port %d not reachable via loopback: %s", port, err))
+			continue
+		}
+		for _, addr := range settings.addresses {
+			if err := d.dial(net.JoinHostPort(addr, strconv.Itoa(port)), localBlockDialTimeout); err != nil {
+				result.suspected = true
+				result.findings = append(result.findings, fmt.Sprintf("port %d not reachable via %s: %s", port, addr, err))
+			}
+		}
+	}
+	if settings.inspectFirewall {
+		rules, err := d.firewallRules()
+		if err != nil {
+			result.findings = append(result.findings, fmt.Sprintf("cannot inspect firewall rules: %s", err))
+		}
+		for _, rule := range blockingFirewallRules(rules, settings.ports) {
+			result.suspected = true
+			result.findings = append(result.findings, fmt.Sprintf("firewall rule with drops: %s", rule))
+		}
+	}
+	return result
+}
+
+// listeningOnPort looks up a TCP socket in listen state bound to the port in `/proc/net/tcp` and `/proc/net/tcp6`.
+func listeningOnPort(port int) (bool, error) {
+	found := false
+	read := 0
+	for _, filename := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(filename)
+		if err != nil {
+			continue
+		}
+		read++
+		found = found || hasListeningSocket(f, port)
+		_ = f.Close()
+	}
+	if read == 0 {
+		return false, fmt.Errorf("no socket table found")
+	}
+	return found, nil
+}
+
+// listenState is the state of a listening socket in the socket tables of the proc file system.
+const listenState = "0A"
+
+func hasListeningSocket(r io.Reader, port int) bool {
+	suffix := fmt.Sprintf(":%04X", port)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// sl local_address rem_address st ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 3 && fields[3] == listenState && strings.HasSuffix(fields[1], suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// listFirewallRules returns the nftables ruleset and the iptables rules with their counters.
+func listFirewallRules() (string, error) {
+	var (
+		sb      strings.Builder
+		lastErr error
+	)
+	ok := false
+	for _, args := range [][]string{{"nft", "list", "ruleset"}, {"iptables-save", "-c"}, {"ip6tables-save", "-c"}} {
+		ctx, cancel := context.WithTimeout(context.Background(), localBlockCommandTimeout)
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output() // #nosec G204 -- fixed commands
+		cancel()
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %s", args[0], err)
+			continue
+		}
+		ok = true
+		sb.Write(out)
+		sb.WriteString("\n")
+	}
+	if !ok {
+		return "", lastErr
+	}
+	return sb.String(), nil
+}
+
+var (
+	iptablesCounters = regexp.MustCompile(`^\[(\d+):\d+\]`)
+	nftCounters      = regexp.MustCompile(`\bcounter packets (\d+)\b`)
+	destPorts        = regexp.MustCompile(`(?:--dports?|\bdport)\s+(\{[^}]*\}|\S+)`)
+	blockingVerdict  = regexp.MustCompile(`(?:-j (?:DROP|REJECT)\b|\b(?:drop|reject)\b)`)
+)
+
+// blockingFirewallRules returns the nftables and iptables rules with a non-zero packet counter dropping or rejecting
+// packets to one of the ports.
+func blockingFirewallRules(rules string, ports []int) []string {
+	var result []string
+	for _, line := range strings.Split(rules, "\n") {
+		line = strings.TrimSpace(line)
+		if !blockingVerdict.MatchString(line) {
+			continue
+		}
+		var counter string
+		if m := iptablesCounters.FindStringSubmatch(line); m != nil {
+			counter = m[1]
+		} else if m := nftCounters.FindStringSubmatch(line); m != nil {
+			counter = m[1]
+		}
+		if counter == "" || counter == "0" {
+			continue
+		}
+		for _, m := range destPorts.FindAllStringSubmatch(line, -1) {
+			if matchesAnyPort(m[1], ports) {
+				result = append(result, line)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// matchesAnyPort checks the port list of a rule like `1011`, `1000:2000`, `1000-2000` or `{ 80, 1011 }`.
+func matchesAnyPort(spec string, ports []int) bool {
+	spec = strings.Trim(spec, "{} ")
+	for _, item := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, found := strings.Cut(item, ":")
+		if !found {
+			from, to, found = strings.Cut(item, "-")
+		}
+		lo, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		hi := lo
+		if found {
+			if hi, err = strconv.Atoi(to); err != nil {
+				continue
+			}
+		}
+		for _, port := range ports {
+			if port >= lo && port <= hi {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isBlockLikeFailure returns true if the failure of a check is a refused connection or a timeout,
+// as caused by a firewall rule rejecting or dropping the packets. Failures after the connection has been established
+// are caused by the peer service and not by a block.
+func isBlockLikeFailure(obs *nwpd.Observation) bool {
+	if category := obs.ResultFields[runners.ResultFieldFailureCategory]; category != "" && !runners.IsConnectFailure(category) {
+		return false
+	}
+	result := strings.ToLower(obs.Result)
+	return strings.Contains(result, "connection refused") ||
+		strings.Contains(result, "timeout") ||
+		strings.Contains(result, "deadline exceeded")
+}
+
+type peerCheck struct {
+	jobID    string
+	destHost string
+}
+
+type peerCheckState struct {
+	timestamp time.Time
+	blocked   bool
+}
+
+// localBlockMonitor watches the observations for the pattern of a local block: checks to many peers are refused or time
+// out at the same time, while checks to outbound targets still succeed. On this pattern, it diagnoses the ports of the
+// agent at most once per interval.
+// A nil monitor ignores all calls.
+type localBlockMonitor struct {
+	lock           sync.Mutex
+	log            logrus.FieldLogger
+	settings       *localBlockSettings
+	diagnoser      *localDiagnoser
+	checks         map[peerCheck]peerCheckState
+	lastOutboundOk time.Time
+	lastDiagnosis  time.Time
+	inFlight       bool
+	status         *nwpd.LocalBlockStatus
+}
+
+func newLocalBlockMonitor(log logrus.FieldLogger) *localBlockMonitor {
+	return &localBlockMonitor{
+		log:       log,
+		diagnoser: newLocalDiagnoser(),
+		checks:    map[peerCheck]peerCheckState{},
+	}
+}
+
+// configure applies the settings. The detection is disabled if the settings are nil.
+func (m *localBlockMonitor) configure(settings *localBlockSettings) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.settings = settings
+	if settings == nil {
+		m.checks = map[peerCheck]peerCheckState{}
+		m.status = nil
+		LocalBlockSuspected.Set(0)
+	}
+}
+
+func (m *localBlockMonitor) observe(obs *nwpd.Observation) {
+	if m == nil || obs.JobID == LocalBlockDiagnosisJobID || obs.StaleEndpoint {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.settings == nil || obs.DestHost == m.settings.self {
+		return
+	}
+	t := obs.Timestamp.AsTime()
+	if !m.settings.peers.Contains(obs.DestHost) {
+		if obs.Ok && t.After(m.lastOutboundOk) {
+			m.lastOutboundOk = t
+		}
+		return
+	}
+	key := peerCheck{jobID: obs.JobID, destHost: obs.DestHost}
+	if last, ok := m.checks[key]; ok && !t.After(last.timestamp) {
+		return
+	}
+	m.checks[key] = peerCheckState{timestamp: t, blocked: !obs.Ok && isBlockLikeFailure(obs)}
+}
+
+// evaluate returns the numbers of the checked peers and of the peers with a refused or timed out last check
+// in the window and if the pattern of a local block is observed.
+func (m *localBlockMonitor) evaluate(now time.Time) (checked, failing int, matched bool) {
+	since := now.Add(-m.settings.window)
+	checkedPeers := common.StringSet{}
+	failingPeers := common.StringSet{}
+	for key, state := range m.checks {
+		if state.timestamp.Before(since) {
+			delete(m.checks, key)
+			continue
+		}
+		checkedPeers.Add(key.destHost)
+		if state.blocked {
+			failingPeers.Add(key.destHost)
+		}
+	}
+	checked, failing = len(checkedPeers), len(failingPeers)
+	matched = failing >= m.settings.minFailingPeers &&
+		float64(failing) >= m.settings.minFailingPeerRatio*float64(checked) &&
+		!m.lastOutboundOk.Before(since)
+	return
+}
+
+// startIfDue returns the settings and the observed pattern if a diagnosis is due.
+// The caller must call finish with the result of the diagnosis.
+func (m *localBlockMonitor) startIfDue(now time.Time) (*localBlockSettings, string) {
+	if m == nil {
+		return nil, ""
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.settings == nil || m.inFlight || (!m.lastDiagnosis.IsZero() && now.Sub(m.lastDiagnosis) < m.settings.interval) {
+		return nil, ""
+	}
+	checked, failing, matched := m.evaluate(now)
+	if !matched {
+		return nil, ""
+	}
+	m.inFlight = true
+	m.lastDiagnosis = now
+	return m.settings, fmt.Sprintf("checks to %d of %d peers refused or timed out while outbound checks succeed", failing, checked)
+}
+
+// finish records the result of the diagnosis and returns the diagnosis observation.
+func (m *localBlockMonitor) finish(now time.Time, nodeName, pattern string, diagnosis *localDiagnosis) *nwpd.Observation {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.inFlight = false
+	reason := fmt.Sprintf("%s: %s", pattern, diagnosis.reason())
+	m.status = &nwpd.LocalBlockStatus{
+		Suspected:     diagnosis.suspected,
+		LastDiagnosis: timestamppb.New(now),
+		Reason:        reason,
+	}
+	obs := &nwpd.Observation{
+		JobID:     LocalBlockDiagnosisJobID,
+		SrcHost:   nodeName,
+		DestHost:  nodeName,
+		Timestamp: timestamppb.New(now),
+		Duration:  durationpb.New(time.Since(now)),
+		Result:    reason,
+		Ok:        !diagnosis.suspected && !diagnosis.listenerDown,
+	}
+	if diagnosis.suspected {
+		LocalBlockSuspected.Set(1)
+		obs.ResultFields = map[string]string{ResultFieldSeverity: SeverityLocalBlockSuspected}
+		m.log.Warnf("%s: %s", SeverityLocalBlockSuspected, reason)
+	} else {
+		LocalBlockSuspected.Set(0)
+		m.log.Infof("local block diagnosis: %s", reason)
+	}
+	return obs
+}
+
+// getStatus returns the result of the last diagnosis or nil if the detection is disabled.
+func (m *localBlockMonitor) getStatus() *nwpd.LocalBlockStatus {
+	if m == nil {
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.settings == nil {
+		return nil
+	}
+	if m.status == nil {
+		return &nwpd.LocalBlockStatus{}
+	}
+	return m.status
+}
+
+// diagnoseLocalBlockIfDue runs the local block diagnosis in the background if the pattern of a local block is observed
+// and the last diagnosis is older than the interval.
+func (s *server) diagnoseLocalBlockIfDue(now time.Time) {
+	settings, pattern := s.localBlock.startIfDue(now)
+	if settings == nil {
+		return
+	}
+	s.log.Infof("running local block diagnosis: %s", pattern)
+	go func() {
+		diagnosis := s.localBlock.diagnoser.run(settings)
+		obs := s.localBlock.finish(now, s.nodeName, pattern, diagnosis)
+		select {
+		case s.obsChan <- obs:
+		case <-s.done:
+		}
+	}()
+}
@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("local block detection", func() {
+	Describe("settings", func() {
+		var s *server
+
+		BeforeEach(func() {
+			s = &server{
+				log:         logrus.NewEntry(logrus.StandardLogger()),
+				nodeName:    "node-a",
+				hostNetwork: true,
+				currentClusterConfig: &config.ClusterConfig{
+					Nodes:        []config.Node{{Hostname: "node-a"}, {Hostname: "node-b"}},
+					PodEndpoints: []config.PodEndpoint{{Nodename: "node-a"}, {Nodename: "node-c"}},
+				},
+			}
+			GinkgoT().Setenv(common.EnvPodIP, "10.0.0.1")
+			GinkgoT().Setenv(common.EnvNodeIP, "10.0.0.1")
+		})
+
+		agentConfigOf := func(lbCfg *config.LocalBlockDetectionConfig) *config.AgentConfig {
+			return &config.AgentConfig{
+				LocalBlockDetection: lbCfg,
+				HostNetwork:         &config.NetworkConfig{HTTPPort: 12996},
+				PodNetwork:          &config.NetworkConfig{HTTPPort: 12996},
+			}
+		}
+
+		It("is disabled by default", func() {
+			settings, err := s.localBlockSettingsOf(agentConfigOf(nil))
+			Expect(err).To(BeNil())
+			Expect(settings).To(BeNil())
+		})
+
+		It("applies defaults", func() {
+			settings, err := s.localBlockSettingsOf(agentConfigOf(&config.LocalBlockDetectionConfig{Enabled: true, InspectFirewall: true}))
+			Expect(err).To(BeNil())
+			Expect(settings.minFailingPeers).To(Equal(defaultLocalBlockMinFailingPeers))
+			Expect(settings.minFailingPeerRatio).To(Equal(defaultLocalBlockMinFailingPeerRatio))
+			Expect(settings.window).To(Equal(defaultLocalBlockWindow))
+			Expect(settings.interval).To(Equal(defaultLocalBlockInterval))
+			Expect(settings.inspectFirewall).To(BeTrue())
+			Expect(settings.ports).To(Equal([]int{12996}))
+			Expect(settings.addresses).To(Equal([]string{"10.0.0.1"}))
+			Expect(settings.peers.ToSortedArray()).To(Equal([]string{"node-b", "node-c"}))
+		})
+
+		It("uses the pod IP and does not inspect the firewall in the pod network", func() {
+			s.hostNetwork = false
+			GinkgoT().Setenv(common.EnvPodIP, "100.64.0.5")
+			settings, err := s.localBlockSettingsOf(agentConfigOf(&config.LocalBlockDetectionConfig{Enabled: true, InspectFirewall: true}))
+			Expect(err).To(BeNil())
+			Expect(settings.inspectFirewall).To(BeFalse())
+			Expect(settings.addresses).To(Equal([]string{"100.64.0.5"}))
+		})
+
+		DescribeTable("rejects invalid configurations",
+			func(lbCfg *config.LocalBlockDetectionConfig, expectedErr string) {
+				cfg := agentConfigOf(lbCfg)
+				if expectedErr == "httpPort" {
+					cfg.HostNetwork.HTTPPort = 0
+					cfg.PodNetwork.HTTPPort = 0
+				}
+				_, err := s.localBlockSettingsOf(cfg)
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			},
+			Entry("negative peers", &config.LocalBlockDetectionConfig{Enabled: true, MinFailingPeers: -1}, "minFailingPeers"),
+			Entry("ratio too large", &config.LocalBlockDetectionConfig{Enabled: true, MinFailingPeerRatio: 1.5}, "minFailingPeerRatio"),
+			Entry("empty window", &config.LocalBlockDetectionConfig{Enabled: true, Window: &metav1.Duration{}}, "window"),
+			Entry("interval too short", &config.LocalBlockDetectionConfig{Enabled: true, Interval: &metav1.Duration{Duration: time.Second}}, "interval"),
+			Entry("without http server", &config.LocalBlockDetectionConfig{Enabled: true}, "httpPort"),
+		)
+	})
+
+	Describe("diagnosis", func() {
+		var (
+			s        *server
+			now      time.Time
+			dialed   []string
+			inbound  error
+			bound    bool
+			rules    string
+			settings *localBlockSettings
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			dialed = nil
+			inbound = nil
+			bound = true
+			rules = ""
+			settings = &localBlockSettings{
+				minFailingPeers:     3,
+				minFailingPeerRatio: 0.5,
+				window:              time.Minute,
+				interval:            10 * time.Minute,
+				ports:               []int{12996},
+				addresses:           []string{"10.0.0.1"},
+				self:                "node-a",
+				peers:               common.StringSet{"node-b": {}, "node-c": {}, "node-d": {}, "node-e": {}},
+			}
+			s = &server{
+				log:        logrus.NewEntry(logrus.StandardLogger()),
+				nodeName:   "node-a",
+				localBlock: newLocalBlockMonitor(logrus.NewEntry(logrus.StandardLogger())),
+				obsChan:    make(chan *nwpd.Observation, 10),
+				done:       make(chan struct{}),
+			}
+			s.localBlock.diagnoser = &localDiagnoser{
+				dial: func(address string, _ time.Duration) error {
+					dialed = append(dialed, address)
+					if strings.HasPrefix(address, "127.0.0.1:") {
+						return nil
+					}
+					return inbound
+				},
+				listening:     func(_ int) (bool, error) { return bound, nil },
+				firewallRules: func() (string, error) { return rules, nil },
+			}
+			s.localBlock.configure(settings)
+			DeferCleanup(func() { s.localBlock.configure(nil) })
+		})
+
+		observe := func(jobID, dest string, age time.Duration, ok bool, result string) {
+			s.localBlock.observe(&nwpd.Observation{JobID: jobID, SrcHost: "node-a", DestHost: dest,
+				Timestamp: timestamppb.New(now.Add(-age)), Ok: ok, Result: result})
+		}
+		refused := func(dest string) string {
+			return fmt.Sprintf("error: dial tcp %s:12996: connect: connection refused", dest)
+		}
+		// everything inbound refused, outbound fine
+		observeLocalBlockPattern := func() {
+			for _, peer := range []string{"node-b", "node-c", "node-d", "node-e"} {
+				observe("tcp-n2p", peer, 5*time.Second, false, refused(peer))
+				observe("ping-n2n", peer, 5*time.Second, true, "")
+			}
+			observe("tcp-n2n", "node-a", 5*time.Second, true, "")
+			observe("https-n2api-ext", "api.example.com", 5*time.Second, true, "")
+		}
+		diagnosisResult := func() *nwpd.Observation {
+			var obs *nwpd.Observation
+			Eventually(s.obsChan).Should(Receive(&obs))
+			return obs
+		}
+
+		It("classifies refused inbound checks with working outbound checks as local block", func() {
+			observeLocalBlockPattern()
+			inbound = syscall.ECONNREFUSED
+
+			s.diagnoseLocalBlockIfDue(now)
+			obs := diagnosisResult()
+			Expect(obs.JobID).To(Equal(LocalBlockDiagnosisJobID))
+			Expect(obs.SrcHost).To(Equal("node-a"))
+			Expect(obs.Ok).To(BeFalse())
+			Expect(obs.ResultFields).To(HaveKeyWithValue(ResultFieldSeverity, SeverityLocalBlockSuspected))
+			Expect(obs.Result).To(ContainSubstring("checks to 4 of 4 peers refused or timed out"))
+			Expect(obs.Result).To(ContainSubstring("port 12996 not reachable via 10.0.0.1"))
+			Expect(dialed).To(Equal([]string{"127.0.0.1:12996", "10.0.0.1:12996"}))
+
+			status := s.localBlock.getStatus()
+			Expect(status.Suspected).To(BeTrue())
+			Expect(status.LastDiagnosis.AsTime()).To(BeTemporally("==", now))
+			Expect(testutil.ToFloat64(LocalBlockSuspected)).To(Equal(1.0))
+		})
+
+		It("diagnoses at most once per interval", func() {
+			observeLocalBlockPattern()
+			s.diagnoseLocalBlockIfDue(now)
+			obs := diagnosisResult()
+			Expect(obs.Ok).To(BeTrue())
+			Expect(obs.ResultFields).To(BeEmpty())
+			Expect(s.localBlock.getStatus().Suspected).To(BeFalse())
+			Expect(testutil.ToFloat64(LocalBlockSuspected)).To(Equal(0.0))
+
+			now = now.Add(settings.interval - time.Second)
+			observeLocalBlockPattern()
+			s.diagnoseLocalBlockIfDue(now)
+			Consistently(s.obsChan, 100*time.Millisecond).ShouldNot(Receive())
+
+			now = now.Add(time.Second)
+			observeLocalBlockPattern()
+			s.diagnoseLocalBlockIfDue(now)
+			diagnosisResult()
+		})
+
+		DescribeTable("does not diagnose without the pattern of a local block",
+			func(observeAll func()) {
+				observeAll()
+				s.diagnoseLocalBlockIfDue(now)
+				Consistently(s.obsChan, 100*time.Millisecond).ShouldNot(Receive())
+				Expect(s.localBlock.getStatus().LastDiagnosis).To(BeNil())
+			},
+			Entry("outbound checks failing too", func() {
+				for _, peer := range []string{"node-b", "node-c", "node-d", "node-e"} {
+					observe("tcp-n2p", peer, 5*time.Second, false, refused(peer))
+				}
+				observe("https-n2api-ext", "api.example.com", 5*time.Second, false, "error: i/o timeout")
+			}),
+			Entry("too few peers failing", func() {
+				observe("tcp-n2p", "node-b", 5*time.Second, false, refused("node-b"))
+				observe("tcp-n2p", "node-c", 5*time.Second, false, refused("node-c"))
+				observe("https-n2api-ext", "api.example.com", 5*time.Second, true, "")
+			}),
+			Entry("failing peers below ratio", func() {
+				settings.minFailingPeers = 1
+				settings.minFailingPeerRatio = 0.75
+				observe("tcp-n2p", "node-b", 5*time.Second, false, refused("node-b"))
+				observe("tcp-n2p", "node-c", 5*time.Second, false, refused("node-c"))
+				observe("tcp-n2p", "node-d", 5*time.Second, true, "")
+				observe("tcp-n2p", "node-e", 5*time.Second, true, "")
+				observe("https-n2api-ext", "api.example.com", 5*time.Second, true, "")
+			}),
+			Entry("other failures than refused or timeout", func() {
+				for _, peer := range []string{"node-b", "node-c", "node-d", "node-e"} {
+					observe("https-n2p", peer, 5*time.Second, false, "error: x509: certificate has expired")
+				}
+				observe("https-n2api-ext", "api.example.com", 5*time.Second, true, "")
+			}),
+			Entry("failures outside of the window", func() {
+				for _, peer := range []string{"node-b", "node-c", "node-d", "node-e"} {
+					observe("tcp-n2p", peer, 2*time.Minute, false, refused(peer))
+				}
+				observe("https-n2api-ext", "api.example.com", 5*time.Second, true, "")
+			}),
+			Entry("peers recovered", func() {
+				for _, peer := range []string{"node-b", "node-c", "node-d", "node-e"} {
+					observe("tcp-n2p", peer, 10*time.Second, false, refused(peer))
+					observe("tcp-n2p", peer, 5*time.Second, true, "")
+				}
+				observe("https-n2api-ext", "api.example.com", 5*time.Second, true, "")
+			}),
+		)
+
+		It("reports a listener not bound anymore", func() {
+			observeLocalBlockPattern()
+			bound = false
+			s.diagnoseLocalBlockIfDue(now)
+			obs := diagnosisResult()
+			Expect(obs.Ok).To(BeFalse())
+			Expect(obs.ResultFields).To(BeEmpty())
+			Expect(obs.Result).To(ContainSubstring("no listener bound to port 12996"))
+			Expect(dialed).To(BeEmpty())
+		})
+
+		It("suspects a local block for firewall rules dropping packets to the port", func() {
+			observeLocalBlockPattern()
+			settings.inspectFirewall = true
+			rules = "[1234:74040] -A INPUT -p tcp -m tcp --dport 12996 -j DROP\n"
+			s.diagnoseLocalBlockIfDue(now)
+			obs := diagnosisResult()
+			Expect(obs.ResultFields).To(HaveKeyWithValue(ResultFieldSeverity, SeverityLocalBlockSuspected))
+			Expect(obs.Result).To(ContainSubstring("firewall rule with drops: [1234:74040] -A INPUT"))
+		})
+
+		It("has no status if disabled", func() {
+			s.localBlock.configure(nil)
+			Expect(s.localBlock.getStatus()).To(BeNil())
+			Expect((*localBlockMonitor)(nil).getStatus()).To(BeNil())
+			s.diagnoseLocalBlockIfDue(now)
+			Expect(s.obsChan).To(BeEmpty())
+		})
+	})
+
+	DescribeTable("finds the firewall rules dropping or rejecting packets to the ports",
+		func(rules string, expected []string) {
+			Expect(blockingFirewallRules(rules, []int{12996, 8881})).To(Equal(expected))
+		},
+		Entry("iptables drop", "[5:300] -A INPUT -p tcp -m tcp --dport 12996 -j DROP",
+			[]string{"[5:300] -A INPUT -p tcp -m tcp --dport 12996 -j DROP"}),
+		Entry("iptables reject in port range", "[5:300] -A INPUT -p tcp -m multiport --dports 8000:9000 -j REJECT --reject-with icmp-port-unreachable",
+			[]string{"[5:300] -A INPUT -p tcp -m multiport --dports 8000:9000 -j REJECT --reject-with icmp-port-unreachable"}),
+		Entry("iptables without packets", "[0:0] -A INPUT -p tcp -m tcp --dport 12996 -j DROP", nil),
+		Entry("iptables accept", "[5:300] -A INPUT -p tcp -m tcp --dport 12996 -j ACCEPT", nil),
+		Entry("iptables other port", "[5:300] -A INPUT -p tcp -m tcp --dport 22 -j DROP", nil),
+		Entry("nft drop in set", "\t\ttcp dport { 80, 8881 } counter packets 7 bytes 420 drop",
+			[]string{"tcp dport { 80, 8881 } counter packets 7 bytes 420 drop"}),
+		Entry("nft reject in range", "tcp dport 12000-13000 counter packets 1 bytes 60 reject",
+			[]string{"tcp dport 12000-13000 counter packets 1 bytes 60 reject"}),
+		Entry("nft without counter", "tcp dport 12996 drop", nil),
+		Entry("nft accept", "tcp dport 12996 counter packets 3 bytes 180 accept", nil),
+	)
+
+	DescribeTable("classifies the failures caused by blocks",
+		func(result string, fields map[string]string, expected bool) {
+			Expect(isBlockLikeFailure(&nwpd.Observation{Result: result, ResultFields: fields})).To(Equal(expected))
+		},
+		Entry("refused", "error: dial tcp 10.0.0.2:12996: connect: connection refused", nil, true),
+		Entry("connect timeout", "error: dial tcp 10.0.0.2:12996: i/o timeout: state=notConnected failure=connectTimeout",
+			map[string]string{runners.ResultFieldFailureCategory: runners.FailureCategoryConnectTimeout}, true),
+		Entry("read timeout of a connected service", "error: no response: state=connected failure=readTimeout",
+			map[string]string{runners.ResultFieldFailureCategory: runners.FailureCategoryReadTimeout}, false),
+		Entry("other failure", "error: unexpected status: status=503", nil, false),
+	)
+
+	It("finds listening sockets in the socket table", func() {
+		table := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:32C4 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:22B1 0100007F:32C4 01 00000000:00000000 00:00000000 00000000     0        0 12346 1 0000000000000000 20 4 30 10 -1
+`
+		Expect(hasListeningSocket(strings.NewReader(table), 12996)).To(BeTrue())
+		Expect(hasListeningSocket(strings.NewReader(table), 8881)).To(BeFalse())
+	})
+})
@@ -5,92 +5,527 @@
 package agent
 
 import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/gardener/network-problem-detector/pkg/agent/aggregation"
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
 	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// reloadResultSuccess and reloadResultFailure are the values of the label `result` of the configuration reloads.
+	reloadResultSuccess = "success"
+	reloadResultFailure = "failure"
+)
+
 func init() {
-	prometheus.MustRegister(AggregatedObservations)
-	prometheus.MustRegister(AggregatedObservationsLatency)
+	prometheus.MustRegister(aggregatedObservationsCollector{})
+	prometheus.MustRegister(RunningJobs)
+	prometheus.MustRegister(InFlightProbes)
+	prometheus.MustRegister(PeerHeartbeats)
+	prometheus.MustRegister(ObservationBufferFull)
+	prometheus.MustRegister(DroppedObservations)
+	prometheus.MustRegister(ObservationQueueLength)
+	prometheus.MustRegister(FailedObservationsByCategory)
+	prometheus.MustRegister(ObservationQueueCapacity)
+	prometheus.MustRegister(RemoteWriteFailures)
+	prometheus.MustRegister(DroppedSpans)
+	prometheus.MustRegister(RemoteSinkSentObservations)
+	prometheus.MustRegister(RemoteSinkDroppedObservations)
+	prometheus.MustRegister(RemoteSinkFailures)
+	prometheus.MustRegister(UnauthorizedRequests)
+	prometheus.MustRegister(WatchDroppedObservations)
+	prometheus.MustRegister(HTTPListenFailures)
+	prometheus.MustRegister(ConfigReloads)
+	prometheus.MustRegister(ConfigLastReload)
+	prometheus.MustRegister(ConfigRecheckReloads)
+	prometheus.MustRegister(ConfigRejections)
+	prometheus.MustRegister(EdgeDown)
+	prometheus.MustRegister(EdgeIncidents)
+	prometheus.MustRegister(ZoneEdgeFailures)
+	prometheus.MustRegister(ZoneEdgeFailureRatio)
+	prometheus.MustRegister(BackedOffDestinations)
+	prometheus.MustRegister(ObservationGaps)
+	prometheus.MustRegister(LocalBlockSuspected)
+	prometheus.MustRegister(ObservationStoreBytes)
+	db.SetDiskUsageGauge(ObservationStoreBytes)
+	prometheus.MustRegister(WriterBufferedRecords)
+	prometheus.MustRegister(WriterFlushedRecords)
+	prometheus.MustRegister(WriterDroppedRecords)
+	db.SetWriteCounters(db.WriteCounters{Buffered: WriterBufferedRecords, Flushed: WriterFlushedRecords, Dropped: WriterDroppedRecords})
+	prometheus.MustRegister(RunnerPanics)
 }
 
 var (
-	AggregatedObservations = prometheus.NewCounterVec(
+	AggregatedObservations        = newAggregatedObservations(nil)
+	AggregatedObservationsLatency = newAggregatedObservationsLatency(nil)
+	// AggregatedObservationsDuration is a histogram of the observation durations, so that percentiles can be queried.
+	AggregatedObservationsDuration = newAggregatedObservationsDuration(nil)
+	RunningJobs                    = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_running_jobs",
+			Help: "Number of currently running jobs",
+		},
+	)
+
+	InFlightProbes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_inflight_probes",
+			Help: "Number of currently running probes of all jobs",
+		},
+	)
+	ObservationBufferFull = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_observation_buffer_full_total",
+			Help: "Total count of observations which could not be buffered immediately",
+		},
+	)
+	DroppedObservations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nwpd_dropped_observations_total",
+			Help: "Total count of observations dropped because of a full observation buffer",
+		},
+		[]string{"jobid", "reason"},
+	)
+	// FailedObservationsByCategory counts the failed observations of the runners reporting a failure category, so that
+	// e.g. connect timeouts caused by dropped packets can be told apart from read timeouts of hung services.
+	FailedObservationsByCategory = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nwpd_failed_observations_by_category_total",
+			Help: "Total count of failed observations by failure category, e.g. connectTimeout or readTimeout",
+		},
+		[]string{"jobid", "category"},
+	)
+	// ObservationQueueLength is the number of observations in the buffer waiting for processing, so that a saturation is visible
+	// before observations are dropped.
+	ObservationQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_observation_queue_length",
+			Help: "Number of buffered observations waiting for processing",
+		},
+	)
+	// ObservationQueueCapacity is the size of the observation buffer.
+	ObservationQueueCapacity = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_observation_queue_capacity",
+			Help: "Size of the observation buffer",
+		},
+	)
+	RemoteWriteFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_remote_write_failures_total",
+			Help: "Total count of failed remote write pushes of the aggregated observation metrics",
+		},
+	)
+	DroppedSpans = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_dropped_spans_total",
+			Help: "Total count of probe trace spans dropped because the export queue was full or the export failed",
+		},
+	)
+	UnauthorizedRequests = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_agent_service_unauthorized_requests_total",
+			Help: "Total count of agent service requests rejected because of a missing or invalid API token",
+		},
+	)
+	WatchDroppedObservations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_watch_dropped_observations_total",
+			Help: "Total count of observations dropped for the live observation feed because a subscriber could not keep up",
+		},
+	)
+	ConfigReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nwpd_config_reloads_total",
+			Help: "Total count of reloads of the configuration files by result",
+		},
+		[]string{"result"},
+	)
+	ConfigLastReload = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_config_last_reload_timestamp_seconds",
+			Help: "Time of the last successful reload of the configuration files",
+		},
+	)
+	ConfigRecheckReloads = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_config_recheck_reloads_total",
+			Help: "Total count of reloads triggered by the periodic recheck of the configuration files, i.e. of missed file events",
+		},
+	)
+	ConfigRejections = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_config_rejections_total",
+			Help: "Total count of agent configurations rejected because they could not be applied, the previous revision is kept",
+		},
+	)
+	HTTPListenFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_http_listen_failures_total",
+			Help: "Total count of failed attempts to listen on the address of the http server",
+		},
+	)
+	RemoteSinkSentObservations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_remote_sink_sent_observations_total",
+			Help: "Total count of observations sent to the remote sink",
+		},
+	)
+	RemoteSinkDroppedObservations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_remote_sink_dropped_observations_total",
+			Help: "Total count of observations dropped because the remote sink queue was full or sending failed",
+		},
+	)
+	RemoteSinkFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_remote_sink_failures_total",
+			Help: "Total count of failed requests to the remote sink including retried ones",
+		},
+	)
+	// EdgeDown has a series with value 1 for each edge with an open incident.
+	EdgeDown = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nwpd_edge_down",
+			Help: "Edges with an open incident after consecutive failures",
+		},
+		[]string{"src", "dest", "jobid"},
+	)
+	EdgeIncidents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nwpd_edge_incidents_total",
+			Help: "Total count of closed incidents of edges",
+		},
+		[]string{"jobid"},
+	)
+	// ZoneEdgeFailures is the number of failed checks between the nodes of two zones in the last report period.
+	ZoneEdgeFailures = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nwpd_zone_edge_failures",
+			Help: "Number of failed checks between the nodes of two zones in the last aggregation report period",
+		},
+		[]string{"src_zone", "dest_zone"},
+	)
+	// ZoneEdgeFailureRatio is the share of failed checks between the nodes of two zones in the last report period.
+	ZoneEdgeFailureRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nwpd_zone_edge_failure_ratio",
+			Help: "Share of failed checks between the nodes of two zones in the last aggregation report period",
+		},
+		[]string{"src_zone", "dest_zone"},
+	)
+	// BackedOffDestinations is the number of destinations of a job skipped by the failure backoff after consecutive failures.
+	BackedOffDestinations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nwpd_backed_off_destinations",
+			Help: "Number of destinations in failure backoff after consecutive failures",
+		},
+		[]string{"jobid"},
+	)
+	// RunnerPanics counts the runs aborted by a panic of the runner, which are reported as failed observation instead of
+	// crashing the agent.
+	RunnerPanics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nwpd_runner_panics_total",
+			Help: "Total count of job runs aborted by a panic of the runner",
+		},
+		[]string{"jobid"},
+	)
+	// ObservationGaps counts the gaps between consecutive observations of an edge exceeding a multiple of the job period.
+	ObservationGaps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nwpd_observation_gaps_total",
+			Help: "Total count of gaps between consecutive observations of an edge by classification",
+		},
+		[]string{"jobid", "category"},
+	)
+	// ObservationStoreBytes is the total size of the observation record files.
+	ObservationStoreBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_observation_store_bytes",
+			Help: "Total size of the observation record files in bytes",
+		},
+	)
+	// WriterBufferedRecords counts the observations and job run records batched by the observation writer.
+	WriterBufferedRecords = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_writer_buffered_records_total",
+			Help: "Total count of records batched for writing to the observation record files",
+		},
+	)
+	// WriterFlushedRecords counts the batched records written to the record files.
+	WriterFlushedRecords = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_writer_flushed_records_total",
+			Help: "Total count of batched records written to the observation record files",
+		},
+	)
+	// WriterDroppedRecords counts the batched records which could not be written to the record files.
+	WriterDroppedRecords = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nwpd_writer_dropped_records_total",
+			Help: "Total count of batched records which could not be written to the observation record files",
+		},
+	)
+	// LocalBlockSuspected is 1 if the last local diagnosis found the ports of the agent blocked locally.
+	LocalBlockSuspected = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nwpd_local_block_suspected",
+			Help: "1 if the last local diagnosis suspects the ports of the agent to be blocked on the node, 0 otherwise",
+		},
+	)
+	// PeerHeartbeats tracks the heartbeats received from the peer agents.
+	PeerHeartbeats = newHeartbeatTracker()
+
+	// metricsLock protects the metric vectors, the additional job label names, and the switch of the duration histogram.
+	metricsLock              sync.RWMutex
+	metricLabelNames         []string
+	durationHistogramEnabled bool
+
+	validMetricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	reservedLabelNames   = common.StringSet{"src": {}, "dest": {}, "jobid": {}, "status": {}}
+)
+
+func newAggregatedObservations(labelNames []string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "nwpd_aggregated_observations",
 			Help: "Total counts of observations",
 		},
-		[]string{"src", "dest", "jobid", "status"},
+		append([]string{"src", "dest", "jobid", "status"}, labelNames...),
+	)
+}
+
+// newAggregatedObservationsDuration creates the duration histogram. It is exposed as native histogram
+// (bucket factor 1.1, at most 100 buckets per series) and with classic buckets for scrapers without native histogram support.
+func newAggregatedObservationsDuration(labelNames []string) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:                            "nwpd_aggregated_observations_duration_seconds",
+			Help:                            "Histogram of the observation durations in seconds",
+			Buckets:                         []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			NativeHistogramBucketFactor:     1.1,
+			NativeHistogramMaxBucketNumber:  100,
+			NativeHistogramMinResetDuration: 1 * time.Hour,
+		},
+		append([]string{"src", "dest", "jobid"}, labelNames...),
 	)
-	AggregatedObservationsLatency = prometheus.NewGaugeVec(
+}
+
+func newAggregatedObservationsLatency(labelNames []string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "nwpd_aggregated_observations_latency_secs",
 			Help: "Observation duration in seconds",
 		},
-		[]string{"src", "dest", "jobid"},
+		append([]string{"src", "dest", "jobid"}, labelNames...),
 	)
-)
+}
+
+// aggregatedObservationsCollector collects the current metric vectors.
+// It is an unchecked collector, as the label names of the vectors change with the allowlist of job labels.
+type aggregatedObservationsCollector struct{}
+
+var _ prometheus.Collector = aggregatedObservationsCollector{}
+
+func (c aggregatedObservationsCollector) Describe(_ chan<- *prometheus.Desc) {}
+
+func (c aggregatedObservationsCollector) Collect(ch chan<- prometheus.Metric) {
+	metricsLock.RLock()
+	defer metricsLock.RUnlock()
+	AggregatedObservations.Collect(ch)
+	AggregatedObservationsLatency.Collect(ch)
+	AggregatedObservationsDuration.Collect(ch)
+}
+
+// metricLabelNamesOf validates the allowlist of job label names and returns it sorted without duplicates.
+func metricLabelNamesOf(names []string) ([]string, error) {
+	set := common.StringSet{}
+	for _, name := range names {
+		if !validMetricLabelName.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid metric label name %q", name)
+		}
+		if reservedLabelNames.Contains(name) {
+			return nil, fmt.Errorf("reserved metric label name %q", name)
+		}
+		set.Add(name)
+	}
+	return set.ToSortedArray(), nil
+}
+
+// configureMetricLabels sets the allowlist of job label names exposed as additional metric labels.
+// If the allowlist changes, the metric vectors are replaced and all existing series are dropped.
+func configureMetricLabels(names []string) error {
+	sorted, err := metricLabelNamesOf(names)
+	if err != nil {
+		return err
+	}
+
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	if reflect.DeepEqual(sorted, metricLabelNames) || len(sorted) == 0 && len(metricLabelNames) == 0 {
+		return nil
+	}
+	AggregatedObservations = newAggregatedObservations(sorted)
+	AggregatedObservationsLatency = newAggregatedObservationsLatency(sorted)
+	AggregatedObservationsDuration = newAggregatedObservationsDuration(sorted)
+	metricLabelNames = sorted
+	metricKeys.clear()
+	return nil
+}
+
+// configureDurationHistogram enables or disables the duration histogram. If disabled, all its series are dropped.
+func configureDurationHistogram(enabled bool) {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	if !enabled {
+		AggregatedObservationsDuration.Reset()
+	}
+	durationHistogramEnabled = enabled
+}
 
 type observationKey struct {
 	src   string
 	dest  string
 	jobid string
+	// labels are the values of the additional metric labels joined to keep the key comparable
+	labels string
 }
 
 type observationKeys struct {
 	lock sync.Mutex
-	keys map[observationKey]struct{}
+	keys map[observationKey][]string
 }
 
 var metricKeys = observationKeys{
-	keys: map[observationKey]struct{}{},
+	keys: map[observationKey][]string{},
 }
 
-func (k *observationKeys) add(src, dest, jobid string) {
+// labelValues returns the values of the additional metric labels for the given job labels.
+// The caller must hold the metricsLock.
+func labelValues(labels map[string]string) []string {
+	if len(metricLabelNames) == 0 {
+		return nil
+	}
+	values := make([]string, len(metricLabelNames))
+	for i, name := range metricLabelNames {
+		values[i] = labels[name]
+	}
+	return values
+}
+
+func (k *observationKeys) add(src, dest, jobid string, values []string) {
 	k.lock.Lock()
 	defer k.lock.Unlock()
 	key := observationKey{
-		src:   src,
-		dest:  dest,
-		jobid: jobid,
+		src:    src,
+		dest:   dest,
+		jobid:  jobid,
+		labels: strings.Join(values, "\x00"),
 	}
 	if _, ok := k.keys[key]; !ok {
-		k.keys[key] = struct{}{}
+		k.keys[key] = values
 	}
 }
 
-func (k *observationKeys) remove(isObsolete func(key observationKey) bool) []observationKey {
+func (k *observationKeys) remove(isObsolete func(key observationKey) bool) map[observationKey][]string {
 	k.lock.Lock()
 	defer k.lock.Unlock()
 
-	var keys []observationKey
-	for key := range k.keys {
+	keys := map[observationKey][]string{}
+	for key, values := range k.keys {
 		if isObsolete(key) {
-			keys = append(keys, key)
+			keys[key] = values
 			delete(k.keys, key)
 		}
 	}
 	return keys
 }
 
-func IncAggregatedObservation(src, dest, jobid string, ok bool) {
-	status := "ok"
-	if !ok {
-		status = "failed"
+func (k *observationKeys) clear() {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.keys = map[observationKey][]string{}
+}
+
+// incidentMetrics exports the open incidents of the aggregator as metrics.
+type incidentMetrics struct{}
+
+var _ aggregation.IncidentObserver = incidentMetrics{}
+
+func (incidentMetrics) IncidentOpened(inc *nwpd.Incident) {
+	EdgeDown.WithLabelValues(inc.SrcHost, inc.DestHost, inc.JobID).Set(1)
+}
+
+func (incidentMetrics) IncidentClosed(inc *nwpd.Incident) {
+	EdgeDown.DeleteLabelValues(inc.SrcHost, inc.DestHost, inc.JobID)
+	EdgeIncidents.WithLabelValues(inc.JobID).Inc()
+}
+
+// zoneMetrics exports the zone edges of the aggregation reports as metrics.
+type zoneMetrics struct{}
+
+var _ aggregation.ZoneObserver = zoneMetrics{}
+
+func (zoneMetrics) ZoneEdgesReported(reports []aggregation.ZoneEdgeReport) {
+	// the zone pairs without checks in the report period are removed
+	ZoneEdgeFailures.Reset()
+	ZoneEdgeFailureRatio.Reset()
+	for _, r := range reports {
+		ZoneEdgeFailures.WithLabelValues(r.SrcZone, r.DestZone).Set(float64(r.Failures))
+		ZoneEdgeFailureRatio.WithLabelValues(r.SrcZone, r.DestZone).Set(r.FailureRatio())
+	}
+}
+
+// observationStatus returns the status label value of the observation.
+func observationStatus(obs *nwpd.Observation) string {
+	switch {
+	case obs.Ok:
+		return "ok"
+	case obs.StaleEndpoint:
+		return "stale"
+	default:
+		return "failed"
 	}
-	metricKeys.add(src, dest, jobid)
-	AggregatedObservations.WithLabelValues(src, dest, jobid, status).Inc()
 }
 
-func ReportAggregatedObservationLatency(src, dest, jobid string, seconds float64) {
-	AggregatedObservationsLatency.WithLabelValues(src, dest, jobid).Set(seconds)
+func IncAggregatedObservation(src, dest, jobid string, labels map[string]string, status string) {
+	metricsLock.RLock()
+	defer metricsLock.RUnlock()
+	values := labelValues(labels)
+	metricKeys.add(src, dest, jobid, values)
+	AggregatedObservations.WithLabelValues(append([]string{src, dest, jobid, status}, values...)...).Inc()
+}
+
+func ReportAggregatedObservationLatency(src, dest, jobid string, labels map[string]string, seconds float64) {
+	metricsLock.RLock()
+	defer metricsLock.RUnlock()
+	values := append([]string{src, dest, jobid}, labelValues(labels)...)
+	AggregatedObservationsLatency.WithLabelValues(values...).Set(seconds)
+	if durationHistogramEnabled {
+		AggregatedObservationsDuration.WithLabelValues(values...).Observe(seconds)
+	}
 }
 
 func deleteOutdatedMetricByObsoleteJobIDs(jobIDs []string) {
+	for _, id := range jobIDs {
+		BackedOffDestinations.DeleteLabelValues(id)
+	}
 	if len(jobIDs) > 0 {
+		metricsLock.RLock()
+		defer metricsLock.RUnlock()
 		keys := metricKeys.remove(func(key observationKey) bool {
 			for _, id := range jobIDs {
 				if key.jobid == id {
@@ -104,16 +539,21 @@ func deleteOutdatedMetricByObsoleteJobIDs(jobIDs []string) {
 }
 
 func deleteOutdatedMetricByValidDestHosts(validDestHosts common.StringSet) {
+	metricsLock.RLock()
+	defer metricsLock.RUnlock()
 	keys := metricKeys.remove(func(key observationKey) bool {
 		return !validDestHosts.Contains(key.src) || !validDestHosts.Contains(key.dest)
 	})
 	deleteOutdatedMetricsByKeys(keys)
 }
 
-func deleteOutdatedMetricsByKeys(keys []observationKey) {
-	for _, key := range keys {
-		AggregatedObservations.DeleteLabelValues(key.src, key.dest, key.jobid, "ok")
-		AggregatedObservations.DeleteLabelValues(key.src, key.dest, key.jobid, "failed")
-		AggregatedObservationsLatency.DeleteLabelValues(key.src, key.dest, key.jobid)
+// deleteOutdatedMetricsByKeys deletes the series of the given keys. The caller must hold the metricsLock.
+func deleteOutdatedMetricsByKeys(keys map[observationKey][]string) {
+	for key, values := range keys {
+		AggregatedObservations.DeleteLabelValues(append([]string{key.src, key.dest, key.jobid, "ok"}, values...)...)
+		AggregatedObservations.DeleteLabelValues(append([]string{key.src, key.dest, key.jobid, "failed"}, values...)...)
+		AggregatedObservations.DeleteLabelValues(append([]string{key.src, key.dest, key.jobid, "stale"}, values...)...)
+		AggregatedObservationsLatency.DeleteLabelValues(append([]string{key.src, key.dest, key.jobid}, values...)...)
+		AggregatedObservationsDuration.DeleteLabelValues(append([]string{key.src, key.dest, key.jobid}, values...)...)
 	}
 }
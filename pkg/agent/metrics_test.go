@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/agent/aggregation"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("metrics", func() {
+	AfterEach(func() {
+		Expect(configureMetricLabels(nil)).To(Succeed())
+	})
+
+	It("adds allowlisted job labels", func() {
+		Expect(configureMetricLabels([]string{"port", "pool"})).To(Succeed())
+		IncAggregatedObservation("node1", "node2", "tcp-kubelet", map[string]string{"port": "10250", "team": "x"}, "ok")
+		IncAggregatedObservation("node1", "node2", "tcp-https", map[string]string{"port": "443"}, "failed")
+
+		Expect(testutil.ToFloat64(AggregatedObservations.WithLabelValues("node1", "node2", "tcp-kubelet", "ok", "", "10250"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(AggregatedObservations.WithLabelValues("node1", "node2", "tcp-https", "failed", "", "443"))).To(Equal(1.0))
+		Expect(testutil.CollectAndCount(aggregatedObservationsCollector{}, "nwpd_aggregated_observations")).To(Equal(2))
+	})
+
+	It("deletes labeled series of obsolete jobs", func() {
+		Expect(configureMetricLabels([]string{"port"})).To(Succeed())
+		configureDurationHistogram(true)
+		defer configureDurationHistogram(false)
+		IncAggregatedObservation("node1", "node2", "tcp-kubelet", map[string]string{"port": "10250"}, "ok")
+		ReportAggregatedObservationLatency("node1", "node2", "tcp-kubelet", map[string]string{"port": "10250"}, 0.1)
+		IncAggregatedObservation("node1", "node2", "tcp-https", map[string]string{"port": "443"}, "stale")
+		Expect(testutil.CollectAndCount(AggregatedObservations)).To(Equal(2))
+		Expect(testutil.CollectAndCount(AggregatedObservationsLatency)).To(Equal(1))
+		Expect(testutil.CollectAndCount(AggregatedObservationsDuration)).To(Equal(1))
+
+		deleteOutdatedMetricByObsoleteJobIDs([]string{"tcp-kubelet"})
+		Expect(testutil.CollectAndCount(AggregatedObservations)).To(Equal(1))
+		Expect(testutil.CollectAndCount(AggregatedObservationsLatency)).To(Equal(0))
+		Expect(testutil.CollectAndCount(AggregatedObservationsDuration)).To(Equal(0))
+
+		deleteOutdatedMetricByValidDestHosts(common.StringSet{})
+		Expect(testutil.CollectAndCount(AggregatedObservations)).To(Equal(0))
+	})
+
+	It("observes the durations in the histogram only if enabled", func() {
+		ReportAggregatedObservationLatency("node1", "node2", "tcp-kubelet", nil, 0.1)
+		Expect(testutil.CollectAndCount(AggregatedObservationsLatency)).To(Equal(1))
+		Expect(testutil.CollectAndCount(AggregatedObservationsDuration)).To(Equal(0))
+
+		configureDurationHistogram(true)
+		ReportAggregatedObservationLatency("node1", "node2", "tcp-kubelet", nil, 0.1)
+		Expect(testutil.CollectAndCount(AggregatedObservationsDuration)).To(Equal(1))
+
+		configureDurationHistogram(false)
+		Expect(testutil.CollectAndCount(AggregatedObservationsDuration)).To(Equal(0))
+		deleteOutdatedMetricByObsoleteJobIDs([]string{"tcp-kubelet"})
+	})
+
+	It("exports open incidents as down edges", func() {
+		inc := &nwpd.Incident{IncidentID: "01H", JobID: "tcp-n2n", SrcHost: "node1", DestHost: "node2"}
+		incidents := testutil.ToFloat64(EdgeIncidents.WithLabelValues("tcp-n2n"))
+		incidentMetrics{}.IncidentOpened(inc)
+		Expect(testutil.ToFloat64(EdgeDown.WithLabelValues("node1", "node2", "tcp-n2n"))).To(Equal(1.0))
+
+		incidentMetrics{}.IncidentClosed(inc)
+		Expect(testutil.CollectAndCount(EdgeDown)).To(Equal(0))
+		Expect(testutil.ToFloat64(EdgeIncidents.WithLabelValues("tcp-n2n"))).To(Equal(incidents + 1))
+	})
+
+	It("exports the zone edges of the last report", func() {
+		zoneMetrics{}.ZoneEdgesReported([]aggregation.ZoneEdgeReport{
+			{SrcZone: "zone-a", DestZone: "zone-b", Checks: 10, Failures: 2},
+			{SrcZone: "zone-a", DestZone: "unknown", Checks: 5},
+		})
+		Expect(testutil.ToFloat64(ZoneEdgeFailures.WithLabelValues("zone-a", "zone-b"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(ZoneEdgeFailureRatio.WithLabelValues("zone-a", "zone-b"))).To(Equal(0.2))
+		Expect(testutil.ToFloat64(ZoneEdgeFailures.WithLabelValues("zone-a", "unknown"))).To(Equal(0.0))
+
+		zoneMetrics{}.ZoneEdgesReported([]aggregation.ZoneEdgeReport{{SrcZone: "zone-a", DestZone: "zone-b", Checks: 10}})
+		Expect(testutil.CollectAndCount(ZoneEdgeFailures)).To(Equal(1))
+		Expect(testutil.CollectAndCount(ZoneEdgeFailureRatio)).To(Equal(1))
+	})
+
+	It("counts the failed observations by category", func() {
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger())}
+		failed := func(category string) *nwpd.Observation {
+			obs := &nwpd.Observation{JobID: "tcp-category", SrcHost: "node1", DestHost: "node2", Result: "error: failed"}
+			if category != "" {
+				obs.ResultFields = map[string]string{runners.ResultFieldFailureCategory: category}
+			}
+			return obs
+		}
+		s.processObservation(failed(runners.FailureCategoryReadTimeout))
+		s.processObservation(failed(runners.FailureCategoryReadTimeout))
+		s.processObservation(failed(""))
+		s.processObservation(&nwpd.Observation{JobID: "tcp-category", SrcHost: "node1", DestHost: "node2", Ok: true})
+
+		Expect(testutil.ToFloat64(FailedObservationsByCategory.WithLabelValues("tcp-category", runners.FailureCategoryReadTimeout))).To(Equal(2.0))
+	})
+
+	It("rejects invalid and reserved label names", func() {
+		Expect(configureMetricLabels([]string{"a-b"})).To(MatchError(ContainSubstring("invalid metric label name")))
+		Expect(configureMetricLabels([]string{"jobid"})).To(MatchError(ContainSubstring("reserved metric label name")))
+	})
+})
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+const (
+	// ExitCodeChecksFailed is the exit code of the once mode if any observation has failed.
+	ExitCodeChecksFailed = 1
+	// ExitCodeError is the exit code of the once mode if the configuration is invalid or a job could not be run to completion.
+	ExitCodeError = 2
+)
+
+// onceEdge identifies the observations of a job between two hosts.
+type onceEdge struct {
+	jobID    string
+	srcHost  string
+	destHost string
+}
+
+// onceEdgeResult counts the observations of an edge.
+type onceEdgeResult struct {
+	observations int
+	failed       int
+	lastFailure  string
+}
+
+// onceResult summarizes the observations of running all jobs a single time.
+type onceResult struct {
+	observations int
+	failed       int
+	edges        map[onceEdge]*onceEdgeResult
+	// timedOut are the jobs cancelled because their run has not finished within the job period
+	timedOut []string
+	// notRun are the jobs which could not be started
+	notRun []string
+}
+
+func newOnceResult() *onceResult {
+	return &onceResult{edges: map[onceEdge]*onceEdgeResult{}}
+}
+
+func (r *onceResult) add(obs *nwpd.Observation) {
+	r.observations++
+	edge := onceEdge{jobID: obs.JobID, srcHost: obs.SrcHost, destHost: obs.DestHost}
+	er := r.edges[edge]
+	if er == nil {
+		er = &onceEdgeResult{}
+		r.edges[edge] = er
+	}
+	er.observations++
+	if !obs.Ok {
+		r.failed++
+		er.failed++
+		er.lastFailure = obs.Result
+	}
+}
+
+// err returns an error with the exit code if a job has not run to completion or any observation has failed.
+func (r *onceResult) err() error {
+	switch {
+	case len(r.timedOut) > 0 || len(r.notRun) > 0:
+		return &common.ExitError{Code: ExitCodeError, Err: fmt.Errorf("%d of %d observations failed, jobs not completed: %s",
+			r.failed, r.observations, strings.Join(append(append([]string{}, r.notRun...), r.timedOut...), ", "))}
+	case r.failed > 0:
+		return &common.ExitError{Code: ExitCodeChecksFailed, Err: fmt.Errorf("%d of %d observations failed", r.failed, r.observations)}
+	default:
+		return nil
+	}
+}
+
+// printSummary prints the failed observations grouped by job ID and edge and the jobs not run to completion.
+func (r *onceResult) printSummary(out io.Writer) error {
+	failing := make([]onceEdge, 0, len(r.edges))
+	for edge, er := range r.edges {
+		if er.failed > 0 {
+			failing = append(failing, edge)
+		}
+	}
+	sort.Slice(failing, func(i, j int) bool {
+		a, b := failing[i], failing[j]
+		if a.jobID != b.jobID {
+			return a.jobID < b.jobID
+		}
+		if a.srcHost != b.srcHost {
+			return a.srcHost < b.srcHost
+		}
+		return a.destHost < b.destHost
+	})
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "summary: %d observations, %d failed\n", r.observations, r.failed)
+	if len(failing) > 0 {
+		fmt.Fprintln(w, "JOBID\tSRC\tDEST\tFAILED\tLAST FAILURE")
+	}
+	for _, edge := range failing {
+		er := r.edges[edge]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\n", edge.jobID, edge.srcHost, edge.destHost, er.failed, er.observations, er.lastFailure)
+	}
+	for _, jobID := range r.notRun {
+		fmt.Fprintf(w, "job %s could not be run\n", jobID)
+	}
+	for _, jobID := range r.timedOut {
+		fmt.Fprintf(w, "job %s timed out\n", jobID)
+	}
+	return w.Flush()
+}
+
+// runOnce runs each job a single time instead of the main loop, prints the observations and the summary to out, and
+// shuts down. A run not finished within the period of its job is cancelled.
+func (s *server) runOnce(out io.Writer) (*onceResult, error) {
+	s.lock.Lock()
+	jobs := make([]*runners.InternalJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	limiter := s.limiter
+	s.lock.Unlock()
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].JobID() < jobs[j].JobID()
+	})
+
+	s.startWriter()
+	result := newOnceResult()
+	var writeErr error
+	process := func(obs *nwpd.Observation) {
+		s.processObservation(obs)
+		result.add(obs)
+		if _, err := fmt.Fprintln(out, nwpd.FormatObservation(obs)); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	poll := time.NewTicker(drainPollPeriod)
+	defer poll.Stop()
+	pending := jobs
+	for len(pending) > 0 || len(s.obsChan) > 0 {
+		select {
+		case obs := <-s.obsChan:
+			process(obs)
+		case record := <-s.runChan:
+			s.processJobRun(record)
+		case <-poll.C:
+			pending = s.tickOnce(pending, limiter, result, time.Now())
+		}
+	}
+	// the observations of the runs that have just finished
+	for len(s.obsChan) > 0 {
+		process(<-s.obsChan)
+	}
+	s.shutdown(drainTimeout)
+	if writeErr == nil {
+		writeErr = result.printSummary(out)
+	}
+	return result, writeErr
+}
+
+// tickOnce starts the pending jobs which have not run yet and returns the jobs still pending.
+func (s *server) tickOnce(pending []*runners.InternalJob, limiter *runners.Limiter, result *onceResult, now time.Time) []*runners.InternalJob {
+	var remaining []*runners.InternalJob
+	for _, job := range pending {
+		lastRun := job.GetLastRun()
+		switch {
+		case lastRun == nil:
+			if err := job.Tick(s.nodeName, s.obsChan, limiter); err != nil {
+				s.log.Warnf("cannot run job %s: %s", job.JobID(), err)
+				result.notRun = append(result.notRun, job.JobID())
+				continue
+			}
+		case !job.Running():
+			continue
+		case now.Sub(*lastRun) > job.Period():
+			s.log.Warnf("job %s has not finished within its period %s, cancelling it", job.JobID(), job.Period())
+			job.Cancel()
+			result.timedOut = append(result.timedOut, job.JobID())
+			continue
+		}
+		remaining = append(remaining, job)
+	}
+	return remaining
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// resultRunner reports an observation per destination with the given result.
+type resultRunner struct {
+	blockingRunner
+	destHosts []string
+	ok        bool
+}
+
+func (r *resultRunner) Run(nodeName string, ch chan<- *nwpd.Observation) {
+	for _, dest := range r.destHosts {
+		obs := &nwpd.Observation{JobID: r.config.JobID, SrcHost: nodeName, DestHost: dest, Timestamp: timestamppb.Now(), Ok: r.ok}
+		if !r.ok {
+			obs.Result = "connection refused"
+		}
+		ch <- obs
+	}
+}
+
+func exitCodeOf(err error) int {
+	var exitErr *common.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 0
+}
+
+var _ = Describe("once", func() {
+	var s *server
+
+	newJob := func(jobID string, ok bool, destHosts ...string) *runners.InternalJob {
+		return runners.NewInternalJob(&resultRunner{
+			blockingRunner: blockingRunner{config: runners.RunnerConfig{Job: config.Job{JobID: jobID}, Period: time.Minute}},
+			destHosts:      destHosts,
+			ok:             ok,
+		}, 0)
+	}
+
+	BeforeEach(func() {
+		s = &server{
+			log:      logrus.NewEntry(logrus.StandardLogger()),
+			nodeName: "node-a",
+			jobs:     map[jobid]*runners.InternalJob{},
+			obsChan:  make(chan *nwpd.Observation, 2),
+			limiter:  runners.NewLimiter(1, nil),
+		}
+	})
+
+	It("runs each job a single time and prints the observations", func() {
+		s.jobs["ping"] = newJob("ping", true, "node-b", "node-c", "node-d")
+		s.jobs["tcp"] = newJob("tcp", false, "node-b")
+		writer, err := db.NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), GinkgoT().TempDir(), "test", 24, false)
+		Expect(err).To(BeNil())
+		s.writer = writer
+		out := &bytes.Buffer{}
+		result, err := s.runOnce(out)
+		Expect(err).To(BeNil())
+		Expect(result.observations).To(Equal(4))
+		Expect(result.failed).To(Equal(1))
+		Expect(result.err()).To(MatchError("1 of 4 observations failed"))
+		Expect(exitCodeOf(result.err())).To(Equal(ExitCodeChecksFailed))
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).To(HaveLen(7))
+		Expect(lines[:4]).To(ContainElement(ContainSubstring("src=node-a dest=node-b jobid=tcp status=failed")))
+		Expect(lines[4:]).To(Equal([]string{
+			"summary: 4 observations, 1 failed",
+			"JOBID  SRC     DEST    FAILED  LAST FAILURE",
+			"tcp    node-a  node-b  1/1     connection refused",
+		}))
+		for _, job := range s.jobs {
+			Expect(job.Cancelled()).To(BeTrue())
+		}
+		Expect(s.writer).To(BeNil())
+		written, err := writer.ListObservations(nwpd.ListObservationsOptions{Start: time.Now().Add(-time.Minute)})
+		Expect(err).To(BeNil())
+		Expect(written).To(HaveLen(4))
+	})
+
+	It("succeeds if all observations are ok", func() {
+		s.jobs["ping"] = newJob("ping", true, "node-b")
+		result, err := s.runOnce(&bytes.Buffer{})
+		Expect(err).To(BeNil())
+		Expect(result.err()).To(BeNil())
+	})
+
+	It("cancels a run not finished within its period", func() {
+		// the blocked run is not cancelable and keeps its slot of the limiter
+		s.limiter = runners.NewLimiter(2, nil)
+		release := make(chan struct{})
+		defer close(release)
+		s.jobs["stuck"] = runners.NewInternalJob(&blockingRunner{
+			config:  runners.RunnerConfig{Job: config.Job{JobID: "stuck"}, Period: 100 * time.Millisecond},
+			started: make(chan string, 1),
+			release: release,
+		}, 0)
+		s.jobs["ping"] = newJob("ping", true, "node-b")
+		out := &bytes.Buffer{}
+		result, err := s.runOnce(out)
+		Expect(err).To(BeNil())
+		Expect(result.timedOut).To(Equal([]string{"stuck"}))
+		Expect(result.err()).To(MatchError("0 of 1 observations failed, jobs not completed: stuck"))
+		Expect(exitCodeOf(result.err())).To(Equal(ExitCodeError))
+		Expect(out.String()).To(HaveSuffix("summary: 1 observations, 0 failed\njob stuck timed out\n"))
+	})
+})
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// packetTrainRetention is the time a received packet train is kept for the report to its sender.
+	packetTrainRetention = 1 * time.Minute
+	// maxPacketTrains is the maximum number of packet trains kept at the same time.
+	maxPacketTrains = 64
+)
+
+type receivedPacketTrain struct {
+	sender string
+	first  time.Time
+	last   time.Time
+	seen   map[uint32]struct{}
+	report common.PacketTrainReport
+}
+
+// packetTrainReceiver receives the UDP packet trains of the peer agents and reports the arrived packets to the senders.
+// Only packets from the node and pod IPs of the cluster configuration are accepted.
+type packetTrainReceiver struct {
+	log logrus.FieldLogger
+
+	lock    sync.Mutex
+	conn    *net.UDPConn
+	port    int
+	allowed common.StringSet
+	trains  map[uint64]*receivedPacketTrain
+	now     func() time.Time
+}
+
+func newPacketTrainReceiver(log logrus.FieldLogger) *packetTrainReceiver {
+	return &packetTrainReceiver{
+		log:     log,
+		allowed: common.StringSet{},
+		trains:  map[uint64]*receivedPacketTrain{},
+		now:     time.Now,
+	}
+}
+
+// packetTrainSettingsOf returns the UDP port and the allowed senders from the cluster configuration.
+// The port is 0 if packet trains are disabled.
+func packetTrainSettingsOf(clusterCfg *config.ClusterConfig) (int, common.StringSet) {
+	allowed := common.StringSet{}
+	if clusterCfg == nil {
+		return 0, allowed
+	}
+	add := func(ip string) {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			allowed.Add(parsed.String())
+		}
+	}
+	for _, n := range clusterCfg.Nodes {
+		add(n.InternalIP)
+	}
+	for _, pe := range clusterCfg.PodEndpoints {
+		add(pe.PodIP)
+	}
+	return clusterCfg.PacketTrainPort, allowed
+}
+
+// configure sets the allowed senders and (re)starts the UDP listener if the port has changed.
+// A port of 0 stops the listener.
+func (r *packetTrainReceiver) configure(port int, allowed common.StringSet) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.allowed = allowed
+	if port == r.port && (port == 0 || r.conn != nil) {
+		return nil
+	}
+	if r.conn != nil {
+		_ = r.conn.Close()
+		r.conn = nil
+	}
+	r.port = port
+	r.trains = map[uint64]*receivedPacketTrain{}
+	if port == 0 {
+		return nil
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	r.log.Infof("listening for packet trains on udp port %d", port)
+	go r.receive(conn)
+	return nil
+}
+
+func (r *packetTrainReceiver) receive(conn *net.UDPConn) {
+	buf := make([]byte, common.MaxPacketTrainPacketSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			r.log.Debugf("receiving packet train packet failed: %s", err)
+			continue
+		}
+		r.add(addr.IP.String(), buf[:n])
+	}
+}
+
+// add records a received packet. Packets of unknown senders, duplicates and packets exceeding the limits are ignored.
+func (r *packetTrainReceiver) add(sender string, data []byte) {
+	pkt, err := common.DecodePacketTrainPacket(data)
+	if err != nil {
+		return
+	}
+	now := r.now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.allowed.Contains(sender) {
+		return
+	}
+	train := r.trains[pkt.TrainID]
+	if train == nil {
+		r.expire(now)
+		if len(r.trains) >= maxPacketTrains {
+			return
+		}
+		train = &receivedPacketTrain{sender: sender, first: now, seen: map[uint32]struct{}{}}
+		r.trains[pkt.TrainID] = train
+	}
+	if train.sender != sender || len(train.seen) >= common.MaxPacketTrainPackets {
+		return
+	}
+	if _, ok := train.seen[pkt.Seq]; ok {
+		return
+	}
+	train.seen[pkt.Seq] = struct{}{}
+	train.last = now
+	train.report.Seqs = append(train.report.Seqs, pkt.Seq)
+	train.report.ArrivalMicros = append(train.report.ArrivalMicros, now.Sub(train.first).Microseconds())
+}
+
+func (r *packetTrainReceiver) expire(now time.Time) {
+	for id, train := range r.trains {
+		if now.Sub(train.last) > packetTrainRetention {
+			delete(r.trains, id)
+		}
+	}
+}
+
+// handleReport returns the report of the packet train given by the query parameter `id` and forgets the train.
+// A train without any received packet results in an empty report.
+func (r *packetTrainReceiver) handleReport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseUint(req.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid packet train id", http.StatusBadRequest)
+		return
+	}
+
+	r.lock.Lock()
+	if r.conn == nil {
+		r.lock.Unlock()
+		http.Error(w, "packet train listener not enabled", http.StatusNotFound)
+		return
+	}
+	report := common.PacketTrainReport{}
+	if train := r.trains[id]; train != nil {
+		report = train.report
+		delete(r.trains, id)
+	}
+	r.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&report); err != nil {
+		r.log.Debugf("writing packet train report failed: %s", err)
+	}
+}
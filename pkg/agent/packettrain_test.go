@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("packet train receiver", func() {
+	var (
+		receiver *packetTrainReceiver
+		now      time.Time
+	)
+
+	packet := func(trainID uint64, seq, count uint32) []byte {
+		buf := make([]byte, 64)
+		common.PacketTrainPacket{TrainID: trainID, Seq: seq, Count: count}.Encode(buf)
+		return buf
+	}
+	report := func(id string) (int, *common.PacketTrainReport) {
+		rec := httptest.NewRecorder()
+		receiver.handleReport(rec, httptest.NewRequest(http.MethodGet, common.PathPacketTrain+"?id="+id, nil))
+		if rec.Code != http.StatusOK {
+			return rec.Code, nil
+		}
+		result := &common.PacketTrainReport{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), result)).To(Succeed())
+		return rec.Code, result
+	}
+
+	BeforeEach(func() {
+		receiver = newPacketTrainReceiver(logrus.NewEntry(logrus.StandardLogger()))
+		now = time.Now()
+		receiver.now = func() time.Time { return now }
+		receiver.allowed = common.StringSet{"10.0.0.1": {}}
+		// enabled without a listener
+		receiver.conn = &net.UDPConn{}
+	})
+
+	It("allows the nodes and pods of the cluster config as senders", func() {
+		port, allowed := packetTrainSettingsOf(&config.ClusterConfig{
+			PacketTrainPort: common.PacketTrainPort,
+			Nodes:           []config.Node{{Hostname: "node1", InternalIP: "10.0.0.1"}},
+			PodEndpoints:    []config.PodEndpoint{{Nodename: "node1", PodIP: "10.128.0.1"}},
+		})
+		Expect(port).To(Equal(common.PacketTrainPort))
+		Expect(allowed.ToSortedArray()).To(Equal([]string{"10.0.0.1", "10.128.0.1"}))
+
+		port, _ = packetTrainSettingsOf(nil)
+		Expect(port).To(Equal(0))
+	})
+
+	It("reports the arrived packets in the order of arrival", func() {
+		receiver.add("10.0.0.1", packet(42, 0, 5))
+		now = now.Add(1 * time.Millisecond)
+		receiver.add("10.0.0.1", packet(42, 2, 5))
+		now = now.Add(1 * time.Millisecond)
+		receiver.add("10.0.0.1", packet(42, 1, 5))
+		receiver.add("10.0.0.1", packet(42, 1, 5))
+
+		code, result := report("42")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(result.Seqs).To(Equal([]uint32{0, 2, 1}))
+		Expect(result.ArrivalMicros).To(Equal([]int64{0, 1000, 2000}))
+
+		// the train is forgotten after the report
+		_, result = report("42")
+		Expect(result.Seqs).To(BeEmpty())
+	})
+
+	It("ignores packets of unknown senders and invalid packets", func() {
+		receiver.add("10.0.0.2", packet(1, 0, 5))
+		receiver.add("10.0.0.1", packet(1, 5, 5))
+		receiver.add("10.0.0.1", packet(1, 0, common.MaxPacketTrainPackets+1))
+		receiver.add("10.0.0.1", []byte("foo"))
+		Expect(receiver.trains).To(BeEmpty())
+	})
+
+	It("limits the number of trains", func() {
+		for i := uint64(0); i < maxPacketTrains+10; i++ {
+			receiver.add("10.0.0.1", packet(i, 0, 2))
+		}
+		Expect(receiver.trains).To(HaveLen(maxPacketTrains))
+
+		now = now.Add(packetTrainRetention + time.Second)
+		receiver.add("10.0.0.1", packet(1000, 0, 2))
+		Expect(receiver.trains).To(HaveLen(1))
+	})
+
+	It("rejects reports if not enabled", func() {
+		receiver.conn = nil
+		code, _ := report("1")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("receives packets on the UDP port", func() {
+		probe, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).To(BeNil())
+		port := probe.LocalAddr().(*net.UDPAddr).Port
+		Expect(probe.Close()).To(Succeed())
+
+		receiver = newPacketTrainReceiver(logrus.NewEntry(logrus.StandardLogger()))
+		Expect(receiver.configure(port, common.StringSet{"127.0.0.1": {}})).To(Succeed())
+		defer func() {
+			Expect(receiver.configure(0, nil)).To(Succeed())
+		}()
+
+		conn, err := net.Dial("udp", probe.LocalAddr().String())
+		Expect(err).To(BeNil())
+		defer conn.Close()
+		for i := uint32(0); i < 3; i++ {
+			_, err := conn.Write(packet(7, i, 3))
+			Expect(err).To(BeNil())
+		}
+		Eventually(func() int {
+			receiver.lock.Lock()
+			defer receiver.lock.Unlock()
+			if train := receiver.trains[7]; train != nil {
+				return len(train.seen)
+			}
+			return 0
+		}).Should(Equal(3))
+	})
+})
@@ -0,0 +1,397 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	defaultRemoteWriteTimeout = 10 * time.Second
+	defaultRemoteWriteRetries = 2
+	maxRemoteWriteRetries     = 5
+	// remoteWriteRetryBackoff is the wait time before the first retry of a push. It is doubled for each further retry.
+	remoteWriteRetryBackoff = 1 * time.Second
+	// maxSecretFileSize is the maximum size of a file with a password, bearer token, or authorization header.
+	maxSecretFileSize = 4096
+	// maxRemoteWriteResponseSize is the maximum size of the response body included in an error.
+	maxRemoteWriteResponseSize = 512
+)
+
+// remoteWriteGatherer gathers the aggregated observation metrics to push.
+var remoteWriteGatherer = func() prometheus.Gatherer {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(aggregatedObservationsCollector{})
+	return registry
+}()
+
+// remoteWriteSettings is the applied remote write configuration.
+type remoteWriteSettings struct {
+	url            string
+	period         time.Duration
+	timeout        time.Duration
+	externalLabels map[string]string
+	// nodeLabel is the name of the label with the node name, which is set by the server.
+	nodeLabel    string
+	nodeName     string
+	maxRetries   int
+	retryBackoff time.Duration
+	username     string
+	// passwordFile and bearerTokenFile are read on each push, so that rotated secrets are picked up.
+	passwordFile    string
+	bearerTokenFile string
+	// passwordRef and bearerTokenRef are resolved by the secret resolver on each push.
+	passwordRef    *config.SecretRef
+	bearerTokenRef *config.SecretRef
+	secrets        *secretResolver
+}
+
+// remoteWriteSettingsOf validates the remote write configuration. The metrics are pushed with the given aggregation report period.
+// It returns nil if no URL is configured.
+func remoteWriteSettingsOf(cfg *config.AgentConfig, reportPeriod time.Duration) (*remoteWriteSettings, error) {
+	rwCfg := cfg.RemoteWrite
+	if rwCfg == nil || rwCfg.URL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rwCfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid RemoteWrite url, must be an absolute http or https URL")
+	}
+	settings := &remoteWriteSettings{
+		url:          rwCfg.URL,
+		period:       reportPeriod,
+		timeout:      defaultRemoteWriteTimeout,
+		maxRetries:   defaultRemoteWriteRetries,
+		retryBackoff: remoteWriteRetryBackoff,
+	}
+	if rwCfg.Timeout != nil {
+		settings.timeout = rwCfg.Timeout.Duration
+		if settings.timeout <= 0 || settings.timeout > reportPeriod {
+			return nil, fmt.Errorf("invalid RemoteWrite timeout, must be > 0 and <= aggregation report period %s", reportPeriod)
+		}
+	}
+	for name := range rwCfg.ExternalLabels {
+		if !validMetricLabelName.MatchString(name) || strings.HasPrefix(name, "__") || name == "le" {
+			return nil, fmt.Errorf("invalid RemoteWrite external label name %q", name)
+		}
+		if reservedLabelNames.Contains(name) {
+			return nil, fmt.Errorf("reserved RemoteWrite external label name %q", name)
+		}
+	}
+	settings.externalLabels = rwCfg.ExternalLabels
+	if name := rwCfg.NodeLabel; name != "" {
+		if !validMetricLabelName.MatchString(name) || strings.HasPrefix(name, "__") || name == "le" {
+			return nil, fmt.Errorf("invalid RemoteWrite node label name %q", name)
+		}
+		if _, ok := rwCfg.ExternalLabels[name]; ok || reservedLabelNames.Contains(name) {
+			return nil, fmt.Errorf("reserved RemoteWrite node label name %q", name)
+		}
+		settings.nodeLabel = name
+	}
+	if rwCfg.MaxRetries != nil {
+		settings.maxRetries = *rwCfg.MaxRetries
+		if settings.maxRetries < 0 || settings.maxRetries > maxRemoteWriteRetries {
+			return nil, fmt.Errorf("invalid RemoteWrite maxRetries, must be in range [0,%d]", maxRemoteWriteRetries)
+		}
+	}
+	authCount := 0
+	for _, set := range []bool{rwCfg.BasicAuth != nil, rwCfg.BearerTokenFile != "", rwCfg.BearerToken != ""} {
+		if set {
+			authCount++
+		}
+	}
+	if authCount > 1 {
+		return nil, fmt.Errorf("invalid RemoteWrite authentication, only one of basicAuth, bearerTokenFile and bearerToken is allowed")
+	}
+	if ba := rwCfg.BasicAuth; ba != nil {
+		if ba.Username == "" || (ba.PasswordFile == "") == (ba.Password == "") {
+			return nil, fmt.Errorf("RemoteWrite basicAuth requires username and either passwordFile or password")
+		}
+		settings.username = ba.Username
+		settings.passwordFile = ba.PasswordFile
+		if ba.Password != "" {
+			ref, err := config.ParseSecretRef(ba.Password)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RemoteWrite basicAuth password: %s", err)
+			}
+			settings.passwordRef = &ref
+		}
+	}
+	settings.bearerTokenFile = rwCfg.BearerTokenFile
+	if rwCfg.BearerToken != "" {
+		ref, err := config.ParseSecretRef(rwCfg.BearerToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RemoteWrite bearerToken: %s", err)
+		}
+		settings.bearerTokenRef = &ref
+	}
+	// fail early if a secret file cannot be read, secret references are resolved on push
+	if settings.passwordRef == nil && settings.bearerTokenRef == nil {
+		if err := settings.authorize(&http.Request{Header: http.Header{}}); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// authorize sets the authorization header of the request.
+func (rw *remoteWriteSettings) authorize(req *http.Request) error {
+	switch {
+	case rw.passwordFile != "":
+		password, err := readSecretFile("RemoteWrite", rw.passwordFile)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(rw.username, password)
+	case rw.passwordRef != nil:
+		password, err := rw.secrets.resolve(*rw.passwordRef)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(rw.username, password)
+	case rw.bearerTokenFile != "":
+		token, err := readSecretFile("RemoteWrite", rw.bearerTokenFile)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case rw.bearerTokenRef != nil:
+		token, err := rw.secrets.resolve(*rw.bearerTokenRef)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// readSecretFile reads the trimmed secret from the file. The section of the configuration is included in errors.
+func readSecretFile(section, filename string) (string, error) {
+	f, err := os.Open(filename) // #nosec G304 -- file provided by configuration
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s secret: %s", section, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSecretFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s secret: %s", section, err)
+	}
+	if len(data) > maxSecretFileSize {
+		return "", fmt.Errorf("%s secret %s too large", section, filename)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%s secret %s is empty", section, filename)
+	}
+	return secret, nil
+}
+
+// labels returns the external labels including the node label.
+func (rw *remoteWriteSettings) labels() map[string]string {
+	if rw.nodeLabel == "" {
+		return rw.externalLabels
+	}
+	labels := map[string]string{rw.nodeLabel: rw.nodeName}
+	for name, value := range rw.externalLabels {
+		labels[name] = value
+	}
+	return labels
+}
+
+// remoteWriteStatusError is the error of a push rejected by the remote write endpoint.
+type remoteWriteStatusError struct {
+	status int
+	body   string
+}
+
+func (e *remoteWriteStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+// retryableRemoteWriteError returns true if a push failed with the error may succeed later.
+func retryableRemoteWriteError(err error) bool {
+	var statusErr *remoteWriteStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status == http.StatusTooManyRequests || statusErr.status/100 == 5
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// sendRemoteWriteIfDue pushes the aggregated observation metrics if the push period has elapsed.
+// At most one push is in flight, so that a slow endpoint cannot pile up pushes. A failed push is retried with backoff
+// until the next push is due, which contains the current values of all series anyway.
+func (s *server) sendRemoteWriteIfDue(now time.Time) {
+	s.lock.Lock()
+	settings := s.remoteWrite
+	if settings == nil || s.remoteWriteInFlight || now.Sub(s.lastRemoteWrite) < settings.period {
+		s.lock.Unlock()
+		return
+	}
+	s.remoteWriteInFlight = true
+	s.lastRemoteWrite = now
+	s.lock.Unlock()
+
+	go func() {
+		defer func() {
+			s.lock.Lock()
+			s.remoteWriteInFlight = false
+			s.lock.Unlock()
+		}()
+		if err := pushRemoteWrite(settings, remoteWriteGatherer, now); err != nil {
+			RemoteWriteFailures.Inc()
+			s.log.Warnf("remote write to %s failed: %s", settings.url, err)
+		}
+	}()
+}
+
+// pushRemoteWrite gathers the metrics and sends them as remote write request. A request failed with a connection error
+// or a status 429 or 5xx is retried at most maxRetries times, as long as the retry starts before the next push is due.
+func pushRemoteWrite(settings *remoteWriteSettings, gatherer prometheus.Gatherer, now time.Time) error {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	data, count := encodeWriteRequest(families, settings.labels(), now)
+	if count == 0 {
+		return nil
+	}
+	body := snappyEncode(data)
+	backoff := settings.retryBackoff
+	for retry := 0; ; retry++ {
+		err = postRemoteWrite(settings, body)
+		if err == nil || !retryableRemoteWriteError(err) || retry >= settings.maxRetries || time.Since(now)+backoff >= settings.period {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func postRemoteWrite(settings *remoteWriteSettings, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, settings.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "network-problem-detector")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if err := settings.authorize(req); err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: settings.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteWriteResponseSize))
+	if resp.StatusCode/100 != 2 {
+		return &remoteWriteStatusError{status: resp.StatusCode, body: strings.TrimSpace(string(respBody))}
+	}
+	return nil
+}
+
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+// encodeWriteRequest encodes the metric families as protobuf message `prometheus.WriteRequest` of the remote write protocol.
+// Counters and gauges are sent as single series, histograms with their classic buckets as `_bucket`, `_sum`, and `_count` series.
+// It returns the encoded message and the number of series.
+func encodeWriteRequest(families []*dto.MetricFamily, externalLabels map[string]string, now time.Time) ([]byte, int) {
+	timestamp := now.UnixMilli()
+	var (
+		buf   []byte
+		count int
+	)
+	add := func(name string, m *dto.Metric, extra *remoteWriteLabel, value float64) {
+		labels := []remoteWriteLabel{{name: "__name__", value: name}}
+		for _, lp := range m.GetLabel() {
+			if lp.GetValue() != "" {
+				labels = append(labels, remoteWriteLabel{name: lp.GetName(), value: lp.GetValue()})
+			}
+		}
+		for name, value := range externalLabels {
+			labels = append(labels, remoteWriteLabel{name: name, value: value})
+		}
+		if extra != nil {
+			labels = append(labels, *extra)
+		}
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].name < labels[j].name
+		})
+		buf = appendTimeSeries(buf, labels, value, timestamp)
+		count++
+	}
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m, nil, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m, nil, m.GetGauge().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					add(name+"_bucket", m, &remoteWriteLabel{name: "le", value: formatBucketBound(b.GetUpperBound())}, float64(b.GetCumulativeCount()))
+				}
+				add(name+"_bucket", m, &remoteWriteLabel{name: "le", value: "+Inf"}, float64(h.GetSampleCount()))
+				add(name+"_sum", m, nil, h.GetSampleSum())
+				add(name+"_count", m, nil, float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return buf, count
+}
+
+func formatBucketBound(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprint(bound)
+}
+
+// appendTimeSeries appends a `timeseries` field with a single sample to the encoded `WriteRequest`.
+func appendTimeSeries(buf []byte, labels []remoteWriteLabel, value float64, timestamp int64) []byte {
+	var series []byte
+	for _, l := range labels {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l.name)
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, l.value)
+		series = protowire.AppendTag(series, 1, protowire.BytesType)
+		series = protowire.AppendBytes(series, label)
+	}
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp)) // #nosec G115 -- protobuf int64 is encoded as two's complement
+	series = protowire.AppendTag(series, 2, protowire.BytesType)
+	series = protowire.AppendBytes(series, sample)
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	return protowire.AppendBytes(buf, series)
+}
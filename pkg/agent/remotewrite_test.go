@@ -0,0 +1,285 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// decodeWriteRequest decodes the series of a `WriteRequest` into a map from the joined labels to the sample value.
+func decodeWriteRequest(data []byte) map[string]float64 {
+	fields := func(msg []byte, fn func(num protowire.Number, value []byte, fixed uint64)) {
+		for len(msg) > 0 {
+			num, typ, n := protowire.ConsumeTag(msg)
+			Expect(n).To(BeNumerically(">", 0))
+			msg = msg[n:]
+			switch typ {
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(msg)
+				Expect(n).To(BeNumerically(">", 0))
+				fn(num, v, 0)
+				msg = msg[n:]
+			case protowire.Fixed64Type:
+				v, n := protowire.ConsumeFixed64(msg)
+				fn(num, nil, v)
+				msg = msg[n:]
+			default:
+				n := protowire.ConsumeFieldValue(num, typ, msg)
+				Expect(n).To(BeNumerically(">", 0))
+				msg = msg[n:]
+			}
+		}
+	}
+	result := map[string]float64{}
+	fields(data, func(_ protowire.Number, series []byte, _ uint64) {
+		var (
+			labels []string
+			value  float64
+		)
+		fields(series, func(num protowire.Number, v []byte, _ uint64) {
+			switch num {
+			case 1:
+				var name, value string
+				fields(v, func(num protowire.Number, v []byte, _ uint64) {
+					if num == 1 {
+						name = string(v)
+					} else {
+						value = string(v)
+					}
+				})
+				labels = append(labels, name+"="+value)
+			case 2:
+				fields(v, func(num protowire.Number, _ []byte, fixed uint64) {
+					if num == 1 {
+						value = math.Float64frombits(fixed)
+					}
+				})
+			}
+		})
+		result[strings.Join(labels, ",")] = value
+	})
+	return result
+}
+
+var _ = Describe("remote write", func() {
+	var secretFile string
+
+	BeforeEach(func() {
+		secretFile = filepath.Join(GinkgoT().TempDir(), "secret")
+		Expect(os.WriteFile(secretFile, []byte("s3cret\n"), 0o600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteOutdatedMetricByObsoleteJobIDs([]string{"rw-test"})
+	})
+
+	agentConfigOf := func(rwCfg *config.RemoteWriteConfig) *config.AgentConfig {
+		return &config.AgentConfig{RemoteWrite: rwCfg}
+	}
+
+	It("falls back to scrape-only without url", func() {
+		settings, err := remoteWriteSettingsOf(agentConfigOf(nil), time.Minute)
+		Expect(err).To(BeNil())
+		Expect(settings).To(BeNil())
+		settings, err = remoteWriteSettingsOf(agentConfigOf(&config.RemoteWriteConfig{}), time.Minute)
+		Expect(err).To(BeNil())
+		Expect(settings).To(BeNil())
+	})
+
+	It("pushes with the aggregation report period", func() {
+		settings, err := remoteWriteSettingsOf(agentConfigOf(&config.RemoteWriteConfig{URL: "https://example.com/api/v1/write"}), 2*time.Minute)
+		Expect(err).To(BeNil())
+		Expect(settings.period).To(Equal(2 * time.Minute))
+		Expect(settings.timeout).To(Equal(defaultRemoteWriteTimeout))
+	})
+
+	DescribeTable("rejects invalid configurations",
+		func(rwCfg *config.RemoteWriteConfig, expectedErr string) {
+			if rwCfg.BasicAuth != nil && rwCfg.BasicAuth.PasswordFile == "secret" {
+				rwCfg.BasicAuth.PasswordFile = secretFile
+			}
+			if rwCfg.BearerTokenFile == "secret" {
+				rwCfg.BearerTokenFile = secretFile
+			}
+			_, err := remoteWriteSettingsOf(agentConfigOf(rwCfg), time.Minute)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("relative url", &config.RemoteWriteConfig{URL: "/api/v1/write"}, "invalid RemoteWrite url"),
+		Entry("unsupported scheme", &config.RemoteWriteConfig{URL: "ftp://example.com/write"}, "invalid RemoteWrite url"),
+		Entry("timeout too long", &config.RemoteWriteConfig{URL: "http://example.com", Timeout: &metav1.Duration{Duration: 2 * time.Minute}}, "invalid RemoteWrite timeout"),
+		Entry("invalid external label", &config.RemoteWriteConfig{URL: "http://example.com", ExternalLabels: map[string]string{"a-b": "x"}}, "invalid RemoteWrite external label"),
+		Entry("reserved external label", &config.RemoteWriteConfig{URL: "http://example.com", ExternalLabels: map[string]string{"src": "x"}}, "reserved RemoteWrite external label"),
+		Entry("invalid node label", &config.RemoteWriteConfig{URL: "http://example.com", NodeLabel: "__node"}, "invalid RemoteWrite node label"),
+		Entry("node label used as external label", &config.RemoteWriteConfig{URL: "http://example.com", NodeLabel: "node",
+			ExternalLabels: map[string]string{"node": "x"}}, "reserved RemoteWrite node label"),
+		Entry("too many retries", &config.RemoteWriteConfig{URL: "http://example.com", MaxRetries: ptr.To(6)}, "invalid RemoteWrite maxRetries"),
+		Entry("both authentications", &config.RemoteWriteConfig{URL: "http://example.com", BearerTokenFile: "secret",
+			BasicAuth: &config.RemoteWriteBasicAuth{Username: "user", PasswordFile: "secret"}}, "only one of"),
+		Entry("missing username", &config.RemoteWriteConfig{URL: "http://example.com", BasicAuth: &config.RemoteWriteBasicAuth{PasswordFile: "secret"}}, "requires username"),
+		Entry("unreadable token file", &config.RemoteWriteConfig{URL: "http://example.com", BearerTokenFile: "/nonexisting"}, "cannot read"),
+	)
+
+	It("encodes histograms with their classic buckets", func() {
+		configureDurationHistogram(true)
+		defer configureDurationHistogram(false)
+		ReportAggregatedObservationLatency("node1", "node2", "rw-test", nil, 0.003)
+		families, err := remoteWriteGatherer.Gather()
+		Expect(err).To(BeNil())
+		data, _ := encodeWriteRequest(families, nil, time.Now())
+		series := decodeWriteRequest(data)
+		prefix := "__name__=nwpd_aggregated_observations_duration_seconds"
+		Expect(series).To(HaveKeyWithValue(prefix+"_bucket,dest=node2,jobid=rw-test,le=0.0025,src=node1", 0.0))
+		Expect(series).To(HaveKeyWithValue(prefix+"_bucket,dest=node2,jobid=rw-test,le=0.005,src=node1", 1.0))
+		Expect(series).To(HaveKeyWithValue(prefix+"_bucket,dest=node2,jobid=rw-test,le=+Inf,src=node1", 1.0))
+		Expect(series).To(HaveKeyWithValue(prefix+"_count,dest=node2,jobid=rw-test,src=node1", 1.0))
+		Expect(series).To(HaveKeyWithValue(prefix+"_sum,dest=node2,jobid=rw-test,src=node1", 0.003))
+		Expect(series).To(HaveKeyWithValue("__name__=nwpd_aggregated_observations_latency_secs,dest=node2,jobid=rw-test,src=node1", 0.003))
+	})
+
+	It("sends the aggregated metrics to the remote write endpoint", func() {
+		var (
+			lock     sync.Mutex
+			received map[string]float64
+			headers  http.Header
+		)
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			body, _ := io.ReadAll(r.Body)
+			data, err := snappyDecode(body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			lock.Lock()
+			received = decodeWriteRequest(data)
+			headers = r.Header
+			lock.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer endpoint.Close()
+
+		settings, err := remoteWriteSettingsOf(agentConfigOf(&config.RemoteWriteConfig{
+			URL:            endpoint.URL,
+			ExternalLabels: map[string]string{"cluster": "shoot--foo--bar"},
+			BasicAuth:      &config.RemoteWriteBasicAuth{Username: "nwpd", PasswordFile: secretFile},
+		}), time.Minute)
+		Expect(err).To(BeNil())
+		IncAggregatedObservation("node1", "node2", "rw-test", nil, "ok")
+		IncAggregatedObservation("node1", "node2", "rw-test", nil, "ok")
+
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), remoteWrite: settings}
+		now := time.Now()
+		s.sendRemoteWriteIfDue(now)
+		Eventually(func() map[string]float64 {
+			lock.Lock()
+			defer lock.Unlock()
+			return received
+		}).Should(HaveKeyWithValue("__name__=nwpd_aggregated_observations,cluster=shoot--foo--bar,dest=node2,jobid=rw-test,src=node1,status=ok", 2.0))
+		lock.Lock()
+		Expect(headers.Get("Content-Encoding")).To(Equal("snappy"))
+		Expect(headers.Get("Content-Type")).To(Equal("application/x-protobuf"))
+		Expect(headers.Get("X-Prometheus-Remote-Write-Version")).To(Equal("0.1.0"))
+		user, password, ok := (&http.Request{Header: headers}).BasicAuth()
+		lock.Unlock()
+		Expect(ok).To(BeTrue())
+		Expect(user).To(Equal("nwpd"))
+		Expect(password).To(Equal("s3cret"))
+
+		// not due yet
+		Eventually(func() bool {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			return s.remoteWriteInFlight
+		}).Should(BeFalse())
+		s.sendRemoteWriteIfDue(now.Add(time.Second))
+		Expect(s.lastRemoteWrite).To(Equal(now))
+	})
+
+	It("authenticates with the bearer token and reports failures", func() {
+		var authorization string
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			http.Error(w, "out of order sample", http.StatusBadRequest)
+		}))
+		defer endpoint.Close()
+
+		settings, err := remoteWriteSettingsOf(agentConfigOf(&config.RemoteWriteConfig{URL: endpoint.URL, BearerTokenFile: secretFile}), time.Minute)
+		Expect(err).To(BeNil())
+		IncAggregatedObservation("node1", "node2", "rw-test", nil, "failed")
+		err = pushRemoteWrite(settings, remoteWriteGatherer, time.Now())
+		Expect(err).To(MatchError(ContainSubstring("unexpected status 400: out of order sample")))
+		Expect(authorization).To(Equal("Bearer s3cret"))
+	})
+
+	It("retries failed pushes until the next push is due and adds the node label", func() {
+		var (
+			lock     sync.Mutex
+			attempts int
+			received map[string]float64
+			status   = http.StatusServiceUnavailable
+		)
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			body, _ := io.ReadAll(r.Body)
+			data, err := snappyDecode(body)
+			Expect(err).To(BeNil())
+			lock.Lock()
+			defer lock.Unlock()
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(status)
+				return
+			}
+			received = decodeWriteRequest(data)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer endpoint.Close()
+
+		settings, err := remoteWriteSettingsOf(agentConfigOf(&config.RemoteWriteConfig{URL: endpoint.URL, NodeLabel: "node"}), time.Minute)
+		Expect(err).To(BeNil())
+		Expect(settings.maxRetries).To(Equal(defaultRemoteWriteRetries))
+		settings.nodeName = "node1"
+		settings.retryBackoff = 10 * time.Millisecond
+		IncAggregatedObservation("node1", "node2", "rw-test", nil, "ok")
+		Expect(pushRemoteWrite(settings, remoteWriteGatherer, time.Now())).To(Succeed())
+		lock.Lock()
+		Expect(attempts).To(Equal(2))
+		Expect(received).To(HaveKeyWithValue("__name__=nwpd_aggregated_observations,dest=node2,jobid=rw-test,node=node1,src=node1,status=ok", 1.0))
+		attempts = 0
+		status = http.StatusBadRequest
+		lock.Unlock()
+
+		// a rejected push is not retried
+		Expect(pushRemoteWrite(settings, remoteWriteGatherer, time.Now())).To(MatchError(ContainSubstring("unexpected status 400")))
+		lock.Lock()
+		Expect(attempts).To(Equal(1))
+		attempts = 0
+		status = http.StatusServiceUnavailable
+		lock.Unlock()
+
+		// no retry if the next push is due
+		Expect(pushRemoteWrite(settings, remoteWriteGatherer, time.Now().Add(-time.Minute))).To(MatchError(ContainSubstring("unexpected status 503")))
+		lock.Lock()
+		Expect(attempts).To(Equal(1))
+		lock.Unlock()
+	})
+})
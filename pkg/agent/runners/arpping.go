@@ -0,0 +1,409 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/atomic"
+)
+
+const (
+	// defaultGatewayHost is the destination host of the observations for the default gateway of the node.
+	defaultGatewayHost = "default-gateway"
+	arpPingTimeout     = 1 * time.Second
+	// arpPollInterval is the interval for reading the neighbor table of the kernel while waiting for the resolution.
+	arpPollInterval = 50 * time.Millisecond
+	// discardPort is the port of the UDP datagram sent to make the kernel resolve the neighbor.
+	discardPort  = 9
+	procNetRoute = "/proc/net/route"
+	procNetARP   = "/proc/net/arp"
+
+	arpPacketLength  = 28
+	arpOpRequest     = 1
+	arpOpReply       = 2
+	arpHTypeEthernet = 1
+	arpPTypeIPv4     = 0x0800
+	// arpFlagComplete is the flag ATF_COM of a resolved entry of the neighbor table.
+	arpFlagComplete = 0x2
+	// routeFlagsDefaultGateway are the flags RTF_UP and RTF_GATEWAY of a route.
+	routeFlagsDefaultGateway = 0x3
+)
+
+type arpPingArgs struct {
+	runnerArgs *runnerArgs
+	hosts      []string
+}
+
+func (a *arpPingArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if err := a.runnerArgs.validateSampling(); err != nil {
+		return err
+	}
+	var nodes []config.Node
+	for _, host := range a.hosts {
+		parts, ok := splitArg(host, ":", 2, 2)
+		if !ok || net.ParseIP(parts[1]).To4() == nil {
+			return fmt.Errorf("invalid job: %s: invalid host %s", strings.Join(a.runnerArgs.args, " "), host)
+		}
+		nodes = append(nodes, config.Node{
+			Hostname:   parts[0],
+			InternalIP: parts[1],
+		})
+	}
+	if len(nodes) == 0 {
+		// the address of the gateway is looked up on each run
+		nodes = []config.Node{{Hostname: defaultGatewayHost}}
+	}
+
+	config := a.runnerArgs.prepareConfig()
+	if r := NewARPPing(nodes, config); r != nil {
+		a.runnerArgs.runner = r
+	}
+	return nil
+}
+
+func createARPPingCmd(ra *runnerArgs) *cobra.Command {
+	a := &arpPingArgs{runnerArgs: ra}
+	cmd := &cobra.Command{
+		Use:   "arpPing",
+		Short: "resolves the MAC address of the default gateway or of neighbors on the same link with ARP requests",
+		RunE:  a.createRunner,
+	}
+	cmd.Flags().StringSliceVar(&a.hosts, "hosts", nil, "Optional neighbors in format <hostname>:<ipv4>. If not specified, the default gateway is used.")
+	addSamplingFlags(cmd, ra)
+	return cmd
+}
+
+// NewARPPing creates a runner resolving the MAC addresses of the given neighbors. A node without internal IP stands for
+// the default gateway.
+func NewARPPing(nodes []config.Node, rconfig RunnerConfig) Runner {
+	if len(nodes) == 0 {
+		return nil
+	}
+	r := &arpPing{
+		robinRound: robinRound[config.Node]{
+			itemsName: "neighbors",
+			items:     config.CloneAndShuffle(nodes),
+			config:    rconfig,
+		},
+		timeout: arpPingTimeout,
+	}
+	r.runFunc = r.arpPingFunc
+	return r
+}
+
+type arpPing struct {
+	robinRound[config.Node]
+	timeout     time.Duration
+	checkOnce   sync.Once
+	unsupported atomic.Error
+	// noRawSocket is set if raw sockets are not permitted, the neighbor table of the kernel is read instead.
+	noRawSocket atomic.Error
+}
+
+var _ Runner = &arpPing{}
+
+func (r *arpPing) Run(nodeName string, ch chan<- *nwpd.Observation) {
+	if !r.supported() {
+		return
+	}
+	r.robinRound.Run(nodeName, ch)
+}
+
+func (r *arpPing) RunAll(nodeName string, destHosts []string, ch chan<- *nwpd.Observation) int {
+	if !r.supported() {
+		return 0
+	}
+	return r.robinRound.RunAll(nodeName, destHosts, ch)
+}
+
+// supported checks once if raw sockets are permitted. Without permission, the runner falls back to the neighbor table.
+func (r *arpPing) supported() bool {
+	r.checkOnce.Do(func() {
+		err := checkARPSocket()
+		switch {
+		case err == nil:
+		case errors.Is(err, errors.ErrUnsupported):
+			r.unsupported.Store(err)
+		case isPermissionError(err):
+			r.noRawSocket.Store(err)
+		}
+	})
+	return r.unsupported.Load() == nil
+}
+
+func (r *arpPing) Description() string {
+	desc := r.robinRound.Description()
+	if err := r.unsupported.Load(); err != nil {
+		desc += fmt.Sprintf(" (disabled: %s)", err)
+	} else if err := r.noRawSocket.Load(); err != nil {
+		desc += fmt.Sprintf(" (reading neighbor table: %s)", err)
+	}
+	return desc
+}
+
+// arpNeighbor is a neighbor on the link of a local interface.
+type arpNeighbor struct {
+	ip    net.IP
+	src   net.IP
+	iface *net.Interface
+}
+
+func (r *arpPing) arpPingFunc(node config.Node, fields resultFields) (string, error) {
+	n, err := neighborOf(node)
+	if err != nil {
+		return "", err
+	}
+	result := &resultparse.ARPPing{Neighbor: n.ip.String(), Interface: n.iface.Name, Source: resultparse.NeighborSourceARP}
+	var mac net.HardwareAddr
+	if r.noRawSocket.Load() != nil {
+		result.Source = resultparse.NeighborSourceKernel
+		mac, err = resolveNeighbor(n, r.timeout)
+	} else {
+		mac, result.RTT, err = sendARPRequest(n, r.timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+	if mac == nil {
+		result.Reason = "no ARP reply"
+		if result.Source == resultparse.NeighborSourceKernel {
+			result.Reason = "neighbor not resolved"
+		}
+		result.LostAfter = r.timeout
+		return "", errors.New(result.Text())
+	}
+	result.MAC = mac.String()
+	fields.set(ResultFieldMAC, result.MAC)
+	if result.RTT != 0 {
+		fields.set(ResultFieldRTTMillis, result.RTT.Milliseconds())
+	}
+	return result.Text(), nil
+}
+
+// neighborOf returns the neighbor for the node, or the default gateway if the node has no internal IP.
+func neighborOf(node config.Node) (*arpNeighbor, error) {
+	n := &arpNeighbor{}
+	if node.InternalIP == "" {
+		gateway, ifaceName, err := readDefaultGateway()
+		if err != nil {
+			return nil, err
+		}
+		if n.iface, err = net.InterfaceByName(ifaceName); err != nil {
+			return nil, err
+		}
+		n.ip = gateway
+	} else if n.ip = net.ParseIP(node.InternalIP).To4(); n.ip == nil {
+		return nil, fmt.Errorf("invalid IPv4 address %s", node.InternalIP)
+	}
+
+	// connecting a UDP socket sends no packet, but selects the source address of the route
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: n.ip, Port: discardPort})
+	if err != nil {
+		return nil, err
+	}
+	n.src = conn.LocalAddr().(*net.UDPAddr).IP.To4()
+	_ = conn.Close()
+	if n.iface == nil {
+		if n.iface, err = interfaceOf(n.src); err != nil {
+			return nil, err
+		}
+	}
+	if len(n.iface.HardwareAddr) != 6 {
+		return nil, fmt.Errorf("interface %s has no Ethernet address", n.iface.Name)
+	}
+	return n, nil
+}
+
+// interfaceOf returns the interface with the address.
+func interfaceOf(ip net.IP) (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface with address %s", ip)
+}
+
+// resolveNeighbor sends a UDP datagram to the neighbor to make the kernel resolve it and waits for a complete entry in the
+// neighbor table. It returns nil if the neighbor is not resolved within the timeout. The kernel may answer from a cached entry,
+// so that an unreachable neighbor is only detected after the entry has expired.
+func resolveNeighbor(n *arpNeighbor, timeout time.Duration) (net.HardwareAddr, error) {
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: n.ip, Port: discardPort})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// an error of a previous datagram may be reported, the datagram is sent nevertheless
+	_, _ = conn.Write([]byte{0})
+
+	deadline := time.Now().Add(timeout)
+	for {
+		entries, err := readARPTable()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.ip.Equal(n.ip) && e.device == n.iface.Name && e.flags&arpFlagComplete != 0 {
+				return e.mac, nil
+			}
+		}
+		if time.Now().Add(arpPollInterval).After(deadline) {
+			return nil, nil
+		}
+		time.Sleep(arpPollInterval)
+	}
+}
+
+// readDefaultGateway returns the gateway and the interface of the default route with the lowest metric.
+func readDefaultGateway() (net.IP, string, error) {
+	f, err := os.Open(procNetRoute)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	return parseDefaultGateway(f)
+}
+
+// parseDefaultGateway parses the IPv4 routing table in the format of /proc/net/route.
+func parseDefaultGateway(r io.Reader) (net.IP, string, error) {
+	var gateway net.IP
+	var iface string
+	metric := math.MaxInt
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		cols := strings.Fields(scanner.Text())
+		if first || len(cols) < 8 {
+			// header line
+			continue
+		}
+		flags, err := strconv.ParseUint(cols[3], 16, 16)
+		if err != nil || cols[1] != "00000000" || cols[7] != "00000000" || flags&routeFlagsDefaultGateway != routeFlagsDefaultGateway {
+			continue
+		}
+		m, err := strconv.Atoi(cols[6])
+		if err != nil || m >= metric {
+			continue
+		}
+		ip, err := parseRouteAddress(cols[2])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid gateway %s: %s", cols[2], err)
+		}
+		gateway, iface, metric = ip, cols[0], m
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	if gateway == nil {
+		return nil, "", fmt.Errorf("no default gateway")
+	}
+	return gateway, iface, nil
+}
+
+// parseRouteAddress parses an address of the routing table, which is printed as hex number in host byte order.
+func parseRouteAddress(s string) (net.IP, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil || len(data) != 4 {
+		return nil, fmt.Errorf("invalid address")
+	}
+	ip := make(net.IP, 4)
+	binary.NativeEndian.PutUint32(ip, binary.BigEndian.Uint32(data))
+	return ip, nil
+}
+
+// arpEntry is an entry of the neighbor table of the kernel.
+type arpEntry struct {
+	ip     net.IP
+	mac    net.HardwareAddr
+	flags  uint64
+	device string
+}
+
+func readARPTable() ([]arpEntry, error) {
+	f, err := os.Open(procNetARP)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseARPTable(f)
+}
+
+// parseARPTable parses the neighbor table in the format of /proc/net/arp. Invalid lines are skipped.
+func parseARPTable(r io.Reader) ([]arpEntry, error) {
+	var entries []arpEntry
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		cols := strings.Fields(scanner.Text())
+		if first || len(cols) < 6 {
+			continue
+		}
+		ip := net.ParseIP(cols[0]).To4()
+		flags, err := strconv.ParseUint(strings.TrimPrefix(cols[2], "0x"), 16, 32)
+		if ip == nil || err != nil {
+			continue
+		}
+		mac, err := net.ParseMAC(cols[3])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, arpEntry{ip: ip, mac: mac, flags: flags, device: cols[5]})
+	}
+	return entries, scanner.Err()
+}
+
+// marshalARPRequest returns an ARP request for the target IP address without the Ethernet header.
+func marshalARPRequest(srcMAC net.HardwareAddr, srcIP, targetIP net.IP) []byte {
+	data := make([]byte, 8, arpPacketLength)
+	binary.BigEndian.PutUint16(data[0:], arpHTypeEthernet)
+	binary.BigEndian.PutUint16(data[2:], arpPTypeIPv4)
+	data[4] = 6
+	data[5] = 4
+	binary.BigEndian.PutUint16(data[6:], arpOpRequest)
+	data = append(data, srcMAC...)
+	data = append(data, srcIP.To4()...)
+	data = append(data, make([]byte, 6)...)
+	return append(data, targetIP.To4()...)
+}
+
+// parseARPReply returns the sender MAC address if the packet is an ARP reply from the target IP address.
+func parseARPReply(data []byte, targetIP net.IP) (net.HardwareAddr, bool) {
+	if len(data) < arpPacketLength || binary.BigEndian.Uint16(data[0:]) != arpHTypeEthernet ||
+		binary.BigEndian.Uint16(data[2:]) != arpPTypeIPv4 || data[4] != 6 || data[5] != 4 ||
+		binary.BigEndian.Uint16(data[6:]) != arpOpReply {
+		return nil, false
+	}
+	if !bytes.Equal(data[14:18], targetIP.To4()) {
+		return nil, false
+	}
+	return net.HardwareAddr(bytes.Clone(data[8:14])), true
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// htons converts a short from host to network byte order.
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+// openARPSocket opens a packet socket receiving the ARP packets of the interface without Ethernet header.
+func openARPSocket(iface *net.Interface) (int, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, int(htons(syscall.ETH_P_ARP)))
+	if err != nil {
+		return 0, err
+	}
+	if iface != nil {
+		if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: htons(syscall.ETH_P_ARP), Ifindex: iface.Index}); err != nil {
+			_ = syscall.Close(fd)
+			return 0, err
+		}
+	}
+	return fd, nil
+}
+
+// checkARPSocket checks if packet sockets are permitted.
+func checkARPSocket() error {
+	fd, err := openARPSocket(nil)
+	if err != nil {
+		return err
+	}
+	return syscall.Close(fd)
+}
+
+// sendARPRequest broadcasts an ARP request for the neighbor and waits for the reply. It returns nil if no reply is received in time.
+func sendARPRequest(n *arpNeighbor, timeout time.Duration) (net.HardwareAddr, time.Duration, error) {
+	fd, err := openARPSocket(n.iface)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer syscall.Close(fd)
+
+	start := time.Now()
+	broadcast := &syscall.SockaddrLinklayer{
+		Protocol: htons(syscall.ETH_P_ARP),
+		Ifindex:  n.iface.Index,
+		Halen:    6,
+		Addr:     [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	if err := syscall.Sendto(fd, marshalARPRequest(n.iface.HardwareAddr, n.src, n.ip), 0, broadcast); err != nil {
+		return nil, 0, err
+	}
+	deadline := start.Add(timeout)
+	buf := make([]byte, 1500)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, 0, nil
+		}
+		tv := syscall.NsecToTimeval(remaining.Nanoseconds())
+		if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+			return nil, 0, err
+		}
+		size, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			return nil, 0, err
+		}
+		if mac, ok := parseARPReply(buf[:size], n.ip); ok {
+			return mac, time.Since(start), nil
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package runners
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// checkARPSocket is only supported on Linux.
+func checkARPSocket() error {
+	return errors.ErrUnsupported
+}
+
+// sendARPRequest is only supported on Linux.
+func sendARPRequest(_ *arpNeighbor, _ time.Duration) (net.HardwareAddr, time.Duration, error) {
+	return nil, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("arpPing", func() {
+	It("parses the default gateway with the lowest metric", func() {
+		table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth1	00000000	FE01A8C0	0003	0	0	200	00000000	0	0	0
+eth0	00000000	0100000A	0003	0	0	100	00000000	0	0	0
+eth0	0000000A	00000000	0001	0	0	0	00FFFFFF	0	0	0
+`
+		gateway, iface, err := parseDefaultGateway(strings.NewReader(table))
+		Expect(err).To(BeNil())
+		Expect(gateway.String()).To(Equal("10.0.0.1"))
+		Expect(iface).To(Equal("eth0"))
+
+		_, _, err = parseDefaultGateway(strings.NewReader(strings.Join(strings.Split(table, "\n")[3:], "\n")))
+		Expect(err).To(MatchError("no default gateway"))
+	})
+
+	It("parses the neighbor table", func() {
+		table := `IP address       HW type     Flags       HW address            Mask     Device
+10.0.0.1         0x1         0x2         aa:bb:cc:dd:ee:ff     *        eth0
+10.0.0.2         0x1         0x0         00:00:00:00:00:00     *        eth0
+invalid
+`
+		entries, err := parseARPTable(strings.NewReader(table))
+		Expect(err).To(BeNil())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].ip.String()).To(Equal("10.0.0.1"))
+		Expect(entries[0].mac.String()).To(Equal("aa:bb:cc:dd:ee:ff"))
+		Expect(entries[0].flags & arpFlagComplete).NotTo(BeZero())
+		Expect(entries[1].flags & arpFlagComplete).To(BeZero())
+		Expect(entries[1].device).To(Equal("eth0"))
+	})
+
+	It("matches the reply to an ARP request", func() {
+		mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+		request := marshalARPRequest(mac, net.IPv4(10, 0, 0, 2), net.IPv4(10, 0, 0, 1))
+		Expect(request).To(HaveLen(arpPacketLength))
+		_, ok := parseARPReply(request, net.IPv4(10, 0, 0, 2))
+		Expect(ok).To(BeFalse(), "request is no reply")
+
+		reply := marshalARPRequest(mac, net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 2))
+		reply[7] = arpOpReply
+		sender, ok := parseARPReply(reply, net.IPv4(10, 0, 0, 1))
+		Expect(ok).To(BeTrue())
+		Expect(sender).To(Equal(mac))
+		_, ok = parseARPReply(reply, net.IPv4(10, 0, 0, 3))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("fails for neighbors without Ethernet address", func() {
+		r := NewARPPing([]config.Node{{Hostname: "localhost", InternalIP: "127.0.0.1"}}, RunnerConfig{}).(*arpPing)
+		_, err := r.arpPingFunc(r.items[0], resultFields{})
+		Expect(err).To(MatchError(ContainSubstring("has no Ethernet address")))
+		_, err = r.arpPingFunc(config.Node{Hostname: "foo", InternalIP: "::1"}, resultFields{})
+		Expect(err).To(MatchError("invalid IPv4 address ::1"))
+	})
+
+	Context("with default gateway", func() {
+		var gateway config.Node
+
+		BeforeEach(func() {
+			gateway = config.Node{Hostname: defaultGatewayHost}
+			if _, err := neighborOf(gateway); err != nil {
+				Skip("no default gateway on an Ethernet interface: " + err.Error())
+			}
+			if _, err := readARPTable(); err != nil {
+				Skip("no neighbor table: " + err.Error())
+			}
+		})
+
+		It("resolves the MAC address with an ARP request", func() {
+			if err := checkARPSocket(); err != nil {
+				Skip("packet sockets not permitted: " + err.Error())
+			}
+			r := NewARPPing([]config.Node{gateway}, RunnerConfig{}).(*arpPing)
+			Expect(r.supported()).To(BeTrue())
+			fields := resultFields{}
+			result, err := r.arpPingFunc(gateway, fields)
+			Expect(err).To(BeNil())
+			parsed := resultparse.Parse(result).(*resultparse.ARPPing)
+			Expect(parsed.Source).To(Equal(resultparse.NeighborSourceARP))
+			Expect(parsed.RTT).NotTo(BeZero())
+			Expect(fields[ResultFieldMAC]).To(Equal(parsed.MAC))
+			_, err = net.ParseMAC(parsed.MAC)
+			Expect(err).To(BeNil())
+		})
+
+		It("reads the neighbor table without raw sockets", func() {
+			r := NewARPPing([]config.Node{gateway}, RunnerConfig{}).(*arpPing)
+			r.checkOnce.Do(func() {})
+			r.noRawSocket.Store(os.ErrPermission)
+			start := time.Now()
+			fields := resultFields{}
+			result, err := r.arpPingFunc(gateway, fields)
+			Expect(err).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", arpPingTimeout))
+			parsed := resultparse.Parse(result).(*resultparse.ARPPing)
+			Expect(parsed.Source).To(Equal(resultparse.NeighborSourceKernel))
+			Expect(parsed.MAC).NotTo(BeEmpty())
+			Expect(fields).To(Equal(resultFields{ResultFieldMAC: parsed.MAC}))
+			Expect(r.Description()).To(ContainSubstring("reading neighbor table"))
+		})
+	})
+})
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+const (
+	// DropReasonBufferFull is the reason of an observation dropped immediately because the observation channel is full.
+	DropReasonBufferFull = "bufferFull"
+	// DropReasonSendTimeout is the reason of an observation dropped because the observation channel was still full after the timeout.
+	DropReasonSendTimeout = "sendTimeout"
+)
+
+// Backpressure defines how the observations of the job runs are forwarded if the observation channel is full.
+// A run waits at most Timeout for free buffer space, the observation is dropped afterwards.
+type Backpressure struct {
+	// Timeout is the maximum time to wait for free buffer space. If zero, the observation is dropped immediately.
+	Timeout time.Duration
+	// OnFull is called if the channel is full when sending an observation (optional).
+	OnFull func(obs *nwpd.Observation)
+	// OnDrop is called for each dropped observation with one of the DropReason constants (optional).
+	OnDrop func(obs *nwpd.Observation, reason string)
+}
+
+// Send forwards the observation to the channel and returns false if it has been dropped.
+// If the backpressure is nil, it blocks until the observation has been accepted.
+func (b *Backpressure) Send(ch chan<- *nwpd.Observation, obs *nwpd.Observation) bool {
+	if b == nil {
+		ch <- obs
+		return true
+	}
+	select {
+	case ch <- obs:
+		return true
+	default:
+	}
+	if b.OnFull != nil {
+		b.OnFull(obs)
+	}
+	reason := DropReasonBufferFull
+	if b.Timeout > 0 {
+		timer := time.NewTimer(b.Timeout)
+		defer timer.Stop()
+		select {
+		case ch <- obs:
+			return true
+		case <-timer.C:
+		}
+		reason = DropReasonSendTimeout
+	}
+	if b.OnDrop != nil {
+		b.OnDrop(obs, reason)
+	}
+	return false
+}
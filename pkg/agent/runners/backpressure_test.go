@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("backpressure", func() {
+	var (
+		ch            chan *nwpd.Observation
+		full, dropped int
+		reason        string
+		bp            *Backpressure
+	)
+
+	BeforeEach(func() {
+		ch = make(chan *nwpd.Observation, 1)
+		full, dropped, reason = 0, 0, ""
+		bp = &Backpressure{
+			OnFull: func(_ *nwpd.Observation) { full++ },
+			OnDrop: func(_ *nwpd.Observation, r string) {
+				dropped++
+				reason = r
+			},
+		}
+	})
+
+	It("forwards without waiting if the channel has free space", func() {
+		Expect(bp.Send(ch, &nwpd.Observation{})).To(BeTrue())
+		Expect(full).To(Equal(0))
+		Expect(dropped).To(Equal(0))
+	})
+
+	It("drops immediately if the timeout is zero", func() {
+		ch <- &nwpd.Observation{}
+		Expect(bp.Send(ch, &nwpd.Observation{})).To(BeFalse())
+		Expect(full).To(Equal(1))
+		Expect(dropped).To(Equal(1))
+		Expect(reason).To(Equal(DropReasonBufferFull))
+	})
+
+	It("drops after the timeout", func() {
+		bp.Timeout = 50 * time.Millisecond
+		ch <- &nwpd.Observation{}
+		start := time.Now()
+		Expect(bp.Send(ch, &nwpd.Observation{})).To(BeFalse())
+		Expect(time.Since(start)).To(BeNumerically(">=", bp.Timeout))
+		Expect(full).To(Equal(1))
+		Expect(dropped).To(Equal(1))
+		Expect(reason).To(Equal(DropReasonSendTimeout))
+	})
+
+	It("forwards if free space becomes available within the timeout", func() {
+		bp.Timeout = 5 * time.Second
+		ch <- &nwpd.Observation{}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			<-ch
+		}()
+		Expect(bp.Send(ch, &nwpd.Observation{})).To(BeTrue())
+		Expect(full).To(Equal(1))
+		Expect(dropped).To(Equal(0))
+	})
+
+	It("blocks without backpressure", func() {
+		var nilBp *Backpressure
+		ch <- &nwpd.Observation{}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			<-ch
+		}()
+		Expect(nilBp.Send(ch, &nwpd.Observation{})).To(BeTrue())
+	})
+})
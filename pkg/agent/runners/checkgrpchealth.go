@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type checkGRPCHealthArgs struct {
+	runnerArgs *runnerArgs
+	host       string
+	port       int
+	tls        bool
+	service    string
+}
+
+func (a *checkGRPCHealthArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if a.host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if a.port <= 0 || a.port > 65535 {
+		return fmt.Errorf("invalid port %d", a.port)
+	}
+
+	endpoints := []config.Endpoint{{Hostname: a.host, Port: a.port}}
+	options := &GRPCHealthOptions{Service: a.service, TLS: a.tls}
+	config := a.runnerArgs.prepareConfig()
+	config.ExternalDestinations = true
+	if r := NewCheckGRPCHealth(endpoints, options, config); r != nil {
+		a.runnerArgs.runner = r
+	}
+	return nil
+}
+
+func createCheckGRPCHealthCmd(ra *runnerArgs) *cobra.Command {
+	a := &checkGRPCHealthArgs{runnerArgs: ra}
+	cmd := &cobra.Command{
+		Use:   "checkGRPCHealth",
+		Short: "calls the gRPC health checking protocol of the given host and port",
+		RunE:  a.createRunner,
+	}
+	cmd.Flags().StringVar(&a.host, "host", "", "hostname or IP address of the gRPC server.")
+	cmd.Flags().IntVar(&a.port, "port", 0, "port of the gRPC server.")
+	cmd.Flags().BoolVar(&a.tls, "tls", false, "uses TLS (without verification of the server certificate).")
+	cmd.Flags().StringVar(&a.service, "service", "", "service name to check (overall server health if not specified).")
+	return cmd
+}
+
+// GRPCHealthOptions are optional settings for the gRPC health checks.
+type GRPCHealthOptions struct {
+	// Service is the service name sent in the health check request.
+	Service string
+	// TLS if true, the connection uses TLS.
+	TLS bool
+}
+
+// NewCheckGRPCHealth creates a runner calling `grpc.health.v1.Health/Check` on the given endpoints.
+func NewCheckGRPCHealth(endpoints []config.Endpoint, options *GRPCHealthOptions, rconfig RunnerConfig) Runner {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	if options == nil {
+		options = &GRPCHealthOptions{}
+	}
+	return &checkGRPCHealth{
+		robinRound: robinRound[config.Endpoint]{
+			itemsName: "endpoints",
+			items:     config.CloneAndShuffle(endpoints),
+			runFunc:   options.checkGRPCHealthFunc,
+			config:    rconfig,
+		},
+		options: options,
+	}
+}
+
+type checkGRPCHealth struct {
+	robinRound[config.Endpoint]
+	options *GRPCHealthOptions
+}
+
+var _ Runner = &checkGRPCHealth{}
+
+func (r *checkGRPCHealth) TestData() any {
+	return []any{r.items, r.options}
+}
+
+const (
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+	grpcHealthTimeout   = 10 * time.Second
+	// maxGRPCMessageLength is the maximum accepted size of the health check response message.
+	maxGRPCMessageLength = 4096
+)
+
+// grpcServingStatus are the names of the `grpc.health.v1.HealthCheckResponse.ServingStatus` values.
+var grpcServingStatus = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+func (o *GRPCHealthOptions) checkGRPCHealthFunc(endpoint config.Endpoint, fields resultFields) (string, error) {
+	tr := &http2.Transport{}
+	scheme := "http"
+	if o.TLS {
+		scheme = "https"
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- connection check only, no sensitive data
+	} else {
+		// plaintext HTTP/2 with prior knowledge (h2c)
+		tr.AllowHTTP = true
+		tr.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}
+	}
+	defer tr.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(context.Background(), grpcHealthTimeout)
+	defer cancel()
+	url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(endpoint.Hostname, strconv.Itoa(endpoint.Port)), grpcHealthCheckPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encodeGRPCHealthCheckRequest(o.Service)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	fields.set(ResultFieldHTTPStatus, resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New((&resultparse.GRPCHealth{Common: resultparse.Common{Reason: "unexpected HTTP status"}, HTTPStatus: resp.StatusCode}).Text())
+	}
+	msg, readErr := readGRPCMessage(resp.Body)
+	// the status is sent as trailer, or as header for responses without message
+	grpcStatus := resp.Trailer.Get("Grpc-Status")
+	grpcMessage := resp.Trailer.Get("Grpc-Message")
+	if grpcStatus == "" {
+		grpcStatus = resp.Header.Get("Grpc-Status")
+		grpcMessage = resp.Header.Get("Grpc-Message")
+	}
+	if grpcStatus != "" {
+		fields.set(ResultFieldGRPCStatus, grpcStatus)
+	}
+	if grpcStatus != "" && grpcStatus != "0" {
+		return "", errors.New((&resultparse.GRPCHealth{Common: resultparse.Common{Reason: "grpc error"}, GRPCStatus: grpcStatus, Message: grpcMessage}).Text())
+	}
+	if readErr != nil {
+		return "", readErr
+	}
+	status, err := decodeGRPCHealthCheckResponse(msg)
+	if err != nil {
+		return "", err
+	}
+	name, ok := grpcServingStatus[status]
+	if !ok {
+		name = strconv.FormatUint(status, 10)
+	}
+	fields.set(ResultFieldServingStatus, name)
+	result := &resultparse.GRPCHealth{ServingStatus: name}
+	if status != 1 {
+		result.Reason = "not serving"
+		return "", errors.New(result.Text())
+	}
+	return result.Text(), nil
+}
+
+// encodeGRPCHealthCheckRequest returns the length-prefixed message `grpc.health.v1.HealthCheckRequest`.
+func encodeGRPCHealthCheckRequest(service string) []byte {
+	var msg []byte
+	if service != "" {
+		msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+		msg = protowire.AppendString(msg, service)
+	}
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg))) // #nosec G115 -- length is small
+	return append(frame, msg...)
+}
+
+// readGRPCMessage reads a single length-prefixed uncompressed gRPC message.
+func readGRPCMessage(body io.Reader) ([]byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(body, header); err != nil {
+		return nil, fmt.Errorf("reading response message failed: %s", err)
+	}
+	if header[0] != 0 {
+		return nil, fmt.Errorf("compressed response message not supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxGRPCMessageLength {
+		return nil, fmt.Errorf("response message too large: %d bytes", length)
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(body, msg); err != nil {
+		return nil, fmt.Errorf("reading response message failed: %s", err)
+	}
+	// read to the end to receive the trailers
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxGRPCMessageLength))
+	return msg, nil
+}
+
+// decodeGRPCHealthCheckResponse returns the status field of the message `grpc.health.v1.HealthCheckResponse`.
+func decodeGRPCHealthCheckResponse(msg []byte) (uint64, error) {
+	var status uint64
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return 0, fmt.Errorf("invalid response message: %s", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+		if num == 1 && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(msg)
+			if n < 0 {
+				return 0, fmt.Errorf("invalid response message: %s", protowire.ParseError(n))
+			}
+			status = v
+			msg = msg[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, msg)
+		if n < 0 {
+			return 0, fmt.Errorf("invalid response message: %s", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+	}
+	return status, nil
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var _ = Describe("checkGRPCHealth", func() {
+	// statusByService maps the requested service to the serving status, missing services are answered with gRPC status NOT_FOUND
+	statusByService := map[string]uint64{"": 1, "down": 2}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer GinkgoRecover()
+		Expect(r.URL.Path).To(Equal(grpcHealthCheckPath))
+		Expect(r.Header.Get("Content-Type")).To(Equal("application/grpc"))
+		body, err := io.ReadAll(r.Body)
+		Expect(err).To(BeNil())
+		Expect(len(body)).To(BeNumerically(">=", 5))
+		service := ""
+		if msg := body[5:]; len(msg) > 0 {
+			_, _, n := protowire.ConsumeTag(msg)
+			service, _ = protowire.ConsumeString(msg[n:])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		status, ok := statusByService[service]
+		if !ok {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		msg := protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), status)
+		frame := make([]byte, 5)
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(msg))) // #nosec G115 -- test only
+		_, _ = w.Write(append(frame, msg...))
+		w.Header().Set("Grpc-Status", "0")
+	})
+
+	endpointOf := func(server *httptest.Server) config.Endpoint {
+		u, err := url.Parse(server.URL)
+		Expect(err).To(BeNil())
+		port, err := strconv.Atoi(u.Port())
+		Expect(err).To(BeNil())
+		return config.Endpoint{Hostname: u.Hostname(), Port: port}
+	}
+
+	Describe("plaintext", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("reports serving", func() {
+			options := &GRPCHealthOptions{}
+			result, err := options.checkGRPCHealthFunc(endpointOf(server), resultFields{})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal("servingStatus=SERVING"))
+		})
+
+		It("fails if service is not serving", func() {
+			options := &GRPCHealthOptions{Service: "down"}
+			_, err := options.checkGRPCHealthFunc(endpointOf(server), resultFields{})
+			Expect(err).To(MatchError("not serving: servingStatus=NOT_SERVING"))
+		})
+
+		It("fails on gRPC error status", func() {
+			options := &GRPCHealthOptions{Service: "unknown"}
+			_, err := options.checkGRPCHealthFunc(endpointOf(server), resultFields{})
+			Expect(err).To(MatchError(`grpc error: grpcStatus=5 message="unknown service"`))
+		})
+	})
+
+	It("supports TLS", func() {
+		server := httptest.NewUnstartedServer(handler)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		options := &GRPCHealthOptions{TLS: true}
+		result, err := options.checkGRPCHealthFunc(endpointOf(server), resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("servingStatus=SERVING"))
+	})
+})
@@ -6,13 +6,17 @@ package runners
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/common"
 	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
 
 	"github.com/spf13/cobra"
 )
@@ -22,24 +26,48 @@ type checkHTTPSGetArgs struct {
 	internalKAPI bool
 	externalKAPI bool
 	endpoints    []string
+	headers      []string
+	expectStatus []int
+	maxRedirects int
 }
 
 func (a *checkHTTPSGetArgs) createRunner(_ *cobra.Command, _ []string) error {
+	options := &HTTPSGetOptions{ExpectedStatus: a.expectStatus}
+	if a.maxRedirects >= 0 {
+		maxRedirects := a.maxRedirects
+		options.MaxRedirects = &maxRedirects
+	}
+	for _, h := range a.headers {
+		parts, ok := splitArg(h, ":", 2, 2)
+		if !ok || strings.TrimSpace(parts[0]) == "" {
+			return fmt.Errorf("invalid header %q (expected format '<key>: <value>')", h)
+		}
+		if options.Headers == nil {
+			options.Headers = http.Header{}
+		}
+		options.Headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	for _, status := range a.expectStatus {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("invalid expected status %d", status)
+		}
+	}
+
 	allowEmpty := false
 	var endpoints []config.Endpoint
 	switch {
 	case len(a.endpoints) > 0:
 		for _, ep := range a.endpoints {
-			parts := strings.SplitN(ep, ":", 2)
-			if len(parts) != 1 && len(parts) != 2 {
+			parts, ok := splitArg(ep, ":", 1, 2)
+			if !ok {
 				return fmt.Errorf("invalid endpoint %s", ep)
 			}
 			port := 443
 			if len(parts) == 2 {
 				var err error
-				port, err = strconv.Atoi(parts[1])
+				port, err = parsePort(parts[1])
 				if err != nil {
-					return fmt.Errorf("invalid endpoint port %s", parts[1])
+					return err
 				}
 			}
 			endpoints = append(endpoints, config.Endpoint{
@@ -66,7 +94,8 @@ func (a *checkHTTPSGetArgs) createRunner(_ *cobra.Command, _ []string) error {
 	}
 
 	config := a.runnerArgs.prepareConfig()
-	if r := NewCheckHTTPSGet(endpoints, config); r != nil {
+	config.ExternalDestinations = !a.internalKAPI
+	if r := NewCheckHTTPSGet(endpoints, options, config); r != nil {
 		a.runnerArgs.runner = r
 	}
 	return nil
@@ -82,40 +111,133 @@ func createCheckHTTPSGetArgs(ra *runnerArgs) *cobra.Command {
 	cmd.Flags().StringSliceVar(&a.endpoints, "endpoints", nil, "endpoints in format <hostname>[:<port>].")
 	cmd.Flags().BoolVar(&a.internalKAPI, "endpoint-internal-kube-apiserver", false, "uses known internal endpoint of kube-apiserver.")
 	cmd.Flags().BoolVar(&a.externalKAPI, "endpoint-external-kube-apiserver", false, "uses known external endpoint of kube-apiserver.")
+	cmd.Flags().StringArrayVar(&a.headers, "header", nil, "additional request header in format '<key>: <value>' (repeatable).")
+	cmd.Flags().IntSliceVar(&a.expectStatus, "expect-status", nil, "allowed response status codes (any status if not specified).")
+	cmd.Flags().IntVar(&a.maxRedirects, "max-redirects", -1, "maximum number of redirects to follow, 0 fails on redirect responses (default behaviour of Go HTTP client if not specified).")
 	return cmd
 }
 
-func NewCheckHTTPSGet(endpoints []config.Endpoint, rconfig RunnerConfig) Runner {
+// HTTPSGetOptions are optional settings for the HTTPS Get requests.
+type HTTPSGetOptions struct {
+	// Headers are additional request headers.
+	Headers http.Header
+	// ExpectedStatus are the allowed response status codes. Any status is allowed if empty.
+	ExpectedStatus []int
+	// MaxRedirects is the maximum number of redirects to follow. If 0, a redirect response fails the check.
+	MaxRedirects *int
+}
+
+func NewCheckHTTPSGet(endpoints []config.Endpoint, options *HTTPSGetOptions, rconfig RunnerConfig) Runner {
 	if len(endpoints) == 0 {
 		return nil
 	}
+	if options == nil {
+		options = &HTTPSGetOptions{}
+	}
 	return &checkHTTPSGet{
-		robinRound[config.Endpoint]{
+		robinRound: robinRound[config.Endpoint]{
 			itemsName: "endpoints",
 			items:     config.CloneAndShuffle(endpoints),
-			runFunc:   checkHTTPSGetFunc,
+			runFunc:   options.checkHTTPSGetFunc,
 			config:    rconfig,
 		},
+		options: options,
 	}
 }
 
 type checkHTTPSGet struct {
 	robinRound[config.Endpoint]
+	options *HTTPSGetOptions
 }
 
 var _ Runner = &checkHTTPSGet{}
 
-func checkHTTPSGetFunc(endpoint config.Endpoint) (string, error) {
+func (r *checkHTTPSGet) TestData() any {
+	return []any{r.items, r.options}
+}
+
+// maxBodySnippetLength is the maximum number of bytes of the response body reported in the result.
+const maxBodySnippetLength = 100
+
+func (o *HTTPSGetOptions) checkHTTPSGetFunc(endpoint config.Endpoint, fields resultFields) (string, error) {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402 -- connection check only, no sensitive data
 	}
-	client := &http.Client{Transport: tr}
+	var redirects []string
+	client := &http.Client{
+		Transport: tr,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if o.MaxRedirects != nil && len(via) > *o.MaxRedirects {
+				if *o.MaxRedirects == 0 {
+					return http.ErrUseLastResponse
+				}
+				return fmt.Errorf("stopped after %d redirects", *o.MaxRedirects)
+			}
+			if o.MaxRedirects == nil && len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			redirects = append(redirects, req.URL.String())
+			return nil
+		},
+	}
 	url := fmt.Sprintf("https://%s:%d", endpoint.Hostname, endpoint.Port)
-	resp, err := client.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
+	for key, values := range o.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if host := o.Headers.Get("Host"); host != "" {
+		req.Host = host
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if len(redirects) > 0 {
+			return "", errors.New((&resultparse.HTTPSGet{Common: resultparse.Common{Reason: err.Error()}, Redirects: redirects}).Text())
+		}
+		return "", err
+	}
 	defer resp.Body.Close()
 
-	return resp.Status, nil
+	fields.set(ResultFieldHTTPStatus, resp.StatusCode)
+	if len(redirects) > 0 {
+		fields.set(ResultFieldRedirects, len(redirects))
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		fields.set(ResultFieldCertDaysRemaining, int(time.Until(resp.TLS.PeerCertificates[0].NotAfter).Hours()/24))
+	}
+	result := &resultparse.HTTPSGet{Status: resp.StatusCode, Body: readBodySnippet(resp.Body)}
+	if len(redirects) > 0 {
+		result.Final = resp.Request.URL.String()
+		result.Redirects = redirects
+	}
+	if o.MaxRedirects != nil && *o.MaxRedirects == 0 && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		result.Reason = "redirect not allowed"
+		result.Location = resp.Header.Get("Location")
+		return "", errors.New(result.Text())
+	}
+	if len(o.ExpectedStatus) > 0 && !slices.Contains(o.ExpectedStatus, resp.StatusCode) {
+		result.Reason = "unexpected status"
+		return "", errors.New(result.Text())
+	}
+	return result.Text(), nil
+}
+
+func readBodySnippet(body io.Reader) string {
+	buf, err := io.ReadAll(io.LimitReader(body, maxBodySnippetLength+1))
+	if err != nil && len(buf) == 0 {
+		return ""
+	}
+	return snippetOf(buf)
+}
+
+// snippetOf returns the trimmed data truncated to maxBodySnippetLength bytes.
+func snippetOf(buf []byte) string {
+	if len(buf) > maxBodySnippetLength {
+		return strings.TrimSpace(string(buf[:maxBodySnippetLength])) + "..."
+	}
+	return strings.TrimSpace(string(buf))
 }
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("checkHTTPSGet", func() {
+	var (
+		server   *httptest.Server
+		endpoint config.Endpoint
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Test") != "yes" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte("not ready"))
+				return
+			}
+			_, _ = w.Write([]byte(strings.Repeat("x", 200)))
+		}))
+		u, err := url.Parse(server.URL)
+		Expect(err).To(BeNil())
+		port, err := strconv.Atoi(u.Port())
+		Expect(err).To(BeNil())
+		endpoint = config.Endpoint{Hostname: u.Hostname(), Port: port}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports status and body snippet", func() {
+		options := &HTTPSGetOptions{}
+		fields := resultFields{}
+		result, err := options.checkHTTPSGetFunc(endpoint, fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(`status=503 body="not ready"`))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldHTTPStatus, "503"))
+		Expect(fields).To(HaveKey(ResultFieldCertDaysRemaining))
+	})
+
+	It("fails on unexpected status", func() {
+		options := &HTTPSGetOptions{ExpectedStatus: []int{200}}
+		_, err := options.checkHTTPSGetFunc(endpoint, resultFields{})
+		Expect(err).To(MatchError(`unexpected status: status=503 body="not ready"`))
+	})
+
+	It("sends headers and truncates body", func() {
+		options := &HTTPSGetOptions{Headers: http.Header{"X-Test": {"yes"}}, ExpectedStatus: []int{200, 204}}
+		result, err := options.checkHTTPSGetFunc(endpoint, resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(`status=200 body="` + strings.Repeat("x", maxBodySnippetLength) + `..."`))
+	})
+
+	Describe("redirects", func() {
+		var redirectServer *httptest.Server
+
+		BeforeEach(func() {
+			redirectServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/":
+					http.Redirect(w, r, "/a", http.StatusFound)
+				case "/a":
+					http.Redirect(w, r, "/b", http.StatusFound)
+				default:
+					_, _ = w.Write([]byte("done"))
+				}
+			}))
+			u, err := url.Parse(redirectServer.URL)
+			Expect(err).To(BeNil())
+			port, err := strconv.Atoi(u.Port())
+			Expect(err).To(BeNil())
+			endpoint = config.Endpoint{Hostname: u.Hostname(), Port: port}
+		})
+
+		AfterEach(func() {
+			redirectServer.Close()
+		})
+
+		It("records the redirect chain", func() {
+			options := &HTTPSGetOptions{}
+			result, err := options.checkHTTPSGetFunc(endpoint, resultFields{})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(fmt.Sprintf(`status=200 body="done" final=%[1]s/b redirect=%[1]s/a redirect=%[1]s/b`, redirectServer.URL)))
+		})
+
+		It("fails if redirects are forbidden", func() {
+			options := &HTTPSGetOptions{MaxRedirects: ptr.To(0)}
+			_, err := options.checkHTTPSGetFunc(endpoint, resultFields{})
+			Expect(err).To(MatchError(ContainSubstring("redirect not allowed: status=302")))
+			Expect(err).To(MatchError(ContainSubstring("location=/a")))
+		})
+
+		It("fails if too many redirects", func() {
+			options := &HTTPSGetOptions{MaxRedirects: ptr.To(1)}
+			_, err := options.checkHTTPSGetFunc(endpoint, resultFields{})
+			Expect(err).To(MatchError(ContainSubstring("stopped after 1 redirects")))
+			Expect(err).To(MatchError(HaveSuffix(": redirect=" + redirectServer.URL + "/a")))
+		})
+	})
+})
@@ -5,13 +5,20 @@
 package runners
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/gardener/network-problem-detector/pkg/common"
 	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
 
 	"github.com/spf13/cobra"
 )
@@ -22,22 +29,64 @@ type checkTCPPortArgs struct {
 	podDS        bool
 	internalKAPI bool
 	externalKAPI bool
+	services     bool
+	verifyPodUID bool
 	endpoints    []string
+	cidrs        []string
+	external     bool
+	send         string
+	expect       string
+	readTimeout  time.Duration
 }
 
+// defaultBannerReadTimeout is the default maximum time for receiving the response of the banner check.
+const defaultBannerReadTimeout = 5 * time.Second
+
 func (a *checkTCPPortArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if err := a.runnerArgs.validateSampling(); err != nil {
+		return err
+	}
+	if a.nodePort < 0 || a.nodePort > 65535 {
+		return fmt.Errorf("invalid node port %d", a.nodePort)
+	}
+	if a.external && a.nodePort == 0 {
+		return fmt.Errorf("option --external-address requires --node-port")
+	}
+	banner, err := a.bannerOptions()
+	if err != nil {
+		return err
+	}
+	if a.verifyPodUID {
+		if banner != nil {
+			return fmt.Errorf("options --send and --expect cannot be combined with --verify-pod-uid")
+		}
+		if !a.podDS {
+			return fmt.Errorf("option --verify-pod-uid requires --endpoints-of-pod-ds")
+		}
+		config := a.runnerArgs.prepareConfig()
+		if r := NewCheckPodIdentity(a.runnerArgs.clusterCfg.PodEndpoints, config); r != nil {
+			a.runnerArgs.runner = r
+		}
+		return nil
+	}
+
 	allowEmpty := false
 	var endpoints []config.Endpoint
 	switch {
-	case len(a.endpoints) > 0:
+	case len(a.endpoints) > 0 || len(a.cidrs) > 0:
+		cidrEndpoints, err := a.cidrEndpoints()
+		if err != nil {
+			return err
+		}
+		endpoints = append(endpoints, cidrEndpoints...)
 		for _, ep := range a.endpoints {
-			parts := strings.SplitN(ep, ":", 3)
-			if len(parts) != 3 {
+			parts, ok := splitArg(ep, ":", 3, 3)
+			if !ok {
 				return fmt.Errorf("invalid endpoint %s", ep)
 			}
-			port, err := strconv.Atoi(parts[2])
+			port, err := parsePort(parts[2])
 			if err != nil {
-				return fmt.Errorf("invalid endpoint port %s", parts[2])
+				return err
 			}
 			endpoints = append(endpoints, config.Endpoint{
 				Hostname: parts[0],
@@ -47,7 +96,11 @@ func (a *checkTCPPortArgs) createRunner(_ *cobra.Command, _ []string) error {
 		}
 	case a.nodePort != 0:
 		allowEmpty = true
-		for _, n := range a.runnerArgs.clusterCfg.Nodes {
+		nodes := a.runnerArgs.clusterCfg.Nodes
+		if a.external {
+			nodes = externalAddressNodes(nodes)
+		}
+		for _, n := range nodes {
 			endpoints = append(endpoints, config.Endpoint{
 				Hostname: n.Hostname,
 				IP:       n.InternalIP,
@@ -73,6 +126,15 @@ func (a *checkTCPPortArgs) createRunner(_ *cobra.Command, _ []string) error {
 		if pe := a.runnerArgs.clusterCfg.KubeAPIServer; pe != nil {
 			endpoints = append(endpoints, *pe)
 		}
+	case a.services:
+		allowEmpty = true
+		for _, se := range a.runnerArgs.clusterCfg.Services {
+			endpoints = append(endpoints, config.Endpoint{
+				Hostname: se.DestHost(),
+				IP:       se.ClusterIP,
+				Port:     se.Port,
+			})
+		}
 	}
 
 	if !allowEmpty && len(endpoints) == 0 {
@@ -80,12 +142,64 @@ func (a *checkTCPPortArgs) createRunner(_ *cobra.Command, _ []string) error {
 	}
 
 	config := a.runnerArgs.prepareConfig()
-	if r := NewCheckTCPPort(endpoints, config); r != nil {
+	config.ExternalDestinations = len(a.endpoints) > 0 || a.externalKAPI
+	if r := NewCheckTCPPort(endpoints, banner, config); r != nil {
 		a.runnerArgs.runner = r
 	}
 	return nil
 }
 
+// bannerOptions returns the options of the banner check or nil if neither --send nor --expect is given.
+func (a *checkTCPPortArgs) bannerOptions() (*TCPBannerOptions, error) {
+	if a.send == "" && a.expect == "" {
+		return nil, nil
+	}
+	if a.readTimeout <= 0 {
+		return nil, fmt.Errorf("invalid read timeout %s, must be positive", a.readTimeout)
+	}
+	// escape sequences like \r\n are interpreted, as many protocols require them
+	send, err := strconv.Unquote(`"` + strings.ReplaceAll(a.send, `"`, `\"`) + `"`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid send string %q: %s", a.send, err)
+	}
+	options := &TCPBannerOptions{Send: send, ReadTimeout: a.readTimeout}
+	if a.expect != "" {
+		if options.Expect, err = regexp.Compile(a.expect); err != nil {
+			return nil, fmt.Errorf("invalid expect pattern %q: %s", a.expect, err)
+		}
+	}
+	return options, nil
+}
+
+// cidrEndpoints returns an endpoint for each address of the CIDR destinations. The address is used as hostname.
+func (a *checkTCPPortArgs) cidrEndpoints() ([]config.Endpoint, error) {
+	var (
+		cidrs []string
+		ports []int
+	)
+	for _, value := range a.cidrs {
+		cidr, port, err := splitCIDRPort(value)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+		ports = append(ports, port)
+	}
+	maxAddresses := maxCIDRAddressesOf(a.runnerArgs.config)
+	var endpoints []config.Endpoint
+	for i, cidr := range cidrs {
+		// expanded one by one for the ports, but limited in total
+		addresses, err := expandCIDRs([]string{cidr}, maxAddresses-len(endpoints))
+		if err != nil {
+			return nil, fmt.Errorf("%s (max %d addresses per job)", err, maxAddresses)
+		}
+		for _, addr := range addresses {
+			endpoints = append(endpoints, config.Endpoint{Hostname: addr, IP: addr, Port: ports[i]})
+		}
+	}
+	return endpoints, nil
+}
+
 func createCheckTCPPortCmd(ra *runnerArgs) *cobra.Command {
 	a := &checkTCPPortArgs{runnerArgs: ra}
 	cmd := &cobra.Command{
@@ -94,39 +208,224 @@ func createCheckTCPPortCmd(ra *runnerArgs) *cobra.Command {
 		RunE:  a.createRunner,
 	}
 	cmd.Flags().StringSliceVar(&a.endpoints, "endpoints", nil, "endpoints in format <hostname>:<ip>:<port>.")
+	cmd.Flags().StringSliceVar(&a.cidrs, "cidr", nil, "CIDR destinations in format <cidr>:<port>, each address of the CIDR is probed.")
 	cmd.Flags().IntVar(&a.nodePort, "node-port", 0, "port on nodes as alternative to specifying endpoints.")
+	cmd.Flags().BoolVar(&a.external, "external-address", false, "uses the external addresses of the nodes with '--node-port'. Nodes without external address are skipped.")
 	cmd.Flags().BoolVar(&a.podDS, "endpoints-of-pod-ds", false, "uses known pod endpoints of the 'nwpd-agent-pod-net' service.")
 	cmd.Flags().BoolVar(&a.internalKAPI, "endpoint-internal-kube-apiserver", false, "uses known internal endpoint of kube-apiserver.")
 	cmd.Flags().BoolVar(&a.externalKAPI, "endpoint-external-kube-apiserver", false, "uses known external endpoint of kube-apiserver.")
+	cmd.Flags().BoolVar(&a.services, "services", false, "uses the cluster IPs of the services selected on deployment.")
+	cmd.Flags().BoolVar(&a.verifyPodUID, "verify-pod-uid", false, "requires the agent pods to echo their pod UID to detect stale pod endpoints (only with '--endpoints-of-pod-ds').")
+	cmd.Flags().StringVar(&a.send, "send", "", "string written after connecting, escape sequences like '\\r\\n' are interpreted.")
+	cmd.Flags().StringVar(&a.expect, "expect", "", "regular expression the response must match, any response is accepted with '--send' only.")
+	cmd.Flags().DurationVar(&a.readTimeout, "read-timeout", defaultBannerReadTimeout, "maximum time for receiving the response with '--send' or '--expect'.")
+	addSamplingFlags(cmd, ra)
+	addSourceFlags(cmd, ra)
+	addDSCPFlag(cmd, ra)
 	return cmd
 }
 
-func NewCheckTCPPort(endpoints []config.Endpoint, rconfig RunnerConfig) Runner {
+// TCPBannerOptions define the application-layer check of a TCP connection, so that a service accepting connections
+// without ever responding is detected.
+type TCPBannerOptions struct {
+	// Send is written to the connection after connecting (optional).
+	Send string
+	// Expect must match the received data. If nil, any received data is accepted.
+	Expect *regexp.Regexp
+	// ReadTimeout is the maximum time for sending and receiving the response.
+	ReadTimeout time.Duration
+}
+
+// maxBannerLength is the maximum number of bytes received for matching the expected response.
+const maxBannerLength = 4096
+
+// NewCheckTCPPort creates a runner connecting to the endpoints. If the banner options are given, a connection must also
+// receive the expected response.
+func NewCheckTCPPort(endpoints []config.Endpoint, banner *TCPBannerOptions, rconfig RunnerConfig) Runner {
 	if len(endpoints) == 0 {
 		return nil
 	}
 	return &checkTCPPort{
-		robinRound[config.Endpoint]{
+		robinRound: robinRound[config.Endpoint]{
 			itemsName: "endpoints",
 			items:     config.CloneAndShuffle(endpoints),
-			runFunc:   checkTCPPortFunc,
+			runFunc:   checkTCPPortFuncOf(rconfig, banner),
 			config:    rconfig,
 		},
+		banner: banner,
 	}
 }
 
 type checkTCPPort struct {
 	robinRound[config.Endpoint]
+	banner *TCPBannerOptions
 }
 
 var _ Runner = &checkTCPPort{}
 
-func checkTCPPortFunc(endpoint config.Endpoint) (string, error) {
-	addr := fmt.Sprintf("%s:%d", endpoint.IP, endpoint.Port)
-	conn, err := net.DialTimeout("tcp", addr, 30*time.Second)
-	if err != nil {
-		return "", err
+func (r *checkTCPPort) TestData() any {
+	return []any{r.items, r.banner}
+}
+
+// checkTCPPortFuncOf returns the run function connecting from the source address and with the marking of the runner config.
+// If the banner options are given, the response is checked before the connection is closed.
+func checkTCPPortFuncOf(rconfig RunnerConfig, banner *TCPBannerOptions) func(config.Endpoint, resultFields) (string, error) {
+	dialer := probeDialerOf("tcp", rconfig, 30*time.Second)
+	return func(endpoint config.Endpoint, fields resultFields) (string, error) {
+		addr := net.JoinHostPort(endpoint.IP, strconv.Itoa(endpoint.Port))
+		conn, err := dialer.Dial("tcp", addr)
+		if err != nil {
+			setProbeSocketFields(rconfig, fields)
+			category := FailureCategoryConnectError
+			if isTimeout(err) {
+				category = FailureCategoryConnectTimeout
+			}
+			return "", tcpFailure(fields, &resultparse.TCP{Common: resultparse.Common{Reason: err.Error()}, State: resultparse.TCPStateNotConnected,
+				Source: rconfig.SourceIP, DSCP: rconfig.DSCP}, category)
+		}
+		defer conn.Close()
+		if banner == nil {
+			return tcpConnected(rconfig, fields), nil
+		}
+		setProbeSocketFields(rconfig, fields)
+		return banner.check(conn, fields, &resultparse.TCP{State: resultparse.TCPStateConnected, Source: rconfig.SourceIP, DSCP: rconfig.DSCP})
 	}
-	_ = conn.Close()
-	return "connected", nil
+}
+
+// tcpFailure returns the error of the failed check with the failure category in the result and the result fields.
+func tcpFailure(fields resultFields, result *resultparse.TCP, category string) error {
+	fields.set(ResultFieldFailureCategory, category)
+	result.Failure = category
+	return errors.New(result.Text())
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// check sends the probe string and reads until the response matches, the maximum banner length is reached, or the
+// read timeout has elapsed. The received data is reported as truncated banner of the result.
+func (o *TCPBannerOptions) check(conn net.Conn, fields resultFields, result *resultparse.TCP) (string, error) {
+	_ = conn.SetDeadline(time.Now().Add(o.ReadTimeout))
+	if o.Send != "" {
+		if _, err := io.WriteString(conn, o.Send); err != nil {
+			result.Reason = fmt.Sprintf("send failed: %s", err)
+			return "", tcpFailure(fields, result, readFailureCategory(err))
+		}
+	}
+	var (
+		received []byte
+		buf      = make([]byte, maxBannerLength)
+		err      error
+	)
+	for len(received) < maxBannerLength && err == nil {
+		var n int
+		n, err = conn.Read(buf[:maxBannerLength-len(received)])
+		received = append(received, buf[:n]...)
+		if o.matches(received) {
+			result.Banner = snippetOf(received)
+			return result.Text(), nil
+		}
+	}
+	result.Banner = snippetOf(received)
+	switch {
+	case len(received) > 0:
+		result.Reason = "unexpected response"
+		return "", tcpFailure(fields, result, FailureCategoryUnexpectedResponse)
+	case isTimeout(err):
+		result.Reason = "no response"
+	default:
+		result.Reason = fmt.Sprintf("no response: %s", err)
+	}
+	return "", tcpFailure(fields, result, readFailureCategory(err))
+}
+
+// readFailureCategory returns the failure category of an error after the connection has been established.
+func readFailureCategory(err error) string {
+	if isTimeout(err) {
+		return FailureCategoryReadTimeout
+	}
+	return FailureCategoryReadError
+}
+
+func (o *TCPBannerOptions) matches(received []byte) bool {
+	if o.Expect == nil {
+		return len(received) > 0
+	}
+	return o.Expect.Match(received)
+}
+
+func tcpConnected(rconfig RunnerConfig, fields resultFields) string {
+	setProbeSocketFields(rconfig, fields)
+	return (&resultparse.TCP{State: resultparse.TCPStateConnected, Source: rconfig.SourceIP, DSCP: rconfig.DSCP}).Text()
+}
+
+// setProbeSocketFields sets the result fields of the source address and the marking, if selected by the runner config.
+func setProbeSocketFields(rconfig RunnerConfig, fields resultFields) {
+	if rconfig.SourceIP != "" {
+		fields.set(ResultFieldSourceIP, rconfig.SourceIP)
+	}
+	if rconfig.DSCP != 0 {
+		fields.set(ResultFieldDSCP, rconfig.DSCP)
+	}
+}
+
+// NewCheckPodIdentity creates a runner connecting to the agent pods and verifying their pod UIDs.
+func NewCheckPodIdentity(endpoints []config.PodEndpoint, rconfig RunnerConfig) Runner {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	return &checkPodIdentity{
+		robinRound[config.PodEndpoint]{
+			itemsName: "pod endpoints",
+			items:     config.CloneAndShuffle(endpoints),
+			runFunc:   checkPodIdentityFuncOf(rconfig),
+			config:    rconfig,
+		},
+	}
+}
+
+type checkPodIdentity struct {
+	robinRound[config.PodEndpoint]
+}
+
+var _ Runner = &checkPodIdentity{}
+
+// checkPodIdentityFuncOf returns the run function requesting the pod identity from the source address and with the marking
+// of the runner config.
+func checkPodIdentityFuncOf(rconfig RunnerConfig) func(config.PodEndpoint, resultFields) (string, error) {
+	client := &http.Client{Timeout: 30 * time.Second, Transport: &http.Transport{DialContext: probeDialerOf("tcp", rconfig, 30*time.Second).DialContext}}
+	return func(endpoint config.PodEndpoint, fields resultFields) (string, error) {
+		url := fmt.Sprintf("http://%s%s", net.JoinHostPort(endpoint.PodIP, strconv.Itoa(int(endpoint.Port))), common.PathPodIdentity)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// the agent pod with the expected UID would listen on the port, so the IP has been reused by another pod
+			if endpoint.PodUID != "" && errors.Is(err, syscall.ECONNREFUSED) {
+				return "", staleEndpointErrorOf(rconfig, endpoint, "")
+			}
+			return "", err
+		}
+		_ = resp.Body.Close()
+		fields.set(ResultFieldHTTPStatus, resp.StatusCode)
+		if endpoint.PodUID == "" {
+			return tcpConnected(rconfig, fields), nil
+		}
+		if uid := resp.Header.Get(common.HeaderPodUID); uid != endpoint.PodUID {
+			return "", staleEndpointErrorOf(rconfig, endpoint, uid)
+		}
+		return tcpConnected(rconfig, fields), nil
+	}
+}
+
+// staleEndpointErrorOf returns the error of a pod endpoint answered by another pod than expected. The uid is empty if
+// the connection was refused.
+func staleEndpointErrorOf(rconfig RunnerConfig, endpoint config.PodEndpoint, uid string) error {
+	r := &resultparse.TCP{Common: resultparse.Common{Reason: "stale endpoint"}, Pod: endpoint.Podname, ExpectedUID: endpoint.PodUID, UID: uid,
+		Source: rconfig.SourceIP, DSCP: rconfig.DSCP}
+	return &staleEndpointError{msg: r.Text()}
 }
@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checkPodIdentity", func() {
+	var (
+		server   *httptest.Server
+		endpoint config.PodEndpoint
+	)
+
+	BeforeEach(func() {
+		// the peer pod has UID "uid-1"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == common.PathPodIdentity {
+				w.Header().Set(common.HeaderPodUID, "uid-1")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		u, err := url.Parse(server.URL)
+		Expect(err).To(BeNil())
+		port, err := strconv.Atoi(u.Port())
+		Expect(err).To(BeNil())
+		endpoint = config.PodEndpoint{Nodename: "node1", Podname: "pod1", PodIP: u.Hostname(), Port: int32(port)} // #nosec G115 -- test only
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("succeeds if the pod UID matches", func() {
+		endpoint.PodUID = "uid-1"
+		result, err := checkPodIdentityFuncOf(RunnerConfig{})(endpoint, resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("state=connected"))
+	})
+
+	It("connects from the source address", func() {
+		endpoint.PodUID = "uid-1"
+		fields := resultFields{}
+		result, err := checkPodIdentityFuncOf(RunnerConfig{SourceIP: "127.0.0.1"})(endpoint, fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("state=connected src=127.0.0.1"))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldSourceIP, "127.0.0.1"))
+
+		result, err = checkTCPPortFuncOf(RunnerConfig{SourceIP: "127.0.0.1"}, nil)(config.Endpoint{Hostname: "node1", IP: endpoint.PodIP, Port: int(endpoint.Port)}, resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("state=connected src=127.0.0.1"))
+	})
+
+	It("succeeds without known pod UID", func() {
+		_, err := checkPodIdentityFuncOf(RunnerConfig{})(endpoint, resultFields{})
+		Expect(err).To(BeNil())
+	})
+
+	It("reports a stale endpoint if the IP is reused by another pod", func() {
+		endpoint.PodUID = "uid-old"
+		_, err := checkPodIdentityFuncOf(RunnerConfig{})(endpoint, resultFields{})
+		Expect(err).To(MatchError(`stale endpoint: pod=pod1 expectedUID=uid-old uid=uid-1`))
+		Expect(isStaleEndpoint(err)).To(BeTrue())
+
+		r := NewCheckPodIdentity([]config.PodEndpoint{endpoint}, RunnerConfig{
+			Job:    config.Job{JobID: "test", Retries: 3},
+			Period: time.Minute,
+		})
+		ch := make(chan *nwpd.Observation, 1)
+		r.Run("node2", ch)
+		obs := <-ch
+		Expect(obs.Ok).To(BeFalse())
+		Expect(obs.StaleEndpoint).To(BeTrue())
+		Expect(obs.Result).To(HavePrefix("error: stale endpoint:"))
+	})
+
+	It("reports a stale endpoint if the connection to the reused IP is refused", func() {
+		server.Close()
+		endpoint.PodUID = "uid-1"
+		_, err := checkPodIdentityFuncOf(RunnerConfig{})(endpoint, resultFields{})
+		Expect(err).To(MatchError(`stale endpoint: pod=pod1 expectedUID=uid-1 uid=""`))
+		Expect(isStaleEndpoint(err)).To(BeTrue())
+
+		// without known pod UID a refused connection is a failure
+		endpoint.PodUID = ""
+		_, err = checkPodIdentityFuncOf(RunnerConfig{})(endpoint, resultFields{})
+		Expect(err).To(MatchError(ContainSubstring("connection refused")))
+		Expect(isStaleEndpoint(err)).To(BeFalse())
+	})
+
+	It("reports a failure if the peer does not respond", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.Close()
+			}
+		}()
+		endpoint.PodIP = "127.0.0.1"
+		endpoint.Port = int32(listener.Addr().(*net.TCPAddr).Port) // #nosec G115 -- test only
+		endpoint.PodUID = "uid-1"
+		_, err = checkPodIdentityFuncOf(RunnerConfig{})(endpoint, resultFields{})
+		Expect(err).NotTo(BeNil())
+		Expect(isStaleEndpoint(err)).To(BeFalse())
+	})
+})
+
+var _ = Describe("checkTCPPort banner", func() {
+	var (
+		listener net.Listener
+		endpoint config.Endpoint
+	)
+
+	// serve handles each accepted connection with the handler.
+	serve := func(handler func(conn net.Conn)) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		listener = l
+		addr := l.Addr().(*net.TCPAddr)
+		endpoint = config.Endpoint{Hostname: "server", IP: addr.IP.String(), Port: addr.Port}
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					handler(conn)
+				}()
+			}
+		}()
+	}
+	// pong answers a PING line with +PONG.
+	pong := func(conn net.Conn) {
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err == nil && line == "PING\r\n" {
+			_, _ = conn.Write([]byte("+PONG\r\n"))
+		} else {
+			_, _ = conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+	}
+	// silent accepts the connection but never responds.
+	silent := func(conn net.Conn) {
+		_, _ = io.Copy(io.Discard, conn)
+	}
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	It("succeeds if the response matches", func() {
+		serve(pong)
+		banner := &TCPBannerOptions{Send: "PING\r\n", Expect: regexp.MustCompile(`^\+PONG`), ReadTimeout: time.Second}
+		result, err := checkTCPPortFuncOf(RunnerConfig{}, banner)(endpoint, resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(`state=connected banner="+PONG"`))
+	})
+
+	It("reads the banner of a service speaking first", func() {
+		serve(func(conn net.Conn) {
+			_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			silent(conn)
+		})
+		banner := &TCPBannerOptions{Expect: regexp.MustCompile(`^SSH-2\.0-`), ReadTimeout: time.Second}
+		result, err := checkTCPPortFuncOf(RunnerConfig{}, banner)(endpoint, resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(`state=connected banner="SSH-2.0-OpenSSH_9.6"`))
+	})
+
+	It("fails on an unexpected response", func() {
+		serve(pong)
+		banner := &TCPBannerOptions{Send: "QUIT\r\n", Expect: regexp.MustCompile(`^\+PONG`), ReadTimeout: time.Second}
+		fields := resultFields{}
+		_, err := checkTCPPortFuncOf(RunnerConfig{}, banner)(endpoint, fields)
+		Expect(err).To(MatchError(`unexpected response: state=connected banner="-ERR unknown command" failure=unexpectedResponse`))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldFailureCategory, FailureCategoryUnexpectedResponse))
+	})
+
+	It("fails if a connected service does not respond", func() {
+		serve(silent)
+		banner := &TCPBannerOptions{Send: "PING\r\n", ReadTimeout: 100 * time.Millisecond}
+		start := time.Now()
+		fields := resultFields{}
+		_, err := checkTCPPortFuncOf(RunnerConfig{}, banner)(endpoint, fields)
+		Expect(err).To(MatchError("no response: state=connected failure=readTimeout"))
+		Expect(time.Since(start)).To(BeNumerically(">=", banner.ReadTimeout))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldFailureCategory, FailureCategoryReadTimeout))
+
+		r := NewCheckTCPPort([]config.Endpoint{endpoint}, banner, RunnerConfig{Job: config.Job{JobID: "test"}, Period: time.Minute})
+		ch := make(chan *nwpd.Observation, 1)
+		r.Run("node1", ch)
+		obs := <-ch
+		Expect(obs.Ok).To(BeFalse())
+		Expect(obs.Result).To(Equal("error: no response: state=connected failure=readTimeout"))
+		Expect(obs.ResultFields).To(HaveKeyWithValue(ResultFieldFailureCategory, FailureCategoryReadTimeout))
+	})
+
+	It("fails if the connection is closed without response", func() {
+		serve(func(_ net.Conn) {})
+		banner := &TCPBannerOptions{Expect: regexp.MustCompile(`^SSH-`), ReadTimeout: time.Second}
+		_, err := checkTCPPortFuncOf(RunnerConfig{}, banner)(endpoint, resultFields{})
+		Expect(err).To(MatchError(`no response: EOF: state=connected failure=readError`))
+	})
+
+	It("reports a failure to connect", func() {
+		serve(silent)
+		listener.Close()
+		fields := resultFields{}
+		_, err := checkTCPPortFuncOf(RunnerConfig{}, nil)(endpoint, fields)
+		Expect(err).To(MatchError(And(ContainSubstring("connection refused"), HaveSuffix(": state=notConnected failure=connectError"))))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldFailureCategory, FailureCategoryConnectError))
+	})
+
+	It("distinguishes timeouts of the connect and the read phase", func() {
+		timeout := &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+		Expect(isTimeout(timeout)).To(BeTrue())
+		Expect(isTimeout(io.EOF)).To(BeFalse())
+		Expect(readFailureCategory(timeout)).To(Equal(FailureCategoryReadTimeout))
+		Expect(readFailureCategory(io.EOF)).To(Equal(FailureCategoryReadError))
+		Expect(IsConnectFailure(FailureCategoryConnectTimeout)).To(BeTrue())
+		Expect(IsConnectFailure(FailureCategoryReadTimeout)).To(BeFalse())
+	})
+
+	It("truncates a long banner", func() {
+		serve(func(conn net.Conn) {
+			_, _ = conn.Write([]byte(strings.Repeat("x", 2*maxBodySnippetLength)))
+		})
+		banner := &TCPBannerOptions{Expect: regexp.MustCompile(`y`), ReadTimeout: time.Second}
+		_, err := checkTCPPortFuncOf(RunnerConfig{}, banner)(endpoint, resultFields{})
+		Expect(err).To(MatchError(`unexpected response: state=connected banner="` + strings.Repeat("x", maxBodySnippetLength) + `..." failure=unexpectedResponse`))
+	})
+})
+
+var _ = Describe("checkTCPPort", func() {
+	It("connects to an IPv6 endpoint", func() {
+		l, err := net.Listen("tcp", "[::1]:0")
+		if err != nil {
+			Skip("no IPv6 loopback: " + err.Error())
+		}
+		defer l.Close()
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.Close()
+			}
+		}()
+		endpoint := config.Endpoint{Hostname: "server", IP: "::1", Port: l.Addr().(*net.TCPAddr).Port}
+		result, err := checkTCPPortFuncOf(RunnerConfig{}, nil)(endpoint, resultFields{})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("state=connected"))
+	})
+})
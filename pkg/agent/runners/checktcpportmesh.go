@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/spf13/cobra"
+)
+
+// maxMeshParallelism is the maximum number of peers probed in parallel by a run of a mesh job.
+const maxMeshParallelism = 32
+
+type checkTCPPortMeshArgs struct {
+	runnerArgs *runnerArgs
+	port       int
+	external   bool
+}
+
+func (a *checkTCPPortMeshArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if a.port == 0 {
+		return fmt.Errorf("missing option --port")
+	}
+	if a.port < 0 || a.port > 65535 {
+		return fmt.Errorf("invalid port %d", a.port)
+	}
+	nodes := a.runnerArgs.clusterCfg.Nodes
+	if a.external {
+		nodes = externalAddressNodes(nodes)
+	}
+	var endpoints []config.Endpoint
+	for _, n := range nodes {
+		endpoints = append(endpoints, config.Endpoint{
+			Hostname: n.Hostname,
+			IP:       n.InternalIP,
+			Port:     a.port,
+		})
+	}
+
+	config := a.runnerArgs.prepareConfig()
+	if r := NewCheckTCPPortMesh(endpoints, config); r != nil {
+		a.runnerArgs.runner = r
+	}
+	return nil
+}
+
+func createCheckTCPPortMeshCmd(ra *runnerArgs) *cobra.Command {
+	a := &checkTCPPortMeshArgs{runnerArgs: ra}
+	cmd := &cobra.Command{
+		Use:   "checkTCPPortMesh",
+		Short: "checks connection to TCP port of all nodes on each run",
+		RunE:  a.createRunner,
+	}
+	cmd.Flags().IntVar(&a.port, "port", 0, "port on the nodes.")
+	cmd.Flags().BoolVar(&a.external, "external-address", false, "uses the external addresses of the nodes. Nodes without external address are skipped.")
+	addSourceFlags(cmd, ra)
+	addDSCPFlag(cmd, ra)
+	return cmd
+}
+
+// NewCheckTCPPortMesh creates a runner connecting to all endpoints except the own node on each run.
+// The job ID of the observations contains the peer, see nwpd.MeshJobID.
+func NewCheckTCPPortMesh(endpoints []config.Endpoint, rconfig RunnerConfig) Runner {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	return &checkTCPPortMesh{
+		robinRound[config.Endpoint]{
+			itemsName:  "peers",
+			items:      config.CloneAndShuffle(endpoints),
+			runFunc:    checkTCPPortFuncOf(rconfig, nil),
+			config:     rconfig,
+			peerJobIDs: true,
+		},
+	}
+}
+
+type checkTCPPortMesh struct {
+	robinRound[config.Endpoint]
+}
+
+var _ Runner = &checkTCPPortMesh{}
+
+func (r *checkTCPPortMesh) Description() string {
+	return fmt.Sprintf("%d %s per tick", len(r.items), r.itemsName)
+}
+
+// Run probes all peers except the own node. Peers in failure backoff are skipped.
+func (r *checkTCPPortMesh) Run(nodeName string, ch chan<- *nwpd.Observation) {
+	r.RunContext(context.Background(), nodeName, ch)
+}
+
+// RunContext is like Run, but stops starting probes if the context is cancelled.
+func (r *checkTCPPortMesh) RunContext(ctx context.Context, nodeName string, ch chan<- *nwpd.Observation) {
+	now := time.Now()
+	slots := make(chan struct{}, maxMeshParallelism)
+	wg := sync.WaitGroup{}
+	for _, item := range r.items {
+		if ctx.Err() != nil {
+			break
+		}
+		if normalise(item.DestHost()) == nodeName || r.backedOff(item, now) {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			// all peers are probed on each run
+			r.runItem(ctx, nodeName, item, len(r.items), ch)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"net"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checkTCPPortMesh", func() {
+	It("probes all peers except the own node on each run", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_ = conn.Close()
+			}
+		}()
+		port := listener.Addr().(*net.TCPAddr).Port
+
+		var endpoints []config.Endpoint
+		for _, node := range []string{"node1", "node2", "node3"} {
+			endpoints = append(endpoints, config.Endpoint{Hostname: node, IP: "127.0.0.1", Port: port})
+		}
+		r := NewCheckTCPPortMesh(endpoints, RunnerConfig{Job: config.Job{JobID: "tcp-mesh"}, Period: 10 * time.Second})
+		Expect(r.Description()).To(Equal("3 peers per tick"))
+		r.(zoneRunner).setNodeZones(map[string]string{"node1": "z1", "node2": "z2", "node3": "z1"})
+
+		ch := make(chan *nwpd.Observation, 10)
+		for run := 0; run < 2; run++ {
+			r.Run("node1", ch)
+			Expect(ch).To(HaveLen(2))
+			observations := map[string]*nwpd.Observation{}
+			for i := 0; i < 2; i++ {
+				obs := <-ch
+				observations[obs.JobID] = obs
+			}
+			Expect(observations).To(HaveKey("tcp-mesh/node2"))
+			Expect(observations).To(HaveKey("tcp-mesh/node3"))
+			obs := observations["tcp-mesh/node2"]
+			Expect(obs.Ok).To(BeTrue(), obs.Result)
+			Expect(obs.SrcHost).To(Equal("node1"))
+			Expect(obs.DestHost).To(Equal("node2"))
+			Expect(obs.DestZone).To(Equal("z2"))
+			// each peer is probed once per period
+			Expect(obs.Period.AsDuration()).To(Equal(10 * time.Second))
+		}
+	})
+
+	It("splits the job ID of the observations", func() {
+		job, peer := nwpd.SplitMeshJobID(nwpd.MeshJobID("tcp-mesh", "node2"))
+		Expect(job).To(Equal("tcp-mesh"))
+		Expect(peer).To(Equal("node2"))
+		job, peer = nwpd.SplitMeshJobID("tcp-n2n")
+		Expect(job).To(Equal("tcp-n2n"))
+		Expect(peer).To(BeEmpty())
+	})
+})
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strings"
+)
+
+// DefaultMaxCIDRAddresses is the maximum number of addresses a job may expand its CIDR destinations to if not configured.
+const DefaultMaxCIDRAddresses = 256
+
+// maxCIDRAddressesOf returns the maximum number of addresses of the CIDR destinations of a job.
+func maxCIDRAddressesOf(cfg RunnerConfig) int {
+	if cfg.MaxCIDRAddresses > 0 {
+		return cfg.MaxCIDRAddresses
+	}
+	return DefaultMaxCIDRAddresses
+}
+
+// cidrSize returns the number of probed addresses of the prefix.
+// For IPv4 prefixes with more than two addresses, the network and broadcast addresses are excluded.
+func cidrSize(prefix netip.Prefix) *big.Int {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(hostBits)) // #nosec G115 -- at most 128
+	if prefix.Addr().Is4() && hostBits > 1 {
+		size.Sub(size, big.NewInt(2))
+	}
+	return size
+}
+
+// expandCIDRs returns the addresses of the CIDRs in ascending order per CIDR.
+// It fails if the CIDRs would expand to more than maxAddresses addresses in total.
+func expandCIDRs(cidrs []string, maxAddresses int) ([]string, error) {
+	var prefixes []netip.Prefix
+	total := new(big.Int)
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %s: %s", cidr, err)
+		}
+		if prefix != prefix.Masked() {
+			return nil, fmt.Errorf("invalid CIDR %s: host bits set, use %s", cidr, prefix.Masked())
+		}
+		total.Add(total, cidrSize(prefix))
+		if total.Cmp(big.NewInt(int64(maxAddresses))) > 0 {
+			return nil, fmt.Errorf("CIDRs %s expand to more than %d addresses", strings.Join(cidrs, ","), maxAddresses)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	addresses := make([]string, 0, total.Int64())
+	for _, prefix := range prefixes {
+		addr := prefix.Addr()
+		skipEdges := prefix.Addr().Is4() && prefix.Bits() < 31
+		if skipEdges {
+			// network address
+			addr = addr.Next()
+		}
+		for ; addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+			if skipEdges && !prefix.Contains(addr.Next()) {
+				// broadcast address
+				break
+			}
+			addresses = append(addresses, addr.String())
+		}
+	}
+	return addresses, nil
+}
+
+// splitCIDRPort splits a CIDR destination in format <cidr>:<port>. The port is separated by the last colon, as IPv6 CIDRs contain colons.
+func splitCIDRPort(value string) (string, int, error) {
+	i := strings.LastIndex(value, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid CIDR destination %s", value)
+	}
+	port, err := parsePort(value[i+1:])
+	if err != nil {
+		return "", 0, err
+	}
+	return value[:i], port, nil
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CIDR expansion", func() {
+	DescribeTable("expands the CIDRs to addresses",
+		func(cidrs []string, maxAddresses int, expected []string) {
+			addresses, err := expandCIDRs(cidrs, maxAddresses)
+			Expect(err).To(BeNil())
+			Expect(addresses).To(Equal(expected))
+		},
+		Entry("without network and broadcast address", []string{"10.0.0.0/29"}, 6,
+			[]string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}),
+		Entry("point-to-point network", []string{"10.0.0.0/31"}, 2, []string{"10.0.0.0", "10.0.0.1"}),
+		Entry("single address", []string{"10.0.0.7/32"}, 1, []string{"10.0.0.7"}),
+		Entry("IPv6", []string{"fd00::/126"}, 4, []string{"fd00::", "fd00::1", "fd00::2", "fd00::3"}),
+		Entry("end of address space", []string{"255.255.255.254/31"}, 2, []string{"255.255.255.254", "255.255.255.255"}),
+		Entry("multiple CIDRs", []string{"10.0.0.0/32", "10.0.1.0/32"}, 2, []string{"10.0.0.0", "10.0.1.0"}),
+	)
+
+	It("rejects CIDRs exceeding the limit in total", func() {
+		_, err := expandCIDRs([]string{"10.0.0.0/29", "10.0.1.0/32"}, 6)
+		Expect(err).To(MatchError(ContainSubstring("expand to more than 6 addresses")))
+		_, err = expandCIDRs([]string{"fd00::/64"}, DefaultMaxCIDRAddresses)
+		Expect(err).To(MatchError(ContainSubstring("expand to more than 256 addresses")))
+		_, err = expandCIDRs([]string{"10.0.0.0"}, DefaultMaxCIDRAddresses)
+		Expect(err).To(MatchError(ContainSubstring("invalid CIDR 10.0.0.0")))
+	})
+
+	It("uses the addresses as destination hosts", func() {
+		job, err := Parse(config.ClusterConfig{}, RunnerConfig{Period: DefaultPeriod}, []string{"checkTCPPort", "--cidr", "10.0.0.0/30:443"}, &config.SampleConfig{})
+		Expect(err).To(BeNil())
+		Expect(job.DestHosts()).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+	})
+})
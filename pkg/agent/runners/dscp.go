@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// maxDSCP is the largest value of the 6 bit DSCP field.
+	maxDSCP = 63
+	// markedPingDataSize is the size of the echo data, the same as of the ping library.
+	markedPingDataSize = 24
+)
+
+func addDSCPFlag(cmd *cobra.Command, ra *runnerArgs) {
+	cmd.Flags().IntVar(&ra.dscp, "dscp", 0, "DSCP value in range [0,63] the probe packets are marked with, e.g. 46 for expedited forwarding.")
+}
+
+// probeDialerOf returns a dialer for the network (tcp or udp) bound to the source address and marking the packets with
+// the DSCP value of the runner config.
+func probeDialerOf(network string, cfg RunnerConfig, timeout time.Duration) *net.Dialer {
+	dialer := &net.Dialer{Timeout: timeout}
+	if ip := net.ParseIP(cfg.SourceIP); ip != nil {
+		if network == "udp" {
+			dialer.LocalAddr = &net.UDPAddr{IP: ip}
+		} else {
+			dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+	}
+	if dscp := cfg.DSCP; dscp != 0 {
+		// set before connecting, so that the TCP handshake is marked, too
+		dialer.Control = func(network, _ string, c syscall.RawConn) error {
+			return setDSCP(c, strings.HasSuffix(network, "6"), dscp)
+		}
+	}
+	return dialer
+}
+
+// markedPing sends an ICMP echo request marked with the DSCP value and waits for the reply, as the ping library cannot mark
+// its packets. It returns nil if no reply has been received within the timeout.
+func markedPing(dest net.IP, sourceIP string, dscp int, timeout time.Duration) (*resultparse.Ping, error) {
+	isIPv6 := dest.To4() == nil
+	network, proto := "ip4:icmp", 1
+	var echoType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	laddr := &net.IPAddr{IP: net.IPv4zero}
+	if isIPv6 {
+		network, proto = "ip6:ipv6-icmp", 58
+		echoType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		laddr.IP = net.IPv6unspecified
+	}
+	if ip := net.ParseIP(sourceIP); ip != nil {
+		laddr.IP = ip
+	}
+	conn, err := net.ListenIP(network, laddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	if err := setDSCP(rawConn, isIPv6, dscp); err != nil {
+		return nil, err
+	}
+	// the TTL of the reply is received as control message
+	var read func(buf []byte) (n, ttl int, peer net.Addr, err error)
+	if isIPv6 {
+		pconn := ipv6.NewPacketConn(conn)
+		if err := pconn.SetControlMessage(ipv6.FlagHopLimit, true); err != nil {
+			return nil, err
+		}
+		read = func(buf []byte) (int, int, net.Addr, error) {
+			n, cm, peer, err := pconn.ReadFrom(buf)
+			if cm == nil {
+				return n, 0, peer, err
+			}
+			return n, cm.HopLimit, peer, err
+		}
+	} else {
+		pconn := ipv4.NewPacketConn(conn)
+		if err := pconn.SetControlMessage(ipv4.FlagTTL, true); err != nil {
+			return nil, err
+		}
+		read = func(buf []byte) (int, int, net.Addr, error) {
+			n, cm, peer, err := pconn.ReadFrom(buf)
+			if cm == nil {
+				return n, 0, peer, err
+			}
+			return n, cm.TTL, peer, err
+		}
+	}
+
+	id := rand.Intn(0xffff) // #nosec G404 -- only used to match replies
+	msg := icmp.Message{Type: echoType, Body: &icmp.Echo{ID: id, Data: make([]byte, markedPingDataSize)}}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if _, err := conn.WriteTo(data, &net.IPAddr{IP: dest}); err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(start.Add(timeout)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, ttl, peer, err := read(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil, nil
+			}
+			return nil, err
+		}
+		rtt := time.Since(start)
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		if echo, ok := reply.Body.(*icmp.Echo); ok && echo.ID == id && echo.Seq == 0 && peer.String() == dest.String() {
+			return &resultparse.Ping{RTT: rtt, TTL: ttl, Size: n, From: peer.String(), Source: sourceIP, DSCP: dscp}, nil
+		}
+	}
+}
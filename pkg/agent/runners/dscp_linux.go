@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// setDSCP marks all packets sent on the socket with the DSCP value in the upper 6 bits of the IPv4 ToS or IPv6 traffic class.
+func setDSCP(c syscall.RawConn, ipv6 bool, dscp int) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if ipv6 {
+			sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, dscp<<2)
+		} else {
+			sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, dscp<<2)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if sockErr != nil {
+		return fmt.Errorf("cannot set DSCP %d: %w", dscp, sockErr)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package runners
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// setDSCP is only supported on Linux.
+func setDSCP(_ syscall.RawConn, _ bool, dscp int) error {
+	return fmt.Errorf("cannot set DSCP %d: %w", dscp, errors.ErrUnsupported)
+}
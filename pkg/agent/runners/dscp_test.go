@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/ipv4"
+)
+
+var _ = Describe("DSCP marking", func() {
+	It("marks the TCP connections before connecting", func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer l.Close()
+
+		conn, err := probeDialerOf("tcp", RunnerConfig{DSCP: 46}, time.Second).Dial("tcp", l.Addr().String())
+		if errors.Is(err, errors.ErrUnsupported) {
+			Skip("marking not supported: " + err.Error())
+		}
+		Expect(err).To(BeNil())
+		defer conn.Close()
+		tos, err := ipv4.NewConn(conn).TOS()
+		Expect(err).To(BeNil())
+		Expect(tos).To(Equal(46 << 2))
+
+		fields := resultFields{}
+		endpoint := config.Endpoint{Hostname: "localhost", IP: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port}
+		result, err := checkTCPPortFuncOf(RunnerConfig{DSCP: 46}, nil)(endpoint, fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("state=connected dscp=46"))
+		Expect(fields).To(Equal(resultFields{ResultFieldDSCP: "46"}))
+	})
+
+	It("pings with marked echo requests", func() {
+		reply, err := markedPing(net.IPv4(127, 0, 0, 1), "", 34, time.Second)
+		if err != nil && isPermissionError(err) {
+			Skip("raw sockets not permitted: " + err.Error())
+		}
+		Expect(err).To(BeNil())
+		Expect(reply).NotTo(BeNil())
+		Expect(reply.From).To(Equal("127.0.0.1"))
+		Expect(reply.TTL).To(Equal(64))
+		Expect(reply.Size).To(Equal(32))
+
+		fields := resultFields{}
+		result, err := pingFuncOf(RunnerConfig{SourceIP: "127.0.0.1", DSCP: 34})(config.Node{Hostname: "localhost", InternalIP: "127.0.0.1"}, fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(MatchRegexp(`^rtt=\S+ ttl=64 size=32 from=127.0.0.1 seq=0 src=127.0.0.1 dscp=34$`))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldDSCP, "34"))
+		Expect(fields).To(HaveKeyWithValue(ResultFieldPacketLoss, "0"))
+	})
+
+	It("reports a lost marked ping", func() {
+		// the deadline has passed before the reply is read
+		reply, err := markedPing(net.IPv4(127, 0, 0, 1), "", 34, time.Nanosecond)
+		if err != nil && isPermissionError(err) {
+			Skip("raw sockets not permitted: " + err.Error())
+		}
+		Expect(err).To(BeNil())
+		Expect(reply).To(BeNil())
+	})
+})
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/backoff"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+)
+
+const (
+	// failureBackoffInitialFactor is the first delay of a failing destination as multiple of its probe interval.
+	failureBackoffInitialFactor = 2
+	// defaultFailureBackoffMaxFactor is the default maximum delay of a failing destination as multiple of its probe interval.
+	defaultFailureBackoffMaxFactor = 8
+	failureBackoffGrowth           = 2
+	failureBackoffJitter           = 0.2
+)
+
+// validateFailureBackoff checks the failure backoff configuration of the job.
+func validateFailureBackoff(cfg *config.FailureBackoffConfig) error {
+	if cfg != nil && cfg.MaxDelay != nil && cfg.MaxDelay.Duration < 0 {
+		return fmt.Errorf("invalid failureBackoff.maxDelay, must be >= 0")
+	}
+	return nil
+}
+
+// failureBackoffPolicyOf returns the backoff policy for destinations probed with the given interval, or nil if disabled.
+func failureBackoffPolicyOf(cfg RunnerConfig, interval time.Duration) *backoff.Policy {
+	enabled := cfg.ExternalDestinations
+	if fb := cfg.FailureBackoff; fb != nil && fb.Enabled != nil {
+		enabled = *fb.Enabled
+	}
+	if !enabled || interval <= 0 {
+		return nil
+	}
+	policy := &backoff.Policy{
+		Initial: failureBackoffInitialFactor * interval,
+		Max:     defaultFailureBackoffMaxFactor * interval,
+		Factor:  failureBackoffGrowth,
+		Jitter:  failureBackoffJitter,
+	}
+	if fb := cfg.FailureBackoff; fb != nil && fb.MaxDelay != nil && fb.MaxDelay.Duration > 0 {
+		policy.Max = max(fb.MaxDelay.Duration, interval)
+	}
+	return policy
+}
+
+// failureBackoff returns the tracker of the failing destinations or nil if the backoff is disabled.
+func (r *robinRound[T]) failureBackoff() *backoff.Tracker {
+	r.backoffOnce.Do(func() {
+		if policy := failureBackoffPolicyOf(r.config, r.destinationInterval(r.peersPerRun())); policy != nil {
+			r.backoff = backoff.NewTracker(*policy)
+			r.updateBackedOffGauge()
+		}
+	})
+	return r.backoff
+}
+
+// backedOff returns true if the item is skipped by the scheduled runs because of previous failures.
+func (r *robinRound[T]) backedOff(item T, now time.Time) bool {
+	tracker := r.failureBackoff()
+	return tracker != nil && !tracker.Allowed(normalise(item.DestHost()), now)
+}
+
+// recordBackoff updates the backoff state of the destination of the observation.
+func (r *robinRound[T]) recordBackoff(destHost string, ok bool, now time.Time) {
+	tracker := r.failureBackoff()
+	if tracker == nil {
+		return
+	}
+	if ok {
+		tracker.Success(destHost)
+	} else {
+		tracker.Failure(destHost, now)
+	}
+	r.updateBackedOffGauge()
+}
+
+func (r *robinRound[T]) updateBackedOffGauge() {
+	if g := r.config.Shared.backedOffGauge(); g != nil {
+		g.WithLabelValues(r.config.JobID).Set(float64(r.backoff.Len()))
+	}
+}
+
+// BackoffStates returns the failure backoff states of the destinations with consecutive failures.
+func (r *robinRound[T]) BackoffStates() []backoff.State {
+	if tracker := r.failureBackoff(); tracker != nil {
+		return tracker.States()
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+)
+
+var fuzzClusterConfig = config.ClusterConfig{
+	NodeCount: 2,
+	Nodes: []config.Node{
+		{Hostname: "node1", InternalIP: "10.0.0.11"},
+		{Hostname: "node2", InternalIP: "10.0.0.12"},
+	},
+	PodEndpoints: []config.PodEndpoint{
+		{Nodename: "node1", Podname: "pod1", PodIP: "10.128.0.11", Port: 1234},
+	},
+	InternalKubeAPIServer: &config.Endpoint{Hostname: "kubernetes", IP: "100.64.0.1", Port: 443},
+	KubeAPIServer:         &config.Endpoint{Hostname: "api.example.com", IP: "1.2.3.4", Port: 443},
+}
+
+// fuzzParse parses a job type with the space separated args and fails on panics only.
+func fuzzParse(f *testing.F, jobType string, seeds ...string) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		args := append([]string{jobType}, strings.Fields(line)...)
+		rconfig := RunnerConfig{Job: config.Job{JobID: "fuzz", Args: args}, Period: time.Second}
+		job, err := Parse(fuzzClusterConfig, rconfig, args, &config.SampleConfig{})
+		if err == nil && job != nil {
+			_ = job.Description()
+			_ = job.DestHosts()
+		}
+	})
+}
+
+func FuzzParsePingHost(f *testing.F) {
+	fuzzParse(f, "pingHost",
+		"",
+		"--hosts node3:10.0.0.13,node4:10.0.0.14",
+		"--hosts :",
+		"--hosts node3",
+		"--max-peers -1 --sample ring",
+		"--period -10s --retries -3",
+	)
+}
+
+func FuzzParseCheckTCPPort(f *testing.F) {
+	fuzzParse(f, "checkTCPPort",
+		"--endpoints server:10.0.0.9:55555",
+		"--endpoints server:10.0.0.9:-1",
+		"--endpoints ::",
+		"--node-port -5",
+		"--endpoints-of-pod-ds --max-peers 100",
+		"--endpoint-internal-kube-apiserver --retry-delay 1ms",
+	)
+}
+
+func FuzzParseCheckHTTPSGet(f *testing.F) {
+	fuzzParse(f, "checkHTTPSGet",
+		"--endpoints server:55555,server2",
+		"--endpoints :443",
+		"--endpoints server: --header :",
+		"--header X-Foo: --expect-status 0",
+		"--max-redirects -5 --endpoint-external-kube-apiserver",
+	)
+}
+
+func FuzzParseNSLookup(f *testing.F) {
+	fuzzParse(f, "nslookup",
+		"--names eu.gcr.io,foo.bar.",
+		"--names . --expect-ip ::1",
+		"--name-internal-kube-apiserver --expect-known-ips",
+		"--names , --expect-ip x",
+	)
+}
+
+func FuzzParseCheckGRPCHealth(f *testing.F) {
+	fuzzParse(f, "checkGRPCHealth",
+		"--host server --port 50051",
+		"--host 10.0.0.1 --port 443 --tls --service foo",
+		"--host : --port -1",
+	)
+}
+
+func FuzzParseMTUProbe(f *testing.F) {
+	fuzzParse(f, "mtuProbe",
+		"--min-mtu 1400",
+		"--hosts host1:10.0.0.1 --max-mtu 9000",
+		"--min-mtu -1 --max-mtu 1",
+	)
+}
+
+func FuzzParseARPPing(f *testing.F) {
+	fuzzParse(f, "arpPing",
+		"",
+		"--hosts peer1:10.0.0.2 --max-peers 1",
+		"--hosts :",
+	)
+}
+
+func FuzzParsePacketTrain(f *testing.F) {
+	fuzzParse(f, "udpPacketTrain",
+		"--endpoints-of-pod-ds --packets 100",
+		"--node-http-port 12996 --interval 100us --size 1200",
+		"--packets 0 --interval -1s --settle 0",
+	)
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("")
+	f.Add("--period 1s")
+	f.Add("unknown --foo")
+	f.Add("pingHost --hosts node3:10.0.0.13")
+	f.Add("checkTCPPort --node-port 443 --source-ip 127.0.0.1 --interface lo")
+	f.Add("pingHost --dscp 46")
+	f.Fuzz(func(_ *testing.T, line string) {
+		args := strings.Fields(line)
+		rconfig := RunnerConfig{Job: config.Job{JobID: "fuzz", Args: args}, Period: time.Second}
+		_, _ = Parse(fuzzClusterConfig, rconfig, args, &config.SampleConfig{})
+	})
+}
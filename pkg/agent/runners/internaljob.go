@@ -7,10 +7,18 @@
 package runners
 
 import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/gardener/network-problem-detector/pkg/common/backoff"
 	"github.com/gardener/network-problem-detector/pkg/common/config"
 	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
 )
@@ -18,6 +26,20 @@ import (
 type RunnerConfig struct {
 	config.Job
 	Period time.Duration
+	// MaxPeers is the number of destinations probed per run. If 0, one destination is probed per run.
+	MaxPeers int
+	// SampleStrategy defines the rotation order of the destinations if MaxPeers is set.
+	SampleStrategy string
+	// ExternalDestinations is true if the destinations are outside of the cluster. The failure backoff is enabled by default for them.
+	ExternalDestinations bool
+	// MaxCIDRAddresses is the maximum number of addresses the CIDR destinations of the job expand to (default DefaultMaxCIDRAddresses).
+	MaxCIDRAddresses int
+	// SourceIP is the local address the probes are sent from. If empty, it is selected by the routing.
+	SourceIP string
+	// DSCP is the value the probe packets are marked with. If 0, the packets are not marked.
+	DSCP int
+	// Shared is the state shared with the other jobs of the agent (optional).
+	Shared *Shared
 }
 
 type Runner interface {
@@ -28,20 +50,80 @@ type Runner interface {
 	DestHosts() []string
 }
 
+// contextRunner is implemented by runners stopping a run early if the job is cancelled.
+type contextRunner interface {
+	RunContext(ctx context.Context, nodeName string, ch chan<- *nwpd.Observation)
+}
+
+// onDemandRunner is implemented by runners supporting runs outside of the schedule.
+type onDemandRunner interface {
+	RunAll(nodeName string, destHosts []string, ch chan<- *nwpd.Observation) int
+}
+
+// zoneRunner is implemented by runners filling the zones of source and destination nodes in the observations.
+type zoneRunner interface {
+	setNodeZones(zones map[string]string)
+}
+
+// backoffRunner is implemented by runners supporting the failure backoff of destinations.
+type backoffRunner interface {
+	BackoffStates() []backoff.State
+}
+
+// RunResult summarises the observations of a finished run.
+type RunResult struct {
+	// Finished is the time the run has finished.
+	Finished time.Time
+	// Ok is the number of successful observations.
+	Ok int
+	// Failed is the number of failed observations.
+	Failed int
+	// LastFailure is the result of the last failed observation.
+	LastFailure string
+}
+
 type InternalJob struct {
 	runner        Runner
 	peerNodeCount int
 	active        atomic.Bool
 	lastRun       atomic.Value
+	// lastSlot is the phase-aligned time in nanoseconds the last run has been scheduled for without jitter, 0 if not set
+	lastSlot   atomic.Int64
+	jitter     atomic.Float64
+	nextJitter atomic.Duration
+	// deferredSince is the time in nanoseconds the due run has first been deferred by the limiter, 0 if not deferred
+	deferredSince atomic.Int64
+
+	// ctx is cancelled if the job is deleted or replaced
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	resultLock          sync.Mutex
+	lastResult          *RunResult
+	consecutiveFailures int
 }
 
 func NewInternalJob(runner Runner, peerNodeCount int) *InternalJob {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &InternalJob{
 		runner:        runner,
 		peerNodeCount: peerNodeCount,
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
+// Cancel stops the job. No further runs are started, and the observations of a run still in progress are dropped.
+// Runners supporting it stop the run early, probes already started are finished.
+func (j *InternalJob) Cancel() {
+	j.cancel()
+}
+
+// Cancelled returns true if the job has been cancelled.
+func (j *InternalJob) Cancelled() bool {
+	return j.ctx.Err() != nil
+}
+
 func (j *InternalJob) JobID() string {
 	return j.runner.Config().JobID
 }
@@ -54,6 +136,10 @@ func (j *InternalJob) Config() RunnerConfig {
 	return j.runner.Config()
 }
 
+func (j *InternalJob) shared() *Shared {
+	return j.runner.Config().Shared
+}
+
 func (j *InternalJob) Description() string {
 	return j.runner.Description()
 }
@@ -66,26 +152,236 @@ func (j *InternalJob) DestHosts() []string {
 	return j.runner.DestHosts()
 }
 
+// SetLastRun sets the last run, the next runs are scheduled in slots of the period after it.
 func (j *InternalJob) SetLastRun(lastRun *time.Time) {
 	j.lastRun.Store(lastRun)
+	var slot int64
+	if lastRun != nil {
+		slot = lastRun.UnixNano()
+	}
+	j.lastSlot.Store(slot)
+}
+
+// ContinueScheduleOf takes over the last run and the phase of the slots of the job replaced by this one.
+func (j *InternalJob) ContinueScheduleOf(old *InternalJob) {
+	j.lastRun.Store(old.GetLastRun())
+	j.lastSlot.Store(old.lastSlot.Load())
+}
+
+// SetJitter sets the maximum random delay of a run as fraction of the period.
+func (j *InternalJob) SetJitter(jitter float64) {
+	j.jitter.Store(jitter)
+}
+
+// SetPhase sets the last run deterministically, so that runs are aligned to a phase derived from the given key.
+func (j *InternalJob) SetPhase(key string, now time.Time) {
+	period := j.Period()
+	if period <= 0 {
+		j.SetLastRun(&now)
+		return
+	}
+	delta := (now.UnixNano() - int64(PhaseOffset(key, period))) % int64(period)
+	if delta < 0 {
+		delta += int64(period)
+	}
+	lastRun := now.Add(-time.Duration(delta))
+	j.SetLastRun(&lastRun)
 }
 
-func (j *InternalJob) Tick(nodeName string, ch chan<- *nwpd.Observation) error {
-	if j.runner == nil || j.active.Load() {
+// PhaseOffset calculates a stable offset in the range [0, period) by hashing the key.
+func PhaseOffset(key string, period time.Duration) time.Duration {
+	if period <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(period))
+}
+
+// Tick starts a run of the job if it is due. If the limiter is set and has no free slot,
+// the job stays due and is started on one of the next ticks.
+func (j *InternalJob) Tick(nodeName string, ch chan<- *nwpd.Observation, limiter *Limiter) error {
+	if j.runner == nil || j.active.Load() || j.Cancelled() {
 		return nil
 	}
 
 	now := time.Now()
-	if now.After(j.getNextRun()) && j.active.CompareAndSwap(false, true) {
+	if due := j.NextRun(); now.After(due) && j.active.CompareAndSwap(false, true) {
+		if limiter != nil && !limiter.TryAcquire() {
+			if due.IsZero() {
+				// first run without phase
+				due = now
+			}
+			j.deferredSince.CompareAndSwap(0, due.UnixNano())
+			j.active.Store(false)
+			return nil
+		}
+		var delay time.Duration
+		if since := j.deferredSince.Swap(0); since != 0 {
+			delay = now.Sub(time.Unix(0, since))
+		}
 		j.lastRun.Store(&now)
+		j.lastSlot.Store(j.slotOf(now).UnixNano())
+		var nextJitter time.Duration
+		if maxJitter := int64(j.jitter.Load() * float64(j.Period())); maxJitter > 0 {
+			nextJitter = time.Duration(rand.Int63n(maxJitter)) //  #nosec G404 -- no cryptographic use
+		}
+		j.nextJitter.Store(nextJitter)
 		go func() {
 			defer j.active.Store(false)
-			j.runner.Run(nodeName, ch)
+			var release func()
+			if limiter != nil {
+				release = sync.OnceFunc(limiter.Release)
+				defer release()
+			}
+			j.trackRun(nodeName, delay, ch, j.shared().Backpressure(), runDeadlineOf(j.Period()), release, func(ctx context.Context, runCh chan<- *nwpd.Observation) {
+				if r, ok := j.runner.(contextRunner); ok {
+					r.RunContext(ctx, nodeName, runCh)
+					return
+				}
+				j.runner.Run(nodeName, runCh)
+			})
 		}()
 	}
 	return nil
 }
 
+// trackRun executes the run and records the summary of the observations forwarded to the channel.
+// If the channel is full, the observations are handled as defined by the backpressure, or the run blocks if it is nil.
+// The delay is the time the start of the run has been deferred by the limiter.
+// If the job is cancelled, the remaining observations of the run are dropped and the run is not recorded.
+// A panic of the run is reported as failed observation. If the deadline is set and exceeded, the context of the run is
+// cancelled, a failed observation is reported and onDeadline is called (optional). As runners not supporting the
+// cancellation cannot be stopped, the run is only finished once the runner has returned.
+func (j *InternalJob) trackRun(nodeName string, delay time.Duration, ch chan<- *nwpd.Observation, bp *Backpressure,
+	deadline time.Duration, onDeadline func(), run func(ctx context.Context, runCh chan<- *nwpd.Observation),
+) {
+	runCh := make(chan *nwpd.Observation)
+	done := make(chan struct{})
+	result := &RunResult{}
+	tracer := j.shared().tracer()
+	recorder := j.shared().recorder()
+	start := time.Now()
+	var (
+		destHosts []string
+		probed    = map[string]struct{}{}
+		dropped   int
+	)
+	go func() {
+		defer close(done)
+		for obs := range runCh {
+			if j.Cancelled() {
+				// the job has been deleted or replaced, its observations must not resurrect its metrics
+				continue
+			}
+			if tracer != nil {
+				tracer.OnObservation(obs)
+			}
+			if obs.Ok {
+				result.Ok++
+			} else {
+				result.Failed++
+				result.LastFailure = obs.Result
+			}
+			if _, ok := probed[obs.DestHost]; recorder != nil && !ok {
+				probed[obs.DestHost] = struct{}{}
+				destHosts = append(destHosts, obs.DestHost)
+			}
+			if !bp.Send(ch, obs) {
+				dropped++
+			}
+		}
+	}()
+	ctx, cancel := j.ctx, context.CancelFunc(func() {})
+	if deadline > 0 {
+		ctx, cancel = context.WithTimeout(j.ctx, deadline)
+	}
+	defer cancel()
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		j.runRecovered(ctx, nodeName, runCh, run)
+	}()
+	select {
+	case <-returned:
+	case <-ctx.Done():
+		if !j.Cancelled() {
+			runCh <- j.failedObservation(nodeName, fmt.Sprintf("run deadline %s exceeded", deadline))
+			if onDeadline != nil {
+				onDeadline()
+			}
+		}
+		<-returned
+	}
+	close(runCh)
+	<-done
+	if j.Cancelled() {
+		return
+	}
+
+	result.Finished = time.Now()
+	if recorder != nil && recorder.OnRun != nil {
+		recorder.OnRun(&nwpd.JobRunRecord{
+			Kind:                nwpd.JobRunKindRun,
+			JobID:               j.JobID(),
+			SrcHost:             nodeName,
+			Start:               timestamppb.New(start),
+			End:                 timestamppb.New(result.Finished),
+			Period:              durationpb.New(j.Period()),
+			Delay:               durationpb.New(delay),
+			DestHosts:           destHosts,
+			DroppedObservations: int32(dropped), // #nosec G115 -- bounded by number of destinations
+		})
+	}
+	j.resultLock.Lock()
+	defer j.resultLock.Unlock()
+	j.lastResult = result
+	if result.Failed > 0 {
+		j.consecutiveFailures++
+	} else {
+		j.consecutiveFailures = 0
+	}
+}
+
+// LastResult returns the summary of the last finished run and the number of consecutive runs with failed observations.
+func (j *InternalJob) LastResult() (*RunResult, int) {
+	j.resultLock.Lock()
+	defer j.resultLock.Unlock()
+	if j.lastResult == nil {
+		return nil, 0
+	}
+	result := *j.lastResult
+	return &result, j.consecutiveFailures
+}
+
+// RunNow probes all destinations of the job immediately, or only the given destination hosts if not empty.
+// It blocks until all observations have been sent to the channel and returns the number of probed destinations.
+func (j *InternalJob) RunNow(nodeName string, destHosts []string, ch chan<- *nwpd.Observation) (int, error) {
+	r, ok := j.runner.(onDemandRunner)
+	if !ok {
+		return 0, fmt.Errorf("job %s does not support on-demand runs", j.JobID())
+	}
+	var count int
+	// the caller consumes all observations of an on-demand run, so no backpressure is applied
+	j.trackRun(nodeName, 0, ch, nil, 0, nil, func(_ context.Context, runCh chan<- *nwpd.Observation) {
+		count = r.RunAll(nodeName, destHosts, runCh)
+	})
+	return count, nil
+}
+
+// BackoffStates returns the failure backoff states of the destinations with consecutive failures.
+func (j *InternalJob) BackoffStates() []backoff.State {
+	if r, ok := j.runner.(backoffRunner); ok {
+		return r.BackoffStates()
+	}
+	return nil
+}
+
+// Running returns true while a run of the job is in progress.
+func (j *InternalJob) Running() bool {
+	return j.active.Load()
+}
+
 func (j *InternalJob) GetLastRun() *time.Time {
 	v := j.lastRun.Load()
 	if v == nil {
@@ -94,10 +390,27 @@ func (j *InternalJob) GetLastRun() *time.Time {
 	return v.(*time.Time)
 }
 
-func (j *InternalJob) getNextRun() time.Time {
-	last := j.GetLastRun()
-	if last == nil {
+// NextRun returns the time the job is due next. It is the slot following the one of the last run plus the random delay,
+// so that neither the jitter nor a delayed start shift the phase of the later runs.
+func (j *InternalJob) NextRun() time.Time {
+	last := j.lastSlot.Load()
+	if last == 0 {
 		return time.Time{}
 	}
-	return last.Add(j.Period())
+	return time.Unix(0, last).Add(j.Period() + j.nextJitter.Load())
+}
+
+// slotOf returns the slot of a run started at the given time, i.e. the latest slot of the phase not after it.
+func (j *InternalJob) slotOf(start time.Time) time.Time {
+	last, period := j.lastSlot.Load(), j.Period()
+	if last == 0 || period <= 0 {
+		// first run without phase
+		return start
+	}
+	slot := time.Unix(0, last).Add(period)
+	if behind := start.Sub(slot); behind >= period {
+		// slots missed, e.g. while deferred by the limiter
+		slot = slot.Add(behind / period * period)
+	}
+	return slot
 }
@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// testRunner calls the run function for each run.
+type testRunner struct {
+	config RunnerConfig
+	run    func(ctx context.Context, ch chan<- *nwpd.Observation)
+}
+
+func (r *testRunner) Run(_ string, ch chan<- *nwpd.Observation) { r.run(context.Background(), ch) }
+func (r *testRunner) Config() RunnerConfig                      { return r.config }
+func (r *testRunner) Description() string                       { return "" }
+func (r *testRunner) TestData() any                             { return nil }
+func (r *testRunner) DestHosts() []string                       { return nil }
+
+// testContextRunner passes the context of the run to the run function.
+type testContextRunner struct {
+	testRunner
+}
+
+func (r *testContextRunner) RunContext(ctx context.Context, _ string, ch chan<- *nwpd.Observation) {
+	r.run(ctx, ch)
+}
+
+var _ = Describe("InternalJob", func() {
+	var (
+		failing map[string]bool
+		shared  *Shared
+	)
+
+	newJob := func() *InternalJob {
+		r := &robinRound[dnsName]{
+			itemsName: "names",
+			items:     []dnsName{{name: "a."}, {name: "b."}},
+			runFunc: func(item dnsName, _ resultFields) (string, error) {
+				if failing[item.name] {
+					return "", fmt.Errorf("lookup %s failed", item.name)
+				}
+				return "ok", nil
+			},
+			config: RunnerConfig{Job: config.Job{JobID: "test"}, Period: time.Millisecond, MaxPeers: 2, Shared: shared},
+		}
+		return NewInternalJob(r, 0)
+	}
+
+	tick := func(job *InternalJob) []*nwpd.Observation {
+		ch := make(chan *nwpd.Observation, 10)
+		// a tick within the period of the previous run is a no-op
+		Eventually(func() bool { return time.Now().After(job.NextRun()) }).Should(BeTrue())
+		Expect(job.Tick("node1", ch, nil)).To(Succeed())
+		var observations []*nwpd.Observation
+		for i := 0; i < 2; i++ {
+			observations = append(observations, <-ch)
+		}
+		Eventually(job.Running).Should(BeFalse())
+		return observations
+	}
+
+	BeforeEach(func() {
+		failing = map[string]bool{}
+		shared = NewShared(nil, nil)
+	})
+
+	It("tracks the result of the last run and the consecutive failures", func() {
+		job := newJob()
+		result, failures := job.LastResult()
+		Expect(result).To(BeNil())
+		Expect(failures).To(Equal(0))
+
+		failing["b."] = true
+		for i := 1; i <= 2; i++ {
+			Expect(tick(job)).To(HaveLen(2))
+			result, failures = job.LastResult()
+			Expect(result.Ok).To(Equal(1))
+			Expect(result.Failed).To(Equal(1))
+			Expect(result.LastFailure).To(ContainSubstring("lookup b. failed"))
+			Expect(result.Finished).NotTo(BeZero())
+			Expect(failures).To(Equal(i))
+		}
+
+		failing = map[string]bool{}
+		tick(job)
+		result, failures = job.LastResult()
+		Expect(result.Ok).To(Equal(2))
+		Expect(result.Failed).To(Equal(0))
+		Expect(failures).To(Equal(0))
+	})
+
+	It("schedules the runs in the slots of the phase with the jitter on top", func() {
+		period := 50 * time.Millisecond
+		job := NewInternalJob(&testRunner{
+			config: RunnerConfig{Job: config.Job{JobID: "slots"}, Period: period},
+			run:    func(_ context.Context, _ chan<- *nwpd.Observation) {},
+		}, 0)
+		job.SetJitter(0.5)
+		base := time.Now().Add(-period)
+		job.SetLastRun(&base)
+		ch := make(chan *nwpd.Observation, 10)
+		for i := 1; i <= 5; i++ {
+			Eventually(func() bool { return time.Now().After(job.NextRun()) }, time.Second, time.Millisecond).Should(BeTrue())
+			Expect(job.Tick("node1", ch, nil)).To(Succeed())
+			Eventually(job.Running).Should(BeFalse())
+			// the delay of the start and the jitter of the previous run do not shift the next slot
+			next := job.NextRun().Sub(base)
+			Expect(next).To(BeNumerically(">=", time.Duration(i+1)*period))
+			Expect(next).To(BeNumerically("<", time.Duration(i+1)*period+period/2))
+		}
+	})
+
+	It("starts no runs after being cancelled", func() {
+		job := newJob()
+		Expect(tick(job)).To(HaveLen(2))
+		job.Cancel()
+		Expect(job.Cancelled()).To(BeTrue())
+		ch := make(chan *nwpd.Observation, 10)
+		Eventually(func() bool { return time.Now().After(job.NextRun()) }).Should(BeTrue())
+		Expect(job.Tick("node1", ch, nil)).To(Succeed())
+		Expect(job.Running()).To(BeFalse())
+		Expect(ch).To(BeEmpty())
+	})
+
+	It("tracks the result of on-demand runs", func() {
+		job := newJob()
+		failing["a."] = true
+		ch := make(chan *nwpd.Observation, 10)
+		count, err := job.RunNow("node1", nil, ch)
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(2))
+		Expect(ch).To(HaveLen(2))
+		result, failures := job.LastResult()
+		Expect(result.Failed).To(Equal(1))
+		Expect(failures).To(Equal(1))
+	})
+
+	It("passes the observations of a run to the probe tracer", func() {
+		var traced []string
+		shared.SetProbeTracer(&ProbeTracer{OnObservation: func(obs *nwpd.Observation) {
+			traced = append(traced, obs.DestHost)
+		}})
+
+		observations := tick(newJob())
+		Expect(traced).To(ConsistOf(observations[0].DestHost, observations[1].DestHost))
+	})
+
+	It("records the runs with the probed destinations and the delay by the limiter", func() {
+		records := make(chan *nwpd.JobRunRecord, 10)
+		shared.SetRunRecorder(&RunRecorder{OnRun: func(record *nwpd.JobRunRecord) {
+			records <- record
+		}})
+
+		job := newJob()
+		observations := tick(job)
+		var record *nwpd.JobRunRecord
+		Eventually(records).Should(Receive(&record))
+		Expect(record.Kind).To(Equal(nwpd.JobRunKindRun))
+		Expect(record.JobID).To(Equal("test"))
+		Expect(record.SrcHost).To(Equal("node1"))
+		Expect(record.DestHosts).To(ConsistOf(observations[0].DestHost, observations[1].DestHost))
+		Expect(record.Period.AsDuration()).To(Equal(time.Millisecond))
+		Expect(record.Delay.AsDuration()).To(BeZero())
+		Expect(record.End.AsTime()).NotTo(BeTemporally("<", record.Start.AsTime()))
+
+		limiter := NewLimiter(1, prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_running_jobs"}))
+		Expect(limiter.TryAcquire()).To(BeTrue())
+		Eventually(func() bool { return time.Now().After(job.NextRun()) }).Should(BeTrue())
+		ch := make(chan *nwpd.Observation, 10)
+		Expect(job.Tick("node1", ch, limiter)).To(Succeed())
+		Expect(job.Running()).To(BeFalse())
+		time.Sleep(20 * time.Millisecond)
+		limiter.Release()
+		Expect(job.Tick("node1", ch, limiter)).To(Succeed())
+		Eventually(records).Should(Receive(&record))
+		Expect(record.Delay.AsDuration()).To(BeNumerically(">=", 20*time.Millisecond))
+	})
+
+	It("reports a panic of the runner as failed observation and keeps running the job", func() {
+		panics := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_runner_panics_total"}, []string{"jobid"})
+		job := NewInternalJob(&testRunner{
+			config: RunnerConfig{Job: config.Job{JobID: "panic"}, Period: time.Millisecond, Shared: NewShared(nil, panics)},
+			run: func(_ context.Context, ch chan<- *nwpd.Observation) {
+				ch <- &nwpd.Observation{JobID: "panic", DestHost: "node2", Ok: true}
+				panic("malformed URL")
+			},
+		}, 0)
+		limiter := NewLimiter(1, nil)
+		for i := 1; i <= 2; i++ {
+			ch := make(chan *nwpd.Observation, 10)
+			Eventually(func() bool { return time.Now().After(job.NextRun()) }).Should(BeTrue())
+			Expect(job.Tick("node1", ch, limiter)).To(Succeed())
+			Eventually(job.Running).Should(BeFalse())
+			Expect(ch).To(HaveLen(2))
+			<-ch
+			obs := <-ch
+			Expect(obs.Ok).To(BeFalse())
+			Expect(obs.JobID).To(Equal("panic"))
+			Expect(obs.SrcHost).To(Equal("node1"))
+			Expect(obs.DestHost).To(BeEmpty())
+			Expect(obs.Result).To(HavePrefix("runner panicked: malformed URL\n"))
+			Expect(obs.Result).To(ContainSubstring("runtime/debug.Stack"))
+			Expect(len(obs.Result)).To(BeNumerically("<=", maxPanicStackBytes+100))
+			Expect(testutil.ToFloat64(panics.WithLabelValues("panic"))).To(Equal(float64(i)))
+			result, _ := job.LastResult()
+			Expect(result.Ok).To(Equal(1))
+			Expect(result.Failed).To(Equal(1))
+		}
+		// the slot of the limiter has been released
+		Expect(limiter.TryAcquire()).To(BeTrue())
+	})
+
+	Describe("run deadline", func() {
+		BeforeEach(func() {
+			old := minRunDeadline
+			minRunDeadline = 50 * time.Millisecond
+			DeferCleanup(func() { minRunDeadline = old })
+		})
+
+		It("cancels the context of a run exceeding its deadline", func() {
+			job := NewInternalJob(&testContextRunner{testRunner{
+				config: RunnerConfig{Job: config.Job{JobID: "slow"}, Period: time.Millisecond},
+				run: func(ctx context.Context, _ chan<- *nwpd.Observation) {
+					<-ctx.Done()
+				},
+			}}, 0)
+			ch := make(chan *nwpd.Observation, 10)
+			Expect(job.Tick("node1", ch, nil)).To(Succeed())
+			var obs *nwpd.Observation
+			Eventually(ch).Should(Receive(&obs))
+			Expect(obs.Ok).To(BeFalse())
+			Expect(obs.Result).To(Equal("run deadline 50ms exceeded"))
+			Eventually(job.Running).Should(BeFalse())
+			Expect(job.Cancelled()).To(BeFalse())
+		})
+
+		It("releases the slot of a hanging run, but keeps the job running until the runner returns", func() {
+			release := make(chan struct{})
+			runs := 0
+			job := NewInternalJob(&testRunner{
+				config: RunnerConfig{Job: config.Job{JobID: "hanging"}, Period: time.Millisecond},
+				run: func(_ context.Context, _ chan<- *nwpd.Observation) {
+					runs++
+					<-release
+				},
+			}, 0)
+			limiter := NewLimiter(1, nil)
+			ch := make(chan *nwpd.Observation, 10)
+			Expect(job.Tick("node1", ch, limiter)).To(Succeed())
+			var obs *nwpd.Observation
+			Eventually(ch).Should(Receive(&obs))
+			Expect(obs.Result).To(Equal("run deadline 50ms exceeded"))
+			Eventually(limiter.TryAcquire).Should(BeTrue())
+			limiter.Release()
+
+			// no further run is started while the runner hangs
+			Expect(job.Running()).To(BeTrue())
+			Expect(job.Tick("node1", ch, limiter)).To(Succeed())
+			close(release)
+			Eventually(job.Running).Should(BeFalse())
+			Expect(runs).To(Equal(1))
+			result, _ := job.LastResult()
+			Expect(result.Failed).To(Equal(1))
+		})
+	})
+})
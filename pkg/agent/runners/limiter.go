@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Limiter restricts the number of simultaneously running jobs or probes.
+type Limiter struct {
+	slots    chan struct{}
+	inFlight prometheus.Gauge
+}
+
+// NewLimiter creates a limiter allowing maxConcurrent simultaneous runs.
+// The optional gauge is updated with the number of in-flight runs.
+func NewLimiter(maxConcurrent int, inFlight prometheus.Gauge) *Limiter {
+	return &Limiter{
+		slots:    make(chan struct{}, maxConcurrent),
+		inFlight: inFlight,
+	}
+}
+
+// Max returns the maximum number of simultaneous runs.
+func (l *Limiter) Max() int {
+	return cap(l.slots)
+}
+
+// TryAcquire acquires a slot without blocking. It returns false if all slots are in use.
+func (l *Limiter) TryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		if l.inFlight != nil {
+			l.inFlight.Inc()
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// Acquire acquires a slot and blocks until one is free.
+func (l *Limiter) Acquire() {
+	l.slots <- struct{}{}
+	if l.inFlight != nil {
+		l.inFlight.Inc()
+	}
+}
+
+// AcquireContext acquires a slot and blocks until one is free or the context is done.
+func (l *Limiter) AcquireContext(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if l.inFlight != nil {
+		l.inFlight.Inc()
+	}
+	return nil
+}
+
+// Release releases a slot acquired with TryAcquire, Acquire or AcquireContext.
+func (l *Limiter) Release() {
+	<-l.slots
+	if l.inFlight != nil {
+		l.inFlight.Dec()
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("probe limiter", func() {
+	It("limits the number of simultaneous probes", func() {
+		var (
+			lock               sync.Mutex
+			inFlight, maxFound int
+		)
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_inflight_probes"})
+		shared := NewShared(nil, nil)
+		shared.SetProbeLimiter(NewLimiter(2, gauge))
+
+		var items []dnsName
+		for i := 0; i < 6; i++ {
+			items = append(items, dnsName{name: fmt.Sprintf("name%d.", i)})
+		}
+		r := &robinRound[dnsName]{
+			itemsName: "names",
+			items:     items,
+			runFunc: func(_ dnsName, _ resultFields) (string, error) {
+				lock.Lock()
+				inFlight++
+				maxFound = max(maxFound, inFlight)
+				lock.Unlock()
+				Expect(testutil.ToFloat64(gauge)).To(BeNumerically("<=", 2))
+				time.Sleep(10 * time.Millisecond)
+				lock.Lock()
+				inFlight--
+				lock.Unlock()
+				return "ok", nil
+			},
+			config: RunnerConfig{Job: config.Job{JobID: "test"}, Period: time.Second, MaxPeers: 6, Shared: shared},
+		}
+
+		ch := make(chan *nwpd.Observation, 6)
+		r.Run("node1", ch)
+		Expect(ch).To(HaveLen(6))
+		Expect(maxFound).To(Equal(2))
+		Expect(testutil.ToFloat64(gauge)).To(Equal(0.0))
+	})
+})
@@ -0,0 +1,306 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/atomic"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	// minIPv4MTU is the minimum MTU every IPv4 link must support.
+	minIPv4MTU        = 68
+	ipv4HeaderLength  = 20
+	icmpHeaderLength  = 8
+	mtuProbeTimeout   = 500 * time.Millisecond
+	defaultMaxMTUSize = 1500
+)
+
+type mtuProbeArgs struct {
+	runnerArgs *runnerArgs
+	hosts      []string
+	minMTU     int
+	maxMTU     int
+}
+
+func (a *mtuProbeArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if err := a.runnerArgs.validateSampling(); err != nil {
+		return err
+	}
+	if a.maxMTU < minIPv4MTU || a.maxMTU > 65535 {
+		return fmt.Errorf("invalid max MTU %d", a.maxMTU)
+	}
+	if a.minMTU < 0 || a.minMTU > a.maxMTU {
+		return fmt.Errorf("invalid min MTU %d", a.minMTU)
+	}
+	var nodes []config.Node
+	if len(a.hosts) > 0 {
+		for _, host := range a.hosts {
+			parts, ok := splitArg(host, ":", 2, 2)
+			if !ok {
+				return fmt.Errorf("invalid job: %s: invalid host %s", strings.Join(a.runnerArgs.args, " "), host)
+			}
+			nodes = append(nodes, config.Node{
+				Hostname:   parts[0],
+				InternalIP: parts[1],
+			})
+		}
+	} else {
+		nodes = a.runnerArgs.clusterCfg.Nodes
+	}
+
+	config := a.runnerArgs.prepareConfig()
+	if r := NewMTUProbe(nodes, a.minMTU, a.maxMTU, config); r != nil {
+		a.runnerArgs.runner = r
+	}
+	return nil
+}
+
+func createMTUProbeCmd(ra *runnerArgs) *cobra.Command {
+	a := &mtuProbeArgs{runnerArgs: ra}
+	cmd := &cobra.Command{
+		Use:   "mtuProbe",
+		Short: "discovers the path MTU to a host with ICMP echo requests with don't fragment flag",
+		RunE:  a.createRunner,
+	}
+	cmd.Flags().StringSliceVar(&a.hosts, "hosts", nil, "Optional hosts in format <hostname>:<ip>. If not specified, the nodelist is used.")
+	cmd.Flags().IntVar(&a.minMTU, "min-mtu", 0, "fails if the discovered path MTU is below this size.")
+	cmd.Flags().IntVar(&a.maxMTU, "max-mtu", defaultMaxMTUSize, "largest packet size to probe.")
+	addSamplingFlags(cmd, ra)
+	return cmd
+}
+
+// NewMTUProbe creates a runner discovering the path MTU to the given nodes.
+func NewMTUProbe(nodes []config.Node, minMTU, maxMTU int, rconfig RunnerConfig) Runner {
+	if len(nodes) == 0 {
+		return nil
+	}
+	p := &mtuProbeOptions{minMTU: minMTU, maxMTU: maxMTU}
+	return &mtuProbe{
+		robinRound: robinRound[config.Node]{
+			itemsName: "nodes",
+			items:     config.CloneAndShuffle(nodes),
+			runFunc:   p.mtuProbeFunc,
+			config:    rconfig,
+		},
+		options: p,
+	}
+}
+
+type mtuProbeOptions struct {
+	minMTU int
+	maxMTU int
+}
+
+type mtuProbe struct {
+	robinRound[config.Node]
+	options     *mtuProbeOptions
+	checkOnce   sync.Once
+	unsupported atomic.Error
+}
+
+var _ Runner = &mtuProbe{}
+
+func (r *mtuProbe) TestData() any {
+	return []any{r.items, *r.options}
+}
+
+func (r *mtuProbe) Run(nodeName string, ch chan<- *nwpd.Observation) {
+	if !r.supported() {
+		// no observations, as raw sockets are not permitted in this environment
+		return
+	}
+	r.robinRound.Run(nodeName, ch)
+}
+
+func (r *mtuProbe) RunAll(nodeName string, destHosts []string, ch chan<- *nwpd.Observation) int {
+	if !r.supported() {
+		return 0
+	}
+	return r.robinRound.RunAll(nodeName, destHosts, ch)
+}
+
+// supported checks once if raw sockets are permitted.
+func (r *mtuProbe) supported() bool {
+	r.checkOnce.Do(func() {
+		conn, err := listenMTUProbe()
+		if err != nil {
+			if isPermissionError(err) {
+				r.unsupported.Store(err)
+			}
+			return
+		}
+		_ = conn.Close()
+	})
+	return r.unsupported.Load() == nil
+}
+
+func (r *mtuProbe) Description() string {
+	desc := r.robinRound.Description()
+	if err := r.unsupported.Load(); err != nil {
+		desc += fmt.Sprintf(" (disabled: %s)", err)
+	}
+	return desc
+}
+
+func isPermissionError(err error) bool {
+	return errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) ||
+		errors.Is(err, errors.ErrUnsupported)
+}
+
+// listenMTUProbe opens a raw ICMP socket with the don't fragment flag set on all sent packets.
+func listenMTUProbe() (*net.IPConn, error) {
+	conn, err := net.ListenIP("ip4:icmp", &net.IPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	if err := setDontFragment(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (o *mtuProbeOptions) mtuProbeFunc(node config.Node, fields resultFields) (string, error) {
+	ip := net.ParseIP(node.InternalIP).To4()
+	if ip == nil {
+		return "", fmt.Errorf("invalid IPv4 address %s", node.InternalIP)
+	}
+	conn, err := listenMTUProbe()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	id := rand.Intn(0xffff) // #nosec G404 -- only used to match replies
+	seq := 0
+	probe := func(size int) (bool, error) {
+		seq++
+		return sendMTUProbe(conn, &net.IPAddr{IP: ip}, id, seq, size)
+	}
+
+	largest := 0
+	if ok, err := probe(o.maxMTU); err != nil {
+		return "", err
+	} else if ok {
+		largest = o.maxMTU
+	} else {
+		// binary search between a delivered and a not delivered size
+		ok, err := probe(minIPv4MTU)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("no probe delivered")
+		}
+		low, high := minIPv4MTU, o.maxMTU
+		for high-low > 1 {
+			mid := (low + high) / 2
+			ok, err := probe(mid)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+		largest = low
+	}
+
+	fields.set(ResultFieldPathMTU, largest)
+	result := &resultparse.MTUProbe{PathMTU: largest}
+	if largest < o.minMTU {
+		result.Reason = "below minimum"
+		result.MinMTU = o.minMTU
+		return "", errors.New(result.Text())
+	}
+	return result.Text(), nil
+}
+
+// sendMTUProbe sends an ICMP echo request with the given IP packet size and waits for the reply.
+// It returns false if the packet is too large for the local interface or no reply is received in time.
+func sendMTUProbe(conn *net.IPConn, dest *net.IPAddr, id, seq, size int) (bool, error) {
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{
+			ID:   id,
+			Seq:  seq,
+			Data: make([]byte, size-ipv4HeaderLength-icmpHeaderLength),
+		},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return false, err
+	}
+	if _, err := conn.WriteTo(data, dest); err != nil {
+		if errors.Is(err, syscall.EMSGSIZE) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	deadline := time.Now().Add(mtuProbeTimeout)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return false, err
+	}
+	buf := make([]byte, size+ipv4HeaderLength)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return false, nil
+			}
+			return false, err
+		}
+		reply, err := icmp.ParseMessage(1, buf[:n])
+		if err != nil {
+			continue
+		}
+		switch reply.Type {
+		case ipv4.ICMPTypeEchoReply:
+			if echo, ok := reply.Body.(*icmp.Echo); ok && echo.ID == id && echo.Seq == seq && peer.String() == dest.String() {
+				return true, nil
+			}
+		case ipv4.ICMPTypeDestinationUnreachable:
+			// code 4: fragmentation needed and don't fragment was set
+			if reply.Code == 4 && isReplyTo(reply, id, seq) {
+				return false, nil
+			}
+		}
+	}
+}
+
+// isReplyTo checks if the ICMP error message refers to the echo request with given ID and sequence number.
+func isReplyTo(reply *icmp.Message, id, seq int) bool {
+	body, ok := reply.Body.(*icmp.DstUnreach)
+	if !ok || len(body.Data) < ipv4HeaderLength+icmpHeaderLength {
+		return false
+	}
+	header, err := ipv4.ParseHeader(body.Data)
+	if err != nil || len(body.Data) < header.Len+icmpHeaderLength {
+		return false
+	}
+	echo := body.Data[header.Len:]
+	return int(echo[4])<<8|int(echo[5]) == id && int(echo[6])<<8|int(echo[7]) == seq
+}
@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package runners
+
+import (
+	"errors"
+	"net"
+)
+
+// setDontFragment is only supported on Linux.
+func setDontFragment(_ *net.IPConn) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"net"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+var _ = Describe("mtuProbe", func() {
+	loopback := config.Node{Hostname: "localhost", InternalIP: "127.0.0.1"}
+
+	BeforeEach(func() {
+		conn, err := listenMTUProbe()
+		if err != nil {
+			Skip("raw sockets not permitted: " + err.Error())
+		}
+		_ = conn.Close()
+	})
+
+	It("reports the largest delivered size", func() {
+		options := &mtuProbeOptions{minMTU: 1400, maxMTU: 1500}
+		fields := resultFields{}
+		result, err := options.mtuProbeFunc(loopback, fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("pathMTU=1500"))
+		Expect(fields).To(Equal(resultFields{ResultFieldPathMTU: "1500"}))
+	})
+
+	It("fails for invalid addresses", func() {
+		options := &mtuProbeOptions{maxMTU: 1500}
+		_, err := options.mtuProbeFunc(config.Node{Hostname: "foo", InternalIP: "::1"}, resultFields{})
+		Expect(err).To(MatchError("invalid IPv4 address ::1"))
+	})
+})
+
+var _ = Describe("isReplyTo", func() {
+	It("matches the echo request embedded in a fragmentation needed message", func() {
+		echo, err := (&icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 0x1234, Seq: 7}}).Marshal(nil)
+		Expect(err).To(BeNil())
+		header, err := (&ipv4.Header{Version: 4, Len: ipv4.HeaderLen, TotalLen: ipv4.HeaderLen + len(echo), Protocol: 1,
+			Src: net.IPv4(10, 0, 0, 1), Dst: net.IPv4(10, 0, 0, 2)}).Marshal()
+		Expect(err).To(BeNil())
+		reply := &icmp.Message{Type: ipv4.ICMPTypeDestinationUnreachable, Code: 4, Body: &icmp.DstUnreach{Data: append(header, echo...)}}
+
+		Expect(isReplyTo(reply, 0x1234, 7)).To(BeTrue())
+		Expect(isReplyTo(reply, 0x1234, 8)).To(BeFalse())
+	})
+})
@@ -5,37 +5,61 @@
 package runners
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"net"
 
 	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
 
 	"github.com/spf13/cobra"
 )
 
 type nslookupArgs struct {
-	runnerArgs   *runnerArgs
-	internalKAPI bool
-	externalKAPI bool
-	names        []string
+	runnerArgs     *runnerArgs
+	internalKAPI   bool
+	externalKAPI   bool
+	names          []string
+	expectIPs      []string
+	expectKnownIPs bool
 }
 
 func (a *nslookupArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if len(a.expectIPs) > 0 && len(a.names) == 0 {
+		return fmt.Errorf("option --expect-ip requires --names")
+	}
+	for _, ip := range a.expectIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid expected IP %s", ip)
+		}
+	}
+
 	allowEmpty := false
 	var names []string
+	expectedIPs := map[string][]string{}
 	if len(a.names) > 0 {
 		for _, name := range a.names {
-			names = append(names, fullQualified(name))
+			fqname := fullQualified(name)
+			names = append(names, fqname)
+			if len(a.expectIPs) > 0 {
+				expectedIPs[fqname] = a.expectIPs
+			}
 		}
 	}
 	if a.internalKAPI {
 		names = append(names, "kubernetes.default.svc.cluster.local.")
+		if pe := a.runnerArgs.clusterCfg.InternalKubeAPIServer; a.expectKnownIPs && pe != nil && pe.IP != "" {
+			expectedIPs["kubernetes.default.svc.cluster.local."] = []string{pe.IP}
+		}
 	}
 	if a.externalKAPI {
 		allowEmpty = true
 		if pe := a.runnerArgs.clusterCfg.KubeAPIServer; pe != nil {
-			names = append(names, fullQualified(pe.Hostname))
+			fqname := fullQualified(pe.Hostname)
+			names = append(names, fqname)
+			if a.expectKnownIPs && pe.IP != "" {
+				expectedIPs[fqname] = []string{pe.IP}
+			}
 		}
 	}
 
@@ -44,7 +68,8 @@ func (a *nslookupArgs) createRunner(_ *cobra.Command, _ []string) error {
 	}
 
 	config := a.runnerArgs.prepareConfig()
-	if r := NewNSLookup(names, config); r != nil {
+	config.ExternalDestinations = len(a.names) > 0 || a.externalKAPI
+	if r := NewNSLookup(names, expectedIPs, config); r != nil {
 		a.runnerArgs.runner = r
 	}
 	return nil
@@ -60,16 +85,20 @@ func createNSLookupCmd(ra *runnerArgs) *cobra.Command {
 	cmd.Flags().StringSliceVar(&a.names, "names", nil, "DNS names")
 	cmd.Flags().BoolVar(&a.internalKAPI, "name-internal-kube-apiserver", false, "uses DNS name 'kubernetes.default.svc.cluster.local.'")
 	cmd.Flags().BoolVar(&a.externalKAPI, "name-external-kube-apiserver", false, "uses known external DNS name of kube-apiserver.")
+	cmd.Flags().StringSliceVar(&a.expectIPs, "expect-ip", nil, "IP address(es) which must be contained in the answers for the names given with '--names'.")
+	cmd.Flags().BoolVar(&a.expectKnownIPs, "expect-known-ips", false, "expects the known IP addresses from the cluster config in the answers for the kube-apiserver names.")
 	return cmd
 }
 
-func NewNSLookup(names []string, rconfig RunnerConfig) Runner {
+// NewNSLookup creates a runner looking up the given DNS names.
+// expectedIPs optionally maps a name to IP addresses which must be contained in its answers.
+func NewNSLookup(names []string, expectedIPs map[string][]string, rconfig RunnerConfig) Runner {
 	if len(names) == 0 {
 		return nil
 	}
 	var dnsNames []dnsName
 	for _, name := range names {
-		dnsNames = append(dnsNames, dnsName(name))
+		dnsNames = append(dnsNames, dnsName{name: name, expectedIPs: expectedIPs[name]})
 	}
 	return &nslookup{
 		robinRound[dnsName]{
@@ -81,10 +110,13 @@ func NewNSLookup(names []string, rconfig RunnerConfig) Runner {
 	}
 }
 
-type dnsName string
+type dnsName struct {
+	name        string
+	expectedIPs []string
+}
 
 func (n dnsName) DestHost() string {
-	return normalise(string(n))
+	return normalise(n.name)
 }
 
 type nslookup struct {
@@ -93,17 +125,35 @@ type nslookup struct {
 
 var _ Runner = &nslookup{}
 
-func lookupFunc(name dnsName) (string, error) {
-	ips, err := net.LookupIP(string(name))
+func lookupFunc(name dnsName, fields resultFields) (string, error) {
+	ips, err := net.LookupIP(name.name)
 	if err != nil {
 		return "", err
 	}
-	sb := bytes.Buffer{}
+	fields.set(ResultFieldAddresses, len(ips))
+	result := &resultparse.NSLookup{}
 	for _, ip := range ips {
-		if sb.Len() > 0 {
-			sb.Write([]byte(","))
+		result.Addresses = append(result.Addresses, ip.String())
+	}
+	if missing := missingIPs(name.expectedIPs, ips); len(missing) > 0 {
+		result.Reason = "expected IPs not found"
+		result.Missing = missing
+		return "", errors.New(result.Text())
+	}
+	return result.Text(), nil
+}
+
+func missingIPs(expected []string, ips []net.IP) []string {
+	var missing []string
+outer:
+	for _, e := range expected {
+		expectedIP := net.ParseIP(e)
+		for _, ip := range ips {
+			if ip.Equal(expectedIP) {
+				continue outer
+			}
 		}
-		sb.Write([]byte(ip.String()))
+		missing = append(missing, e)
 	}
-	return sb.String(), nil
+	return missing
 }
@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultPacketTrainPackets  = 50
+	defaultPacketTrainInterval = 1 * time.Millisecond
+	// minPacketTrainInterval limits the send rate to 10000 packets per second.
+	minPacketTrainInterval = 100 * time.Microsecond
+	// maxPacketTrainDuration is the maximum time for sending all packets of a train.
+	maxPacketTrainDuration    = 5 * time.Second
+	defaultPacketTrainSize    = 64
+	defaultPacketTrainSettle  = 200 * time.Millisecond
+	maxPacketTrainSettle      = 5 * time.Second
+	defaultPacketTrainMaxLoss = 5.0
+	packetTrainReportTimeout  = 5 * time.Second
+	maxPacketTrainReportSize  = 64 * 1024
+)
+
+// errPacketTrainUnsupported is returned if the peer agent does not listen for packet trains.
+var errPacketTrainUnsupported = errors.New("peer does not support packet trains")
+
+// packetTrainTarget is a peer agent receiving packet trains.
+type packetTrainTarget struct {
+	Hostname string
+	IP       string
+	// HTTPPort is the port of the http server of the peer agent providing the report.
+	HTTPPort int
+}
+
+func (t packetTrainTarget) DestHost() string {
+	return t.Hostname
+}
+
+type packetTrainArgs struct {
+	runnerArgs   *runnerArgs
+	podDS        bool
+	nodeHTTPPort int
+	options      packetTrainOptions
+}
+
+func (a *packetTrainArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if err := a.runnerArgs.validateSampling(); err != nil {
+		return err
+	}
+	if err := a.options.validate(); err != nil {
+		return err
+	}
+	if a.nodeHTTPPort < 0 || a.nodeHTTPPort > 65535 {
+		return fmt.Errorf("invalid node http port %d", a.nodeHTTPPort)
+	}
+	if a.podDS == (a.nodeHTTPPort != 0) {
+		return fmt.Errorf("either --endpoints-of-pod-ds or --node-http-port must be specified")
+	}
+	a.options.port = a.runnerArgs.clusterCfg.PacketTrainPort
+	if a.options.port == 0 {
+		// packet trains are not enabled in the cluster
+		return nil
+	}
+
+	var targets []packetTrainTarget
+	if a.podDS {
+		for _, pe := range a.runnerArgs.clusterCfg.PodEndpoints {
+			targets = append(targets, packetTrainTarget{Hostname: pe.Nodename, IP: pe.PodIP, HTTPPort: int(pe.Port)})
+		}
+	} else {
+		for _, n := range a.runnerArgs.clusterCfg.Nodes {
+			targets = append(targets, packetTrainTarget{Hostname: n.Hostname, IP: n.InternalIP, HTTPPort: a.nodeHTTPPort})
+		}
+	}
+
+	config := a.runnerArgs.prepareConfig()
+	if r := NewPacketTrain(targets, a.options, config); r != nil {
+		a.runnerArgs.runner = r
+	}
+	return nil
+}
+
+func createPacketTrainCmd(ra *runnerArgs) *cobra.Command {
+	a := &packetTrainArgs{runnerArgs: ra}
+	cmd := &cobra.Command{
+		Use:   "udpPacketTrain",
+		Short: "measures one-way loss, reordering and jitter of a burst of UDP packets to the peer agents",
+		RunE:  a.createRunner,
+	}
+	cmd.Flags().BoolVar(&a.podDS, "endpoints-of-pod-ds", false, "uses known pod endpoints of the 'nwpd-agent-pod-net' service.")
+	cmd.Flags().IntVar(&a.nodeHTTPPort, "node-http-port", 0, "http port of the agents in the host network, uses the nodes as destinations.")
+	cmd.Flags().IntVar(&a.options.packets, "packets", defaultPacketTrainPackets, fmt.Sprintf("number of packets per train (max %d).", common.MaxPacketTrainPackets))
+	cmd.Flags().DurationVar(&a.options.interval, "interval", defaultPacketTrainInterval, fmt.Sprintf("interval between two packets (min %s).", minPacketTrainInterval))
+	cmd.Flags().IntVar(&a.options.size, "size", defaultPacketTrainSize, fmt.Sprintf("UDP payload size of a packet, range [%d,%d].", common.MinPacketTrainPacketSize, common.MaxPacketTrainPacketSize))
+	cmd.Flags().DurationVar(&a.options.settle, "settle", defaultPacketTrainSettle, "wait time after the last packet before the report is requested.")
+	cmd.Flags().Float64Var(&a.options.maxLoss, "max-loss", defaultPacketTrainMaxLoss, "fails if the packet loss in percent is greater.")
+	addSamplingFlags(cmd, ra)
+	addSourceFlags(cmd, ra)
+	addDSCPFlag(cmd, ra)
+	return cmd
+}
+
+type packetTrainOptions struct {
+	port     int
+	packets  int
+	interval time.Duration
+	size     int
+	settle   time.Duration
+	maxLoss  float64
+	// socket selects the source address and the marking of the packets.
+	socket RunnerConfig
+}
+
+func (o *packetTrainOptions) validate() error {
+	if o.packets < 2 || o.packets > common.MaxPacketTrainPackets {
+		return fmt.Errorf("invalid packets %d, must be in range [2,%d]", o.packets, common.MaxPacketTrainPackets)
+	}
+	if o.interval < minPacketTrainInterval {
+		return fmt.Errorf("invalid interval %s, must be >= %s", o.interval, minPacketTrainInterval)
+	}
+	if time.Duration(o.packets-1)*o.interval > maxPacketTrainDuration {
+		return fmt.Errorf("invalid packets and interval, sending a train must not take longer than %s", maxPacketTrainDuration)
+	}
+	if o.size < common.MinPacketTrainPacketSize || o.size > common.MaxPacketTrainPacketSize {
+		return fmt.Errorf("invalid size %d, must be in range [%d,%d]", o.size, common.MinPacketTrainPacketSize, common.MaxPacketTrainPacketSize)
+	}
+	if o.settle <= 0 || o.settle > maxPacketTrainSettle {
+		return fmt.Errorf("invalid settle %s, must be in range (0s,%s]", o.settle, maxPacketTrainSettle)
+	}
+	if o.maxLoss < 0 || o.maxLoss > 100 {
+		return fmt.Errorf("invalid max-loss %g, must be in range [0,100]", o.maxLoss)
+	}
+	return nil
+}
+
+// NewPacketTrain creates a runner sending UDP packet trains to the given peer agents.
+func NewPacketTrain(targets []packetTrainTarget, options packetTrainOptions, rconfig RunnerConfig) Runner {
+	if len(targets) == 0 {
+		return nil
+	}
+	o := &options
+	o.socket = RunnerConfig{SourceIP: rconfig.SourceIP, DSCP: rconfig.DSCP}
+	return &packetTrain{
+		robinRound: robinRound[packetTrainTarget]{
+			itemsName: "agents",
+			items:     config.CloneAndShuffle(targets),
+			runFunc:   o.packetTrainFunc,
+			config:    rconfig,
+		},
+		options: o,
+	}
+}
+
+type packetTrain struct {
+	robinRound[packetTrainTarget]
+	options *packetTrainOptions
+}
+
+var _ Runner = &packetTrain{}
+
+func (r *packetTrain) TestData() any {
+	return []any{r.items, *r.options}
+}
+
+// packetTrainStats are the one-way delivery statistics of a packet train.
+type packetTrainStats struct {
+	received  int
+	loss      float64
+	reordered int
+	jitter    time.Duration
+}
+
+// analyzePacketTrain calculates the statistics from the report of the receiver and the send offsets of the packets.
+// A packet is counted as reordered if it arrives after a packet with a higher sequence number.
+// The jitter is the mean deviation of the arrival spacing from the send spacing of consecutively arrived packets.
+func analyzePacketTrain(report *common.PacketTrainReport, sent []time.Duration) packetTrainStats {
+	stats := packetTrainStats{}
+	seen := map[uint32]struct{}{}
+	var (
+		maxSeq        uint32
+		prevSeq       uint32
+		prevArrival   time.Duration
+		jitterSum     time.Duration
+		jitterSamples int
+	)
+	for i, seq := range report.Seqs {
+		if int(seq) >= len(sent) || i >= len(report.ArrivalMicros) {
+			continue
+		}
+		if _, ok := seen[seq]; ok {
+			continue
+		}
+		seen[seq] = struct{}{}
+		arrival := time.Duration(report.ArrivalMicros[i]) * time.Microsecond
+		if stats.received > 0 {
+			if seq < maxSeq {
+				stats.reordered++
+			}
+			d := (arrival - prevArrival) - (sent[seq] - sent[prevSeq])
+			if d < 0 {
+				d = -d
+			}
+			jitterSum += d
+			jitterSamples++
+		}
+		maxSeq = max(maxSeq, seq)
+		prevSeq = seq
+		prevArrival = arrival
+		stats.received++
+	}
+	stats.loss = float64(len(sent)-stats.received) * 100 / float64(len(sent))
+	if jitterSamples > 0 {
+		stats.jitter = jitterSum / time.Duration(jitterSamples)
+	}
+	return stats
+}
+
+func (o *packetTrainOptions) packetTrainFunc(target packetTrainTarget, fields resultFields) (string, error) {
+	var idBytes [8]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return "", err
+	}
+	trainID := binary.BigEndian.Uint64(idBytes[:])
+
+	sent, err := o.sendPacketTrain(target, trainID)
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		err = errPacketTrainUnsupported
+	}
+	if err == nil {
+		time.Sleep(o.settle)
+		var report *common.PacketTrainReport
+		report, err = fetchPacketTrainReport(target, trainID)
+		if err == nil {
+			return o.evaluate(report, sent, fields)
+		}
+	}
+	if errors.Is(err, errPacketTrainUnsupported) {
+		// a peer without the feature is no network problem
+		fields.set(ResultFieldPacketTrain, resultparse.PacketTrainUnsupported)
+		return (&resultparse.PacketTrain{Unsupported: true}).Text(), nil
+	}
+	return "", err
+}
+
+// sendPacketTrain sends the numbered packets with the configured interval and returns their send offsets.
+func (o *packetTrainOptions) sendPacketTrain(target packetTrainTarget, trainID uint64) ([]time.Duration, error) {
+	conn, err := probeDialerOf("udp", o.socket, 0).Dial("udp", net.JoinHostPort(target.IP, strconv.Itoa(o.port)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, o.size)
+	sent := make([]time.Duration, o.packets)
+	start := time.Now()
+	for i := range sent {
+		if d := time.Until(start.Add(time.Duration(i) * o.interval)); d > 0 {
+			time.Sleep(d)
+		}
+		common.PacketTrainPacket{TrainID: trainID, Seq: uint32(i), Count: uint32(o.packets)}.Encode(buf) // #nosec G115 -- limited by MaxPacketTrainPackets
+		sent[i] = time.Since(start)
+		if _, err := conn.Write(buf); err != nil {
+			return nil, fmt.Errorf("sending packet %d failed: %w", i, err)
+		}
+	}
+	return sent, nil
+}
+
+func fetchPacketTrainReport(target packetTrainTarget, trainID uint64) (*common.PacketTrainReport, error) {
+	client := &http.Client{Timeout: packetTrainReportTimeout}
+	url := fmt.Sprintf("http://%s%s?id=%d", net.JoinHostPort(target.IP, strconv.Itoa(target.HTTPPort)), common.PathPacketTrain, trainID)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("requesting packet train report failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errPacketTrainUnsupported
+	default:
+		return nil, fmt.Errorf("requesting packet train report failed with status code %d", resp.StatusCode)
+	}
+	report := &common.PacketTrainReport{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPacketTrainReportSize)).Decode(report); err != nil {
+		return nil, fmt.Errorf("invalid packet train report: %w", err)
+	}
+	return report, nil
+}
+
+func (o *packetTrainOptions) evaluate(report *common.PacketTrainReport, sent []time.Duration, fields resultFields) (string, error) {
+	stats := analyzePacketTrain(report, sent)
+	fields.set(ResultFieldPacketLoss, fmt.Sprintf("%.1f", stats.loss))
+	fields.set(ResultFieldReordered, stats.reordered)
+	fields.set(ResultFieldJitterMillis, fmt.Sprintf("%.3f", float64(stats.jitter)/float64(time.Millisecond)))
+	result := &resultparse.PacketTrain{Received: stats.received, Sent: len(sent), Loss: stats.loss, Reordered: stats.reordered, Jitter: stats.jitter,
+		Source: o.socket.SourceIP, DSCP: o.socket.DSCP}
+	setProbeSocketFields(o.socket, fields)
+	if stats.loss > o.maxLoss {
+		result.Reason = "loss above maximum"
+		result.MaxLoss = o.maxLoss
+		return "", errors.New(result.Text())
+	}
+	return result.Text(), nil
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakePacketTrainReceiver receives packet trains on a local UDP port and reports them over HTTP.
+// Every packet with a sequence number contained in drop is ignored.
+type fakePacketTrainReceiver struct {
+	conn   *net.UDPConn
+	server *httptest.Server
+	drop   map[uint32]bool
+
+	lock    sync.Mutex
+	reports map[uint64]*common.PacketTrainReport
+	first   map[uint64]time.Time
+}
+
+func newFakePacketTrainReceiver(drop map[uint32]bool) *fakePacketTrainReceiver {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	Expect(err).To(BeNil())
+	r := &fakePacketTrainReceiver{
+		conn:    conn,
+		drop:    drop,
+		reports: map[uint64]*common.PacketTrainReport{},
+		first:   map[uint64]time.Time{},
+	}
+	go func() {
+		buf := make([]byte, common.MaxPacketTrainPacketSize)
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			pkt, err := common.DecodePacketTrainPacket(buf[:n])
+			if err != nil || r.drop[pkt.Seq] {
+				continue
+			}
+			r.lock.Lock()
+			report := r.reports[pkt.TrainID]
+			if report == nil {
+				report = &common.PacketTrainReport{}
+				r.reports[pkt.TrainID] = report
+				r.first[pkt.TrainID] = time.Now()
+			}
+			report.Seqs = append(report.Seqs, pkt.Seq)
+			report.ArrivalMicros = append(report.ArrivalMicros, time.Since(r.first[pkt.TrainID]).Microseconds())
+			r.lock.Unlock()
+		}
+	}()
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id, _ := strconv.ParseUint(req.URL.Query().Get("id"), 10, 64)
+		r.lock.Lock()
+		report := r.reports[id]
+		r.lock.Unlock()
+		if report == nil {
+			report = &common.PacketTrainReport{}
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	}))
+	return r
+}
+
+func (r *fakePacketTrainReceiver) close() {
+	r.server.Close()
+	_ = r.conn.Close()
+}
+
+func (r *fakePacketTrainReceiver) target() packetTrainTarget {
+	return packetTrainTarget{Hostname: "node2", IP: "127.0.0.1", HTTPPort: r.server.Listener.Addr().(*net.TCPAddr).Port}
+}
+
+func (r *fakePacketTrainReceiver) udpPort() int {
+	return r.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+var _ = Describe("udpPacketTrain", func() {
+	options := func(port int) *packetTrainOptions {
+		return &packetTrainOptions{
+			port:     port,
+			packets:  20,
+			interval: 200 * time.Microsecond,
+			size:     64,
+			settle:   100 * time.Millisecond,
+			maxLoss:  10,
+		}
+	}
+
+	It("analyzes loss, reordering and jitter", func() {
+		sent := []time.Duration{0, 1 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond}
+		report := &common.PacketTrainReport{
+			Seqs:          []uint32{0, 2, 1, 4, 4, 9},
+			ArrivalMicros: []int64{0, 2000, 2500, 4000, 4100, 5000},
+		}
+		stats := analyzePacketTrain(report, sent)
+		Expect(stats.received).To(Equal(4))
+		Expect(stats.loss).To(Equal(20.0))
+		Expect(stats.reordered).To(Equal(1))
+		// spacing deviations: |2ms-2ms|, |0.5ms-(-1ms)|, |1.5ms-3ms|
+		Expect(stats.jitter).To(Equal(1 * time.Millisecond))
+	})
+
+	It("reports an empty train as complete loss", func() {
+		stats := analyzePacketTrain(&common.PacketTrainReport{}, make([]time.Duration, 10))
+		Expect(stats.received).To(Equal(0))
+		Expect(stats.loss).To(Equal(100.0))
+		Expect(stats.jitter).To(Equal(time.Duration(0)))
+	})
+
+	It("measures the one-way delivery to the peer", func() {
+		receiver := newFakePacketTrainReceiver(map[uint32]bool{3: true})
+		defer receiver.close()
+
+		fields := resultFields{}
+		result, err := options(receiver.udpPort()).packetTrainFunc(receiver.target(), fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(HavePrefix("received=19 sent=20 loss=5.0 reordered=0 jitter="))
+		Expect(fields[ResultFieldPacketLoss]).To(Equal("5.0"))
+		Expect(fields).To(HaveKey(ResultFieldReordered))
+		Expect(fields).To(HaveKey(ResultFieldJitterMillis))
+	})
+
+	It("fails if the loss exceeds the maximum", func() {
+		receiver := newFakePacketTrainReceiver(map[uint32]bool{1: true, 2: true, 3: true})
+		defer receiver.close()
+
+		fields := resultFields{}
+		_, err := options(receiver.udpPort()).packetTrainFunc(receiver.target(), fields)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(MatchRegexp(`^loss above maximum: received=17 sent=20 loss=15\.0 .* maxLoss=10$`))
+		Expect(fields[ResultFieldPacketLoss]).To(Equal("15.0"))
+	})
+
+	It("degrades gracefully if the peer does not provide reports", func() {
+		receiver := newFakePacketTrainReceiver(nil)
+		defer receiver.close()
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		target := receiver.target()
+		target.HTTPPort = server.Listener.Addr().(*net.TCPAddr).Port
+		fields := resultFields{}
+		result, err := options(receiver.udpPort()).packetTrainFunc(target, fields)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("packetTrain=unsupported"))
+		Expect(fields[ResultFieldPacketTrain]).To(Equal("unsupported"))
+	})
+
+	It("is skipped if packet trains are not enabled in the cluster", func() {
+		clusterCfg := config.ClusterConfig{Nodes: []config.Node{{Hostname: "node1", InternalIP: "10.0.0.11"}}}
+		job, err := Parse(clusterCfg, RunnerConfig{Job: config.Job{JobID: "test"}}, []string{"udpPacketTrain", "--node-http-port", "12996"}, &config.SampleConfig{})
+		Expect(err).To(BeNil())
+		Expect(job).To(BeNil())
+	})
+
+	It("validates the caps", func() {
+		o := options(1)
+		o.packets = common.MaxPacketTrainPackets + 1
+		Expect(o.validate()).To(MatchError(ContainSubstring("invalid packets")))
+		o = options(1)
+		o.interval = 10 * time.Microsecond
+		Expect(o.validate()).To(MatchError(ContainSubstring("invalid interval")))
+		o = options(1)
+		o.packets = 1000
+		o.interval = 10 * time.Millisecond
+		Expect(o.validate()).To(MatchError(ContainSubstring("must not take longer")))
+		o = options(1)
+		o.size = 8
+		Expect(o.validate()).To(MatchError(ContainSubstring("invalid size")))
+	})
+})
@@ -5,12 +5,14 @@
 package runners
 
 import (
+	"fmt"
 	"math"
 	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/common/config"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type runnerArgs struct {
@@ -19,6 +21,13 @@ type runnerArgs struct {
 	config      RunnerConfig
 	period      time.Duration
 	scalePeriod bool
+	retries     int
+	retryDelay  time.Duration
+	maxPeers    int
+	sample      string
+	sourceIP    string
+	iface       string
+	dscp        int
 	runner      Runner
 }
 
@@ -30,9 +39,52 @@ func (ra *runnerArgs) prepareConfig() RunnerConfig {
 	if ra.scalePeriod && len(ra.clusterCfg.Nodes) > 1 {
 		cfg.Period = time.Duration(math.Pow(float64(ra.clusterCfg.NodeCount), float64(0.6)) * float64(cfg.Period))
 	}
+	if ra.retries != 0 {
+		cfg.Retries = ra.retries
+	}
+	if ra.retryDelay != 0 {
+		cfg.RetryDelay = &metav1.Duration{Duration: ra.retryDelay}
+	}
+	if ra.sourceIP != "" {
+		cfg.SourceIP = ra.sourceIP
+	}
+	if ra.dscp != 0 {
+		cfg.DSCP = ra.dscp
+	}
+	if ra.maxPeers > 0 {
+		cfg.MaxPeers = ra.maxPeers
+		cfg.SampleStrategy = ra.sample
+		if cfg.SampleStrategy == "" {
+			cfg.SampleStrategy = SampleStrategyRandom
+		}
+	}
 	return cfg
 }
 
+const (
+	// SampleStrategyRandom rotates through the destinations in a random order which is stable per node and job.
+	SampleStrategyRandom = "random"
+	// SampleStrategyRing rotates through the destinations ordered by hostname starting with the ring neighbors of the node.
+	SampleStrategyRing = "ring"
+)
+
+func addSamplingFlags(cmd *cobra.Command, ra *runnerArgs) {
+	cmd.Flags().IntVar(&ra.maxPeers, "max-peers", 0, "number of destinations probed per run (rotating through all destinations).")
+	cmd.Flags().StringVar(&ra.sample, "sample", "", "rotation strategy if '--max-peers' is set: 'random' (default) or 'ring'.")
+}
+
+func (ra *runnerArgs) validateSampling() error {
+	if ra.maxPeers < 0 {
+		return fmt.Errorf("invalid max-peers %d", ra.maxPeers)
+	}
+	switch ra.sample {
+	case "", SampleStrategyRandom, SampleStrategyRing:
+	default:
+		return fmt.Errorf("invalid sample strategy %s (allowed: %s, %s)", ra.sample, SampleStrategyRandom, SampleStrategyRing)
+	}
+	return nil
+}
+
 func GetNewRoot(ra *runnerArgs) *cobra.Command {
 	root := &cobra.Command{
 		Use:   "runner",
@@ -40,10 +92,17 @@ func GetNewRoot(ra *runnerArgs) *cobra.Command {
 	}
 	root.PersistentFlags().DurationVar(&ra.period, "period", 0, "overwrites default execution period")
 	root.PersistentFlags().BoolVar(&ra.scalePeriod, "scale-period", false, "scales period by number of nodes")
+	root.PersistentFlags().IntVar(&ra.retries, "retries", 0, "overwrites number of additional attempts for a failing destination")
+	root.PersistentFlags().DurationVar(&ra.retryDelay, "retry-delay", 0, "overwrites delay between two attempts")
 	root.AddCommand(createPingHostCmd(ra))
 	root.AddCommand(createCheckTCPPortCmd(ra))
+	root.AddCommand(createCheckTCPPortMeshCmd(ra))
 	root.AddCommand(createCheckHTTPSGetArgs(ra))
 	root.AddCommand(createNSLookupCmd(ra))
+	root.AddCommand(createCheckGRPCHealthCmd(ra))
+	root.AddCommand(createMTUProbeCmd(ra))
+	root.AddCommand(createARPPingCmd(ra))
+	root.AddCommand(createPacketTrainCmd(ra))
 	return root
 }
 
@@ -61,6 +120,22 @@ func Parse(clusterCfg config.ClusterConfig, config RunnerConfig, args []string,
 		return nil, cmd.FlagErrorFunc()(cmd, err)
 	}
 
+	if cmd.RunE == nil {
+		return nil, fmt.Errorf("missing job type")
+	}
+	if ra.period < 0 || ra.retries < 0 || ra.retryDelay < 0 {
+		return nil, fmt.Errorf("negative values not allowed for period, retries, or retry delay")
+	}
+	if err := validateFailureBackoff(config.FailureBackoff); err != nil {
+		return nil, err
+	}
+	if ra.sourceIP, err = sourceAddressOf(ra.sourceIP, ra.iface); err != nil {
+		return nil, err
+	}
+	if ra.dscp < 0 || ra.dscp > maxDSCP {
+		return nil, fmt.Errorf("invalid dscp %d, must be in range [0,%d]", ra.dscp, maxDSCP)
+	}
+
 	ra.args = args
 	ra.clusterCfg = sampleCfg.ShuffledSample(clusterCfg)
 	ra.config = config
@@ -72,5 +147,9 @@ func Parse(clusterCfg config.ClusterConfig, config RunnerConfig, args []string,
 	if ra.runner == nil {
 		return nil, nil
 	}
+	if zr, ok := ra.runner.(zoneRunner); ok {
+		// the zones of all nodes are needed, as the source node may not be part of the sample
+		zr.setNodeZones(clusterCfg.NodeZones())
+	}
 	return NewInternalJob(ra.runner, len(ra.clusterCfg.Nodes)), nil
 }
@@ -5,6 +5,9 @@
 package runners
 
 import (
+	"net"
+	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/common"
@@ -12,6 +15,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 func init() {
@@ -41,6 +46,18 @@ var _ = Describe("parser", func() {
 				IP:       "1.2.3.4",
 				Port:     443,
 			},
+			Services: []config.ServiceEndpoint{
+				{Namespace: "kube-system", Name: "kube-dns", ClusterIP: "100.64.0.10", Port: 53},
+			},
+		}
+		// node3 has no external address
+		clusterCfgExternal = config.ClusterConfig{
+			NodeCount: 3,
+			Nodes: []config.Node{
+				{Hostname: "node1", InternalIP: "10.0.0.11", ExternalIP: "1.2.3.11"},
+				{Hostname: "node2", InternalIP: "10.0.0.12", ExternalDNS: "node2.example.com"},
+				{Hostname: "node3", InternalIP: "10.0.0.13"},
+			},
 		}
 		config2     = RunnerConfig{Job: config.Job{JobID: "test"}, Period: 10 * time.Second}
 		clusterCfg2 = config.ClusterConfig{
@@ -78,8 +95,29 @@ var _ = Describe("parser", func() {
 		dnsnames = []string{
 			"eu.gcr.io.", "foo.bar.", common.DomainNameKubernetesService, "api.shoot.domain.com.",
 		}
+		// external marks the destinations as external, so that the failure backoff is enabled by default
+		external = func(cfg RunnerConfig) RunnerConfig {
+			cfg.ExternalDestinations = true
+			return cfg
+		}
+		withSource = func(cfg RunnerConfig, sourceIP string) RunnerConfig {
+			cfg.SourceIP = sourceIP
+			return cfg
+		}
+		withDSCP = func(cfg RunnerConfig, dscp int) RunnerConfig {
+			cfg.DSCP = dscp
+			return cfg
+		}
 	)
 
+	It("passes the zones of all nodes to the runner", func() {
+		clusterCfg := clusterCfg1
+		clusterCfg.Nodes = []config.Node{{Hostname: "node1", Zone: "zone-a"}, {Hostname: "node2", Zone: "zone-b"}}
+		job, err := Parse(clusterCfg, config1, []string{"pingHost", "--hosts", "node2:10.0.0.12"}, &config.SampleConfig{})
+		Expect(err).To(BeNil())
+		Expect(job.runner.(*pingHost).nodeZones).To(Equal(map[string]string{"node1": "zone-a", "node2": "zone-b"}))
+	})
+
 	DescribeTable("should parse runner commands",
 		func(clusterCfg config.ClusterConfig, runnerConfig RunnerConfig, args []string, expected interface{}) {
 			actual, err := Parse(clusterCfg, runnerConfig, args, &config.SampleConfig{})
@@ -102,34 +140,243 @@ var _ = Describe("parser", func() {
 			[]string{"pingHost", "--period", "10s", "--hosts", "node3:10.0.0.13,node4:10.0.0.14"}, NewPingHost(clusterCfg2.Nodes, config2)),
 		Entry("pingHost - invalid option", clusterCfg1, config1,
 			[]string{"pingHost", "--foo"}, "unknown flag: --foo"),
+		Entry("pingHost with sampling", clusterCfg1, config1,
+			[]string{"pingHost", "--max-peers", "5", "--sample", "ring"},
+			NewPingHost(clusterCfg1.Nodes, RunnerConfig{Job: config1.Job, Period: config1.Period, MaxPeers: 5, SampleStrategy: SampleStrategyRing})),
+		Entry("checkTCPPort with node port and default sampling", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--max-peers", "1"},
+			NewCheckTCPPort(endpoints2, nil, RunnerConfig{Job: config1.Job, Period: config1.Period, MaxPeers: 1, SampleStrategy: SampleStrategyRandom})),
+		Entry("pingHost - invalid sample strategy", clusterCfg1, config1,
+			[]string{"pingHost", "--max-peers", "5", "--sample", "foo"}, "invalid sample strategy foo"),
 		Entry("pingHost - invalid host", clusterCfg1, config1,
 			[]string{"pingHost", "--hosts", "node3"}, "invalid host node3"),
 		Entry("checkTCPPort", clusterCfg1, config1,
-			[]string{"checkTCPPort", "--period", "10s", "--endpoints", "server:10.0.0.9:55555"}, NewCheckTCPPort(endpoints1, config2)),
+			[]string{"checkTCPPort", "--period", "10s", "--endpoints", "server:10.0.0.9:55555"}, NewCheckTCPPort(endpoints1, nil, external(config2))),
 		Entry("checkTCPPort - missing endpoints", clusterCfg1, config1,
 			[]string{"checkTCPPort"}, "no endpoints"),
 		Entry("checkTCPPort - invalid endpoint", clusterCfg1, config1,
 			[]string{"checkTCPPort", "--endpoints", "server:10.0.0.9:x"}, "invalid endpoint port x"),
+		Entry("checkTCPPort - invalid node port", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "-1"}, "invalid node port -1"),
+		Entry("pingHost with CIDR", clusterCfg1, config1,
+			[]string{"pingHost", "--cidr", "10.0.1.0/30", "--hosts", "node3:10.0.0.13"},
+			NewPingHost([]config.Node{
+				{Hostname: "10.0.1.1", InternalIP: "10.0.1.1"},
+				{Hostname: "10.0.1.2", InternalIP: "10.0.1.2"},
+				{Hostname: "node3", InternalIP: "10.0.0.13"},
+			}, config1)),
+		Entry("pingHost - CIDR exceeding limit", clusterCfg1, config1,
+			[]string{"pingHost", "--cidr", "10.0.0.0/16"}, "expand to more than 256 addresses"),
+		Entry("checkTCPPort with CIDRs", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--cidr", "100.64.0.8/31:443,fd00::10/127:8080"},
+			NewCheckTCPPort([]config.Endpoint{
+				{Hostname: "100.64.0.8", IP: "100.64.0.8", Port: 443},
+				{Hostname: "100.64.0.9", IP: "100.64.0.9", Port: 443},
+				{Hostname: "fd00::10", IP: "fd00::10", Port: 8080},
+				{Hostname: "fd00::11", IP: "fd00::11", Port: 8080},
+			}, nil, config1)),
+		Entry("checkTCPPort - CIDRs exceeding configured limit", clusterCfg1,
+			RunnerConfig{Job: config1.Job, Period: config1.Period, MaxCIDRAddresses: 3},
+			[]string{"checkTCPPort", "--cidr", "100.64.0.8/31:443,100.64.0.10/31:443"}, "max 3 addresses per job"),
+		Entry("checkTCPPort - invalid CIDR", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--cidr", "100.64.0.9/30:443"}, "host bits set, use 100.64.0.8/30"),
+		Entry("checkTCPPort - CIDR without port", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--cidr", "100.64.0.8/30"}, "invalid CIDR destination 100.64.0.8/30"),
+		Entry("pingHost with external addresses", clusterCfgExternal, config1,
+			[]string{"pingHost", "--external-address"},
+			NewPingHost([]config.Node{
+				{Hostname: "node1", InternalIP: "1.2.3.11", ExternalIP: "1.2.3.11"},
+				{Hostname: "node2", InternalIP: "node2.example.com", ExternalDNS: "node2.example.com"},
+			}, config1)),
+		Entry("pingHost - external addresses with hosts", clusterCfgExternal, config1,
+			[]string{"pingHost", "--external-address", "--hosts", "node3:10.0.0.13"}, "cannot be combined with --hosts"),
+		Entry("checkTCPPort with node port on external addresses", clusterCfgExternal, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--external-address"},
+			NewCheckTCPPort([]config.Endpoint{
+				{Hostname: "node1", IP: "1.2.3.11", Port: 55555},
+				{Hostname: "node2", IP: "node2.example.com", Port: 55555},
+			}, nil, config1)),
+		Entry("checkTCPPort - external addresses without node port", clusterCfgExternal, config1,
+			[]string{"checkTCPPort", "--external-address", "--endpoints-of-pod-ds"}, "requires --node-port"),
+		Entry("checkTCPPortMesh", clusterCfg1, config1,
+			[]string{"checkTCPPortMesh", "--port", "55555"}, NewCheckTCPPortMesh(endpoints2, config1)),
+		Entry("checkTCPPortMesh on external addresses", clusterCfgExternal, config1,
+			[]string{"checkTCPPortMesh", "--port", "55555", "--external-address"},
+			NewCheckTCPPortMesh([]config.Endpoint{
+				{Hostname: "node1", IP: "1.2.3.11", Port: 55555},
+				{Hostname: "node2", IP: "node2.example.com", Port: 55555},
+			}, config1)),
+		Entry("checkTCPPortMesh - missing port", clusterCfg1, config1,
+			[]string{"checkTCPPortMesh"}, "missing option --port"),
+		Entry("checkTCPPortMesh - invalid port", clusterCfg1, config1,
+			[]string{"checkTCPPortMesh", "--port", "70000"}, "invalid port 70000"),
+		Entry("checkTCPPortMesh - sampling not supported", clusterCfg1, config1,
+			[]string{"checkTCPPortMesh", "--port", "55555", "--max-peers", "1"}, "unknown flag: --max-peers"),
+		Entry("missing job type", clusterCfg1, config1,
+			[]string{"--period", "10s"}, "missing job type"),
+		Entry("pingHost - negative retries", clusterCfg1, config1,
+			[]string{"pingHost", "--retries", "-1"}, "negative values not allowed"),
 		Entry("checkTCPPort with node port", clusterCfg1, config1,
-			[]string{"checkTCPPort", "--node-port", "55555"}, NewCheckTCPPort(endpoints2, config1)),
+			[]string{"checkTCPPort", "--node-port", "55555"}, NewCheckTCPPort(endpoints2, nil, config1)),
 		Entry("checkTCPPort with pod endpoints", clusterCfg1, config1,
-			[]string{"checkTCPPort", "--endpoints-of-pod-ds"}, NewCheckTCPPort(endpointsPods, config1)),
+			[]string{"checkTCPPort", "--endpoints-of-pod-ds"}, NewCheckTCPPort(endpointsPods, nil, config1)),
+		Entry("checkTCPPort with pod endpoints and pod UID verification", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--endpoints-of-pod-ds", "--verify-pod-uid"}, NewCheckPodIdentity(clusterCfg1.PodEndpoints, config1)),
+		Entry("checkTCPPort - pod UID verification without pod endpoints", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "1234", "--verify-pod-uid"}, "option --verify-pod-uid requires --endpoints-of-pod-ds"),
+		Entry("checkTCPPort with banner check", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--send", `PING\r\n`, "--expect", `^\+PONG`},
+			NewCheckTCPPort(endpoints2, &TCPBannerOptions{Send: "PING\r\n", Expect: regexp.MustCompile(`^\+PONG`), ReadTimeout: 5 * time.Second}, config1)),
+		Entry("checkTCPPort with banner check without send string", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--expect", "^SSH-", "--read-timeout", "2s"},
+			NewCheckTCPPort(endpoints2, &TCPBannerOptions{Expect: regexp.MustCompile("^SSH-"), ReadTimeout: 2 * time.Second}, config1)),
+		Entry("checkTCPPort - invalid expect pattern", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--expect", "("}, "invalid expect pattern"),
+		Entry("checkTCPPort - invalid read timeout", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--send", "x", "--read-timeout", "0s"}, "invalid read timeout 0s"),
+		Entry("checkTCPPort - banner check with pod UID verification", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--endpoints-of-pod-ds", "--verify-pod-uid", "--send", "x"}, "cannot be combined with --verify-pod-uid"),
 		Entry("checkTCPPort with internal kube-apiserver endpoints", clusterCfg1, config1,
-			[]string{"checkTCPPort", "--endpoint-internal-kube-apiserver"}, NewCheckTCPPort(endpointsInternalKubeAPIServer, config1)),
+			[]string{"checkTCPPort", "--endpoint-internal-kube-apiserver"}, NewCheckTCPPort(endpointsInternalKubeAPIServer, nil, config1)),
+		Entry("checkTCPPort with service endpoints", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--services"},
+			NewCheckTCPPort([]config.Endpoint{{Hostname: "kube-dns.kube-system.svc", IP: "100.64.0.10", Port: 53}}, nil, config1)),
 		Entry("checkTCPPort with external kube-apiserver endpoints", clusterCfg1, config1,
-			[]string{"checkTCPPort", "--endpoint-external-kube-apiserver"}, NewCheckTCPPort(endpointsKubeAPIServer, config1)),
+			[]string{"checkTCPPort", "--endpoint-external-kube-apiserver"}, NewCheckTCPPort(endpointsKubeAPIServer, nil, external(config1))),
 		Entry("checkHTTPSGet", clusterCfg1, config1,
-			[]string{"checkHTTPSGet", "--period", "10s", "--endpoints", "server:55555,server2"}, NewCheckTCPPort(httpsEndpoints1, config2)),
+			[]string{"checkHTTPSGet", "--period", "10s", "--endpoints", "server:55555,server2"}, NewCheckHTTPSGet(httpsEndpoints1, nil, external(config2))),
+		Entry("checkHTTPSGet with headers and expected status", clusterCfg1, config1,
+			[]string{"checkHTTPSGet", "--endpoints", "server:55555,server2", "--header", "Host: foo.example.com", "--header", "X-Test:a,b", "--expect-status", "200,204"},
+			NewCheckHTTPSGet(httpsEndpoints1, &HTTPSGetOptions{
+				Headers:        http.Header{"Host": {"foo.example.com"}, "X-Test": {"a,b"}},
+				ExpectedStatus: []int{200, 204},
+			}, external(config1))),
+		Entry("checkHTTPSGet without redirects", clusterCfg1, config1,
+			[]string{"checkHTTPSGet", "--endpoints", "server:55555,server2", "--max-redirects", "0"},
+			NewCheckHTTPSGet(httpsEndpoints1, &HTTPSGetOptions{MaxRedirects: ptr.To(0)}, external(config1))),
+		Entry("checkHTTPSGet - invalid header", clusterCfg1, config1,
+			[]string{"checkHTTPSGet", "--endpoints", "server", "--header", "foo"}, "invalid header"),
+		Entry("checkHTTPSGet - invalid expected status", clusterCfg1, config1,
+			[]string{"checkHTTPSGet", "--endpoints", "server", "--expect-status", "1000"}, "invalid expected status 1000"),
 		Entry("checkHTTPSGet - missing endpoints", clusterCfg1, config1,
 			[]string{"checkHTTPSGet"}, "no endpoints"),
 		Entry("checkHTTPSGet - invalid endpoint", clusterCfg1, config1,
 			[]string{"checkHTTPSGet", "--endpoints", "server:x"}, "invalid endpoint port x"),
 		Entry("checkHTTPSGet with internal kube-apiserver endpoints", clusterCfg1, config1,
-			[]string{"checkHTTPSGet", "--endpoint-internal-kube-apiserver"}, NewCheckHTTPSGet(httpsEndpointsInternalKubeAPIServer, config1)),
+			[]string{"checkHTTPSGet", "--endpoint-internal-kube-apiserver"}, NewCheckHTTPSGet(httpsEndpointsInternalKubeAPIServer, nil, config1)),
 		Entry("checkHTTPSGet with external kube-apiserver endpoints", clusterCfg1, config1,
-			[]string{"checkHTTPSGet", "--endpoint-external-kube-apiserver"}, NewCheckHTTPSGet(endpointsKubeAPIServer, config1)),
+			[]string{"checkHTTPSGet", "--endpoint-external-kube-apiserver"}, NewCheckHTTPSGet(endpointsKubeAPIServer, nil, external(config1))),
+		Entry("checkGRPCHealth", clusterCfg1, config1,
+			[]string{"checkGRPCHealth", "--host", "server", "--port", "50051", "--tls", "--service", "foo"},
+			NewCheckGRPCHealth([]config.Endpoint{{Hostname: "server", Port: 50051}}, &GRPCHealthOptions{Service: "foo", TLS: true}, external(config1))),
+		Entry("checkGRPCHealth - missing host", clusterCfg1, config1,
+			[]string{"checkGRPCHealth", "--port", "50051"}, "missing host"),
+		Entry("checkGRPCHealth - invalid port", clusterCfg1, config1,
+			[]string{"checkGRPCHealth", "--host", "server", "--port", "70000"}, "invalid port 70000"),
+		Entry("mtuProbe", clusterCfg1, config1,
+			[]string{"mtuProbe", "--min-mtu", "1400"}, NewMTUProbe(clusterCfg1.Nodes, 1400, 1500, config1)),
+		Entry("mtuProbe with hosts", clusterCfg1, config1,
+			[]string{"mtuProbe", "--hosts", "host1:10.0.0.1", "--max-mtu", "9000"}, NewMTUProbe([]config.Node{{Hostname: "host1", InternalIP: "10.0.0.1"}}, 0, 9000, config1)),
+		Entry("mtuProbe - invalid min MTU", clusterCfg1, config1,
+			[]string{"mtuProbe", "--min-mtu", "2000"}, "invalid min MTU 2000"),
+		Entry("arpPing", clusterCfg1, config1,
+			[]string{"arpPing"}, NewARPPing([]config.Node{{Hostname: defaultGatewayHost}}, config1)),
+		Entry("arpPing with hosts", clusterCfg1, config1,
+			[]string{"arpPing", "--hosts", "peer1:10.0.0.2"}, NewARPPing([]config.Node{{Hostname: "peer1", InternalIP: "10.0.0.2"}}, config1)),
+		Entry("arpPing - invalid host", clusterCfg1, config1,
+			[]string{"arpPing", "--hosts", "peer1:fd00::1"}, "invalid job: arpPing --hosts peer1:fd00::1: invalid host peer1:fd00::1"),
+		Entry("udpPacketTrain with pod endpoints", withPacketTrainPort(clusterCfg1), config1,
+			[]string{"udpPacketTrain", "--endpoints-of-pod-ds", "--packets", "100"},
+			NewPacketTrain([]packetTrainTarget{
+				{Hostname: "node1", IP: "10.128.0.11", HTTPPort: 1234},
+				{Hostname: "node2", IP: "10.128.0.12", HTTPPort: 1234},
+			}, packetTrainOptions{port: common.PacketTrainPort, packets: 100, interval: 1 * time.Millisecond, size: 64, settle: 200 * time.Millisecond, maxLoss: 5}, config1)),
+		Entry("udpPacketTrain with nodes", withPacketTrainPort(clusterCfg1), config1,
+			[]string{"udpPacketTrain", "--node-http-port", "12996", "--max-loss", "1"},
+			NewPacketTrain([]packetTrainTarget{
+				{Hostname: "node1", IP: "10.0.0.11", HTTPPort: 12996},
+				{Hostname: "node2", IP: "10.0.0.12", HTTPPort: 12996},
+			}, packetTrainOptions{port: common.PacketTrainPort, packets: 50, interval: 1 * time.Millisecond, size: 64, settle: 200 * time.Millisecond, maxLoss: 1}, config1)),
+		Entry("udpPacketTrain - missing destinations", withPacketTrainPort(clusterCfg1), config1,
+			[]string{"udpPacketTrain"}, "either --endpoints-of-pod-ds or --node-http-port must be specified"),
+		Entry("udpPacketTrain - rate too high", withPacketTrainPort(clusterCfg1), config1,
+			[]string{"udpPacketTrain", "--endpoints-of-pod-ds", "--interval", "10us"}, "invalid interval 10µs"),
+		Entry("udpPacketTrain with source IP", withPacketTrainPort(clusterCfg1), config1,
+			[]string{"udpPacketTrain", "--node-http-port", "12996", "--source-ip", "127.0.0.1"},
+			NewPacketTrain([]packetTrainTarget{
+				{Hostname: "node1", IP: "10.0.0.11", HTTPPort: 12996},
+				{Hostname: "node2", IP: "10.0.0.12", HTTPPort: 12996},
+			}, packetTrainOptions{port: common.PacketTrainPort, packets: 50, interval: 1 * time.Millisecond, size: 64, settle: 200 * time.Millisecond, maxLoss: 5},
+				withSource(config1, "127.0.0.1"))),
+		Entry("checkTCPPort with source IP", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--endpoints", "server:10.0.0.9:55555", "--source-ip", "127.0.0.1"},
+			NewCheckTCPPort(endpoints1, nil, withSource(external(config1), "127.0.0.1"))),
+		Entry("checkTCPPortMesh with source interface", clusterCfg1, config1,
+			[]string{"checkTCPPortMesh", "--port", "55555", "--interface", loopbackInterface()},
+			NewCheckTCPPortMesh(endpoints2, withSource(config1, "127.0.0.1"))),
+		Entry("pingHost with source IP of interface", clusterCfg1, config1,
+			[]string{"pingHost", "--source-ip", "::ffff:127.0.0.1", "--interface", loopbackInterface()},
+			NewPingHost(clusterCfg1.Nodes, withSource(config1, "127.0.0.1"))),
+		Entry("pingHost - source IP not local", clusterCfg1, config1,
+			[]string{"pingHost", "--source-ip", "203.0.113.1"}, "source IP 203.0.113.1 is not a local address"),
+		Entry("pingHost - source IP not of interface", clusterCfg1, config1,
+			[]string{"pingHost", "--source-ip", "203.0.113.1", "--interface", loopbackInterface()},
+			"source IP 203.0.113.1 is not an address of interface "+loopbackInterface()),
+		Entry("pingHost - invalid source IP", clusterCfg1, config1,
+			[]string{"pingHost", "--source-ip", "10.0.0"}, "invalid source IP 10.0.0"),
+		Entry("checkTCPPort with DSCP", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--dscp", "46"}, NewCheckTCPPort(endpoints2, nil, withDSCP(config1, 46))),
+		Entry("udpPacketTrain with DSCP", withPacketTrainPort(clusterCfg1), config1,
+			[]string{"udpPacketTrain", "--endpoints-of-pod-ds", "--dscp", "10"},
+			NewPacketTrain([]packetTrainTarget{
+				{Hostname: "node1", IP: "10.128.0.11", HTTPPort: 1234},
+				{Hostname: "node2", IP: "10.128.0.12", HTTPPort: 1234},
+			}, packetTrainOptions{port: common.PacketTrainPort, packets: 50, interval: 1 * time.Millisecond, size: 64, settle: 200 * time.Millisecond, maxLoss: 5},
+				withDSCP(config1, 10))),
+		Entry("pingHost - invalid DSCP", clusterCfg1, config1,
+			[]string{"pingHost", "--dscp", "64"}, "invalid dscp 64, must be in range [0,63]"),
+		Entry("checkTCPPortMesh - negative DSCP", clusterCfg1, config1,
+			[]string{"checkTCPPortMesh", "--port", "55555", "--dscp", "-1"}, "invalid dscp -1, must be in range [0,63]"),
+		Entry("checkTCPPort - unknown interface", clusterCfg1, config1,
+			[]string{"checkTCPPort", "--node-port", "55555", "--interface", "nwpd-missing0"}, "invalid interface nwpd-missing0"),
 		Entry("nslookup with host names", clusterCfg1, config1,
 			[]string{"nslookup", "--names", "eu.gcr.io,foo.bar.", "--name-internal-kube-apiserver", "--name-external-kube-apiserver"},
-			NewNSLookup(dnsnames, config1)),
+			NewNSLookup(dnsnames, nil, external(config1))),
+		Entry("nslookup with expected IPs", clusterCfg1, config1,
+			[]string{"nslookup", "--names", "eu.gcr.io", "--expect-ip", "1.1.1.1", "--expect-ip", "2.2.2.2,3.3.3.3"},
+			NewNSLookup([]string{"eu.gcr.io."}, map[string][]string{"eu.gcr.io.": {"1.1.1.1", "2.2.2.2", "3.3.3.3"}}, external(config1))),
+		Entry("nslookup with expected known IPs", clusterCfg1, config1,
+			[]string{"nslookup", "--name-internal-kube-apiserver", "--name-external-kube-apiserver", "--expect-known-ips"},
+			NewNSLookup([]string{"kubernetes.default.svc.cluster.local.", "api.shoot.domain.com."},
+				map[string][]string{"kubernetes.default.svc.cluster.local.": {"100.64.0.1"}, "api.shoot.domain.com.": {"1.2.3.4"}}, external(config1))),
+		Entry("nslookup with retries", clusterCfg1, config1,
+			[]string{"nslookup", "--names", "eu.gcr.io", "--retries", "2", "--retry-delay", "500ms"},
+			NewNSLookup([]string{"eu.gcr.io."}, nil, RunnerConfig{
+				Job:                  config.Job{JobID: "test", Retries: 2, RetryDelay: &metav1.Duration{Duration: 500 * time.Millisecond}},
+				Period:               15 * time.Second,
+				ExternalDestinations: true,
+			})),
+		Entry("nslookup - invalid expected IP", clusterCfg1, config1,
+			[]string{"nslookup", "--names", "eu.gcr.io", "--expect-ip", "foo"}, "invalid expected IP foo"),
+		Entry("nslookup - expected IP without names", clusterCfg1, config1,
+			[]string{"nslookup", "--name-internal-kube-apiserver", "--expect-ip", "1.1.1.1"}, "option --expect-ip requires --names"),
 	)
 })
+
+func withPacketTrainPort(clusterCfg config.ClusterConfig) config.ClusterConfig {
+	clusterCfg.PacketTrainPort = common.PacketTrainPort
+	return clusterCfg
+}
+
+// loopbackInterface returns the name of the loopback interface with the address 127.0.0.1.
+func loopbackInterface() string {
+	ifaces, err := net.Interfaces()
+	Expect(err).To(BeNil())
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface.Name
+		}
+	}
+	Fail("no loopback interface")
+	return ""
+}
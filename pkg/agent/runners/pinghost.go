@@ -5,28 +5,50 @@
 package runners
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
 
 	"github.com/go-ping/ping"
 	"github.com/spf13/cobra"
 	"go.uber.org/atomic"
 )
 
+// pingTimeout is the maximum time for receiving the reply.
+const pingTimeout = 1 * time.Second
+
 type pingHostArgs struct {
 	runnerArgs *runnerArgs
 	hosts      []string
+	cidrs      []string
+	external   bool
 }
 
 func (a *pingHostArgs) createRunner(_ *cobra.Command, _ []string) error {
+	if err := a.runnerArgs.validateSampling(); err != nil {
+		return err
+	}
 	var nodes []config.Node
-	if len(a.hosts) > 0 {
+	if a.external && (len(a.hosts) > 0 || len(a.cidrs) > 0) {
+		return fmt.Errorf("option --external-address cannot be combined with --hosts or --cidr")
+	}
+	if len(a.hosts) > 0 || len(a.cidrs) > 0 {
+		maxAddresses := maxCIDRAddressesOf(a.runnerArgs.config)
+		addresses, err := expandCIDRs(a.cidrs, maxAddresses)
+		if err != nil {
+			return fmt.Errorf("%s (max %d addresses per job)", err, maxAddresses)
+		}
+		for _, addr := range addresses {
+			nodes = append(nodes, config.Node{Hostname: addr, InternalIP: addr})
+		}
 		for _, host := range a.hosts {
-			parts := strings.SplitN(host, ":", 2)
-			if len(parts) != 2 {
+			parts, ok := splitArg(host, ":", 2, 2)
+			if !ok {
 				return fmt.Errorf("invalid job: %s: invalid host %s", strings.Join(a.runnerArgs.args, " "), host)
 			}
 			nodes = append(nodes, config.Node{
@@ -34,6 +56,8 @@ func (a *pingHostArgs) createRunner(_ *cobra.Command, _ []string) error {
 				InternalIP: parts[1],
 			})
 		}
+	} else if a.external {
+		nodes = externalAddressNodes(a.runnerArgs.clusterCfg.Nodes)
 	} else {
 		nodes = a.runnerArgs.clusterCfg.Nodes
 	}
@@ -53,6 +77,11 @@ func createPingHostCmd(ra *runnerArgs) *cobra.Command {
 		RunE:  a.createRunner,
 	}
 	cmd.Flags().StringSliceVar(&a.hosts, "hosts", nil, "Optional hosts in format <hostname>:<ip>. If not specified, the nodelist is used.")
+	cmd.Flags().BoolVar(&a.external, "external-address", false, "pings the external addresses of the nodes instead of the internal IPs. Nodes without external address are skipped.")
+	cmd.Flags().StringSliceVar(&a.cidrs, "cidr", nil, "Optional CIDRs, each address of the CIDR is pinged. If neither hosts nor CIDRs are specified, the nodelist is used.")
+	addSamplingFlags(cmd, ra)
+	addSourceFlags(cmd, ra)
+	addDSCPFlag(cmd, ra)
 	return cmd
 }
 
@@ -64,7 +93,7 @@ func NewPingHost(nodes []config.Node, rconfig RunnerConfig) Runner {
 		robinRound[config.Node]{
 			itemsName: "nodes",
 			items:     config.CloneAndShuffle(nodes),
-			runFunc:   pingFunc,
+			runFunc:   pingFuncOf(rconfig),
 			config:    rconfig,
 		},
 	}
@@ -76,33 +105,62 @@ type pingHost struct {
 
 var _ Runner = &pingHost{}
 
-func pingFunc(node config.Node) (string, error) {
-	pinger, err := ping.NewPinger(node.InternalIP)
-	if err != nil {
-		return "", err
-	}
-	pinger.SetPrivileged(true)
-	pinger.Count = 1
-	pinger.Timeout = 1 * time.Second
-
-	result := atomic.String{}
-	pinger.OnRecv = func(pkt *ping.Packet) {
-		result.Store(fmt.Sprintf("%d bytes from %s: icmp_seq=%d time=%v\n",
-			pkt.Nbytes, pkt.IPAddr, pkt.Seq, pkt.Rtt))
-	}
+// pingFuncOf returns the run function pinging from the source address and with the marking of the runner config.
+func pingFuncOf(rconfig RunnerConfig) func(config.Node, resultFields) (string, error) {
+	sourceIP, dscp := rconfig.SourceIP, rconfig.DSCP
+	return func(node config.Node, fields resultFields) (string, error) {
+		setProbeSocketFields(rconfig, fields)
+		lost := &resultparse.Ping{Common: resultparse.Common{Reason: "ping lost"}, LostAfter: pingTimeout, Source: sourceIP, DSCP: dscp}
+		if dscp != 0 {
+			return markedPingHost(node, rconfig, lost, fields)
+		}
+		pinger, err := ping.NewPinger(node.InternalIP)
+		if err != nil {
+			return "", err
+		}
+		pinger.SetPrivileged(true)
+		pinger.Count = 1
+		pinger.Timeout = pingTimeout
+		pinger.Source = sourceIP
 
-	pinger.OnDuplicateRecv = func(pkt *ping.Packet) {
-		result.Store(fmt.Sprintf("%d bytes from %s: icmp_seq=%d time=%v ttl=%v (DUP!)\n",
-			pkt.Nbytes, pkt.IPAddr, pkt.Seq, pkt.Rtt, pkt.Ttl))
+		result := atomic.String{}
+		pinger.OnRecv = func(pkt *ping.Packet) {
+			result.Store((&resultparse.Ping{RTT: pkt.Rtt, TTL: pkt.Ttl, Size: pkt.Nbytes, From: pkt.IPAddr.String(), Seq: pkt.Seq, Source: sourceIP}).Text())
+		}
+
+		pinger.OnDuplicateRecv = func(pkt *ping.Packet) {
+			result.Store((&resultparse.Ping{RTT: pkt.Rtt, TTL: pkt.Ttl, Size: pkt.Nbytes, From: pkt.IPAddr.String(), Seq: pkt.Seq, Duplicate: true, Source: sourceIP}).Text())
+		}
+
+		err = pinger.Run()
+		if err != nil {
+			return "", err
+		}
+		stats := pinger.Statistics()
+		fields.set(ResultFieldPacketLoss, stats.PacketLoss)
+		if stats.PacketsRecv == 1 {
+			fields.set(ResultFieldRTTMillis, stats.AvgRtt.Milliseconds())
+			return result.Load(), nil
+		}
+		return "", errors.New(lost.Text())
 	}
+}
 
-	err = pinger.Run()
+// markedPingHost pings the node with a marked echo request.
+func markedPingHost(node config.Node, rconfig RunnerConfig, lost *resultparse.Ping, fields resultFields) (string, error) {
+	addr, err := net.ResolveIPAddr("ip", node.InternalIP)
+	if err != nil {
+		return "", err
+	}
+	reply, err := markedPing(addr.IP, rconfig.SourceIP, rconfig.DSCP, pingTimeout)
 	if err != nil {
 		return "", err
 	}
-	stats := pinger.Statistics()
-	if stats.PacketsRecv == 1 {
-		return result.Load(), nil
+	if reply == nil {
+		fields.set(ResultFieldPacketLoss, 100)
+		return "", errors.New(lost.Text())
 	}
-	return "", fmt.Errorf("ping lost after %d ms", pinger.Timeout.Milliseconds())
+	fields.set(ResultFieldPacketLoss, 0)
+	fields.set(ResultFieldRTTMillis, reply.RTT.Milliseconds())
+	return reply.Text(), nil
 }
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+)
+
+// Names of the structured result fields reported by the runners in addition to the human-readable result.
+const (
+	// ResultFieldAttempts is the number of attempts if a check has been retried.
+	ResultFieldAttempts = "attempts"
+	// ResultFieldHTTPStatus is the HTTP status code of the response.
+	ResultFieldHTTPStatus = "httpStatus"
+	// ResultFieldCertDaysRemaining is the number of days until the server certificate expires.
+	ResultFieldCertDaysRemaining = "certDaysRemaining"
+	// ResultFieldRedirects is the number of followed redirects.
+	ResultFieldRedirects = "redirects"
+	// ResultFieldGRPCStatus is the gRPC status code of the response.
+	ResultFieldGRPCStatus = "grpcStatus"
+	// ResultFieldServingStatus is the serving status of a gRPC health check.
+	ResultFieldServingStatus = "servingStatus"
+	// ResultFieldPacketLoss is the packet loss in percent.
+	ResultFieldPacketLoss = "packetLoss"
+	// ResultFieldRTTMillis is the round-trip time in milliseconds.
+	ResultFieldRTTMillis = "rttMillis"
+	// ResultFieldPathMTU is the largest delivered IP packet size.
+	ResultFieldPathMTU = "pathMTU"
+	// ResultFieldAddresses is the number of resolved addresses.
+	ResultFieldAddresses = "addresses"
+	// ResultFieldReordered is the number of packets arrived after a packet with a higher sequence number.
+	ResultFieldReordered = "reordered"
+	// ResultFieldJitterMillis is the mean deviation of the packet arrival spacing from the send spacing in milliseconds.
+	ResultFieldJitterMillis = "jitterMillis"
+	// ResultFieldPacketTrain is set to `unsupported` if the peer agent does not receive packet trains.
+	ResultFieldPacketTrain = "packetTrain"
+	// ResultFieldMAC is the resolved MAC address of a neighbor.
+	ResultFieldMAC = "mac"
+	// ResultFieldSourceIP is the local address a probe is sent from if selected by the job.
+	ResultFieldSourceIP = "sourceIP"
+	// ResultFieldDSCP is the DSCP value the probe packets are marked with if selected by the job.
+	ResultFieldDSCP = "dscp"
+	// ResultFieldFailureCategory is the phase and kind of failure of a check, one of the FailureCategory constants.
+	ResultFieldFailureCategory = "failureCategory"
+)
+
+// Failure categories of the TCP checks, so that drops in the network can be told apart from hung services.
+const (
+	// FailureCategoryConnectTimeout is a connection not established within the timeout, e.g. because a firewall drops the SYN packets.
+	FailureCategoryConnectTimeout = "connectTimeout"
+	// FailureCategoryConnectError is a connection failed otherwise, e.g. because it was refused or the network is unreachable.
+	FailureCategoryConnectError = "connectError"
+	// FailureCategoryReadTimeout is an established connection without response within the read timeout of the banner check.
+	FailureCategoryReadTimeout = "readTimeout"
+	// FailureCategoryReadError is an established connection closed or reset by the peer before the response of the banner check.
+	FailureCategoryReadError = "readError"
+	// FailureCategoryUnexpectedResponse is a response not matching the expected pattern of the banner check.
+	FailureCategoryUnexpectedResponse = "unexpectedResponse"
+)
+
+// FailureCategories are all failure categories.
+var FailureCategories = []string{
+	FailureCategoryConnectTimeout,
+	FailureCategoryConnectError,
+	FailureCategoryReadTimeout,
+	FailureCategoryReadError,
+	FailureCategoryUnexpectedResponse,
+}
+
+// IsConnectFailure returns true if the check failed before the connection was established.
+func IsConnectFailure(category string) bool {
+	return category == FailureCategoryConnectTimeout || category == FailureCategoryConnectError
+}
+
+// ResultFieldNames are the names of all result fields in a stable order, e.g. for the columns of a CSV export.
+var ResultFieldNames = []string{
+	ResultFieldAttempts,
+	ResultFieldHTTPStatus,
+	ResultFieldCertDaysRemaining,
+	ResultFieldRedirects,
+	ResultFieldGRPCStatus,
+	ResultFieldServingStatus,
+	ResultFieldPacketLoss,
+	ResultFieldRTTMillis,
+	ResultFieldPathMTU,
+	ResultFieldAddresses,
+	ResultFieldReordered,
+	ResultFieldJitterMillis,
+	ResultFieldPacketTrain,
+	ResultFieldMAC,
+	ResultFieldSourceIP,
+	ResultFieldDSCP,
+	ResultFieldFailureCategory,
+}
+
+// resultFields collects the structured result fields of a check. The fields are also reported for failed checks.
+type resultFields map[string]string
+
+func (f resultFields) set(name string, value any) {
+	f[name] = fmt.Sprint(value)
+}
@@ -5,9 +5,17 @@
 package runners
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/backoff"
 	"github.com/gardener/network-problem-detector/pkg/common/config"
 	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
 
@@ -15,7 +23,8 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-type runFunc[T config.WithDestHost] func(item T) (result string, err error)
+// runFunc checks the item and returns the human-readable result. Structured values can be added to the result fields.
+type runFunc[T config.WithDestHost] func(item T, fields resultFields) (result string, err error)
 
 type robinRound[T config.WithDestHost] struct {
 	itemsName string
@@ -23,6 +32,16 @@ type robinRound[T config.WithDestHost] struct {
 	items     []T
 	next      int
 	config    RunnerConfig
+	// order is the rotation order of the item indices if sampling is active
+	order []int
+
+	backoffOnce sync.Once
+	// backoff tracks the failing destinations, nil if the failure backoff is disabled
+	backoff *backoff.Tracker
+	// nodeZones maps the hostnames of the nodes to their zones
+	nodeZones map[string]string
+	// peerJobIDs if set, the job IDs of the observations contain the destination host, see nwpd.MeshJobID
+	peerJobIDs bool
 }
 
 func (r *robinRound[T]) Config() RunnerConfig {
@@ -30,9 +49,26 @@ func (r *robinRound[T]) Config() RunnerConfig {
 }
 
 func (r *robinRound[T]) Description() string {
+	if r.config.MaxPeers > 0 {
+		return fmt.Sprintf("%d %s, %d peers per tick (%s)", len(r.items), r.itemsName, r.peersPerRun(), r.config.SampleStrategy)
+	}
 	return fmt.Sprintf("%d %s", len(r.items), r.itemsName)
 }
 
+func (r *robinRound[T]) peersPerRun() int {
+	if r.config.MaxPeers <= 0 {
+		return 1
+	}
+	if r.config.MaxPeers > len(r.items) {
+		return len(r.items)
+	}
+	return r.config.MaxPeers
+}
+
+func (r *robinRound[T]) setNodeZones(zones map[string]string) {
+	r.nodeZones = zones
+}
+
 func (r *robinRound[T]) TestData() any {
 	return r.items
 }
@@ -45,26 +81,214 @@ func (r *robinRound[T]) DestHosts() []string {
 	return hosts
 }
 
+// Run probes the next destination(s) of the rotation. Destinations in failure backoff are skipped.
 func (r *robinRound[T]) Run(nodeName string, ch chan<- *nwpd.Observation) {
-	item := r.items[r.next]
-	r.next = (r.next + 1) % len(r.items)
+	r.RunContext(context.Background(), nodeName, ch)
+}
+
+// RunContext is like Run, but stops starting probes and retries if the context is cancelled.
+// The observations of probes finishing after the cancellation are not sent.
+func (r *robinRound[T]) RunContext(ctx context.Context, nodeName string, ch chan<- *nwpd.Observation) {
+	now := time.Now()
+	if r.config.MaxPeers <= 0 {
+		item := r.items[r.next]
+		r.next = (r.next + 1) % len(r.items)
+		if !r.backedOff(item, now) {
+			r.runItem(ctx, nodeName, item, 1, ch)
+		}
+		return
+	}
+
+	if r.order == nil {
+		r.order = r.rotationOrder(nodeName)
+	}
+	count := r.peersPerRun()
+	wg := sync.WaitGroup{}
+	for i := 0; i < count && ctx.Err() == nil; i++ {
+		item := r.items[r.order[r.next]]
+		r.next = (r.next + 1) % len(r.items)
+		if r.backedOff(item, now) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runItem(ctx, nodeName, item, count, ch)
+		}()
+	}
+	wg.Wait()
+}
+
+// maxOnDemandParallelism is the maximum number of destinations probed in parallel by an on-demand run.
+const maxOnDemandParallelism = 8
+
+// RunAll probes all destinations immediately, or only the given destination hosts if not empty.
+// It returns the number of probed destinations. The rotation state of the scheduled runs is not changed.
+func (r *robinRound[T]) RunAll(nodeName string, destHosts []string, ch chan<- *nwpd.Observation) int {
+	filter := common.StringSet{}
+	filter.AddAll(destHosts...)
+	var items []T
+	for _, item := range r.items {
+		if len(filter) == 0 || filter.Contains(normalise(item.DestHost())) {
+			items = append(items, item)
+		}
+	}
+	peersPerRun := r.peersPerRun()
+	slots := make(chan struct{}, maxOnDemandParallelism)
+	wg := sync.WaitGroup{}
+	for _, item := range items {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			r.runItem(context.Background(), nodeName, item, peersPerRun, ch)
+		}()
+	}
+	wg.Wait()
+	return len(items)
+}
+
+// rotationOrder returns the item indices in the order given by the sample strategy.
+// The order is deterministic for the node and the job.
+func (r *robinRound[T]) rotationOrder(nodeName string) []int {
+	switch r.config.SampleStrategy {
+	case SampleStrategyRing:
+		order := make([]int, len(r.items))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return r.items[order[i]].DestHost() < r.items[order[j]].DestHost()
+		})
+		// start with the ring neighbour following the own node
+		start := sort.Search(len(order), func(i int) bool {
+			return r.items[order[i]].DestHost() > nodeName
+		})
+		return append(order[start:], order[:start]...)
+	default:
+		sorted := make([]int, len(r.items))
+		for i := range sorted {
+			sorted[i] = i
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return r.items[sorted[i]].DestHost() < r.items[sorted[j]].DestHost()
+		})
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(nodeName + "/" + r.config.JobID))
+		rnd := rand.New(rand.NewSource(int64(h.Sum64()))) // #nosec G404 -- no cryptographic use
+		order := make([]int, len(sorted))
+		for i, j := range rnd.Perm(len(sorted)) {
+			order[i] = sorted[j]
+		}
+		return order
+	}
+}
 
+func (r *robinRound[T]) runItem(ctx context.Context, nodeName string, item T, peersPerRun int, ch chan<- *nwpd.Observation) {
+	obs := r.probe(ctx, nodeName, item, peersPerRun)
+	if ctx.Err() != nil {
+		return
+	}
+	ch <- obs
+}
+
+// probe checks the item. If the probe limiter is set, it waits for a free slot first.
+func (r *robinRound[T]) probe(ctx context.Context, nodeName string, item T, peersPerRun int) *nwpd.Observation {
+	if limiter := r.config.Shared.ProbeLimiter(); limiter != nil {
+		limiter.Acquire()
+		defer limiter.Release()
+	}
 	obs := &nwpd.Observation{
 		SrcHost:   nodeName,
 		DestHost:  normalise(item.DestHost()),
 		Timestamp: timestamppb.Now(),
 		JobID:     r.config.JobID,
+		Labels:    r.config.Labels,
+	}
+	if r.peerJobIDs {
+		obs.JobID = nwpd.MeshJobID(obs.JobID, obs.DestHost)
+	}
+	if destZone, ok := r.nodeZones[obs.DestHost]; ok {
+		obs.DestZone = destZone
+		obs.SrcZone = r.nodeZones[nodeName]
+		if obs.SrcZone == "" {
+			obs.SrcZone = config.UnknownZone
+		}
 	}
 
-	start := time.Now()
-	result, err := r.runFunc(item)
-	obs.Duration = durationpb.New(time.Since(start))
-	obs.Period = durationpb.New(r.config.Period * time.Duration(len(r.items)))
+	result, fields, duration, attempts, err := r.runWithRetries(ctx, item)
+	if attempts > 1 {
+		fields.set(ResultFieldAttempts, attempts)
+	}
+	if len(fields) > 0 {
+		obs.ResultFields = fields
+	}
+	obs.Duration = durationpb.New(duration)
+	obs.Period = durationpb.New(r.destinationInterval(peersPerRun))
 	obs.Ok = err == nil
-	if err != nil {
+	r.recordBackoff(obs.DestHost, obs.Ok, time.Now())
+	obs.StaleEndpoint = isStaleEndpoint(err)
+	switch {
+	case err != nil && attempts > 1:
+		obs.Result = fmt.Sprintf("error: %s (%d attempts)", err, attempts)
+	case err != nil:
 		obs.Result = fmt.Sprintf("error: %s", err)
-	} else {
+	case attempts > 1:
+		obs.Result = fmt.Sprintf("%s (attempt %d)", result, attempts)
+	default:
 		obs.Result = result
 	}
-	ch <- obs
+	return obs
+}
+
+// destinationInterval returns the interval between two probes of a destination.
+func (r *robinRound[T]) destinationInterval(peersPerRun int) time.Duration {
+	runs := (len(r.items) + peersPerRun - 1) / peersPerRun
+	return r.config.Period * time.Duration(runs)
+}
+
+// staleEndpointError marks a destination endpoint as outdated, e.g. if the IP of a deleted pod is reused by another pod.
+type staleEndpointError struct {
+	msg string
+}
+
+func (e *staleEndpointError) Error() string {
+	return e.msg
+}
+
+func isStaleEndpoint(err error) bool {
+	var staleErr *staleEndpointError
+	return errors.As(err, &staleErr)
+}
+
+// runWithRetries calls the run function and retries it on failure as configured.
+// Additional attempts are only started if they can complete within the job period.
+// The returned result fields and duration are the ones of the last attempt.
+func (r *robinRound[T]) runWithRetries(ctx context.Context, item T) (result string, fields resultFields, duration time.Duration, attempts int, err error) {
+	var retryDelay time.Duration
+	if r.config.RetryDelay != nil {
+		retryDelay = r.config.RetryDelay.Duration
+	}
+	deadline := time.Now().Add(r.config.Period)
+	for {
+		attempts++
+		start := time.Now()
+		fields = resultFields{}
+		result, err = r.runFunc(item, fields)
+		duration = time.Since(start)
+		if err == nil || attempts > r.config.Retries || isStaleEndpoint(err) {
+			return
+		}
+		if time.Now().Add(retryDelay + duration).After(deadline) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+	}
 }
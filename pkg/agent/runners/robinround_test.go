@@ -0,0 +1,279 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("robinRound", func() {
+	var (
+		calls      int
+		failFirstN int
+	)
+
+	newRunner := func(retries int, retryDelay, period time.Duration) *robinRound[dnsName] {
+		return &robinRound[dnsName]{
+			itemsName: "names",
+			items:     []dnsName{{name: "foo."}},
+			runFunc: func(_ dnsName, _ resultFields) (string, error) {
+				calls++
+				if calls <= failFirstN {
+					return "", fmt.Errorf("failed")
+				}
+				return "ok", nil
+			},
+			config: RunnerConfig{
+				Job:    config.Job{JobID: "test", Retries: retries, RetryDelay: &metav1.Duration{Duration: retryDelay}},
+				Period: period,
+			},
+		}
+	}
+
+	run := func(r *robinRound[dnsName]) *nwpd.Observation {
+		ch := make(chan *nwpd.Observation, 1)
+		r.Run("node1", ch)
+		return <-ch
+	}
+
+	BeforeEach(func() {
+		calls = 0
+		failFirstN = 0
+	})
+
+	It("does not retry a successful first attempt", func() {
+		obs := run(newRunner(3, time.Second, time.Minute))
+		Expect(calls).To(Equal(1))
+		Expect(obs.Ok).To(BeTrue())
+		Expect(obs.Result).To(Equal("ok"))
+		Expect(obs.ResultFields).To(BeNil())
+	})
+
+	It("reports the number of attempts needed", func() {
+		failFirstN = 2
+		obs := run(newRunner(3, time.Millisecond, time.Minute))
+		Expect(calls).To(Equal(3))
+		Expect(obs.Ok).To(BeTrue())
+		Expect(obs.Result).To(Equal("ok (attempt 3)"))
+		Expect(obs.ResultFields).To(Equal(map[string]string{ResultFieldAttempts: "3"}))
+	})
+
+	It("reports failure after all attempts", func() {
+		failFirstN = 10
+		obs := run(newRunner(2, time.Millisecond, time.Minute))
+		Expect(calls).To(Equal(3))
+		Expect(obs.Ok).To(BeFalse())
+		Expect(obs.Result).To(Equal("error: failed (3 attempts)"))
+	})
+
+	It("stops retrying if the period would be exceeded", func() {
+		failFirstN = 10
+		obs := run(newRunner(5, 100*time.Millisecond, 150*time.Millisecond))
+		Expect(calls).To(Equal(2))
+		Expect(obs.Ok).To(BeFalse())
+	})
+
+	It("stops retrying and drops the observation if the job is cancelled", func() {
+		failFirstN = 10
+		r := newRunner(5, time.Minute, time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := make(chan *nwpd.Observation, 1)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+		start := time.Now()
+		r.RunContext(ctx, "node1", ch)
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+		Expect(calls).To(Equal(1))
+		Expect(ch).To(BeEmpty())
+	})
+
+	It("fills the zones if the destination is a node", func() {
+		r := &robinRound[config.Node]{
+			itemsName: "nodes",
+			items:     []config.Node{{Hostname: "node2"}, {Hostname: "node3"}, {Hostname: "server"}},
+			runFunc:   func(_ config.Node, _ resultFields) (string, error) { return "ok", nil },
+			config:    RunnerConfig{Job: config.Job{JobID: "test"}, Period: time.Second, MaxPeers: 3},
+		}
+		r.setNodeZones(config.ClusterConfig{Nodes: []config.Node{
+			{Hostname: "node2", Zone: "zone-b"}, {Hostname: "node3"},
+		}}.NodeZones())
+		ch := make(chan *nwpd.Observation, 3)
+		r.Run("node1", ch)
+		close(ch)
+		zones := map[string]string{}
+		for obs := range ch {
+			zones[obs.DestHost] = obs.SrcZone + "->" + obs.DestZone
+		}
+		Expect(zones).To(Equal(map[string]string{"node2": "unknown->zone-b", "node3": "unknown->unknown", "server": "->"}))
+	})
+
+	Describe("sampling", func() {
+		var nodes []config.Node
+
+		newSampleRunner := func(maxPeers int, strategy string) *robinRound[config.Node] {
+			return &robinRound[config.Node]{
+				itemsName: "nodes",
+				items:     nodes,
+				runFunc: func(_ config.Node, _ resultFields) (string, error) {
+					return "ok", nil
+				},
+				config: RunnerConfig{
+					Job:            config.Job{JobID: "test"},
+					Period:         time.Second,
+					MaxPeers:       maxPeers,
+					SampleStrategy: strategy,
+				},
+			}
+		}
+
+		runOnce := func(r *robinRound[config.Node], nodeName string) []string {
+			ch := make(chan *nwpd.Observation, len(nodes))
+			r.Run(nodeName, ch)
+			close(ch)
+			var hosts []string
+			for obs := range ch {
+				Expect(obs.Period.AsDuration()).To(Equal(time.Duration((len(nodes)+r.config.MaxPeers-1)/r.config.MaxPeers) * time.Second))
+				hosts = append(hosts, obs.DestHost)
+			}
+			return hosts
+		}
+
+		BeforeEach(func() {
+			nodes = nil
+			for i := 1; i <= 5; i++ {
+				nodes = append(nodes, config.Node{Hostname: fmt.Sprintf("node%d", i), InternalIP: fmt.Sprintf("10.0.0.%d", i)})
+			}
+		})
+
+		It("probes ring neighbours first", func() {
+			r := newSampleRunner(2, SampleStrategyRing)
+			Expect(r.Description()).To(Equal("5 nodes, 2 peers per tick (ring)"))
+			Expect(runOnce(r, "node3")).To(ConsistOf("node4", "node5"))
+			Expect(runOnce(r, "node3")).To(ConsistOf("node1", "node2"))
+			Expect(runOnce(r, "node3")).To(ConsistOf("node3", "node4"))
+		})
+
+		It("covers all destinations with a stable random order", func() {
+			r1 := newSampleRunner(2, SampleStrategyRandom)
+			r2 := newSampleRunner(2, SampleStrategyRandom)
+			var all []string
+			for i := 0; i < 3; i++ {
+				hosts := runOnce(r1, "node1")
+				Expect(hosts).To(HaveLen(2))
+				all = append(all, hosts...)
+				runOnce(r2, "node1")
+			}
+			Expect(all).To(ContainElements("node1", "node2", "node3", "node4", "node5"))
+			Expect(r1.order).To(Equal(r2.order))
+		})
+	})
+
+	Describe("failure backoff", func() {
+		var failing map[string]bool
+
+		newBackoffRunner := func(external bool, fb *config.FailureBackoffConfig) *robinRound[dnsName] {
+			return &robinRound[dnsName]{
+				itemsName: "names",
+				items:     []dnsName{{name: "down.example.com."}, {name: "up.example.com."}},
+				runFunc: func(item dnsName, _ resultFields) (string, error) {
+					if failing[item.name] {
+						return "", fmt.Errorf("no such host")
+					}
+					return "ok", nil
+				},
+				config: RunnerConfig{
+					Job:                  config.Job{JobID: "test", FailureBackoff: fb},
+					Period:               10 * time.Millisecond,
+					MaxPeers:             2,
+					SampleStrategy:       SampleStrategyRing,
+					ExternalDestinations: external,
+				},
+			}
+		}
+
+		runOnce := func(r *robinRound[dnsName]) []string {
+			ch := make(chan *nwpd.Observation, len(r.items))
+			r.Run("node1", ch)
+			close(ch)
+			var hosts []string
+			for obs := range ch {
+				hosts = append(hosts, obs.DestHost)
+			}
+			return hosts
+		}
+
+		BeforeEach(func() {
+			failing = map[string]bool{"down.example.com.": true}
+		})
+
+		It("skips failing external destinations and resets on success", func() {
+			r := newBackoffRunner(true, nil)
+			Expect(runOnce(r)).To(ConsistOf("down.example.com", "up.example.com"))
+			// the first delay is twice the probe interval minus up to 20% jitter
+			Expect(runOnce(r)).To(ConsistOf("up.example.com"))
+			states := r.BackoffStates()
+			Expect(states).To(HaveLen(1))
+			Expect(states[0].Key).To(Equal("down.example.com"))
+			Expect(states[0].Failures).To(Equal(1))
+
+			Eventually(func() []string { return runOnce(r) }).WithTimeout(time.Second).WithPolling(5 * time.Millisecond).
+				Should(ContainElement("down.example.com"))
+			Expect(r.BackoffStates()[0].Failures).To(Equal(2))
+
+			failing = nil
+			Eventually(func() []string { return runOnce(r) }).WithTimeout(time.Second).WithPolling(5 * time.Millisecond).
+				Should(ContainElement("down.example.com"))
+			Expect(r.BackoffStates()).To(BeEmpty())
+			Expect(runOnce(r)).To(ConsistOf("down.example.com", "up.example.com"))
+		})
+
+		It("exempts internal destinations by default", func() {
+			r := newBackoffRunner(false, nil)
+			Expect(runOnce(r)).To(HaveLen(2))
+			Expect(runOnce(r)).To(HaveLen(2))
+			Expect(r.BackoffStates()).To(BeNil())
+		})
+
+		It("can be enabled for internal and disabled for external destinations", func() {
+			enabled, disabled := true, false
+			r := newBackoffRunner(false, &config.FailureBackoffConfig{Enabled: &enabled})
+			runOnce(r)
+			Expect(runOnce(r)).To(ConsistOf("up.example.com"))
+
+			r = newBackoffRunner(true, &config.FailureBackoffConfig{Enabled: &disabled})
+			runOnce(r)
+			Expect(runOnce(r)).To(HaveLen(2))
+		})
+
+		It("probes backed-off destinations on demand", func() {
+			r := newBackoffRunner(true, nil)
+			runOnce(r)
+			ch := make(chan *nwpd.Observation, 2)
+			Expect(r.RunAll("node1", nil, ch)).To(Equal(2))
+			Expect(r.BackoffStates()[0].Failures).To(Equal(2))
+		})
+
+		It("caps the delay", func() {
+			policy := failureBackoffPolicyOf(RunnerConfig{Period: time.Second, ExternalDestinations: true,
+				Job: config.Job{FailureBackoff: &config.FailureBackoffConfig{MaxDelay: &metav1.Duration{Duration: 5 * time.Second}}}}, 2*time.Second)
+			Expect(policy.Initial).To(Equal(4 * time.Second))
+			Expect(policy.Max).To(Equal(5 * time.Second))
+			Expect(failureBackoffPolicyOf(RunnerConfig{ExternalDestinations: true}, 2*time.Second).Max).To(Equal(16 * time.Second))
+			Expect(validateFailureBackoff(&config.FailureBackoffConfig{MaxDelay: &metav1.Duration{Duration: -time.Second}})).
+				To(MatchError(ContainSubstring("invalid failureBackoff.maxDelay")))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// runDeadlineFactor is the deadline of a scheduled run as multiple of the job period.
+	runDeadlineFactor = 2
+	// maxPanicStackBytes is the maximum length of the stack included in the result of a panicked run.
+	maxPanicStackBytes = 2000
+)
+
+// minRunDeadline is the minimum deadline of a scheduled run.
+var minRunDeadline = time.Minute
+
+// runDeadlineOf returns the deadline of a scheduled run of a job with the given period.
+func runDeadlineOf(period time.Duration) time.Duration {
+	return max(runDeadlineFactor*period, minRunDeadline)
+}
+
+// runRecovered executes the run and reports a panic of the runner as failed observation with the panic value and the
+// truncated stack, so that a panic does not crash the agent. Panics of goroutines started by the runner are not recovered.
+func (j *InternalJob) runRecovered(ctx context.Context, nodeName string, runCh chan<- *nwpd.Observation,
+	run func(ctx context.Context, runCh chan<- *nwpd.Observation),
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			if c := j.shared().panicsCounter(); c != nil {
+				c.WithLabelValues(j.JobID()).Inc()
+			}
+			stack := debug.Stack()
+			if len(stack) > maxPanicStackBytes {
+				stack = append(stack[:maxPanicStackBytes:maxPanicStackBytes], "..."...)
+			}
+			runCh <- j.failedObservation(nodeName, fmt.Sprintf("runner panicked: %v\n%s", r, stack))
+		}
+	}()
+	run(ctx, runCh)
+}
+
+// failedObservation returns a failed observation of the run without destination.
+func (j *InternalJob) failedObservation(nodeName, result string) *nwpd.Observation {
+	return &nwpd.Observation{
+		JobID:     j.JobID(),
+		SrcHost:   nodeName,
+		Timestamp: timestamppb.Now(),
+		Period:    durationpb.New(j.Period()),
+		Result:    result,
+	}
+}
@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+// RunRecorder is notified about the finished runs of the jobs.
+type RunRecorder struct {
+	// OnRun is called with the record of each finished run.
+	OnRun func(record *nwpd.JobRunRecord)
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+)
+
+// DefaultPeriod is the period of a job if neither the job nor the network configuration specifies it.
+const DefaultPeriod = 1 * time.Second
+
+// NeedsRescaling returns true if the scaling policy of the agent configuration has been rendered
+// for a number of nodes on another level of the policy.
+func NeedsRescaling(cfg *config.AgentConfig, nodeCount int) bool {
+	p := cfg.ScalingPolicy
+	return p != nil && p.Level(nodeCount) != p.Level(cfg.ScaledForNodeCount)
+}
+
+// ApplyScalingPolicy renders the scaling policy for the given number of nodes as explicit `--period` and `--max-peers` args of the jobs.
+// The original args are kept in `UnscaledArgs`, so that the policy can be applied again for another number of nodes.
+func ApplyScalingPolicy(cfg *config.AgentConfig, nodeCount int) error {
+	policy := cfg.ScalingPolicy
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, networkCfg := range []*config.NetworkConfig{cfg.HostNetwork, cfg.PodNetwork} {
+		if networkCfg == nil {
+			continue
+		}
+		defaultPeriod := DefaultPeriod
+		if networkCfg.DefaultPeriod.Duration != 0 {
+			defaultPeriod = networkCfg.DefaultPeriod.Duration
+		}
+		for i := range networkCfg.Jobs {
+			job := &networkCfg.Jobs[i]
+			args := job.Args
+			if job.UnscaledArgs != nil {
+				args = job.UnscaledArgs
+			}
+			scaling := config.NoScaling
+			if policy != nil && len(args) > 0 {
+				scaling = policy.Evaluate(nodeCount, args[0])
+			}
+			scaled, err := scaleArgs(args, scaling, defaultPeriod, nodeCount)
+			if err != nil {
+				return fmt.Errorf("scaling job %s failed: %s", job.JobID, err)
+			}
+			if slices.Equal(scaled, args) {
+				job.Args = args
+				job.UnscaledArgs = nil
+			} else {
+				job.Args = scaled
+				job.UnscaledArgs = args
+			}
+		}
+	}
+	cfg.ScaledForNodeCount = nodeCount
+	if policy == nil {
+		cfg.ScaledForNodeCount = 0
+	}
+	return nil
+}
+
+func scaleArgs(args []string, scaling config.Scaling, defaultPeriod time.Duration, nodeCount int) ([]string, error) {
+	result := append([]string{}, args...)
+	if scaling.PeriodMultiplier != 1 {
+		period := defaultPeriod
+		if value, ok := getFlagValue(args, "period"); ok {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid period %q", value)
+			}
+			period = d
+		}
+		scaled := time.Duration(float64(period) * scaling.PeriodMultiplier).Round(time.Millisecond)
+		result = setFlagValue(result, "period", scaled.String())
+	}
+	if scaling.SampleRatio < 1 && supportsFlag(args, "max-peers") {
+		peers := int(math.Max(1, math.Ceil(scaling.SampleRatio*float64(nodeCount))))
+		if value, ok := getFlagValue(args, "max-peers"); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max-peers %q", value)
+			}
+			if n > 0 && n < peers {
+				peers = n
+			}
+		}
+		result = setFlagValue(result, "max-peers", strconv.Itoa(peers))
+	}
+	return result, nil
+}
+
+// supportsFlag checks if the job type of the args has a flag with the given name.
+func supportsFlag(args []string, name string) bool {
+	cmd, _, err := GetNewRoot(&runnerArgs{}).Find(args)
+	if err != nil || cmd.RunE == nil {
+		return false
+	}
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func getFlagValue(args []string, name string) (string, bool) {
+	flag := "--" + name
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func setFlagValue(args []string, name, value string) []string {
+	flag := "--" + name
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			args[i+1] = value
+			return args
+		}
+		if strings.HasPrefix(arg, flag+"=") {
+			args[i] = flag + "=" + value
+			return args
+		}
+	}
+	return append(args, flag, value)
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("scaling policy", func() {
+	var cfg *config.AgentConfig
+
+	BeforeEach(func() {
+		cfg = &config.AgentConfig{
+			ScalingPolicy: &config.ScalingPolicy{
+				Rules: []config.ScalingRule{
+					{AboveNodes: 200, JobTypes: []string{"pingHost", "nslookup"}, PeriodMultiplier: 2, SampleRatio: 0.5},
+				},
+			},
+			PodNetwork: &config.NetworkConfig{
+				DefaultPeriod: metav1.Duration{Duration: 10 * time.Second},
+				Jobs: []config.Job{
+					{JobID: "ping", Args: []string{"pingHost"}},
+					{JobID: "ping-limited", Args: []string{"pingHost", "--period=30s", "--max-peers", "50"}},
+					{JobID: "nslookup", Args: []string{"nslookup", "--names", "foo.bar"}},
+					{JobID: "tcp", Args: []string{"checkTCPPort", "--node-port", "10250"}},
+				},
+			},
+		}
+	})
+
+	argsOf := func() [][]string {
+		var result [][]string
+		for _, job := range cfg.PodNetwork.Jobs {
+			result = append(result, job.Args)
+		}
+		return result
+	}
+
+	It("renders scaling as explicit args", func() {
+		Expect(ApplyScalingPolicy(cfg, 300)).To(Succeed())
+		Expect(cfg.ScaledForNodeCount).To(Equal(300))
+		Expect(argsOf()).To(Equal([][]string{
+			{"pingHost", "--period", "20s", "--max-peers", "150"},
+			{"pingHost", "--period=1m0s", "--max-peers", "50"},
+			// nslookup has no destination sampling
+			{"nslookup", "--names", "foo.bar", "--period", "20s"},
+			{"checkTCPPort", "--node-port", "10250"},
+		}))
+		Expect(cfg.PodNetwork.Jobs[0].UnscaledArgs).To(Equal([]string{"pingHost"}))
+		Expect(cfg.PodNetwork.Jobs[3].UnscaledArgs).To(BeNil())
+
+		for _, job := range cfg.PodNetwork.Jobs {
+			_, err := Parse(config.ClusterConfig{}, RunnerConfig{Job: job}, job.Args, &config.SampleConfig{})
+			Expect(err).To(BeNil())
+		}
+	})
+
+	It("restores the original args below the threshold", func() {
+		original := argsOf()
+		Expect(ApplyScalingPolicy(cfg, 300)).To(Succeed())
+		Expect(ApplyScalingPolicy(cfg, 400)).To(Succeed())
+		Expect(cfg.PodNetwork.Jobs[0].Args).To(Equal([]string{"pingHost", "--period", "20s", "--max-peers", "200"}))
+		Expect(ApplyScalingPolicy(cfg, 100)).To(Succeed())
+		Expect(argsOf()).To(Equal(original))
+		for _, job := range cfg.PodNetwork.Jobs {
+			Expect(job.UnscaledArgs).To(BeNil())
+		}
+	})
+
+	It("needs rescaling only if a threshold is crossed", func() {
+		Expect(NeedsRescaling(cfg, 100)).To(BeFalse())
+		Expect(NeedsRescaling(cfg, 201)).To(BeTrue())
+		Expect(ApplyScalingPolicy(cfg, 201)).To(Succeed())
+		Expect(NeedsRescaling(cfg, 201)).To(BeFalse())
+		Expect(NeedsRescaling(cfg, 600)).To(BeFalse())
+		Expect(NeedsRescaling(cfg, 200)).To(BeTrue())
+		Expect(NeedsRescaling(&config.AgentConfig{}, 600)).To(BeFalse())
+	})
+
+	It("rejects invalid periods", func() {
+		cfg.PodNetwork.Jobs[0].Args = []string{"pingHost", "--period", "fast"}
+		Expect(ApplyScalingPolicy(cfg, 300)).To(MatchError(ContainSubstring("scaling job ping failed")))
+	})
+})
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Shared holds the probe limiter, the handling of the observations and the metrics shared by the runs of all jobs of an agent.
+// It is created by the agent server and passed to the jobs with the runner config. The probe limiter, the backpressure,
+// the probe tracer and the run recorder can be replaced while the jobs are running.
+// A nil Shared is valid: the probes are not limited, the runs block on a full observation channel, and nothing is traced,
+// recorded or counted. The setters have no effect on a nil Shared.
+type Shared struct {
+	probeLimiter atomic.Pointer[Limiter]
+	backpressure atomic.Pointer[Backpressure]
+	probeTracer  atomic.Pointer[ProbeTracer]
+	runRecorder  atomic.Pointer[RunRecorder]
+
+	// backedOffDestinations is the gauge vector with label `jobid` for the number of destinations in failure backoff or nil.
+	backedOffDestinations *prometheus.GaugeVec
+	// runnerPanics is the counter vector with label `jobid` for the runs aborted by a panic or nil.
+	runnerPanics *prometheus.CounterVec
+}
+
+// NewShared creates the shared state of the jobs. The optional gauge vector with label `jobid` is updated with the number
+// of destinations in failure backoff of each job, the optional counter vector with label `jobid` is incremented for each
+// run aborted by a panic.
+func NewShared(backedOffDestinations *prometheus.GaugeVec, runnerPanics *prometheus.CounterVec) *Shared {
+	return &Shared{
+		backedOffDestinations: backedOffDestinations,
+		runnerPanics:          runnerPanics,
+	}
+}
+
+// SetProbeLimiter sets the limiter for the number of simultaneous probes of all jobs.
+// Probes in progress release their slot on the previous limiter.
+func (s *Shared) SetProbeLimiter(l *Limiter) {
+	if s != nil {
+		s.probeLimiter.Store(l)
+	}
+}
+
+// ProbeLimiter returns the current limiter for the number of simultaneous probes or nil if not set.
+func (s *Shared) ProbeLimiter() *Limiter {
+	if s == nil {
+		return nil
+	}
+	return s.probeLimiter.Load()
+}
+
+// SetBackpressure sets the handling of a full observation channel for the runs of all jobs.
+// If not set, the runs block until the observation has been accepted.
+func (s *Shared) SetBackpressure(b *Backpressure) {
+	if s != nil {
+		s.backpressure.Store(b)
+	}
+}
+
+// Backpressure returns the current handling of a full observation channel or nil if not set.
+func (s *Shared) Backpressure() *Backpressure {
+	if s == nil {
+		return nil
+	}
+	return s.backpressure.Load()
+}
+
+// SetProbeTracer sets the tracer of the probes of all jobs. Tracing is disabled if nil.
+func (s *Shared) SetProbeTracer(t *ProbeTracer) {
+	if s != nil {
+		s.probeTracer.Store(t)
+	}
+}
+
+func (s *Shared) tracer() *ProbeTracer {
+	if s == nil {
+		return nil
+	}
+	return s.probeTracer.Load()
+}
+
+// SetRunRecorder sets the recorder of the finished runs of all jobs. Runs are not recorded if nil.
+func (s *Shared) SetRunRecorder(r *RunRecorder) {
+	if s != nil {
+		s.runRecorder.Store(r)
+	}
+}
+
+func (s *Shared) recorder() *RunRecorder {
+	if s == nil {
+		return nil
+	}
+	return s.runRecorder.Load()
+}
+
+func (s *Shared) backedOffGauge() *prometheus.GaugeVec {
+	if s == nil {
+		return nil
+	}
+	return s.backedOffDestinations
+}
+
+func (s *Shared) panicsCounter() *prometheus.CounterVec {
+	if s == nil {
+		return nil
+	}
+	return s.runnerPanics
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/spf13/cobra"
+)
+
+func addSourceFlags(cmd *cobra.Command, ra *runnerArgs) {
+	cmd.Flags().StringVar(&ra.sourceIP, "source-ip", "", "local address the probes are sent from.")
+	cmd.Flags().StringVar(&ra.iface, "interface", "", "network interface the probes are sent from, uses its first IPv4 (or else global) address. Ignored if '--source-ip' is set.")
+}
+
+// sourceAddressOf returns the local address selected by the source IP or the interface name, or an empty string if none is
+// selected. It fails if the source IP is not an address of the node or the interface.
+func sourceAddressOf(sourceIP, ifaceName string) (string, error) {
+	if sourceIP == "" && ifaceName == "" {
+		return "", nil
+	}
+	var (
+		addrs []net.Addr
+		err   error
+	)
+	if ifaceName != "" {
+		iface, ierr := net.InterfaceByName(ifaceName)
+		if ierr != nil {
+			return "", fmt.Errorf("invalid interface %s: %s", ifaceName, ierr)
+		}
+		addrs, err = iface.Addrs()
+	} else {
+		addrs, err = net.InterfaceAddrs()
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot list local addresses: %s", err)
+	}
+	local := localAddressesOf(addrs)
+
+	if sourceIP != "" {
+		ip, err := netip.ParseAddr(sourceIP)
+		if err != nil {
+			return "", fmt.Errorf("invalid source IP %s", sourceIP)
+		}
+		ip = ip.Unmap()
+		for _, addr := range local {
+			if addr == ip {
+				return ip.String(), nil
+			}
+		}
+		if ifaceName != "" {
+			return "", fmt.Errorf("source IP %s is not an address of interface %s", sourceIP, ifaceName)
+		}
+		return "", fmt.Errorf("source IP %s is not a local address", sourceIP)
+	}
+
+	var fallback netip.Addr
+	for _, addr := range local {
+		if addr.Is4() {
+			return addr.String(), nil
+		}
+		if !fallback.IsValid() && !addr.IsLinkLocalUnicast() {
+			fallback = addr
+		}
+	}
+	if !fallback.IsValid() {
+		return "", fmt.Errorf("invalid interface %s: no usable address", ifaceName)
+	}
+	return fallback.String(), nil
+}
+
+// localAddressesOf returns the IP addresses of the interface addresses.
+func localAddressesOf(addrs []net.Addr) []netip.Addr {
+	var result []netip.Addr
+	for _, a := range addrs {
+		var ip net.IP
+		switch v := a.(type) {
+		case *net.IPNet:
+			ip = v.IP
+		case *net.IPAddr:
+			ip = v.IP
+		}
+		if addr, ok := netip.AddrFromSlice(ip); ok {
+			result = append(result, addr.Unmap())
+		}
+	}
+	return result
+}
@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package runners
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+// ProbeTracer creates the trace spans of the probes of the job runs.
+type ProbeTracer struct {
+	// OnObservation is called for each observation of a run before it is forwarded to the observation channel.
+	OnObservation func(obs *nwpd.Observation)
+}
@@ -5,7 +5,11 @@
 package runners
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
 )
 
 func normalise(dnsname string) string {
@@ -21,3 +25,38 @@ func fullQualified(dnsname string) string {
 	}
 	return dnsname
 }
+
+// splitArg splits a flag value at the separator into at least minParts and at most maxParts parts.
+func splitArg(value, sep string, minParts, maxParts int) ([]string, bool) {
+	parts := strings.SplitN(value, sep, maxParts)
+	if len(parts) < minParts {
+		return nil, false
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, false
+		}
+	}
+	return parts, true
+}
+
+// parsePort parses a port number and checks its range.
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid endpoint port %s", value)
+	}
+	return port, nil
+}
+
+// externalAddressNodes returns the nodes with an external address, which replaces the internal IP as probed address.
+func externalAddressNodes(nodes []config.Node) []config.Node {
+	var result []config.Node
+	for _, n := range nodes {
+		if addr := n.ExternalAddress(); addr != "" {
+			n.InternalIP = addr
+			result = append(result, n)
+		}
+	}
+	return result
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// defaultSecretRefreshPeriod is the default period for re-resolving the referenced secrets.
+	defaultSecretRefreshPeriod = 5 * time.Minute
+	// minSecretRefreshPeriod is the minimum period for re-resolving the referenced secrets.
+	minSecretRefreshPeriod = 10 * time.Second
+	// secretResolveTimeout is the timeout for reading a secret from the kube-apiserver.
+	secretResolveTimeout = 10 * time.Second
+	// redactedSecret replaces the resolved secret values in logs and in the job status.
+	redactedSecret = "***"
+)
+
+var errNoSecretAccess = fmt.Errorf("secret references require the %s environment", config.EnvironmentKubernetes)
+
+// secretResolutionError is returned if a secret referenced by a job cannot be resolved.
+type secretResolutionError struct {
+	ref config.SecretRef
+	err error
+}
+
+func (e *secretResolutionError) Error() string {
+	return fmt.Sprintf("cannot resolve %s: %s", e.ref, e.err)
+}
+
+func (e *secretResolutionError) Unwrap() error {
+	return e.err
+}
+
+type cachedSecret struct {
+	value string
+	err   error
+}
+
+// secretResolver resolves the secret references of job args and the remote write configuration.
+// Resolved values are cached and re-resolved after the refresh period or on configuration reload.
+// A nil resolver or a resolver without client factory fails on all references, as they require the kubernetes environment.
+type secretResolver struct {
+	lock      sync.Mutex
+	newClient func() (kubernetes.Interface, error)
+	client    kubernetes.Interface
+	period    time.Duration
+	cache     map[config.SecretRef]*cachedSecret
+	// redacted are all resolved values, including outdated ones.
+	redacted    []string
+	lastRefresh time.Time
+}
+
+func newSecretResolver(newClient func() (kubernetes.Interface, error)) *secretResolver {
+	return &secretResolver{
+		newClient:   newClient,
+		period:      defaultSecretRefreshPeriod,
+		cache:       map[config.SecretRef]*cachedSecret{},
+		lastRefresh: time.Now(),
+	}
+}
+
+// newInClusterClient creates the client for reading the secrets with the service account of the agent pod.
+func newInClusterClient() (kubernetes.Interface, error) {
+	base := common.ClientsetBase{InCluster: true}
+	if err := base.SetupClientSet(); err != nil {
+		return nil, err
+	}
+	return base.Clientset, nil
+}
+
+// secretRefreshPeriodOf returns the period for re-resolving the referenced secrets.
+func secretRefreshPeriodOf(cfg *config.AgentConfig) (time.Duration, error) {
+	if cfg.SecretRefreshPeriod == nil {
+		return defaultSecretRefreshPeriod, nil
+	}
+	if period := cfg.SecretRefreshPeriod.Duration; period < minSecretRefreshPeriod {
+		return 0, fmt.Errorf("invalid secretRefreshPeriod, must be >= %s", minSecretRefreshPeriod)
+	}
+	return cfg.SecretRefreshPeriod.Duration, nil
+}
+
+func (r *secretResolver) setRefreshPeriod(period time.Duration) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.period = period
+}
+
+// resolveArgs returns the args with all secret references replaced by the secret values.
+func (r *secretResolver) resolveArgs(args []string) ([]string, error) {
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		value, err := config.ReplaceSecretRefs(arg, r.resolve)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = value
+	}
+	return resolved, nil
+}
+
+// resolve returns the value of the referenced secret key, either cached or read from the kube-apiserver.
+func (r *secretResolver) resolve(ref config.SecretRef) (string, error) {
+	if r == nil {
+		return "", &secretResolutionError{ref: ref, err: errNoSecretAccess}
+	}
+	r.lock.Lock()
+	entry := r.cache[ref]
+	r.lock.Unlock()
+	if entry != nil {
+		return entry.value, entry.err
+	}
+	entry = r.read(ref)
+	return entry.value, entry.err
+}
+
+// read reads the secret key and updates the cache.
+func (r *secretResolver) read(ref config.SecretRef) *cachedSecret {
+	entry := &cachedSecret{}
+	entry.value, entry.err = r.get(ref)
+	if entry.err != nil {
+		entry.err = &secretResolutionError{ref: ref, err: entry.err}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cache[ref] = entry
+	if entry.value != "" && !slices.Contains(r.redacted, entry.value) {
+		r.redacted = append(r.redacted, entry.value)
+		// replace longer values first, so that no parts of them are left if a value contains another one
+		sort.Slice(r.redacted, func(i, j int) bool {
+			return len(r.redacted[i]) > len(r.redacted[j])
+		})
+	}
+	return entry
+}
+
+func (r *secretResolver) get(ref config.SecretRef) (string, error) {
+	r.lock.Lock()
+	if r.newClient == nil {
+		r.lock.Unlock()
+		return "", errNoSecretAccess
+	}
+	if r.client == nil {
+		client, err := r.newClient()
+		if err != nil {
+			r.lock.Unlock()
+			return "", err
+		}
+		r.client = client
+	}
+	client := r.client
+	r.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+	secret, err := client.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("key %s of secret %s/%s is empty", ref.Key, ref.Namespace, ref.Name)
+	}
+	return value, nil
+}
+
+// refreshIfDue re-resolves all cached references if the refresh period has elapsed since the last refresh.
+// It returns true if any value or resolution error has changed.
+func (r *secretResolver) refreshIfDue(now time.Time) bool {
+	if r == nil {
+		return false
+	}
+	r.lock.Lock()
+	due := now.Sub(r.lastRefresh) >= r.period
+	r.lock.Unlock()
+	if !due {
+		return false
+	}
+	return r.refresh(now)
+}
+
+// refresh re-resolves all cached references.
+// It returns true if any value or resolution error has changed.
+func (r *secretResolver) refresh(now time.Time) bool {
+	if r == nil {
+		return false
+	}
+	r.lock.Lock()
+	old := r.cache
+	r.cache = map[config.SecretRef]*cachedSecret{}
+	r.lastRefresh = now
+	r.lock.Unlock()
+
+	changed := false
+	for ref, entry := range old {
+		current := r.read(ref)
+		if current.value != entry.value || (current.err == nil) != (entry.err == nil) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// redact replaces all resolved secret values contained in the text.
+func (r *secretResolver) redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, value := range r.redacted {
+		text = strings.ReplaceAll(text, value, redactedSecret)
+	}
+	return text
+}
+
+// redactionHook redacts the resolved secret values in the message and the fields of all log entries.
+type redactionHook struct {
+	secrets *secretResolver
+}
+
+var _ logrus.Hook = redactionHook{}
+
+func (h redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h redactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.secrets.redact(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = h.secrets.redact(v)
+		case error:
+			if text := h.secrets.redact(v.Error()); text != v.Error() {
+				entry.Data[key] = text
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("secrets", func() {
+	const token = "t0ken-value"
+
+	var (
+		client  *fake.Clientset
+		secrets *secretResolver
+	)
+
+	newSecret := func(namespace, name string, data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Data: map[string][]byte{}}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		return secret
+	}
+
+	secretReads := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "get" && action.GetResource().Resource == "secrets" {
+				count++
+			}
+		}
+		return count
+	}
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset(
+			newSecret("monitoring", "webhook", map[string]string{"token": token + "\n", "header": "no-colon-" + token, "empty": " "}),
+		)
+		secrets = newSecretResolver(func() (kubernetes.Interface, error) { return client, nil })
+	})
+
+	It("resolves the references of job args and caches the values", func() {
+		args, err := secrets.resolveArgs([]string{"checkHTTPSGet", "--header", "Authorization: Bearer secretRef:monitoring/webhook#token"})
+		Expect(err).To(BeNil())
+		Expect(args).To(Equal([]string{"checkHTTPSGet", "--header", "Authorization: Bearer " + token}))
+
+		_, err = secrets.resolveArgs([]string{"--header=X-Token: secretRef:monitoring/webhook#token"})
+		Expect(err).To(BeNil())
+		Expect(secretReads()).To(Equal(1))
+	})
+
+	DescribeTable("fails on unresolvable references",
+		func(arg, expected string) {
+			_, err := secrets.resolveArgs([]string{"checkHTTPSGet", arg})
+			var secretErr *secretResolutionError
+			Expect(errors.As(err, &secretErr)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expected))
+		},
+		Entry("missing secret", "secretRef:monitoring/other#token", `secrets "other" not found`),
+		Entry("missing key", "secretRef:monitoring/webhook#password", "key password not found"),
+		Entry("empty value", "secretRef:monitoring/webhook#empty", "is empty"),
+	)
+
+	It("fails without in-cluster access", func() {
+		var noSecrets *secretResolver
+		_, err := noSecrets.resolveArgs([]string{"secretRef:monitoring/webhook#token"})
+		Expect(err).To(MatchError(ContainSubstring("require the kubernetes environment")))
+
+		secrets.newClient = nil
+		_, err = secrets.resolveArgs([]string{"secretRef:monitoring/webhook#token"})
+		Expect(err).To(MatchError(ContainSubstring("require the kubernetes environment")))
+		Expect(secretReads()).To(Equal(0))
+	})
+
+	It("re-resolves after the refresh period and reports changes", func() {
+		secrets.setRefreshPeriod(time.Minute)
+		_, err := secrets.resolve(config.SecretRef{Namespace: "monitoring", Name: "webhook", Key: "token"})
+		Expect(err).To(BeNil())
+
+		now := time.Now()
+		Expect(secrets.refreshIfDue(now)).To(BeFalse())
+		Expect(secretReads()).To(Equal(1))
+		Expect(secrets.refreshIfDue(now.Add(time.Minute))).To(BeFalse())
+		Expect(secretReads()).To(Equal(2))
+
+		_, err = client.CoreV1().Secrets("monitoring").Update(context.Background(),
+			newSecret("monitoring", "webhook", map[string]string{"token": "rotated-value"}), metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(secrets.refreshIfDue(now.Add(90 * time.Second))).To(BeFalse())
+		Expect(secrets.refresh(now.Add(90 * time.Second))).To(BeTrue())
+		value, err := secrets.resolve(config.SecretRef{Namespace: "monitoring", Name: "webhook", Key: "token"})
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal("rotated-value"))
+
+		// the outdated value is still redacted
+		Expect(secrets.redact("old " + token + ", new rotated-value")).To(Equal("old ***, new ***"))
+	})
+
+	It("validates the refresh period", func() {
+		Expect(secretRefreshPeriodOf(&config.AgentConfig{})).To(Equal(defaultSecretRefreshPeriod))
+		Expect(secretRefreshPeriodOf(&config.AgentConfig{SecretRefreshPeriod: &metav1.Duration{Duration: time.Minute}})).To(Equal(time.Minute))
+		_, err := secretRefreshPeriodOf(&config.AgentConfig{SecretRefreshPeriod: &metav1.Duration{Duration: time.Second}})
+		Expect(err).To(MatchError(ContainSubstring("secretRefreshPeriod")))
+	})
+
+	Describe("redaction", func() {
+		BeforeEach(func() {
+			_, err := secrets.resolveArgs([]string{"secretRef:monitoring/webhook#token", "secretRef:monitoring/webhook#header"})
+			Expect(err).To(BeNil())
+		})
+
+		It("replaces longer values first", func() {
+			Expect(secrets.redact("a no-colon-" + token + " b " + token)).To(Equal("a *** b ***"))
+			Expect(secrets.redact("nothing secret")).To(Equal("nothing secret"))
+			var noSecrets *secretResolver
+			Expect(noSecrets.redact(token)).To(Equal(token))
+		})
+
+		It("redacts the message and the fields of log entries", func() {
+			var buf bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&buf)
+			logger.SetFormatter(&logrus.JSONFormatter{})
+			logger.AddHook(redactionHook{secrets: secrets})
+
+			logger.WithField("header", "Bearer "+token).
+				WithField("error", fmt.Errorf("request with %s failed", token)).
+				WithField("count", 3).
+				Warnf("cannot use token %s", token)
+			Expect(buf.String()).NotTo(ContainSubstring(token))
+			Expect(buf.String()).To(ContainSubstring(`"msg":"cannot use token ***"`))
+			Expect(buf.String()).To(ContainSubstring(`"header":"Bearer ***"`))
+			Expect(buf.String()).To(ContainSubstring(`"error":"request with *** failed"`))
+			Expect(buf.String()).To(ContainSubstring(`"count":3`))
+		})
+
+		It("redacts the results of observations", func() {
+			s := &server{log: logrus.NewEntry(logrus.StandardLogger()), secrets: secrets}
+			obs := &nwpd.Observation{JobID: "redact-test", SrcHost: "node1", DestHost: "node2", Result: "401 for token " + token}
+			s.processObservation(obs)
+			Expect(obs.Result).To(Equal("401 for token ***"))
+		})
+	})
+
+	Describe("jobs", func() {
+		newTestServer := func() *server {
+			return &server{
+				log:                logrus.NewEntry(logrus.StandardLogger()),
+				nodeName:           "node-a",
+				jobs:               map[jobid]*runners.InternalJob{},
+				currentAgentConfig: &config.AgentConfig{},
+				secrets:            secrets,
+			}
+		}
+		agentConfigOf := func(jobs ...config.Job) *config.AgentConfig {
+			return &config.AgentConfig{PodNetwork: &config.NetworkConfig{Jobs: jobs}}
+		}
+		httpsJob := func(ref string) config.Job {
+			return config.Job{JobID: "https", Args: []string{"checkHTTPSGet", "--endpoints", "webhook.example.com", "--header", "Authorization: Bearer " + ref}}
+		}
+		nslookupJob := config.Job{JobID: "nslookup", Args: []string{"nslookup", "--names", "foo.bar"}}
+
+		statusJSON := func(s *server) (*nwpd.GetJobStatusResponse, string) {
+			resp, err := s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+			Expect(err).To(BeNil())
+			data, err := protojson.Marshal(resp)
+			Expect(err).To(BeNil())
+			rec := httptest.NewRecorder()
+			s.handleJobs(rec, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+			return resp, string(data) + rec.Body.String()
+		}
+
+		It("runs jobs with resolved secrets, but keeps the references in the job status", func() {
+			s := newTestServer()
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token"), nslookupJob))).To(Succeed())
+			Expect(s.jobs).To(HaveLen(2))
+			Expect(s.jobs["https"].Config().Args).To(ContainElement("Authorization: Bearer secretRef:monitoring/webhook#token"))
+
+			resp, data := statusJSON(s)
+			Expect(data).NotTo(ContainSubstring(token))
+			Expect(data).To(ContainSubstring("secretRef:monitoring/webhook#token"))
+			Expect(resp.Jobs[0].JobID).To(Equal("https"))
+			Expect(resp.Jobs[0].Degraded).To(BeFalse())
+		})
+
+		It("degrades jobs with unresolvable secrets without failing the configuration", func() {
+			s := newTestServer()
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/missing#token"), nslookupJob))).To(Succeed())
+			Expect(s.jobs).To(HaveLen(1))
+			Expect(s.jobs).To(HaveKey("nslookup"))
+
+			resp, _ := statusJSON(s)
+			Expect(resp.Jobs).To(HaveLen(2))
+			Expect(resp.Jobs[0].JobID).To(Equal("https"))
+			Expect(resp.Jobs[0].Skipped).To(BeTrue())
+			Expect(resp.Jobs[0].Degraded).To(BeTrue())
+			Expect(resp.Jobs[0].SkipReason).To(ContainSubstring("cannot resolve secretRef:monitoring/missing#token"))
+		})
+
+		It("keeps a running job with its previous secrets if a secret cannot be resolved anymore", func() {
+			s := newTestServer()
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token")))).To(Succeed())
+			Expect(client.CoreV1().Secrets("monitoring").Delete(context.Background(), "webhook", metav1.DeleteOptions{})).To(Succeed())
+			Expect(secrets.refresh(time.Now())).To(BeTrue())
+
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token")))).To(Succeed())
+			Expect(s.jobs).To(HaveKey("https"))
+			resp, _ := statusJSON(s)
+			Expect(resp.Jobs[0].Skipped).To(BeFalse())
+			Expect(resp.Jobs[0].Degraded).To(BeTrue())
+		})
+
+		It("redacts resolved values in the skip reason of invalid jobs", func() {
+			s := newTestServer()
+			invalid := config.Job{JobID: "invalid", Args: []string{"checkHTTPSGet", "--endpoints", "webhook.example.com", "--header", "secretRef:monitoring/webhook#header"}}
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token"), invalid))).To(Succeed())
+
+			resp, data := statusJSON(s)
+			Expect(data).NotTo(ContainSubstring(token))
+			Expect(resp.Jobs[1].JobID).To(Equal("invalid"))
+			Expect(resp.Jobs[1].Degraded).To(BeFalse())
+			Expect(resp.Jobs[1].SkipReason).To(ContainSubstring(`invalid header "***"`))
+		})
+
+		It("redacts resolved values in the rejection of invalid jobs", func() {
+			s := newTestServer()
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token")))).To(Succeed())
+			err := s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token"),
+				config.Job{JobID: "invalid", Args: []string{"checkHTTPSGet", "--endpoints", "webhook.example.com", "--header", "secretRef:monitoring/webhook#header"}},
+			))
+			Expect(err).To(MatchError(ContainSubstring("invalid job invalid")))
+			Expect(s.jobs).To(HaveLen(1))
+			Expect(secrets.redact(err.Error())).To(ContainSubstring(`invalid header "***"`))
+		})
+
+		It("skips jobs with secret references in the standalone environment", func() {
+			s := newTestServer()
+			s.environment = config.EnvironmentStandalone
+			s.secrets = nil
+			Expect(s.applyAgentConfig(agentConfigOf(httpsJob("secretRef:monitoring/webhook#token")))).To(Succeed())
+			Expect(s.jobs).To(BeEmpty())
+			resp, _ := statusJSON(s)
+			Expect(resp.Jobs[0].Skipped).To(BeTrue())
+			Expect(resp.Jobs[0].SkipReason).To(ContainSubstring("require the kubernetes environment"))
+			Expect(secretReads()).To(Equal(0))
+		})
+	})
+
+	It("authenticates the remote write with referenced secrets", func() {
+		var authorization string
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer endpoint.Close()
+
+		cfg := &config.AgentConfig{RemoteWrite: &config.RemoteWriteConfig{URL: endpoint.URL, BearerToken: "secretRef:monitoring/webhook#token"}}
+		settings, err := remoteWriteSettingsOf(cfg, time.Minute)
+		Expect(err).To(BeNil())
+		Expect(secretReads()).To(Equal(0))
+		settings.secrets = secrets
+		IncAggregatedObservation("node1", "node2", "rw-secret-test", nil, "ok")
+		Expect(pushRemoteWrite(settings, remoteWriteGatherer, time.Now())).To(Succeed())
+		Expect(authorization).To(Equal("Bearer " + token))
+
+		cfg.RemoteWrite.BearerToken = "secretRef:monitoring/missing#token"
+		settings, err = remoteWriteSettingsOf(cfg, time.Minute)
+		Expect(err).To(BeNil())
+		settings.secrets = secrets
+		Expect(pushRemoteWrite(settings, remoteWriteGatherer, time.Now())).To(MatchError(ContainSubstring("cannot resolve")))
+
+		cfg.RemoteWrite.BearerToken = token
+		_, err = remoteWriteSettingsOf(cfg, time.Minute)
+		Expect(err).To(MatchError(ContainSubstring("invalid RemoteWrite bearerToken")))
+	})
+})
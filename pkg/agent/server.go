@@ -6,16 +6,22 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -24,52 +30,141 @@ import (
 	"github.com/gardener/network-problem-detector/pkg/agent/runners"
 	"github.com/gardener/network-problem-detector/pkg/common"
 	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/filter"
 	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"github.com/twitchtv/twirp"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 type jobid = string
 
+const (
+	// defaultObservationsLimit is the maximum number of observations returned by GetObservations if the request has no limit.
+	defaultObservationsLimit = 10000
+	// filterTimeBudget is the maximum time for evaluating the filter expression of a request.
+	filterTimeBudget = 5 * time.Second
+	// defaultMaxConcurrentJobs is the default maximum number of simultaneously running jobs.
+	defaultMaxConcurrentJobs = 16
+	// defaultMaxInFlightProbes is the default maximum number of simultaneous probes of all jobs.
+	defaultMaxInFlightProbes = 64
+	// drainTimeout is the maximum time for processing the remaining observations on shutdown.
+	drainTimeout = 5 * time.Second
+	// drainPollPeriod is the period for checking if the drain on shutdown is complete.
+	drainPollPeriod = 10 * time.Millisecond
+	// httpShutdownTimeout is the maximum time for completing the active http requests on shutdown.
+	httpShutdownTimeout = 5 * time.Second
+	// httpRetryMinBackoff and httpRetryMaxBackoff bound the delay for retrying to listen on the http server address.
+	httpRetryMinBackoff = 1 * time.Second
+	httpRetryMaxBackoff = 1 * time.Minute
+	// maxIncidentThreshold is the maximum number of consecutive observations for opening or closing an incident.
+	maxIncidentThreshold = 100
+)
+
 type server struct {
-	lock                 sync.Mutex
-	reloadLock           sync.Mutex
-	log                  logrus.FieldLogger
-	agentConfigFile      string
-	clusterConfigFile    string
-	nodeName             string
-	hostNetwork          bool
+	lock                sync.Mutex
+	reloadLock          sync.Mutex
+	writerLock          sync.RWMutex
+	log                 logrus.FieldLogger
+	agentConfigFile     string
+	clusterConfigFile   string
+	nodeName            string
+	podUID              string
+	hostNetwork         bool
+	environmentOverride string
+	environment         string
+	// httpAddress is the address the http server listens on, empty if not started
+	httpAddress          string
+	httpServer           *http.Server
+	httpRetryAt          time.Time
+	httpBackoff          time.Duration
+	httpListenError      atomic.Pointer[string]
+	disabledFeatures     []string
+	logDirectory         string
 	jobs                 map[jobid]*runners.InternalJob
+	limiter              *runners.Limiter
+	shared               *runners.Shared
+	manualTriggers       map[jobid]time.Time
+	skippedJobs          map[jobid]skippedJob
+	secrets              *secretResolver
+	secretRefreshActive  atomic.Bool
+	disabledJobs         common.StringSet
+	deletedJobs          common.StringSet
+	heartbeat            *heartbeatSettings
+	heartbeats           *heartbeatTracker
+	packetTrains         *packetTrainReceiver
+	lastHeartbeat        time.Time
+	heartbeatInFlight    bool
+	remoteWrite          *remoteWriteSettings
+	lastRemoteWrite      time.Time
+	remoteWriteInFlight  bool
+	tracer               atomic.Pointer[probeTracer]
+	sink                 atomic.Pointer[remoteSink]
+	serviceTLS           atomic.Pointer[agentServiceTLS]
+	serviceAuth          atomic.Pointer[agentServiceAuthSettings]
+	lastTraceExport      time.Time
+	traceExportInFlight  bool
+	okObservations       atomic.Int64
+	failedObservations   atomic.Int64
 	maxPeerNodes         int
 	nodeSampleStore      *config.NodeSampleStore
 	currentAgentConfig   *config.AgentConfig
 	currentClusterConfig *config.ClusterConfig
 	obsChan              chan *nwpd.Observation
+	runChan              chan *nwpd.JobRunRecord
+	gaps                 *gapMonitor
+	localBlock           *localBlockMonitor
 	writer               nwpd.ObservationWriter
+	writerSettings       writerSettings
+	writerStarted        bool
+	writerDone           chan struct{}
+	writerRunning        atomic.Bool
+	watchers             *observationHub
+	reloadFailures       atomic.Int32
+	configStatus         configStatus
+	loadedConfigHash     atomic.Pointer[string]
+	lastConfigRecheck    time.Time
+	lastTick             atomic.Int64
+	rollups              *db.RollupStore
 	aggregator           aggregation.ObservationListenerExtended
-	tickPeriod           time.Duration
+	timing               timing
+	reloadTimer          *time.Timer
+	reloadRunning        atomic.Bool
+	reloadPending        atomic.Bool
 	done                 chan struct{}
 }
 
 var _ nwpd.AgentService = &server{}
 
-func newServer(log logrus.FieldLogger, agentConfigFile, clusterConfigFile string, hostNetwork bool) (*server, error) {
+func newServer(log logrus.FieldLogger, agentConfigFile, clusterConfigFile string, hostNetwork bool, environment string) (*server, error) {
 	nodeName := getNodeName()
 	return &server{
-		log:               log,
-		agentConfigFile:   agentConfigFile,
-		clusterConfigFile: clusterConfigFile,
-		nodeName:          nodeName,
-		hostNetwork:       hostNetwork,
-		nodeSampleStore:   config.NewNodeSampleStore(nodeName),
-		jobs:              map[jobid]*runners.InternalJob{},
-		obsChan:           make(chan *nwpd.Observation, 100),
-		tickPeriod:        200 * time.Millisecond,
-		done:              make(chan struct{}),
+		log:                 log,
+		agentConfigFile:     agentConfigFile,
+		clusterConfigFile:   clusterConfigFile,
+		nodeName:            nodeName,
+		podUID:              os.Getenv(common.EnvPodUID),
+		hostNetwork:         hostNetwork,
+		environmentOverride: environment,
+		logDirectory:        common.PathLogDir,
+		nodeSampleStore:     config.NewNodeSampleStore(nodeName),
+		jobs:                map[jobid]*runners.InternalJob{},
+		shared:              runners.NewShared(BackedOffDestinations, RunnerPanics),
+		heartbeats:          PeerHeartbeats,
+		packetTrains:        newPacketTrainReceiver(log),
+		secrets:             newSecretResolver(newInClusterClient),
+		obsChan:             make(chan *nwpd.Observation, defaultObservationBufferSize),
+		runChan:             make(chan *nwpd.JobRunRecord, jobRunBufferSize),
+		gaps:                newGapMonitor(log.WithField("sub", "gaps")),
+		localBlock:          newLocalBlockMonitor(log.WithField("sub", "localblock")),
+		watchers:            newObservationHub(),
+		timing:              defaultTiming(),
+		done:                make(chan struct{}),
 	}, nil
 }
 
@@ -81,19 +176,32 @@ func getNodeName() string {
 	return nodeName
 }
 
+// getAgentConfig returns the applied agent configuration, or nil if none has been applied yet.
+func (s *server) getAgentConfig() *config.AgentConfig {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.currentAgentConfig
+}
+
+// getNetworkCfg returns the network configuration of the applied agent configuration. The caller must hold s.lock.
 func (s *server) getNetworkCfg() *config.NetworkConfig {
+	return s.getNetworkCfgOf(s.currentAgentConfig)
+}
+
+func (s *server) getNetworkCfgOf(cfg *config.AgentConfig) *config.NetworkConfig {
 	networkCfg := &config.NetworkConfig{}
-	if s.currentAgentConfig != nil {
-		if hostNetwork && s.currentAgentConfig.HostNetwork != nil {
-			networkCfg = s.currentAgentConfig.HostNetwork
-		} else if !hostNetwork && s.currentAgentConfig.PodNetwork != nil {
-			networkCfg = s.currentAgentConfig.PodNetwork
+	if cfg != nil {
+		if s.hostNetwork && cfg.HostNetwork != nil {
+			networkCfg = cfg.HostNetwork
+		} else if !s.hostNetwork && cfg.PodNetwork != nil {
+			networkCfg = cfg.PodNetwork
 		}
 	}
 	return networkCfg
 }
 
 func (s *server) setup() error {
+	s.recordConfigFilesHash()
 	cfg, err := config.LoadAgentConfig(s.agentConfigFile)
 	if err != nil {
 		return err
@@ -102,33 +210,64 @@ func (s *server) setup() error {
 	if err != nil {
 		return err
 	}
+	env, detected, err := environmentOf(s.environmentOverride, cfg)
+	if err != nil {
+		return err
+	}
+	s.environment = env
+	s.disabledFeatures = disabledFeaturesOf(env, cfg)
+	if env != config.EnvironmentKubernetes {
+		// no in-cluster access for reading secrets
+		s.secrets.newClient = nil
+	}
+	s.logEnvironment(detected)
 
 	options := &aggregation.ObsAggregationOptions{
-		Log:          s.log.WithField("sub", "aggr"),
-		NodeName:     s.nodeName,
-		ReportPeriod: 1 * time.Minute,
-		TimeWindow:   30 * time.Minute,
-		LogDirectory: common.PathLogDir,
-		HostNetwork:  s.hostNetwork,
-	}
-	if cfg.K8sExporter != nil {
+		Log:         s.log.WithField("sub", "aggr"),
+		NodeName:    s.nodeName,
+		HostNetwork: s.hostNetwork,
+	}
+	aggrCfg := s.aggregationConfigOf(cfg)
+	output, err := aggregationOutputOf(aggrCfg, s.logDirectory)
+	if err != nil {
+		return err
+	}
+	options.LogDirectory, options.ReportWriter = reportOutputOf(output)
+	options.ReportResultFields = aggrCfg.AggregationReportResultFields
+	options.IncidentMinFailures, options.IncidentMinRecoveries, err = incidentThresholdsOf(cfg)
+	if err != nil {
+		return err
+	}
+	options.IncidentObserver = incidentMetrics{}
+	options.ZoneObserver = zoneMetrics{}
+	options.ReportFormat, err = reportFormatOf(aggrCfg)
+	if err != nil {
+		return err
+	}
+	options.ReportLogJSON = aggrCfg.IsReportLogJSON()
+	options.TopFailingEdges, options.TopFailingEdgesMinChecks, err = topFailingEdgesOf(aggrCfg)
+	if err != nil {
+		return err
+	}
+	options.ReportRetention, options.ReportMaxBytes, err = reportRotationOf(cfg)
+	if err != nil {
+		return err
+	}
+	ws, err := s.writerSettingsOf(cfg)
+	if err != nil {
+		return err
+	}
+	options.IncidentFile = ws.incidentFile()
+	if cfg.K8sExporter != nil && s.environment != config.EnvironmentStandalone {
 		options.K8sExporterConfig = *cfg.K8sExporter
 		if options.K8sExporterConfig.HeartbeatPeriod.Duration < 1*time.Minute {
 			return fmt.Errorf("invalid K8sExporter heartbeatPeriod, must be >= 1m")
 		}
 	}
 
-	if cfg.AggregationReportPeriod != nil {
-		options.ReportPeriod = cfg.AggregationReportPeriod.Duration
-		if options.ReportPeriod < 30*time.Second {
-			return fmt.Errorf("invalid AggregationReportPeriod, must be >= 30s")
-		}
-	}
-	if cfg.AggregationTimeWindow != nil {
-		options.TimeWindow = cfg.AggregationTimeWindow.Duration
-		if options.TimeWindow < 5*time.Minute {
-			return fmt.Errorf("invalid AggregationTimeWindow, must be >= 5m")
-		}
+	options.ReportPeriod, options.TimeWindow, err = aggregationTimings(aggrCfg)
+	if err != nil {
+		return err
 	}
 	s.aggregator, err = aggregation.NewObsAggregator(options)
 	if err != nil {
@@ -136,69 +275,492 @@ func (s *server) setup() error {
 	}
 	s.maxPeerNodes = cfg.MaxPeerNodes
 
+	t, err := timingOf(cfg, s.getNetworkCfgOf(cfg))
+	if err != nil {
+		return err
+	}
+	// the buffer size can only be changed before the observations are processed
+	s.obsChan = make(chan *nwpd.Observation, t.observationBufferSize)
+	ObservationQueueCapacity.Set(float64(cap(s.obsChan)))
+
+	s.shared.SetRunRecorder(&runners.RunRecorder{OnRun: s.recordJobRun})
+	s.recordJobRun(&nwpd.JobRunRecord{Kind: nwpd.JobRunKindAgentStart, SrcHost: s.nodeName, Start: timestamppb.Now()})
+
 	return s.applyAgentConfig(cfg)
 }
 
-func (s *server) applyAgentConfig(cfg *config.AgentConfig) error {
-	oldJobs := s.getNetworkCfg().Jobs
-	clone, err := cfg.Clone()
+// httpAddressOf returns the listen address of the http server, empty if the http server is disabled.
+// The placeholder `{podIP}` is replaced by the pod IP.
+func httpAddressOf(networkCfg *config.NetworkConfig) (string, error) {
+	if networkCfg.HTTPPort == 0 {
+		return "", nil
+	}
+	bindAddress := networkCfg.HTTPBindAddress
+	if bindAddress == config.PlaceholderPodIP {
+		bindAddress = os.Getenv(common.EnvPodIP)
+		if bindAddress == "" {
+			return "", fmt.Errorf("invalid httpBindAddress, %s requires the environment variable %s", config.PlaceholderPodIP, common.EnvPodIP)
+		}
+	}
+	if bindAddress != "" && net.ParseIP(bindAddress) == nil {
+		return "", fmt.Errorf("invalid httpBindAddress %q, must be an IP address or %s", bindAddress, config.PlaceholderPodIP)
+	}
+	return net.JoinHostPort(bindAddress, strconv.Itoa(networkCfg.HTTPPort)), nil
+}
+
+// dataFilePrefixOf returns the prefix of the record files with the placeholders expanded.
+func dataFilePrefixOf(networkCfg *config.NetworkConfig) (string, error) {
+	prefix := "agent"
+	if networkCfg.DataFilePrefix != "" {
+		prefix = networkCfg.DataFilePrefix
+	}
+	return db.ExpandFilePrefix(prefix)
+}
+
+// aggregationConfigOf returns the aggregation settings of the daemon set.
+// The settings of the network configuration take precedence over the ones of the agent configuration.
+func (s *server) aggregationConfigOf(cfg *config.AgentConfig) *config.AggregationConfig {
+	aggrCfg := cfg.AggregationConfig.Override(s.getNetworkCfgOf(cfg).AggregationConfig)
+	return &aggrCfg
+}
+
+// EffectiveAggregationConfig returns the aggregation settings of the daemon set in the host or pod network
+// with the built-in defaults for all settings not set in the network or the agent configuration.
+func EffectiveAggregationConfig(cfg *config.AgentConfig, hostNetwork bool) (*config.AggregationConfig, error) {
+	networkCfg := cfg.PodNetwork
+	if hostNetwork {
+		networkCfg = cfg.HostNetwork
+	}
+	aggrCfg := cfg.AggregationConfig
+	if networkCfg != nil {
+		aggrCfg = aggrCfg.Override(networkCfg.AggregationConfig)
+	}
+	reportPeriod, timeWindow, err := aggregationTimings(&aggrCfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	s.currentAgentConfig = clone
+	aggrCfg.AggregationReportPeriod = &metav1.Duration{Duration: reportPeriod}
+	aggrCfg.AggregationTimeWindow = &metav1.Duration{Duration: timeWindow}
+	if aggrCfg.AggregationReportFormat, err = reportFormatOf(&aggrCfg); err != nil {
+		return nil, err
+	}
+	if aggrCfg.AggregationOutput, err = aggregationOutputOf(&aggrCfg, common.PathLogDir); err != nil {
+		return nil, err
+	}
+	aggrCfg.AggregationReportLogJSON = ptr.To(aggrCfg.IsReportLogJSON())
+	aggrCfg.AggregationReportTopFailingEdges, aggrCfg.AggregationReportTopFailingEdgesMinChecks, err = topFailingEdgesOf(&aggrCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &aggrCfg, nil
+}
 
-	networkCfg := s.getNetworkCfg()
-	if cfg.OutputDir != "" && s.writer == nil {
-		prefix := "agent"
-		if networkCfg.DataFilePrefix != "" {
-			prefix = networkCfg.DataFilePrefix
+// aggregationTimings returns report period and time window of the aggregation.
+func aggregationTimings(cfg *config.AggregationConfig) (reportPeriod, timeWindow time.Duration, err error) {
+	reportPeriod = 1 * time.Minute
+	timeWindow = 30 * time.Minute
+	if cfg.AggregationReportPeriod != nil {
+		reportPeriod = cfg.AggregationReportPeriod.Duration
+		if reportPeriod < 30*time.Second {
+			return 0, 0, fmt.Errorf("invalid AggregationReportPeriod, must be >= 30s")
 		}
-		var err error
-		s.writer, err = db.NewObsWriter(s.log.WithField("sub", "writer"), cfg.OutputDir, prefix, cfg.RetentionHours)
-		if err != nil {
-			return err
+	}
+	if cfg.AggregationTimeWindow != nil {
+		timeWindow = cfg.AggregationTimeWindow.Duration
+		if timeWindow < 5*time.Minute {
+			return 0, 0, fmt.Errorf("invalid AggregationTimeWindow, must be >= 5m")
 		}
 	}
+	return
+}
 
-	validDestHosts := common.StringSet{}
-	applied := common.StringSet{}
-	peerNodeCount := 1
-	for _, j := range networkCfg.Jobs {
-		job, err := s.parseJob(&j)
-		if err != nil {
-			return err
+// reportFormatOf returns the format of the aggregation report file.
+func reportFormatOf(cfg *config.AggregationConfig) (string, error) {
+	switch cfg.AggregationReportFormat {
+	case "", config.ReportFormatText:
+		return config.ReportFormatText, nil
+	case config.ReportFormatJSON:
+		return config.ReportFormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid AggregationReportFormat, must be %s or %s", config.ReportFormatText, config.ReportFormatJSON)
+	}
+}
+
+// aggregationOutputOf returns the validated sink of the aggregation report, by default the report files in the default directory.
+func aggregationOutputOf(cfg *config.AggregationConfig, defaultDir string) (string, error) {
+	output := cfg.AggregationOutput
+	switch {
+	case output == "":
+		return config.AggregationOutputFilePrefix + defaultDir, nil
+	case output == config.AggregationOutputStdout, output == config.AggregationOutputNone:
+		return output, nil
+	case strings.HasPrefix(output, config.AggregationOutputFilePrefix) && len(output) > len(config.AggregationOutputFilePrefix):
+		return output, nil
+	default:
+		return "", fmt.Errorf("invalid AggregationOutput, expected format %s<dir>, %s or %s",
+			config.AggregationOutputFilePrefix, config.AggregationOutputStdout, config.AggregationOutputNone)
+	}
+}
+
+// reportOutputOf returns the directory of the report files and the writer the report is written to instead
+// for a validated aggregation output. Both are empty for the output `none`.
+func reportOutputOf(output string) (string, io.Writer) {
+	switch output {
+	case config.AggregationOutputStdout:
+		return "", os.Stdout
+	case config.AggregationOutputNone:
+		return "", nil
+	default:
+		return strings.TrimPrefix(output, config.AggregationOutputFilePrefix), nil
+	}
+}
+
+// incidentThresholdsOf returns the number of consecutive failures for opening and of consecutive successes for closing an incident.
+func incidentThresholdsOf(cfg *config.AgentConfig) (minFailures, minRecoveries int, err error) {
+	minFailures = aggregation.DefaultIncidentMinFailures
+	minRecoveries = aggregation.DefaultIncidentMinRecoveries
+	if cfg.Incidents != nil {
+		if cfg.Incidents.MinFailures != 0 {
+			minFailures = cfg.Incidents.MinFailures
+			if minFailures < 1 || minFailures > maxIncidentThreshold {
+				return 0, 0, fmt.Errorf("invalid Incidents minFailures, must be in range [1,%d]", maxIncidentThreshold)
+			}
 		}
-		if job != nil {
-			s.addOrReplaceJob(job)
-			for _, s := range job.DestHosts() {
-				validDestHosts.Add(s)
+		if cfg.Incidents.MinRecoveries != 0 {
+			minRecoveries = cfg.Incidents.MinRecoveries
+			if minRecoveries < 1 || minRecoveries > maxIncidentThreshold {
+				return 0, 0, fmt.Errorf("invalid Incidents minRecoveries, must be in range [1,%d]", maxIncidentThreshold)
 			}
-			if job.PeerNodeCount() > peerNodeCount {
-				peerNodeCount = job.PeerNodeCount()
+		}
+	}
+	return
+}
+
+// topFailingEdgesOf returns the number of edges and the minimum number of checks of an edge for the top failing edges summary.
+func topFailingEdgesOf(cfg *config.AggregationConfig) (k, minChecks int, err error) {
+	k = aggregation.DefaultTopFailingEdges
+	minChecks = aggregation.DefaultTopFailingEdgesMinChecks
+	switch {
+	case cfg.AggregationReportTopFailingEdges < 0:
+		return 0, 0, fmt.Errorf("invalid AggregationReportTopFailingEdges, must be >= 0")
+	case cfg.AggregationReportTopFailingEdges > 0:
+		k = cfg.AggregationReportTopFailingEdges
+	}
+	switch {
+	case cfg.AggregationReportTopFailingEdgesMinChecks < 0:
+		return 0, 0, fmt.Errorf("invalid AggregationReportTopFailingEdgesMinChecks, must be >= 0")
+	case cfg.AggregationReportTopFailingEdgesMinChecks > 0:
+		minChecks = cfg.AggregationReportTopFailingEdgesMinChecks
+	}
+	return
+}
+
+// reportRotationOf returns the retention and the maximum total size of the aggregation report files.
+func reportRotationOf(cfg *config.AgentConfig) (retention time.Duration, maxBytes int64, err error) {
+	retention = aggregation.DefaultReportRetention
+	maxBytes = aggregation.DefaultReportMaxBytes
+	switch {
+	case cfg.ReportRetentionHours < 0:
+		return 0, 0, fmt.Errorf("invalid ReportRetentionHours, must be >= 0")
+	case cfg.ReportRetentionHours > 0:
+		retention = time.Duration(cfg.ReportRetentionHours) * time.Hour
+	}
+	switch {
+	case cfg.ReportMaxMegabytes < 0:
+		return 0, 0, fmt.Errorf("invalid ReportMaxMegabytes, must be >= 0")
+	case cfg.ReportMaxMegabytes > 0:
+		maxBytes = int64(cfg.ReportMaxMegabytes) * 1000 * 1000
+	}
+	return
+}
+
+// maxDiskUsageOf returns the maximum total size of the record files in bytes (0 for no limit).
+func maxDiskUsageOf(cfg *config.AgentConfig) (int64, error) {
+	if cfg.MaxDiskUsageMegabytes < 0 {
+		return 0, fmt.Errorf("invalid MaxDiskUsageMegabytes, must be >= 0")
+	}
+	return int64(cfg.MaxDiskUsageMegabytes) * 1000 * 1000, nil
+}
+
+// maxConcurrentJobsOf returns the maximum number of simultaneously running jobs.
+func maxConcurrentJobsOf(cfg *config.AgentConfig) (int, error) {
+	switch {
+	case cfg.MaxConcurrentJobs < 0:
+		return 0, fmt.Errorf("invalid MaxConcurrentJobs, must be >= 0")
+	case cfg.MaxConcurrentJobs == 0:
+		return defaultMaxConcurrentJobs, nil
+	default:
+		return cfg.MaxConcurrentJobs, nil
+	}
+}
+
+func maxInFlightProbesOf(cfg *config.AgentConfig) (int, error) {
+	switch {
+	case cfg.MaxInFlightProbes < 0:
+		return 0, fmt.Errorf("invalid MaxInFlightProbes, must be >= 0")
+	case cfg.MaxInFlightProbes == 0:
+		return defaultMaxInFlightProbes, nil
+	default:
+		return cfg.MaxInFlightProbes, nil
+	}
+}
+
+// applyAgentConfig applies the agent configuration as a whole. If it cannot be applied, the previous configuration
+// and its jobs are kept untouched.
+func (s *server) applyAgentConfig(cfg *config.AgentConfig) error {
+	if err := s.tryApplyAgentConfig(cfg); err != nil {
+		s.log.Warnf("config rejected, keeping previous revision %d: %s", s.configStatus.currentRevision(), s.secrets.redact(err.Error()))
+		ConfigRejections.Inc()
+		return err
+	}
+	return nil
+}
+
+// tryApplyAgentConfig validates the configuration and parses all jobs before any setting or job is changed.
+func (s *server) tryApplyAgentConfig(cfg *config.AgentConfig) error {
+	oldJobs := s.getNetworkCfgOf(s.getAgentConfig()).Jobs
+	clone, err := cfg.Clone()
+	if err != nil {
+		return err
+	}
+	if clone.ScalingPolicy != nil {
+		if err := clone.ScalingPolicy.Validate(); err != nil {
+			return err
+		}
+		// guard against an agent configuration rendered for another cluster size
+		if s.currentClusterConfig != nil && runners.NeedsRescaling(clone, s.currentClusterConfig.NodeCount) {
+			nodeCount := s.currentClusterConfig.NodeCount
+			s.log.Infof("applying scaling policy for %d nodes (rendered for %d nodes)", nodeCount, clone.ScaledForNodeCount)
+			if err := runners.ApplyScalingPolicy(clone, nodeCount); err != nil {
+				return err
 			}
 		}
-		applied.Add(j.JobID)
+	}
+	if networkCfg := s.getNetworkCfgOf(clone); networkCfg.Jitter < 0 || networkCfg.Jitter >= 1 {
+		return fmt.Errorf("invalid jitter, must be in range [0.0,1.0)")
+	}
+	if s.getNetworkCfgOf(clone).MaxCIDRAddresses < 0 {
+		return fmt.Errorf("invalid maxCIDRAddresses, must be >= 0")
+	}
+	// a changed address is applied by the main loop
+	if _, err := httpAddressOf(s.getNetworkCfgOf(clone)); err != nil {
+		return err
+	}
+	aggrCfg := s.aggregationConfigOf(clone)
+	reportPeriod, timeWindow, err := aggregationTimings(aggrCfg)
+	if err != nil {
+		return err
+	}
+	env, _, err := environmentOf(s.environmentOverride, clone)
+	if err != nil {
+		return err
+	}
+	if s.environment != "" && env != s.environment {
+		s.log.Warnf("environment %s is only applied on restart, current environment is %s", env, s.environment)
+	}
+	maxConcurrentJobs, err := maxConcurrentJobsOf(clone)
+	if err != nil {
+		return err
+	}
+	incidentMinFailures, incidentMinRecoveries, err := incidentThresholdsOf(clone)
+	if err != nil {
+		return err
+	}
+	reportFormat, err := reportFormatOf(aggrCfg)
+	if err != nil {
+		return err
+	}
+	aggregationOutput, err := aggregationOutputOf(aggrCfg, s.logDirectory)
+	if err != nil {
+		return err
+	}
+	topFailingEdges, topMinChecks, err := topFailingEdgesOf(aggrCfg)
+	if err != nil {
+		return err
+	}
+	reportRetention, reportMaxBytes, err := reportRotationOf(clone)
+	if err != nil {
+		return err
+	}
+	maxInFlightProbes, err := maxInFlightProbesOf(clone)
+	if err != nil {
+		return err
+	}
+	maxDiskUsage, err := maxDiskUsageOf(clone)
+	if err != nil {
+		return err
+	}
+	newTiming, err := timingOf(clone, s.getNetworkCfgOf(clone))
+	if err != nil {
+		return err
+	}
+	heartbeat, err := s.heartbeatSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	remoteWrite, err := remoteWriteSettingsOf(clone, reportPeriod)
+	if err != nil {
+		return err
+	}
+	tracing, err := tracingSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	sink, err := remoteSinkSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	serviceTLSSettings, err := agentServiceTLSSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	serviceTLS, err := s.agentServiceTLSOf(serviceTLSSettings)
+	if err != nil {
+		return err
+	}
+	serviceAuth, err := agentServiceAuthSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	secretRefreshPeriod, err := secretRefreshPeriodOf(clone)
+	if err != nil {
+		return err
+	}
+	gapOptions, err := gapDetectionOptionsOf(clone)
+	if err != nil {
+		return err
+	}
+	localBlock, err := s.localBlockSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	ws, err := s.writerSettingsOf(clone)
+	if err != nil {
+		return err
+	}
+	if _, err := metricLabelNamesOf(clone.MetricLabels); err != nil {
+		return err
+	}
+	// without a previous revision to keep, e.g. on start, invalid jobs are skipped instead of failing the whole agent
+	staged, err := s.stageJobs(s.getNetworkCfgOf(clone), newTiming, s.configStatus.currentRevision() == 0)
+	if err != nil {
+		return err
+	}
+	// the writer is only replaced if the new one could be created, nothing below fails
+	if err := s.applyWriterSettings(ws, maxDiskUsage, newTiming); err != nil {
+		return err
+	}
+	if err := configureMetricLabels(clone.MetricLabels); err != nil {
+		return err
+	}
+	configureDurationHistogram(clone.DurationHistogram)
+	s.lock.Lock()
+	if s.limiter == nil || s.limiter.Max() != maxConcurrentJobs {
+		// runs in progress release their slot on the old limiter
+		s.limiter = runners.NewLimiter(maxConcurrentJobs, RunningJobs)
+	}
+	s.lock.Unlock()
+	if limiter := s.shared.ProbeLimiter(); limiter == nil || limiter.Max() != maxInFlightProbes {
+		s.shared.SetProbeLimiter(runners.NewLimiter(maxInFlightProbes, InFlightProbes))
+	}
+	if s.aggregator != nil {
+		s.aggregator.Reconfigure(reportPeriod, timeWindow)
+		s.aggregator.SetReportResultFields(aggrCfg.AggregationReportResultFields)
+		s.aggregator.SetIncidentThresholds(incidentMinFailures, incidentMinRecoveries)
+		s.aggregator.SetReportFormat(reportFormat, aggrCfg.IsReportLogJSON())
+		s.aggregator.SetTopFailingEdges(topFailingEdges, topMinChecks)
+		s.aggregator.SetReportRotation(reportRetention, reportMaxBytes)
+		s.aggregator.SetReportOutput(reportOutputOf(aggregationOutput))
+		s.aggregator.SetIncidentFile(ws.incidentFile())
+	}
+	s.secrets.setRefreshPeriod(secretRefreshPeriod)
+	s.gaps.configure(gapOptions)
+	s.localBlock.configure(localBlock)
+	if remoteWrite != nil {
+		remoteWrite.secrets = s.secrets
+		remoteWrite.nodeName = s.nodeName
+	}
+	s.lock.Lock()
+	s.heartbeat = heartbeat
+	s.remoteWrite = remoteWrite
+	s.timing = newTiming
+	s.lock.Unlock()
+	s.applyTracing(tracing)
+	s.applyRemoteSink(sink)
+	s.applyAgentServiceTLS(serviceTLS)
+	s.applyAgentServiceAuth(serviceAuth)
+	if s.obsChan != nil && cap(s.obsChan) != newTiming.observationBufferSize {
+		s.log.Warnf("timing observationBufferSize %d is only applied on restart, current size is %d", newTiming.observationBufferSize, cap(s.obsChan))
+	}
+	s.shared.SetBackpressure(newBackpressureReporter(s.log, cap(s.obsChan)).backpressure(newTiming.observationWait()))
+	if s.heartbeats != nil {
+		s.heartbeats.configure(heartbeat)
+	}
+	if s.packetTrains != nil {
+		port, allowed := packetTrainSettingsOf(s.currentClusterConfig)
+		if port != 0 && s.getNetworkCfgOf(clone).HTTPPort == 0 {
+			s.log.Warnf("packet trains require the http server, but httpPort is not set")
+			port = 0
+		}
+		if err := s.packetTrains.configure(port, allowed); err != nil {
+			s.log.Warnf("cannot listen for packet trains on udp port %d: %s", port, err)
+		}
+	}
+	s.lock.Lock()
+	s.currentAgentConfig = clone
+	s.lock.Unlock()
+	for _, job := range staged.jobs {
+		s.addOrReplaceJob(job)
 	}
 
 	var obsoleteJobIDs []string
 	for _, j := range oldJobs {
-		if !applied.Contains(j.JobID) {
+		if staged.applied.Contains(j.JobID) && !reflect.DeepEqual(j.Labels, staged.labels[j.JobID]) {
+			// series with the old label values are outdated
 			obsoleteJobIDs = append(obsoleteJobIDs, j.JobID)
-			if err := s.deleteJob(j.JobID); err != nil {
+		}
+		if !staged.applied.Contains(j.JobID) {
+			if !staged.notMatching.Contains(j.JobID) {
+				obsoleteJobIDs = append(obsoleteJobIDs, j.JobID)
+			}
+			reason := "removed"
+			if sj, ok := staged.skipped[j.JobID]; ok {
+				reason = sj.reason
+			}
+			if err := s.deleteJob(j.JobID, reason); err != nil {
 				return err
 			}
 		}
 	}
+	for jobID := range staged.notMatching {
+		if err := s.deleteJob(jobID, staged.skipped[jobID].reason); err != nil {
+			return err
+		}
+	}
+	for jobID := range staged.disabled {
+		if err := s.deleteJob(jobID, "disabled"); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	s.skippedJobs = staged.skipped
+	s.disabledJobs = staged.disabled
+	jobs := make([]*runners.InternalJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.lock.Unlock()
+	s.logTiming(newTiming, jobs)
 	deleteOutdatedMetricByObsoleteJobIDs(obsoleteJobIDs)
-	deleteOutdatedMetricByValidDestHosts(validDestHosts)
+	deleteOutdatedMetricByValidDestHosts(staged.validDestHosts)
 	if s.aggregator != nil {
 		validSrcHosts := common.StringSet{}
 		validSrcHosts.Add(s.nodeName)
 		s.aggregator.UpdateValidEdges(aggregation.ValidEdges{
-			JobIDs:        applied,
+			JobIDs:        staged.applied,
 			SrcHosts:      validSrcHosts,
-			DestHosts:     validDestHosts,
-			PeerNodeCount: peerNodeCount,
+			DestHosts:     staged.validDestHosts,
+			PeerNodeCount: staged.peerNodeCount,
 		})
 	}
 	go func() {
@@ -206,38 +768,189 @@ func (s *server) applyAgentConfig(cfg *config.AgentConfig) error {
 		// wait for request timeout
 		time.Sleep(1 * time.Minute)
 		deleteOutdatedMetricByObsoleteJobIDs(obsoleteJobIDs)
-		deleteOutdatedMetricByValidDestHosts(validDestHosts)
+		deleteOutdatedMetricByValidDestHosts(staged.validDestHosts)
 	}()
 
+	s.configStatus.applied(time.Now())
 	return nil
 }
 
-func (s *server) parseJob(job *config.Job) (*runners.InternalJob, error) {
+// stagedJobs are the jobs of a network configuration parsed before any of them is started.
+type stagedJobs struct {
+	jobs           []*runners.InternalJob
+	applied        common.StringSet
+	notMatching    common.StringSet
+	disabled       common.StringSet
+	skipped        map[jobid]skippedJob
+	labels         map[string]map[string]string
+	validDestHosts common.StringSet
+	peerNodeCount  int
+}
+
+// stageJobs parses all jobs of the network configuration without changing the running jobs. An invalid job rejects the
+// configuration unless skipInvalid is set, while a job with an unresolvable secret is skipped and keeps a running job with the same ID.
+func (s *server) stageJobs(networkCfg *config.NetworkConfig, t timing, skipInvalid bool) (*stagedJobs, error) {
+	staged := &stagedJobs{
+		applied:        common.StringSet{},
+		notMatching:    common.StringSet{},
+		disabled:       common.StringSet{},
+		skipped:        map[jobid]skippedJob{},
+		labels:         map[string]map[string]string{},
+		validDestHosts: common.StringSet{},
+		peerNodeCount:  1,
+	}
+	for _, j := range networkCfg.Jobs {
+		staged.labels[j.JobID] = j.Labels
+		if !j.IsEnabled() {
+			// a disabled job is stopped like a removed one
+			s.log.Debugf("skipping job %s: disabled", j.JobID)
+			staged.skipped[j.JobID] = skippedJob{args: j.Args, reason: "disabled", disabled: true}
+			staged.disabled.Add(j.JobID)
+			continue
+		}
+		if s.environment == config.EnvironmentStandalone {
+			if reason := kubernetesOnlyReasonOf(j.Args); reason != "" {
+				s.log.Debugf("skipping job %s: %s", j.JobID, reason)
+				staged.skipped[j.JobID] = skippedJob{args: j.Args, reason: reason}
+				staged.notMatching.Add(j.JobID)
+				continue
+			}
+		}
+		match, err := s.matchesNode(&j)
+		if err != nil {
+			if !skipInvalid {
+				return nil, err
+			}
+			s.log.Warnf("skipping job: %s", err)
+			staged.skipped[j.JobID] = skippedJob{args: j.Args, reason: err.Error()}
+			staged.notMatching.Add(j.JobID)
+			continue
+		}
+		if !match {
+			reason := fmt.Sprintf("not selected for node %s", s.nodeName)
+			s.log.Debugf("skipping job %s: %s", j.JobID, reason)
+			staged.skipped[j.JobID] = skippedJob{args: j.Args, reason: reason}
+			staged.notMatching.Add(j.JobID)
+			continue
+		}
+		job, err := s.parseJob(&j, networkCfg, t)
+		if err != nil {
+			var secretErr *secretResolutionError
+			if !errors.As(err, &secretErr) {
+				if !skipInvalid {
+					return nil, err
+				}
+				s.log.Warnf("skipping job: %s", err)
+				staged.skipped[j.JobID] = skippedJob{args: j.Args, reason: err.Error()}
+				continue
+			}
+			// skip the degraded job, but keep a running job with the same ID
+			s.log.Warnf("job %s degraded: %s", j.JobID, err)
+			staged.skipped[j.JobID] = skippedJob{args: j.Args, reason: err.Error(), degraded: true}
+			if s.getJob(j.JobID) != nil {
+				staged.applied.Add(j.JobID)
+			}
+			continue
+		}
+		if job != nil {
+			staged.jobs = append(staged.jobs, job)
+			for _, s := range job.DestHosts() {
+				staged.validDestHosts.Add(s)
+			}
+			if job.PeerNodeCount() > staged.peerNodeCount {
+				staged.peerNodeCount = job.PeerNodeCount()
+			}
+		}
+		staged.applied.Add(j.JobID)
+	}
+	return staged, nil
+}
+
+func (s *server) getJob(jobID string) *runners.InternalJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.jobs[jobID]
+}
+
+// matchesNode checks if the job node selector and node name pattern match the node of the agent.
+func (s *server) matchesNode(job *config.Job) (bool, error) {
+	if job.NodeNamePattern != "" {
+		re, err := regexp.Compile("^(?:" + job.NodeNamePattern + ")$")
+		if err != nil {
+			return false, fmt.Errorf("invalid job %s: invalid node name pattern: %s", job.JobID, err)
+		}
+		if !re.MatchString(s.nodeName) {
+			return false, nil
+		}
+	}
+	if len(job.NodeSelector) == 0 {
+		return true, nil
+	}
+	var labels map[string]string
+	if s.currentClusterConfig != nil {
+		for _, n := range s.currentClusterConfig.Nodes {
+			if n.Hostname == s.nodeName {
+				labels = n.Labels
+				break
+			}
+		}
+	}
+	for key, value := range job.NodeSelector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *server) parseJob(job *config.Job, networkCfg *config.NetworkConfig, t timing) (internalJob *runners.InternalJob, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			internalJob = nil
+			err = fmt.Errorf("invalid job %s: panic on parsing: %v", job.JobID, r)
+		}
+	}()
+
 	n := len(job.Args)
 	if n == 0 {
-		return nil, fmt.Errorf("no job args")
+		return nil, fmt.Errorf("invalid job %s: no job args", job.JobID)
 	}
 
-	defaultPeriod := 1 * time.Second
-	if s.getNetworkCfg().DefaultPeriod.Duration != 0 {
-		defaultPeriod = s.getNetworkCfg().DefaultPeriod.Duration
+	defaultPeriod := runners.DefaultPeriod
+	if networkCfg.DefaultPeriod.Duration != 0 {
+		defaultPeriod = networkCfg.DefaultPeriod.Duration
 	}
 	rconfig := runners.RunnerConfig{
-		Job:    *job,
-		Period: defaultPeriod,
+		Job:              *job,
+		Period:           defaultPeriod,
+		MaxCIDRAddresses: networkCfg.MaxCIDRAddresses,
+		Shared:           s.shared,
 	}
 	clusterCfg := config.ClusterConfig{}
 	if s.currentClusterConfig != nil {
 		clusterCfg = *s.currentClusterConfig
 	}
+	// the job config keeps the secret references, so that the resolved values are not exposed by the job status
+	args, err := s.secrets.resolveArgs(job.Args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job %s: %w", job.JobID, err)
+	}
 	shuffleCfg := config.SampleConfig{
 		MaxNodes:        s.maxPeerNodes,
 		NodeSampleStore: s.nodeSampleStore,
 	}
-	internalJob, err := runners.Parse(clusterCfg, rconfig, job.Args, &shuffleCfg)
+	internalJob, err = runners.Parse(clusterCfg, rconfig, args, &shuffleCfg)
 	if err != nil {
 		return nil, fmt.Errorf("invalid job %s: %s", job.JobID, err)
 	}
+	if internalJob == nil {
+		// no destinations, e.g. no peer nodes yet
+		return nil, nil
+	}
+	if err := t.checkJobPeriod(internalJob); err != nil {
+		return nil, err
+	}
 	return internalJob, nil
 }
 
@@ -245,16 +958,75 @@ func (s *server) addOrReplaceJob(job *runners.InternalJob) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	prefix := "starting"
-	if oldJob := s.jobs[job.JobID()]; oldJob != nil {
-		prefix = "restarting"
-		job.SetLastRun(oldJob.GetLastRun())
-	} else {
-		virtualLastRun := time.Now().Add(-time.Duration(float64(job.Period()) * rand.Float64())) // #nosec G404 -- no cryptographic use
-		job.SetLastRun(&virtualLastRun)
+	networkCfg := s.getNetworkCfg()
+	job.SetJitter(networkCfg.Jitter)
+	prefix := "starting"
+	if oldJob := s.jobs[job.JobID()]; oldJob != nil {
+		prefix = "restarting"
+		oldJob.Cancel()
+		job.ContinueScheduleOf(oldJob)
+	} else {
+		if s.disabledJobs.Contains(job.JobID()) {
+			// the schedule of a re-enabled job is seeded like the one of a new job
+			prefix = "re-enabling"
+		}
+		phaseKey := job.JobID()
+		if networkCfg.SpreadByNode == nil || *networkCfg.SpreadByNode {
+			phaseKey = s.nodeName + "/" + phaseKey
+		}
+		job.SetPhase(phaseKey, time.Now())
+	}
+	s.jobs[job.JobID()] = job
+	s.deletedJobs.Delete(job.JobID())
+	s.logStart(job, prefix)
+}
+
+func (s *server) getTiming() timing {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.timing
+}
+
+// logTiming logs the effective timing profile and warns if the observation buffer may overflow.
+func (s *server) logTiming(t timing, jobs []*runners.InternalJob) {
+	burst := expectedBurst(jobs)
+	s.log.Infof("timing: %s, jobs=%d, expectedBurst=%d", t, len(jobs), burst)
+	if s.obsChan != nil && burst > cap(s.obsChan) {
+		s.log.Warnf("expected burst of %d observations exceeds the observation buffer size %d, consider increasing timing observationBufferSize", burst, cap(s.obsChan))
+	}
+}
+
+// scheduleReload reloads the configuration after the reload debounce, so that multiple file changes are applied at once.
+// It is only called from the run loop.
+func (s *server) scheduleReload() {
+	debounce := s.getTiming().reloadDebounce
+	if debounce == 0 {
+		go s.requestReload()
+		return
+	}
+	if s.reloadTimer != nil {
+		s.reloadTimer.Stop()
+	}
+	s.reloadTimer = time.AfterFunc(debounce, s.requestReload)
+}
+
+// requestReload reloads the configuration unless a reload is already running. In this case the running reload is
+// repeated once after it has finished, so that all requests arriving meanwhile are coalesced into a single reload.
+func (s *server) requestReload() {
+	if !s.reloadRunning.CompareAndSwap(false, true) {
+		s.reloadPending.Store(true)
+		s.log.Debug("reload already running, coalesced")
+		return
+	}
+	for {
+		// requests arriving before the reload starts are covered by it
+		s.reloadPending.Store(false)
+		s.reloadConfig()
+		s.reloadRunning.Store(false)
+		if !s.reloadPending.Load() || !s.reloadRunning.CompareAndSwap(false, true) {
+			return
+		}
 	}
-	s.jobs[job.JobID()] = job
-	s.logStart(job, prefix)
 }
 
 func (s *server) logStart(job *runners.InternalJob, prefix string) {
@@ -266,24 +1038,48 @@ func (s *server) logStart(job *runners.InternalJob, prefix string) {
 		desc, job.Period().Seconds())
 }
 
-func (s *server) deleteJob(jobID string) error {
+// deleteJob stops the scheduled runs of the job and records the cancellation with the given reason.
+func (s *server) deleteJob(jobID, reason string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	if oldJob := s.jobs[jobID]; oldJob != nil {
+		oldJob.Cancel()
 		delete(s.jobs, jobID)
+		if s.deletedJobs == nil {
+			s.deletedJobs = common.StringSet{}
+		}
+		s.deletedJobs.Add(jobID)
 		s.log.Infof("deleted job %s", jobID)
+		s.recordJobRun(&nwpd.JobRunRecord{
+			Kind:    nwpd.JobRunKindCancelled,
+			JobID:   jobID,
+			SrcHost: s.nodeName,
+			Start:   timestamppb.Now(),
+			Reason:  reason,
+		})
 	}
 	return nil
 }
 
-func (s *server) GetObservations(_ context.Context, request *nwpd.GetObservationsRequest) (*nwpd.GetObservationsResponse, error) {
+// listOptionsOf converts the request to the options for listing the observations. The returned function reports
+// if the evaluation of the filter expression has exceeded the time budget.
+func listOptionsOf(request *nwpd.GetObservationsRequest) (nwpd.ListObservationsOptions, func() bool, error) {
 	options := nwpd.ListObservationsOptions{
-		Limit:           int(request.Limit),
-		FilterJobIDs:    request.RestrictToJobIDs,
-		FilterSrcHosts:  request.RestrictToSrcHosts,
-		FilterDestHosts: request.RestrictToDestHosts,
-		FailuresOnly:    request.FailuresOnly,
+		Limit:               int(request.Limit),
+		FilterJobIDs:        request.RestrictToJobIDs,
+		FilterSrcHosts:      request.RestrictToSrcHosts,
+		FilterDestHosts:     request.RestrictToDestHosts,
+		FilterLabels:        request.RestrictToLabels,
+		FilterResultFields:  request.RestrictToResultFields,
+		FilterJobIDRegex:    request.JobIDRegex,
+		FilterSrcHostRegex:  request.SrcHostRegex,
+		FilterDestHostRegex: request.DestHostRegex,
+		FilterResultRegex:   request.ResultPattern,
+		MinFailureStreak:    int(request.OnlyFailureStreaksOfAtLeast),
+		FailuresOnly:        request.FailuresOnly,
+		SortBy:              request.SortBy,
+		SortDescending:      request.SortDescending,
 	}
 	if request.Start != nil {
 		options.Start = request.Start.AsTime()
@@ -291,13 +1087,95 @@ func (s *server) GetObservations(_ context.Context, request *nwpd.GetObservation
 	if request.End != nil {
 		options.End = request.End.AsTime()
 	}
-	result, err := s.writer.ListObservations(options)
+	if request.MinDuration != nil {
+		options.MinDuration = request.MinDuration.AsDuration()
+	}
+	if request.PageToken != "" {
+		cursor, err := nwpd.ParsePageToken(request.PageToken)
+		if err != nil {
+			return options, nil, twirp.InvalidArgumentError("pageToken", err.Error())
+		}
+		options.After = cursor
+	}
+	var budgetExceeded bool
+	if request.Filter != "" {
+		expr, err := filter.Parse(request.Filter)
+		if err != nil {
+			return options, nil, twirp.InvalidArgumentError("filter", err.Error())
+		}
+		deadline := time.Now().Add(filterTimeBudget)
+		options.Filter = func(obs *nwpd.Observation) bool {
+			if budgetExceeded || time.Now().After(deadline) {
+				budgetExceeded = true
+				return false
+			}
+			return expr.Match(obs)
+		}
+	}
+	return options, func() bool { return budgetExceeded }, nil
+}
+
+// listError converts an error of listing the observations to a twirp error.
+func listError(err error, budgetExceeded func() bool) error {
+	if err != nil {
+		var filterErr *nwpd.InvalidFilterError
+		if errors.As(err, &filterErr) {
+			return twirp.InvalidArgumentError(filterErr.Field, filterErr.Err.Error())
+		}
+		return err
+	}
+	if budgetExceeded() {
+		return twirp.NewError(twirp.DeadlineExceeded, fmt.Sprintf("evaluation of filter exceeded time budget of %s", filterTimeBudget))
+	}
+	return nil
+}
+
+func (s *server) GetObservations(_ context.Context, request *nwpd.GetObservationsRequest) (*nwpd.GetObservationsResponse, error) {
+	options, budgetExceeded, err := listOptionsOf(request)
 	if err != nil {
 		return nil, err
 	}
-	return &nwpd.GetObservationsResponse{
+	limit := options.Limit
+	if limit <= 0 {
+		limit = defaultObservationsLimit
+	}
+	// one more to detect a further page
+	options.Limit = limit + 1
+	result, err := s.getWriter().ListObservations(options)
+	if err := listError(err, budgetExceeded); err != nil {
+		return nil, err
+	}
+	resp := &nwpd.GetObservationsResponse{
 		Observations: result,
-	}, nil
+	}
+	if len(result) > limit {
+		resp.Observations = result[:limit]
+		if options.IsDefaultOrder() {
+			resp.NextPageToken = nwpd.NextCursor(resp.Observations, options.After).Token()
+		}
+	}
+	return resp, nil
+}
+
+// StreamObservations sends the observations matching the request ordered by timestamp ascending one by one without
+// loading them into memory at once. In contrast to GetObservations, a limit of 0 means no limit. The iteration stops
+// on the first error returned by send or if the context is done.
+// As twirp does not support server streaming, it is served as newline-delimited JSON by the export endpoint.
+func (s *server) StreamObservations(ctx context.Context, request *nwpd.GetObservationsRequest, send func(obs *nwpd.Observation) error) error {
+	if (request.SortBy != "" && request.SortBy != nwpd.SortByTimestamp) || request.SortDescending {
+		return twirp.InvalidArgumentError("sortBy", "not supported for streamed observations")
+	}
+	options, budgetExceeded, err := listOptionsOf(request)
+	if err != nil {
+		return err
+	}
+	err = s.getWriter().IterateObservations(options, func(obs *nwpd.Observation) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+		return false, send(obs)
+	})
+	return listError(err, budgetExceeded)
 }
 
 type edge struct {
@@ -306,37 +1184,74 @@ type edge struct {
 }
 
 func (s *server) GetAggregatedObservations(ctx context.Context, request *nwpd.GetObservationsRequest) (*nwpd.GetAggregatedObservationsResponse, error) {
-	resp, err := s.GetObservations(ctx, request)
+	aggregateBy := request.AggregateBy
+	if aggregateBy == "" {
+		aggregateBy = nwpd.AggregateByHost
+	}
+	if !slices.Contains(nwpd.AggregateByFields, aggregateBy) {
+		return nil, twirp.InvalidArgumentError("aggregateBy", "must be one of "+strings.Join(nwpd.AggregateByFields, ", "))
+	}
+	byZone := aggregateBy == nwpd.AggregateByZone
+	if (request.SortBy != "" && request.SortBy != nwpd.SortByTimestamp) || request.SortDescending {
+		return nil, twirp.InvalidArgumentError("sortBy", "not supported for aggregated observations")
+	}
+	options, budgetExceeded, err := listOptionsOf(request)
 	if err != nil {
 		return nil, err
 	}
-	result := resp.Observations
-	if len(result) == 0 {
-		return &nwpd.GetAggregatedObservationsResponse{}, nil
+	if options.Limit <= 0 {
+		options.Limit = defaultObservationsLimit
 	}
-	rstart := result[0].Timestamp.AsTime()
 	rdelta := 1 * time.Minute
 	if request.AggregationWindow != nil && request.AggregationWindow.AsDuration().Milliseconds() > 30000 {
 		rdelta = request.AggregationWindow.AsDuration()
 	}
+	rend := time.Now()
+	if request.End != nil {
+		rend = request.End.AsTime()
+	}
+	var rstart, firstStart, currEnd time.Time
+	setStart := func(start time.Time) {
+		rstart = start
+		firstStart = start
+		currEnd = start.Add(rdelta)
+	}
 	if request.Start != nil {
-		rstart = request.Start.AsTime()
+		setStart(request.Start.AsTime())
 	}
-	currEnd := rstart.Add(rdelta)
 	var aggregated []*nwpd.AggregatedObservation
 	currAggr := map[edge]*nwpd.AggregatedObservation{}
+	currLatency := map[edge]map[string]*db.LatencyHistogram{}
 	addAggregations := func() {
-		for _, aggr := range currAggr {
+		for e, aggr := range currAggr {
 			for k, c := range aggr.JobsOkCount {
 				if dur := aggr.MeanOkDuration[k]; dur != nil {
 					aggr.MeanOkDuration[k] = durationpb.New(dur.AsDuration() / time.Duration(c))
 				}
 			}
+			for k, h := range currLatency[e] {
+				if aggr.P50OkDuration == nil {
+					aggr.P50OkDuration = map[string]*durationpb.Duration{}
+					aggr.P95OkDuration = map[string]*durationpb.Duration{}
+					aggr.P99OkDuration = map[string]*durationpb.Duration{}
+				}
+				p := h.Percentiles()
+				aggr.P50OkDuration[k] = durationpb.New(p.P50)
+				aggr.P95OkDuration[k] = durationpb.New(p.P95)
+				aggr.P99OkDuration[k] = durationpb.New(p.P99)
+			}
 			aggregated = append(aggregated, aggr)
 		}
 		currAggr = map[edge]*nwpd.AggregatedObservation{}
+		currLatency = map[edge]map[string]*db.LatencyHistogram{}
 	}
-	for _, obs := range result {
+	// the observations are aggregated while iterating, so that only the aggregations are kept in memory
+	count := 0
+	err = s.getWriter().IterateObservations(options, func(obs *nwpd.Observation) (bool, error) {
+		if count == 0 && request.Start == nil {
+			setStart(obs.Timestamp.AsTime())
+		}
+		count++
 		for !obs.Timestamp.AsTime().Before(currEnd) {
 			rstart = currEnd
 			currEnd = rstart.Add(rdelta)
@@ -344,17 +1259,37 @@ func (s *server) GetAggregatedObservations(ctx context.Context, request *nwpd.Ge
 		}
 
 		edge := edge{src: obs.SrcHost, dest: obs.DestHost}
+		switch aggregateBy {
+		case nwpd.AggregateByZone:
+			if obs.DestZone == "" {
+				// destination is not a node or observation stored by an older version
+				return false, nil
+			}
+			edge.src, edge.dest = obs.SrcZone, obs.DestZone
+		case nwpd.AggregateByJob:
+			// the counts are kept per job anyway
+			edge.src, edge.dest = nwpd.AggregateAll, nwpd.AggregateAll
+		case nwpd.AggregateBySrcHost:
+			edge.dest = nwpd.AggregateAll
+		case nwpd.AggregateByDestHost:
+			edge.src = nwpd.AggregateAll
+		}
 		aggr := currAggr[edge]
 		if aggr == nil {
 			aggr = &nwpd.AggregatedObservation{
-				SrcHost:        obs.SrcHost,
-				DestHost:       obs.DestHost,
 				PeriodStart:    timestamppb.New(rstart),
 				PeriodEnd:      timestamppb.New(currEnd),
 				JobsOkCount:    map[string]int32{},
 				JobsNotOkCount: map[string]int32{},
 				MeanOkDuration: map[string]*durationpb.Duration{},
 			}
+			if byZone {
+				aggr.SrcZone = edge.src
+				aggr.DestZone = edge.dest
+			} else {
+				aggr.SrcHost = edge.src
+				aggr.DestHost = edge.dest
+			}
 			currAggr[edge] = aggr
 		}
 		if obs.Ok {
@@ -366,149 +1301,650 @@ func (s *server) GetAggregatedObservations(ctx context.Context, request *nwpd.Ge
 				}
 				dur += obs.Duration.AsDuration()
 				aggr.MeanOkDuration[obs.JobID] = durationpb.New(dur)
+				if currLatency[edge] == nil {
+					currLatency[edge] = map[string]*db.LatencyHistogram{}
+				}
+				h := currLatency[edge][obs.JobID]
+				if h == nil {
+					h = &db.LatencyHistogram{}
+					currLatency[edge][obs.JobID] = h
+				}
+				h.Add(obs.Duration.AsDuration())
+			}
+		} else if obs.StaleEndpoint {
+			if aggr.JobsStaleCount == nil {
+				aggr.JobsStaleCount = map[string]int32{}
 			}
+			aggr.JobsStaleCount[obs.JobID]++
 		} else {
 			aggr.JobsNotOkCount[obs.JobID]++
 		}
+		return false, nil
+	})
+	if err := listError(err, budgetExceeded); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		if !request.IncludeNoDataEdges {
+			return &nwpd.GetAggregatedObservationsResponse{}, nil
+		}
+		if request.Start == nil {
+			setStart(rend.Add(-rdelta))
+		}
 	}
 	addAggregations()
 
+	if request.IncludeNoDataEdges && !request.FailuresOnly && aggregateBy == nwpd.AggregateByHost && s.aggregator != nil {
+		aggregated = addNoDataEdges(aggregated, s.aggregator.GetValidEdges(), request, options.Limit, firstStart, rend, rdelta)
+	}
+
 	return &nwpd.GetAggregatedObservationsResponse{
 		AggregatedObservations: aggregated,
 	}, nil
 }
 
+// addNoDataEdges adds an aggregated observation flagged with `noData` for each valid edge and each aggregation window
+// without observations. The total number of items is restricted by the limit, i.e. the defaulted limit of the request.
+func addNoDataEdges(aggregated []*nwpd.AggregatedObservation, validEdges []aggregation.ValidEdge, request *nwpd.GetObservationsRequest,
+	limit int, start, end time.Time, delta time.Duration,
+) []*nwpd.AggregatedObservation {
+	type windowEdge struct {
+		start time.Time
+		edge  edge
+	}
+	srcHosts := common.StringSet{}
+	srcHosts.AddAll(request.RestrictToSrcHosts...)
+	destHosts := common.StringSet{}
+	destHosts.AddAll(request.RestrictToDestHosts...)
+	// the regular expressions have already been validated by GetObservations
+	srcHostRegex, err := regexp.Compile(request.SrcHostRegex)
+	if err != nil {
+		return aggregated
+	}
+	destHostRegex, err := regexp.Compile(request.DestHostRegex)
+	if err != nil {
+		return aggregated
+	}
+	var edges []aggregation.ValidEdge
+	for _, e := range validEdges {
+		if (srcHosts.Len() == 0 || srcHosts.Contains(e.SrcHost)) && (destHosts.Len() == 0 || destHosts.Contains(e.DestHost)) &&
+			srcHostRegex.MatchString(e.SrcHost) && destHostRegex.MatchString(e.DestHost) {
+			edges = append(edges, e)
+		}
+	}
+	if len(edges) == 0 {
+		return aggregated
+	}
+
+	present := map[windowEdge]struct{}{}
+	for _, aggr := range aggregated {
+		present[windowEdge{start: aggr.PeriodStart.AsTime(), edge: edge{src: aggr.SrcHost, dest: aggr.DestHost}}] = struct{}{}
+	}
+	count := len(aggregated)
+	for wstart := start; wstart.Before(end) && count < limit; wstart = wstart.Add(delta) {
+		wend := wstart.Add(delta)
+		for _, e := range edges {
+			if _, ok := present[windowEdge{start: wstart, edge: edge{src: e.SrcHost, dest: e.DestHost}}]; ok {
+				continue
+			}
+			if count >= limit {
+				break
+			}
+			aggregated = append(aggregated, &nwpd.AggregatedObservation{
+				SrcHost:          e.SrcHost,
+				DestHost:         e.DestHost,
+				PeriodStart:      timestamppb.New(wstart),
+				PeriodEnd:        timestamppb.New(wend),
+				NoData:           true,
+				NotValidInPeriod: e.ValidSince.After(wend),
+			})
+			count++
+		}
+	}
+
+	sort.SliceStable(aggregated, func(i, j int) bool {
+		return aggregated[i].PeriodStart.AsTime().Before(aggregated[j].PeriodStart.AsTime())
+	})
+	return aggregated
+}
+
+func (s *server) GetDailyRollups(_ context.Context, request *nwpd.GetDailyRollupsRequest) (*nwpd.GetDailyRollupsResponse, error) {
+	store := s.getRollups()
+	if store == nil {
+		return nil, fmt.Errorf("daily rollups not available without output directory")
+	}
+	end := time.Now()
+	if request.End != nil {
+		end = request.End.AsTime()
+	}
+	start := end.AddDate(0, 0, -30)
+	if request.Start != nil {
+		start = request.Start.AsTime()
+	}
+	rollups, err := store.ListDailyRollups(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &nwpd.GetDailyRollupsResponse{Rollups: rollups}, nil
+}
+
+func (s *server) ListIncidents(_ context.Context, request *nwpd.ListIncidentsRequest) (*nwpd.ListIncidentsResponse, error) {
+	if s.aggregator == nil {
+		return nil, fmt.Errorf("incidents not available without aggregator")
+	}
+	var incidents []*nwpd.Incident
+	for _, inc := range s.aggregator.ListIncidents() {
+		if db.MatchIncident(request, inc) {
+			incidents = append(incidents, inc)
+		}
+	}
+	return &nwpd.ListIncidentsResponse{Incidents: incidents}, nil
+}
+
+func (s *server) GetSummary(_ context.Context, request *nwpd.GetSummaryRequest) (*nwpd.GetSummaryResponse, error) {
+	if s.aggregator == nil {
+		return nil, fmt.Errorf("summary not available without aggregator")
+	}
+	if request.Limit < 0 {
+		return nil, twirp.InvalidArgumentError("limit", "must be >= 0")
+	}
+	summary := s.aggregator.GetFailingEdgesSummary()
+	resp := &nwpd.GetSummaryResponse{MinChecks: int32(summary.MinChecks)} // #nosec G115 -- small configuration value
+	if !summary.PeriodEnd.IsZero() {
+		resp.PeriodStart = timestamppb.New(summary.PeriodStart)
+		resp.PeriodEnd = timestamppb.New(summary.PeriodEnd)
+	}
+	for _, e := range summary.Edges {
+		if request.Limit > 0 && len(resp.Edges) >= int(request.Limit) {
+			break
+		}
+		resp.Edges = append(resp.Edges, &nwpd.FailingEdge{
+			SrcHost:      e.SrcHost,
+			DestHost:     e.DestHost,
+			Checks:       int32(e.Checks),   // #nosec G115 -- checks of a report period
+			Failures:     int32(e.Failures), // #nosec G115 -- checks of a report period
+			FailureRatio: e.FailureRatio(),
+			JobIDs:       e.JobIDs,
+			Ongoing:      e.Ongoing,
+		})
+	}
+	return resp, nil
+}
+
+func (s *server) updateRollups() {
+	store := s.getRollups()
+	if store == nil {
+		return
+	}
+	s.reloadLock.Lock()
+	clusterCfg := s.currentClusterConfig
+	s.reloadLock.Unlock()
+	if err := store.Update(time.Now(), newDestClassifier(clusterCfg)); err != nil {
+		s.log.Warnf("updating daily rollups failed: %s", err)
+	}
+}
+
+// newDestClassifier classifies destination hosts by the cluster config.
+func newDestClassifier(clusterCfg *config.ClusterConfig) db.DestClassifier {
+	nodes := common.StringSet{}
+	apiServers := common.StringSet{}
+	services := common.StringSet{}
+	if clusterCfg != nil {
+		for _, n := range clusterCfg.Nodes {
+			nodes.Add(n.Hostname)
+		}
+		for _, ep := range []*config.Endpoint{clusterCfg.InternalKubeAPIServer, clusterCfg.KubeAPIServer} {
+			if ep != nil {
+				apiServers.Add(ep.Hostname)
+			}
+		}
+		for _, se := range clusterCfg.Services {
+			services.Add(se.DestHost())
+		}
+	}
+	return func(destHost string) string {
+		switch {
+		case nodes.Contains(destHost):
+			return "node"
+		case apiServers.Contains(destHost):
+			return "kube-apiserver"
+		case services.Contains(destHost):
+			return "service"
+		default:
+			return "external"
+		}
+	}
+}
+
 func (s *server) stop() {
-	if s.writer != nil {
-		s.writer.Stop()
-		s.writer = nil
+	// the live observation feeds are ended first, as the shutdown waits for the active requests
+	s.watchers.close()
+	s.shutdownHTTPServer()
+	s.stopWriter()
+	if s.packetTrains != nil {
+		_ = s.packetTrains.configure(0, nil)
+	}
+	s.applyTracing(nil)
+	s.stopRemoteSink()
+	s.shared.SetRunRecorder(nil)
+}
+
+// serveHTTP starts the http server on the address of the network configuration, or restarts it if the address has changed.
+// If the server cannot listen on the address, it is retried with exponential backoff. A running server is kept until the
+// server on the new address has been started, unless it blocks the new address.
+func (s *server) serveHTTP(now time.Time) {
+	address, err := httpAddressOf(s.getNetworkCfgOf(s.getAgentConfig()))
+	if err != nil {
+		// rejected on applying the configuration
+		return
+	}
+	if address == s.httpAddress && (s.httpServer != nil || address == "") {
+		return
+	}
+	if address == "" {
+		s.log.Infof("http server disabled")
+		s.shutdownHTTPServer()
+		s.httpAddress = ""
+		s.httpListenError.Store(nil)
+		return
+	}
+	if now.Before(s.httpRetryAt) {
+		return
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil && s.httpServer != nil {
+		// the new address may differ only by the bind address
+		s.log.Infof("stopping http server on %s to listen on %s", s.httpAddress, address)
+		s.shutdownHTTPServer()
+		listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		s.httpBackoff = min(max(2*s.httpBackoff, httpRetryMinBackoff), httpRetryMaxBackoff)
+		s.httpRetryAt = now.Add(s.httpBackoff)
+		msg := fmt.Sprintf("cannot listen on %s: %s", address, err)
+		s.httpListenError.Store(&msg)
+		HTTPListenFailures.Inc()
+		s.log.Errorf("%s, retrying in %s", msg, s.httpBackoff)
+		return
+	}
+	s.httpBackoff = 0
+	s.httpRetryAt = time.Time{}
+	s.httpListenError.Store(nil)
+	if s.httpServer != nil {
+		s.log.Infof("http server address changed from %s to %s", s.httpAddress, address)
+		s.shutdownHTTPServer()
+	}
+	server := &http.Server{
+		Addr:    address,
+		Handler: s.newServeMux(address),
+		// Set timeouts to avoid Slowloris attacks and other issues
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  15 * time.Second,
+	}
+	s.httpServer = server
+	s.httpAddress = address
+	go func() {
+		// plaintext and TLS connections are accepted on the same port
+		err := server.Serve(newSniffingListener(listener, s.agentServiceTLSConfig))
+		if errors.Is(err, http.ErrServerClosed) {
+			s.log.Infof("http server on %s stopped", address)
+			return
+		}
+		s.log.Errorf("http server on %s stopped: %s", address, err)
+	}()
+}
+
+// shutdownHTTPServer stops the http server gracefully, so that its port is released.
+func (s *server) shutdownHTTPServer() {
+	if s.httpServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.log.Warnf("shutdown of http server failed: %s", err)
+		_ = s.httpServer.Close()
 	}
+	s.httpServer = nil
 }
 
 func (s *server) reloadConfig() {
 	s.reloadLock.Lock()
 	defer s.reloadLock.Unlock()
 
-	agentConfig, err := config.LoadAgentConfig(s.agentConfigFile)
+	err := s.reloadConfigFiles()
+	s.configStatus.reloaded(time.Now(), err)
 	if err != nil {
-		s.log.Warnf("cannot load agent configuration from %s", s.agentConfigFile)
+		s.log.Warn(err)
+		ConfigReloads.WithLabelValues(reloadResultFailure).Inc()
+		s.reloadFailures.Add(1)
 		return
 	}
+	ConfigReloads.WithLabelValues(reloadResultSuccess).Inc()
+	ConfigLastReload.SetToCurrentTime()
+	s.reloadFailures.Store(0)
+}
+
+// reloadConfigFiles loads the configuration files and applies them if they have changed.
+func (s *server) reloadConfigFiles() error {
+	s.recordConfigFilesHash()
+	agentConfig, err := config.LoadAgentConfig(s.agentConfigFile)
+	if err != nil {
+		return fmt.Errorf("cannot load agent configuration from %s: %w", s.agentConfigFile, err)
+	}
 	clusterConfig, err := config.LoadClusterConfig(s.clusterConfigFile)
 	if err != nil {
-		s.log.Warnf("cannot load cluster configuration from %s", s.clusterConfigFile)
+		return fmt.Errorf("cannot load cluster configuration from %s: %w", s.clusterConfigFile, err)
+	}
+	// re-resolve the referenced secrets, so that a reload picks up changed secrets
+	secretsChanged := s.secrets.refresh(time.Now())
+	changed := secretsChanged || !reflect.DeepEqual(clusterConfig, s.currentClusterConfig) || !reflect.DeepEqual(agentConfig, s.getAgentConfig())
+	if !changed {
+		s.log.Debug("no reload needed")
+		return nil
+	}
+	s.log.Infof("reloaded configuration from %s and %s", s.agentConfigFile, s.clusterConfigFile)
+	oldClusterConfig := s.currentClusterConfig
+	s.currentClusterConfig = clusterConfig
+	if err := s.applyAgentConfig(agentConfig); err != nil {
+		// the jobs are still parsed with the previous cluster configuration
+		s.currentClusterConfig = oldClusterConfig
+		return fmt.Errorf("cannot apply new agent configuration from %s: %w", s.agentConfigFile, err)
+	}
+	s.log.Infof("configuration applied")
+	return nil
+}
+
+// refreshSecretsIfDue re-resolves the referenced secrets after the refresh period and re-applies the configuration
+// if any of them has changed.
+func (s *server) refreshSecretsIfDue(now time.Time) {
+	if s.secrets == nil || !s.secretRefreshActive.CompareAndSwap(false, true) {
 		return
 	}
-	changed := !reflect.DeepEqual(clusterConfig, s.currentClusterConfig) || !reflect.DeepEqual(agentConfig, s.currentAgentConfig)
-	if changed {
-		s.log.Infof("reloaded configuration from %s and %s", s.agentConfigFile, s.clusterConfigFile)
-		s.currentClusterConfig = clusterConfig
-		err = s.applyAgentConfig(agentConfig)
-		if err != nil {
-			s.log.Warnf("cannot apply new agent configuration from %s", s.agentConfigFile)
+	go func() {
+		defer s.secretRefreshActive.Store(false)
+		if !s.secrets.refreshIfDue(now) {
 			return
 		}
-		s.log.Infof("configuration applied")
-	} else {
-		s.log.Debug("no reload needed")
+		s.reloadLock.Lock()
+		defer s.reloadLock.Unlock()
+		s.log.Infof("referenced secrets changed, re-applying configuration")
+		if err := s.applyAgentConfig(s.getAgentConfig()); err != nil {
+			s.log.Warnf("cannot re-apply agent configuration: %s", err)
+		}
+	}()
+}
+
+// handlePodIdentity echoes the pod UID, so that peers can detect stale pod endpoints.
+func (s *server) handlePodIdentity(w http.ResponseWriter, _ *http.Request) {
+	if s.podUID != "" {
+		w.Header().Set(common.HeaderPodUID, s.podUID)
 	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *server) run() {
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+// newServeMux registers the handlers of the http server on a new mux, so that they are scoped to this server instance.
+func (s *server) newServeMux(httpAddress string) *http.ServeMux {
+	mux := http.NewServeMux()
+	port := s.getNetworkCfgOf(s.getAgentConfig()).HTTPPort
+	s.log.Infof("provide metrics at '%s/metrics'", httpAddress)
+	mux.Handle("/metrics", promhttp.Handler())
 
-	ticker := time.NewTicker(s.tickPeriod)
+	twirpServer := nwpd.NewAgentServiceServer(s)
+	s.log.Infof("provide agent service at '%s%s'", httpAddress, twirpServer.PathPrefix())
+	if s.serviceTLS.Load() == nil {
+		s.log.Warnf("agent service is served without TLS, anyone with network access to port %d can read the observations (see agentServiceTLS)", port)
+	}
+	// peers, probes, and health checks are served without TLS and token
+	agentService := func(handler http.Handler) http.Handler {
+		return s.requireAgentServiceTLS(s.requireAgentServiceToken(handler))
+	}
+	mux.Handle(twirpServer.PathPrefix(), agentService(twirpServer))
+	mux.HandleFunc(common.PathPodIdentity, s.handlePodIdentity)
+	mux.Handle(common.PathExportObservations, agentService(http.HandlerFunc(s.handleExportObservations)))
+	mux.Handle(common.PathWatchObservations, agentService(http.HandlerFunc(s.handleWatchObservations)))
+	mux.Handle(common.PathJobs, agentService(http.HandlerFunc(s.handleJobs)))
+	mux.Handle(common.PathStatus, agentService(http.HandlerFunc(s.handleStatus)))
+	mux.HandleFunc(common.PathHeartbeat, s.heartbeats.handleHeartbeat)
+	mux.HandleFunc(common.PathPacketTrain, s.packetTrains.handleReport)
+	mux.HandleFunc(common.PathHealthz, s.handleHealthz)
+	mux.HandleFunc(common.PathReadyz, s.handleReadyz)
+	return mux
+}
 
-	if port := s.getNetworkCfg().HTTPPort; port != 0 {
-		s.log.Infof("provide metrics at ':%d/metrics'", port)
-		http.Handle("/metrics", promhttp.Handler())
+// run serves the http server and processes the observations and configuration changes until the server is stopped.
+func (s *server) run() error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
 
-		twirpServer := nwpd.NewAgentServiceServer(s)
-		s.log.Infof("provide agent service at ':%d%s'", port, twirpServer.PathPrefix())
-		http.Handle(twirpServer.PathPrefix(), twirpServer)
+	tickPeriod := s.getTiming().tickPeriod
+	ticker := time.NewTicker(tickPeriod)
+	s.lastTick.Store(time.Now().UnixNano())
 
-		go func() {
-			server := &http.Server{
-				Addr:    fmt.Sprintf(":%d", port),
-				Handler: http.DefaultServeMux,
-				// Set timeouts to avoid Slowloris attacks and other issues
-				ReadTimeout:  10 * time.Second,
-				WriteTimeout: 10 * time.Second,
-				IdleTimeout:  15 * time.Second,
-			}
-			err := server.ListenAndServe()
-			s.log.Warnf(err.Error())
-		}()
-	}
-	if s.writer != nil {
-		go s.writer.Run()
-	}
-	watcher, err := fsnotify.NewWatcher()
+	s.serveHTTP(time.Now())
+	s.startWriter()
+	rollupTicker := time.NewTicker(1 * time.Hour)
+	defer rollupTicker.Stop()
+	go s.updateRollups()
+	configWatcher, err := newConfigWatcher(s.log, s.agentConfigFile, s.clusterConfigFile)
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := watcher.Add(path.Dir(s.agentConfigFile)); err != nil {
-		_ = watcher.Close()
-		log.Fatal(err)
-	}
-	if err := watcher.Add(path.Dir(s.clusterConfigFile)); err != nil {
-		_ = watcher.Close()
-		log.Fatal(err)
-	}
-	defer watcher.Close()
+	defer configWatcher.close()
+	watcher := configWatcher.watcher
+	watchedDirs := map[string]bool{}
+	s.watchAgentServiceTLSFiles(watcher, watchedDirs)
 
 	for {
 		select {
 		case <-s.done:
 			ticker.Stop()
-			s.stop()
-			return
-		case <-interrupt:
+			s.shutdown(drainTimeout)
+			return nil
+		case sig := <-interrupt:
+			s.log.Infof("received %s, shutting down", sig)
 			ticker.Stop()
-			s.stop()
-			return
+			s.shutdown(drainTimeout)
+			return nil
 		case obs := <-s.obsChan:
-			logObservation := s.currentAgentConfig.LogObservations
-			if logObservation {
-				fields := logrus.Fields{
-					"src":   obs.SrcHost,
-					"dest":  obs.DestHost,
-					"ok":    obs.Ok,
-					"jobid": obs.JobID,
-					"time":  obs.Timestamp.AsTime(),
-				}
-				s.log.WithFields(fields).Info(obs.Result)
-			}
-			IncAggregatedObservation(obs.SrcHost, obs.DestHost, obs.JobID, obs.Ok)
-			if obs.Ok && obs.Duration != nil {
-				ReportAggregatedObservationLatency(obs.SrcHost, obs.DestHost, obs.JobID, obs.Duration.AsDuration().Seconds())
-			}
-			if s.writer != nil {
-				s.writer.Add(obs)
-			}
-			if s.aggregator != nil {
-				s.aggregator.Add(obs)
-			}
+			s.processObservation(obs)
+			ObservationQueueLength.Set(float64(len(s.obsChan)))
+		case record := <-s.runChan:
+			s.processJobRun(record)
 		case err := <-watcher.Errors:
 			s.log.Warning("watcher failed: %s", err)
 			s.stop()
-			return
-		case <-watcher.Events:
-			s.log.Debug("watch")
-			go s.reloadConfig()
+			return nil
+		case event := <-watcher.Events:
+			s.log.Debugf("watch %s", event)
+			if configWatcher.handle(event) {
+				s.scheduleReload()
+			}
+			s.reloadAgentServiceTLSIfChanged()
 		case <-ticker.C:
+			s.lastTick.Store(time.Now().UnixNano())
+			ObservationQueueLength.Set(float64(len(s.obsChan)))
+			if t := s.getTiming().tickPeriod; t != tickPeriod {
+				tickPeriod = t
+				ticker.Reset(tickPeriod)
+			}
 			s.triggerJobs()
+			s.sendHeartbeatIfDue(time.Now())
+			s.sendRemoteWriteIfDue(time.Now())
+			s.sendTracesIfDue(time.Now())
+			s.refreshSecretsIfDue(time.Now())
+			s.serveHTTP(time.Now())
+			s.recheckConfigIfDue(time.Now())
+			configWatcher.follow()
+			s.watchAgentServiceTLSFiles(watcher, watchedDirs)
+			s.gaps.classifyIfDue(time.Now())
+			s.diagnoseLocalBlockIfDue(time.Now())
+		case <-rollupTicker.C:
+			go s.updateRollups()
 		}
 	}
 }
 
-func (s *server) triggerJobs() {
+// processObservation updates the metrics and forwards the observation to the aggregator, the remote sink, and the writer.
+func (s *server) processObservation(obs *nwpd.Observation) {
+	if s.isDeletedJob(obs.JobID) {
+		s.log.Debugf("dropped observation of deleted job %s", obs.JobID)
+		return
+	}
+	obs.Result = s.secrets.redact(obs.Result)
+	if cfg := s.getAgentConfig(); cfg != nil && cfg.LogObservations {
+		fields := logrus.Fields{
+			"src":   obs.SrcHost,
+			"dest":  obs.DestHost,
+			"ok":    obs.Ok,
+			"jobid": obs.JobID,
+			"time":  obs.Timestamp.AsTime(),
+		}
+		s.log.WithFields(fields).Info(obs.Result)
+	}
+	if obs.Ok {
+		s.okObservations.Add(1)
+	} else {
+		s.failedObservations.Add(1)
+	}
+	IncAggregatedObservation(obs.SrcHost, obs.DestHost, obs.JobID, obs.Labels, observationStatus(obs))
+	if category := obs.ResultFields[runners.ResultFieldFailureCategory]; !obs.Ok && category != "" {
+		FailedObservationsByCategory.WithLabelValues(obs.JobID, category).Inc()
+	}
+	if obs.Ok && obs.Duration != nil {
+		ReportAggregatedObservationLatency(obs.SrcHost, obs.DestHost, obs.JobID, obs.Labels, obs.Duration.AsDuration().Seconds())
+	}
+	// the aggregator is called first, as it attaches the incident ID to the observation
+	if s.aggregator != nil {
+		s.aggregator.Add(obs)
+	}
+	if t := s.tracer.Load(); t != nil {
+		t.finishSpan(obs)
+	}
+	s.gaps.observe(obs)
+	s.localBlock.observe(obs)
+	if sink := s.sink.Load(); sink != nil {
+		sink.add(obs)
+	}
+	// the lock is held while adding, so that a replaced writer is not stopped before it has taken the observation
+	s.writerLock.RLock()
+	if s.writer != nil {
+		s.writer.Add(obs)
+	}
+	s.writerLock.RUnlock()
+	s.watchers.publish(obs)
+}
+
+// isDeletedJob returns true if the observations with the job ID belong to a deleted job.
+func (s *server) isDeletedJob(jobID string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.deletedJobs) == 0 {
+		return false
+	}
+	id, _ := nwpd.SplitMeshJobID(jobID)
+	return s.deletedJobs.Contains(id)
+}
+
+// recordJobRun buffers a job run record for the gap detection and the writer.
+// It is called by the jobs at the end of each run and must not block.
+func (s *server) recordJobRun(record *nwpd.JobRunRecord) {
+	select {
+	case s.runChan <- record:
+	default:
+		s.log.Debugf("dropped %s record of job %s: buffer full", record.Kind, record.JobID)
+	}
+}
+
+// processJobRun forwards the job run record to the gap detection and the writer.
+func (s *server) processJobRun(record *nwpd.JobRunRecord) {
+	s.gaps.addJobRun(record)
+	s.writerLock.RLock()
+	if s.writer != nil {
+		s.writer.AddJobRun(record)
+	}
+	s.writerLock.RUnlock()
+}
+
+// shutdown drains the observations, cancels the runs still in progress after the timeout, and writes the final
+// aggregation report before the writer is stopped.
+func (s *server) shutdown(timeout time.Duration) {
+	s.drain(timeout)
+	s.cancelJobs()
+	if s.aggregator != nil {
+		s.aggregator.Flush()
+	}
+	s.stop()
+}
+
+// cancelJobs cancels all jobs, so that the runs still in progress send no further observations.
+func (s *server) cancelJobs() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, job := range s.jobs {
+		job.Cancel()
+	}
+}
+
+// drain processes the observations of the buffer and of the runs still in progress on shutdown.
+// It returns when the buffer is empty and no job is running, or after the timeout.
+func (s *server) drain(timeout time.Duration) int {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(drainPollPeriod)
+	defer poll.Stop()
+
+	count := 0
+	for {
+		select {
+		case obs := <-s.obsChan:
+			s.processObservation(obs)
+			count++
+		case record := <-s.runChan:
+			s.processJobRun(record)
+		case <-poll.C:
+			if len(s.obsChan) == 0 && len(s.runChan) == 0 && !s.jobsRunning() {
+				s.log.Infof("drained %d observations on shutdown", count)
+				return count
+			}
+		case <-deadline.C:
+			s.log.Warnf("drain timeout after %d observations, %d observations dropped", count, len(s.obsChan))
+			return count
+		}
+	}
+}
+
+// jobsRunning returns true if a run is in progress, the runs of cancelled jobs are ignored as their observations are dropped.
+func (s *server) jobsRunning() bool {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	for _, job := range s.jobs {
+		if job.Running() && !job.Cancelled() {
+			return true
+		}
+	}
+	return false
+}
 
+func (s *server) triggerJobs() {
+	// the lock is only held for taking a snapshot of the jobs, so that config reloads are not blocked
+	s.lock.Lock()
+	jobs := make([]*runners.InternalJob, 0, len(s.jobs))
 	for _, job := range s.jobs {
-		if err := job.Tick(s.nodeName, s.obsChan); err != nil {
+		jobs = append(jobs, job)
+	}
+	limiter := s.limiter
+	s.lock.Unlock()
+
+	// start the jobs in order of their due time, so that a job delayed by the concurrency limit
+	// is started before jobs becoming due later.
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].NextRun().Before(jobs[j].NextRun())
+	})
+	for _, job := range jobs {
+		if err := job.Tick(s.nodeName, s.obsChan, limiter); err != nil {
 			s.log.Debug(err)
 		}
 	}
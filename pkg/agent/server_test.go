@@ -0,0 +1,1371 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/aggregation"
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+// blockingRunner reports its start and blocks until released.
+type blockingRunner struct {
+	config  runners.RunnerConfig
+	started chan<- string
+	release chan struct{}
+}
+
+var _ runners.Runner = &blockingRunner{}
+
+func (r *blockingRunner) Run(_ string, _ chan<- *nwpd.Observation) {
+	r.started <- r.config.JobID
+	<-r.release
+}
+
+func (r *blockingRunner) Config() runners.RunnerConfig { return r.config }
+func (r *blockingRunner) Description() string          { return "" }
+func (r *blockingRunner) TestData() any                { return nil }
+func (r *blockingRunner) DestHosts() []string          { return nil }
+
+// delayedRunner reports a single observation after being released.
+type delayedRunner struct {
+	blockingRunner
+}
+
+func (r *delayedRunner) Run(nodeName string, ch chan<- *nwpd.Observation) {
+	r.blockingRunner.Run(nodeName, ch)
+	ch <- &nwpd.Observation{JobID: r.config.JobID, SrcHost: nodeName, DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true}
+}
+
+// panickingRunner panics on each run.
+type panickingRunner struct {
+	blockingRunner
+}
+
+func (r *panickingRunner) Run(_ string, _ chan<- *nwpd.Observation) {
+	panic("malformed URL")
+}
+
+// recordingAggregator records the aggregation settings changed at runtime and the added observations.
+type recordingAggregator struct {
+	aggregation.ObservationListenerExtended
+	reportPeriod time.Duration
+	timeWindow   time.Duration
+	resultFields []string
+	format       string
+	logJSON      bool
+	topEdges     int
+	incidentFile string
+	logDirectory string
+	reportWriter io.Writer
+	added        int
+	flushedAt    int
+}
+
+func (a *recordingAggregator) SetReportResultFields(names []string)       { a.resultFields = names }
+func (a *recordingAggregator) SetIncidentThresholds(_, _ int)             {}
+func (a *recordingAggregator) SetTopFailingEdges(k, _ int)                { a.topEdges = k }
+func (a *recordingAggregator) SetReportRotation(_ time.Duration, _ int64) {}
+func (a *recordingAggregator) SetIncidentFile(filename string)            { a.incidentFile = filename }
+func (a *recordingAggregator) UpdateValidEdges(_ aggregation.ValidEdges)  {}
+func (a *recordingAggregator) Add(_ *nwpd.Observation)                    { a.added++ }
+func (a *recordingAggregator) Flush()                                     { a.flushedAt = a.added }
+
+func (a *recordingAggregator) Reconfigure(reportPeriod, timeWindow time.Duration) {
+	a.reportPeriod, a.timeWindow = reportPeriod, timeWindow
+}
+
+func (a *recordingAggregator) SetReportFormat(format string, logJSON bool) {
+	a.format, a.logJSON = format, logJSON
+}
+
+func (a *recordingAggregator) SetReportOutput(logDirectory string, w io.Writer) {
+	a.logDirectory, a.reportWriter = logDirectory, w
+}
+
+// stalledWriter simulates a writer which does not take over its buffered records until released.
+type stalledWriter struct {
+	nwpd.ObservationWriter
+	release chan struct{}
+}
+
+func (w *stalledWriter) Run() {
+	<-w.release
+	w.ObservationWriter.Run()
+}
+
+var _ = Describe("server", func() {
+	It("echoes the pod UID", func() {
+		s := &server{podUID: "uid-1"}
+		rec := httptest.NewRecorder()
+		s.handlePodIdentity(rec, httptest.NewRequest(http.MethodGet, common.PathPodIdentity, nil))
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(rec.Header().Get(common.HeaderPodUID)).To(Equal("uid-1"))
+	})
+
+	Describe("job phase", func() {
+		var (
+			period     = 10 * time.Second
+			clusterCfg = config.ClusterConfig{}
+		)
+
+		newTestServer := func(nodeName string, networkCfg *config.NetworkConfig) *server {
+			return &server{
+				log:                logrus.NewEntry(logrus.StandardLogger()),
+				nodeName:           nodeName,
+				jobs:               map[jobid]*runners.InternalJob{},
+				currentAgentConfig: &config.AgentConfig{HostNetwork: networkCfg, PodNetwork: networkCfg},
+			}
+		}
+
+		phaseOf := func(s *server) time.Duration {
+			rconfig := runners.RunnerConfig{Job: config.Job{JobID: "job1"}, Period: period}
+			job, err := runners.Parse(clusterCfg, rconfig, []string{"nslookup", "--names", "foo.bar"}, &config.SampleConfig{})
+			Expect(err).To(BeNil())
+			s.addOrReplaceJob(job)
+			return time.Duration(s.jobs["job1"].GetLastRun().UnixNano() % int64(period))
+		}
+
+		It("differs for different node names", func() {
+			networkCfg := &config.NetworkConfig{}
+			phase1 := phaseOf(newTestServer("node-a", networkCfg))
+			phase2 := phaseOf(newTestServer("node-b", networkCfg))
+			Expect(phase1).NotTo(Equal(phase2))
+			Expect(phaseOf(newTestServer("node-a", networkCfg))).To(Equal(phase1))
+		})
+
+		It("is the same for all nodes if spreading by node is disabled", func() {
+			networkCfg := &config.NetworkConfig{SpreadByNode: ptr.To(false)}
+			phase1 := phaseOf(newTestServer("node-a", networkCfg))
+			phase2 := phaseOf(newTestServer("node-b", networkCfg))
+			Expect(phase1).To(Equal(phase2))
+		})
+
+		It("skips malformed jobs and applies the others on start", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			err := s.applyAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{
+				Jobs: []config.Job{
+					{JobID: "bad1", Args: []string{"checkTCPPort", "--endpoints", "server:10.0.0.9:-1"}},
+					{JobID: "bad2", Args: []string{"--period", "1s"}},
+					{JobID: "bad3"},
+					{JobID: "good", Args: []string{"nslookup", "--names", "foo.bar"}},
+				},
+			}})
+			Expect(err).To(BeNil())
+			Expect(s.jobs).To(HaveLen(1))
+			Expect(s.jobs).To(HaveKey("good"))
+			Expect(s.skippedJobs).To(HaveKey("bad1"))
+			Expect(s.skippedJobs).To(HaveKey("bad2"))
+			Expect(s.skippedJobs).To(HaveKey("bad3"))
+			Expect(s.configStatus.currentRevision()).To(Equal(int64(1)))
+		})
+
+		It("rejects a configuration with a malformed job and keeps the previous jobs", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			previous := &config.AgentConfig{PodNetwork: &config.NetworkConfig{
+				Jobs: []config.Job{
+					{JobID: "good", Args: []string{"nslookup", "--names", "foo.bar"}},
+					{JobID: "removed", Args: []string{"nslookup", "--names", "foo.bar"}},
+				},
+			}}
+			Expect(s.applyAgentConfig(previous)).To(Succeed())
+			good := s.jobs["good"]
+			applied := s.currentAgentConfig
+			rejections := testutil.ToFloat64(ConfigRejections)
+
+			for _, bad := range []config.Job{
+				{JobID: "bad1", Args: []string{"checkTCPPort", "--endpoints", "server:10.0.0.9:-1"}},
+				{JobID: "bad2", Args: []string{"--period", "1s"}},
+				{JobID: "bad3"},
+			} {
+				err := s.applyAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{
+					Jobs: []config.Job{
+						{JobID: "good", Args: []string{"nslookup", "--names", "foo.bar", "--period", "20s"}},
+						{JobID: "added", Args: []string{"nslookup", "--names", "foo.bar"}},
+						bad,
+					},
+				}})
+				Expect(err).To(MatchError(ContainSubstring("invalid job " + bad.JobID)))
+				Expect(s.jobs).To(HaveLen(2))
+				Expect(s.jobs["good"]).To(BeIdenticalTo(good))
+				Expect(s.jobs).To(HaveKey("removed"))
+				Expect(good.Cancelled()).To(BeFalse())
+				Expect(s.currentAgentConfig).To(BeIdenticalTo(applied))
+			}
+			Expect(s.configStatus.currentRevision()).To(Equal(int64(1)))
+			Expect(testutil.ToFloat64(ConfigRejections)).To(Equal(rejections + 3))
+		})
+
+		It("applies configurations while observations are processed", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 100; i++ {
+					s.processObservation(&nwpd.Observation{JobID: "unknown", SrcHost: "node-a", DestHost: "node-b", Ok: true})
+				}
+			}()
+			for i := 0; i < 10; i++ {
+				Expect(s.applyAgentConfig(&config.AgentConfig{LogObservations: i%2 == 0, PodNetwork: &config.NetworkConfig{}})).To(Succeed())
+			}
+			Eventually(done).Should(BeClosed())
+			Expect(s.getAgentConfig().LogObservations).To(BeFalse())
+		})
+
+		It("skips jobs not selected for the node", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			s.currentClusterConfig = &config.ClusterConfig{Nodes: []config.Node{
+				{Hostname: "node-a", InternalIP: "10.0.0.1", Labels: map[string]string{"pool": "canary"}},
+			}}
+			agentConfig := &config.AgentConfig{PodNetwork: &config.NetworkConfig{
+				Jobs: []config.Job{
+					{JobID: "canary", Args: []string{"nslookup", "--names", "foo.bar"}, NodeSelector: map[string]string{"pool": "canary"}},
+					{JobID: "other-pool", Args: []string{"nslookup", "--names", "foo.bar"}, NodeSelector: map[string]string{"pool": "other"}},
+					{JobID: "name-match", Args: []string{"nslookup", "--names", "foo.bar"}, NodeNamePattern: "node-[a-c]"},
+					{JobID: "name-no-match", Args: []string{"nslookup", "--names", "foo.bar"}, NodeNamePattern: "node"},
+				},
+			}}
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+			Expect(s.jobs).To(HaveLen(2))
+			Expect(s.jobs).To(HaveKey("canary"))
+			Expect(s.jobs).To(HaveKey("name-match"))
+
+			s.currentClusterConfig.Nodes[0].Labels = nil
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+			Expect(s.jobs).To(HaveLen(1))
+			Expect(s.jobs).To(HaveKey("name-match"))
+
+			agentConfig.PodNetwork.Jobs = append(agentConfig.PodNetwork.Jobs,
+				config.Job{JobID: "bad-pattern", Args: []string{"nslookup", "--names", "foo.bar"}, NodeNamePattern: "node-("})
+			Expect(s.applyAgentConfig(agentConfig)).To(MatchError(ContainSubstring("invalid job bad-pattern: invalid node name pattern")))
+			Expect(s.jobs).To(HaveLen(1))
+		})
+
+		It("applies the scaling policy if rendered for another cluster size", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			s.currentClusterConfig = &config.ClusterConfig{NodeCount: 300}
+			agentConfig := &config.AgentConfig{
+				ScalingPolicy: &config.ScalingPolicy{Rules: []config.ScalingRule{{AboveNodes: 200, PeriodMultiplier: 3}}},
+				PodNetwork: &config.NetworkConfig{
+					DefaultPeriod: metav1.Duration{Duration: 10 * time.Second},
+					Jobs:          []config.Job{{JobID: "nslookup", Args: []string{"nslookup", "--names", "foo.bar"}}},
+				},
+			}
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+			Expect(s.jobs["nslookup"].Period()).To(Equal(30 * time.Second))
+			Expect(s.currentAgentConfig.ScaledForNodeCount).To(Equal(300))
+			Expect(agentConfig.PodNetwork.Jobs[0].Args).To(Equal([]string{"nslookup", "--names", "foo.bar"}))
+
+			agentConfig.ScalingPolicy.Rules[0].SampleRatio = 2
+			Expect(s.applyAgentConfig(agentConfig)).To(MatchError(ContainSubstring("sampleRatio")))
+		})
+
+		It("stops disabled jobs and seeds their schedule like a new job if enabled again", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			agentConfig := &config.AgentConfig{PodNetwork: &config.NetworkConfig{
+				Jobs: []config.Job{
+					{JobID: "job1", Args: []string{"nslookup", "--names", "foo.bar", "--period", "10s"}},
+					{JobID: "job2", Args: []string{"nslookup", "--names", "foo.bar"}},
+				},
+			}}
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+			Expect(s.jobs).To(HaveLen(2))
+			// a schedule out of phase, e.g. after a deferred run
+			lastRun := time.Now().Add(-25*time.Second + 3*time.Millisecond)
+			s.jobs["job1"].SetLastRun(&lastRun)
+			BackedOffDestinations.WithLabelValues("job1").Set(1)
+
+			agentConfig.PodNetwork.Jobs[0].Enabled = ptr.To(false)
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+			Expect(s.jobs).To(HaveLen(1))
+			Expect(s.jobs).To(HaveKey("job2"))
+			// the metrics are removed like for a removed job
+			Expect(BackedOffDestinations.DeleteLabelValues("job1")).To(BeFalse())
+			resp, err := s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+			Expect(err).To(BeNil())
+			Expect(resp.Jobs).To(HaveLen(2))
+			Expect(resp.Jobs[0].JobID).To(Equal("job1"))
+			Expect(resp.Jobs[0].Disabled).To(BeTrue())
+			Expect(resp.Jobs[0].Skipped).To(BeTrue())
+			Expect(resp.Jobs[0].SkipReason).To(Equal("disabled"))
+
+			// still remembered after another reload
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+
+			agentConfig.PodNetwork.Jobs[0].Enabled = ptr.To(true)
+			Expect(s.applyAgentConfig(agentConfig)).To(Succeed())
+			Expect(s.jobs).To(HaveKey("job1"))
+			seeded := *s.jobs["job1"].GetLastRun()
+			offset := runners.PhaseOffset("node-a/job1", 10*time.Second)
+			Expect(time.Duration(seeded.UnixNano()-int64(offset)) % (10 * time.Second)).To(BeZero())
+			Expect(seeded).To(BeTemporally(">", time.Now().Add(-10*time.Second)))
+			Expect(s.disabledJobs).To(BeEmpty())
+		})
+
+		It("rejects invalid jitter", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			err := s.applyAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{Jitter: 1.5}})
+			Expect(err).To(MatchError(ContainSubstring("invalid jitter")))
+		})
+
+		It("rejects a negative CIDR address limit", func() {
+			s := newTestServer("node-a", &config.NetworkConfig{})
+			err := s.applyAgentConfig(&config.AgentConfig{PodNetwork: &config.NetworkConfig{MaxCIDRAddresses: -1}})
+			Expect(err).To(MatchError(ContainSubstring("invalid maxCIDRAddresses")))
+		})
+	})
+
+	Describe("incident thresholds", func() {
+		It("defaults and validates the range", func() {
+			minFailures, minRecoveries, err := incidentThresholdsOf(&config.AgentConfig{})
+			Expect(err).To(BeNil())
+			Expect(minFailures).To(Equal(aggregation.DefaultIncidentMinFailures))
+			Expect(minRecoveries).To(Equal(aggregation.DefaultIncidentMinRecoveries))
+
+			minFailures, minRecoveries, err = incidentThresholdsOf(&config.AgentConfig{Incidents: &config.IncidentConfig{MinFailures: 1, MinRecoveries: 5}})
+			Expect(err).To(BeNil())
+			Expect(minFailures).To(Equal(1))
+			Expect(minRecoveries).To(Equal(5))
+
+			_, _, err = incidentThresholdsOf(&config.AgentConfig{Incidents: &config.IncidentConfig{MinFailures: -1}})
+			Expect(err).To(MatchError(ContainSubstring("invalid Incidents minFailures")))
+			_, _, err = incidentThresholdsOf(&config.AgentConfig{Incidents: &config.IncidentConfig{MinRecoveries: 101}})
+			Expect(err).To(MatchError(ContainSubstring("invalid Incidents minRecoveries")))
+		})
+	})
+
+	It("rejects invalid regular expressions of observation requests", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := db.NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+
+		_, err = s.GetObservations(context.Background(), &nwpd.GetObservationsRequest{DestHostRegex: "10.0.["})
+		var twerr twirp.Error
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Code()).To(Equal(twirp.InvalidArgument))
+		Expect(twerr.Meta("argument")).To(Equal("destHostRegex"))
+
+		_, err = s.GetObservations(context.Background(), &nwpd.GetObservationsRequest{DestHostRegex: `^10\.0\.`})
+		Expect(err).To(BeNil())
+	})
+
+	It("pages through observations with continuation tokens", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := db.NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+
+		now := time.Now()
+		for i, dest := range []string{"node2", "node3", "node4"} {
+			writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: dest, Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Second)), Ok: true})
+		}
+		request := &nwpd.GetObservationsRequest{Start: timestamppb.New(now.Add(-time.Minute)), End: timestamppb.New(now.Add(time.Minute)), Limit: 2}
+		var resp *nwpd.GetObservationsResponse
+		Eventually(func() int {
+			resp, err = s.GetObservations(context.Background(), request)
+			Expect(err).To(BeNil())
+			return len(resp.Observations)
+		}).Should(Equal(2))
+		Expect(resp.NextPageToken).NotTo(BeEmpty())
+
+		request.PageToken = resp.NextPageToken
+		resp, err = s.GetObservations(context.Background(), request)
+		Expect(err).To(BeNil())
+		Expect(resp.Observations).To(HaveLen(1))
+		Expect(resp.Observations[0].DestHost).To(Equal("node4"))
+		Expect(resp.NextPageToken).To(BeEmpty())
+
+		request.PageToken = "not-a-token"
+		_, err = s.GetObservations(context.Background(), request)
+		var twerr twirp.Error
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Code()).To(Equal(twirp.InvalidArgument))
+		Expect(twerr.Meta("argument")).To(Equal("pageToken"))
+
+		// no continuation for other orders
+		request.PageToken = ""
+		request.SortDescending = true
+		resp, err = s.GetObservations(context.Background(), request)
+		Expect(err).To(BeNil())
+		Expect(resp.Observations).To(HaveLen(2))
+		Expect(resp.Observations[0].DestHost).To(Equal("node4"))
+		Expect(resp.NextPageToken).To(BeEmpty())
+
+		request.SortBy = "ok"
+		_, err = s.GetObservations(context.Background(), request)
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Code()).To(Equal(twirp.InvalidArgument))
+		Expect(twerr.Meta("argument")).To(Equal("sortBy"))
+
+		request.SortBy = nwpd.SortByDuration
+		_, err = s.GetAggregatedObservations(context.Background(), request)
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Meta("argument")).To(Equal("sortBy"))
+	})
+
+	It("pages through failure streaks filtered on the server", func() {
+		dir := GinkgoT().TempDir()
+		writer, err := db.NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), dir, "test", 24, false)
+		Expect(err).To(BeNil())
+		go writer.Run()
+		defer writer.Stop()
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+
+		now := time.Now().Add(-time.Minute)
+		// four failures of node2 with growing duration, a single failure of node3
+		for i, dest := range []string{"node2", "node3", "node2", "node3", "node2", "node2"} {
+			writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: dest, Timestamp: timestamppb.New(now.Add(time.Duration(i) * time.Second)),
+				Duration: durationpb.New(time.Duration(i+1) * time.Second), Ok: i == 3, ResultFields: map[string]string{"packetLoss": "100"}})
+		}
+		request := &nwpd.GetObservationsRequest{Start: timestamppb.New(now), OnlyFailureStreaksOfAtLeast: 2, Limit: 2,
+			RestrictToJobIDs: []string{"ping"}, ResultPattern: "packetLoss=100", MinDuration: durationpb.New(time.Second)}
+		var resp *nwpd.GetObservationsResponse
+		Eventually(func() int {
+			resp, err = s.GetObservations(context.Background(), request)
+			Expect(err).To(BeNil())
+			return len(resp.Observations)
+		}).Should(Equal(2))
+		Expect(resp.Observations[0].DestHost).To(Equal("node2"))
+		Expect(resp.Observations[0].Duration.AsDuration()).To(Equal(3 * time.Second))
+		Expect(resp.Observations[1].Duration.AsDuration()).To(Equal(5 * time.Second))
+		Expect(resp.NextPageToken).NotTo(BeEmpty())
+
+		// the streak started before the page token is still detected
+		request.PageToken = resp.NextPageToken
+		resp, err = s.GetObservations(context.Background(), request)
+		Expect(err).To(BeNil())
+		Expect(resp.Observations).To(HaveLen(1))
+		Expect(resp.Observations[0].Duration.AsDuration()).To(Equal(6 * time.Second))
+		Expect(resp.NextPageToken).To(BeEmpty())
+
+		request.PageToken = ""
+		request.ResultPattern = "packetLoss=(100"
+		_, err = s.GetObservations(context.Background(), request)
+		var twerr twirp.Error
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Code()).To(Equal(twirp.InvalidArgument))
+		Expect(twerr.Meta("argument")).To(Equal("resultPattern"))
+	})
+
+	It("aggregates observations by zone pairs", func() {
+		writer := &fakeWriter{}
+		now := time.Now()
+		for i, dest := range []string{"node2", "node3", "node4"} {
+			writer.Add(&nwpd.Observation{JobID: "ping", SrcHost: "node1", DestHost: dest, SrcZone: "zone-a", DestZone: "zone-b",
+				Timestamp: timestamppb.New(now), Ok: i != 0})
+		}
+		writer.Add(&nwpd.Observation{JobID: "https", SrcHost: "node1", DestHost: "api.example.com", Timestamp: timestamppb.New(now), Ok: true})
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+
+		resp, err := s.GetAggregatedObservations(context.Background(), &nwpd.GetObservationsRequest{AggregateBy: nwpd.AggregateByZone})
+		Expect(err).To(BeNil())
+		Expect(resp.AggregatedObservations).To(HaveLen(1))
+		ao := resp.AggregatedObservations[0]
+		Expect(ao.SrcZone).To(Equal("zone-a"))
+		Expect(ao.DestZone).To(Equal("zone-b"))
+		Expect(ao.SrcHost).To(BeEmpty())
+		Expect(ao.JobsOkCount).To(Equal(map[string]int32{"ping": 2}))
+		Expect(ao.JobsNotOkCount).To(Equal(map[string]int32{"ping": 1}))
+
+		resp, err = s.GetAggregatedObservations(context.Background(), &nwpd.GetObservationsRequest{})
+		Expect(err).To(BeNil())
+		Expect(resp.AggregatedObservations).To(HaveLen(4))
+
+		_, err = s.GetAggregatedObservations(context.Background(), &nwpd.GetObservationsRequest{AggregateBy: "region"})
+		var twerr twirp.Error
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Code()).To(Equal(twirp.InvalidArgument))
+	})
+
+	DescribeTable("validates the http bind address",
+		func(port int, bindAddress, expected, expectedErr string) {
+			GinkgoT().Setenv(common.EnvPodIP, "10.0.0.5")
+			address, err := httpAddressOf(&config.NetworkConfig{HTTPPort: port, HTTPBindAddress: bindAddress})
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(expected))
+		},
+		Entry("all interfaces by default", 1011, "", ":1011", ""),
+		Entry("loopback", 1011, "127.0.0.1", "127.0.0.1:1011", ""),
+		Entry("IPv6", 1011, "::1", "[::1]:1011", ""),
+		Entry("pod IP", 1011, "{podIP}", "10.0.0.5:1011", ""),
+		Entry("disabled http server", 0, "127.0.0.1", "", ""),
+		Entry("host name", 1011, "localhost", "", "invalid httpBindAddress \"localhost\", must be an IP address or {podIP}"),
+	)
+
+	Describe("http server", func() {
+		freePort := func() int {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+			defer l.Close()
+			return l.Addr().(*net.TCPAddr).Port
+		}
+		newHTTPServer := func(port int) *server {
+			s := &server{log: logrus.NewEntry(logrus.StandardLogger())}
+			s.currentAgentConfig = &config.AgentConfig{PodNetwork: &config.NetworkConfig{HTTPPort: port, HTTPBindAddress: "127.0.0.1"}}
+			DeferCleanup(s.shutdownHTTPServer)
+			return s
+		}
+		healthz := func(port int) error {
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, common.PathHealthz)) // #nosec G107 -- local test server
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		}
+
+		It("retries to listen with backoff if the address is in use", func() {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+			defer l.Close()
+			s := newHTTPServer(l.Addr().(*net.TCPAddr).Port)
+
+			now := time.Now()
+			s.serveHTTP(now)
+			Expect(s.httpServer).To(BeNil())
+			Expect(s.httpRetryAt).To(Equal(now.Add(httpRetryMinBackoff)))
+			Expect(s.readinessProblems()).To(ContainElement(ContainSubstring("cannot listen on " + l.Addr().String())))
+			s.serveHTTP(now.Add(httpRetryMinBackoff))
+			Expect(s.httpRetryAt).To(Equal(now.Add(3 * httpRetryMinBackoff)))
+
+			Expect(l.Close()).To(Succeed())
+			s.serveHTTP(now.Add(2 * httpRetryMinBackoff))
+			Expect(s.httpServer).To(BeNil())
+			s.serveHTTP(now.Add(3 * httpRetryMinBackoff))
+			Expect(s.httpServer).NotTo(BeNil())
+			Expect(s.httpListenError.Load()).To(BeNil())
+			Expect(s.readinessProblems()).NotTo(ContainElement(ContainSubstring("cannot listen")))
+			Eventually(func() error { return healthz(l.Addr().(*net.TCPAddr).Port) }, 5*time.Second, 50*time.Millisecond).Should(Succeed())
+		})
+
+		It("moves to the changed port and stops if disabled", func() {
+			port1, port2 := freePort(), freePort()
+			s := newHTTPServer(port1)
+			s.serveHTTP(time.Now())
+			Expect(s.httpAddress).To(Equal(fmt.Sprintf("127.0.0.1:%d", port1)))
+			Eventually(func() error { return healthz(port1) }, 5*time.Second, 50*time.Millisecond).Should(Succeed())
+
+			s.currentAgentConfig.PodNetwork.HTTPPort = port2
+			s.serveHTTP(time.Now())
+			Expect(s.httpAddress).To(Equal(fmt.Sprintf("127.0.0.1:%d", port2)))
+			Eventually(func() error { return healthz(port2) }, 5*time.Second, 50*time.Millisecond).Should(Succeed())
+			Expect(healthz(port1)).NotTo(Succeed())
+
+			s.currentAgentConfig.PodNetwork.HTTPPort = 0
+			s.serveHTTP(time.Now())
+			Expect(s.httpServer).To(BeNil())
+			Expect(s.httpAddress).To(BeEmpty())
+			Expect(healthz(port2)).NotTo(Succeed())
+		})
+	})
+
+	It("releases the http port when stopped", func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		port := l.Addr().(*net.TCPAddr).Port
+		Expect(l.Close()).To(Succeed())
+
+		dir := GinkgoT().TempDir()
+		networkCfg := &config.NetworkConfig{HTTPPort: port, HTTPBindAddress: "127.0.0.1"}
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		data, err := yaml.Marshal(&config.AgentConfig{PodNetwork: networkCfg, HostNetwork: networkCfg})
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		healthz := fmt.Sprintf("http://127.0.0.1:%d%s", port, common.PathHealthz)
+
+		for i := 0; i < 2; i++ {
+			s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+			Expect(err).To(BeNil())
+			s.logDirectory = filepath.Join(dir, "log")
+			Expect(s.setup()).To(Succeed())
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				defer GinkgoRecover()
+				Expect(s.run()).To(Succeed())
+			}()
+			Eventually(func() error {
+				resp, err := http.Get(healthz) // #nosec G107 -- local test server
+				if err == nil {
+					resp.Body.Close()
+				}
+				return err
+			}, 5*time.Second, 50*time.Millisecond).Should(Succeed())
+			close(s.done)
+			Eventually(stopped, 10*time.Second).Should(BeClosed())
+			Expect(s.httpServer).To(BeNil())
+			_, err = http.Get(healthz) // #nosec G107 -- local test server
+			Expect(err).NotTo(BeNil())
+		}
+	})
+
+	It("starts with the valid jobs of a configuration with a malformed job", func() {
+		dir := GinkgoT().TempDir()
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		data, err := yaml.Marshal(&config.AgentConfig{PodNetwork: &config.NetworkConfig{
+			Jobs: []config.Job{
+				{JobID: "bad", Args: []string{"checkTCPPort", "--endpoints", "server:10.0.0.9:-1"}},
+				{JobID: "good", Args: []string{"nslookup", "--names", "foo.bar"}},
+			},
+		}})
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+		s.logDirectory = filepath.Join(dir, "log")
+		Expect(s.setup()).To(Succeed())
+		Expect(s.jobs).To(HaveLen(1))
+		Expect(s.jobs).To(HaveKey("good"))
+
+		resp, err := s.GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+		Expect(err).To(BeNil())
+		Expect(resp.Jobs).To(HaveLen(2))
+		Expect(resp.Jobs[0].JobID).To(Equal("bad"))
+		Expect(resp.Jobs[0].Skipped).To(BeTrue())
+		Expect(resp.Jobs[0].SkipReason).To(ContainSubstring("invalid endpoint port"))
+	})
+
+	It("keeps ticking while the writer is stalled", func() {
+		dir := GinkgoT().TempDir()
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		data, err := yaml.Marshal(&config.AgentConfig{OutputDir: filepath.Join(dir, "records"), PodNetwork: &config.NetworkConfig{}})
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+		s.logDirectory = filepath.Join(dir, "log")
+		Expect(s.setup()).To(Succeed())
+		release := make(chan struct{})
+		s.writer = &stalledWriter{ObservationWriter: s.writer, release: release}
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			defer GinkgoRecover()
+			Expect(s.run()).To(Succeed())
+		}()
+		defer func() {
+			close(release)
+			close(s.done)
+			Eventually(stopped, 10*time.Second).Should(BeClosed())
+		}()
+
+		// more observations than the buffers of the server and the writer can take
+		droppedBefore := testutil.ToFloat64(WriterDroppedRecords)
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			for i := 0; i < 3*cap(s.obsChan); i++ {
+				s.obsChan <- &nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: fmt.Sprintf("node-%d", i), Timestamp: timestamppb.Now(), Ok: true}
+			}
+		}()
+		Eventually(sent, 5*time.Second).Should(BeClosed())
+		sentAt := time.Now()
+		Eventually(func() int64 { return s.lastTick.Load() }, 5*time.Second).Should(BeNumerically(">", sentAt.UnixNano()))
+		Eventually(func() int { return len(s.obsChan) }).Should(BeZero())
+		Expect(testutil.ToFloat64(WriterDroppedRecords) - droppedBefore).To(BeNumerically(">=", float64(cap(s.obsChan))))
+		Expect(s.readinessProblems()).NotTo(ContainElement(ContainSubstring("last tick")))
+	})
+
+	It("keeps ticking if a runner panics", func() {
+		dir := GinkgoT().TempDir()
+		agentConfigFile := filepath.Join(dir, "agent-config.yaml")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		data, err := yaml.Marshal(&config.AgentConfig{PodNetwork: &config.NetworkConfig{}})
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+		s.logDirectory = filepath.Join(dir, "log")
+		Expect(s.setup()).To(Succeed())
+		// no incidents are opened in the shared metrics
+		s.aggregator = &recordingAggregator{}
+		runnerConfigOf := func(jobID string) runners.RunnerConfig {
+			return runners.RunnerConfig{Job: config.Job{JobID: jobID}, Period: 10 * time.Millisecond, Shared: s.shared}
+		}
+		panicking := runners.NewInternalJob(&panickingRunner{blockingRunner{config: runnerConfigOf("panic-test")}}, 0)
+		ok := runners.NewInternalJob(&resultRunner{blockingRunner: blockingRunner{config: runnerConfigOf("ok")}, destHosts: []string{"node-b"}, ok: true}, 0)
+		s.jobs["panic-test"] = panicking
+		s.jobs["ok"] = ok
+		panicsBefore := testutil.ToFloat64(RunnerPanics.WithLabelValues("panic-test"))
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			defer GinkgoRecover()
+			Expect(s.run()).To(Succeed())
+		}()
+		defer func() {
+			close(s.done)
+			Eventually(stopped, 10*time.Second).Should(BeClosed())
+		}()
+
+		Eventually(func() float64 {
+			return testutil.ToFloat64(RunnerPanics.WithLabelValues("panic-test")) - panicsBefore
+		}, 5*time.Second).Should(BeNumerically(">=", 3))
+		result, failures := panicking.LastResult()
+		Expect(result.LastFailure).To(HavePrefix("runner panicked: malformed URL"))
+		Expect(failures).To(BeNumerically(">=", 2))
+		result, _ = ok.LastResult()
+		Expect(result.Ok).To(Equal(1))
+		Expect(s.readinessProblems()).NotTo(ContainElement(ContainSubstring("last tick")))
+	})
+
+	It("selects the network configuration by its own network", func() {
+		agentCfg := &config.AgentConfig{
+			HostNetwork: &config.NetworkConfig{HTTPPort: 1011, DataFilePrefix: "host", Jobs: []config.Job{
+				{JobID: "nslookup-host", Args: []string{"nslookup", "--names", "foo.bar"}},
+			}},
+			PodNetwork: &config.NetworkConfig{HTTPPort: 1012, DataFilePrefix: "pod", Jobs: []config.Job{
+				{JobID: "nslookup-pod", Args: []string{"nslookup", "--names", "foo.bar"}},
+			}},
+		}
+		for _, hostNetwork := range []bool{true, false} {
+			s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), "agent-config.yaml", "cluster-config.yaml", hostNetwork, config.EnvironmentStandalone)
+			Expect(err).To(BeNil())
+			s.currentClusterConfig = &config.ClusterConfig{}
+			Expect(s.applyAgentConfig(agentCfg)).To(Succeed())
+			expected := agentCfg.PodNetwork
+			if hostNetwork {
+				expected = agentCfg.HostNetwork
+			}
+			Expect(s.getNetworkCfg().HTTPPort).To(Equal(expected.HTTPPort), "hostNetwork=%t", hostNetwork)
+			Expect(s.getNetworkCfg().DataFilePrefix).To(Equal(expected.DataFilePrefix), "hostNetwork=%t", hostNetwork)
+			Expect(s.jobs).To(HaveLen(1))
+			Expect(s.jobs).To(HaveKey(jobid(expected.Jobs[0].JobID)), "hostNetwork=%t", hostNetwork)
+		}
+	})
+
+	It("scopes the http handlers to the server instance", func() {
+		log := logrus.NewEntry(logrus.StandardLogger())
+		var muxes []*http.ServeMux
+		for _, uid := range []string{"uid1", "uid2"} {
+			s := &server{log: log, podUID: uid}
+			Expect(func() { muxes = append(muxes, s.newServeMux("127.0.0.1:1011")) }).NotTo(Panic())
+		}
+		for i, uid := range []string{"uid1", "uid2"} {
+			rec := httptest.NewRecorder()
+			muxes[i].ServeHTTP(rec, httptest.NewRequest(http.MethodGet, common.PathPodIdentity, nil))
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+			Expect(rec.Header().Get(common.HeaderPodUID)).To(Equal(uid))
+		}
+	})
+
+	It("aggregates observations by job, source or destination host", func() {
+		writer := &fakeWriter{}
+		start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+		add := func(offset time.Duration, jobID, src, dest string, duration time.Duration) {
+			writer.Add(&nwpd.Observation{JobID: jobID, SrcHost: src, DestHost: dest, Timestamp: timestamppb.New(start.Add(offset)),
+				Ok: duration > 0, Duration: durationpb.New(duration)})
+		}
+		add(0, "ping", "node1", "node2", 10*time.Millisecond)
+		add(time.Second, "ping", "node1", "node3", 30*time.Millisecond)
+		add(2*time.Second, "ping", "node2", "node3", 0)
+		add(3*time.Second, "https", "node1", "api", 100*time.Millisecond)
+		// second window
+		add(time.Minute, "ping", "node2", "node3", 50*time.Millisecond)
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), writer: writer}
+
+		type group struct {
+			src, dest string
+			start     int64
+		}
+		aggregate := func(aggregateBy string) map[group]*nwpd.AggregatedObservation {
+			resp, err := s.GetAggregatedObservations(context.Background(), &nwpd.GetObservationsRequest{AggregateBy: aggregateBy,
+				Start: timestamppb.New(start), AggregationWindow: durationpb.New(time.Minute)})
+			Expect(err).To(BeNil())
+			result := map[group]*nwpd.AggregatedObservation{}
+			for _, ao := range resp.AggregatedObservations {
+				Expect(ao.PeriodEnd.AsTime().Sub(ao.PeriodStart.AsTime())).To(Equal(time.Minute))
+				result[group{src: ao.SrcHost, dest: ao.DestHost, start: ao.PeriodStart.AsTime().Unix()}] = ao
+			}
+			return result
+		}
+		first, second := start.Unix(), start.Add(time.Minute).Unix()
+
+		byJob := aggregate(nwpd.AggregateByJob)
+		Expect(byJob).To(HaveLen(2))
+		ao := byJob[group{"*", "*", first}]
+		Expect(ao.JobsOkCount).To(Equal(map[string]int32{"ping": 2, "https": 1}))
+		Expect(ao.JobsNotOkCount).To(Equal(map[string]int32{"ping": 1}))
+		Expect(ao.MeanOkDuration["ping"].AsDuration()).To(Equal(20 * time.Millisecond))
+		Expect(ao.MeanOkDuration["https"].AsDuration()).To(Equal(100 * time.Millisecond))
+		Expect(byJob[group{"*", "*", second}].MeanOkDuration["ping"].AsDuration()).To(Equal(50 * time.Millisecond))
+
+		bySrc := aggregate(nwpd.AggregateBySrcHost)
+		Expect(bySrc).To(HaveLen(3))
+		Expect(bySrc[group{"node1", "*", first}].JobsOkCount).To(Equal(map[string]int32{"ping": 2, "https": 1}))
+		Expect(bySrc[group{"node2", "*", first}].JobsNotOkCount).To(Equal(map[string]int32{"ping": 1}))
+		Expect(bySrc[group{"node2", "*", second}].JobsOkCount).To(Equal(map[string]int32{"ping": 1}))
+
+		byDest := aggregate(nwpd.AggregateByDestHost)
+		Expect(byDest).To(HaveLen(4))
+		ao = byDest[group{"*", "node3", first}]
+		Expect(ao.JobsOkCount).To(Equal(map[string]int32{"ping": 1}))
+		Expect(ao.JobsNotOkCount).To(Equal(map[string]int32{"ping": 1}))
+		Expect(ao.MeanOkDuration["ping"].AsDuration()).To(Equal(30 * time.Millisecond))
+		Expect(byDest).To(HaveKey(group{"*", "api", first}))
+
+		Expect(aggregate("")).To(HaveLen(5))
+	})
+
+	It("defaults to the text report format and rejects unknown formats", func() {
+		format, err := reportFormatOf(&config.AggregationConfig{})
+		Expect(err).To(BeNil())
+		Expect(format).To(Equal(config.ReportFormatText))
+		format, err = reportFormatOf(&config.AggregationConfig{AggregationReportFormat: config.ReportFormatJSON})
+		Expect(err).To(BeNil())
+		Expect(format).To(Equal(config.ReportFormatJSON))
+		_, err = reportFormatOf(&config.AggregationConfig{AggregationReportFormat: "yaml"})
+		Expect(err).To(MatchError(ContainSubstring("invalid AggregationReportFormat")))
+	})
+
+	It("defaults to the report files in the log directory and rejects unknown aggregation outputs", func() {
+		output, err := aggregationOutputOf(&config.AggregationConfig{}, "/var/log/nwpd")
+		Expect(err).To(BeNil())
+		Expect(output).To(Equal("file:/var/log/nwpd"))
+		dir, w := reportOutputOf(output)
+		Expect(dir).To(Equal("/var/log/nwpd"))
+		Expect(w).To(BeNil())
+		output, err = aggregationOutputOf(&config.AggregationConfig{AggregationOutput: "file:/tmp/reports"}, "/var/log/nwpd")
+		Expect(err).To(BeNil())
+		Expect(reportOutputOf(output)).To(Equal("/tmp/reports"))
+		output, err = aggregationOutputOf(&config.AggregationConfig{AggregationOutput: config.AggregationOutputStdout}, "/var/log/nwpd")
+		Expect(err).To(BeNil())
+		dir, w = reportOutputOf(output)
+		Expect(dir).To(BeEmpty())
+		Expect(w).To(BeIdenticalTo(os.Stdout))
+		output, err = aggregationOutputOf(&config.AggregationConfig{AggregationOutput: config.AggregationOutputNone}, "/var/log/nwpd")
+		Expect(err).To(BeNil())
+		dir, w = reportOutputOf(output)
+		Expect(dir).To(BeEmpty())
+		Expect(w).To(BeNil())
+		for _, invalid := range []string{"file:", "stderr", "/var/log/nwpd"} {
+			_, err = aggregationOutputOf(&config.AggregationConfig{AggregationOutput: invalid}, "/var/log/nwpd")
+			Expect(err).To(MatchError(ContainSubstring("invalid AggregationOutput")), invalid)
+		}
+	})
+
+	It("defaults and validates the top failing edges settings", func() {
+		k, minChecks, err := topFailingEdgesOf(&config.AggregationConfig{})
+		Expect(err).To(BeNil())
+		Expect(k).To(Equal(aggregation.DefaultTopFailingEdges))
+		Expect(minChecks).To(Equal(aggregation.DefaultTopFailingEdgesMinChecks))
+		k, minChecks, err = topFailingEdgesOf(&config.AggregationConfig{AggregationReportTopFailingEdges: 3, AggregationReportTopFailingEdgesMinChecks: 20})
+		Expect(err).To(BeNil())
+		Expect(k).To(Equal(3))
+		Expect(minChecks).To(Equal(20))
+		_, _, err = topFailingEdgesOf(&config.AggregationConfig{AggregationReportTopFailingEdges: -1})
+		Expect(err).To(MatchError(ContainSubstring("invalid AggregationReportTopFailingEdges")))
+		_, _, err = topFailingEdgesOf(&config.AggregationConfig{AggregationReportTopFailingEdgesMinChecks: -1})
+		Expect(err).To(MatchError(ContainSubstring("invalid AggregationReportTopFailingEdgesMinChecks")))
+	})
+
+	It("defaults and validates the report rotation settings", func() {
+		retention, maxBytes, err := reportRotationOf(&config.AgentConfig{})
+		Expect(err).To(BeNil())
+		Expect(retention).To(Equal(aggregation.DefaultReportRetention))
+		Expect(maxBytes).To(Equal(int64(aggregation.DefaultReportMaxBytes)))
+		retention, maxBytes, err = reportRotationOf(&config.AgentConfig{ReportRetentionHours: 6, ReportMaxMegabytes: 50})
+		Expect(err).To(BeNil())
+		Expect(retention).To(Equal(6 * time.Hour))
+		Expect(maxBytes).To(Equal(int64(50 * 1000 * 1000)))
+		_, _, err = reportRotationOf(&config.AgentConfig{ReportRetentionHours: -1})
+		Expect(err).To(MatchError(ContainSubstring("invalid ReportRetentionHours")))
+		_, _, err = reportRotationOf(&config.AgentConfig{ReportMaxMegabytes: -1})
+		Expect(err).To(MatchError(ContainSubstring("invalid ReportMaxMegabytes")))
+	})
+
+	It("validates the maximum disk usage of the record files", func() {
+		maxBytes, err := maxDiskUsageOf(&config.AgentConfig{})
+		Expect(err).To(BeNil())
+		Expect(maxBytes).To(Equal(int64(0)))
+		maxBytes, err = maxDiskUsageOf(&config.AgentConfig{MaxDiskUsageMegabytes: 500})
+		Expect(err).To(BeNil())
+		Expect(maxBytes).To(Equal(int64(500 * 1000 * 1000)))
+		_, err = maxDiskUsageOf(&config.AgentConfig{MaxDiskUsageMegabytes: -1})
+		Expect(err).To(MatchError(ContainSubstring("invalid MaxDiskUsageMegabytes")))
+	})
+
+	Describe("aggregation settings", func() {
+		minute := func(n int) *metav1.Duration {
+			return &metav1.Duration{Duration: time.Duration(n) * time.Minute}
+		}
+
+		It("uses the built-in defaults", func() {
+			aggrCfg, err := EffectiveAggregationConfig(&config.AgentConfig{}, false)
+			Expect(err).To(BeNil())
+			Expect(*aggrCfg).To(Equal(config.AggregationConfig{
+				AggregationReportPeriod:                   minute(1),
+				AggregationTimeWindow:                     minute(30),
+				AggregationReportFormat:                   config.ReportFormatText,
+				AggregationOutput:                         "file:" + common.PathLogDir,
+				AggregationReportLogJSON:                  ptr.To(false),
+				AggregationReportTopFailingEdges:          aggregation.DefaultTopFailingEdges,
+				AggregationReportTopFailingEdgesMinChecks: aggregation.DefaultTopFailingEdgesMinChecks,
+			}))
+		})
+
+		It("prefers the network settings over the global ones over the built-in defaults", func() {
+			cfg := &config.AgentConfig{
+				AggregationConfig: config.AggregationConfig{
+					AggregationReportPeriod:       minute(2),
+					AggregationReportFormat:       config.ReportFormatJSON,
+					AggregationReportLogJSON:      ptr.To(true),
+					AggregationReportResultFields: []string{"httpStatus"},
+				},
+				HostNetwork: &config.NetworkConfig{AggregationConfig: config.AggregationConfig{
+					AggregationTimeWindow:         minute(120),
+					AggregationReportResultFields: []string{"httpStatus", "attempts"},
+				}},
+				PodNetwork: &config.NetworkConfig{AggregationConfig: config.AggregationConfig{
+					AggregationReportPeriod:          minute(5),
+					AggregationReportLogJSON:         ptr.To(false),
+					AggregationReportTopFailingEdges: 3,
+				}},
+			}
+			host, err := EffectiveAggregationConfig(cfg, true)
+			Expect(err).To(BeNil())
+			Expect(host.AggregationReportPeriod).To(Equal(minute(2)))
+			Expect(host.AggregationTimeWindow).To(Equal(minute(120)))
+			Expect(host.AggregationReportFormat).To(Equal(config.ReportFormatJSON))
+			Expect(host.IsReportLogJSON()).To(BeTrue())
+			Expect(host.AggregationReportResultFields).To(Equal([]string{"httpStatus", "attempts"}))
+			Expect(host.AggregationReportTopFailingEdges).To(Equal(aggregation.DefaultTopFailingEdges))
+
+			pod, err := EffectiveAggregationConfig(cfg, false)
+			Expect(err).To(BeNil())
+			Expect(pod.AggregationReportPeriod).To(Equal(minute(5)))
+			Expect(pod.AggregationTimeWindow).To(Equal(minute(30)))
+			Expect(pod.AggregationReportFormat).To(Equal(config.ReportFormatJSON))
+			Expect(pod.IsReportLogJSON()).To(BeFalse())
+			Expect(pod.AggregationReportResultFields).To(Equal([]string{"httpStatus"}))
+			Expect(pod.AggregationReportTopFailingEdges).To(Equal(3))
+			// the agent configuration is not modified
+			Expect(cfg.AggregationReportTopFailingEdges).To(Equal(0))
+
+			cfg.PodNetwork.AggregationTimeWindow = minute(1)
+			_, err = EffectiveAggregationConfig(cfg, false)
+			Expect(err).To(MatchError(ContainSubstring("invalid AggregationTimeWindow")))
+			_, err = EffectiveAggregationConfig(cfg, true)
+			Expect(err).To(BeNil())
+		})
+
+		It("applies the settings of the network on reload", func() {
+			aggregator := &recordingAggregator{}
+			s := &server{
+				log:        logrus.NewEntry(logrus.StandardLogger()),
+				jobs:       map[jobid]*runners.InternalJob{},
+				aggregator: aggregator,
+			}
+			cfg := &config.AgentConfig{
+				AggregationConfig: config.AggregationConfig{AggregationReportPeriod: minute(2), AggregationTimeWindow: minute(60)},
+				HostNetwork: &config.NetworkConfig{AggregationConfig: config.AggregationConfig{
+					AggregationReportFormat: config.ReportFormatJSON,
+				}},
+				PodNetwork: &config.NetworkConfig{},
+			}
+			Expect(s.applyAgentConfig(cfg)).To(Succeed())
+			Expect(aggregator.reportPeriod).To(Equal(2 * time.Minute))
+			Expect(aggregator.timeWindow).To(Equal(time.Hour))
+			Expect(aggregator.format).To(Equal(config.ReportFormatText))
+			Expect(aggregator.topEdges).To(Equal(aggregation.DefaultTopFailingEdges))
+			Expect(aggregator.reportWriter).To(BeNil())
+
+			cfg.PodNetwork.AggregationConfig = config.AggregationConfig{
+				AggregationReportPeriod:          minute(10),
+				AggregationReportFormat:          config.ReportFormatJSON,
+				AggregationOutput:                config.AggregationOutputStdout,
+				AggregationReportLogJSON:         ptr.To(true),
+				AggregationReportResultFields:    []string{"attempts"},
+				AggregationReportTopFailingEdges: 1,
+			}
+			Expect(s.applyAgentConfig(cfg)).To(Succeed())
+			Expect(aggregator.reportPeriod).To(Equal(10 * time.Minute))
+			Expect(aggregator.timeWindow).To(Equal(time.Hour))
+			Expect(aggregator.format).To(Equal(config.ReportFormatJSON))
+			Expect(aggregator.logJSON).To(BeTrue())
+			Expect(aggregator.resultFields).To(Equal([]string{"attempts"}))
+			Expect(aggregator.topEdges).To(Equal(1))
+			Expect(aggregator.reportWriter).To(BeIdenticalTo(os.Stdout))
+
+			// invalid network settings are rejected and the current settings are kept
+			cfg.PodNetwork.AggregationReportPeriod = &metav1.Duration{Duration: time.Second}
+			Expect(s.applyAgentConfig(cfg)).NotTo(Succeed())
+			Expect(aggregator.reportPeriod).To(Equal(10 * time.Minute))
+		})
+	})
+
+	It("returns the top failing edges of the last report", func() {
+		aggregator, err := aggregation.NewObsAggregator(&aggregation.ObsAggregationOptions{
+			Log:                      logrus.NewEntry(logrus.StandardLogger()),
+			NodeName:                 "node1",
+			ReportPeriod:             1 * time.Hour,
+			TimeWindow:               30 * time.Minute,
+			TopFailingEdgesMinChecks: 1,
+		})
+		Expect(err).To(BeNil())
+		s := &server{log: logrus.NewEntry(logrus.StandardLogger()), aggregator: aggregator}
+
+		resp, err := s.GetSummary(context.Background(), &nwpd.GetSummaryRequest{})
+		Expect(err).To(BeNil())
+		Expect(resp.PeriodEnd).To(BeNil())
+		Expect(resp.Edges).To(BeEmpty())
+
+		for _, dest := range []string{"node2", "node3"} {
+			aggregator.Add(&nwpd.Observation{JobID: "job1", SrcHost: "node1", DestHost: dest, Timestamp: timestamppb.Now(),
+				Period: durationpb.New(10 * time.Second)})
+		}
+		// triggers the report on the next observation
+		aggregator.Reconfigure(0, 30*time.Minute)
+		aggregator.Add(&nwpd.Observation{JobID: "job1", SrcHost: "node1", DestHost: "node2", Timestamp: timestamppb.Now(),
+			Period: durationpb.New(10 * time.Second), Ok: true})
+		Eventually(func() []*nwpd.FailingEdge {
+			resp, err = s.GetSummary(context.Background(), &nwpd.GetSummaryRequest{Limit: 1})
+			Expect(err).To(BeNil())
+			return resp.Edges
+		}).Should(HaveLen(1))
+		Expect(resp.MinChecks).To(Equal(int32(1)))
+		Expect(resp.PeriodEnd).NotTo(BeNil())
+		Expect(resp.Edges[0]).To(HaveField("DestHost", "node3"))
+		Expect(resp.Edges[0]).To(HaveField("FailureRatio", 1.0))
+		Expect(resp.Edges[0]).To(HaveField("JobIDs", []string{"job1"}))
+		Expect(resp.Edges[0]).To(HaveField("Ongoing", true))
+
+		_, err = s.GetSummary(context.Background(), &nwpd.GetSummaryRequest{Limit: -1})
+		var twerr twirp.Error
+		Expect(errors.As(err, &twerr)).To(BeTrue())
+		Expect(twerr.Code()).To(Equal(twirp.InvalidArgument))
+	})
+
+	Describe("concurrency limit", func() {
+		It("defaults to a generous limit and rejects negative values", func() {
+			n, err := maxConcurrentJobsOf(&config.AgentConfig{})
+			Expect(err).To(BeNil())
+			Expect(n).To(Equal(defaultMaxConcurrentJobs))
+			_, err = maxConcurrentJobsOf(&config.AgentConfig{MaxConcurrentJobs: -1})
+			Expect(err).To(MatchError(ContainSubstring("invalid MaxConcurrentJobs")))
+		})
+
+		It("limits the in-flight probes of all jobs", func() {
+			n, err := maxInFlightProbesOf(&config.AgentConfig{})
+			Expect(err).To(BeNil())
+			Expect(n).To(Equal(defaultMaxInFlightProbes))
+			_, err = maxInFlightProbesOf(&config.AgentConfig{MaxInFlightProbes: -1})
+			Expect(err).To(MatchError(ContainSubstring("invalid MaxInFlightProbes")))
+
+			s := &server{
+				log:                logrus.NewEntry(logrus.StandardLogger()),
+				jobs:               map[jobid]*runners.InternalJob{},
+				shared:             runners.NewShared(nil, nil),
+				currentAgentConfig: &config.AgentConfig{},
+			}
+			Expect(s.applyAgentConfig(&config.AgentConfig{MaxInFlightProbes: 5,
+				PodNetwork: &config.NetworkConfig{Jobs: []config.Job{{JobID: "nslookup", Args: []string{"nslookup", "--names", "foo.bar"}}}},
+			})).To(Succeed())
+			Expect(s.shared.ProbeLimiter().Max()).To(Equal(5))
+			// the jobs are parsed with the limiter of their server
+			Expect(s.jobs["nslookup"].Config().Shared).To(BeIdenticalTo(s.shared))
+		})
+
+		It("delays jobs without free slot and starts them in order of their due time", func() {
+			started := make(chan string, 10)
+			s := &server{
+				log:     logrus.NewEntry(logrus.StandardLogger()),
+				jobs:    map[jobid]*runners.InternalJob{},
+				limiter: runners.NewLimiter(1, nil),
+			}
+			releases := map[string]chan struct{}{}
+			now := time.Now()
+			for i, id := range []string{"slow", "job1", "job2"} {
+				r := &blockingRunner{
+					config:  runners.RunnerConfig{Job: config.Job{JobID: id}, Period: time.Millisecond},
+					started: started,
+					release: make(chan struct{}),
+				}
+				releases[id] = r.release
+				job := runners.NewInternalJob(r, 0)
+				lastRun := now.Add(time.Duration(i-10) * time.Second)
+				job.SetLastRun(&lastRun)
+				s.jobs[id] = job
+			}
+
+			runNext := func() string {
+				s.triggerJobs()
+				var id string
+				Eventually(started).Should(Receive(&id))
+				s.triggerJobs()
+				Consistently(started, 50*time.Millisecond).ShouldNot(Receive())
+				Expect(s.limiter.TryAcquire()).To(BeFalse())
+				releases[id] <- struct{}{}
+				// wait until the slot is released
+				Eventually(s.limiter.TryAcquire).Should(BeTrue())
+				s.limiter.Release()
+				return id
+			}
+
+			// the slow job is started first as it is due the longest time, but must not starve the others
+			Expect(runNext()).To(Equal("slow"))
+			Expect(runNext()).To(Equal("job1"))
+			Expect(runNext()).To(Equal("job2"))
+			Expect(runNext()).To(Equal("slow"))
+		})
+	})
+
+	Describe("drain on shutdown", func() {
+		newDrainServer := func(bufferSize int) (*server, nwpd.ObservationWriter) {
+			writer, err := db.NewObsWriter(logrus.NewEntry(logrus.StandardLogger()), GinkgoT().TempDir(), "test", 24, false)
+			Expect(err).To(BeNil())
+			s := &server{
+				log:      logrus.NewEntry(logrus.StandardLogger()),
+				nodeName: "node-a",
+				jobs:     map[jobid]*runners.InternalJob{},
+				obsChan:  make(chan *nwpd.Observation, bufferSize),
+				writer:   writer,
+			}
+			s.startWriter()
+			return s, writer
+		}
+		list := func(writer nwpd.ObservationWriter) nwpd.Observations {
+			result, err := writer.ListObservations(nwpd.ListObservationsOptions{Start: time.Now().Add(-time.Minute)})
+			Expect(err).To(BeNil())
+			return result
+		}
+
+		It("writes the buffered observations and the ones of running jobs", func() {
+			s, writer := newDrainServer(100)
+			started := make(chan string, 1)
+			r := &delayedRunner{blockingRunner{
+				config:  runners.RunnerConfig{Job: config.Job{JobID: "job1"}, Period: time.Second},
+				started: started,
+				release: make(chan struct{}),
+			}}
+			s.jobs["job1"] = runners.NewInternalJob(r, 0)
+			s.triggerJobs()
+			Eventually(started).Should(Receive())
+
+			for i := 0; i < cap(s.obsChan); i++ {
+				s.obsChan <- &nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true}
+			}
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				close(r.release)
+			}()
+			Expect(s.drain(5 * time.Second)).To(Equal(101))
+			s.stop()
+			Expect(list(writer)).To(HaveLen(101))
+		})
+
+		It("gives up after the timeout", func() {
+			s, writer := newDrainServer(10)
+			started := make(chan string, 1)
+			r := &blockingRunner{
+				config:  runners.RunnerConfig{Job: config.Job{JobID: "stuck"}, Period: time.Second},
+				started: started,
+				release: make(chan struct{}),
+			}
+			defer close(r.release)
+			s.jobs["stuck"] = runners.NewInternalJob(r, 0)
+			s.triggerJobs()
+			Eventually(started).Should(Receive())
+			s.obsChan <- &nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true}
+
+			start := time.Now()
+			Expect(s.drain(100 * time.Millisecond)).To(Equal(1))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			s.stop()
+			Expect(list(writer)).To(HaveLen(1))
+		})
+
+		It("cancels the remaining runs and writes the final report after the pending observations", func() {
+			s, writer := newDrainServer(10)
+			aggregator := &recordingAggregator{}
+			s.aggregator = aggregator
+			started := make(chan string, 1)
+			r := &delayedRunner{blockingRunner{
+				config:  runners.RunnerConfig{Job: config.Job{JobID: "stuck"}, Period: time.Second},
+				started: started,
+				release: make(chan struct{}),
+			}}
+			job := runners.NewInternalJob(r, 0)
+			s.jobs["stuck"] = job
+			s.triggerJobs()
+			Eventually(started).Should(Receive())
+			for i := 0; i < 3; i++ {
+				s.obsChan <- &nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true}
+			}
+
+			s.shutdown(100 * time.Millisecond)
+			Expect(job.Cancelled()).To(BeTrue())
+			Expect(aggregator.flushedAt).To(Equal(3))
+			Expect(s.writer).To(BeNil())
+			Expect(list(writer)).To(HaveLen(3))
+
+			// the observation of the cancelled run is dropped
+			close(r.release)
+			Eventually(job.Running).Should(BeFalse())
+			Expect(s.obsChan).To(BeEmpty())
+		})
+	})
+
+	Describe("job cancellation", func() {
+		newSlowJob := func(jobID string) (*runners.InternalJob, *delayedRunner, chan string) {
+			started := make(chan string, 1)
+			r := &delayedRunner{blockingRunner{
+				config:  runners.RunnerConfig{Job: config.Job{JobID: jobID}, Period: time.Second},
+				started: started,
+				release: make(chan struct{}),
+			}}
+			return runners.NewInternalJob(r, 0), r, started
+		}
+
+		It("drops the observations of a deleted job", func() {
+			writer := &fakeWriter{}
+			s := &server{
+				log:      logrus.NewEntry(logrus.StandardLogger()),
+				nodeName: "node-a",
+				jobs:     map[jobid]*runners.InternalJob{},
+				obsChan:  make(chan *nwpd.Observation, 10),
+				runChan:  make(chan *nwpd.JobRunRecord, 10),
+				writer:   writer,
+			}
+			job, r, started := newSlowJob("slow")
+			s.jobs["slow"] = job
+			s.triggerJobs()
+			Eventually(started).Should(Receive())
+
+			Expect(s.deleteJob("slow", "deleted")).To(Succeed())
+			Expect(job.Cancelled()).To(BeTrue())
+			close(r.release)
+			Eventually(job.Running).Should(BeFalse())
+			Expect(s.obsChan).To(BeEmpty())
+			s.triggerJobs()
+			Consistently(s.obsChan, 100*time.Millisecond).ShouldNot(Receive())
+
+			// an observation buffered before the deletion is dropped too
+			s.processObservation(&nwpd.Observation{JobID: "slow", SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true})
+			s.processObservation(&nwpd.Observation{JobID: nwpd.MeshJobID("slow", "node-b"), SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true})
+			Expect(writer.observations).To(BeEmpty())
+
+			// the observations of a job added again are processed
+			job, _, _ = newSlowJob("slow")
+			s.addOrReplaceJob(job)
+			s.processObservation(&nwpd.Observation{JobID: "slow", SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true})
+			Expect(writer.observations).To(HaveLen(1))
+		})
+
+		It("cancels the replaced job", func() {
+			s := &server{
+				log:                logrus.NewEntry(logrus.StandardLogger()),
+				nodeName:           "node-a",
+				jobs:               map[jobid]*runners.InternalJob{},
+				obsChan:            make(chan *nwpd.Observation, 10),
+				currentAgentConfig: &config.AgentConfig{},
+			}
+			oldJob, r, started := newSlowJob("slow")
+			s.addOrReplaceJob(oldJob)
+			oldJob.SetLastRun(nil)
+			s.triggerJobs()
+			Eventually(started).Should(Receive())
+
+			newJob, newRunner, _ := newSlowJob("slow")
+			defer close(newRunner.release)
+			s.addOrReplaceJob(newJob)
+			Expect(oldJob.Cancelled()).To(BeTrue())
+			Expect(newJob.Cancelled()).To(BeFalse())
+			close(r.release)
+			Eventually(oldJob.Running).Should(BeFalse())
+			Expect(s.obsChan).To(BeEmpty())
+		})
+	})
+
+	Describe("addNoDataEdges", func() {
+		var (
+			start      = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+			validEdges = []aggregation.ValidEdge{
+				{SrcHost: "node1", DestHost: "node2", ValidSince: start.Add(-time.Hour)},
+				{SrcHost: "node1", DestHost: "node3", ValidSince: start.Add(90 * time.Second)},
+			}
+		)
+
+		It("fills windows of valid edges without observations", func() {
+			aggregated := []*nwpd.AggregatedObservation{
+				{
+					SrcHost:     "node1",
+					DestHost:    "node2",
+					PeriodStart: timestamppb.New(start),
+					PeriodEnd:   timestamppb.New(start.Add(time.Minute)),
+					JobsOkCount: map[string]int32{"job1": 3},
+				},
+			}
+			result := addNoDataEdges(aggregated, validEdges, &nwpd.GetObservationsRequest{}, defaultObservationsLimit, start, start.Add(2*time.Minute), time.Minute)
+			Expect(result).To(HaveLen(4))
+			Expect(result[0].NoData).To(BeFalse())
+			var noData []string
+			for _, ao := range result[1:] {
+				Expect(ao.NoData).To(BeTrue())
+				noData = append(noData, ao.DestHost+"@"+ao.PeriodStart.AsTime().Format("15:04"))
+				if ao.DestHost == "node3" && ao.PeriodStart.AsTime().Equal(start) {
+					Expect(ao.NotValidInPeriod).To(BeTrue())
+				} else {
+					Expect(ao.NotValidInPeriod).To(BeFalse())
+				}
+			}
+			Expect(noData).To(ConsistOf("node3@12:00", "node2@12:01", "node3@12:01"))
+		})
+
+		It("respects regular expressions for source and destination hosts", func() {
+			request := &nwpd.GetObservationsRequest{SrcHostRegex: "^node1$", DestHostRegex: "3$"}
+			result := addNoDataEdges(nil, validEdges, request, defaultObservationsLimit, start, start.Add(time.Minute), time.Minute)
+			Expect(result).To(HaveLen(1))
+			Expect(result[0].DestHost).To(Equal("node3"))
+		})
+
+		It("respects destination filter and limit", func() {
+			request := &nwpd.GetObservationsRequest{RestrictToDestHosts: []string{"node2"}}
+			result := addNoDataEdges(nil, validEdges, request, 3, start, start.Add(10*time.Minute), time.Minute)
+			Expect(result).To(HaveLen(3))
+			for _, ao := range result {
+				Expect(ao.DestHost).To(Equal("node2"))
+			}
+		})
+	})
+})
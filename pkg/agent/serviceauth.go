@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/twitchtv/twirp"
+)
+
+// agentServiceAuthSettings define the bearer token required for the agent service.
+type agentServiceAuthSettings struct {
+	// tokenFile is read on each request, so that a rotated secret is picked up.
+	tokenFile string
+	// tokenRef is resolved by the secret resolver on each request.
+	tokenRef *config.SecretRef
+}
+
+func agentServiceAuthSettingsOf(cfg *config.AgentConfig) (*agentServiceAuthSettings, error) {
+	switch {
+	case cfg.APIToken == "" && cfg.APITokenFile == "":
+		return nil, nil
+	case cfg.APIToken != "" && cfg.APITokenFile != "":
+		return nil, fmt.Errorf("invalid API token, only one of apiToken and apiTokenFile is allowed")
+	case cfg.APITokenFile != "":
+		// fail early if the file cannot be read, secret references are resolved on request
+		if _, err := readSecretFile("API token", cfg.APITokenFile); err != nil {
+			return nil, err
+		}
+		return &agentServiceAuthSettings{tokenFile: cfg.APITokenFile}, nil
+	}
+	ref, err := config.ParseSecretRef(cfg.APIToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiToken: %s", err)
+	}
+	return &agentServiceAuthSettings{tokenRef: &ref}, nil
+}
+
+// token returns the current bearer token.
+func (a *agentServiceAuthSettings) token(secrets *secretResolver) (string, error) {
+	if a.tokenFile != "" {
+		return readSecretFile("API token", a.tokenFile)
+	}
+	return secrets.resolve(*a.tokenRef)
+}
+
+// applyAgentServiceAuth replaces the token settings of the agent service, nil if no token is required.
+// Requests in progress are not affected, as the token is only checked at the start of a request.
+func (s *server) applyAgentServiceAuth(a *agentServiceAuthSettings) {
+	if old := s.serviceAuth.Swap(a); old != nil && a == nil {
+		s.log.Warnf("API token disabled, the agent service is served without authorization")
+	}
+}
+
+// requireAgentServiceToken rejects requests without the bearer token if a token is configured for the agent service.
+// Unauthorized requests are answered with the twirp error code `unauthenticated`.
+func (s *server) requireAgentServiceToken(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := s.serviceAuth.Load()
+		if auth == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		token, err := auth.token(s.secrets)
+		if err != nil {
+			s.log.Warnf("cannot read API token: %s", err)
+			_ = twirp.WriteError(w, twirp.NewError(twirp.Unavailable, "API token not available"))
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(token)) != 1 {
+			UnauthorizedRequests.Inc()
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			_ = twirp.WriteError(w, twirp.NewError(twirp.Unauthenticated, "missing or invalid API token"))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
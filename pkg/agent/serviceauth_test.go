@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/twitchtv/twirp"
+)
+
+// summaryService answers GetSummary only.
+type summaryService struct {
+	nwpd.AgentService
+}
+
+func (summaryService) GetSummary(context.Context, *nwpd.GetSummaryRequest) (*nwpd.GetSummaryResponse, error) {
+	return &nwpd.GetSummaryResponse{}, nil
+}
+
+// tokenTransport sets the authorization header like the agent client.
+type tokenTransport string
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("agent service API token", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "token"), []byte("token-1\n"), 0o600)).To(Succeed())
+	})
+
+	DescribeTable("validates the configuration",
+		func(apiToken, apiTokenFile string, expected *agentServiceAuthSettings, expectedErr string) {
+			if apiTokenFile != "" {
+				apiTokenFile = filepath.Join(dir, apiTokenFile)
+				if expected != nil {
+					expected.tokenFile = apiTokenFile
+				}
+			}
+			settings, err := agentServiceAuthSettingsOf(&config.AgentConfig{APIToken: apiToken, APITokenFile: apiTokenFile})
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+			Expect(err).To(BeNil())
+			Expect(settings).To(Equal(expected))
+		},
+		Entry("no token by default", "", "", nil, ""),
+		Entry("token file", "", "token", &agentServiceAuthSettings{}, ""),
+		Entry("secret reference", "secretRef:kube-system/nwpd-api-token#token", "",
+			&agentServiceAuthSettings{tokenRef: &config.SecretRef{Namespace: "kube-system", Name: "nwpd-api-token", Key: "token"}}, ""),
+		Entry("both", "secretRef:kube-system/nwpd-api-token#token", "token", nil,
+			"invalid API token, only one of apiToken and apiTokenFile is allowed"),
+		Entry("plain token", "token-1", "", nil, "invalid apiToken: invalid secret reference"),
+		Entry("missing file", "", "missing", nil, "cannot read API token secret"),
+	)
+
+	Context("with server", func() {
+		var (
+			s          *server
+			httpServer *httptest.Server
+		)
+
+		BeforeEach(func() {
+			s = &server{log: logrus.NewEntry(logrus.StandardLogger())}
+			twirpServer := nwpd.NewAgentServiceServer(summaryService{})
+			mux := http.NewServeMux()
+			mux.Handle(twirpServer.PathPrefix(), s.requireAgentServiceToken(twirpServer))
+			mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+			httpServer = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			httpServer.Close()
+		})
+
+		getSummary := func(token string) error {
+			httpClient := &http.Client{}
+			if token != "" {
+				httpClient.Transport = tokenTransport(token)
+			}
+			_, err := nwpd.NewAgentServiceProtobufClient(httpServer.URL, httpClient).GetSummary(context.Background(), &nwpd.GetSummaryRequest{})
+			return err
+		}
+		expectCode := func(err error, code twirp.ErrorCode) {
+			var twirpErr twirp.Error
+			Expect(errors.As(err, &twirpErr)).To(BeTrue(), "twirp error expected: %v", err)
+			Expect(twirpErr.Code()).To(Equal(code))
+		}
+
+		It("requires no token by default", func() {
+			Expect(getSummary("")).To(Succeed())
+		})
+
+		It("rejects requests without the token and picks up a rotated token", func() {
+			settings, err := agentServiceAuthSettingsOf(&config.AgentConfig{APITokenFile: filepath.Join(dir, "token")})
+			Expect(err).To(BeNil())
+			s.applyAgentServiceAuth(settings)
+
+			unauthorized := testutil.ToFloat64(UnauthorizedRequests)
+			expectCode(getSummary(""), twirp.Unauthenticated)
+			expectCode(getSummary("token-2"), twirp.Unauthenticated)
+			Expect(testutil.ToFloat64(UnauthorizedRequests)).To(Equal(unauthorized + 2))
+			Expect(getSummary("token-1")).To(Succeed())
+
+			resp, err := http.Get(httpServer.URL + "/healthz")
+			Expect(err).To(BeNil())
+			_ = resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK), "health checks are served without token")
+
+			Expect(os.WriteFile(filepath.Join(dir, "token"), []byte("token-2"), 0o600)).To(Succeed())
+			expectCode(getSummary("token-1"), twirp.Unauthenticated)
+			Expect(getSummary("token-2")).To(Succeed())
+
+			s.applyAgentServiceAuth(nil)
+			Expect(getSummary("")).To(Succeed())
+		})
+
+		It("fails closed if the token cannot be read", func() {
+			settings, err := agentServiceAuthSettingsOf(&config.AgentConfig{APIToken: "secretRef:kube-system/nwpd-api-token#token"})
+			Expect(err).To(BeNil())
+			s.applyAgentServiceAuth(settings)
+
+			// the secrets cannot be resolved outside of the kubernetes environment
+			expectCode(getSummary("token-1"), twirp.Unavailable)
+		})
+	})
+})
@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// tlsRecordTypeHandshake is the first byte of a TLS connection.
+	tlsRecordTypeHandshake = 0x16
+	// sniffTimeout is the maximum time for receiving the first byte of a connection.
+	sniffTimeout = 10 * time.Second
+)
+
+type agentServiceTLSSettings struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+}
+
+func agentServiceTLSSettingsOf(cfg *config.AgentConfig) (*agentServiceTLSSettings, error) {
+	c := cfg.AgentServiceTLS
+	if c == nil || (c.CertFile == "" && c.KeyFile == "" && c.ClientCAFile == "") {
+		return nil, nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("invalid AgentServiceTLS, certFile and keyFile must both be set")
+	}
+	return &agentServiceTLSSettings{certFile: c.CertFile, keyFile: c.KeyFile, clientCAFile: c.ClientCAFile}, nil
+}
+
+func (t *agentServiceTLSSettings) files() []string {
+	files := []string{t.certFile, t.keyFile}
+	if t.clientCAFile != "" {
+		files = append(files, t.clientCAFile)
+	}
+	return files
+}
+
+// fileStamp identifies the content of a file for detecting changes.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// agentServiceTLS is the TLS configuration loaded from the files of the settings.
+type agentServiceTLS struct {
+	settings *agentServiceTLSSettings
+	config   *tls.Config
+	stamps   map[string]fileStamp
+}
+
+// loadAgentServiceTLS reads the certificate, the key, and the client CAs.
+func loadAgentServiceTLS(settings *agentServiceTLSSettings) (*agentServiceTLS, error) {
+	stamps := map[string]fileStamp{}
+	for _, file := range settings.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	cert, err := tls.LoadX509KeyPair(settings.certFile, settings.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load certificate %s: %s", settings.certFile, err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"http/1.1"},
+	}
+	if settings.clientCAFile != "" {
+		data, err := os.ReadFile(settings.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", settings.clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &agentServiceTLS{settings: settings, config: cfg, stamps: stamps}, nil
+}
+
+// changed returns true if any of the files has been modified since loading.
+func (t *agentServiceTLS) changed() bool {
+	for file, stamp := range t.stamps {
+		info, err := os.Stat(file)
+		if err != nil || !info.ModTime().Equal(stamp.modTime) || info.Size() != stamp.size {
+			return true
+		}
+	}
+	return false
+}
+
+// agentServiceTLSOf loads the TLS configuration of the settings. The current configuration is reused if neither the settings
+// nor the files have changed.
+func (s *server) agentServiceTLSOf(settings *agentServiceTLSSettings) (*agentServiceTLS, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	if old := s.serviceTLS.Load(); old != nil && reflect.DeepEqual(old.settings, settings) && !old.changed() {
+		return old, nil
+	}
+	return loadAgentServiceTLS(settings)
+}
+
+// applyAgentServiceTLS replaces the TLS configuration of the agent service, nil for plaintext.
+func (s *server) applyAgentServiceTLS(t *agentServiceTLS) {
+	if old := s.serviceTLS.Swap(t); old != nil && t == nil {
+		s.log.Warnf("agent service TLS disabled, the agent service is served without TLS")
+	}
+}
+
+// reloadAgentServiceTLSIfChanged reloads the certificates if the files have changed, e.g. after a rotation.
+// If loading fails, e.g. because the files are not updated completely, the previous certificates are kept.
+func (s *server) reloadAgentServiceTLSIfChanged() {
+	old := s.serviceTLS.Load()
+	if old == nil || !old.changed() {
+		return
+	}
+	t, err := loadAgentServiceTLS(old.settings)
+	if err != nil {
+		s.log.Warnf("cannot reload agent service certificates, keeping the previous ones: %s", err)
+		return
+	}
+	if s.serviceTLS.CompareAndSwap(old, t) {
+		s.log.Infof("reloaded agent service certificate %s", old.settings.certFile)
+	}
+}
+
+// watchAgentServiceTLSFiles adds the directories of the certificate files to the watcher.
+func (s *server) watchAgentServiceTLSFiles(watcher *fsnotify.Watcher, watched map[string]bool) {
+	t := s.serviceTLS.Load()
+	if t == nil {
+		return
+	}
+	for _, file := range t.settings.files() {
+		// the directory is watched, as mounted secrets are updated by replacing a symbolic link
+		dir := path.Dir(file)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			s.log.Warnf("cannot watch directory %s: %s", dir, err)
+			continue
+		}
+		watched[dir] = true
+	}
+}
+
+// agentServiceTLSConfig returns the current TLS configuration or nil if the agent service is served without TLS.
+func (s *server) agentServiceTLSConfig() *tls.Config {
+	if t := s.serviceTLS.Load(); t != nil {
+		return t.config
+	}
+	return nil
+}
+
+// requireAgentServiceTLS rejects plaintext requests if TLS is configured for the agent service.
+func (s *server) requireAgentServiceTLS(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil && s.serviceTLS.Load() != nil {
+			http.Error(w, "agent service requires TLS", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// sniffingListener accepts plaintext and TLS connections on the same port. TLS connections are detected by the first byte
+// of the handshake, so that peers and probes can still use plaintext HTTP if TLS is configured for the agent service.
+type sniffingListener struct {
+	net.Listener
+	tlsConfig func() *tls.Config
+	conns     chan net.Conn
+	errs      chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newSniffingListener(l net.Listener, tlsConfig func() *tls.Config) *sniffingListener {
+	sl := &sniffingListener{
+		Listener:  l,
+		tlsConfig: tlsConfig,
+		conns:     make(chan net.Conn),
+		errs:      make(chan error, 1),
+		done:      make(chan struct{}),
+	}
+	go sl.acceptLoop()
+	return sl
+}
+
+func (l *sniffingListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			l.errs <- err
+			return
+		}
+		// the first byte is read in parallel, so that a slow client does not block other connections
+		go l.sniff(conn)
+	}
+}
+
+func (l *sniffingListener) sniff(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	first, err := r.Peek(1)
+	_ = conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	var c net.Conn = &peekedConn{Conn: conn, r: r}
+	if first[0] == tlsRecordTypeHandshake {
+		cfg := l.tlsConfig()
+		if cfg == nil {
+			_ = conn.Close()
+			return
+		}
+		c = tls.Server(c, cfg)
+	}
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		_ = conn.Close()
+	}
+}
+
+func (l *sniffingListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		// keep the error for further calls
+		l.errs <- err
+		return nil, err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *sniffingListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// peekedConn is a connection with the first bytes already read into the buffered reader.
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+// testCA issues certificates for the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA() *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).To(BeNil())
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate with the common name and its key to the files.
+func (ca *testCA) issue(commonName, certFile, keyFile string, usage x509.ExtKeyUsage) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).To(BeNil())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).To(BeNil())
+	Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)).To(Succeed())
+}
+
+var _ = Describe("agent service TLS", func() {
+	DescribeTable("validates the configuration",
+		func(tlsCfg *config.AgentServiceTLSConfig, expected *agentServiceTLSSettings, expectedErr string) {
+			settings, err := agentServiceTLSSettingsOf(&config.AgentConfig{AgentServiceTLS: tlsCfg})
+			if expectedErr != "" {
+				Expect(err).To(MatchError(expectedErr))
+				return
+			}
+			Expect(err).To(BeNil())
+			Expect(settings).To(Equal(expected))
+		},
+		Entry("plaintext by default", nil, nil, ""),
+		Entry("plaintext if empty", &config.AgentServiceTLSConfig{}, nil, ""),
+		Entry("server certificate", &config.AgentServiceTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"},
+			&agentServiceTLSSettings{certFile: "tls.crt", keyFile: "tls.key"}, ""),
+		Entry("client certificates", &config.AgentServiceTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt"},
+			&agentServiceTLSSettings{certFile: "tls.crt", keyFile: "tls.key", clientCAFile: "ca.crt"}, ""),
+		Entry("missing key", &config.AgentServiceTLSConfig{CertFile: "tls.crt"}, nil,
+			"invalid AgentServiceTLS, certFile and keyFile must both be set"),
+		Entry("client CA only", &config.AgentServiceTLSConfig{ClientCAFile: "ca.crt"}, nil,
+			"invalid AgentServiceTLS, certFile and keyFile must both be set"),
+	)
+
+	Context("with listener", func() {
+		var (
+			s          *server
+			ca         *testCA
+			dir        string
+			settings   *agentServiceTLSSettings
+			baseURL    string
+			httpServer *http.Server
+		)
+
+		BeforeEach(func() {
+			s = &server{log: logrus.NewEntry(logrus.StandardLogger())}
+			ca = newTestCA()
+			dir = GinkgoT().TempDir()
+			settings = &agentServiceTLSSettings{
+				certFile:     filepath.Join(dir, "tls.crt"),
+				keyFile:      filepath.Join(dir, "tls.key"),
+				clientCAFile: filepath.Join(dir, "ca.crt"),
+			}
+			ca.issue("agent-1", settings.certFile, settings.keyFile, x509.ExtKeyUsageServerAuth)
+			ca.issue("client", filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), x509.ExtKeyUsageClientAuth)
+			Expect(os.WriteFile(settings.clientCAFile, ca.pem, 0o600)).To(Succeed())
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("metrics")) })
+			mux.Handle("/observations", s.requireAgentServiceTLS(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("observations"))
+			})))
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+			httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+			go func() { _ = httpServer.Serve(newSniffingListener(l, s.agentServiceTLSConfig)) }()
+			baseURL = "://" + l.Addr().String()
+		})
+
+		AfterEach(func() {
+			_ = httpServer.Close()
+		})
+
+		get := func(client *http.Client, url string) (int, string, error) {
+			resp, err := client.Get(url)
+			if err != nil {
+				return 0, "", err
+			}
+			defer resp.Body.Close()
+			var body [64]byte
+			n, _ := resp.Body.Read(body[:])
+			return resp.StatusCode, string(body[:n]), nil
+		}
+		tlsClient := func(options agentclient.TLSOptions) *http.Client {
+			cfg, err := options.Config()
+			Expect(err).To(BeNil())
+			return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}, Timeout: 5 * time.Second}
+		}
+		clientOptions := func() agentclient.TLSOptions {
+			return agentclient.TLSOptions{
+				CAFile:   settings.clientCAFile,
+				CertFile: filepath.Join(dir, "client.crt"),
+				KeyFile:  filepath.Join(dir, "client.key"),
+			}
+		}
+		serverName := func() string {
+			cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+			Expect(err).To(BeNil())
+			conn, err := tls.Dial("tcp", baseURL[3:], &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{cert}}) // #nosec G402 -- test
+			Expect(err).To(BeNil())
+			defer conn.Close()
+			return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+		}
+
+		It("serves plaintext by default", func() {
+			status, body, err := get(http.DefaultClient, "http"+baseURL+"/observations")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("observations"))
+
+			_, _, err = get(tlsClient(agentclient.TLSOptions{Enabled: true}), "https"+baseURL+"/observations")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("requires TLS with client certificates for the agent service only", func() {
+			t, err := s.agentServiceTLSOf(settings)
+			Expect(err).To(BeNil())
+			s.applyAgentServiceTLS(t)
+
+			status, body, err := get(http.DefaultClient, "http"+baseURL+"/metrics")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("metrics"))
+			status, _, err = get(http.DefaultClient, "http"+baseURL+"/observations")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(http.StatusForbidden))
+
+			status, body, err = get(tlsClient(clientOptions()), "https"+baseURL+"/observations")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("observations"))
+
+			_, _, err = get(tlsClient(agentclient.TLSOptions{CAFile: settings.clientCAFile}), "https"+baseURL+"/observations")
+			Expect(err).NotTo(BeNil(), "client certificate required")
+
+			s.applyAgentServiceTLS(nil)
+			status, _, err = get(http.DefaultClient, "http"+baseURL+"/observations")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(http.StatusOK))
+		})
+
+		It("reloads the certificates after the files have changed", func() {
+			t, err := s.agentServiceTLSOf(settings)
+			Expect(err).To(BeNil())
+			s.applyAgentServiceTLS(t)
+			Expect(serverName()).To(Equal("agent-1"))
+			unchanged, err := s.agentServiceTLSOf(settings)
+			Expect(err).To(BeNil())
+			Expect(unchanged).To(BeIdenticalTo(t))
+
+			// incomplete rotation
+			later := time.Now().Add(time.Minute)
+			Expect(os.WriteFile(settings.keyFile, []byte("invalid"), 0o600)).To(Succeed())
+			Expect(os.Chtimes(settings.keyFile, later, later)).To(Succeed())
+			s.reloadAgentServiceTLSIfChanged()
+			Expect(serverName()).To(Equal("agent-1"))
+
+			ca.issue("agent-2", settings.certFile, settings.keyFile, x509.ExtKeyUsageServerAuth)
+			for i, file := range []string{settings.certFile, settings.keyFile} {
+				mtime := later.Add(time.Duration(i+1) * time.Second)
+				Expect(os.Chtimes(file, mtime, mtime)).To(Succeed())
+			}
+			s.reloadAgentServiceTLSIfChanged()
+			Expect(serverName()).To(Equal("agent-2"))
+			status, _, err := get(tlsClient(clientOptions()), "https"+baseURL+"/observations")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal(http.StatusOK))
+		})
+
+	})
+})
@@ -0,0 +1,470 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/version"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	defaultRemoteSinkBatchSize     = 500
+	defaultRemoteSinkQueueSize     = 10000
+	defaultRemoteSinkFlushInterval = 5 * time.Second
+	defaultRemoteSinkTimeout       = 10 * time.Second
+	// remoteSinkMaxAttempts is the number of attempts to send a batch before it is dropped.
+	remoteSinkMaxAttempts = 5
+	// remoteSinkInitialBackoff is the wait time before the first retry of a batch. It is doubled for each further retry.
+	remoteSinkInitialBackoff = 1 * time.Second
+	remoteSinkMaxBackoff     = 30 * time.Second
+	// maxRemoteSinkResponseSize is the maximum size of the response body included in an error.
+	maxRemoteSinkResponseSize = 512
+
+	// OTLP log values
+	otlpDefaultLogsPath = "/v1/logs"
+	otlpSeverityInfo    = 9
+	otlpSeverityWarn    = 13
+)
+
+// remoteSinkSettings is the applied remote sink configuration.
+type remoteSinkSettings struct {
+	url           string
+	format        string
+	batchSize     int
+	queueSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	// authHeaderFile is read on each request, so that a rotated secret is picked up.
+	authHeaderFile string
+	initialBackoff time.Duration
+}
+
+// remoteSinkSettingsOf validates the remote sink configuration. It returns nil if no URL is configured.
+func remoteSinkSettingsOf(cfg *config.AgentConfig) (*remoteSinkSettings, error) {
+	sCfg := cfg.RemoteSink
+	if sCfg == nil || sCfg.URL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(sCfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid RemoteSink url, must be an absolute http or https URL")
+	}
+	settings := &remoteSinkSettings{
+		format:         sCfg.Format,
+		batchSize:      defaultRemoteSinkBatchSize,
+		queueSize:      defaultRemoteSinkQueueSize,
+		flushInterval:  defaultRemoteSinkFlushInterval,
+		timeout:        defaultRemoteSinkTimeout,
+		authHeaderFile: sCfg.AuthHeaderFile,
+		initialBackoff: remoteSinkInitialBackoff,
+	}
+	switch sCfg.Format {
+	case "":
+		settings.format = config.RemoteSinkFormatJSON
+	case config.RemoteSinkFormatJSON:
+	case config.RemoteSinkFormatOTLP:
+		if u.Path == "" || u.Path == "/" {
+			u.Path = otlpDefaultLogsPath
+		}
+	default:
+		return nil, fmt.Errorf("invalid RemoteSink format %q, must be one of %s, %s", sCfg.Format, config.RemoteSinkFormatJSON, config.RemoteSinkFormatOTLP)
+	}
+	settings.url = u.String()
+	switch {
+	case sCfg.BatchSize < 0:
+		return nil, fmt.Errorf("invalid RemoteSink batchSize, must be >= 0")
+	case sCfg.BatchSize > 0:
+		settings.batchSize = sCfg.BatchSize
+	}
+	switch {
+	case sCfg.QueueSize < 0:
+		return nil, fmt.Errorf("invalid RemoteSink queueSize, must be >= 0")
+	case sCfg.QueueSize > 0:
+		settings.queueSize = sCfg.QueueSize
+	}
+	if settings.queueSize < settings.batchSize {
+		return nil, fmt.Errorf("invalid RemoteSink queueSize %d, must be >= batchSize %d", settings.queueSize, settings.batchSize)
+	}
+	if sCfg.FlushInterval != nil {
+		settings.flushInterval = sCfg.FlushInterval.Duration
+		if settings.flushInterval < 100*time.Millisecond || settings.flushInterval > time.Minute {
+			return nil, fmt.Errorf("invalid RemoteSink flushInterval, must be in range [100ms,1m]")
+		}
+	}
+	if sCfg.Timeout != nil {
+		settings.timeout = sCfg.Timeout.Duration
+		if settings.timeout <= 0 || settings.timeout > time.Minute {
+			return nil, fmt.Errorf("invalid RemoteSink timeout, must be > 0 and <= 1m")
+		}
+	}
+	// fail early if the file cannot be read
+	if settings.authHeaderFile != "" {
+		if _, err := readSecretFile("RemoteSink", settings.authHeaderFile); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// remoteSink mirrors the processed observations to a remote HTTP endpoint. The observations are queued and sent in batches
+// by a separate goroutine, so that a slow or failing endpoint never blocks the processing of the observations.
+// If the queue is full, the oldest observations are dropped and counted in the metric `nwpd_remote_sink_dropped_observations_total`.
+type remoteSink struct {
+	settings remoteSinkSettings
+	log      logrus.FieldLogger
+	// resource is the encoded OTLP resource of all log records.
+	resource []byte
+	client   *http.Client
+	// ctx is cancelled on stop to abort a request or a retry in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// wakeup is signalled if a batch is complete.
+	wakeup chan struct{}
+	// done is closed on stop to send the remaining observations.
+	done    chan struct{}
+	stopped chan struct{}
+
+	lock   sync.Mutex
+	queue  []*nwpd.Observation
+	closed bool
+}
+
+func newRemoteSink(log logrus.FieldLogger, settings remoteSinkSettings, nodeName string, hostNetwork bool) *remoteSink {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &remoteSink{
+		settings: settings,
+		log:      log,
+		resource: otlpResourceOf(nodeName, hostNetwork),
+		client:   &http.Client{Timeout: settings.timeout},
+		ctx:      ctx,
+		cancel:   cancel,
+		wakeup:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// add queues the observation. It never blocks.
+func (k *remoteSink) add(obs *nwpd.Observation) {
+	k.lock.Lock()
+	if k.closed {
+		k.lock.Unlock()
+		RemoteSinkDroppedObservations.Inc()
+		return
+	}
+	k.enqueue(obs)
+	complete := len(k.queue) >= k.settings.batchSize
+	k.lock.Unlock()
+	if complete {
+		select {
+		case k.wakeup <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// enqueue appends the observations and drops the oldest ones exceeding the queue size. The lock must be held.
+func (k *remoteSink) enqueue(observations ...*nwpd.Observation) {
+	k.queue = append(k.queue, observations...)
+	if n := len(k.queue) - k.settings.queueSize; n > 0 {
+		RemoteSinkDroppedObservations.Add(float64(n))
+		k.queue = k.queue[n:]
+	}
+}
+
+// takeBatch removes the next batch from the queue. An incomplete batch is only returned if all is true.
+func (k *remoteSink) takeBatch(all bool) []*nwpd.Observation {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	n := min(len(k.queue), k.settings.batchSize)
+	if n == 0 || (!all && n < k.settings.batchSize) {
+		return nil
+	}
+	batch := k.queue[:n:n]
+	k.queue = k.queue[n:]
+	return batch
+}
+
+// queued returns the number of queued observations.
+func (k *remoteSink) queued() int {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return len(k.queue)
+}
+
+// run sends the complete batches as soon as they are queued, and all queued observations after the flush interval.
+func (k *remoteSink) run() {
+	defer close(k.stopped)
+	ticker := time.NewTicker(k.settings.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.done:
+			// single attempt for the remaining observations
+			k.flush(true, 1)
+			return
+		case <-k.ctx.Done():
+			return
+		case <-k.wakeup:
+			k.flush(false, remoteSinkMaxAttempts)
+		case <-ticker.C:
+			k.flush(true, remoteSinkMaxAttempts)
+		}
+	}
+}
+
+func (k *remoteSink) flush(all bool, maxAttempts int) {
+	for k.ctx.Err() == nil {
+		batch := k.takeBatch(all)
+		if batch == nil {
+			return
+		}
+		k.send(batch, maxAttempts)
+	}
+}
+
+// send posts the batch. A failed request is retried with exponential backoff, unless the endpoint rejects the batch with a client error.
+// The batch is dropped after the last attempt.
+func (k *remoteSink) send(batch []*nwpd.Observation, maxAttempts int) {
+	body, contentType, err := k.encode(batch, time.Now())
+	if err != nil {
+		RemoteSinkDroppedObservations.Add(float64(len(batch)))
+		k.log.Warnf("cannot encode %d observations for remote sink: %s", len(batch), err)
+		return
+	}
+	backoff := k.settings.initialBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := k.post(body, contentType)
+		if err == nil {
+			RemoteSinkSentObservations.Add(float64(len(batch)))
+			return
+		}
+		RemoteSinkFailures.Inc()
+		if !retry || attempt >= maxAttempts {
+			RemoteSinkDroppedObservations.Add(float64(len(batch)))
+			k.log.Warnf("dropped %d observations for remote sink %s (attempts: %d): %s", len(batch), k.settings.url, attempt, err)
+			return
+		}
+		k.log.Debugf("sending to remote sink %s failed, retrying in %s: %s", k.settings.url, backoff, err)
+		select {
+		case <-k.ctx.Done():
+			RemoteSinkDroppedObservations.Add(float64(len(batch)))
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, remoteSinkMaxBackoff)
+	}
+}
+
+// post sends the request. It returns true if a failed request should be retried.
+func (k *remoteSink) post(body []byte, contentType string) (bool, error) {
+	req, err := http.NewRequestWithContext(k.ctx, http.MethodPost, k.settings.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "network-problem-detector")
+	if k.settings.authHeaderFile != "" {
+		value, err := readSecretFile("RemoteSink", k.settings.authHeaderFile)
+		if err != nil {
+			return true, err
+		}
+		req.Header.Set("Authorization", value)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSinkResponseSize))
+	if resp.StatusCode/100 != 2 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5
+		return retry, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return false, nil
+}
+
+// encode returns the request body with the observations in the format of the sink and its content type.
+func (k *remoteSink) encode(batch []*nwpd.Observation, now time.Time) ([]byte, string, error) {
+	if k.settings.format == config.RemoteSinkFormatOTLP {
+		return encodeLogsRequest(k.resource, batch, now), "application/x-protobuf", nil
+	}
+	var buf bytes.Buffer
+	encoder, err := db.NewEncoder(&buf, db.ExportFormatJSON, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, obs := range batch {
+		if err := encoder.Encode(obs); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), encoder.ContentType(), nil
+}
+
+// stop stops sending. If flush is true, the queued observations are sent with a single attempt within the request timeout.
+// It returns the observations which have not been sent.
+func (k *remoteSink) stop(flush bool) []*nwpd.Observation {
+	k.lock.Lock()
+	k.closed = true
+	k.lock.Unlock()
+	if flush {
+		close(k.done)
+		select {
+		case <-k.stopped:
+		case <-time.After(k.settings.timeout):
+		}
+	}
+	k.cancel()
+	<-k.stopped
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	remaining := k.queue
+	k.queue = nil
+	return remaining
+}
+
+// applyRemoteSink replaces the remote sink if the settings have changed. The sink is disabled if the settings are nil.
+// The queued observations of a replaced sink are taken over by the new one.
+func (s *server) applyRemoteSink(settings *remoteSinkSettings) {
+	current := s.sink.Load()
+	if settings == nil {
+		s.sink.Store(nil)
+		if current != nil {
+			RemoteSinkDroppedObservations.Add(float64(len(current.stop(false))))
+		}
+		return
+	}
+	if current != nil && current.settings == *settings {
+		return
+	}
+	sink := newRemoteSink(s.log.WithField("sub", "sink"), *settings, s.nodeName, s.hostNetwork)
+	s.sink.Store(sink)
+	if current != nil {
+		remaining := current.stop(false)
+		sink.lock.Lock()
+		sink.queue = append(remaining, sink.queue...)
+		sink.enqueue()
+		sink.lock.Unlock()
+	}
+	go sink.run()
+}
+
+// stopRemoteSink sends the queued observations and stops the remote sink on shutdown.
+func (s *server) stopRemoteSink() {
+	if current := s.sink.Swap(nil); current != nil {
+		if remaining := current.stop(true); len(remaining) > 0 {
+			RemoteSinkDroppedObservations.Add(float64(len(remaining)))
+			s.log.Warnf("dropped %d observations not sent to remote sink on shutdown", len(remaining))
+		}
+	}
+}
+
+// encodeLogsRequest encodes the observations as protobuf message `opentelemetry.proto.collector.logs.v1.ExportLogsServiceRequest`
+// with a log record per observation.
+func encodeLogsRequest(resource []byte, observations []*nwpd.Observation, now time.Time) []byte {
+	var scope []byte
+	scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+	scope = protowire.AppendString(scope, tracingScopeName)
+	if version.Version != "" {
+		scope = protowire.AppendTag(scope, 2, protowire.BytesType)
+		scope = protowire.AppendString(scope, version.Version)
+	}
+
+	var scopeLogs []byte
+	scopeLogs = protowire.AppendTag(scopeLogs, 1, protowire.BytesType)
+	scopeLogs = protowire.AppendBytes(scopeLogs, scope)
+	for _, obs := range observations {
+		scopeLogs = protowire.AppendTag(scopeLogs, 2, protowire.BytesType)
+		scopeLogs = protowire.AppendBytes(scopeLogs, encodeLogRecord(obs, now))
+	}
+
+	var resourceLogs []byte
+	resourceLogs = protowire.AppendTag(resourceLogs, 1, protowire.BytesType)
+	resourceLogs = protowire.AppendBytes(resourceLogs, resource)
+	resourceLogs = protowire.AppendTag(resourceLogs, 2, protowire.BytesType)
+	resourceLogs = protowire.AppendBytes(resourceLogs, scopeLogs)
+
+	var buf []byte
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	return protowire.AppendBytes(buf, resourceLogs)
+}
+
+// encodeLogRecord encodes the observation as protobuf message `opentelemetry.proto.logs.v1.LogRecord` with the result as body.
+// Failed observations have the severity `WARN`. The result fields and labels are added as attributes `nwpd.result.<name>`
+// and `nwpd.label.<name>`.
+func encodeLogRecord(obs *nwpd.Observation, now time.Time) []byte {
+	var buf []byte
+	if obs.Timestamp != nil {
+		buf = protowire.AppendTag(buf, 1, protowire.Fixed64Type)
+		buf = protowire.AppendFixed64(buf, uint64(obs.Timestamp.AsTime().UnixNano())) // #nosec G115 -- timestamps after 1970
+	}
+	severity, severityText := otlpSeverityInfo, "INFO"
+	if !obs.Ok {
+		severity, severityText = otlpSeverityWarn, "WARN"
+	}
+	buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(severity)) // #nosec G115 -- constant enum value
+	buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+	buf = protowire.AppendString(buf, severityText)
+	var body []byte
+	body = protowire.AppendTag(body, 1, protowire.BytesType)
+	body = protowire.AppendString(body, obs.Result)
+	buf = protowire.AppendTag(buf, 5, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, body)
+
+	buf = appendOTLPAttribute(buf, 6, "nwpd.jobid", obs.JobID)
+	buf = appendOTLPAttribute(buf, 6, "nwpd.src", obs.SrcHost)
+	buf = appendOTLPAttribute(buf, 6, "nwpd.dest", obs.DestHost)
+	buf = appendOTLPAttribute(buf, 6, "nwpd.ok", obs.Ok)
+	if obs.SrcZone != "" {
+		buf = appendOTLPAttribute(buf, 6, "nwpd.src_zone", obs.SrcZone)
+	}
+	if obs.DestZone != "" {
+		buf = appendOTLPAttribute(buf, 6, "nwpd.dest_zone", obs.DestZone)
+	}
+	if obs.Duration != nil {
+		buf = appendOTLPAttribute(buf, 6, "nwpd.duration_ms", float64(obs.Duration.AsDuration())/float64(time.Millisecond))
+	}
+	if obs.IncidentID != "" {
+		buf = appendOTLPAttribute(buf, 6, "nwpd.incident_id", obs.IncidentID)
+	}
+	for _, name := range sortedKeys(obs.ResultFields) {
+		buf = appendOTLPAttribute(buf, 6, "nwpd.result."+name, obs.ResultFields[name])
+	}
+	for _, name := range sortedKeys(obs.Labels) {
+		buf = appendOTLPAttribute(buf, 6, "nwpd.label."+name, obs.Labels[name])
+	}
+	buf = protowire.AppendTag(buf, 11, protowire.Fixed64Type)
+	return protowire.AppendFixed64(buf, uint64(now.UnixNano())) // #nosec G115 -- timestamps after 1970
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
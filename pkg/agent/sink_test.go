@@ -0,0 +1,315 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("remote sink", func() {
+	It("is disabled without url and validates the configuration", func() {
+		settings, err := remoteSinkSettingsOf(&config.AgentConfig{})
+		Expect(err).To(BeNil())
+		Expect(settings).To(BeNil())
+
+		settings, err = remoteSinkSettingsOf(&config.AgentConfig{RemoteSink: &config.RemoteSinkConfig{URL: "https://sink.example.com/ingest"}})
+		Expect(err).To(BeNil())
+		Expect(*settings).To(Equal(remoteSinkSettings{
+			url:            "https://sink.example.com/ingest",
+			format:         config.RemoteSinkFormatJSON,
+			batchSize:      defaultRemoteSinkBatchSize,
+			queueSize:      defaultRemoteSinkQueueSize,
+			flushInterval:  defaultRemoteSinkFlushInterval,
+			timeout:        defaultRemoteSinkTimeout,
+			initialBackoff: remoteSinkInitialBackoff,
+		}))
+
+		settings, err = remoteSinkSettingsOf(&config.AgentConfig{RemoteSink: &config.RemoteSinkConfig{URL: "http://otel-collector:4318", Format: "otlp"}})
+		Expect(err).To(BeNil())
+		Expect(settings.url).To(Equal("http://otel-collector:4318/v1/logs"))
+
+		for _, c := range []struct {
+			cfg config.RemoteSinkConfig
+			err string
+		}{
+			{config.RemoteSinkConfig{URL: "sink:8080"}, "invalid RemoteSink url"},
+			{config.RemoteSinkConfig{URL: "http://sink", Format: "xml"}, "invalid RemoteSink format"},
+			{config.RemoteSinkConfig{URL: "http://sink", BatchSize: -1}, "invalid RemoteSink batchSize"},
+			{config.RemoteSinkConfig{URL: "http://sink", QueueSize: -1}, "invalid RemoteSink queueSize"},
+			{config.RemoteSinkConfig{URL: "http://sink", BatchSize: 100, QueueSize: 10}, "invalid RemoteSink queueSize 10, must be >= batchSize 100"},
+			{config.RemoteSinkConfig{URL: "http://sink", FlushInterval: &metav1.Duration{Duration: 10 * time.Millisecond}}, "invalid RemoteSink flushInterval"},
+			{config.RemoteSinkConfig{URL: "http://sink", Timeout: &metav1.Duration{Duration: 2 * time.Minute}}, "invalid RemoteSink timeout"},
+			{config.RemoteSinkConfig{URL: "http://sink", AuthHeaderFile: "/not-existing"}, "cannot read RemoteSink secret"},
+		} {
+			_, err := remoteSinkSettingsOf(&config.AgentConfig{RemoteSink: &c.cfg})
+			Expect(err).To(MatchError(ContainSubstring(c.err)), c.err)
+		}
+	})
+
+	Describe("sending", func() {
+		var (
+			s        *server
+			lock     sync.Mutex
+			requests []*http.Request
+			bodies   [][]byte
+			handle   func(n int) int
+		)
+
+		respond := func(h func(n int) int) {
+			lock.Lock()
+			defer lock.Unlock()
+			handle = h
+		}
+		received := func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(bodies)
+		}
+		// receivedJobIDs returns the job IDs of the newline-delimited JSON observations of all requests.
+		receivedJobIDs := func() [][]string {
+			lock.Lock()
+			defer lock.Unlock()
+			var jobIDs [][]string
+			for _, body := range bodies {
+				var ids []string
+				scanner := bufio.NewScanner(bytes.NewReader(body))
+				for scanner.Scan() {
+					obs := map[string]any{}
+					Expect(json.Unmarshal(scanner.Bytes(), &obs)).To(Succeed())
+					ids = append(ids, obs["jobID"].(string))
+				}
+				jobIDs = append(jobIDs, ids)
+			}
+			return jobIDs
+		}
+		newObservation := func(i int) *nwpd.Observation {
+			return &nwpd.Observation{
+				JobID:     fmt.Sprintf("job%d", i),
+				SrcHost:   "node1",
+				DestHost:  "node2",
+				Timestamp: timestamppb.Now(),
+				Ok:        true,
+			}
+		}
+		apply := func(cfg config.RemoteSinkConfig) {
+			settings, err := remoteSinkSettingsOf(&config.AgentConfig{RemoteSink: &cfg})
+			Expect(err).To(BeNil())
+			settings.initialBackoff = 10 * time.Millisecond
+			s.applyRemoteSink(settings)
+		}
+		var sinkURL string
+
+		BeforeEach(func() {
+			requests = nil
+			bodies = nil
+			handle = func(_ int) int { return http.StatusOK }
+			sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				body, err := io.ReadAll(r.Body)
+				Expect(err).To(BeNil())
+				lock.Lock()
+				requests = append(requests, r)
+				n := len(requests)
+				h := handle
+				lock.Unlock()
+				status := h(n)
+				if status/100 == 2 {
+					lock.Lock()
+					bodies = append(bodies, body)
+					lock.Unlock()
+				}
+				w.WriteHeader(status)
+			}))
+			DeferCleanup(sink.Close)
+			sinkURL = sink.URL
+
+			var err error
+			s, err = newServer(logrus.NewEntry(logrus.StandardLogger()), "", "", false, "")
+			Expect(err).To(BeNil())
+			s.nodeName = "node1"
+			DeferCleanup(func() { s.applyRemoteSink(nil) })
+		})
+
+		It("sends the observations in batches with the authorization header", func() {
+			authFile := filepath.Join(GinkgoT().TempDir(), "auth")
+			Expect(os.WriteFile(authFile, []byte("Bearer secret\n"), 0o600)).To(Succeed())
+			apply(config.RemoteSinkConfig{URL: sinkURL, BatchSize: 3, QueueSize: 10, AuthHeaderFile: authFile,
+				FlushInterval: &metav1.Duration{Duration: time.Second}})
+			sent := testutil.ToFloat64(RemoteSinkSentObservations)
+
+			for i := 0; i < 7; i++ {
+				s.processObservation(newObservation(i))
+			}
+			// the complete batches are sent immediately
+			Eventually(received).Should(Equal(2))
+			Expect(receivedJobIDs()).To(Equal([][]string{{"job0", "job1", "job2"}, {"job3", "job4", "job5"}}))
+			// the incomplete batch after the flush interval
+			Eventually(received, 3*time.Second).Should(Equal(3))
+			Expect(receivedJobIDs()[2]).To(Equal([]string{"job6"}))
+			Expect(testutil.ToFloat64(RemoteSinkSentObservations)).To(Equal(sent + 7))
+
+			lock.Lock()
+			defer lock.Unlock()
+			Expect(requests[0].Method).To(Equal(http.MethodPost))
+			Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+			Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer secret"))
+		})
+
+		It("retries failed requests with backoff, but not rejected ones", func() {
+			apply(config.RemoteSinkConfig{URL: sinkURL, BatchSize: 2})
+			failures := testutil.ToFloat64(RemoteSinkFailures)
+			dropped := testutil.ToFloat64(RemoteSinkDroppedObservations)
+			respond(func(n int) int {
+				switch {
+				case n <= 2:
+					return http.StatusServiceUnavailable
+				case n == 3:
+					return http.StatusOK
+				default:
+					return http.StatusBadRequest
+				}
+			})
+
+			s.processObservation(newObservation(0))
+			s.processObservation(newObservation(1))
+			Eventually(received).Should(Equal(1))
+			Expect(receivedJobIDs()).To(Equal([][]string{{"job0", "job1"}}))
+			Expect(testutil.ToFloat64(RemoteSinkFailures)).To(Equal(failures + 2))
+
+			s.processObservation(newObservation(2))
+			s.processObservation(newObservation(3))
+			Eventually(func() float64 { return testutil.ToFloat64(RemoteSinkDroppedObservations) }).Should(Equal(dropped + 2))
+			Consistently(func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return len(requests)
+			}, 200*time.Millisecond).Should(Equal(4))
+		})
+
+		It("never blocks the processing and drops the oldest observations if the queue is full", func() {
+			release := make(chan struct{})
+			respond(func(n int) int {
+				if n == 1 {
+					<-release
+				}
+				return http.StatusOK
+			})
+			apply(config.RemoteSinkConfig{URL: sinkURL, BatchSize: 2, QueueSize: 4})
+			dropped := testutil.ToFloat64(RemoteSinkDroppedObservations)
+			sent := testutil.ToFloat64(RemoteSinkSentObservations)
+
+			start := time.Now()
+			for i := 0; i < 20; i++ {
+				s.processObservation(newObservation(i))
+			}
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			// the first batch is in flight
+			Eventually(func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return len(requests)
+			}).Should(Equal(1))
+			sink := s.sink.Load()
+			Expect(sink.queued()).To(BeNumerically("<=", 4))
+			droppedNow := testutil.ToFloat64(RemoteSinkDroppedObservations) - dropped
+			Expect(droppedNow).To(BeNumerically(">=", 14))
+			Expect(int(droppedNow) + sink.queued() + 2).To(Equal(20))
+
+			close(release)
+			Eventually(func() float64 { return testutil.ToFloat64(RemoteSinkSentObservations) }, 10*time.Second).Should(Equal(sent + 20 - droppedNow))
+			jobIDs := receivedJobIDs()
+			Expect(jobIDs[len(jobIDs)-1]).To(ContainElement("job19"))
+		})
+
+		It("sends OTLP log records", func() {
+			apply(config.RemoteSinkConfig{URL: sinkURL, Format: config.RemoteSinkFormatOTLP, BatchSize: 1})
+			obs := &nwpd.Observation{
+				JobID:        "tcp-n2n",
+				SrcHost:      "node1",
+				SrcZone:      "z1",
+				DestHost:     "node2",
+				Timestamp:    timestamppb.New(time.Now().Add(-time.Second)),
+				Duration:     durationpb.New(250 * time.Millisecond),
+				Result:       "error: connection refused",
+				IncidentID:   "01HINCIDENT",
+				ResultFields: map[string]string{"attempts": "3"},
+				Labels:       map[string]string{"team": "net"},
+			}
+			s.processObservation(obs)
+			Eventually(received).Should(Equal(1))
+
+			lock.Lock()
+			defer lock.Unlock()
+			Expect(requests[0].URL.Path).To(Equal("/v1/logs"))
+			Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/x-protobuf"))
+			request := protoFieldsOf(bodies[0])
+			resourceLogs := protoFieldsOf(request[1][0].bytes)
+			resource := otlpAttributesOf(protoFieldsOf(resourceLogs[1][0].bytes)[1])
+			Expect(resource).To(HaveKeyWithValue("host.name", "node1"))
+			scopeLogs := protoFieldsOf(resourceLogs[2][0].bytes)
+			Expect(scopeLogs[2]).To(HaveLen(1))
+
+			record := protoFieldsOf(scopeLogs[2][0].bytes)
+			Expect(record[1][0].value).To(Equal(uint64(obs.Timestamp.AsTime().UnixNano()))) // #nosec G115 -- test only
+			Expect(record[2][0].value).To(Equal(uint64(otlpSeverityWarn)))
+			Expect(string(record[3][0].bytes)).To(Equal("WARN"))
+			Expect(string(protoFieldsOf(record[5][0].bytes)[1][0].bytes)).To(Equal("error: connection refused"))
+			Expect(otlpAttributesOf(record[6])).To(Equal(map[string]any{
+				"nwpd.jobid":           "tcp-n2n",
+				"nwpd.src":             "node1",
+				"nwpd.src_zone":        "z1",
+				"nwpd.dest":            "node2",
+				"nwpd.ok":              false,
+				"nwpd.duration_ms":     250.0,
+				"nwpd.incident_id":     "01HINCIDENT",
+				"nwpd.result.attempts": "3",
+				"nwpd.label.team":      "net",
+			}))
+			Expect(record[11]).To(HaveLen(1))
+		})
+
+		It("keeps the queue on reconfiguration and sends it on shutdown", func() {
+			apply(config.RemoteSinkConfig{URL: sinkURL, BatchSize: 10, FlushInterval: &metav1.Duration{Duration: time.Minute}})
+			sink := s.sink.Load()
+			s.processObservation(newObservation(0))
+			s.processObservation(newObservation(1))
+
+			// unchanged settings
+			settings := sink.settings
+			s.applyRemoteSink(&settings)
+			Expect(s.sink.Load()).To(BeIdenticalTo(sink))
+
+			apply(config.RemoteSinkConfig{URL: sinkURL, BatchSize: 5, FlushInterval: &metav1.Duration{Duration: time.Minute}})
+			Expect(s.sink.Load()).NotTo(BeIdenticalTo(sink))
+			s.processObservation(newObservation(2))
+			Expect(received()).To(Equal(0))
+
+			s.stopRemoteSink()
+			Expect(s.sink.Load()).To(BeNil())
+			Expect(receivedJobIDs()).To(Equal([][]string{{"job0", "job1", "job2"}}))
+		})
+	})
+})
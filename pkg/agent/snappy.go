@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"encoding/binary"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// snappyBlockSize is the maximum size of the input encoded independently, so that all copy offsets fit into two bytes.
+	snappyBlockSize = 1 << 16
+	// snappyTableBits is the number of bits of the hash table for finding the matches of a block.
+	snappyTableBits = 14
+	// snappyMinMatch is the minimum length of a match emitted as copy.
+	snappyMinMatch = 4
+)
+
+// snappyEncode encodes the data in the snappy block format required by the remote write protocol.
+// The data is split into blocks of 64 KiB. Within a block, repeated sequences of at least four bytes found by a hash table
+// are emitted as copies of the previous occurrence, the remaining bytes as literals.
+func snappyEncode(data []byte) []byte {
+	out := make([]byte, 0, len(data)+len(data)/60+16)
+	out = protowire.AppendVarint(out, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), snappyBlockSize)
+		out = snappyEncodeBlock(out, data[:n])
+		data = data[n:]
+	}
+	return out
+}
+
+func snappyHash(u uint32) uint32 {
+	return (u * 0x1e35a7bd) >> (32 - snappyTableBits)
+}
+
+// snappyEncodeBlock appends the encoded block of at most snappyBlockSize bytes.
+func snappyEncodeBlock(out, block []byte) []byte {
+	// positions of the last occurrences of the hashed four bytes sequences
+	var table [1 << snappyTableBits]uint16
+	nextEmit := 0
+	for s := 0; s+snappyMinMatch <= len(block); {
+		u := binary.LittleEndian.Uint32(block[s:])
+		h := snappyHash(u)
+		candidate := int(table[h])
+		table[h] = uint16(s) // #nosec G115 -- bounded by block size
+		if candidate >= s || binary.LittleEndian.Uint32(block[candidate:]) != u {
+			s++
+			continue
+		}
+		length := snappyMinMatch
+		for s+length < len(block) && block[candidate+length] == block[s+length] {
+			length++
+		}
+		out = snappyAppendLiteral(out, block[nextEmit:s])
+		out = snappyAppendCopy(out, s-candidate, length)
+		s += length
+		nextEmit = s
+	}
+	return snappyAppendLiteral(out, block[nextEmit:])
+}
+
+// snappyAppendLiteral appends a literal element of at most snappyBlockSize bytes.
+func snappyAppendLiteral(out, literal []byte) []byte {
+	n := len(literal) - 1
+	switch {
+	case n < 0:
+		return out
+	case n < 60:
+		out = append(out, byte(n<<2))
+	case n < 1<<8:
+		out = append(out, 60<<2, byte(n))
+	default:
+		// length-1 in the following two bytes (little-endian)
+		out = append(out, 61<<2, byte(n), byte(n>>8))
+	}
+	return append(out, literal...)
+}
+
+// snappyAppendCopy appends the copy elements for a match with an offset below snappyBlockSize.
+func snappyAppendCopy(out []byte, offset, length int) []byte {
+	// a copy with a two bytes offset has a length of at most 64, the remainder keeps the minimum length of 4
+	for length >= 68 {
+		out = append(out, 63<<2|2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+	if length > 64 {
+		out = append(out, 59<<2|2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+	if length >= 12 || offset >= 2048 {
+		return append(out, byte(length-1)<<2|2, byte(offset), byte(offset>>8))
+	}
+	// a copy with a one byte offset has a length of 4 to 11 and an offset with 11 bits
+	return append(out, byte(offset>>8)<<5|byte(length-4)<<2|1, byte(offset))
+}
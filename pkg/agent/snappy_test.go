@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// snappyDecode decodes a snappy block as specified by the snappy format description.
+func snappyDecode(data []byte) ([]byte, error) {
+	size, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return nil, fmt.Errorf("invalid length")
+	}
+	data = data[n:]
+	var out []byte
+	for len(data) > 0 {
+		tag := data[0]
+		data = data[1:]
+		var length, offset, extra int
+		switch tag & 3 {
+		case 0:
+			length = int(tag>>2) + 1
+			if tag>>2 >= 60 {
+				extra = int(tag>>2) - 59
+				if len(data) < extra {
+					return nil, fmt.Errorf("truncated literal length")
+				}
+				length = 1
+				for i := extra - 1; i >= 0; i-- {
+					length += int(data[i]) << (8 * i)
+				}
+				data = data[extra:]
+			}
+			if len(data) < length {
+				return nil, fmt.Errorf("truncated literal")
+			}
+			out = append(out, data[:length]...)
+			data = data[length:]
+			continue
+		case 1:
+			if len(data) < 1 {
+				return nil, fmt.Errorf("truncated copy")
+			}
+			length = int(tag>>2&7) + 4
+			offset = int(tag>>5)<<8 | int(data[0])
+			data = data[1:]
+		case 2, 3:
+			extra = 2
+			if tag&3 == 3 {
+				extra = 4
+			}
+			if len(data) < extra {
+				return nil, fmt.Errorf("truncated copy")
+			}
+			length = int(tag>>2) + 1
+			for i := extra - 1; i >= 0; i-- {
+				offset = offset<<8 | int(data[i])
+			}
+			data = data[extra:]
+		}
+		if offset <= 0 || offset > len(out) {
+			return nil, fmt.Errorf("invalid copy offset %d", offset)
+		}
+		// the copy may overlap with its output
+		for i := 0; i < length; i++ {
+			out = append(out, out[len(out)-offset])
+		}
+	}
+	if len(out) != int(size) { // #nosec G115 -- test only
+		return nil, fmt.Errorf("length mismatch")
+	}
+	return out, nil
+}
+
+var _ = Describe("snappy", func() {
+	sequence := func(n int) []byte {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		return data
+	}
+
+	// the expected blocks are built by hand following the snappy format description
+	DescribeTable("encodes the reference blocks",
+		func(data, expected []byte) {
+			Expect(snappyEncode(data)).To(Equal(expected))
+			decoded, err := snappyDecode(expected)
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(data))
+		},
+		Entry("empty", []byte{}, []byte{0x00}),
+		Entry("short literal", []byte("abc"), []byte("\x03\x08abc")),
+		Entry("literal with one byte length", sequence(64), append([]byte{0x40, 0xf0, 63}, sequence(64)...)),
+		Entry("overlapping copy with one byte offset", bytes.Repeat([]byte("a"), 10), []byte("\x0a\x00a\x15\x01")),
+		Entry("copy with one byte offset", bytes.Repeat([]byte("0123456789"), 2), []byte("\x14\x240123456789\x19\x0a")),
+		Entry("copy with two bytes offset", bytes.Repeat([]byte("abcd"), 5), []byte("\x14\x0cabcd\x3e\x04\x00")),
+		Entry("long copy", bytes.Repeat([]byte("a"), 100), []byte("\x64\x00a\xfe\x01\x00\x8a\x01\x00")),
+	)
+
+	It("compresses repeated data of multiple blocks", func() {
+		var data []byte
+		for i := 0; len(data) < 3*snappyBlockSize+5; i++ {
+			data = append(data, fmt.Sprintf(`nwpd_aggregated_observations_total{jobid="tcp-n2p",src="node-%d",dest="node-%d"} %d`, i%7, i%5, i)...)
+		}
+		encoded := snappyEncode(data)
+		Expect(len(encoded)).To(BeNumerically("<", len(data)/2))
+		decoded, err := snappyDecode(encoded)
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(data))
+	})
+
+	It("encodes random data", func() {
+		rnd := rand.New(rand.NewSource(1)) // #nosec G404 -- test only
+		for _, size := range []int{1, 3, 17, 1000, snappyBlockSize, snappyBlockSize + 1, 2*snappyBlockSize + 100} {
+			data := make([]byte, size)
+			for i := range data {
+				// a small alphabet for short matches
+				data[i] = byte('a' + rnd.Intn(4))
+			}
+			decoded, err := snappyDecode(snappyEncode(data))
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(data))
+		}
+	})
+})
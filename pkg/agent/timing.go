@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+)
+
+const (
+	defaultTickPeriod             = 200 * time.Millisecond
+	minTickPeriod                 = 10 * time.Millisecond
+	maxTickPeriod                 = 10 * time.Second
+	defaultObservationBufferSize  = 100
+	maxObservationBufferSize      = 100000
+	defaultReloadDebounce         = 1 * time.Second
+	maxReloadDebounce             = 1 * time.Minute
+	defaultConfigRecheckPeriod    = 5 * time.Minute
+	minConfigRecheckPeriod        = 10 * time.Second
+	maxConfigRecheckPeriod        = 24 * time.Hour
+	defaultObservationSendTimeout = 5 * time.Second
+	maxObservationSendTimeout     = 1 * time.Minute
+	minWriteFlushInterval         = 100 * time.Millisecond
+	maxWriteFlushInterval         = 1 * time.Minute
+	maxWriteBatchSize             = 100000
+)
+
+// timing is the effective timing profile of the agent.
+type timing struct {
+	tickPeriod                time.Duration
+	observationBufferSize     int
+	reloadDebounce            time.Duration
+	configRecheckPeriod       time.Duration
+	defaultPeriod             time.Duration
+	observationSendTimeout    time.Duration
+	observationOverflowPolicy string
+	writeFlushInterval        time.Duration
+	writeBatchSize            int
+}
+
+func (t timing) String() string {
+	return fmt.Sprintf("tickPeriod=%s, observationBufferSize=%d, reloadDebounce=%s, configRecheckPeriod=%s, defaultPeriod=%s, observationSendTimeout=%s, observationOverflowPolicy=%s, writeFlushInterval=%s, writeBatchSize=%d",
+		t.tickPeriod, t.observationBufferSize, t.reloadDebounce, t.configRecheckPeriod, t.defaultPeriod, t.observationSendTimeout, t.observationOverflowPolicy,
+		t.writeFlushInterval, t.writeBatchSize)
+}
+
+// defaultTiming returns the timing profile used until the configuration is loaded.
+func defaultTiming() timing {
+	return timing{
+		tickPeriod:                defaultTickPeriod,
+		observationBufferSize:     defaultObservationBufferSize,
+		reloadDebounce:            defaultReloadDebounce,
+		configRecheckPeriod:       defaultConfigRecheckPeriod,
+		defaultPeriod:             runners.DefaultPeriod,
+		observationSendTimeout:    defaultObservationSendTimeout,
+		observationOverflowPolicy: config.ObservationOverflowPolicyBlock,
+		writeFlushInterval:        db.DefaultWriteFlushInterval,
+		writeBatchSize:            db.DefaultWriteBatchSize,
+	}
+}
+
+// timingOf returns the effective timing profile of the agent configuration and validates it.
+func timingOf(cfg *config.AgentConfig, networkCfg *config.NetworkConfig) (timing, error) {
+	t := defaultTiming()
+	if networkCfg.DefaultPeriod.Duration != 0 {
+		t.defaultPeriod = networkCfg.DefaultPeriod.Duration
+	}
+	if tc := cfg.Timing; tc != nil {
+		if tc.TickPeriod != nil {
+			t.tickPeriod = tc.TickPeriod.Duration
+		}
+		if tc.ObservationBufferSize != 0 {
+			t.observationBufferSize = tc.ObservationBufferSize
+		}
+		if tc.ReloadDebounce != nil {
+			t.reloadDebounce = tc.ReloadDebounce.Duration
+		}
+		if tc.ConfigRecheckPeriod != nil {
+			t.configRecheckPeriod = tc.ConfigRecheckPeriod.Duration
+		}
+		if tc.ObservationSendTimeout != nil {
+			t.observationSendTimeout = tc.ObservationSendTimeout.Duration
+		}
+		if tc.ObservationOverflowPolicy != "" {
+			t.observationOverflowPolicy = tc.ObservationOverflowPolicy
+		}
+		if tc.WriteFlushInterval != nil {
+			t.writeFlushInterval = tc.WriteFlushInterval.Duration
+		}
+		if tc.WriteBatchSize != 0 {
+			t.writeBatchSize = tc.WriteBatchSize
+		}
+	}
+	if t.tickPeriod < minTickPeriod || t.tickPeriod > maxTickPeriod {
+		return t, fmt.Errorf("invalid timing tickPeriod, must be in range [%s,%s]", minTickPeriod, maxTickPeriod)
+	}
+	if t.observationBufferSize < 1 || t.observationBufferSize > maxObservationBufferSize {
+		return t, fmt.Errorf("invalid timing observationBufferSize, must be in range [1,%d]", maxObservationBufferSize)
+	}
+	if t.reloadDebounce < 0 || t.reloadDebounce > maxReloadDebounce {
+		return t, fmt.Errorf("invalid timing reloadDebounce, must be in range [0s,%s]", maxReloadDebounce)
+	}
+	if t.configRecheckPeriod != 0 && (t.configRecheckPeriod < minConfigRecheckPeriod || t.configRecheckPeriod > maxConfigRecheckPeriod) {
+		return t, fmt.Errorf("invalid timing configRecheckPeriod, must be 0s or in range [%s,%s]", minConfigRecheckPeriod, maxConfigRecheckPeriod)
+	}
+	if t.observationSendTimeout < 0 || t.observationSendTimeout > maxObservationSendTimeout {
+		return t, fmt.Errorf("invalid timing observationSendTimeout, must be in range [0s,%s]", maxObservationSendTimeout)
+	}
+	if t.observationOverflowPolicy != config.ObservationOverflowPolicyBlock && t.observationOverflowPolicy != config.ObservationOverflowPolicyDrop {
+		return t, fmt.Errorf("invalid timing observationOverflowPolicy %q, must be %s or %s", t.observationOverflowPolicy,
+			config.ObservationOverflowPolicyBlock, config.ObservationOverflowPolicyDrop)
+	}
+	if t.writeFlushInterval < minWriteFlushInterval || t.writeFlushInterval > maxWriteFlushInterval {
+		return t, fmt.Errorf("invalid timing writeFlushInterval, must be in range [%s,%s]", minWriteFlushInterval, maxWriteFlushInterval)
+	}
+	if t.writeBatchSize < 1 || t.writeBatchSize > maxWriteBatchSize {
+		return t, fmt.Errorf("invalid timing writeBatchSize, must be in range [1,%d]", maxWriteBatchSize)
+	}
+	if t.defaultPeriod <= t.tickPeriod {
+		return t, fmt.Errorf("invalid defaultPeriod %s, must be greater than timing tickPeriod %s", t.defaultPeriod, t.tickPeriod)
+	}
+	return t, nil
+}
+
+// observationWait returns the maximum time a job run waits for free space in the observation buffer.
+func (t timing) observationWait() time.Duration {
+	if t.observationOverflowPolicy == config.ObservationOverflowPolicyDrop {
+		return 0
+	}
+	return t.observationSendTimeout
+}
+
+// checkJobPeriod validates that the job period is greater than the tick period, as otherwise runs would be skipped silently.
+func (t timing) checkJobPeriod(job *runners.InternalJob) error {
+	if job.Period() <= t.tickPeriod {
+		return fmt.Errorf("invalid job %s: period %s must be greater than timing tickPeriod %s", job.JobID(), job.Period(), t.tickPeriod)
+	}
+	return nil
+}
+
+// expectedBurst returns the number of observations if the runs of all jobs are finished at the same time.
+func expectedBurst(jobs []*runners.InternalJob) int {
+	burst := 0
+	for _, job := range jobs {
+		n := 1
+		if maxPeers := job.Config().MaxPeers; maxPeers > 0 {
+			n = max(1, min(maxPeers, len(job.DestHosts())))
+		}
+		burst += n
+	}
+	return burst
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("timing", func() {
+	duration := func(d time.Duration) *metav1.Duration {
+		return &metav1.Duration{Duration: d}
+	}
+
+	It("applies the defaults", func() {
+		t, err := timingOf(&config.AgentConfig{}, &config.NetworkConfig{})
+		Expect(err).To(BeNil())
+		Expect(t).To(Equal(defaultTiming()))
+		Expect(t.String()).To(Equal("tickPeriod=200ms, observationBufferSize=100, reloadDebounce=1s, configRecheckPeriod=5m0s, defaultPeriod=1s, observationSendTimeout=5s, observationOverflowPolicy=block, writeFlushInterval=5s, writeBatchSize=500"))
+	})
+
+	It("applies the configured values", func() {
+		cfg := &config.AgentConfig{Timing: &config.TimingConfig{
+			TickPeriod:                duration(50 * time.Millisecond),
+			ObservationBufferSize:     500,
+			ReloadDebounce:            duration(0),
+			ConfigRecheckPeriod:       duration(0),
+			ObservationSendTimeout:    duration(time.Second),
+			ObservationOverflowPolicy: config.ObservationOverflowPolicyDrop,
+			WriteFlushInterval:        duration(time.Second),
+			WriteBatchSize:            50,
+		}}
+		t, err := timingOf(cfg, &config.NetworkConfig{DefaultPeriod: metav1.Duration{Duration: 5 * time.Second}})
+		Expect(err).To(BeNil())
+		Expect(t).To(Equal(timing{
+			tickPeriod:                50 * time.Millisecond,
+			observationBufferSize:     500,
+			reloadDebounce:            0,
+			configRecheckPeriod:       0,
+			defaultPeriod:             5 * time.Second,
+			observationSendTimeout:    time.Second,
+			observationOverflowPolicy: config.ObservationOverflowPolicyDrop,
+			writeFlushInterval:        time.Second,
+			writeBatchSize:            50,
+		}))
+		Expect(t.observationWait()).To(BeZero())
+		Expect(defaultTiming().observationWait()).To(Equal(defaultObservationSendTimeout))
+	})
+
+	DescribeTable("enforces the invariants",
+		func(tc *config.TimingConfig, defaultPeriod time.Duration, expectedErr string) {
+			_, err := timingOf(&config.AgentConfig{Timing: tc}, &config.NetworkConfig{DefaultPeriod: metav1.Duration{Duration: defaultPeriod}})
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("tick period too small", &config.TimingConfig{TickPeriod: duration(time.Millisecond)}, time.Duration(0), "tickPeriod"),
+		Entry("tick period too large", &config.TimingConfig{TickPeriod: duration(time.Minute)}, time.Duration(0), "tickPeriod"),
+		Entry("negative buffer size", &config.TimingConfig{ObservationBufferSize: -1}, time.Duration(0), "observationBufferSize"),
+		Entry("buffer size too large", &config.TimingConfig{ObservationBufferSize: maxObservationBufferSize + 1}, time.Duration(0), "observationBufferSize"),
+		Entry("negative reload debounce", &config.TimingConfig{ReloadDebounce: duration(-time.Second)}, time.Duration(0), "reloadDebounce"),
+		Entry("recheck period too small", &config.TimingConfig{ConfigRecheckPeriod: duration(time.Second)}, time.Duration(0), "configRecheckPeriod"),
+		Entry("send timeout too large", &config.TimingConfig{ObservationSendTimeout: duration(time.Hour)}, time.Duration(0), "observationSendTimeout"),
+		Entry("unknown overflow policy", &config.TimingConfig{ObservationOverflowPolicy: "wait"}, time.Duration(0), "observationOverflowPolicy"),
+		Entry("flush interval too small", &config.TimingConfig{WriteFlushInterval: duration(time.Millisecond)}, time.Duration(0), "writeFlushInterval"),
+		Entry("negative batch size", &config.TimingConfig{WriteBatchSize: -1}, time.Duration(0), "writeBatchSize"),
+		Entry("default period not greater than tick period", &config.TimingConfig{TickPeriod: duration(2 * time.Second)}, 2*time.Second, "invalid defaultPeriod"),
+	)
+
+	It("skips jobs with a period not greater than the tick period on start and rejects them later", func() {
+		s := &server{
+			log:                logrus.NewEntry(logrus.StandardLogger()),
+			nodeName:           "node-a",
+			jobs:               map[jobid]*runners.InternalJob{},
+			currentAgentConfig: &config.AgentConfig{},
+		}
+		networkCfg := &config.NetworkConfig{
+			DefaultPeriod: metav1.Duration{Duration: 10 * time.Second},
+			Jobs: []config.Job{
+				{JobID: "fast", Args: []string{"nslookup", "--names", "foo.bar", "--period", "500ms"}},
+				{JobID: "slow", Args: []string{"nslookup", "--names", "foo.bar"}},
+			},
+		}
+		cfg := &config.AgentConfig{
+			Timing:      &config.TimingConfig{TickPeriod: duration(time.Second)},
+			HostNetwork: networkCfg,
+			PodNetwork:  networkCfg,
+		}
+		Expect(s.applyAgentConfig(cfg)).To(Succeed())
+		Expect(s.jobs).To(HaveLen(1))
+		Expect(s.jobs).To(HaveKey("slow"))
+		Expect(s.skippedJobs["fast"].reason).To(ContainSubstring("must be greater than timing tickPeriod 1s"))
+
+		cfg.Timing = nil
+		Expect(s.applyAgentConfig(cfg)).To(Succeed())
+		Expect(s.jobs).To(HaveLen(2))
+		Expect(s.getTiming().tickPeriod).To(Equal(defaultTickPeriod))
+
+		// with a previous revision the configuration is rejected as a whole
+		cfg.Timing = &config.TimingConfig{TickPeriod: duration(time.Second)}
+		Expect(s.applyAgentConfig(cfg)).To(MatchError(ContainSubstring("must be greater than timing tickPeriod 1s")))
+		Expect(s.jobs).To(HaveLen(2))
+		Expect(s.currentAgentConfig.Timing).To(BeNil())
+		Expect(s.getTiming().tickPeriod).To(Equal(defaultTickPeriod))
+		cfg.Timing = nil
+
+		// a job without destinations is applied without being scheduled
+		networkCfg.Jobs = append(networkCfg.Jobs, config.Job{JobID: "tcp-n2n", Args: []string{"checkTCPPort", "--node-port", "1011"}})
+		Expect(s.applyAgentConfig(cfg)).To(Succeed())
+		Expect(s.jobs).To(HaveLen(2))
+	})
+
+	It("calculates the expected burst of observations", func() {
+		clusterCfg := config.ClusterConfig{Nodes: []config.Node{
+			{Hostname: "node-a", InternalIP: "10.0.0.1"},
+			{Hostname: "node-b", InternalIP: "10.0.0.2"},
+			{Hostname: "node-c", InternalIP: "10.0.0.3"},
+		}}
+		var jobs []*runners.InternalJob
+		for _, args := range [][]string{
+			{"nslookup", "--names", "foo.bar,foo.baz"},
+			{"checkTCPPort", "--node-port", "443", "--max-peers", "2"},
+			{"checkTCPPort", "--node-port", "443", "--max-peers", "10"},
+		} {
+			rconfig := runners.RunnerConfig{Job: config.Job{JobID: args[0], Args: args}, Period: 10 * time.Second}
+			job, err := runners.Parse(clusterCfg, rconfig, args, &config.SampleConfig{})
+			Expect(err).To(BeNil())
+			jobs = append(jobs, job)
+		}
+		Expect(expectedBurst(jobs)).To(Equal(1 + 2 + 3))
+	})
+
+	It("coalesces the reload requests", func() {
+		s := &server{
+			log:               logrus.NewEntry(logrus.StandardLogger()),
+			agentConfigFile:   "/nonexisting/agent-config.yaml",
+			clusterConfigFile: "/nonexisting/cluster-config.yaml",
+		}
+		// the first reload blocks until the lock is released, the requests meanwhile are coalesced into one reload
+		s.reloadLock.Lock()
+		go s.requestReload()
+		Eventually(s.reloadRunning.Load).Should(BeTrue())
+		for i := 0; i < 5; i++ {
+			s.requestReload()
+		}
+		s.reloadLock.Unlock()
+		Eventually(s.reloadFailures.Load, time.Second).Should(Equal(int32(2)))
+		Eventually(s.reloadRunning.Load).Should(BeFalse())
+		Consistently(s.reloadFailures.Load, 200*time.Millisecond).Should(Equal(int32(2)))
+	})
+})
@@ -0,0 +1,349 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/agent/version"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	defaultTracingTimeout = 10 * time.Second
+	// traceExportPeriod is the period for exporting the queued spans.
+	traceExportPeriod = 5 * time.Second
+	// maxQueuedSpans is the maximum number of spans waiting for their observation to be processed or for the export.
+	maxQueuedSpans = 4096
+	// maxPendingSpanAge is the maximum time a span waits for the processing of its observation, e.g. if the observation has been dropped.
+	maxPendingSpanAge = 1 * time.Minute
+	// maxTraceExportResponseSize is the maximum size of the response body included in an error.
+	maxTraceExportResponseSize = 512
+	// tracingServiceName is the service name of the exported spans.
+	tracingServiceName = "network-problem-detector"
+	// tracingScopeName is the instrumentation scope of the exported spans.
+	tracingScopeName = "github.com/gardener/network-problem-detector/pkg/agent"
+
+	// OTLP enum values
+	otlpSpanKindClient    = 3
+	otlpStatusCodeOk      = 1
+	otlpStatusCodeError   = 2
+	otlpDefaultTracesPath = "/v1/traces"
+)
+
+// tracingSettings is the applied tracing configuration.
+type tracingSettings struct {
+	endpoint string
+	timeout  time.Duration
+}
+
+// tracingSettingsOf validates the tracing configuration. It returns nil if no endpoint is configured.
+func tracingSettingsOf(cfg *config.AgentConfig) (*tracingSettings, error) {
+	tCfg := cfg.Tracing
+	if tCfg == nil || tCfg.Endpoint == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(tCfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Tracing endpoint, must be an absolute http or https URL")
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpDefaultTracesPath
+	}
+	settings := &tracingSettings{
+		endpoint: u.String(),
+		timeout:  defaultTracingTimeout,
+	}
+	if tCfg.Timeout != nil {
+		settings.timeout = tCfg.Timeout.Duration
+		if settings.timeout <= 0 || settings.timeout > time.Minute {
+			return nil, fmt.Errorf("invalid Tracing timeout, must be > 0 and <= 1m")
+		}
+	}
+	return settings, nil
+}
+
+// probeSpan is the trace span of a single probe.
+type probeSpan struct {
+	traceID    [16]byte
+	spanID     [8]byte
+	start      time.Time
+	end        time.Time
+	jobID      string
+	src        string
+	dest       string
+	ok         bool
+	result     string
+	incidentID string
+}
+
+type pendingSpan struct {
+	span    *probeSpan
+	created time.Time
+}
+
+// probeTracer creates a span for each probe and queues it for the export.
+// The span is started by the job run and finished when the observation is processed, so that the incident ID is included.
+type probeTracer struct {
+	settings tracingSettings
+	// resource is the encoded OTLP resource of all spans.
+	resource []byte
+
+	lock    sync.Mutex
+	pending map[*nwpd.Observation]pendingSpan
+	queue   []*probeSpan
+}
+
+func newProbeTracer(settings tracingSettings, nodeName string, hostNetwork bool) *probeTracer {
+	return &probeTracer{
+		settings: settings,
+		resource: otlpResourceOf(nodeName, hostNetwork),
+		pending:  map[*nwpd.Observation]pendingSpan{},
+	}
+}
+
+// otlpResourceOf returns the encoded OTLP resource of the agent.
+func otlpResourceOf(nodeName string, hostNetwork bool) []byte {
+	var resource []byte
+	resource = appendOTLPAttribute(resource, 1, "service.name", tracingServiceName)
+	if version.Version != "" {
+		resource = appendOTLPAttribute(resource, 1, "service.version", version.Version)
+	}
+	resource = appendOTLPAttribute(resource, 1, "host.name", nodeName)
+	network := common.NameDaemonSetAgentPodNet
+	if hostNetwork {
+		network = common.NameDaemonSetAgentHostNet
+	}
+	return appendOTLPAttribute(resource, 1, "service.instance.id", network+"/"+nodeName)
+}
+
+// startSpan creates the span of the probe of the observation. It is called by the job run.
+func (t *probeTracer) startSpan(obs *nwpd.Observation) {
+	span := &probeSpan{
+		start:  obs.Timestamp.AsTime(),
+		jobID:  obs.JobID,
+		src:    obs.SrcHost,
+		dest:   obs.DestHost,
+		ok:     obs.Ok,
+		result: obs.Result,
+	}
+	span.end = span.start
+	if obs.Duration != nil {
+		span.end = span.start.Add(obs.Duration.AsDuration())
+	}
+	_, _ = rand.Read(span.traceID[:])
+	_, _ = rand.Read(span.spanID[:])
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.pending)+len(t.queue) >= maxQueuedSpans {
+		DroppedSpans.Inc()
+		return
+	}
+	t.pending[obs] = pendingSpan{span: span, created: time.Now()}
+}
+
+// finishSpan completes the span of the observation and queues it for the export. It is called when the observation is processed.
+func (t *probeTracer) finishSpan(obs *nwpd.Observation) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p, ok := t.pending[obs]
+	if !ok {
+		return
+	}
+	delete(t.pending, obs)
+	p.span.incidentID = obs.IncidentID
+	t.queue = append(t.queue, p.span)
+}
+
+// takeSpans returns the queued spans and drops the spans of observations which have not been processed in time.
+func (t *probeTracer) takeSpans(now time.Time) []*probeSpan {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for obs, p := range t.pending {
+		if now.Sub(p.created) > maxPendingSpanAge {
+			delete(t.pending, obs)
+			DroppedSpans.Inc()
+		}
+	}
+	spans := t.queue
+	t.queue = nil
+	return spans
+}
+
+// applyTracing replaces the probe tracer if the tracing settings have changed. Tracing is disabled if the settings are nil.
+// Spans of a replaced tracer which have not been exported yet are dropped.
+func (s *server) applyTracing(settings *tracingSettings) {
+	current := s.tracer.Load()
+	switch {
+	case settings == nil:
+		s.shared.SetProbeTracer(nil)
+		s.tracer.Store(nil)
+	case current == nil || current.settings != *settings:
+		t := newProbeTracer(*settings, s.nodeName, s.hostNetwork)
+		s.tracer.Store(t)
+		s.shared.SetProbeTracer(&runners.ProbeTracer{OnObservation: t.startSpan})
+	}
+}
+
+// sendTracesIfDue exports the queued spans if the export period has elapsed. At most one export is in flight.
+// The spans of a failed export are dropped and counted in the metric `nwpd_dropped_spans_total`.
+func (s *server) sendTracesIfDue(now time.Time) {
+	t := s.tracer.Load()
+	if t == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.traceExportInFlight || now.Sub(s.lastTraceExport) < traceExportPeriod {
+		s.lock.Unlock()
+		return
+	}
+	s.lastTraceExport = now
+	spans := t.takeSpans(now)
+	if len(spans) == 0 {
+		s.lock.Unlock()
+		return
+	}
+	s.traceExportInFlight = true
+	s.lock.Unlock()
+
+	go func() {
+		defer func() {
+			s.lock.Lock()
+			s.traceExportInFlight = false
+			s.lock.Unlock()
+		}()
+		if err := t.export(spans); err != nil {
+			DroppedSpans.Add(float64(len(spans)))
+			s.log.Warnf("trace export to %s failed: %s", t.settings.endpoint, err)
+		}
+	}()
+}
+
+// export sends the spans as OTLP/HTTP request with protobuf encoding.
+func (t *probeTracer) export(spans []*probeSpan) error {
+	req, err := http.NewRequest(http.MethodPost, t.settings.endpoint, bytes.NewReader(encodeTraceRequest(t.resource, spans)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "network-problem-detector")
+	client := &http.Client{Timeout: t.settings.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxTraceExportResponseSize))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// encodeTraceRequest encodes the spans as protobuf message `opentelemetry.proto.collector.trace.v1.ExportTraceServiceRequest`.
+func encodeTraceRequest(resource []byte, spans []*probeSpan) []byte {
+	var scope []byte
+	scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+	scope = protowire.AppendString(scope, tracingScopeName)
+	if version.Version != "" {
+		scope = protowire.AppendTag(scope, 2, protowire.BytesType)
+		scope = protowire.AppendString(scope, version.Version)
+	}
+
+	var scopeSpans []byte
+	scopeSpans = protowire.AppendTag(scopeSpans, 1, protowire.BytesType)
+	scopeSpans = protowire.AppendBytes(scopeSpans, scope)
+	for _, span := range spans {
+		scopeSpans = protowire.AppendTag(scopeSpans, 2, protowire.BytesType)
+		scopeSpans = protowire.AppendBytes(scopeSpans, encodeSpan(span))
+	}
+
+	var resourceSpans []byte
+	resourceSpans = protowire.AppendTag(resourceSpans, 1, protowire.BytesType)
+	resourceSpans = protowire.AppendBytes(resourceSpans, resource)
+	resourceSpans = protowire.AppendTag(resourceSpans, 2, protowire.BytesType)
+	resourceSpans = protowire.AppendBytes(resourceSpans, scopeSpans)
+
+	var buf []byte
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	return protowire.AppendBytes(buf, resourceSpans)
+}
+
+// encodeSpan encodes the span as protobuf message `opentelemetry.proto.trace.v1.Span`.
+func encodeSpan(span *probeSpan) []byte {
+	var buf []byte
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, span.traceID[:])
+	buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, span.spanID[:])
+	buf = protowire.AppendTag(buf, 5, protowire.BytesType)
+	buf = protowire.AppendString(buf, "probe "+span.jobID)
+	buf = protowire.AppendTag(buf, 6, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, otlpSpanKindClient)
+	buf = protowire.AppendTag(buf, 7, protowire.Fixed64Type)
+	buf = protowire.AppendFixed64(buf, uint64(span.start.UnixNano())) // #nosec G115 -- timestamps after 1970
+	buf = protowire.AppendTag(buf, 8, protowire.Fixed64Type)
+	buf = protowire.AppendFixed64(buf, uint64(span.end.UnixNano())) // #nosec G115 -- timestamps after 1970
+	buf = appendOTLPAttribute(buf, 9, "nwpd.jobid", span.jobID)
+	buf = appendOTLPAttribute(buf, 9, "nwpd.src", span.src)
+	buf = appendOTLPAttribute(buf, 9, "nwpd.dest", span.dest)
+	buf = appendOTLPAttribute(buf, 9, "nwpd.ok", span.ok)
+	buf = appendOTLPAttribute(buf, 9, "nwpd.duration_ms", float64(span.end.Sub(span.start))/float64(time.Millisecond))
+	if span.incidentID != "" {
+		buf = appendOTLPAttribute(buf, 9, "nwpd.incident_id", span.incidentID)
+	}
+
+	var status []byte
+	if span.ok {
+		status = protowire.AppendTag(status, 3, protowire.VarintType)
+		status = protowire.AppendVarint(status, otlpStatusCodeOk)
+	} else {
+		status = protowire.AppendTag(status, 2, protowire.BytesType)
+		status = protowire.AppendString(status, span.result)
+		status = protowire.AppendTag(status, 3, protowire.VarintType)
+		status = protowire.AppendVarint(status, otlpStatusCodeError)
+	}
+	buf = protowire.AppendTag(buf, 15, protowire.BytesType)
+	return protowire.AppendBytes(buf, status)
+}
+
+// appendOTLPAttribute appends a field with the given number containing the attribute as message `opentelemetry.proto.common.v1.KeyValue`.
+// The value must be a string, bool or float64.
+func appendOTLPAttribute(buf []byte, field protowire.Number, key string, value any) []byte {
+	var anyValue []byte
+	switch v := value.(type) {
+	case string:
+		anyValue = protowire.AppendTag(anyValue, 1, protowire.BytesType)
+		anyValue = protowire.AppendString(anyValue, v)
+	case bool:
+		anyValue = protowire.AppendTag(anyValue, 2, protowire.VarintType)
+		anyValue = protowire.AppendVarint(anyValue, protowire.EncodeBool(v))
+	case float64:
+		anyValue = protowire.AppendTag(anyValue, 4, protowire.Fixed64Type)
+		anyValue = protowire.AppendFixed64(anyValue, math.Float64bits(v))
+	}
+	var kv []byte
+	kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+	kv = protowire.AppendString(kv, key)
+	kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+	kv = protowire.AppendBytes(kv, anyValue)
+	buf = protowire.AppendTag(buf, field, protowire.BytesType)
+	return protowire.AppendBytes(buf, kv)
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// protoField is a decoded protobuf field, either length-delimited or a varint or fixed64 value.
+type protoField struct {
+	bytes []byte
+	value uint64
+}
+
+// protoFieldsOf decodes the fields of a protobuf message by field number.
+func protoFieldsOf(msg []byte) map[protowire.Number][]protoField {
+	fields := map[protowire.Number][]protoField{}
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		Expect(n).To(BeNumerically(">", 0))
+		msg = msg[n:]
+		var f protoField
+		switch typ {
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(msg)
+		case protowire.VarintType:
+			f.value, n = protowire.ConsumeVarint(msg)
+		case protowire.Fixed64Type:
+			f.value, n = protowire.ConsumeFixed64(msg)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, msg)
+		}
+		Expect(n).To(BeNumerically(">", 0))
+		msg = msg[n:]
+		fields[num] = append(fields[num], f)
+	}
+	return fields
+}
+
+// otlpAttributesOf decodes the `KeyValue` fields into a map of the string, bool or double values.
+func otlpAttributesOf(kvs []protoField) map[string]any {
+	attrs := map[string]any{}
+	for _, kv := range kvs {
+		fields := protoFieldsOf(kv.bytes)
+		value := protoFieldsOf(fields[2][0].bytes)
+		key := string(fields[1][0].bytes)
+		switch {
+		case value[1] != nil:
+			attrs[key] = string(value[1][0].bytes)
+		case value[2] != nil:
+			attrs[key] = value[2][0].value != 0
+		case value[4] != nil:
+			attrs[key] = math.Float64frombits(value[4][0].value)
+		}
+	}
+	return attrs
+}
+
+var _ = Describe("tracing", func() {
+	It("is disabled without endpoint and validates the configuration", func() {
+		settings, err := tracingSettingsOf(&config.AgentConfig{})
+		Expect(err).To(BeNil())
+		Expect(settings).To(BeNil())
+
+		settings, err = tracingSettingsOf(&config.AgentConfig{Tracing: &config.TracingConfig{Endpoint: "http://otel-collector:4318"}})
+		Expect(err).To(BeNil())
+		Expect(settings.endpoint).To(Equal("http://otel-collector:4318/v1/traces"))
+		Expect(settings.timeout).To(Equal(defaultTracingTimeout))
+
+		_, err = tracingSettingsOf(&config.AgentConfig{Tracing: &config.TracingConfig{Endpoint: "otel-collector:4318"}})
+		Expect(err).To(MatchError(ContainSubstring("invalid Tracing endpoint")))
+		_, err = tracingSettingsOf(&config.AgentConfig{Tracing: &config.TracingConfig{
+			Endpoint: "https://otel.example.com/otlp/v1/traces",
+			Timeout:  &metav1.Duration{Duration: 2 * time.Minute},
+		}})
+		Expect(err).To(MatchError(ContainSubstring("invalid Tracing timeout")))
+	})
+
+	Describe("export", func() {
+		var (
+			s      *server
+			lock   sync.Mutex
+			bodies [][]byte
+			status int
+			obs    *nwpd.Observation
+		)
+
+		received := func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(bodies)
+		}
+
+		BeforeEach(func() {
+			bodies = nil
+			status = http.StatusOK
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.URL.Path).To(Equal("/v1/traces"))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/x-protobuf"))
+				body, err := io.ReadAll(r.Body)
+				Expect(err).To(BeNil())
+				lock.Lock()
+				bodies = append(bodies, body)
+				lock.Unlock()
+				w.WriteHeader(status)
+			}))
+			DeferCleanup(collector.Close)
+
+			var err error
+			s, err = newServer(logrus.NewEntry(logrus.StandardLogger()), "", "", false, "")
+			Expect(err).To(BeNil())
+			s.nodeName = "node1"
+			settings, err := tracingSettingsOf(&config.AgentConfig{Tracing: &config.TracingConfig{Endpoint: collector.URL}})
+			Expect(err).To(BeNil())
+			s.applyTracing(settings)
+			DeferCleanup(func() { s.applyTracing(nil) })
+
+			obs = &nwpd.Observation{
+				JobID:      "tcp-n2n",
+				SrcHost:    "node1",
+				DestHost:   "node2",
+				Timestamp:  timestamppb.New(time.Now().Add(-time.Second)),
+				Duration:   durationpb.New(250 * time.Millisecond),
+				Ok:         false,
+				Result:     "error: connection refused",
+				IncidentID: "01HINCIDENT",
+			}
+		})
+
+		It("exports a span per processed probe", func() {
+			s.tracer.Load().startSpan(obs)
+			s.processObservation(obs)
+			s.sendTracesIfDue(time.Now())
+			Eventually(received).Should(Equal(1))
+
+			request := protoFieldsOf(bodies[0])
+			resourceSpans := protoFieldsOf(request[1][0].bytes)
+			resource := otlpAttributesOf(protoFieldsOf(resourceSpans[1][0].bytes)[1])
+			Expect(resource).To(HaveKeyWithValue("service.name", tracingServiceName))
+			Expect(resource).To(HaveKeyWithValue("host.name", "node1"))
+			scopeSpans := protoFieldsOf(resourceSpans[2][0].bytes)
+			Expect(scopeSpans[2]).To(HaveLen(1))
+
+			span := protoFieldsOf(scopeSpans[2][0].bytes)
+			Expect(span[1][0].bytes).To(HaveLen(16))
+			Expect(span[2][0].bytes).To(HaveLen(8))
+			Expect(string(span[5][0].bytes)).To(Equal("probe tcp-n2n"))
+			start := obs.Timestamp.AsTime().UnixNano()
+			Expect(span[7][0].value).To(Equal(uint64(start)))                               // #nosec G115 -- test only
+			Expect(span[8][0].value).To(Equal(uint64(start + int64(250*time.Millisecond)))) // #nosec G115 -- test only
+			Expect(otlpAttributesOf(span[9])).To(Equal(map[string]any{
+				"nwpd.jobid":       "tcp-n2n",
+				"nwpd.src":         "node1",
+				"nwpd.dest":        "node2",
+				"nwpd.ok":          false,
+				"nwpd.duration_ms": 250.0,
+				"nwpd.incident_id": "01HINCIDENT",
+			}))
+			spanStatus := protoFieldsOf(span[15][0].bytes)
+			Expect(string(spanStatus[2][0].bytes)).To(Equal("error: connection refused"))
+			Expect(spanStatus[3][0].value).To(Equal(uint64(otlpStatusCodeError)))
+
+			// nothing queued
+			s.lastTraceExport = time.Time{}
+			s.sendTracesIfDue(time.Now())
+			Consistently(received, 100*time.Millisecond).Should(Equal(1))
+		})
+
+		It("drops the spans of failed exports and of unprocessed observations", func() {
+			status = http.StatusServiceUnavailable
+			dropped := testutil.ToFloat64(DroppedSpans)
+			t := s.tracer.Load()
+			t.startSpan(obs)
+			s.processObservation(obs)
+			s.sendTracesIfDue(time.Now())
+			Eventually(func() float64 { return testutil.ToFloat64(DroppedSpans) }).Should(Equal(dropped + 1))
+
+			// the observation has been dropped by the backpressure
+			t.startSpan(&nwpd.Observation{JobID: "tcp-n2n", Timestamp: timestamppb.Now()})
+			Expect(t.takeSpans(time.Now())).To(BeEmpty())
+			Expect(t.takeSpans(time.Now().Add(2 * maxPendingSpanAge))).To(BeEmpty())
+			Expect(testutil.ToFloat64(DroppedSpans)).To(Equal(dropped + 2))
+		})
+
+		It("keeps the tracer for unchanged settings and removes it if disabled", func() {
+			t := s.tracer.Load()
+			settings := t.settings
+			s.applyTracing(&settings)
+			Expect(s.tracer.Load()).To(BeIdenticalTo(t))
+
+			s.applyTracing(nil)
+			Expect(s.tracer.Load()).To(BeNil())
+			s.processObservation(obs)
+			s.sendTracesIfDue(time.Now())
+			Consistently(received, 100*time.Millisecond).Should(Equal(0))
+		})
+	})
+})
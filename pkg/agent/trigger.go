@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/proto"
+)
+
+// minManualTriggerInterval is the minimum time between two manual triggers of the same job.
+const minManualTriggerInterval = 10 * time.Second
+
+// TriggerJob runs a job immediately for all or the selected destinations and returns the observations.
+// The observations are processed like the ones of scheduled runs.
+func (s *server) TriggerJob(ctx context.Context, request *nwpd.TriggerJobRequest) (*nwpd.TriggerJobResponse, error) {
+	s.lock.Lock()
+	job := s.jobs[request.JobID]
+	if job == nil {
+		s.lock.Unlock()
+		return nil, twirp.NotFoundError(fmt.Sprintf("unknown job %q", request.JobID))
+	}
+	now := time.Now()
+	if last, ok := s.manualTriggers[request.JobID]; ok && now.Sub(last) < minManualTriggerInterval {
+		s.lock.Unlock()
+		return nil, twirp.NewError(twirp.ResourceExhausted, fmt.Sprintf("job %s was triggered less than %s ago", request.JobID, minManualTriggerInterval))
+	}
+	if s.manualTriggers == nil {
+		s.manualTriggers = map[string]time.Time{}
+	}
+	s.manualTriggers[request.JobID] = now
+	limiter := s.limiter
+	s.lock.Unlock()
+
+	// a manual run counts against the maximum number of simultaneously running jobs like a scheduled one
+	if limiter != nil {
+		if err := limiter.AcquireContext(ctx); err != nil {
+			return nil, err
+		}
+		defer limiter.Release()
+	}
+
+	ch := make(chan *nwpd.Observation)
+	var (
+		count  int
+		runErr error
+	)
+	go func() {
+		defer close(ch)
+		count, runErr = job.RunNow(s.nodeName, request.RestrictToDestHosts, ch)
+	}()
+
+	resp := &nwpd.TriggerJobResponse{}
+	for obs := range ch {
+		if s.obsChan != nil {
+			// the processing of the observation must not race with the serialization of the response
+			s.shared.Backpressure().Send(s.obsChan, proto.Clone(obs).(*nwpd.Observation))
+		}
+		resp.Observations = append(resp.Observations, obs)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if runErr != nil {
+		return nil, twirp.NewError(twirp.FailedPrecondition, runErr.Error())
+	}
+	if count == 0 && len(request.RestrictToDestHosts) > 0 {
+		return nil, twirp.InvalidArgumentError("restrictToDestHosts", "no destination of the job matches")
+	}
+	return resp, nil
+}
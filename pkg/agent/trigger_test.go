@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("trigger", func() {
+	var (
+		s        *server
+		listener net.Listener
+	)
+
+	errorCodeOf := func(err error) twirp.ErrorCode {
+		var twerr twirp.Error
+		if errors.As(err, &twerr) {
+			return twerr.Code()
+		}
+		return twirp.NoError
+	}
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		port := listener.Addr().(*net.TCPAddr).Port
+
+		s = &server{
+			log:                logrus.NewEntry(logrus.StandardLogger()),
+			nodeName:           "node-a",
+			jobs:               map[jobid]*runners.InternalJob{},
+			currentAgentConfig: &config.AgentConfig{},
+		}
+		args := []string{"checkTCPPort", "--endpoints",
+			fmt.Sprintf("host-a:127.0.0.1:%d,host-b:127.0.0.1:%d", port, port)}
+		rconfig := runners.RunnerConfig{Job: config.Job{JobID: "tcp", Args: args}, Period: 10 * time.Second}
+		job, err := runners.Parse(config.ClusterConfig{}, rconfig, rconfig.Args, &config.SampleConfig{})
+		Expect(err).To(BeNil())
+		s.addOrReplaceJob(job)
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	It("runs the job for all destinations", func() {
+		resp, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(err).To(BeNil())
+		Expect(resp.Observations).To(HaveLen(2))
+		var destHosts []string
+		for _, obs := range resp.Observations {
+			Expect(obs.JobID).To(Equal("tcp"))
+			Expect(obs.SrcHost).To(Equal("node-a"))
+			Expect(obs.Ok).To(BeTrue())
+			destHosts = append(destHosts, obs.DestHost)
+		}
+		Expect(destHosts).To(ConsistOf("host-a", "host-b"))
+	})
+
+	It("restricts the run to the given destinations", func() {
+		resp, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp", RestrictToDestHosts: []string{"host-b"}})
+		Expect(err).To(BeNil())
+		Expect(resp.Observations).To(HaveLen(1))
+		Expect(resp.Observations[0].DestHost).To(Equal("host-b"))
+	})
+
+	It("rejects unmatched destinations", func() {
+		_, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp", RestrictToDestHosts: []string{"host-c"}})
+		Expect(errorCodeOf(err)).To(Equal(twirp.InvalidArgument))
+	})
+
+	It("rejects unknown jobs", func() {
+		_, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "unknown"})
+		Expect(errorCodeOf(err)).To(Equal(twirp.NotFound))
+	})
+
+	It("limits the rate of manual triggers per job", func() {
+		_, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(err).To(BeNil())
+		_, err = s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(errorCodeOf(err)).To(Equal(twirp.ResourceExhausted))
+
+		s.manualTriggers["tcp"] = time.Now().Add(-minManualTriggerInterval)
+		_, err = s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(err).To(BeNil())
+	})
+
+	It("waits for a free slot of the job limiter", func() {
+		s.limiter = runners.NewLimiter(1, nil)
+		Expect(s.limiter.TryAcquire()).To(BeTrue())
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := s.TriggerJob(ctx, &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		s.limiter.Release()
+		delete(s.manualTriggers, "tcp")
+		_, err = s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(err).To(BeNil())
+		Expect(s.limiter.TryAcquire()).To(BeTrue())
+	})
+
+	It("forwards copies of the observations without blocking on a full channel", func() {
+		s.obsChan = make(chan *nwpd.Observation, 1)
+		s.shared = runners.NewShared(nil, nil)
+		s.shared.SetBackpressure(&runners.Backpressure{})
+		resp, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "tcp"})
+		Expect(err).To(BeNil())
+		Expect(resp.Observations).To(HaveLen(2))
+		Expect(s.obsChan).To(HaveLen(1))
+		forwarded := <-s.obsChan
+		Expect(forwarded).NotTo(BeIdenticalTo(resp.Observations[0]))
+		Expect(forwarded).NotTo(BeIdenticalTo(resp.Observations[1]))
+		Expect(proto.Equal(forwarded, resp.Observations[0]) || proto.Equal(forwarded, resp.Observations[1])).To(BeTrue())
+	})
+
+	It("rejects jobs without on-demand support", func() {
+		r := &blockingRunner{config: runners.RunnerConfig{Job: config.Job{JobID: "blocking"}, Period: time.Second}}
+		s.jobs["blocking"] = runners.NewInternalJob(r, 0)
+		_, err := s.TriggerJob(context.Background(), &nwpd.TriggerJobRequest{JobID: "blocking"})
+		Expect(errorCodeOf(err)).To(Equal(twirp.FailedPrecondition))
+	})
+})
@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/filter"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/twitchtv/twirp"
+)
+
+const (
+	// watchBufferSize is the number of observations buffered for a subscriber before further observations are dropped.
+	watchBufferSize = 256
+	// maxWatchSubscribers is the maximum number of concurrent subscribers of the live observation feed.
+	maxWatchSubscribers = 16
+)
+
+// watchSubscription receives the matching observations published to the hub.
+type watchSubscription struct {
+	match   func(obs *nwpd.Observation) bool
+	ch      chan *nwpd.Observation
+	dropped atomic.Int64
+}
+
+// observationHub fans out the processed observations to the subscribers of the live observation feed.
+// Publishing never blocks: if the buffer of a subscriber is full, the observation is dropped for it.
+type observationHub struct {
+	lock        sync.RWMutex
+	subscribers map[*watchSubscription]struct{}
+	closed      bool
+}
+
+func newObservationHub() *observationHub {
+	return &observationHub{subscribers: map[*watchSubscription]struct{}{}}
+}
+
+// subscribe adds a subscriber for the observations selected by match.
+func (h *observationHub) subscribe(match func(obs *nwpd.Observation) bool) (*watchSubscription, error) {
+	if h == nil {
+		return nil, twirp.Unavailable.Error("live observation feed not available")
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.closed {
+		return nil, twirp.Unavailable.Error("agent is stopping")
+	}
+	if len(h.subscribers) >= maxWatchSubscribers {
+		return nil, twirp.ResourceExhausted.Errorf("too many subscribers, maximum is %d", maxWatchSubscribers)
+	}
+	sub := &watchSubscription{match: match, ch: make(chan *nwpd.Observation, watchBufferSize)}
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// unsubscribe removes the subscriber. Its channel is closed unless the hub has already been closed.
+func (h *observationHub) unsubscribe(sub *watchSubscription) {
+	if h == nil || sub == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// publish passes the observation to all subscribers it matches.
+func (h *observationHub) publish(obs *nwpd.Observation) {
+	if h == nil {
+		return
+	}
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	for sub := range h.subscribers {
+		if !sub.match(obs) {
+			continue
+		}
+		select {
+		case sub.ch <- obs:
+		default:
+			sub.dropped.Add(1)
+			WatchDroppedObservations.Inc()
+		}
+	}
+}
+
+// close ends all subscriptions, further subscriptions are rejected.
+func (h *observationHub) close() {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// watchMatcherOf returns the function selecting the watched observations of the request.
+// The time range is ignored, as only the observations processed from now on are watched.
+func watchMatcherOf(request *nwpd.GetObservationsRequest) (func(obs *nwpd.Observation) bool, error) {
+	if (request.SortBy != "" && request.SortBy != nwpd.SortByTimestamp) || request.SortDescending {
+		return nil, twirp.InvalidArgumentError("sortBy", "not supported for watched observations")
+	}
+	if request.PageToken != "" {
+		return nil, twirp.InvalidArgumentError("pageToken", "not supported for watched observations")
+	}
+	if request.OnlyFailureStreaksOfAtLeast != 0 {
+		return nil, twirp.InvalidArgumentError("onlyFailureStreaksOfAtLeast", "not supported for watched observations")
+	}
+	options, _, err := listOptionsOf(request)
+	if err != nil {
+		return nil, err
+	}
+	if request.Filter != "" {
+		// the filter time budget of the list requests does not apply to a long-lived subscription
+		expr, err := filter.Parse(request.Filter)
+		if err != nil {
+			return nil, twirp.InvalidArgumentError("filter", err.Error())
+		}
+		options.Filter = expr.Match
+	}
+	match, err := db.NewObservationMatcher(options)
+	if err != nil {
+		return nil, listError(err, func() bool { return false })
+	}
+	return match, nil
+}
+
+// WatchObservations sends the observations processed by the agent from now on that match the filters of the request
+// until the context is done, the limit is reached, or the agent stops. The callback subscribed is called once after
+// the subscription was successful. Observations are dropped if the sender cannot keep up, the number of dropped
+// observations is returned.
+func (s *server) WatchObservations(ctx context.Context, request *nwpd.GetObservationsRequest, subscribed func(), send func(obs *nwpd.Observation) error) (int64, error) {
+	match, err := watchMatcherOf(request)
+	if err != nil {
+		return 0, err
+	}
+	sub, err := s.watchers.subscribe(match)
+	if err != nil {
+		return 0, err
+	}
+	defer s.watchers.unsubscribe(sub)
+	if subscribed != nil {
+		subscribed()
+	}
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return sub.dropped.Load(), nil
+		case obs, ok := <-sub.ch:
+			if !ok {
+				return sub.dropped.Load(), nil
+			}
+			if err := send(obs); err != nil {
+				return sub.dropped.Load(), err
+			}
+			count++
+			if request.Limit > 0 && count >= int(request.Limit) {
+				return sub.dropped.Load(), nil
+			}
+		}
+	}
+}
+
+// handleWatchObservations streams the observations processed by the agent from now on as newline-delimited JSON or
+// as CSV with the query parameter `format=csv`. The optional request body is a JSON encoded `GetObservationsRequest`
+// to filter the observations. The number of dropped observations is sent in the trailer `X-Nwpd-Dropped-Observations`.
+func (s *server) handleWatchObservations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = db.ExportFormatJSON
+	}
+	enc, err := db.NewEncoder(w, format, runners.ResultFieldNames)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request, err := readObservationsRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	started := false
+	flush := func() error {
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	}
+	count := 0
+	dropped, err := s.WatchObservations(r.Context(), request, func() {
+		// the timeouts of the http server must not end the stream
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+		started = true
+		w.Header().Set("Content-Type", enc.ContentType())
+		w.Header().Set("Trailer", common.HeaderDroppedObservations)
+		w.WriteHeader(http.StatusOK)
+		_ = flush()
+	}, func(obs *nwpd.Observation) error {
+		count++
+		if err := enc.Encode(obs); err != nil {
+			return err
+		}
+		return flush()
+	})
+	if err != nil && !started {
+		writeExportError(w, err)
+		return
+	}
+	if err != nil {
+		s.log.Warnf("watching observations failed after %d observations: %s", count, err)
+	}
+	if dropped > 0 {
+		s.log.Infof("watch client too slow, %d of %d observations dropped", dropped, dropped+int64(count))
+	}
+	w.Header().Set(common.HeaderDroppedObservations, strconv.FormatInt(dropped, 10))
+	if err := enc.Flush(); err != nil {
+		s.log.Warnf("watching observations failed: %s", err)
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("observation hub", func() {
+	matchAll := func(_ *nwpd.Observation) bool { return true }
+	newObs := func(jobID string, ok bool) *nwpd.Observation {
+		return &nwpd.Observation{SrcHost: "node1", DestHost: "node2", JobID: jobID, Ok: ok, Timestamp: timestamppb.Now()}
+	}
+
+	It("drops observations for a subscriber with a full buffer", func() {
+		hub := newObservationHub()
+		slow, err := hub.subscribe(matchAll)
+		Expect(err).To(BeNil())
+		failures, err := hub.subscribe(func(obs *nwpd.Observation) bool { return !obs.Ok })
+		Expect(err).To(BeNil())
+
+		before := testutil.ToFloat64(WatchDroppedObservations)
+		for i := 0; i < watchBufferSize+3; i++ {
+			hub.publish(newObs("ping", i%2 == 0))
+		}
+		Expect(slow.ch).To(HaveLen(watchBufferSize))
+		Expect(slow.dropped.Load()).To(Equal(int64(3)))
+		Expect(failures.ch).To(HaveLen((watchBufferSize + 3) / 2))
+		Expect(failures.dropped.Load()).To(BeZero())
+		Expect(testutil.ToFloat64(WatchDroppedObservations) - before).To(Equal(3.0))
+	})
+
+	It("closes the channel on unsubscribe and close", func() {
+		hub := newObservationHub()
+		sub1, err := hub.subscribe(matchAll)
+		Expect(err).To(BeNil())
+		sub2, err := hub.subscribe(matchAll)
+		Expect(err).To(BeNil())
+
+		hub.unsubscribe(sub1)
+		hub.unsubscribe(sub1)
+		Expect(sub1.ch).To(BeClosed())
+		hub.publish(newObs("ping", true))
+		Expect(sub2.ch).To(HaveLen(1))
+
+		hub.close()
+		hub.close()
+		Expect(<-sub2.ch).NotTo(BeNil())
+		Expect(sub2.ch).To(BeClosed())
+		hub.unsubscribe(sub2)
+		hub.publish(newObs("ping", true))
+		_, err = hub.subscribe(matchAll)
+		Expect(err).To(MatchError(ContainSubstring("agent is stopping")))
+	})
+
+	It("limits the number of subscribers", func() {
+		hub := newObservationHub()
+		for i := 0; i < maxWatchSubscribers; i++ {
+			_, err := hub.subscribe(matchAll)
+			Expect(err).To(BeNil())
+		}
+		_, err := hub.subscribe(matchAll)
+		Expect(err).To(MatchError(ContainSubstring("too many subscribers")))
+	})
+
+	It("is safe to use if not set", func() {
+		var hub *observationHub
+		hub.publish(newObs("ping", true))
+		hub.unsubscribe(nil)
+		hub.close()
+		_, err := hub.subscribe(matchAll)
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("watch", func() {
+	var (
+		s  *server
+		ts *httptest.Server
+	)
+
+	BeforeEach(func() {
+		s = &server{log: logrus.NewEntry(logrus.StandardLogger()), watchers: newObservationHub()}
+		ts = httptest.NewServer(http.HandlerFunc(s.handleWatchObservations))
+		DeferCleanup(ts.Close)
+	})
+
+	subscribers := func() int {
+		s.watchers.lock.RLock()
+		defer s.watchers.lock.RUnlock()
+		return len(s.watchers.subscribers)
+	}
+
+	watch := func(body string) (*http.Response, error) {
+		return ts.Client().Post(ts.URL+common.PathWatchObservations, "application/json", strings.NewReader(body))
+	}
+
+	It("streams the matching observations processed from now on", func() {
+		resp, err := watch(`{"restrictToJobIDs":["ping"],"filter":"!ok","limit":2}`)
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+		Expect(subscribers()).To(Equal(1))
+
+		for _, obs := range []*nwpd.Observation{
+			{SrcHost: "node1", DestHost: "node2", JobID: "ping", Ok: true, Timestamp: timestamppb.New(time.Unix(1000, 0))},
+			{SrcHost: "node1", DestHost: "node2", JobID: "tcp", Timestamp: timestamppb.New(time.Unix(1001, 0))},
+			{SrcHost: "node1", DestHost: "node2", JobID: "ping", Timestamp: timestamppb.New(time.Unix(1002, 0)), Result: "timeout"},
+			{SrcHost: "node1", DestHost: "node3", JobID: "ping", Timestamp: timestamppb.New(time.Unix(1003, 0)), Result: "timeout"},
+		} {
+			s.watchers.publish(obs)
+		}
+		data, err := io.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(HaveLen(2))
+		var obs map[string]any
+		Expect(json.Unmarshal([]byte(lines[1]), &obs)).To(Succeed())
+		Expect(obs).To(HaveKeyWithValue("destHost", "node3"))
+		Expect(resp.Trailer.Get(common.HeaderDroppedObservations)).To(Equal("0"))
+		Eventually(subscribers).Should(BeZero())
+	})
+
+	It("ends the stream when the agent stops", func() {
+		resp, err := watch("")
+		Expect(err).To(BeNil())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		s.watchers.close()
+		data, err := io.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(data).To(BeEmpty())
+	})
+
+	It("rejects invalid requests", func() {
+		for body, status := range map[string]int{
+			`{"filter":"duration > fast"}`:      http.StatusBadRequest,
+			`{"jobIDRegex":"("}`:                http.StatusBadRequest,
+			`{"sortBy":"duration"}`:             http.StatusBadRequest,
+			`{"onlyFailureStreaksOfAtLeast":3}`: http.StatusBadRequest,
+			`{"limit":"x"}`:                     http.StatusBadRequest,
+		} {
+			resp, err := watch(body)
+			Expect(err).To(BeNil())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(status), body)
+		}
+		s.watchers = nil
+		resp, err := watch("")
+		Expect(err).To(BeNil())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	})
+})
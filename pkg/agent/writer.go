@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+// writerSettings are the settings of the observation writer and the rollup store which can only be changed by
+// replacing them. The zero value disables the writer.
+type writerSettings struct {
+	outputDir           string
+	prefix              string
+	retentionHours      int
+	compress            bool
+	compressRotated     bool
+	rollupRetentionDays int
+}
+
+// writerSettingsOf returns the writer settings of the daemon set with the expanded file prefix.
+func (s *server) writerSettingsOf(cfg *config.AgentConfig) (writerSettings, error) {
+	if cfg.OutputDir == "" {
+		return writerSettings{}, nil
+	}
+	prefix, err := dataFilePrefixOf(s.getNetworkCfgOf(cfg))
+	if err != nil {
+		return writerSettings{}, err
+	}
+	return writerSettings{
+		outputDir:           cfg.OutputDir,
+		prefix:              prefix,
+		retentionHours:      cfg.RetentionHours,
+		compress:            cfg.CompressData,
+		compressRotated:     cfg.CompressRotatedData,
+		rollupRetentionDays: cfg.RollupRetentionDays,
+	}, nil
+}
+
+// incidentFile returns the file for persisting the incidents, empty if the writer is disabled.
+func (ws writerSettings) incidentFile() string {
+	if ws.outputDir == "" {
+		return ""
+	}
+	return db.IncidentFilename(ws.outputDir, ws.prefix)
+}
+
+// applyWriterSettings replaces the writer and the rollup store if their settings have changed, and applies the
+// settings which can be changed at runtime. The old writer is stopped after its buffered records have been written.
+// The records processed meanwhile wait for the writer lock, so that none of them is lost on the handover.
+func (s *server) applyWriterSettings(settings writerSettings, maxDiskUsage int64, t timing) error {
+	s.writerLock.Lock()
+	defer s.writerLock.Unlock()
+
+	changed := settings != s.writerSettings || (s.writer == nil && settings.outputDir != "")
+	if changed {
+		var (
+			writer  nwpd.ObservationWriter
+			rollups *db.RollupStore
+			err     error
+		)
+		if settings.outputDir != "" {
+			var options []db.ObsWriterOption
+			if settings.compressRotated {
+				options = append(options, db.CompressRotatedFiles())
+			}
+			writer, err = db.NewObsWriter(s.log.WithField("sub", "writer"), settings.outputDir, settings.prefix, settings.retentionHours,
+				settings.compress, options...)
+			if err != nil {
+				return err
+			}
+			rollups, err = db.NewRollupStore(s.log.WithField("sub", "rollups"), settings.outputDir, settings.prefix, s.nodeName,
+				settings.retentionHours, settings.rollupRetentionDays)
+			if err != nil {
+				return err
+			}
+		}
+		if s.writer != nil {
+			s.log.Infof("writer settings changed, replacing the observation writer of %s", s.writerSettings.outputDir)
+			s.stopWriterLocked()
+		}
+		s.writer, s.rollups, s.writerSettings = writer, rollups, settings
+	}
+	if s.writer != nil {
+		s.writer.SetMaxDiskUsage(maxDiskUsage)
+		s.writer.SetWriteBatching(t.writeFlushInterval, t.writeBatchSize)
+	}
+	if changed && s.writerStarted {
+		s.runWriterLocked()
+	}
+	return nil
+}
+
+// getWriter returns the current writer, nil if the observations are not stored.
+func (s *server) getWriter() nwpd.ObservationWriter {
+	s.writerLock.RLock()
+	defer s.writerLock.RUnlock()
+	return s.writer
+}
+
+// getRollups returns the current rollup store, nil if the observations are not stored.
+func (s *server) getRollups() *db.RollupStore {
+	s.writerLock.RLock()
+	defer s.writerLock.RUnlock()
+	return s.rollups
+}
+
+// startWriter runs the observation writer and its replacements until it is stopped.
+func (s *server) startWriter() {
+	s.writerLock.Lock()
+	defer s.writerLock.Unlock()
+	s.writerStarted = true
+	s.runWriterLocked()
+}
+
+// stopWriter stops the running writer after the buffered records have been written.
+func (s *server) stopWriter() {
+	s.writerLock.Lock()
+	defer s.writerLock.Unlock()
+	s.stopWriterLocked()
+	s.writerStarted = false
+	s.writer = nil
+}
+
+// runWriterLocked runs the current writer. It must be called with the writer lock held.
+func (s *server) runWriterLocked() {
+	if s.writer == nil {
+		return
+	}
+	writer := s.writer
+	done := make(chan struct{})
+	s.writerDone = done
+	s.writerRunning.Store(true)
+	go func() {
+		defer close(done)
+		writer.Run()
+	}()
+}
+
+// stopWriterLocked stops the current writer if it is running and waits until it has returned.
+// It must be called with the writer lock held.
+func (s *server) stopWriterLocked() {
+	if s.writer == nil || s.writerDone == nil {
+		return
+	}
+	s.writer.Stop()
+	<-s.writerDone
+	s.writerDone = nil
+	s.writerRunning.Store(false)
+}
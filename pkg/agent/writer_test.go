@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("writer settings", func() {
+	var (
+		dir             string
+		agentConfigFile string
+	)
+
+	writeAgentConfig := func(outputDir string, retentionHours int, reportPeriod time.Duration) {
+		data, err := yaml.Marshal(&config.AgentConfig{
+			OutputDir:         outputDir,
+			RetentionHours:    retentionHours,
+			AggregationConfig: config.AggregationConfig{AggregationReportPeriod: &metav1.Duration{Duration: reportPeriod}},
+			Timing:            &config.TimingConfig{WriteFlushInterval: &metav1.Duration{Duration: 100 * time.Millisecond}},
+			PodNetwork:        &config.NetworkConfig{},
+		})
+		Expect(err).To(BeNil())
+		Expect(os.WriteFile(agentConfigFile, data, 0o600)).To(Succeed())
+	}
+
+	list := func(writer nwpd.ObservationWriter) nwpd.Observations {
+		result, err := writer.ListObservations(nwpd.ListObservationsOptions{Start: time.Now().Add(-time.Hour)})
+		Expect(err).To(BeNil())
+		return result
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		agentConfigFile = filepath.Join(dir, "agent-config.yaml")
+	})
+
+	It("replaces the writer on reload without losing observations", func() {
+		records := filepath.Join(dir, "records")
+		clusterConfigFile := filepath.Join(dir, "cluster-config.yaml")
+		writeAgentConfig(records, 48, time.Minute)
+		Expect(os.WriteFile(clusterConfigFile, []byte("{}"), 0o600)).To(Succeed())
+		Expect(os.MkdirAll(records, 0o750)).To(Succeed())
+		// a record file within the retention of 48 hours, but outside of 24 hours
+		oldFile := filepath.Join(records, "agent-old"+db.RecordFileSuffix)
+		Expect(os.WriteFile(oldFile, nil, 0o600)).To(Succeed())
+		oldTime := time.Now().Add(-30 * time.Hour)
+		Expect(os.Chtimes(oldFile, oldTime, oldTime)).To(Succeed())
+
+		s, err := newServer(logrus.NewEntry(logrus.StandardLogger()), agentConfigFile, clusterConfigFile, false, config.EnvironmentStandalone)
+		Expect(err).To(BeNil())
+		s.logDirectory = filepath.Join(dir, "log")
+		Expect(s.setup()).To(Succeed())
+		aggregator := &recordingAggregator{}
+		s.aggregator = aggregator
+		first := s.getWriter()
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			defer GinkgoRecover()
+			Expect(s.run()).To(Succeed())
+		}()
+
+		const count = 200
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			for i := 0; i < count; i++ {
+				s.obsChan <- &nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: fmt.Sprintf("node-%d", i), Timestamp: timestamppb.Now(), Ok: true}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+
+		writeAgentConfig(records, 24, 2*time.Minute)
+		s.reloadConfig()
+		second := s.getWriter()
+		Expect(second).NotTo(BeIdenticalTo(first))
+		Expect(aggregator.reportPeriod).To(Equal(2 * time.Minute))
+		Expect(aggregator.incidentFile).To(Equal(db.IncidentFilename(records, "agent")))
+		Eventually(func() bool {
+			_, err := os.Stat(oldFile)
+			return os.IsNotExist(err)
+		}, 5*time.Second, 50*time.Millisecond).Should(BeTrue())
+
+		// unchanged settings keep the writer
+		s.reloadConfig()
+		Expect(s.getWriter()).To(BeIdenticalTo(second))
+
+		Eventually(sent, 5*time.Second).Should(BeClosed())
+		Eventually(func() int { return len(s.obsChan) }).Should(BeZero())
+		close(s.done)
+		Eventually(stopped, 10*time.Second).Should(BeClosed())
+		Expect(list(second)).To(HaveLen(count))
+	})
+
+	It("moves the records to a changed output directory and stops the writer if disabled", func() {
+		writeAgentConfig(filepath.Join(dir, "records"), 24, time.Minute)
+		s := &server{
+			log:        logrus.NewEntry(logrus.StandardLogger()),
+			jobs:       map[jobid]*runners.InternalJob{},
+			aggregator: &recordingAggregator{},
+		}
+		cfg, err := config.LoadAgentConfig(agentConfigFile)
+		Expect(err).To(BeNil())
+		Expect(s.applyAgentConfig(cfg)).To(Succeed())
+		s.startWriter()
+		first := s.getWriter()
+		s.processObservation(&nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: "node-b", Timestamp: timestamppb.Now(), Ok: true})
+
+		moved := filepath.Join(dir, "moved")
+		cfg.OutputDir = moved
+		Expect(s.applyAgentConfig(cfg)).To(Succeed())
+		second := s.getWriter()
+		Expect(second).NotTo(BeIdenticalTo(first))
+		Expect(s.writerRunning.Load()).To(BeTrue())
+		Expect(list(first)).To(HaveLen(1))
+		s.processObservation(&nwpd.Observation{JobID: "ping", SrcHost: "node-a", DestHost: "node-c", Timestamp: timestamppb.Now(), Ok: true})
+
+		cfg.OutputDir = ""
+		Expect(s.applyAgentConfig(cfg)).To(Succeed())
+		Expect(s.getWriter()).To(BeNil())
+		Expect(s.getRollups()).To(BeNil())
+		Expect(s.writerRunning.Load()).To(BeFalse())
+		observations := list(second)
+		Expect(observations).To(HaveLen(1))
+		Expect(observations[0].DestHost).To(Equal("node-c"))
+		s.stop()
+	})
+})
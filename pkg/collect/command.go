@@ -135,6 +135,12 @@ func (cc *collectCommand) loadFrom(log logrus.FieldLogger, dir string, pod *core
 		cc.failedNodes.Inc()
 		return
 	}
+	incidentFilenames, err := db.GetAnyIncidentFiles(dir, false)
+	if err != nil {
+		log.Errorf("listing temp dir %s failed: %s", dir, err)
+		cc.failedNodes.Inc()
+		return
+	}
 	if len(filenames) == 0 && stderr.Len() != 0 {
 		log.Errorf("execution with unexpected result: %s", stderr.String())
 		cc.failedNodes.Inc()
@@ -149,7 +155,7 @@ func (cc *collectCommand) loadFrom(log logrus.FieldLogger, dir string, pod *core
 	}
 	countBytes := 0
 	countFiles := 0
-	for _, filename := range filenames {
+	for _, filename := range append(filenames, incidentFilenames...) {
 		_, name := path.Split(filename)
 		destFilename := path.Join(outdir, name)
 		n, err := copyFile(filename, destFilename)
@@ -12,6 +12,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
 	"github.com/gardener/network-problem-detector/pkg/common"
 
 	"github.com/spf13/cobra"
@@ -47,7 +48,7 @@ func (cc *runCollectCommand) listFiles(dir string) ([]string, error) {
 	}
 	var filenames []string
 	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".records") {
+		if !file.IsDir() && (db.IsRecordFile(file.Name()) || strings.HasSuffix(file.Name(), db.IncidentFileSuffix)) {
 			filenames = append(filenames, file.Name())
 		}
 	}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agentclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PortForward is a 'kubectl port-forward' to an agent pod.
+type PortForward struct {
+	cmd       *exec.Cmd
+	port      int
+	tlsConfig *tls.Config
+	token     string
+}
+
+// StartPortForward starts a 'kubectl port-forward' to the HTTP port of the given agent pod.
+// If targetPort is 0, the default port of the daemon set is used. If TLS is enabled by the options, the agent service is accessed with TLS.
+// If an API token is configured by the token options, it is sent with all requests.
+func StartPortForward(log logrus.FieldLogger, kubeconfig, podname string, targetPort int, tlsOptions *TLSOptions, tokenOptions *TokenOptions) (*PortForward, error) {
+	tlsConfig, err := tlsOptions.Config()
+	if err != nil {
+		return nil, err
+	}
+	token, err := tokenOptions.Token(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	port := 18007
+	for !checkPortAvailable(port) {
+		port++
+	}
+	if targetPort == 0 {
+		if strings.HasPrefix(podname, common.NameDaemonSetAgentHostNet) {
+			targetPort = common.HostNetPodHTTPPort
+		} else {
+			targetPort = common.PodNetPodHTTPPort
+		}
+	}
+
+	kubeconfigOpt := ""
+	if kubeconfig != "" {
+		kubeconfigOpt = " --kubeconfig=" + kubeconfig
+	}
+
+	log.Infof("Connecting to pod %s", podname)
+	cmdline := fmt.Sprintf("kubectl %s -n kube-system  port-forward %s %d:%d", kubeconfigOpt, podname, port, targetPort)
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", cmdline)              //  #nosec G204 -- only used in interactive shell
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true} // create process group for child processes
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 20; i++ {
+		if !checkPortAvailable(port) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return &PortForward{cmd: cmd, port: port, tlsConfig: tlsConfig, token: token}, nil
+}
+
+// Client returns a client for the agent service using the port forward.
+func (pf *PortForward) Client() nwpd.AgentService {
+	return nwpd.NewAgentServiceProtobufClient(pf.BaseURL(), pf.HTTPClient())
+}
+
+// HTTPClient returns the HTTP client for requests to the base URL.
+func (pf *PortForward) HTTPClient() *http.Client {
+	var transport http.RoundTripper = http.DefaultTransport
+	if pf.tlsConfig != nil {
+		transport = &http.Transport{TLSClientConfig: pf.tlsConfig}
+	}
+	if pf.token != "" {
+		transport = &bearerTransport{token: pf.token, base: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// BaseURL returns the local base URL of the port forward.
+func (pf *PortForward) BaseURL() string {
+	if pf.tlsConfig != nil {
+		return fmt.Sprintf("https://localhost:%d", pf.port)
+	}
+	return fmt.Sprintf("http://localhost:%d", pf.port)
+}
+
+// Close stops the port forward.
+func (pf *PortForward) Close() {
+	_ = syscall.Kill(-pf.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+func checkPortAvailable(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = ln.Close()
+	return true
+}
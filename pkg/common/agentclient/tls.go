@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agentclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+// TLSOptions are the options for connecting to an agent service served with TLS.
+type TLSOptions struct {
+	// Enabled if true, the connection uses TLS. It is implied by the other options.
+	Enabled bool
+	// CAFile is the file with the CA certificates for verifying the agent certificate. If empty, the agent certificate is not verified.
+	CAFile string
+	// CertFile and KeyFile are the files with the client certificate and its key.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name for verifying the agent certificate, by default `localhost` of the port forward.
+	ServerName string
+}
+
+// AddFlags adds the flags of the options.
+func (o *TLSOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Enabled, "tls", false, "connect to the agent service with TLS (implied by the other '--tls-*' flags)")
+	flags.StringVar(&o.CAFile, "tls-ca-file", "", "CA certificates for verifying the agent certificate (not verified if not specified)")
+	flags.StringVar(&o.CertFile, "tls-cert-file", "", "client certificate, if the agent requires client certificates")
+	flags.StringVar(&o.KeyFile, "tls-key-file", "", "private key of the client certificate")
+	flags.StringVar(&o.ServerName, "tls-server-name", "", "server name for verifying the agent certificate (default 'localhost')")
+}
+
+func (o *TLSOptions) enabled() bool {
+	return o != nil && (o.Enabled || o.CAFile != "" || o.CertFile != "" || o.KeyFile != "" || o.ServerName != "")
+}
+
+// Config returns the client TLS configuration or nil if TLS is not enabled.
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	if !o.enabled() {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: o.ServerName,
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf("--tls-cert-file and --tls-key-file must both be set")
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate %s: %s", o.CertFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if o.CAFile != "" {
+		data, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+	} else {
+		// the agent is only reached through the port forward authenticated by the kube-apiserver
+		cfg.InsecureSkipVerify = true // #nosec G402 -- opt-in by omitting the CA file
+	}
+	return cfg, nil
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agentclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TokenOptions are the options for the API token required by the agent service.
+type TokenOptions struct {
+	// TokenFile is the file containing the token.
+	TokenFile string
+	// TokenSecret is the reference to the token in a Kubernetes secret in format `<namespace>/<name>#<key>`, read with the kubeconfig.
+	TokenSecret string
+}
+
+// AddFlags adds the flags of the options.
+func (o *TokenOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.TokenFile, "token-file", "", "file containing the API token, if the agent service requires a token")
+	flags.StringVar(&o.TokenSecret, "token-secret", "", "secret key containing the API token in format '<namespace>/<name>#<key>', e.g. 'kube-system/nwpd-api-token#token'")
+}
+
+// Token returns the API token or an empty string if no token is configured. The secret is read with the kubeconfig.
+func (o *TokenOptions) Token(kubeconfig string) (string, error) {
+	switch {
+	case o == nil || (o.TokenFile == "" && o.TokenSecret == ""):
+		return "", nil
+	case o.TokenFile != "" && o.TokenSecret != "":
+		return "", fmt.Errorf("only one of --token-file and --token-secret is allowed")
+	case o.TokenFile != "":
+		data, err := os.ReadFile(o.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("cannot read API token: %s", err)
+		}
+		return nonEmptyToken(string(data), o.TokenFile)
+	}
+	ref, err := config.ParseSecretRef(config.SecretRefPrefix + o.TokenSecret)
+	if err != nil {
+		return "", fmt.Errorf("invalid --token-secret %s, expected format <namespace>/<name>#<key>", o.TokenSecret)
+	}
+	base := common.ClientsetBase{Kubeconfig: kubeconfig}
+	if err := base.SetupClientSet(); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	secret, err := base.Clientset.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("cannot read API token: %s", err)
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("cannot read API token: key %s not found in secret %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+	return nonEmptyToken(string(data), o.TokenSecret)
+}
+
+func nonEmptyToken(value, source string) (string, error) {
+	token := strings.TrimSpace(value)
+	if token == "" {
+		return "", fmt.Errorf("API token %s is empty", source)
+	}
+	return token, nil
+}
+
+// bearerTransport adds the API token to all requests.
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package backoff provides an exponential backoff with jitter, and a tracker of the backoff state per key.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Policy defines the delays of an exponential backoff.
+type Policy struct {
+	// Initial is the delay after the first failure.
+	Initial time.Duration
+	// Max caps the delay.
+	Max time.Duration
+	// Factor is the growth of the delay per additional failure. Values < 1 are handled as 1.
+	Factor float64
+	// Jitter is the maximum random reduction of the delay as fraction of the delay. Valid range: [0.0,1.0)
+	Jitter float64
+}
+
+// Base returns the delay after the given number of consecutive failures without jitter.
+func (p Policy) Base(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	factor := math.Max(p.Factor, 1)
+	d := float64(p.Initial) * math.Pow(factor, float64(failures-1))
+	if p.Max > 0 && d > float64(p.Max) {
+		return p.Max
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// Delay returns the delay after the given number of consecutive failures.
+// The jitter reduces the base delay by a random fraction in the range [0, Jitter) given by rnd, which must return values in [0.0,1.0).
+// So the delay never exceeds the cap.
+func (p Policy) Delay(failures int, rnd func() float64) time.Duration {
+	d := p.Base(failures)
+	if p.Jitter <= 0 || rnd == nil {
+		return d
+	}
+	return d - time.Duration(p.Jitter*rnd()*float64(d))
+}
+
+// State is the backoff state of a key.
+type State struct {
+	Key string
+	// Failures is the number of consecutive failures.
+	Failures int
+	// Until is the end of the current delay.
+	Until time.Time
+}
+
+// Tracker tracks the backoff state per key. It is safe for concurrent use.
+type Tracker struct {
+	policy Policy
+	rnd    func() float64
+
+	lock   sync.Mutex
+	states map[string]*State
+}
+
+// NewTracker creates a tracker for the policy.
+func NewTracker(policy Policy) *Tracker {
+	return &Tracker{
+		policy: policy,
+		rnd:    rand.Float64, // #nosec G404 -- no cryptographic use
+		states: map[string]*State{},
+	}
+}
+
+// Policy returns the policy of the tracker.
+func (t *Tracker) Policy() Policy {
+	return t.policy
+}
+
+// Allowed returns false while the key is in a backoff delay.
+func (t *Tracker) Allowed(key string, now time.Time) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s := t.states[key]
+	return s == nil || !now.Before(s.Until)
+}
+
+// Failure records a failure of the key and starts the next delay. It returns the new state.
+func (t *Tracker) Failure(key string, now time.Time) State {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s := t.states[key]
+	if s == nil {
+		s = &State{Key: key}
+		t.states[key] = s
+	}
+	s.Failures++
+	s.Until = now.Add(t.policy.Delay(s.Failures, t.rnd))
+	return *s
+}
+
+// Success resets the backoff state of the key.
+func (t *Tracker) Success(key string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.states, key)
+}
+
+// Len returns the number of keys with consecutive failures.
+func (t *Tracker) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.states)
+}
+
+// States returns the backoff states of all keys with consecutive failures sorted by key.
+func (t *Tracker) States() []State {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	states := make([]State, 0, len(t.states))
+	for _, s := range t.states {
+		states = append(states, *s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+	return states
+}
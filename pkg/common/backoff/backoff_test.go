@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backoff
+
+import (
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("backoff", func() {
+	policy := Policy{Initial: time.Second, Max: 10 * time.Second, Factor: 2, Jitter: 0.2}
+
+	DescribeTable("grows exponentially up to the cap",
+		func(failures int, expected time.Duration) {
+			Expect(policy.Base(failures)).To(Equal(expected))
+			Expect(policy.Delay(failures, nil)).To(Equal(expected))
+		},
+		Entry("no failure", 0, time.Duration(0)),
+		Entry("first failure", 1, time.Second),
+		Entry("second failure", 2, 2*time.Second),
+		Entry("fourth failure", 4, 8*time.Second),
+		Entry("capped", 5, 10*time.Second),
+		Entry("capped without overflow", 1000, 10*time.Second),
+	)
+
+	It("handles factors below 1 as constant delay", func() {
+		p := Policy{Initial: time.Second, Factor: 0.5}
+		Expect(p.Base(3)).To(Equal(time.Second))
+	})
+
+	It("keeps the jitter within bounds", func() {
+		rnd := rand.New(rand.NewSource(1)) // #nosec G404 -- no cryptographic use
+		for failures := 1; failures <= 6; failures++ {
+			base := policy.Base(failures)
+			minDelay := base - time.Duration(policy.Jitter*float64(base))
+			for i := 0; i < 100; i++ {
+				d := policy.Delay(failures, rnd.Float64)
+				Expect(d).To(BeNumerically(">=", minDelay))
+				Expect(d).To(BeNumerically("<=", base))
+			}
+		}
+		Expect(policy.Delay(1, func() float64 { return 0 })).To(Equal(time.Second))
+		Expect(policy.Delay(1, func() float64 { return 0.5 })).To(Equal(900 * time.Millisecond))
+	})
+
+	Describe("tracker", func() {
+		var (
+			tracker *Tracker
+			now     time.Time
+		)
+
+		BeforeEach(func() {
+			tracker = NewTracker(policy)
+			tracker.rnd = func() float64 { return 0 }
+			now = time.Now()
+		})
+
+		It("delays a key after failures", func() {
+			Expect(tracker.Allowed("a", now)).To(BeTrue())
+			s := tracker.Failure("a", now)
+			Expect(s).To(Equal(State{Key: "a", Failures: 1, Until: now.Add(time.Second)}))
+			Expect(tracker.Allowed("a", now.Add(999*time.Millisecond))).To(BeFalse())
+			Expect(tracker.Allowed("a", now.Add(time.Second))).To(BeTrue())
+			Expect(tracker.Allowed("b", now)).To(BeTrue())
+
+			s = tracker.Failure("a", now.Add(time.Second))
+			Expect(s.Failures).To(Equal(2))
+			Expect(s.Until).To(Equal(now.Add(3 * time.Second)))
+		})
+
+		It("resets on success", func() {
+			tracker.Failure("a", now)
+			tracker.Failure("a", now)
+			tracker.Failure("b", now)
+			Expect(tracker.Len()).To(Equal(2))
+			tracker.Success("a")
+			Expect(tracker.Allowed("a", now)).To(BeTrue())
+			Expect(tracker.States()).To(Equal([]State{{Key: "b", Failures: 1, Until: now.Add(time.Second)}}))
+
+			// the delay starts again with the initial delay
+			Expect(tracker.Failure("a", now).Until).To(Equal(now.Add(time.Second)))
+			Expect(tracker.States()[0].Key).To(Equal("a"))
+		})
+	})
+})
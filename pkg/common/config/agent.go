@@ -10,21 +10,113 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// EnvironmentKubernetes is the environment of an agent running in a pod of a Kubernetes cluster.
+	EnvironmentKubernetes = "kubernetes"
+	// EnvironmentStandalone is the environment of an agent running outside of Kubernetes, e.g. on a VM.
+	EnvironmentStandalone = "standalone"
+
+	// ReportFormatText is the format of the human-oriented aggregation report.
+	ReportFormatText = "text"
+	// ReportFormatJSON is the format of the aggregation report with a JSON object per edge and report period.
+	ReportFormatJSON = "json"
+
+	// AggregationOutputFilePrefix is the prefix of the aggregation output to the report files in a directory, e.g. `file:/var/log/nwpd`.
+	AggregationOutputFilePrefix = "file:"
+	// AggregationOutputStdout is the aggregation output to stdout, e.g. for log collection.
+	AggregationOutputStdout = "stdout"
+	// AggregationOutputNone is the aggregation output if the report is only logged.
+	AggregationOutputNone = "none"
+
+	// RemoteSinkFormatJSON is the format of the remote sink with newline-delimited JSON observations.
+	RemoteSinkFormatJSON = "json"
+	// RemoteSinkFormatOTLP is the format of the remote sink with an OTLP log record per observation.
+	RemoteSinkFormatOTLP = "otlp"
+
+	// ObservationOverflowPolicyBlock lets a job run wait at most the observation send timeout if the observation buffer is full.
+	ObservationOverflowPolicyBlock = "block"
+	// ObservationOverflowPolicyDrop drops the observations of a job run immediately if the observation buffer is full.
+	ObservationOverflowPolicyDrop = "drop"
+
+	// PlaceholderPodIP is replaced by the IP of the agent pod in the bind address of the http server.
+	PlaceholderPodIP = "{podIP}"
+)
+
 type AgentConfig struct {
+	// Environment is either `kubernetes` or `standalone`. If not set, it is detected on agent start.
+	// In the standalone environment, all features depending on Kubernetes are disabled. It is only applied on agent start.
+	Environment string `json:"environment,omitempty"`
 	// OutputDir is the directory to store the observations.
 	OutputDir string `json:"outputDir,omitempty"`
 	// RetentionHours defines how many hours to keep old observations.
 	RetentionHours int `json:"retentionHours,omitempty"`
+	// MaxDiskUsageMegabytes is the maximum total size of the observation record files (0 means no limit).
+	// If exceeded, the oldest record files are deleted even within the retention. The current file is never deleted.
+	MaxDiskUsageMegabytes int `json:"maxDiskUsageMegabytes,omitempty"`
+	// CompressData if true, new observation record files are gzip compressed. Uncompressed files written before stay readable.
+	// It is only applied on agent start.
+	CompressData bool `json:"compressData,omitempty"`
+	// CompressRotatedData if true, the uncompressed observation record files are gzip compressed after the hour they have been written.
+	// In contrast to CompressData, the current file is written uncompressed. It is only applied on agent start.
+	CompressRotatedData bool `json:"compressRotatedData,omitempty"`
+	// RollupRetentionDays defines how many days to keep the daily rollups of the observations (default 400 days).
+	RollupRetentionDays int `json:"rollupRetentionDays,omitempty"`
 	// LogObservations defines if observations should be logged additionally (for debug purposes)
 	LogObservations bool `json:"logObservations"`
 	// K8sExporter defines configuration of the K8s exporter for writing node conditions and events
 	K8sExporter *K8sExporterConfig `json:"k8sExporter,omitempty"`
-	// AggregationReportPeriod defines how often aggregated report is logged.
-	AggregationReportPeriod *metav1.Duration `json:"aggregationReportPeriod,omitempty"`
-	// AggregationTimeWindow defines when an aggregation edge outdates if no new observations arrive
-	AggregationTimeWindow *metav1.Duration `json:"aggregationTimeWindow,omitempty"`
+	// AggregationConfig defines the aggregation of the observations and the content of the aggregation reports.
+	// The settings are the defaults for both daemon sets and can be overridden in HostNetwork and PodNetwork.
+	AggregationConfig `json:",inline"`
+	// ReportRetentionHours defines how many hours to keep the rotated aggregation report files (default 48 hours).
+	ReportRetentionHours int `json:"reportRetentionHours,omitempty"`
+	// ReportMaxMegabytes is the maximum total size of the current and the rotated aggregation report files (default 20).
+	// The oldest rotated files are deleted first.
+	ReportMaxMegabytes int `json:"reportMaxMegabytes,omitempty"`
 	// MaxPeerNodes defines the maximum number of nodes to check (0 means check all nodes)
 	MaxPeerNodes int `json:"maxPeerNodes,omitempty"`
+	// MaxConcurrentJobs is the maximum number of simultaneously running jobs (default 16).
+	// Jobs which cannot be started because of the limit are delayed until a running job has finished.
+	MaxConcurrentJobs int `json:"maxConcurrentJobs,omitempty"`
+	// MaxInFlightProbes is the maximum number of simultaneous probes of all jobs (default 64).
+	// Probes which cannot be started because of the limit wait for a running probe to finish.
+	MaxInFlightProbes int `json:"maxInFlightProbes,omitempty"`
+	// ScalingPolicy if set, scales the job periods and the destination sampling with the number of nodes.
+	// The policy is rendered as explicit job args by the deploy command and the controller.
+	ScalingPolicy *ScalingPolicy `json:"scalingPolicy,omitempty"`
+	// ScaledForNodeCount is the number of nodes the scaling policy has been rendered for.
+	ScaledForNodeCount int `json:"scaledForNodeCount,omitempty"`
+	// PeerHeartbeat if set and enabled, the agent sends signed heartbeats to peer agents and tracks the heartbeats of its peers.
+	PeerHeartbeat *PeerHeartbeatConfig `json:"peerHeartbeat,omitempty"`
+	// Incidents defines the thresholds for opening and closing incidents of failing edges.
+	Incidents *IncidentConfig `json:"incidents,omitempty"`
+	// GapDetection defines the detection of gaps between consecutive observations of an edge.
+	GapDetection *GapDetectionConfig `json:"gapDetection,omitempty"`
+	// LocalBlockDetection if set and enabled, the agent diagnoses its own ports if the checks to many peers fail
+	// while the checks to external destinations still succeed, e.g. because of a node-local firewall rule.
+	LocalBlockDetection *LocalBlockDetectionConfig `json:"localBlockDetection,omitempty"`
+	// Timing defines the timing of the job scheduling and the observation processing.
+	Timing *TimingConfig `json:"timing,omitempty"`
+	// RemoteWrite if set, the aggregated observation metrics are pushed additionally via Prometheus remote write.
+	RemoteWrite *RemoteWriteConfig `json:"remoteWrite,omitempty"`
+	// Tracing if set, a trace span is exported for each probe via OTLP.
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+	// RemoteSink if set, all processed observations are mirrored to a remote HTTP endpoint in batches.
+	RemoteSink *RemoteSinkConfig `json:"remoteSink,omitempty"`
+	// AgentServiceTLS if set, the agent service is only served with TLS on the HTTP port.
+	AgentServiceTLS *AgentServiceTLSConfig `json:"agentServiceTLS,omitempty"`
+	// APIToken if set, is the reference to the bearer token required for the agent service in format `secretRef:<namespace>/<name>#<key>`.
+	APIToken string `json:"apiToken,omitempty"`
+	// APITokenFile if set, is the file containing the bearer token required for the agent service (e.g. mounted from a secret).
+	APITokenFile string `json:"apiTokenFile,omitempty"`
+	// SecretRefreshPeriod is the period for re-resolving the Kubernetes secrets referenced by job args, the remote write
+	// configuration, and the API token with `secretRef:<namespace>/<name>#<key>` (default 5m).
+	SecretRefreshPeriod *metav1.Duration `json:"secretRefreshPeriod,omitempty"`
+	// MetricLabels is the allowlist of job label names exposed as additional labels of the aggregated observation metrics.
+	MetricLabels []string `json:"metricLabels,omitempty"`
+	// DurationHistogram if true, the observation durations are exposed additionally as histogram per edge and job.
+	// It is disabled by default, as it multiplies the number of series of each edge by the number of buckets.
+	DurationHistogram bool `json:"durationHistogram,omitempty"`
 	// HostNetwork is the configuration specific for daemon set in node network
 	HostNetwork *NetworkConfig `json:"hostNetwork,omitempty"`
 	// PodNetwork is the configuration specific for daemon set in node network
@@ -43,20 +135,259 @@ func (c *AgentConfig) Clone() (*AgentConfig, error) {
 	return clone, nil
 }
 
+// AggregationConfig defines the aggregation of the observations and the content of the aggregation reports.
+type AggregationConfig struct {
+	// AggregationReportPeriod defines how often aggregated report is logged.
+	AggregationReportPeriod *metav1.Duration `json:"aggregationReportPeriod,omitempty"`
+	// AggregationTimeWindow defines when an aggregation edge outdates if no new observations arrive
+	AggregationTimeWindow *metav1.Duration `json:"aggregationTimeWindow,omitempty"`
+	// AggregationReportResultFields are the names of the result fields (e.g. `httpStatus`) of the last observation
+	// of an edge included in the aggregated report.
+	AggregationReportResultFields []string `json:"aggregationReportResultFields,omitempty"`
+	// AggregationReportFormat is the format of the aggregation report file in the log directory, either `text` (default) or `json`.
+	// In the json format, a JSON object is written for each edge and report period to a JSON lines file (`.jsonl`).
+	AggregationReportFormat string `json:"aggregationReportFormat,omitempty"`
+	// AggregationOutput is the sink of the aggregation report besides the log output, either `file:<dir>` for the report files
+	// in the directory (default `file:/var/log/nwpd`), `stdout` or `none`.
+	AggregationOutput string `json:"aggregationOutput,omitempty"`
+	// AggregationReportLogJSON if true and the report format is json, the edge reports are logged with structured fields
+	// instead of text lines.
+	AggregationReportLogJSON *bool `json:"aggregationReportLogJSON,omitempty"`
+	// AggregationReportTopFailingEdges is the number of edges with the highest failure ratio listed at the top of
+	// each report (default 10).
+	AggregationReportTopFailingEdges int `json:"aggregationReportTopFailingEdges,omitempty"`
+	// AggregationReportTopFailingEdgesMinChecks is the minimum number of checks in the report period of an edge
+	// to be listed in the top failing edges (default 5).
+	AggregationReportTopFailingEdgesMinChecks int `json:"aggregationReportTopFailingEdgesMinChecks,omitempty"`
+}
+
+// IsReportLogJSON returns true if the edge reports are logged with structured fields.
+func (c *AggregationConfig) IsReportLogJSON() bool {
+	return c.AggregationReportLogJSON != nil && *c.AggregationReportLogJSON
+}
+
+// Override returns the aggregation settings with all settings of the override which are set.
+func (c AggregationConfig) Override(override AggregationConfig) AggregationConfig {
+	if override.AggregationReportPeriod != nil {
+		c.AggregationReportPeriod = override.AggregationReportPeriod
+	}
+	if override.AggregationTimeWindow != nil {
+		c.AggregationTimeWindow = override.AggregationTimeWindow
+	}
+	if override.AggregationReportResultFields != nil {
+		c.AggregationReportResultFields = override.AggregationReportResultFields
+	}
+	if override.AggregationReportFormat != "" {
+		c.AggregationReportFormat = override.AggregationReportFormat
+	}
+	if override.AggregationOutput != "" {
+		c.AggregationOutput = override.AggregationOutput
+	}
+	if override.AggregationReportLogJSON != nil {
+		c.AggregationReportLogJSON = override.AggregationReportLogJSON
+	}
+	if override.AggregationReportTopFailingEdges != 0 {
+		c.AggregationReportTopFailingEdges = override.AggregationReportTopFailingEdges
+	}
+	if override.AggregationReportTopFailingEdgesMinChecks != 0 {
+		c.AggregationReportTopFailingEdgesMinChecks = override.AggregationReportTopFailingEdgesMinChecks
+	}
+	return c
+}
+
 type NetworkConfig struct {
-	// DataFilePrefix is the prefix for observation data files.
+	// DataFilePrefix is the prefix for observation data files. The placeholders `{nodename}` and `{pod}` are replaced
+	// by the node and pod name of the agent on start, e.g. for agents writing to a shared volume.
 	DataFilePrefix string `json:"dataFilePrefix,omitempty"`
 	// HTTPPort is the port of the http server.
 	HTTPPort int `json:"httpPort,omitempty"`
+	// HTTPBindAddress is the IP address the http server listens on, e.g. `127.0.0.1`, or `{podIP}` for the IP of the agent pod.
+	// If not set, it listens on all interfaces. Peers can only reach the agent on this address, e.g. for heartbeats and packet trains.
+	HTTPBindAddress string `json:"httpBindAddress,omitempty"`
 	// Jobs are the jobs to execute.
 	Jobs []Job `json:"jobs,omitempty"`
 	// DefaultPeriod is the period used for a new job if it doesn't specify the period.
 	DefaultPeriod metav1.Duration `json:"defaultPeriod,omitempty"`
+	// Jitter is the maximum random delay of a job run as fraction of the job period. The delay is added to each run without
+	// shifting the phase of the later runs. Valid range: [0.0,1.0)
+	Jitter float64 `json:"jitter,omitempty"`
+	// SpreadByNode if true or not set, the phase of the job runs is derived from node name and job ID, otherwise from the job ID only.
+	SpreadByNode *bool `json:"spreadByNode,omitempty"`
+	// MaxCIDRAddresses is the maximum number of addresses the CIDR destinations of a job may expand to (default 256).
+	MaxCIDRAddresses int `json:"maxCIDRAddresses,omitempty"`
+	// AggregationConfig overrides the aggregation settings of the agent configuration for the daemon set in this network.
+	AggregationConfig `json:",inline"`
 }
 
 type Job struct {
 	JobID string   `json:"jobID"`
 	Args  []string `json:"args,omitempty"`
+	// Enabled if false, the job is stopped but kept in the configuration (default true).
+	Enabled *bool `json:"enabled,omitempty"`
+	// UnscaledArgs are the original args of the job if they have been modified by the scaling policy.
+	UnscaledArgs []string `json:"unscaledArgs,omitempty"`
+	// Retries is the number of additional attempts for a failing destination before a not-ok observation is reported.
+	Retries int `json:"retries,omitempty"`
+	// RetryDelay is the delay between two attempts.
+	RetryDelay *metav1.Duration `json:"retryDelay,omitempty"`
+	// NodeSelector if set, the job only runs on agents whose node has all of these labels.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// NodeNamePattern if set, the job only runs on agents whose node name matches this regular expression.
+	NodeNamePattern string `json:"nodeNamePattern,omitempty"`
+	// Labels are user-defined labels added to all observations of the job.
+	// Only labels listed in `AgentConfig.MetricLabels` are exposed as metric labels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// FailureBackoff stretches the probe interval of destinations failing consecutively.
+	FailureBackoff *FailureBackoffConfig `json:"failureBackoff,omitempty"`
+}
+
+// FailureBackoffConfig configures the backoff of failing destinations. After a failure, a destination is skipped for
+// twice its probe interval, doubling with each further failure up to the maximum delay. A success resets the backoff.
+type FailureBackoffConfig struct {
+	// Enabled enables the backoff. By default, it is enabled for external destinations and disabled for cluster-internal destinations.
+	Enabled *bool `json:"enabled,omitempty"`
+	// MaxDelay is the maximum delay of a failing destination (default 8 times the probe interval of the destination).
+	MaxDelay *metav1.Duration `json:"maxDelay,omitempty"`
+}
+
+// IsEnabled returns false if the job is disabled explicitly.
+func (j *Job) IsEnabled() bool {
+	return j.Enabled == nil || *j.Enabled
+}
+
+type TimingConfig struct {
+	// TickPeriod is the period for checking if jobs are due (default 200ms). It must be smaller than the period of all jobs.
+	TickPeriod *metav1.Duration `json:"tickPeriod,omitempty"`
+	// ObservationBufferSize is the number of observations buffered for processing (default 100). It is only applied on agent start.
+	ObservationBufferSize int `json:"observationBufferSize,omitempty"`
+	// ReloadDebounce is the delay for reloading the configuration after a file change,
+	// so that multiple changes in short succession are applied at once (default 1s).
+	// Changes detected while a reload is running are coalesced into a single further reload.
+	ReloadDebounce *metav1.Duration `json:"reloadDebounce,omitempty"`
+	// ConfigRecheckPeriod is the period for re-reading the configuration files and reloading them if their contents have changed,
+	// so that a missed file event does not keep a stale configuration (default 5m, 0 to disable).
+	ConfigRecheckPeriod *metav1.Duration `json:"configRecheckPeriod,omitempty"`
+	// ObservationSendTimeout is the maximum time a job run waits if the observation buffer is full (default 5s).
+	// The observation is dropped afterwards and counted in the metric `nwpd_dropped_observations_total`.
+	ObservationSendTimeout *metav1.Duration `json:"observationSendTimeout,omitempty"`
+	// ObservationOverflowPolicy defines the handling of a full observation buffer, `block` to wait at most the
+	// ObservationSendTimeout or `drop` to drop the observations immediately (default `block`).
+	ObservationOverflowPolicy string `json:"observationOverflowPolicy,omitempty"`
+	// WriteFlushInterval is the interval for writing the batched observations to the record file and syncing it (default 5s).
+	WriteFlushInterval *metav1.Duration `json:"writeFlushInterval,omitempty"`
+	// WriteBatchSize is the number of batched observations and job run records written before the flush interval has elapsed (default 500).
+	WriteBatchSize int `json:"writeBatchSize,omitempty"`
+}
+
+type IncidentConfig struct {
+	// MinFailures is the number of consecutive failures of an edge for starting an incident (default 3). Valid range: [1,100]
+	MinFailures int `json:"minFailures,omitempty"`
+	// MinRecoveries is the number of consecutive successful observations of an edge for closing its incident (default 2).
+	// It protects against flapping edges. Valid range: [1,100]
+	MinRecoveries int `json:"minRecoveries,omitempty"`
+}
+
+type GapDetectionConfig struct {
+	// Factor is the multiple of the job period the time between two consecutive observations of an edge
+	// must exceed to be counted as gap (default 3). Valid range: >= 1.5
+	Factor float64 `json:"factor,omitempty"`
+	// GracePeriod is the time after the start of the agent in which ending gaps are explained (default 2m).
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+	// MaintenanceWindows explain the gaps overlapping them.
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+type LocalBlockDetectionConfig struct {
+	// Enabled if true, the agent runs the local diagnosis if the failure pattern of a local block is observed.
+	Enabled bool `json:"enabled"`
+	// MinFailingPeers is the minimum number of peer nodes with refused or timed out checks (default 3).
+	MinFailingPeers int `json:"minFailingPeers,omitempty"`
+	// MinFailingPeerRatio is the minimum ratio of the checked peer nodes with refused or timed out checks (default 0.5).
+	// Valid range: (0,1]
+	MinFailingPeerRatio float64 `json:"minFailingPeerRatio,omitempty"`
+	// Window is the time window of the evaluated observations (default 1m).
+	Window *metav1.Duration `json:"window,omitempty"`
+	// Interval is the minimum time between two diagnoses (default 10m, minimum 1m).
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// InspectFirewall if true, the agent in the host network additionally looks for nftables and iptables rules
+	// dropping or rejecting packets to its ports. It requires the `nft` or the `iptables-save` command.
+	InspectFirewall bool `json:"inspectFirewall,omitempty"`
+}
+
+type MaintenanceWindow struct {
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
+	// Reason is shown for the explained gaps.
+	Reason string `json:"reason,omitempty"`
+}
+
+type RemoteWriteConfig struct {
+	// URL is the remote write endpoint (e.g. `https://prometheus.example.com/api/v1/write`).
+	// The metrics are pushed with the aggregation report period. If empty, the metrics are only provided for scraping.
+	URL string `json:"url,omitempty"`
+	// Timeout is the timeout of a single push (default 10s).
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// ExternalLabels are added to all pushed series (e.g. to identify the cluster).
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	// NodeLabel if set, is the name of a label with the node name of the agent added to all pushed series (e.g. `node`).
+	NodeLabel string `json:"nodeLabel,omitempty"`
+	// MaxRetries is the maximum number of retries of a push failed with a connection error or a status 429 or 5xx (default 2, at most 5).
+	// The retries are stopped when the next push is due.
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	// BasicAuth if set, the push is authenticated with basic auth.
+	BasicAuth *RemoteWriteBasicAuth `json:"basicAuth,omitempty"`
+	// BearerTokenFile if set, is the file containing the bearer token for authenticating the push (e.g. mounted from a secret).
+	BearerTokenFile string `json:"bearerTokenFile,omitempty"`
+	// BearerToken if set, is the reference to the bearer token in a Kubernetes secret in format `secretRef:<namespace>/<name>#<key>`.
+	BearerToken string `json:"bearerToken,omitempty"`
+}
+
+type TracingConfig struct {
+	// Endpoint is the URL of the OTLP/HTTP traces receiver of the collector (e.g. `http://otel-collector:4318/v1/traces`).
+	// If the URL has no path, `/v1/traces` is used. Tracing is disabled if empty.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Timeout is the timeout of a single export (default 10s).
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+type RemoteSinkConfig struct {
+	// URL is the HTTP endpoint the batches of observations are posted to. The sink is disabled if empty.
+	// For the format `otlp`, `/v1/logs` is used if the URL has no path.
+	URL string `json:"url,omitempty"`
+	// Format is either `json` (default) for newline-delimited JSON or `otlp` for OTLP/HTTP logs with protobuf encoding.
+	Format string `json:"format,omitempty"`
+	// BatchSize is the maximum number of observations per request (default 500).
+	BatchSize int `json:"batchSize,omitempty"`
+	// QueueSize is the maximum number of observations waiting to be sent (default 10000).
+	// If exceeded, the oldest observations are dropped. It must not be smaller than the batch size.
+	QueueSize int `json:"queueSize,omitempty"`
+	// FlushInterval is the maximum time an observation waits for its batch to be filled (default 5s).
+	FlushInterval *metav1.Duration `json:"flushInterval,omitempty"`
+	// Timeout is the timeout of a single request (default 10s).
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// AuthHeaderFile if set, is the file containing the value of the `Authorization` header (e.g. `Bearer <token>` mounted from a secret).
+	// It is read on each request, so that a rotated secret is picked up.
+	AuthHeaderFile string `json:"authHeaderFile,omitempty"`
+}
+
+type AgentServiceTLSConfig struct {
+	// CertFile is the file with the PEM encoded server certificate chain (e.g. `tls.crt` mounted from a secret).
+	CertFile string `json:"certFile"`
+	// KeyFile is the file with the PEM encoded private key of the server certificate (e.g. `tls.key`).
+	KeyFile string `json:"keyFile"`
+	// ClientCAFile if set, is the file with the PEM encoded CA certificates for verifying client certificates (e.g. `ca.crt`).
+	// Clients connecting with TLS must then present a certificate signed by one of the CAs.
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+}
+
+type RemoteWriteBasicAuth struct {
+	// Username is the user name.
+	Username string `json:"username"`
+	// PasswordFile is the file containing the password (e.g. mounted from a secret).
+	PasswordFile string `json:"passwordFile"`
+	// Password is the reference to the password in a Kubernetes secret in format `secretRef:<namespace>/<name>#<key>`.
+	// It can be used instead of PasswordFile.
+	Password string `json:"password,omitempty"`
 }
 
 type K8sExporterConfig struct {
@@ -67,3 +398,14 @@ type K8sExporterConfig struct {
 	// MinFailingPeerNodeShare if > 0, reports node conditions `ClusterNetworkProblems` or `HostNetworkProblems` for node checks only if minimum share of destination peer nodes are failing. Valid range: [0.0,1.0]
 	MinFailingPeerNodeShare float64 `json:"minFailingPeerNodeShare,omitempty"`
 }
+
+type PeerHeartbeatConfig struct {
+	// Enabled if true, the agent sends heartbeats to its peer agents and exposes the heartbeat age of the agents sending to it.
+	Enabled bool `json:"enabled"`
+	// Period defines how often a heartbeat is sent (default 30s, minimum 10s).
+	Period *metav1.Duration `json:"period,omitempty"`
+	// Peers is the number of peer agents receiving the heartbeat of an agent (default 2). Valid range: [1,5]
+	Peers int `json:"peers,omitempty"`
+	// KeyFile is the file containing the key shared by all agents for signing the heartbeats.
+	KeyFile string `json:"keyFile"`
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("aggregation config", func() {
+	It("parses the global and the network specific settings", func() {
+		cfg, err := config.ParseAgentConfig([]byte(`
+aggregationReportPeriod: 2m
+aggregationReportFormat: json
+aggregationReportLogJSON: true
+hostNetwork:
+  aggregationTimeWindow: 2h
+  aggregationOutput: stdout
+podNetwork:
+  aggregationReportPeriod: 5m
+  aggregationReportLogJSON: false
+  aggregationReportTopFailingEdges: 3
+`))
+		Expect(err).To(BeNil())
+		Expect(cfg.AggregationReportPeriod.Duration).To(Equal(2 * time.Minute))
+		Expect(cfg.AggregationReportFormat).To(Equal(config.ReportFormatJSON))
+		Expect(cfg.IsReportLogJSON()).To(BeTrue())
+		Expect(cfg.HostNetwork.AggregationTimeWindow.Duration).To(Equal(2 * time.Hour))
+		Expect(cfg.HostNetwork.AggregationOutput).To(Equal(config.AggregationOutputStdout))
+		Expect(cfg.PodNetwork.AggregationReportPeriod.Duration).To(Equal(5 * time.Minute))
+		Expect(cfg.PodNetwork.AggregationReportLogJSON).To(Equal(ptr.To(false)))
+		Expect(cfg.PodNetwork.AggregationReportTopFailingEdges).To(Equal(3))
+
+		// the settings are not nested on marshalling
+		data, err := yaml.Marshal(cfg)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(ContainSubstring("\naggregationReportPeriod: 2m0s\n"))
+		Expect(string(data)).NotTo(ContainSubstring("AggregationConfig"))
+	})
+
+	It("overrides the settings which are set", func() {
+		global := config.AggregationConfig{
+			AggregationReportPeriod:                   &metav1.Duration{Duration: 2 * time.Minute},
+			AggregationTimeWindow:                     &metav1.Duration{Duration: time.Hour},
+			AggregationReportResultFields:             []string{"httpStatus"},
+			AggregationReportFormat:                   config.ReportFormatJSON,
+			AggregationOutput:                         "file:/var/log/nwpd",
+			AggregationReportLogJSON:                  ptr.To(true),
+			AggregationReportTopFailingEdges:          5,
+			AggregationReportTopFailingEdgesMinChecks: 10,
+		}
+		Expect(global.Override(config.AggregationConfig{})).To(Equal(global))
+
+		override := config.AggregationConfig{
+			AggregationReportPeriod:                   &metav1.Duration{Duration: 5 * time.Minute},
+			AggregationReportResultFields:             []string{},
+			AggregationOutput:                         config.AggregationOutputNone,
+			AggregationReportLogJSON:                  ptr.To(false),
+			AggregationReportTopFailingEdgesMinChecks: 1,
+		}
+		Expect(global.Override(override)).To(Equal(config.AggregationConfig{
+			AggregationReportPeriod:                   &metav1.Duration{Duration: 5 * time.Minute},
+			AggregationTimeWindow:                     &metav1.Duration{Duration: time.Hour},
+			AggregationReportResultFields:             []string{},
+			AggregationReportFormat:                   config.ReportFormatJSON,
+			AggregationOutput:                         config.AggregationOutputNone,
+			AggregationReportLogJSON:                  ptr.To(false),
+			AggregationReportTopFailingEdges:          5,
+			AggregationReportTopFailingEdgesMinChecks: 1,
+		}))
+		// the overridden settings are unchanged
+		Expect(global.AggregationReportPeriod.Duration).To(Equal(2 * time.Minute))
+	})
+})
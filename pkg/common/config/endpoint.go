@@ -11,17 +11,47 @@ type WithDestHost interface {
 type Node struct {
 	Hostname   string `json:"hostname"`
 	InternalIP string `json:"internalIP"`
+	// ExternalIP is an address of type `ExternalIP` of the node status if any, preferring IPv4.
+	ExternalIP string `json:"externalIP,omitempty"`
+	// ExternalDNS is an address of type `ExternalDNS` of the node status if any.
+	ExternalDNS string `json:"externalDNS,omitempty"`
+	// Labels are the labels of the node, used for matching job node selectors.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Zone is the value of the node label `topology.kubernetes.io/zone`.
+	Zone string `json:"zone,omitempty"`
 }
 
 func (n Node) DestHost() string {
 	return n.Hostname
 }
 
+// ExternalAddress returns the external IP address of the node, the external DNS name if there is no external IP address,
+// or an empty string if the node has no external address.
+func (n Node) ExternalAddress() string {
+	if n.ExternalIP != "" {
+		return n.ExternalIP
+	}
+	return n.ExternalDNS
+}
+
+// UnknownZone is the zone of the nodes without zone label.
+const UnknownZone = "unknown"
+
+// ZoneOrUnknown returns the zone of the node or UnknownZone if not set.
+func (n Node) ZoneOrUnknown() string {
+	if n.Zone == "" {
+		return UnknownZone
+	}
+	return n.Zone
+}
+
 type PodEndpoint struct {
 	Nodename string `json:"nodename"`
 	Podname  string `json:"podname"`
 	PodIP    string `json:"podIP"`
 	Port     int32  `json:"port"`
+	// PodUID is the UID of the agent pod, used to detect outdated endpoints.
+	PodUID string `json:"podUID,omitempty"`
 }
 
 func (e PodEndpoint) DestHost() string {
@@ -38,6 +68,19 @@ func (e Endpoint) DestHost() string {
 	return e.Hostname
 }
 
+// ServiceEndpoint is the cluster IP and a port of a service.
+type ServiceEndpoint struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	ClusterIP string `json:"clusterIP"`
+	Port      int    `json:"port"`
+}
+
+// DestHost returns the name of the service qualified by its namespace, so that the observations are attributed to the service.
+func (e ServiceEndpoint) DestHost() string {
+	return e.Name + "." + e.Namespace + ".svc"
+}
+
 type ClusterConfig struct {
 	// NodeCount is the number known nodes (not anly the subset used as destinations)
 	NodeCount int
@@ -49,4 +92,17 @@ type ClusterConfig struct {
 	InternalKubeAPIServer *Endpoint `json:"internalKubeAPIServer,omitempty"`
 	// KubeAPIServer is the discovered external address of the kube-apiserver (relies on Gardener shoot-info)
 	KubeAPIServer *Endpoint `json:"kubeAPIServer,omitempty"`
+	// PacketTrainPort if set, the agents listen on this UDP port for the packet trains of their peers.
+	PacketTrainPort int `json:"packetTrainPort,omitempty"`
+	// Services are the services selected on deployment for probing their cluster IP.
+	Services []ServiceEndpoint `json:"services,omitempty"`
+}
+
+// NodeZones returns the zones of the nodes by hostname. Nodes without zone label are mapped to UnknownZone.
+func (cc ClusterConfig) NodeZones() map[string]string {
+	zones := make(map[string]string, len(cc.Nodes))
+	for _, n := range cc.Nodes {
+		zones[n.Hostname] = n.ZoneOrUnknown()
+	}
+	return zones
 }
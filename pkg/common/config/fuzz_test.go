@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+)
+
+func FuzzParseAgentConfig(f *testing.F) {
+	f.Add([]byte(`outputDir: /var/lib/records
+retentionHours: 4
+aggregationReportPeriod: 1m
+podNetwork:
+  dataFilePrefix: pod
+  httpPort: 8881
+  defaultPeriod: 10s
+  jitter: 0.2
+  jobs:
+  - jobID: tcp-p2p
+    args: ["checkTCPPort", "--endpoints-of-pod-ds"]
+    retries: 2
+    retryDelay: 500ms
+`))
+	f.Add([]byte(`{"hostNetwork": {"jobs": [{"jobID": "x", "args": ["pingHost"]}]}}`))
+	f.Add([]byte(`podNetwork: {jobs: [{jobID: "x", args: "pingHost"}]}`))
+	f.Add([]byte(`aggregationTimeWindow: -5m`))
+	f.Add([]byte(`"unterminated`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, err := ParseAgentConfig(data)
+		if err != nil {
+			return
+		}
+		if _, err := cfg.Clone(); err != nil {
+			t.Errorf("clone of parsed config failed: %s", err)
+		}
+	})
+}
+
+func FuzzParseClusterConfig(f *testing.F) {
+	f.Add([]byte(`nodeCount: 2
+nodes:
+- hostname: node1
+  internalIP: 10.0.0.1
+podEndpoints:
+- nodename: node1
+  podname: pod1
+  podIP: 10.128.0.1
+  port: 8881
+kubeAPIServer:
+  hostname: api.example.com
+  ip: 1.2.3.4
+  port: 443
+`))
+	f.Add([]byte(`{"nodes": [{"hostname": 1}]}`))
+	f.Add([]byte(`podEndpoints: [{port: 99999999999}]`))
+
+	f.Fuzz(func(_ *testing.T, data []byte) {
+		_, _ = ParseClusterConfig(data)
+	})
+}
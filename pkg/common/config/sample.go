@@ -83,6 +83,8 @@ func (sc *SampleConfig) ShuffledSample(cc ClusterConfig) ClusterConfig {
 		PodEndpoints:          CloneAndShuffle(selectSample(sc, cc.PodEndpoints)),
 		InternalKubeAPIServer: cc.InternalKubeAPIServer,
 		KubeAPIServer:         cc.KubeAPIServer,
+		PacketTrainPort:       cc.PacketTrainPort,
+		Services:              cc.Services,
 	}
 }
 
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ScalingPolicy defines how the job periods and the destination sampling scale with the number of nodes.
+type ScalingPolicy struct {
+	// Rules are the scaling rules. For each job, the matching rule with the highest node threshold is applied.
+	Rules []ScalingRule `json:"rules"`
+}
+
+// ScalingRule scales the jobs of a job class if the cluster has more nodes than the threshold.
+type ScalingRule struct {
+	// AboveNodes is the threshold. The rule applies if the number of nodes is greater.
+	AboveNodes int `json:"aboveNodes"`
+	// JobTypes restricts the rule to jobs of these types (e.g. `pingHost`). If empty, the rule applies to all jobs.
+	JobTypes []string `json:"jobTypes,omitempty"`
+	// PeriodMultiplier is the factor applied to the job period (default 1).
+	PeriodMultiplier float64 `json:"periodMultiplier,omitempty"`
+	// SampleRatio is the share of destinations probed per run. Valid range: (0.0,1.0] (default 1).
+	SampleRatio float64 `json:"sampleRatio,omitempty"`
+}
+
+// Scaling is the result of evaluating a scaling policy for a job.
+type Scaling struct {
+	PeriodMultiplier float64
+	SampleRatio      float64
+}
+
+// NoScaling keeps period and sampling of a job unchanged.
+var NoScaling = Scaling{PeriodMultiplier: 1, SampleRatio: 1}
+
+// Validate checks the rules of the policy.
+func (p *ScalingPolicy) Validate() error {
+	for i, rule := range p.Rules {
+		if rule.AboveNodes < 0 {
+			return fmt.Errorf("invalid scaling rule %d: aboveNodes must be >= 0", i)
+		}
+		if rule.PeriodMultiplier < 0 {
+			return fmt.Errorf("invalid scaling rule %d: periodMultiplier must be > 0", i)
+		}
+		if rule.SampleRatio < 0 || rule.SampleRatio > 1 {
+			return fmt.Errorf("invalid scaling rule %d: sampleRatio must be in range (0.0,1.0]", i)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the scaling of a job of the given type for the number of nodes.
+func (p *ScalingPolicy) Evaluate(nodeCount int, jobType string) Scaling {
+	var match *ScalingRule
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if nodeCount <= rule.AboveNodes || !rule.appliesTo(jobType) {
+			continue
+		}
+		if match == nil || rule.AboveNodes > match.AboveNodes {
+			match = rule
+		}
+	}
+	if match == nil {
+		return NoScaling
+	}
+	scaling := NoScaling
+	if match.PeriodMultiplier > 0 {
+		scaling.PeriodMultiplier = match.PeriodMultiplier
+	}
+	if match.SampleRatio > 0 {
+		scaling.SampleRatio = match.SampleRatio
+	}
+	return scaling
+}
+
+// Level returns the number of thresholds exceeded by the number of nodes.
+// As long as the level does not change, the evaluation of the policy yields the same results.
+func (p *ScalingPolicy) Level(nodeCount int) int {
+	thresholds := map[int]struct{}{}
+	for _, rule := range p.Rules {
+		thresholds[rule.AboveNodes] = struct{}{}
+	}
+	sorted := make([]int, 0, len(thresholds))
+	for t := range thresholds {
+		sorted = append(sorted, t)
+	}
+	sort.Ints(sorted)
+	return sort.SearchInts(sorted, nodeCount)
+}
+
+func (r *ScalingRule) appliesTo(jobType string) bool {
+	if len(r.JobTypes) == 0 {
+		return true
+	}
+	for _, t := range r.JobTypes {
+		if t == jobType {
+			return true
+		}
+	}
+	return false
+}
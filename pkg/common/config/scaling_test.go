@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("scaling policy", func() {
+	policy := &config.ScalingPolicy{
+		Rules: []config.ScalingRule{
+			{AboveNodes: 100, PeriodMultiplier: 1.5},
+			{AboveNodes: 200, JobTypes: []string{"pingHost"}, PeriodMultiplier: 2, SampleRatio: 0.5},
+			{AboveNodes: 500, JobTypes: []string{"pingHost", "checkTCPPort"}, SampleRatio: 0.2},
+		},
+	}
+
+	DescribeTable("evaluates the rule with the highest matching threshold",
+		func(nodeCount int, jobType string, expected config.Scaling) {
+			Expect(policy.Evaluate(nodeCount, jobType)).To(Equal(expected))
+		},
+		Entry("below all thresholds", 50, "pingHost", config.NoScaling),
+		Entry("at threshold", 100, "pingHost", config.NoScaling),
+		Entry("above first threshold", 101, "pingHost", config.Scaling{PeriodMultiplier: 1.5, SampleRatio: 1}),
+		Entry("job type specific rule", 201, "pingHost", config.Scaling{PeriodMultiplier: 2, SampleRatio: 0.5}),
+		Entry("other job type", 201, "nslookup", config.Scaling{PeriodMultiplier: 1.5, SampleRatio: 1}),
+		Entry("defaults of unset values", 600, "checkTCPPort", config.Scaling{PeriodMultiplier: 1, SampleRatio: 0.2}),
+	)
+
+	It("calculates the level of exceeded thresholds", func() {
+		Expect(policy.Level(0)).To(Equal(0))
+		Expect(policy.Level(100)).To(Equal(0))
+		Expect(policy.Level(101)).To(Equal(1))
+		Expect(policy.Level(200)).To(Equal(1))
+		Expect(policy.Level(201)).To(Equal(2))
+		Expect(policy.Level(1000)).To(Equal(3))
+		Expect((&config.ScalingPolicy{}).Level(1000)).To(Equal(0))
+	})
+
+	It("validates the rules", func() {
+		Expect(policy.Validate()).To(Succeed())
+		Expect((&config.ScalingPolicy{Rules: []config.ScalingRule{{AboveNodes: -1}}}).Validate()).To(MatchError(ContainSubstring("aboveNodes")))
+		Expect((&config.ScalingPolicy{Rules: []config.ScalingRule{{PeriodMultiplier: -2}}}).Validate()).To(MatchError(ContainSubstring("periodMultiplier")))
+		Expect((&config.ScalingPolicy{Rules: []config.ScalingRule{{SampleRatio: 1.5}}}).Validate()).To(MatchError(ContainSubstring("sampleRatio")))
+	})
+})
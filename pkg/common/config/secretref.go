@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretRefPrefix starts a reference to a key of a Kubernetes secret in job args and the remote write configuration.
+const SecretRefPrefix = "secretRef:"
+
+// secretRefPattern matches a secret reference in format `secretRef:<namespace>/<name>#<key>`.
+var secretRefPattern = regexp.MustCompile(`secretRef:([a-z0-9](?:[-a-z0-9]*[a-z0-9])?)/([a-z0-9](?:[-.a-z0-9]*[a-z0-9])?)#([-._a-zA-Z0-9]+)`)
+
+// SecretRef references a key of a Kubernetes secret.
+type SecretRef struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+func (r SecretRef) String() string {
+	return fmt.Sprintf("%s%s/%s#%s", SecretRefPrefix, r.Namespace, r.Name, r.Key)
+}
+
+// ParseSecretRef parses a value consisting of a single secret reference.
+func ParseSecretRef(value string) (SecretRef, error) {
+	m := secretRefPattern.FindStringSubmatch(value)
+	if m == nil || m[0] != value {
+		return SecretRef{}, fmt.Errorf("invalid secret reference %q, expected format %s<namespace>/<name>#<key>", value, SecretRefPrefix)
+	}
+	return SecretRef{Namespace: m[1], Name: m[2], Key: m[3]}, nil
+}
+
+// ReplaceSecretRefs replaces all secret references contained in the value with the result of resolve.
+// It fails if the value contains a malformed reference.
+func ReplaceSecretRefs(value string, resolve func(ref SecretRef) (string, error)) (string, error) {
+	if !strings.Contains(value, SecretRefPrefix) {
+		return value, nil
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range secretRefPattern.FindAllStringSubmatchIndex(value, -1) {
+		if strings.Contains(value[last:m[0]], SecretRefPrefix) {
+			break
+		}
+		resolved, err := resolve(SecretRef{Namespace: value[m[2]:m[3]], Name: value[m[4]:m[5]], Key: value[m[6]:m[7]]})
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value[last:m[0]])
+		sb.WriteString(resolved)
+		last = m[1]
+	}
+	if rest := value[last:]; strings.Contains(rest, SecretRefPrefix) {
+		idx := strings.Index(rest, SecretRefPrefix)
+		return "", fmt.Errorf("invalid secret reference at %q, expected format %s<namespace>/<name>#<key>", rest[idx:], SecretRefPrefix)
+	}
+	sb.WriteString(value[last:])
+	return sb.String(), nil
+}
+
+// FindSecretRefs returns the secret references contained in the value.
+func FindSecretRefs(value string) []SecretRef {
+	var refs []SecretRef
+	for _, m := range secretRefPattern.FindAllStringSubmatch(value, -1) {
+		refs = append(refs, SecretRef{Namespace: m[1], Name: m[2], Key: m[3]})
+	}
+	return refs
+}
+
+// SecretRefs returns the distinct secret references of the job args of both networks, of the remote write configuration,
+// and of the API token sorted by namespace, name, and key.
+func (c *AgentConfig) SecretRefs() []SecretRef {
+	set := map[SecretRef]struct{}{}
+	add := func(value string) {
+		for _, ref := range FindSecretRefs(value) {
+			set[ref] = struct{}{}
+		}
+	}
+	for _, networkCfg := range []*NetworkConfig{c.HostNetwork, c.PodNetwork} {
+		if networkCfg == nil {
+			continue
+		}
+		for _, j := range networkCfg.Jobs {
+			for _, arg := range j.Args {
+				add(arg)
+			}
+		}
+	}
+	if rw := c.RemoteWrite; rw != nil {
+		add(rw.BearerToken)
+		if rw.BasicAuth != nil {
+			add(rw.BasicAuth.Password)
+		}
+	}
+	add(c.APIToken)
+	refs := make([]SecretRef, 0, len(set))
+	for ref := range set {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+		if refs[i].Name != refs[j].Name {
+			return refs[i].Name < refs[j].Name
+		}
+		return refs[i].Key < refs[j].Key
+	})
+	return refs
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"fmt"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("secret references", func() {
+	resolve := func(ref config.SecretRef) (string, error) {
+		return fmt.Sprintf("<%s/%s/%s>", ref.Namespace, ref.Name, ref.Key), nil
+	}
+
+	It("parses a single reference", func() {
+		ref, err := config.ParseSecretRef("secretRef:monitoring/remote-write#token")
+		Expect(err).To(BeNil())
+		Expect(ref).To(Equal(config.SecretRef{Namespace: "monitoring", Name: "remote-write", Key: "token"}))
+		Expect(ref.String()).To(Equal("secretRef:monitoring/remote-write#token"))
+
+		for _, value := range []string{"plain", "secretRef:monitoring/remote-write", "secretRef:Mon/name#key", "Bearer secretRef:ns/name#key"} {
+			_, err = config.ParseSecretRef(value)
+			Expect(err).NotTo(BeNil(), value)
+		}
+	})
+
+	DescribeTable("replaces the references contained in a value",
+		func(value, expected string) {
+			Expect(config.ReplaceSecretRefs(value, resolve)).To(Equal(expected))
+		},
+		Entry("no reference", "--header=Accept: text/plain", "--header=Accept: text/plain"),
+		Entry("whole value", "secretRef:ns/name#key", "<ns/name/key>"),
+		Entry("embedded", "Authorization: Bearer secretRef:ns/webhook.token#token_1", "Authorization: Bearer <ns/webhook.token/token_1>"),
+		Entry("multiple", "secretRef:ns/a#user:secretRef:ns/b#password", "<ns/a/user>:<ns/b/password>"),
+	)
+
+	DescribeTable("rejects malformed references",
+		func(value string) {
+			_, err := config.ReplaceSecretRefs(value, resolve)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("invalid secret reference"))
+		},
+		Entry("missing key", "Bearer secretRef:ns/name"),
+		Entry("after valid reference", "secretRef:ns/a#user:secretRef:ns/"),
+		Entry("before valid reference", "secretRef:NS/a#user:secretRef:ns/b#password"),
+	)
+
+	It("stops on resolution errors", func() {
+		_, err := config.ReplaceSecretRefs("secretRef:ns/name#key", func(_ config.SecretRef) (string, error) {
+			return "", fmt.Errorf("not found")
+		})
+		Expect(err).To(MatchError("not found"))
+	})
+
+	It("collects the distinct references of jobs, remote write, and the API token", func() {
+		cfg := &config.AgentConfig{
+			HostNetwork: &config.NetworkConfig{Jobs: []config.Job{
+				{JobID: "https", Args: []string{"checkHTTPSGet", "--header", "Authorization: Bearer secretRef:ns2/token#value"}},
+			}},
+			PodNetwork: &config.NetworkConfig{Jobs: []config.Job{
+				{JobID: "https", Args: []string{"checkHTTPSGet", "--header", "Authorization: Bearer secretRef:ns2/token#value"}},
+				{JobID: "tcp", Args: []string{"checkTCPPort", "--endpoints", "example.com:443"}},
+			}},
+			RemoteWrite: &config.RemoteWriteConfig{
+				URL:       "https://example.com/api/v1/write",
+				BasicAuth: &config.RemoteWriteBasicAuth{Username: "user", Password: "secretRef:ns1/remote-write#password"},
+			},
+			APIToken: "secretRef:kube-system/nwpd-api-token#token",
+		}
+		Expect(cfg.SecretRefs()).To(Equal([]config.SecretRef{
+			{Namespace: "kube-system", Name: "nwpd-api-token", Key: "token"},
+			{Namespace: "ns1", Name: "remote-write", Key: "password"},
+			{Namespace: "ns2", Name: "token", Key: "value"},
+		}))
+		Expect((&config.AgentConfig{}).SecretRefs()).To(BeEmpty())
+	})
+})
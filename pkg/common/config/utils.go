@@ -22,28 +22,44 @@ func LoadAgentConfig(configFile string) (*AgentConfig, error) {
 		return nil, err
 	}
 
-	cfg := &AgentConfig{}
-	err = yaml.Unmarshal(data, cfg)
+	cfg, err := ParseAgentConfig(data)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshalling %s failed: %w", configFile, err)
 	}
 	return cfg, nil
 }
 
+// ParseAgentConfig unmarshals the agent configuration from YAML or JSON.
+func ParseAgentConfig(data []byte) (*AgentConfig, error) {
+	cfg := &AgentConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func LoadClusterConfig(configFile string) (*ClusterConfig, error) {
 	data, err := os.ReadFile(configFile) // #nosec G304
 	if err != nil {
 		return nil, err
 	}
 
-	cfg := &ClusterConfig{}
-	err = yaml.Unmarshal(data, cfg)
+	cfg, err := ParseClusterConfig(data)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshalling %s failed: %w", configFile, err)
 	}
 	return cfg, nil
 }
 
+// ParseClusterConfig unmarshals the cluster configuration from YAML or JSON.
+func ParseClusterConfig(data []byte) (*ClusterConfig, error) {
+	cfg := &ClusterConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func CloneAndShuffle[T any](items []T) []T {
 	if DisableShuffleForTesting {
 		return items
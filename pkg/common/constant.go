@@ -25,6 +25,32 @@ const (
 	EnvNodeIP = "NODE_IP"
 	// EnvPodIP is the env variable to get the pod ip in an agent pod.
 	EnvPodIP = "POD_IP"
+	// EnvPodName is the env variable to get the pod name in an agent pod.
+	EnvPodName = "POD_NAME"
+	// EnvPodUID is the env variable to get the pod UID in an agent pod.
+	EnvPodUID = "POD_UID"
+	// HeaderPodUID is the HTTP response header used by an agent to echo its pod UID.
+	HeaderPodUID = "X-Nwpd-Pod-Uid"
+	// PathPodIdentity is the HTTP path of an agent returning its pod UID in the response header.
+	PathPodIdentity = "/identity"
+	// HeaderHeartbeatSignature is the HTTP request header containing the HMAC-SHA256 signature of a peer heartbeat.
+	HeaderHeartbeatSignature = "X-Nwpd-Signature"
+	// PathHeartbeat is the HTTP path of an agent receiving the heartbeats of its peers.
+	PathHeartbeat = "/heartbeat"
+	// PathHealthz is the HTTP path of the liveness probe of an agent.
+	PathHealthz = "/healthz"
+	// PathReadyz is the HTTP path of the readiness probe of an agent.
+	PathReadyz = "/readyz"
+	// PathExportObservations is the HTTP path of an agent to export the stored observations as newline-delimited JSON or CSV.
+	PathExportObservations = "/export/observations"
+	// PathWatchObservations is the HTTP path of an agent to stream the observations processed from now on as newline-delimited JSON or CSV.
+	PathWatchObservations = "/watch/observations"
+	// HeaderDroppedObservations is the HTTP trailer of the watched observations with the number of observations dropped for a slow client.
+	HeaderDroppedObservations = "X-Nwpd-Dropped-Observations"
+	// PathJobs is the HTTP path of an agent to list the current jobs and their schedule.
+	PathJobs = "/jobs"
+	// PathStatus is the HTTP path of an agent to get the job status as JSON.
+	PathStatus = "/status"
 	// LabelKeyK8sApp is the label key used to mark the pods.
 	LabelKeyK8sApp = "k8s-app"
 	// ApplicationName is the application name.
@@ -49,4 +75,6 @@ const (
 	PodNetPodHTTPPort = 8881
 	// HostNetPodHTTPPort is the port used for the metrics http server of the pods running in the host network.
 	HostNetPodHTTPPort = 12996
+	// PacketTrainPort is the UDP port used by the agents for receiving packet trains if enabled.
+	PacketTrainPort = 12997
 )
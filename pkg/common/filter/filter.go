@@ -0,0 +1,386 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filter implements a small expression language to select observations.
+//
+// Examples:
+//
+//	!ok && destHost in 10.250.3.0/24 && duration > 2s
+//	jobID =~ "^tcp-" || labels.pool == canary
+//
+// Expressions have no side effects and their evaluation cost is bounded by the length of the expression.
+package filter
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+const (
+	// MaxExpressionLength is the maximum length of an expression.
+	MaxExpressionLength = 1024
+	// maxNodes is the maximum number of nodes of the syntax tree.
+	maxNodes = 100
+	// labelsPrefix is the prefix of the fields selecting a job label.
+	labelsPrefix = "labels."
+	// resultFieldsPrefix is the prefix of the fields selecting a result field.
+	resultFieldsPrefix = "fields."
+)
+
+// Fields are the names of the observation fields usable in expressions. Job labels are selected with `labels.<name>`,
+// result fields with `fields.<name>`.
+var Fields = []string{"jobID", "srcHost", "destHost", "result", "ok", "stale", "duration", "period"}
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindBool
+	kindDuration
+)
+
+var fieldKinds = map[string]fieldKind{
+	"jobID":    kindString,
+	"srcHost":  kindString,
+	"destHost": kindString,
+	"result":   kindString,
+	"ok":       kindBool,
+	"stale":    kindBool,
+	"duration": kindDuration,
+	"period":   kindDuration,
+}
+
+// Expression is a parsed filter expression.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses and validates a filter expression.
+func Parse(expr string) (*Expression, error) {
+	if len(expr) > MaxExpressionLength {
+		return nil, fmt.Errorf("filter expression too long (max %d characters)", MaxExpressionLength)
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+	}
+	return &Expression{source: expr, root: root}, nil
+}
+
+// Match evaluates the expression for the given observation.
+func (e *Expression) Match(obs *nwpd.Observation) bool {
+	return e.root.eval(obs)
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+type node interface {
+	eval(obs *nwpd.Observation) bool
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(obs *nwpd.Observation) bool {
+	return !n.operand.eval(obs)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(obs *nwpd.Observation) bool {
+	return n.left.eval(obs) && n.right.eval(obs)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(obs *nwpd.Observation) bool {
+	return n.left.eval(obs) || n.right.eval(obs)
+}
+
+type boolNode struct {
+	field string
+	value bool
+}
+
+func (n *boolNode) eval(obs *nwpd.Observation) bool {
+	return boolValue(obs, n.field) == n.value
+}
+
+type stringNode struct {
+	field string
+	op    string
+	value string
+	regex *regexp.Regexp
+	cidr  *net.IPNet
+}
+
+func (n *stringNode) eval(obs *nwpd.Observation) bool {
+	v := stringValue(obs, n.field)
+	switch n.op {
+	case "==":
+		return v == n.value
+	case "!=":
+		return v != n.value
+	case "=~":
+		return n.regex.MatchString(v)
+	case "!~":
+		return !n.regex.MatchString(v)
+	case "in":
+		ip := net.ParseIP(v)
+		return ip != nil && n.cidr.Contains(ip)
+	}
+	return false
+}
+
+type durationNode struct {
+	field string
+	op    string
+	value time.Duration
+}
+
+func (n *durationNode) eval(obs *nwpd.Observation) bool {
+	v := durationValue(obs, n.field)
+	switch n.op {
+	case "==":
+		return v == n.value
+	case "!=":
+		return v != n.value
+	case "<":
+		return v < n.value
+	case "<=":
+		return v <= n.value
+	case ">":
+		return v > n.value
+	case ">=":
+		return v >= n.value
+	}
+	return false
+}
+
+func stringValue(obs *nwpd.Observation, field string) string {
+	switch field {
+	case "jobID":
+		return obs.JobID
+	case "srcHost":
+		return obs.SrcHost
+	case "destHost":
+		return obs.DestHost
+	case "result":
+		return obs.Result
+	}
+	if strings.HasPrefix(field, resultFieldsPrefix) {
+		return obs.ResultFields[strings.TrimPrefix(field, resultFieldsPrefix)]
+	}
+	return obs.Labels[strings.TrimPrefix(field, labelsPrefix)]
+}
+
+func boolValue(obs *nwpd.Observation, field string) bool {
+	if field == "stale" {
+		return obs.StaleEndpoint
+	}
+	return obs.Ok
+}
+
+func durationValue(obs *nwpd.Observation, field string) time.Duration {
+	if field == "period" {
+		return obs.Period.AsDuration()
+	}
+	return obs.Duration.AsDuration()
+}
+
+func kindOf(field string) (fieldKind, bool) {
+	if strings.HasPrefix(field, labelsPrefix) && len(field) > len(labelsPrefix) {
+		return kindString, true
+	}
+	if strings.HasPrefix(field, resultFieldsPrefix) && len(field) > len(resultFieldsPrefix) {
+		return kindString, true
+	}
+	kind, ok := fieldKinds[field]
+	return kind, ok
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	nodes  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) newNode(n node) (node, error) {
+	p.nodes++
+	if p.nodes > maxNodes {
+		return nil, fmt.Errorf("filter expression too complex (max %d terms)", maxNodes)
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = p.newNode(&orNode{left: left, right: right}); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isOp("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = p.newNode(&andNode{left: left, right: right}); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.next()
+	switch {
+	case t.isOp("!"):
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return p.newNode(&notNode{operand: operand})
+	case t.isOp("("):
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); !closing.isOp(")") {
+			return nil, fmt.Errorf("expected ')' at position %d, but found %s", closing.pos, closing)
+		}
+		return n, nil
+	case t.kind == tokenWord:
+		return p.parseComparison(t)
+	}
+	return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+func (p *parser) parseComparison(field token) (node, error) {
+	kind, ok := kindOf(field.text)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d (valid fields: %s, labels.<name>, fields.<name>)", field.text, field.pos, strings.Join(Fields, ", "))
+	}
+	op := p.peek()
+	if op.kind != tokenOp || !isComparison(op.text) {
+		if kind == kindBool {
+			return p.newNode(&boolNode{field: field.text, value: true})
+		}
+		return nil, fmt.Errorf("expected comparison operator after field %q at position %d", field.text, op.pos)
+	}
+	p.next()
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("expected value after %q at position %d, but found %s", op.text, value.pos, value)
+	}
+
+	invalidOp := func() (node, error) {
+		return nil, fmt.Errorf("operator %q not supported for field %q at position %d", op.text, field.text, op.pos)
+	}
+	switch kind {
+	case kindBool:
+		if op.text != "==" && op.text != "!=" {
+			return invalidOp()
+		}
+		var b bool
+		switch value.text {
+		case "true":
+			b = true
+		case "false":
+		default:
+			return nil, fmt.Errorf("invalid boolean %q at position %d", value.text, value.pos)
+		}
+		return p.newNode(&boolNode{field: field.text, value: b == (op.text == "==")})
+	case kindDuration:
+		if op.text == "=~" || op.text == "!~" || op.text == "in" {
+			return invalidOp()
+		}
+		d, err := time.ParseDuration(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q at position %d", value.text, value.pos)
+		}
+		return p.newNode(&durationNode{field: field.text, op: op.text, value: d})
+	default:
+		n := &stringNode{field: field.text, op: op.text, value: value.text}
+		switch op.text {
+		case "==", "!=":
+		case "=~", "!~":
+			regex, err := regexp.Compile(value.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q at position %d: %s", value.text, value.pos, err)
+			}
+			n.regex = regex
+		case "in":
+			_, cidr, err := net.ParseCIDR(value.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q at position %d", value.text, value.pos)
+			}
+			n.cidr = cidr
+		default:
+			return invalidOp()
+		}
+		return p.newNode(n)
+	}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~", "in":
+		return true
+	}
+	return false
+}
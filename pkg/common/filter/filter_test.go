@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = Describe("filter", func() {
+	var (
+		failed = &nwpd.Observation{
+			JobID:    "tcp-n2api-ext",
+			SrcHost:  "node-1",
+			DestHost: "10.250.3.17",
+			Duration: durationpb.New(3 * time.Second),
+			Period:   durationpb.New(10 * time.Second),
+			Result:   "dial tcp 10.250.3.17:443: i/o timeout",
+			Labels:   map[string]string{"pool": "canary"},
+		}
+		ok = &nwpd.Observation{
+			JobID:        "ping-n2n",
+			SrcHost:      "node-1",
+			DestHost:     "node-2",
+			Duration:     durationpb.New(2 * time.Millisecond),
+			Ok:           true,
+			ResultFields: map[string]string{"packetLoss": "0"},
+		}
+	)
+
+	DescribeTable("matches observations",
+		func(expr string, matchFailed, matchOk bool) {
+			e, err := Parse(expr)
+			Expect(err).To(BeNil())
+			Expect(e.Match(failed)).To(Equal(matchFailed), "failed observation")
+			Expect(e.Match(ok)).To(Equal(matchOk), "ok observation")
+		},
+		Entry("boolean field", "ok", false, true),
+		Entry("negation", "!ok", true, false),
+		Entry("boolean comparison", "ok == false", true, false),
+		Entry("stale", "stale", false, false),
+		Entry("string equality", "destHost == node-2", false, true),
+		Entry("quoted string", `result == "dial tcp 10.250.3.17:443: i/o timeout"`, true, false),
+		Entry("string inequality", `srcHost != "node-1"`, false, false),
+		Entry("regular expression", `jobID =~ "^tcp-"`, true, false),
+		Entry("negated regular expression", `jobID !~ "^tcp-"`, false, true),
+		Entry("CIDR", "destHost in 10.250.3.0/24", true, false),
+		Entry("CIDR without match", "destHost in 10.250.4.0/24", false, false),
+		Entry("duration", "duration > 2s", true, false),
+		Entry("duration less or equal", "duration <= 2ms", false, true),
+		Entry("period", "period == 10s", true, false),
+		Entry("job label", "labels.pool == canary", true, false),
+		Entry("missing job label", `labels.pool == ""`, false, true),
+		Entry("result field", "fields.packetLoss == 0", false, true),
+		Entry("missing result field", `fields.packetLoss == ""`, true, false),
+		Entry("conjunction", "!ok && destHost in 10.250.3.0/24 && duration > 2s", true, false),
+		Entry("disjunction", "ok || duration>2s", true, true),
+		Entry("precedence of and over or", "ok || srcHost == node-1 && duration > 2s", true, true),
+		Entry("parentheses", "(ok || srcHost == node-1) && duration > 2s", true, false),
+		Entry("double negation", "!!ok", false, true),
+	)
+
+	DescribeTable("rejects malformed expressions",
+		func(expr, msg string) {
+			_, err := Parse(expr)
+			Expect(err).To(MatchError(ContainSubstring(msg)))
+		},
+		Entry("empty", "  ", "empty filter expression"),
+		Entry("unknown field", "host == x", `unknown field "host"`),
+		Entry("missing value", "jobID ==", "expected value"),
+		Entry("missing operator", "jobID", "expected comparison operator"),
+		Entry("invalid duration", "duration > fast", "invalid duration"),
+		Entry("invalid boolean", "ok == yes", "invalid boolean"),
+		Entry("invalid regular expression", `jobID =~ "("`, "invalid regular expression"),
+		Entry("invalid CIDR", "destHost in 10.250.3.0", "invalid CIDR"),
+		Entry("unsupported operator", "jobID > a", "not supported"),
+		Entry("unbalanced parentheses", "(ok", "expected ')'"),
+		Entry("trailing tokens", "ok ok", "unexpected 'ok'"),
+		Entry("unterminated string", `jobID == "x`, "unterminated string"),
+		Entry("invalid character", "jobID == x;", "unexpected character"),
+		Entry("too long", "ok || "+strings.Repeat("x", MaxExpressionLength), "too long"),
+		Entry("too complex", strings.Repeat("!", 200)+"ok", "too complex"),
+	)
+})
@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+)
+
+func FuzzParse(f *testing.F) {
+	f.Add("!ok && destHost in 10.250.3.0/24 && duration > 2s")
+	f.Add(`jobID =~ "^tcp-" || labels.pool == canary`)
+	f.Add("((stale)")
+	f.Add(`result == "\"`)
+
+	obs := &nwpd.Observation{JobID: "tcp", DestHost: "10.0.0.1"}
+	f.Fuzz(func(_ *testing.T, expr string) {
+		e, err := Parse(expr)
+		if err != nil {
+			return
+		}
+		_ = e.Match(obs)
+	})
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenOp
+	tokenWord
+	tokenString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) isOp(op string) bool {
+	return t.kind == tokenOp && t.text == op
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// operators sorted so that longer operators are matched first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_.-/:", c) >= 0
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+outer:
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			s, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: i})
+			i = end + 1
+			continue
+		case isWordChar(c):
+			end := i
+			for end < len(expr) && isWordChar(expr[end]) {
+				end++
+			}
+			t := token{kind: tokenWord, text: expr[i:end], pos: i}
+			if t.text == "in" {
+				t.kind = tokenOp
+			}
+			tokens = append(tokens, t)
+			i = end
+			continue
+		}
+		for _, op := range operators {
+			if strings.HasPrefix(expr[i:], op) {
+				tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+				i += len(op)
+				continue outer
+			}
+		}
+		return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
@@ -0,0 +1,14 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package nwpd
+
+const (
+	// JobRunKindRun is the kind of the record of a finished run.
+	JobRunKindRun = "run"
+	// JobRunKindCancelled is the kind of the record if the scheduled runs of a job have been stopped.
+	JobRunKindCancelled = "cancelled"
+	// JobRunKindAgentStart is the kind of the record of the start of the agent.
+	JobRunKindAgentStart = "agentStart"
+)
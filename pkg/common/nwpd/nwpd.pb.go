@@ -41,6 +41,37 @@ type GetObservationsRequest struct {
 	RestrictToDestHosts []string               `protobuf:"bytes,6,rep,name=restrictToDestHosts,proto3" json:"restrictToDestHosts,omitempty"`
 	AggregationWindow   *durationpb.Duration   `protobuf:"bytes,7,opt,name=aggregationWindow,proto3" json:"aggregationWindow,omitempty"`
 	FailuresOnly        bool                   `protobuf:"varint,8,opt,name=failuresOnly,proto3" json:"failuresOnly,omitempty"`
+	// includeNoDataEdges adds all currently valid edges without observations in an aggregation window (only for aggregated observations)
+	IncludeNoDataEdges bool `protobuf:"varint,9,opt,name=includeNoDataEdges,proto3" json:"includeNoDataEdges,omitempty"`
+	// restrictToLabels only returns observations of jobs having all of these labels
+	RestrictToLabels map[string]string `protobuf:"bytes,10,rep,name=restrictToLabels,proto3" json:"restrictToLabels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// filter is an expression evaluated on the agent to select observations, e.g. `!ok && duration > 2s`
+	Filter string `protobuf:"bytes,11,opt,name=filter,proto3" json:"filter,omitempty"`
+	// restrictToResultFields only returns observations having all of these result field values
+	RestrictToResultFields map[string]string `protobuf:"bytes,12,rep,name=restrictToResultFields,proto3" json:"restrictToResultFields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// jobIDRegex only returns observations with job IDs matching this regular expression (in addition to restrictToJobIDs)
+	JobIDRegex string `protobuf:"bytes,13,opt,name=jobIDRegex,proto3" json:"jobIDRegex,omitempty"`
+	// srcHostRegex only returns observations with source hosts matching this regular expression (in addition to restrictToSrcHosts)
+	SrcHostRegex string `protobuf:"bytes,14,opt,name=srcHostRegex,proto3" json:"srcHostRegex,omitempty"`
+	// destHostRegex only returns observations with destination hosts matching this regular expression (in addition to restrictToDestHosts)
+	DestHostRegex string `protobuf:"bytes,15,opt,name=destHostRegex,proto3" json:"destHostRegex,omitempty"`
+	// aggregateBy is the grouping of the aggregated observations: `host` (default) for edges, `job` for jobs, `srcHost` for source hosts,
+	// `destHost` for destination hosts or `zone` for zone pairs. Host fields not used for grouping are set to `*`
+	AggregateBy string `protobuf:"bytes,16,opt,name=aggregateBy,proto3" json:"aggregateBy,omitempty"`
+	// pageToken continues the listing after the last observation of the previous page (the `nextPageToken` of its response)
+	PageToken string `protobuf:"bytes,17,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	// sortBy is the field to sort the observations by before applying the limit: `timestamp` (default), `duration` or `jobID`
+	SortBy string `protobuf:"bytes,18,opt,name=sortBy,proto3" json:"sortBy,omitempty"`
+	// sortDescending sorts the observations in descending order, page tokens are only supported for sorting by timestamp ascending
+	SortDescending bool `protobuf:"varint,19,opt,name=sortDescending,proto3" json:"sortDescending,omitempty"`
+	// resultPattern only returns observations with a result matching this regular expression (RE2 syntax).
+	// As the result text is not persisted, older observations are matched against their result fields formatted as `key=value` pairs
+	ResultPattern string `protobuf:"bytes,20,opt,name=resultPattern,proto3" json:"resultPattern,omitempty"`
+	// minDuration only returns observations with a duration longer than this duration
+	MinDuration *durationpb.Duration `protobuf:"bytes,21,opt,name=minDuration,proto3" json:"minDuration,omitempty"`
+	// onlyFailureStreaksOfAtLeast only returns failed observations being part of a run of at least this number of consecutive
+	// failures of the same job and edge in the time range
+	OnlyFailureStreaksOfAtLeast int32 `protobuf:"varint,22,opt,name=onlyFailureStreaksOfAtLeast,proto3" json:"onlyFailureStreaksOfAtLeast,omitempty"`
 }
 
 func (x *GetObservationsRequest) Reset() {
@@ -131,12 +162,112 @@ func (x *GetObservationsRequest) GetFailuresOnly() bool {
 	return false
 }
 
+func (x *GetObservationsRequest) GetIncludeNoDataEdges() bool {
+	if x != nil {
+		return x.IncludeNoDataEdges
+	}
+	return false
+}
+
+func (x *GetObservationsRequest) GetRestrictToLabels() map[string]string {
+	if x != nil {
+		return x.RestrictToLabels
+	}
+	return nil
+}
+
+func (x *GetObservationsRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetRestrictToResultFields() map[string]string {
+	if x != nil {
+		return x.RestrictToResultFields
+	}
+	return nil
+}
+
+func (x *GetObservationsRequest) GetJobIDRegex() string {
+	if x != nil {
+		return x.JobIDRegex
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetSrcHostRegex() string {
+	if x != nil {
+		return x.SrcHostRegex
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetDestHostRegex() string {
+	if x != nil {
+		return x.DestHostRegex
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetAggregateBy() string {
+	if x != nil {
+		return x.AggregateBy
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetSortDescending() bool {
+	if x != nil {
+		return x.SortDescending
+	}
+	return false
+}
+
+func (x *GetObservationsRequest) GetResultPattern() string {
+	if x != nil {
+		return x.ResultPattern
+	}
+	return ""
+}
+
+func (x *GetObservationsRequest) GetMinDuration() *durationpb.Duration {
+	if x != nil {
+		return x.MinDuration
+	}
+	return nil
+}
+
+func (x *GetObservationsRequest) GetOnlyFailureStreaksOfAtLeast() int32 {
+	if x != nil {
+		return x.OnlyFailureStreaksOfAtLeast
+	}
+	return 0
+}
+
 type GetObservationsResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Observations []*Observation `protobuf:"bytes,1,rep,name=observations,proto3" json:"observations,omitempty"`
+	// nextPageToken is set if more observations are available than the limit of the request (only for sorting by timestamp ascending)
+	NextPageToken string `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
 }
 
 func (x *GetObservationsResponse) Reset() {
@@ -178,6 +309,13 @@ func (x *GetObservationsResponse) GetObservations() []*Observation {
 	return nil
 }
 
+func (x *GetObservationsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
 type GetAggregatedObservationsResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -237,6 +375,19 @@ type AggregatedObservation struct {
 	JobsOkCount    map[string]int32                `protobuf:"bytes,5,rep,name=jobsOkCount,proto3" json:"jobsOkCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 	JobsNotOkCount map[string]int32                `protobuf:"bytes,6,rep,name=jobsNotOkCount,proto3" json:"jobsNotOkCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 	MeanOkDuration map[string]*durationpb.Duration `protobuf:"bytes,7,rep,name=meanOkDuration,proto3" json:"meanOkDuration,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// noData is true if the edge is currently valid, but there are no observations in the period
+	NoData bool `protobuf:"varint,8,opt,name=noData,proto3" json:"noData,omitempty"`
+	// notValidInPeriod is true for noData edges which were not known as valid during the period
+	NotValidInPeriod bool `protobuf:"varint,9,opt,name=notValidInPeriod,proto3" json:"notValidInPeriod,omitempty"`
+	// jobsStaleCount counts the observations of stale destination endpoints (not included in jobsNotOkCount)
+	JobsStaleCount map[string]int32 `protobuf:"bytes,10,rep,name=jobsStaleCount,proto3" json:"jobsStaleCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// p50OkDuration, p95OkDuration and p99OkDuration are the estimated percentiles of the durations of the successful observations
+	P50OkDuration map[string]*durationpb.Duration `protobuf:"bytes,11,rep,name=p50OkDuration,proto3" json:"p50OkDuration,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	P95OkDuration map[string]*durationpb.Duration `protobuf:"bytes,12,rep,name=p95OkDuration,proto3" json:"p95OkDuration,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	P99OkDuration map[string]*durationpb.Duration `protobuf:"bytes,13,rep,name=p99OkDuration,proto3" json:"p99OkDuration,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// srcZone and destZone are the zones of the edge (only for aggregation by zone)
+	SrcZone  string `protobuf:"bytes,14,opt,name=srcZone,proto3" json:"srcZone,omitempty"`
+	DestZone string `protobuf:"bytes,15,opt,name=destZone,proto3" json:"destZone,omitempty"`
 }
 
 func (x *AggregatedObservation) Reset() {
@@ -320,6 +471,62 @@ func (x *AggregatedObservation) GetMeanOkDuration() map[string]*durationpb.Durat
 	return nil
 }
 
+func (x *AggregatedObservation) GetNoData() bool {
+	if x != nil {
+		return x.NoData
+	}
+	return false
+}
+
+func (x *AggregatedObservation) GetNotValidInPeriod() bool {
+	if x != nil {
+		return x.NotValidInPeriod
+	}
+	return false
+}
+
+func (x *AggregatedObservation) GetJobsStaleCount() map[string]int32 {
+	if x != nil {
+		return x.JobsStaleCount
+	}
+	return nil
+}
+
+func (x *AggregatedObservation) GetP50OkDuration() map[string]*durationpb.Duration {
+	if x != nil {
+		return x.P50OkDuration
+	}
+	return nil
+}
+
+func (x *AggregatedObservation) GetP95OkDuration() map[string]*durationpb.Duration {
+	if x != nil {
+		return x.P95OkDuration
+	}
+	return nil
+}
+
+func (x *AggregatedObservation) GetP99OkDuration() map[string]*durationpb.Duration {
+	if x != nil {
+		return x.P99OkDuration
+	}
+	return nil
+}
+
+func (x *AggregatedObservation) GetSrcZone() string {
+	if x != nil {
+		return x.SrcZone
+	}
+	return ""
+}
+
+func (x *AggregatedObservation) GetDestZone() string {
+	if x != nil {
+		return x.DestZone
+	}
+	return ""
+}
+
 type Observation struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -333,6 +540,17 @@ type Observation struct {
 	Result    string                 `protobuf:"bytes,6,opt,name=result,proto3" json:"result,omitempty"` // not persisted
 	Ok        bool                   `protobuf:"varint,7,opt,name=ok,proto3" json:"ok,omitempty"`
 	Period    *durationpb.Duration   `protobuf:"bytes,8,opt,name=period,proto3" json:"period,omitempty"`
+	// labels are the user-defined labels of the job
+	Labels map[string]string `protobuf:"bytes,9,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// staleEndpoint is true if the destination pod endpoint is outdated (the peer did not echo the expected pod UID)
+	StaleEndpoint bool `protobuf:"varint,10,opt,name=staleEndpoint,proto3" json:"staleEndpoint,omitempty"`
+	// incidentID is the ID of the open incident of the edge (only set for failed observations)
+	IncidentID string `protobuf:"bytes,11,opt,name=incidentID,proto3" json:"incidentID,omitempty"`
+	// resultFields are the structured values of the result, e.g. `httpStatus` or `pathMTU`
+	ResultFields map[string]string `protobuf:"bytes,12,rep,name=resultFields,proto3" json:"resultFields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// srcZone and destZone are the zones of the source and destination nodes, empty if the destination is not a node
+	SrcZone  string `protobuf:"bytes,13,opt,name=srcZone,proto3" json:"srcZone,omitempty"`
+	DestZone string `protobuf:"bytes,14,opt,name=destZone,proto3" json:"destZone,omitempty"`
 }
 
 func (x *Observation) Reset() {
@@ -423,22 +641,60 @@ func (x *Observation) GetPeriod() *durationpb.Duration {
 	return nil
 }
 
-type IntObservation struct {
+func (x *Observation) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Observation) GetStaleEndpoint() bool {
+	if x != nil {
+		return x.StaleEndpoint
+	}
+	return false
+}
+
+func (x *Observation) GetIncidentID() string {
+	if x != nil {
+		return x.IncidentID
+	}
+	return ""
+}
+
+func (x *Observation) GetResultFields() map[string]string {
+	if x != nil {
+		return x.ResultFields
+	}
+	return nil
+}
+
+func (x *Observation) GetSrcZone() string {
+	if x != nil {
+		return x.SrcZone
+	}
+	return ""
+}
+
+func (x *Observation) GetDestZone() string {
+	if x != nil {
+		return x.DestZone
+	}
+	return ""
+}
+
+type TriggerJobRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	JobID          int64 `protobuf:"varint,1,opt,name=JobID,proto3" json:"JobID,omitempty"`
-	SrcHost        int64 `protobuf:"varint,2,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
-	DestHost       int64 `protobuf:"varint,3,opt,name=destHost,proto3" json:"destHost,omitempty"`
-	TimeMillis     int64 `protobuf:"varint,4,opt,name=timeMillis,proto3" json:"timeMillis,omitempty"`
-	DurationMillis int32 `protobuf:"varint,5,opt,name=durationMillis,proto3" json:"durationMillis,omitempty"`
-	Ok             bool  `protobuf:"varint,6,opt,name=ok,proto3" json:"ok,omitempty"`
-	PeriodMillis   int32 `protobuf:"varint,7,opt,name=periodMillis,proto3" json:"periodMillis,omitempty"`
+	JobID string `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	// restrictToDestHosts only probes these destinations if not empty
+	RestrictToDestHosts []string `protobuf:"bytes,2,rep,name=restrictToDestHosts,proto3" json:"restrictToDestHosts,omitempty"`
 }
 
-func (x *IntObservation) Reset() {
-	*x = IntObservation{}
+func (x *TriggerJobRequest) Reset() {
+	*x = TriggerJobRequest{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -446,13 +702,13 @@ func (x *IntObservation) Reset() {
 	}
 }
 
-func (x *IntObservation) String() string {
+func (x *TriggerJobRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IntObservation) ProtoMessage() {}
+func (*TriggerJobRequest) ProtoMessage() {}
 
-func (x *IntObservation) ProtoReflect() protoreflect.Message {
+func (x *TriggerJobRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -464,85 +720,95 @@ func (x *IntObservation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IntObservation.ProtoReflect.Descriptor instead.
-func (*IntObservation) Descriptor() ([]byte, []int) {
+// Deprecated: Use TriggerJobRequest.ProtoReflect.Descriptor instead.
+func (*TriggerJobRequest) Descriptor() ([]byte, []int) {
 	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *IntObservation) GetJobID() int64 {
+func (x *TriggerJobRequest) GetJobID() string {
 	if x != nil {
 		return x.JobID
 	}
-	return 0
+	return ""
 }
 
-func (x *IntObservation) GetSrcHost() int64 {
+func (x *TriggerJobRequest) GetRestrictToDestHosts() []string {
 	if x != nil {
-		return x.SrcHost
+		return x.RestrictToDestHosts
 	}
-	return 0
+	return nil
 }
 
-func (x *IntObservation) GetDestHost() int64 {
-	if x != nil {
-		return x.DestHost
-	}
-	return 0
+type TriggerJobResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Observations []*Observation `protobuf:"bytes,1,rep,name=observations,proto3" json:"observations,omitempty"`
 }
 
-func (x *IntObservation) GetTimeMillis() int64 {
-	if x != nil {
-		return x.TimeMillis
+func (x *TriggerJobResponse) Reset() {
+	*x = TriggerJobResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return 0
 }
 
-func (x *IntObservation) GetDurationMillis() int32 {
-	if x != nil {
-		return x.DurationMillis
-	}
-	return 0
+func (x *TriggerJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *IntObservation) GetOk() bool {
-	if x != nil {
-		return x.Ok
+func (*TriggerJobResponse) ProtoMessage() {}
+
+func (x *TriggerJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *IntObservation) GetPeriodMillis() int32 {
+// Deprecated: Use TriggerJobResponse.ProtoReflect.Descriptor instead.
+func (*TriggerJobResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TriggerJobResponse) GetObservations() []*Observation {
 	if x != nil {
-		return x.PeriodMillis
+		return x.Observations
 	}
-	return 0
+	return nil
 }
 
-type Int64Arrays struct {
+type GetJobStatusRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-
-	Array []int64 `protobuf:"varint,1,rep,packed,name=array,proto3" json:"array,omitempty"`
 }
 
-func (x *Int64Arrays) Reset() {
-	*x = Int64Arrays{}
+func (x *GetJobStatusRequest) Reset() {
+	*x = GetJobStatusRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[6]
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Int64Arrays) String() string {
+func (x *GetJobStatusRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Int64Arrays) ProtoMessage() {}
+func (*GetJobStatusRequest) ProtoMessage() {}
 
-func (x *Int64Arrays) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[6]
+func (x *GetJobStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -553,44 +819,42 @@ func (x *Int64Arrays) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Int64Arrays.ProtoReflect.Descriptor instead.
-func (*Int64Arrays) Descriptor() ([]byte, []int) {
-	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{6}
-}
-
-func (x *Int64Arrays) GetArray() []int64 {
-	if x != nil {
-		return x.Array
-	}
-	return nil
+// Deprecated: Use GetJobStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetJobStatusRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{7}
 }
 
-type IntString struct {
+type GetJobStatusResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Key   int64  `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Jobs []*JobStatus `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// environment is the environment of the agent, either `kubernetes` or `standalone`
+	Environment string `protobuf:"bytes,2,opt,name=environment,proto3" json:"environment,omitempty"`
+	// disabledFeatures are the features disabled because of the environment with the reason
+	DisabledFeatures []string `protobuf:"bytes,3,rep,name=disabledFeatures,proto3" json:"disabledFeatures,omitempty"`
+	// localBlock is the result of the last local block diagnosis, if the detection is enabled
+	LocalBlock *LocalBlockStatus `protobuf:"bytes,4,opt,name=localBlock,proto3" json:"localBlock,omitempty"`
 }
 
-func (x *IntString) Reset() {
-	*x = IntString{}
+func (x *GetJobStatusResponse) Reset() {
+	*x = GetJobStatusResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[7]
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *IntString) String() string {
+func (x *GetJobStatusResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*IntString) ProtoMessage() {}
+func (*GetJobStatusResponse) ProtoMessage() {}
 
-func (x *IntString) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[7]
+func (x *GetJobStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -601,26 +865,1889 @@ func (x *IntString) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use IntString.ProtoReflect.Descriptor instead.
-func (*IntString) Descriptor() ([]byte, []int) {
-	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use GetJobStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetJobStatusResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *IntString) GetKey() int64 {
+func (x *GetJobStatusResponse) GetJobs() []*JobStatus {
 	if x != nil {
-		return x.Key
+		return x.Jobs
 	}
-	return 0
+	return nil
 }
 
-func (x *IntString) GetValue() string {
+func (x *GetJobStatusResponse) GetEnvironment() string {
 	if x != nil {
-		return x.Value
+		return x.Environment
 	}
 	return ""
 }
 
-var File_pkg_common_nwpd_nwpd_proto protoreflect.FileDescriptor
+func (x *GetJobStatusResponse) GetDisabledFeatures() []string {
+	if x != nil {
+		return x.DisabledFeatures
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetLocalBlock() *LocalBlockStatus {
+	if x != nil {
+		return x.LocalBlock
+	}
+	return nil
+}
+
+type GetConfigStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetConfigStatusRequest) Reset() {
+	*x = GetConfigStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetConfigStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigStatusRequest) ProtoMessage() {}
+
+func (x *GetConfigStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigStatusRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{9}
+}
+
+// GetConfigStatusResponse describes the configuration applied by the agent and the result of the last reload.
+type GetConfigStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// revision is incremented on each successful application of the agent configuration
+	Revision int64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	// lastApplied is the time of the last successful application of the agent configuration
+	LastApplied *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=lastApplied,proto3" json:"lastApplied,omitempty"`
+	// lastReload is the time of the last reload of the configuration files
+	LastReload *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=lastReload,proto3" json:"lastReload,omitempty"`
+	// lastReloadError is the error of the last reload, empty if it has succeeded
+	LastReloadError string `protobuf:"bytes,4,opt,name=lastReloadError,proto3" json:"lastReloadError,omitempty"`
+	// consecutiveReloadFailures is the number of failed reloads since the last successful one
+	ConsecutiveReloadFailures int32 `protobuf:"varint,5,opt,name=consecutiveReloadFailures,proto3" json:"consecutiveReloadFailures,omitempty"`
+}
+
+func (x *GetConfigStatusResponse) Reset() {
+	*x = GetConfigStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetConfigStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigStatusResponse) ProtoMessage() {}
+
+func (x *GetConfigStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetConfigStatusResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetConfigStatusResponse) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *GetConfigStatusResponse) GetLastApplied() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastApplied
+	}
+	return nil
+}
+
+func (x *GetConfigStatusResponse) GetLastReload() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastReload
+	}
+	return nil
+}
+
+func (x *GetConfigStatusResponse) GetLastReloadError() string {
+	if x != nil {
+		return x.LastReloadError
+	}
+	return ""
+}
+
+func (x *GetConfigStatusResponse) GetConsecutiveReloadFailures() int32 {
+	if x != nil {
+		return x.ConsecutiveReloadFailures
+	}
+	return 0
+}
+
+type LocalBlockStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// suspected is true if the last diagnosis found the ports of the agent blocked locally
+	Suspected     bool                   `protobuf:"varint,1,opt,name=suspected,proto3" json:"suspected,omitempty"`
+	LastDiagnosis *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=lastDiagnosis,proto3" json:"lastDiagnosis,omitempty"`
+	// reason describes the findings of the last diagnosis
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *LocalBlockStatus) Reset() {
+	*x = LocalBlockStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LocalBlockStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocalBlockStatus) ProtoMessage() {}
+
+func (x *LocalBlockStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocalBlockStatus.ProtoReflect.Descriptor instead.
+func (*LocalBlockStatus) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LocalBlockStatus) GetSuspected() bool {
+	if x != nil {
+		return x.Suspected
+	}
+	return false
+}
+
+func (x *LocalBlockStatus) GetLastDiagnosis() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastDiagnosis
+	}
+	return nil
+}
+
+func (x *LocalBlockStatus) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type JobStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobID       string                 `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	Args        []string               `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	Period      *durationpb.Duration   `protobuf:"bytes,3,opt,name=period,proto3" json:"period,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Running     bool                   `protobuf:"varint,5,opt,name=running,proto3" json:"running,omitempty"`
+	LastRun     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=lastRun,proto3" json:"lastRun,omitempty"`
+	NextRun     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=nextRun,proto3" json:"nextRun,omitempty"`
+	// lastRunOk is the number of successful observations of the last finished run
+	LastRunOk int32 `protobuf:"varint,8,opt,name=lastRunOk,proto3" json:"lastRunOk,omitempty"`
+	// lastRunFailed is the number of failed observations of the last finished run
+	LastRunFailed int32 `protobuf:"varint,9,opt,name=lastRunFailed,proto3" json:"lastRunFailed,omitempty"`
+	// lastFailure is the result of the last failed observation of the last finished run
+	LastFailure string `protobuf:"bytes,10,opt,name=lastFailure,proto3" json:"lastFailure,omitempty"`
+	// consecutiveFailures is the number of consecutive runs with failed observations
+	ConsecutiveFailures int32 `protobuf:"varint,11,opt,name=consecutiveFailures,proto3" json:"consecutiveFailures,omitempty"`
+	// skipped is true if the job is not scheduled, as it has been skipped at parse time
+	Skipped bool `protobuf:"varint,12,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	// skipReason is the reason for skipping the job configuration (a scheduled job keeps running with its previous configuration)
+	SkipReason string `protobuf:"bytes,13,opt,name=skipReason,proto3" json:"skipReason,omitempty"`
+	// disabled is true if the job is disabled in the configuration (skipped is true too)
+	Disabled bool `protobuf:"varint,14,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	// backoffs are the destinations with consecutive failures in failure backoff
+	Backoffs []*DestinationBackoff `protobuf:"bytes,15,rep,name=backoffs,proto3" json:"backoffs,omitempty"`
+	// degraded is true if a secret referenced by the job cannot be resolved (a scheduled job keeps running with its previous secrets)
+	Degraded bool `protobuf:"varint,16,opt,name=degraded,proto3" json:"degraded,omitempty"`
+}
+
+func (x *JobStatus) Reset() {
+	*x = JobStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobStatus) ProtoMessage() {}
+
+func (x *JobStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobStatus.ProtoReflect.Descriptor instead.
+func (*JobStatus) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *JobStatus) GetJobID() string {
+	if x != nil {
+		return x.JobID
+	}
+	return ""
+}
+
+func (x *JobStatus) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *JobStatus) GetPeriod() *durationpb.Duration {
+	if x != nil {
+		return x.Period
+	}
+	return nil
+}
+
+func (x *JobStatus) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *JobStatus) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *JobStatus) GetLastRun() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastRun
+	}
+	return nil
+}
+
+func (x *JobStatus) GetNextRun() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextRun
+	}
+	return nil
+}
+
+func (x *JobStatus) GetLastRunOk() int32 {
+	if x != nil {
+		return x.LastRunOk
+	}
+	return 0
+}
+
+func (x *JobStatus) GetLastRunFailed() int32 {
+	if x != nil {
+		return x.LastRunFailed
+	}
+	return 0
+}
+
+func (x *JobStatus) GetLastFailure() string {
+	if x != nil {
+		return x.LastFailure
+	}
+	return ""
+}
+
+func (x *JobStatus) GetConsecutiveFailures() int32 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (x *JobStatus) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+func (x *JobStatus) GetSkipReason() string {
+	if x != nil {
+		return x.SkipReason
+	}
+	return ""
+}
+
+func (x *JobStatus) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *JobStatus) GetBackoffs() []*DestinationBackoff {
+	if x != nil {
+		return x.Backoffs
+	}
+	return nil
+}
+
+func (x *JobStatus) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+type DestinationBackoff struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DestHost string `protobuf:"bytes,1,opt,name=destHost,proto3" json:"destHost,omitempty"`
+	// failures is the number of consecutive failures
+	Failures int32 `protobuf:"varint,2,opt,name=failures,proto3" json:"failures,omitempty"`
+	// until is the end of the current delay, the destination is skipped by the scheduled runs before
+	Until *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`
+}
+
+func (x *DestinationBackoff) Reset() {
+	*x = DestinationBackoff{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DestinationBackoff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DestinationBackoff) ProtoMessage() {}
+
+func (x *DestinationBackoff) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DestinationBackoff.ProtoReflect.Descriptor instead.
+func (*DestinationBackoff) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DestinationBackoff) GetDestHost() string {
+	if x != nil {
+		return x.DestHost
+	}
+	return ""
+}
+
+func (x *DestinationBackoff) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *DestinationBackoff) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+type ListIncidentsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// start restricts to incidents open at or after this time (optional)
+	Start *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	// openOnly only returns the incidents not yet closed
+	OpenOnly            bool     `protobuf:"varint,2,opt,name=openOnly,proto3" json:"openOnly,omitempty"`
+	RestrictToJobIDs    []string `protobuf:"bytes,3,rep,name=restrictToJobIDs,proto3" json:"restrictToJobIDs,omitempty"`
+	RestrictToDestHosts []string `protobuf:"bytes,4,rep,name=restrictToDestHosts,proto3" json:"restrictToDestHosts,omitempty"`
+}
+
+func (x *ListIncidentsRequest) Reset() {
+	*x = ListIncidentsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListIncidentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncidentsRequest) ProtoMessage() {}
+
+func (x *ListIncidentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncidentsRequest.ProtoReflect.Descriptor instead.
+func (*ListIncidentsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListIncidentsRequest) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *ListIncidentsRequest) GetOpenOnly() bool {
+	if x != nil {
+		return x.OpenOnly
+	}
+	return false
+}
+
+func (x *ListIncidentsRequest) GetRestrictToJobIDs() []string {
+	if x != nil {
+		return x.RestrictToJobIDs
+	}
+	return nil
+}
+
+func (x *ListIncidentsRequest) GetRestrictToDestHosts() []string {
+	if x != nil {
+		return x.RestrictToDestHosts
+	}
+	return nil
+}
+
+type ListIncidentsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Incidents []*Incident `protobuf:"bytes,1,rep,name=incidents,proto3" json:"incidents,omitempty"`
+}
+
+func (x *ListIncidentsResponse) Reset() {
+	*x = ListIncidentsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListIncidentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIncidentsResponse) ProtoMessage() {}
+
+func (x *ListIncidentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIncidentsResponse.ProtoReflect.Descriptor instead.
+func (*ListIncidentsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListIncidentsResponse) GetIncidents() []*Incident {
+	if x != nil {
+		return x.Incidents
+	}
+	return nil
+}
+
+// Incident correlates the consecutive failures of an edge from the transition to failing until its recovery.
+type Incident struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// incidentID is a ULID assigned when the edge transitions to failing
+	IncidentID string `protobuf:"bytes,1,opt,name=incidentID,proto3" json:"incidentID,omitempty"`
+	JobID      string `protobuf:"bytes,2,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	SrcHost    string `protobuf:"bytes,3,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
+	DestHost   string `protobuf:"bytes,4,opt,name=destHost,proto3" json:"destHost,omitempty"`
+	// start is the time of the first failure
+	Start *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start,proto3" json:"start,omitempty"`
+	// end is the time of the recovery, not set if the incident is open
+	End                *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=end,proto3" json:"end,omitempty"`
+	LastFailure        *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=lastFailure,proto3" json:"lastFailure,omitempty"`
+	FailedCount        int32                  `protobuf:"varint,8,opt,name=failedCount,proto3" json:"failedCount,omitempty"`
+	OkCount            int32                  `protobuf:"varint,9,opt,name=okCount,proto3" json:"okCount,omitempty"`
+	FirstFailureReason string                 `protobuf:"bytes,10,opt,name=firstFailureReason,proto3" json:"firstFailureReason,omitempty"`
+	LastFailureReason  string                 `protobuf:"bytes,11,opt,name=lastFailureReason,proto3" json:"lastFailureReason,omitempty"`
+}
+
+func (x *Incident) Reset() {
+	*x = Incident{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Incident) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Incident) ProtoMessage() {}
+
+func (x *Incident) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Incident.ProtoReflect.Descriptor instead.
+func (*Incident) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Incident) GetIncidentID() string {
+	if x != nil {
+		return x.IncidentID
+	}
+	return ""
+}
+
+func (x *Incident) GetJobID() string {
+	if x != nil {
+		return x.JobID
+	}
+	return ""
+}
+
+func (x *Incident) GetSrcHost() string {
+	if x != nil {
+		return x.SrcHost
+	}
+	return ""
+}
+
+func (x *Incident) GetDestHost() string {
+	if x != nil {
+		return x.DestHost
+	}
+	return ""
+}
+
+func (x *Incident) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Incident) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *Incident) GetLastFailure() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFailure
+	}
+	return nil
+}
+
+func (x *Incident) GetFailedCount() int32 {
+	if x != nil {
+		return x.FailedCount
+	}
+	return 0
+}
+
+func (x *Incident) GetOkCount() int32 {
+	if x != nil {
+		return x.OkCount
+	}
+	return 0
+}
+
+func (x *Incident) GetFirstFailureReason() string {
+	if x != nil {
+		return x.FirstFailureReason
+	}
+	return ""
+}
+
+func (x *Incident) GetLastFailureReason() string {
+	if x != nil {
+		return x.LastFailureReason
+	}
+	return ""
+}
+
+type GetSummaryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// limit restricts the number of returned edges (optional)
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *GetSummaryRequest) Reset() {
+	*x = GetSummaryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSummaryRequest) ProtoMessage() {}
+
+func (x *GetSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSummaryRequest.ProtoReflect.Descriptor instead.
+func (*GetSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetSummaryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// GetSummaryResponse contains the top failing edges of the last aggregation report.
+type GetSummaryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PeriodStart *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=periodStart,proto3" json:"periodStart,omitempty"`
+	PeriodEnd   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=periodEnd,proto3" json:"periodEnd,omitempty"`
+	// minChecks is the minimum number of checks of an edge to be included
+	MinChecks int32 `protobuf:"varint,3,opt,name=minChecks,proto3" json:"minChecks,omitempty"`
+	// edges are sorted by failure ratio descending
+	Edges []*FailingEdge `protobuf:"bytes,4,rep,name=edges,proto3" json:"edges,omitempty"`
+}
+
+func (x *GetSummaryResponse) Reset() {
+	*x = GetSummaryResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSummaryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSummaryResponse) ProtoMessage() {}
+
+func (x *GetSummaryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSummaryResponse.ProtoReflect.Descriptor instead.
+func (*GetSummaryResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetSummaryResponse) GetPeriodStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PeriodStart
+	}
+	return nil
+}
+
+func (x *GetSummaryResponse) GetPeriodEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PeriodEnd
+	}
+	return nil
+}
+
+func (x *GetSummaryResponse) GetMinChecks() int32 {
+	if x != nil {
+		return x.MinChecks
+	}
+	return 0
+}
+
+func (x *GetSummaryResponse) GetEdges() []*FailingEdge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+// FailingEdge summarizes the failed checks of all jobs between two hosts in a report period.
+type FailingEdge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SrcHost  string `protobuf:"bytes,1,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
+	DestHost string `protobuf:"bytes,2,opt,name=destHost,proto3" json:"destHost,omitempty"`
+	Checks   int32  `protobuf:"varint,3,opt,name=checks,proto3" json:"checks,omitempty"`
+	Failures int32  `protobuf:"varint,4,opt,name=failures,proto3" json:"failures,omitempty"`
+	// failureRatio is the share of failed checks in the range [0.0,1.0]
+	FailureRatio float64  `protobuf:"fixed64,5,opt,name=failureRatio,proto3" json:"failureRatio,omitempty"`
+	JobIDs       []string `protobuf:"bytes,6,rep,name=jobIDs,proto3" json:"jobIDs,omitempty"`
+	// ongoing is true if the last observation of any job of the edge has failed
+	Ongoing bool `protobuf:"varint,7,opt,name=ongoing,proto3" json:"ongoing,omitempty"`
+}
+
+func (x *FailingEdge) Reset() {
+	*x = FailingEdge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FailingEdge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailingEdge) ProtoMessage() {}
+
+func (x *FailingEdge) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailingEdge.ProtoReflect.Descriptor instead.
+func (*FailingEdge) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *FailingEdge) GetSrcHost() string {
+	if x != nil {
+		return x.SrcHost
+	}
+	return ""
+}
+
+func (x *FailingEdge) GetDestHost() string {
+	if x != nil {
+		return x.DestHost
+	}
+	return ""
+}
+
+func (x *FailingEdge) GetChecks() int32 {
+	if x != nil {
+		return x.Checks
+	}
+	return 0
+}
+
+func (x *FailingEdge) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *FailingEdge) GetFailureRatio() float64 {
+	if x != nil {
+		return x.FailureRatio
+	}
+	return 0
+}
+
+func (x *FailingEdge) GetJobIDs() []string {
+	if x != nil {
+		return x.JobIDs
+	}
+	return nil
+}
+
+func (x *FailingEdge) GetOngoing() bool {
+	if x != nil {
+		return x.Ongoing
+	}
+	return false
+}
+
+type GetFailuresSinceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// since is the start of the time window (optional, default 5 minutes ago)
+	Since               *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	RestrictToJobIDs    []string               `protobuf:"bytes,2,rep,name=restrictToJobIDs,proto3" json:"restrictToJobIDs,omitempty"`
+	RestrictToSrcHosts  []string               `protobuf:"bytes,3,rep,name=restrictToSrcHosts,proto3" json:"restrictToSrcHosts,omitempty"`
+	RestrictToDestHosts []string               `protobuf:"bytes,4,rep,name=restrictToDestHosts,proto3" json:"restrictToDestHosts,omitempty"`
+}
+
+func (x *GetFailuresSinceRequest) Reset() {
+	*x = GetFailuresSinceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFailuresSinceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFailuresSinceRequest) ProtoMessage() {}
+
+func (x *GetFailuresSinceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFailuresSinceRequest.ProtoReflect.Descriptor instead.
+func (*GetFailuresSinceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetFailuresSinceRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *GetFailuresSinceRequest) GetRestrictToJobIDs() []string {
+	if x != nil {
+		return x.RestrictToJobIDs
+	}
+	return nil
+}
+
+func (x *GetFailuresSinceRequest) GetRestrictToSrcHosts() []string {
+	if x != nil {
+		return x.RestrictToSrcHosts
+	}
+	return nil
+}
+
+func (x *GetFailuresSinceRequest) GetRestrictToDestHosts() []string {
+	if x != nil {
+		return x.RestrictToDestHosts
+	}
+	return nil
+}
+
+// GetFailuresSinceResponse contains the failures per edge and job since the start of the time window.
+type GetFailuresSinceResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Since *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	// edges are sorted by the time of the last failure descending
+	Edges []*EdgeFailures `protobuf:"bytes,2,rep,name=edges,proto3" json:"edges,omitempty"`
+	// truncated is true if there were too many failures and only the most recent ones are counted
+	Truncated bool `protobuf:"varint,3,opt,name=truncated,proto3" json:"truncated,omitempty"`
+}
+
+func (x *GetFailuresSinceResponse) Reset() {
+	*x = GetFailuresSinceResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFailuresSinceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFailuresSinceResponse) ProtoMessage() {}
+
+func (x *GetFailuresSinceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFailuresSinceResponse.ProtoReflect.Descriptor instead.
+func (*GetFailuresSinceResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetFailuresSinceResponse) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *GetFailuresSinceResponse) GetEdges() []*EdgeFailures {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+func (x *GetFailuresSinceResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// EdgeFailures counts the failed checks of a job between two hosts.
+type EdgeFailures struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobID             string                 `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	SrcHost           string                 `protobuf:"bytes,2,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
+	DestHost          string                 `protobuf:"bytes,3,opt,name=destHost,proto3" json:"destHost,omitempty"`
+	Failures          int32                  `protobuf:"varint,4,opt,name=failures,proto3" json:"failures,omitempty"`
+	LastFailure       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=lastFailure,proto3" json:"lastFailure,omitempty"`
+	LastFailureReason string                 `protobuf:"bytes,6,opt,name=lastFailureReason,proto3" json:"lastFailureReason,omitempty"`
+	// incidentID is the ID of the incident of the last failure if any
+	IncidentID string `protobuf:"bytes,7,opt,name=incidentID,proto3" json:"incidentID,omitempty"`
+}
+
+func (x *EdgeFailures) Reset() {
+	*x = EdgeFailures{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EdgeFailures) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EdgeFailures) ProtoMessage() {}
+
+func (x *EdgeFailures) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EdgeFailures.ProtoReflect.Descriptor instead.
+func (*EdgeFailures) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *EdgeFailures) GetJobID() string {
+	if x != nil {
+		return x.JobID
+	}
+	return ""
+}
+
+func (x *EdgeFailures) GetSrcHost() string {
+	if x != nil {
+		return x.SrcHost
+	}
+	return ""
+}
+
+func (x *EdgeFailures) GetDestHost() string {
+	if x != nil {
+		return x.DestHost
+	}
+	return ""
+}
+
+func (x *EdgeFailures) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *EdgeFailures) GetLastFailure() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastFailure
+	}
+	return nil
+}
+
+func (x *EdgeFailures) GetLastFailureReason() string {
+	if x != nil {
+		return x.LastFailureReason
+	}
+	return ""
+}
+
+func (x *EdgeFailures) GetIncidentID() string {
+	if x != nil {
+		return x.IncidentID
+	}
+	return ""
+}
+
+// IncidentSnapshot is the persisted state of the incidents of an agent.
+type IncidentSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Open []*Incident `protobuf:"bytes,1,rep,name=open,proto3" json:"open,omitempty"`
+	// closed are the summaries of the recently closed incidents
+	Closed []*Incident `protobuf:"bytes,2,rep,name=closed,proto3" json:"closed,omitempty"`
+}
+
+func (x *IncidentSnapshot) Reset() {
+	*x = IncidentSnapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IncidentSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncidentSnapshot) ProtoMessage() {}
+
+func (x *IncidentSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncidentSnapshot.ProtoReflect.Descriptor instead.
+func (*IncidentSnapshot) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *IncidentSnapshot) GetOpen() []*Incident {
+	if x != nil {
+		return x.Open
+	}
+	return nil
+}
+
+func (x *IncidentSnapshot) GetClosed() []*Incident {
+	if x != nil {
+		return x.Closed
+	}
+	return nil
+}
+
+type GetDailyRollupsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Start *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+}
+
+func (x *GetDailyRollupsRequest) Reset() {
+	*x = GetDailyRollupsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDailyRollupsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDailyRollupsRequest) ProtoMessage() {}
+
+func (x *GetDailyRollupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDailyRollupsRequest.ProtoReflect.Descriptor instead.
+func (*GetDailyRollupsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetDailyRollupsRequest) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *GetDailyRollupsRequest) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+type GetDailyRollupsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rollups []*DailyRollup `protobuf:"bytes,1,rep,name=rollups,proto3" json:"rollups,omitempty"`
+}
+
+func (x *GetDailyRollupsResponse) Reset() {
+	*x = GetDailyRollupsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDailyRollupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDailyRollupsResponse) ProtoMessage() {}
+
+func (x *GetDailyRollupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDailyRollupsResponse.ProtoReflect.Descriptor instead.
+func (*GetDailyRollupsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetDailyRollupsResponse) GetRollups() []*DailyRollup {
+	if x != nil {
+		return x.Rollups
+	}
+	return nil
+}
+
+// DailyRollup summarizes the observations of a node for one day (UTC).
+type DailyRollup struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// date in format YYYY-MM-DD
+	Date    string `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	SrcHost string `protobuf:"bytes,2,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
+	// missing is true if there are no observations for this day (e.g. agent was down)
+	Missing bool           `protobuf:"varint,3,opt,name=missing,proto3" json:"missing,omitempty"`
+	Entries []*RollupEntry `protobuf:"bytes,4,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *DailyRollup) Reset() {
+	*x = DailyRollup{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DailyRollup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DailyRollup) ProtoMessage() {}
+
+func (x *DailyRollup) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DailyRollup.ProtoReflect.Descriptor instead.
+func (*DailyRollup) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DailyRollup) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *DailyRollup) GetSrcHost() string {
+	if x != nil {
+		return x.SrcHost
+	}
+	return ""
+}
+
+func (x *DailyRollup) GetMissing() bool {
+	if x != nil {
+		return x.Missing
+	}
+	return false
+}
+
+func (x *DailyRollup) GetEntries() []*RollupEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type RollupEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobID       string               `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	DestClass   string               `protobuf:"bytes,2,opt,name=destClass,proto3" json:"destClass,omitempty"`
+	OkCount     int32                `protobuf:"varint,3,opt,name=okCount,proto3" json:"okCount,omitempty"`
+	NotOkCount  int32                `protobuf:"varint,4,opt,name=notOkCount,proto3" json:"notOkCount,omitempty"`
+	P50Duration *durationpb.Duration `protobuf:"bytes,5,opt,name=p50Duration,proto3" json:"p50Duration,omitempty"`
+	P90Duration *durationpb.Duration `protobuf:"bytes,6,opt,name=p90Duration,proto3" json:"p90Duration,omitempty"`
+	P99Duration *durationpb.Duration `protobuf:"bytes,7,opt,name=p99Duration,proto3" json:"p99Duration,omitempty"`
+}
+
+func (x *RollupEntry) Reset() {
+	*x = RollupEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RollupEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollupEntry) ProtoMessage() {}
+
+func (x *RollupEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollupEntry.ProtoReflect.Descriptor instead.
+func (*RollupEntry) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RollupEntry) GetJobID() string {
+	if x != nil {
+		return x.JobID
+	}
+	return ""
+}
+
+func (x *RollupEntry) GetDestClass() string {
+	if x != nil {
+		return x.DestClass
+	}
+	return ""
+}
+
+func (x *RollupEntry) GetOkCount() int32 {
+	if x != nil {
+		return x.OkCount
+	}
+	return 0
+}
+
+func (x *RollupEntry) GetNotOkCount() int32 {
+	if x != nil {
+		return x.NotOkCount
+	}
+	return 0
+}
+
+func (x *RollupEntry) GetP50Duration() *durationpb.Duration {
+	if x != nil {
+		return x.P50Duration
+	}
+	return nil
+}
+
+func (x *RollupEntry) GetP90Duration() *durationpb.Duration {
+	if x != nil {
+		return x.P90Duration
+	}
+	return nil
+}
+
+func (x *RollupEntry) GetP99Duration() *durationpb.Duration {
+	if x != nil {
+		return x.P99Duration
+	}
+	return nil
+}
+
+type IntObservation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	JobID          int64 `protobuf:"varint,1,opt,name=JobID,proto3" json:"JobID,omitempty"`
+	SrcHost        int64 `protobuf:"varint,2,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
+	DestHost       int64 `protobuf:"varint,3,opt,name=destHost,proto3" json:"destHost,omitempty"`
+	TimeMillis     int64 `protobuf:"varint,4,opt,name=timeMillis,proto3" json:"timeMillis,omitempty"`
+	DurationMillis int32 `protobuf:"varint,5,opt,name=durationMillis,proto3" json:"durationMillis,omitempty"`
+	Ok             bool  `protobuf:"varint,6,opt,name=ok,proto3" json:"ok,omitempty"`
+	PeriodMillis   int32 `protobuf:"varint,7,opt,name=periodMillis,proto3" json:"periodMillis,omitempty"`
+	// labels maps the IDs of label keys to the IDs of label values
+	Labels        map[int64]int64 `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	StaleEndpoint bool            `protobuf:"varint,9,opt,name=staleEndpoint,proto3" json:"staleEndpoint,omitempty"`
+	// incidentID is the ID of the incident ID string or 0 if not set
+	IncidentID int64 `protobuf:"varint,10,opt,name=incidentID,proto3" json:"incidentID,omitempty"`
+	// resultFields maps the IDs of result field names to the values
+	ResultFields map[int64]string `protobuf:"bytes,11,rep,name=resultFields,proto3" json:"resultFields,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// srcZone and destZone are the IDs of the zone strings
+	SrcZone  int64 `protobuf:"varint,12,opt,name=srcZone,proto3" json:"srcZone,omitempty"`
+	DestZone int64 `protobuf:"varint,13,opt,name=destZone,proto3" json:"destZone,omitempty"`
+}
+
+func (x *IntObservation) Reset() {
+	*x = IntObservation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IntObservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntObservation) ProtoMessage() {}
+
+func (x *IntObservation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntObservation.ProtoReflect.Descriptor instead.
+func (*IntObservation) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *IntObservation) GetJobID() int64 {
+	if x != nil {
+		return x.JobID
+	}
+	return 0
+}
+
+func (x *IntObservation) GetSrcHost() int64 {
+	if x != nil {
+		return x.SrcHost
+	}
+	return 0
+}
+
+func (x *IntObservation) GetDestHost() int64 {
+	if x != nil {
+		return x.DestHost
+	}
+	return 0
+}
+
+func (x *IntObservation) GetTimeMillis() int64 {
+	if x != nil {
+		return x.TimeMillis
+	}
+	return 0
+}
+
+func (x *IntObservation) GetDurationMillis() int32 {
+	if x != nil {
+		return x.DurationMillis
+	}
+	return 0
+}
+
+func (x *IntObservation) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *IntObservation) GetPeriodMillis() int32 {
+	if x != nil {
+		return x.PeriodMillis
+	}
+	return 0
+}
+
+func (x *IntObservation) GetLabels() map[int64]int64 {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *IntObservation) GetStaleEndpoint() bool {
+	if x != nil {
+		return x.StaleEndpoint
+	}
+	return false
+}
+
+func (x *IntObservation) GetIncidentID() int64 {
+	if x != nil {
+		return x.IncidentID
+	}
+	return 0
+}
+
+func (x *IntObservation) GetResultFields() map[int64]string {
+	if x != nil {
+		return x.ResultFields
+	}
+	return nil
+}
+
+func (x *IntObservation) GetSrcZone() int64 {
+	if x != nil {
+		return x.SrcZone
+	}
+	return 0
+}
+
+func (x *IntObservation) GetDestZone() int64 {
+	if x != nil {
+		return x.DestZone
+	}
+	return 0
+}
+
+// JobRunRecord is persisted in the record files for each finished run of a job and for events stopping the runs.
+// It explains the gaps between consecutive observations of an edge.
+type JobRunRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// kind is `run` for a finished run, `cancelled` if the scheduled runs of the job have been stopped,
+	// or `agentStart` for the start of the agent (without job ID)
+	Kind    string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	JobID   string `protobuf:"bytes,2,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	SrcHost string `protobuf:"bytes,3,opt,name=srcHost,proto3" json:"srcHost,omitempty"`
+	// start is the start of the run or the time of the event
+	Start  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start,proto3" json:"start,omitempty"`
+	End    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=end,proto3" json:"end,omitempty"`
+	Period *durationpb.Duration   `protobuf:"bytes,6,opt,name=period,proto3" json:"period,omitempty"`
+	// delay is the time the start of the run was deferred by the limit of concurrent jobs
+	Delay *durationpb.Duration `protobuf:"bytes,7,opt,name=delay,proto3" json:"delay,omitempty"`
+	// destHosts are the destinations probed by the run
+	DestHosts []string `protobuf:"bytes,8,rep,name=destHosts,proto3" json:"destHosts,omitempty"`
+	// destHostsOmitted is true if the destinations have been too many to be persisted
+	DestHostsOmitted bool `protobuf:"varint,9,opt,name=destHostsOmitted,proto3" json:"destHostsOmitted,omitempty"`
+	// droppedObservations is the number of observations of the run dropped because the observation buffer was full
+	DroppedObservations int32 `protobuf:"varint,10,opt,name=droppedObservations,proto3" json:"droppedObservations,omitempty"`
+	// reason is the reason of a cancellation, e.g. `disabled` or `removed`
+	Reason string `protobuf:"bytes,11,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *JobRunRecord) Reset() {
+	*x = JobRunRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JobRunRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRunRecord) ProtoMessage() {}
+
+func (x *JobRunRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRunRecord.ProtoReflect.Descriptor instead.
+func (*JobRunRecord) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *JobRunRecord) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *JobRunRecord) GetJobID() string {
+	if x != nil {
+		return x.JobID
+	}
+	return ""
+}
+
+func (x *JobRunRecord) GetSrcHost() string {
+	if x != nil {
+		return x.SrcHost
+	}
+	return ""
+}
+
+func (x *JobRunRecord) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *JobRunRecord) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *JobRunRecord) GetPeriod() *durationpb.Duration {
+	if x != nil {
+		return x.Period
+	}
+	return nil
+}
+
+func (x *JobRunRecord) GetDelay() *durationpb.Duration {
+	if x != nil {
+		return x.Delay
+	}
+	return nil
+}
+
+func (x *JobRunRecord) GetDestHosts() []string {
+	if x != nil {
+		return x.DestHosts
+	}
+	return nil
+}
+
+func (x *JobRunRecord) GetDestHostsOmitted() bool {
+	if x != nil {
+		return x.DestHostsOmitted
+	}
+	return false
+}
+
+func (x *JobRunRecord) GetDroppedObservations() int32 {
+	if x != nil {
+		return x.DroppedObservations
+	}
+	return 0
+}
+
+func (x *JobRunRecord) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type Int64Arrays struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Array []int64 `protobuf:"varint,1,rep,packed,name=array,proto3" json:"array,omitempty"`
+}
+
+func (x *Int64Arrays) Reset() {
+	*x = Int64Arrays{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Int64Arrays) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Int64Arrays) ProtoMessage() {}
+
+func (x *Int64Arrays) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Int64Arrays.ProtoReflect.Descriptor instead.
+func (*Int64Arrays) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *Int64Arrays) GetArray() []int64 {
+	if x != nil {
+		return x.Array
+	}
+	return nil
+}
+
+type IntString struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   int64  `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *IntString) Reset() {
+	*x = IntString{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IntString) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntString) ProtoMessage() {}
+
+func (x *IntString) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_common_nwpd_nwpd_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntString.ProtoReflect.Descriptor instead.
+func (*IntString) Descriptor() ([]byte, []int) {
+	return file_pkg_common_nwpd_nwpd_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *IntString) GetKey() int64 {
+	if x != nil {
+		return x.Key
+	}
+	return 0
+}
+
+func (x *IntString) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+var File_pkg_common_nwpd_nwpd_proto protoreflect.FileDescriptor
 
 var file_pkg_common_nwpd_nwpd_proto_rawDesc = []byte{
 	0x0a, 0x1a, 0x70, 0x6b, 0x67, 0x2f, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2f, 0x6e, 0x77, 0x70,
@@ -629,7 +2756,7 @@ var file_pkg_common_nwpd_nwpd_proto_rawDesc = []byte{
 	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x22, 0x89, 0x03, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xc2, 0x09, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72,
 	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x30,
 	0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
 	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
@@ -653,113 +2780,581 @@ var file_pkg_common_nwpd_nwpd_proto_rawDesc = []byte{
 	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x11, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67,
 	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x22, 0x0a, 0x0c, 0x66,
 	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x0c, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x22,
-	0x50, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x0c, 0x6f, 0x62,
-	0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x11, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x22, 0x78, 0x0a, 0x21, 0x47, 0x65, 0x74, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74,
-	0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x53, 0x0a, 0x16, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67,
-	0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67,
-	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x16, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f,
-	0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xa8, 0x05, 0x0a, 0x15,
-	0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x12,
-	0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x0b, 0x70,
-	0x65, 0x72, 0x69, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x70, 0x65,
-	0x72, 0x69, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x38, 0x0a, 0x09, 0x70, 0x65, 0x72,
-	0x69, 0x6f, 0x64, 0x45, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64,
-	0x45, 0x6e, 0x64, 0x12, 0x4e, 0x0a, 0x0b, 0x6a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43, 0x6f, 0x75,
-	0x6e, 0x74, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e,
+	0x08, 0x52, 0x0c, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x12,
+	0x2e, 0x0a, 0x12, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x4e, 0x6f, 0x44, 0x61, 0x74, 0x61,
+	0x45, 0x64, 0x67, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x69, 0x6e, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x4e, 0x6f, 0x44, 0x61, 0x74, 0x61, 0x45, 0x64, 0x67, 0x65, 0x73, 0x12,
+	0x5e, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x4c, 0x61, 0x62,
+	0x65, 0x6c, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x6e, 0x77, 0x70, 0x64,
+	0x2e, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74,
+	0x54, 0x6f, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10, 0x72,
+	0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x70, 0x0a, 0x16, 0x72, 0x65, 0x73, 0x74, 0x72,
+	0x69, 0x63, 0x74, 0x54, 0x6f, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x38, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47,
+	0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x16, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6a, 0x6f, 0x62,
+	0x49, 0x44, 0x52, 0x65, 0x67, 0x65, 0x78, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6a,
+	0x6f, 0x62, 0x49, 0x44, 0x52, 0x65, 0x67, 0x65, 0x78, 0x12, 0x22, 0x0a, 0x0c, 0x73, 0x72, 0x63,
+	0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x67, 0x65, 0x78, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x67, 0x65, 0x78, 0x12, 0x24, 0x0a,
+	0x0d, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x67, 0x65, 0x78, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x52, 0x65,
+	0x67, 0x65, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65,
+	0x42, 0x79, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67,
+	0x61, 0x74, 0x65, 0x42, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x72, 0x74, 0x42, 0x79, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x72, 0x74, 0x42, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x73,
+	0x6f, 0x72, 0x74, 0x44, 0x65, 0x73, 0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x13, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0e, 0x73, 0x6f, 0x72, 0x74, 0x44, 0x65, 0x73, 0x63, 0x65, 0x6e, 0x64,
+	0x69, 0x6e, 0x67, 0x12, 0x24, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x50, 0x61, 0x74,
+	0x74, 0x65, 0x72, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x50, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x12, 0x3b, 0x0a, 0x0b, 0x6d, 0x69, 0x6e,
+	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x6d, 0x69, 0x6e, 0x44, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x40, 0x0a, 0x1b, 0x6f, 0x6e, 0x6c, 0x79, 0x46, 0x61,
+	0x69, 0x6c, 0x75, 0x72, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6b, 0x73, 0x4f, 0x66, 0x41, 0x74,
+	0x4c, 0x65, 0x61, 0x73, 0x74, 0x18, 0x16, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1b, 0x6f, 0x6e, 0x6c,
+	0x79, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6b, 0x73, 0x4f,
+	0x66, 0x41, 0x74, 0x4c, 0x65, 0x61, 0x73, 0x74, 0x1a, 0x43, 0x0a, 0x15, 0x52, 0x65, 0x73, 0x74,
+	0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x49, 0x0a,
+	0x1b, 0x52, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x76, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x4f,
+	0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x0c, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6e, 0x77, 0x70, 0x64,
+	0x2e, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x6f, 0x62,
+	0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x6e, 0x65,
+	0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x22, 0x78, 0x0a, 0x21, 0x47, 0x65, 0x74, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65,
+	0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x53, 0x0a, 0x16, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61,
+	0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67, 0x67,
+	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x16, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62,
+	0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xd7, 0x0b, 0x0a, 0x15, 0x41,
+	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x0b, 0x70, 0x65,
+	0x72, 0x69, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x70, 0x65, 0x72,
+	0x69, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x38, 0x0a, 0x09, 0x70, 0x65, 0x72, 0x69,
+	0x6f, 0x64, 0x45, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x45,
+	0x6e, 0x64, 0x12, 0x4e, 0x0a, 0x0b, 0x6a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41,
+	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x6a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x57, 0x0a, 0x0e, 0x6a, 0x6f, 0x62, 0x73, 0x4e, 0x6f, 0x74, 0x4f, 0x6b, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6e, 0x77, 0x70,
+	0x64, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65,
+	0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4a, 0x6f, 0x62, 0x73, 0x4e, 0x6f, 0x74, 0x4f,
+	0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x6a, 0x6f, 0x62,
+	0x73, 0x4e, 0x6f, 0x74, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x57, 0x0a, 0x0e, 0x6d,
+	0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65,
+	0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x2e, 0x4d, 0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x6d, 0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x44, 0x61, 0x74, 0x61, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x44, 0x61, 0x74, 0x61, 0x12, 0x2a, 0x0a, 0x10,
+	0x6e, 0x6f, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x49, 0x6e, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x6e, 0x6f, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64,
+	0x49, 0x6e, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x57, 0x0a, 0x0e, 0x6a, 0x6f, 0x62, 0x73,
+	0x53, 0x74, 0x61, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x2f, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74,
+	0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4a, 0x6f,
+	0x62, 0x73, 0x53, 0x74, 0x61, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x0e, 0x6a, 0x6f, 0x62, 0x73, 0x53, 0x74, 0x61, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x54, 0x0a, 0x0d, 0x70, 0x35, 0x30, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e,
 	0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e,
-	0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x6a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x12, 0x57, 0x0a, 0x0e, 0x6a, 0x6f, 0x62, 0x73, 0x4e, 0x6f, 0x74, 0x4f, 0x6b,
-	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6e, 0x77,
-	0x70, 0x64, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73,
-	0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x4a, 0x6f, 0x62, 0x73, 0x4e, 0x6f, 0x74,
-	0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x6a, 0x6f,
-	0x62, 0x73, 0x4e, 0x6f, 0x74, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x57, 0x0a, 0x0e,
-	0x6d, 0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67, 0x67, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x50, 0x35, 0x30, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x70, 0x35, 0x30, 0x4f, 0x6b, 0x44,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x54, 0x0a, 0x0d, 0x70, 0x39, 0x35, 0x4f, 0x6b,
+	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e,
+	0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64,
+	0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x50, 0x39, 0x35, 0x4f,
+	0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d,
+	0x70, 0x39, 0x35, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x54, 0x0a,
+	0x0d, 0x70, 0x39, 0x39, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0d,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x41, 0x67, 0x67, 0x72,
 	0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x2e, 0x4d, 0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x6d, 0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x3e, 0x0a, 0x10, 0x4a, 0x6f, 0x62, 0x73, 0x4f, 0x6b, 0x43,
-	0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x41, 0x0a, 0x13, 0x4a, 0x6f, 0x62, 0x73, 0x4e, 0x6f, 0x74,
-	0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
-	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
-	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5c, 0x0a, 0x13, 0x4d, 0x65, 0x61, 0x6e,
-	0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
-	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x12, 0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa5, 0x02, 0x0a, 0x0b, 0x4f, 0x62, 0x73, 0x65, 0x72,
-	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07,
-	0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73,
-	0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f,
-	0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f,
-	0x73, 0x74, 0x12, 0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
-	0x70, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x35, 0x0a, 0x08,
-	0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x6e, 0x2e, 0x50, 0x39, 0x39, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x70, 0x39, 0x39, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x5a, 0x6f, 0x6e, 0x65, 0x18, 0x0e,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x5a, 0x6f, 0x6e, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x64, 0x65, 0x73, 0x74, 0x5a, 0x6f, 0x6e, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x64, 0x65, 0x73, 0x74, 0x5a, 0x6f, 0x6e, 0x65, 0x1a, 0x3e, 0x0a, 0x10, 0x4a, 0x6f, 0x62,
+	0x73, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x41, 0x0a, 0x13, 0x4a, 0x6f, 0x62,
+	0x73, 0x4e, 0x6f, 0x74, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5c, 0x0a, 0x13,
+	0x4d, 0x65, 0x61, 0x6e, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x41, 0x0a, 0x13, 0x4a, 0x6f,
+	0x62, 0x73, 0x53, 0x74, 0x61, 0x6c, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5b, 0x0a,
+	0x12, 0x50, 0x35, 0x30, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5b, 0x0a, 0x12, 0x50, 0x39,
+	0x35, 0x4f, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5b, 0x0a, 0x12, 0x50, 0x39, 0x39, 0x4f, 0x6b,
+	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
 	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x06, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f,
-	0x6b, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x6f, 0x6b, 0x12, 0x31, 0x0a, 0x06, 0x70,
-	0x65, 0x72, 0x69, 0x6f, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x22, 0xd8,
-	0x01, 0x0a, 0x0e, 0x49, 0x6e, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x12, 0x14, 0x0a, 0x05, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x05, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f,
-	0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73,
-	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1e, 0x0a,
-	0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x12, 0x26, 0x0a,
-	0x0e, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d,
-	0x69, 0x6c, 0x6c, 0x69, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x6b, 0x18, 0x06, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x02, 0x6f, 0x6b, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x4d,
-	0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x70, 0x65, 0x72,
-	0x69, 0x6f, 0x64, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x22, 0x23, 0x0a, 0x0b, 0x49, 0x6e, 0x74,
-	0x36, 0x34, 0x41, 0x72, 0x72, 0x61, 0x79, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x72, 0x72, 0x61,
-	0x79, 0x18, 0x01, 0x20, 0x03, 0x28, 0x03, 0x52, 0x05, 0x61, 0x72, 0x72, 0x61, 0x79, 0x22, 0x33,
-	0x0a, 0x09, 0x49, 0x6e, 0x74, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x9d, 0x05, 0x0a, 0x0b, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72,
+	0x63, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63,
+	0x48, 0x6f, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74,
+	0x12, 0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x35, 0x0a, 0x08, 0x64, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x6b, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x6f, 0x6b, 0x12, 0x31, 0x0a, 0x06, 0x70, 0x65, 0x72,
+	0x69, 0x6f, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x35, 0x0a, 0x06,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x6e,
+	0x77, 0x70, 0x64, 0x2e, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62,
+	0x65, 0x6c, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x45, 0x6e, 0x64, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x6c,
+	0x65, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e, 0x63,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69,
+	0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x47, 0x0a, 0x0c, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x23, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x5a, 0x6f, 0x6e, 0x65, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x5a, 0x6f, 0x6e, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x64, 0x65, 0x73, 0x74, 0x5a, 0x6f, 0x6e, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x64, 0x65, 0x73, 0x74, 0x5a, 0x6f, 0x6e, 0x65, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x1a, 0x3f, 0x0a, 0x11, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x5b, 0x0a, 0x11, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x4a,
+	0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6a, 0x6f, 0x62,
+	0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x12,
+	0x30, 0x0a, 0x13, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x44, 0x65, 0x73,
+	0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x72, 0x65,
+	0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x44, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74,
+	0x73, 0x22, 0x4b, 0x0a, 0x12, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x4a, 0x6f, 0x62, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x0c, 0x6f, 0x62, 0x73, 0x65, 0x72,
+	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x6e, 0x77, 0x70, 0x64, 0x2e, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0c, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x15,
+	0x0a, 0x13, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xc1, 0x01, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23,
+	0x0a, 0x04, 0x6a, 0x6f, 0x62, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6e,
+	0x77, 0x70, 0x64, 0x2e, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x04, 0x6a,
+	0x6f, 0x62, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f,
+	0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65,
+	0x64, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x10, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x12, 0x36, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x4c, 0x6f, 0x63,
+	0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x0a, 0x6c,
+	0x6f, 0x63, 0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x18, 0x0a, 0x16, 0x47, 0x65, 0x74,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x97, 0x02, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x3c, 0x0a, 0x0b, 0x6c,
+	0x61, 0x73, 0x74, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x6c, 0x61,
+	0x73, 0x74, 0x41, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x12, 0x3a, 0x0a, 0x0a, 0x6c, 0x61, 0x73,
+	0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x52,
+	0x65, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x65, 0x6c,
+	0x6f, 0x61, 0x64, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x6c, 0x61, 0x73, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x3c, 0x0a, 0x19, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x52, 0x65,
+	0x6c, 0x6f, 0x61, 0x64, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x19, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x52,
+	0x65, 0x6c, 0x6f, 0x61, 0x64, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x22, 0x8a, 0x01,
+	0x0a, 0x10, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x12, 0x40, 0x0a, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x69,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73,
+	0x69, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0xd0, 0x04, 0x0a, 0x09, 0x4a,
+	0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6a, 0x6f, 0x62, 0x49,
+	0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x12,
+	0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72,
+	0x67, 0x73, 0x12, 0x31, 0x0a, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x70,
+	0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69,
+	0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e,
+	0x67, 0x12, 0x34, 0x0a, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07,
+	0x6c, 0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x12, 0x34, 0x0a, 0x07, 0x6e, 0x65, 0x78, 0x74, 0x52,
+	0x75, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x6e, 0x65, 0x78, 0x74, 0x52, 0x75, 0x6e, 0x12, 0x1c, 0x0a,
+	0x09, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x4f, 0x6b, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x4f, 0x6b, 0x12, 0x24, 0x0a, 0x0d, 0x6c,
+	0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x46, 0x61, 0x69, 0x6c, 0x65,
+	0x64, 0x12, 0x20, 0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c,
+	0x75, 0x72, 0x65, 0x12, 0x30, 0x0a, 0x13, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69,
+	0x76, 0x65, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x13, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x46, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x12,
+	0x1e, 0x0a, 0x0a, 0x73, 0x6b, 0x69, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6b, 0x69, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12,
+	0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x34, 0x0a, 0x08, 0x62,
+	0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e,
+	0x6e, 0x77, 0x70, 0x64, 0x2e, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x42, 0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66, 0x52, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64, 0x18, 0x10, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64, 0x22, 0x7e, 0x0a,
+	0x12, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x61, 0x63, 0x6b,
+	0x6f, 0x66, 0x66, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x05, 0x75,
+	0x6e, 0x74, 0x69, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x22, 0xc2, 0x01,
+	0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6f, 0x70, 0x65, 0x6e,
+	0x4f, 0x6e, 0x6c, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x6f, 0x70, 0x65, 0x6e,
+	0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x2a, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74,
+	0x54, 0x6f, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10,
+	0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x73,
+	0x12, 0x30, 0x0a, 0x13, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x44, 0x65,
+	0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x72,
+	0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x44, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73,
+	0x74, 0x73, 0x22, 0x45, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x09, 0x69,
+	0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e,
+	0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x09,
+	0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xae, 0x03, 0x0a, 0x08, 0x49, 0x6e,
+	0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69, 0x6e, 0x63, 0x69,
+	0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73,
+	0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f,
+	0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f,
+	0x73, 0x74, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x12, 0x2c, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x03, 0x65,
+	0x6e, 0x64, 0x12, 0x3c, 0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72,
+	0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65,
+	0x12, 0x20, 0x0a, 0x0b, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x07, 0x6f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x12,
+	0x66, 0x69, 0x72, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x52, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x66, 0x69, 0x72, 0x73, 0x74, 0x46,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x2c, 0x0a, 0x11,
+	0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x52, 0x65, 0x61, 0x73, 0x6f,
+	0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x29, 0x0a, 0x11, 0x47, 0x65,
+	0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0xd3, 0x01, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x0b,
+	0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x70,
+	0x65, 0x72, 0x69, 0x6f, 0x64, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x38, 0x0a, 0x09, 0x70, 0x65,
+	0x72, 0x69, 0x6f, 0x64, 0x45, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x70, 0x65, 0x72, 0x69, 0x6f,
+	0x64, 0x45, 0x6e, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x69, 0x6e, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d, 0x69, 0x6e, 0x43, 0x68, 0x65, 0x63,
+	0x6b, 0x73, 0x12, 0x27, 0x0a, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x46, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67,
+	0x45, 0x64, 0x67, 0x65, 0x52, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x22, 0xcd, 0x01, 0x0a, 0x0b,
+	0x46, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x64, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72,
+	0x63, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x06, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x66, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65,
+	0x52, 0x61, 0x74, 0x69, 0x6f, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x66, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x52, 0x61, 0x74, 0x69, 0x6f, 0x12, 0x16, 0x0a, 0x06, 0x6a, 0x6f, 0x62,
+	0x49, 0x44, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x6a, 0x6f, 0x62, 0x49, 0x44,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x6e, 0x67, 0x6f, 0x69, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x6f, 0x6e, 0x67, 0x6f, 0x69, 0x6e, 0x67, 0x22, 0xd9, 0x01, 0x0a, 0x17,
+	0x47, 0x65, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x53, 0x69, 0x6e, 0x63, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x12, 0x2a, 0x0a, 0x10, 0x72, 0x65, 0x73,
+	0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x10, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x4a,
+	0x6f, 0x62, 0x49, 0x44, 0x73, 0x12, 0x2e, 0x0a, 0x12, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63,
+	0x74, 0x54, 0x6f, 0x53, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x12, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x53, 0x72, 0x63,
+	0x48, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x30, 0x0a, 0x13, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63,
+	0x74, 0x54, 0x6f, 0x44, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x13, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x6f, 0x44, 0x65,
+	0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x22, 0x94, 0x01, 0x0a, 0x18, 0x47, 0x65, 0x74, 0x46,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x45, 0x64, 0x67,
+	0x65, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x52, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73,
+	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x22, 0x82,
+	0x02, 0x0a, 0x0c, 0x45, 0x64, 0x67, 0x65, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x66,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x66,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x3c, 0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x46,
+	0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61,
+	0x69, 0x6c, 0x75, 0x72, 0x65, 0x12, 0x2c, 0x0a, 0x11, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x11, 0x6c, 0x61, 0x73, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x52, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49,
+	0x44, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e,
+	0x74, 0x49, 0x44, 0x22, 0x5e, 0x0a, 0x10, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x53,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x22, 0x0a, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x49, 0x6e, 0x63,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x12, 0x26, 0x0a, 0x06, 0x63,
+	0x6c, 0x6f, 0x73, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6e, 0x77,
+	0x70, 0x64, 0x2e, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x63, 0x6c, 0x6f,
+	0x73, 0x65, 0x64, 0x22, 0x78, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x52,
+	0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x30, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12,
+	0x2c, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x22, 0x46, 0x0a,
+	0x17, 0x47, 0x65, 0x74, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x07, 0x72, 0x6f, 0x6c, 0x6c,
+	0x75, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6e, 0x77, 0x70, 0x64,
+	0x2e, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x52, 0x07, 0x72, 0x6f,
+	0x6c, 0x6c, 0x75, 0x70, 0x73, 0x22, 0x82, 0x01, 0x0a, 0x0b, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x52,
+	0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63,
+	0x48, 0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48,
+	0x6f, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x12, 0x2b, 0x0a,
+	0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0xb2, 0x02, 0x0a, 0x0b, 0x52,
+	0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6a, 0x6f,
+	0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44,
+	0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x73, 0x74, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x65, 0x73, 0x74, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x6f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x07, 0x6f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x6e, 0x6f, 0x74, 0x4f,
+	0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6e, 0x6f,
+	0x74, 0x4f, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x70, 0x35, 0x30, 0x44,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x70, 0x35, 0x30, 0x44, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3b, 0x0a, 0x0b, 0x70, 0x39, 0x30, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x70, 0x39, 0x30, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x3b, 0x0a, 0x0b, 0x70, 0x39, 0x39, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0b, 0x70, 0x39, 0x39, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22,
+	0xd6, 0x04, 0x0a, 0x0e, 0x49, 0x6e, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x4a, 0x6f, 0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x48,
+	0x6f, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f,
+	0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x1e,
+	0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x12, 0x26,
+	0x0a, 0x0e, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x6b, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x02, 0x6f, 0x6b, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64,
+	0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x70, 0x65,
+	0x72, 0x69, 0x6f, 0x64, 0x4d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x12, 0x38, 0x0a, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6e, 0x77, 0x70,
+	0x64, 0x2e, 0x49, 0x6e, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x45, 0x6e, 0x64,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x73, 0x74, 0x61,
+	0x6c, 0x65, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x6e,
+	0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a,
+	0x69, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x4a, 0x0a, 0x0c, 0x72, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x26, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x49, 0x6e, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72,
+	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x5a, 0x6f, 0x6e,
+	0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x72, 0x63, 0x5a, 0x6f, 0x6e, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x5a, 0x6f, 0x6e, 0x65, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x5a, 0x6f, 0x6e, 0x65, 0x1a, 0x39, 0x0a, 0x0b,
+	0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
 	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x32, 0xc6, 0x01, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x12, 0x50, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72,
-	0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47,
-	0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74,
-	0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x41, 0x67, 0x67,
-	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3f, 0x0a, 0x11, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xaa, 0x03, 0x0a, 0x0c, 0x4a, 0x6f, 0x62,
+	0x52, 0x75, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69, 0x6e,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x6a, 0x6f, 0x62, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6a, 0x6f,
+	0x62, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x72, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x12, 0x30, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12,
+	0x2c, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x31, 0x0a,
+	0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64,
+	0x12, 0x2f, 0x0a, 0x05, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x64, 0x65, 0x6c, 0x61,
+	0x79, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x08,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x12,
+	0x2a, 0x0a, 0x10, 0x64, 0x65, 0x73, 0x74, 0x48, 0x6f, 0x73, 0x74, 0x73, 0x4f, 0x6d, 0x69, 0x74,
+	0x74, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x64, 0x65, 0x73, 0x74, 0x48,
+	0x6f, 0x73, 0x74, 0x73, 0x4f, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x13, 0x64,
+	0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65,
+	0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x23, 0x0a, 0x0b, 0x49, 0x6e, 0x74, 0x36, 0x34, 0x41, 0x72,
+	0x72, 0x61, 0x79, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x72, 0x72, 0x61, 0x79, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x03, 0x52, 0x05, 0x61, 0x72, 0x72, 0x61, 0x79, 0x22, 0x33, 0x0a, 0x09, 0x49, 0x6e,
+	0x74, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x32,
+	0xda, 0x05, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x50, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
 	0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x62,
 	0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x27, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x67, 0x67, 0x72,
-	0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x3e, 0x5a, 0x3c,
+	0x74, 0x1a, 0x1d, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65,
+	0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x64, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61,
+	0x74, 0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x1c, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e,
+	0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74,
+	0x65, 0x64, 0x4f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x50, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x44,
+	0x61, 0x69, 0x6c, 0x79, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x73, 0x12, 0x1c, 0x2e, 0x6e, 0x77,
+	0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x52, 0x6f, 0x6c, 0x6c, 0x75,
+	0x70, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6e, 0x77, 0x70, 0x64,
+	0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x52, 0x6f, 0x6c, 0x6c, 0x75, 0x70, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x0a, 0x54, 0x72,
+	0x69, 0x67, 0x67, 0x65, 0x72, 0x4a, 0x6f, 0x62, 0x12, 0x17, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e,
+	0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x4a, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x18, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72,
+	0x4a, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x47, 0x0a,
+	0x0c, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x19, 0x2e,
+	0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e,
+	0x47, 0x65, 0x74, 0x4a, 0x6f, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x0d, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e,
+	0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1a, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x49, 0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x49,
+	0x6e, 0x63, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x41, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79,
+	0x12, 0x17, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61,
+	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6e, 0x77, 0x70, 0x64,
+	0x2e, 0x47, 0x65, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x46, 0x61, 0x69, 0x6c,
+	0x75, 0x72, 0x65, 0x73, 0x53, 0x69, 0x6e, 0x63, 0x65, 0x12, 0x1d, 0x2e, 0x6e, 0x77, 0x70, 0x64,
+	0x2e, 0x47, 0x65, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x53, 0x69, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6e, 0x77, 0x70, 0x64, 0x2e,
+	0x47, 0x65, 0x74, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x53, 0x69, 0x6e, 0x63, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x50, 0x0a, 0x0f, 0x47, 0x65,
+	0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x2e,
+	0x6e, 0x77, 0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6e, 0x77,
+	0x70, 0x64, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x3e, 0x5a, 0x3c,
 	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x61, 0x72, 0x64, 0x65,
 	0x6e, 0x65, 0x72, 0x2f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2d, 0x70, 0x72, 0x6f, 0x62,
 	0x6c, 0x65, 0x6d, 0x2d, 0x64, 0x65, 0x74, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2f, 0x70, 0x6b, 0x67,
@@ -779,46 +3374,144 @@ func file_pkg_common_nwpd_nwpd_proto_rawDescGZIP() []byte {
 	return file_pkg_common_nwpd_nwpd_proto_rawDescData
 }
 
-var file_pkg_common_nwpd_nwpd_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_pkg_common_nwpd_nwpd_proto_msgTypes = make([]protoimpl.MessageInfo, 45)
 var file_pkg_common_nwpd_nwpd_proto_goTypes = []interface{}{
 	(*GetObservationsRequest)(nil),            // 0: nwpd.GetObservationsRequest
 	(*GetObservationsResponse)(nil),           // 1: nwpd.GetObservationsResponse
 	(*GetAggregatedObservationsResponse)(nil), // 2: nwpd.GetAggregatedObservationsResponse
 	(*AggregatedObservation)(nil),             // 3: nwpd.AggregatedObservation
 	(*Observation)(nil),                       // 4: nwpd.Observation
-	(*IntObservation)(nil),                    // 5: nwpd.IntObservation
-	(*Int64Arrays)(nil),                       // 6: nwpd.Int64Arrays
-	(*IntString)(nil),                         // 7: nwpd.IntString
-	nil,                                       // 8: nwpd.AggregatedObservation.JobsOkCountEntry
-	nil,                                       // 9: nwpd.AggregatedObservation.JobsNotOkCountEntry
-	nil,                                       // 10: nwpd.AggregatedObservation.MeanOkDurationEntry
-	(*timestamppb.Timestamp)(nil),             // 11: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),               // 12: google.protobuf.Duration
+	(*TriggerJobRequest)(nil),                 // 5: nwpd.TriggerJobRequest
+	(*TriggerJobResponse)(nil),                // 6: nwpd.TriggerJobResponse
+	(*GetJobStatusRequest)(nil),               // 7: nwpd.GetJobStatusRequest
+	(*GetJobStatusResponse)(nil),              // 8: nwpd.GetJobStatusResponse
+	(*GetConfigStatusRequest)(nil),            // 9: nwpd.GetConfigStatusRequest
+	(*GetConfigStatusResponse)(nil),           // 10: nwpd.GetConfigStatusResponse
+	(*LocalBlockStatus)(nil),                  // 11: nwpd.LocalBlockStatus
+	(*JobStatus)(nil),                         // 12: nwpd.JobStatus
+	(*DestinationBackoff)(nil),                // 13: nwpd.DestinationBackoff
+	(*ListIncidentsRequest)(nil),              // 14: nwpd.ListIncidentsRequest
+	(*ListIncidentsResponse)(nil),             // 15: nwpd.ListIncidentsResponse
+	(*Incident)(nil),                          // 16: nwpd.Incident
+	(*GetSummaryRequest)(nil),                 // 17: nwpd.GetSummaryRequest
+	(*GetSummaryResponse)(nil),                // 18: nwpd.GetSummaryResponse
+	(*FailingEdge)(nil),                       // 19: nwpd.FailingEdge
+	(*GetFailuresSinceRequest)(nil),           // 20: nwpd.GetFailuresSinceRequest
+	(*GetFailuresSinceResponse)(nil),          // 21: nwpd.GetFailuresSinceResponse
+	(*EdgeFailures)(nil),                      // 22: nwpd.EdgeFailures
+	(*IncidentSnapshot)(nil),                  // 23: nwpd.IncidentSnapshot
+	(*GetDailyRollupsRequest)(nil),            // 24: nwpd.GetDailyRollupsRequest
+	(*GetDailyRollupsResponse)(nil),           // 25: nwpd.GetDailyRollupsResponse
+	(*DailyRollup)(nil),                       // 26: nwpd.DailyRollup
+	(*RollupEntry)(nil),                       // 27: nwpd.RollupEntry
+	(*IntObservation)(nil),                    // 28: nwpd.IntObservation
+	(*JobRunRecord)(nil),                      // 29: nwpd.JobRunRecord
+	(*Int64Arrays)(nil),                       // 30: nwpd.Int64Arrays
+	(*IntString)(nil),                         // 31: nwpd.IntString
+	nil,                                       // 32: nwpd.GetObservationsRequest.RestrictToLabelsEntry
+	nil,                                       // 33: nwpd.GetObservationsRequest.RestrictToResultFieldsEntry
+	nil,                                       // 34: nwpd.AggregatedObservation.JobsOkCountEntry
+	nil,                                       // 35: nwpd.AggregatedObservation.JobsNotOkCountEntry
+	nil,                                       // 36: nwpd.AggregatedObservation.MeanOkDurationEntry
+	nil,                                       // 37: nwpd.AggregatedObservation.JobsStaleCountEntry
+	nil,                                       // 38: nwpd.AggregatedObservation.P50OkDurationEntry
+	nil,                                       // 39: nwpd.AggregatedObservation.P95OkDurationEntry
+	nil,                                       // 40: nwpd.AggregatedObservation.P99OkDurationEntry
+	nil,                                       // 41: nwpd.Observation.LabelsEntry
+	nil,                                       // 42: nwpd.Observation.ResultFieldsEntry
+	nil,                                       // 43: nwpd.IntObservation.LabelsEntry
+	nil,                                       // 44: nwpd.IntObservation.ResultFieldsEntry
+	(*timestamppb.Timestamp)(nil),             // 45: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),               // 46: google.protobuf.Duration
 }
 var file_pkg_common_nwpd_nwpd_proto_depIdxs = []int32{
-	11, // 0: nwpd.GetObservationsRequest.start:type_name -> google.protobuf.Timestamp
-	11, // 1: nwpd.GetObservationsRequest.end:type_name -> google.protobuf.Timestamp
-	12, // 2: nwpd.GetObservationsRequest.aggregationWindow:type_name -> google.protobuf.Duration
-	4,  // 3: nwpd.GetObservationsResponse.observations:type_name -> nwpd.Observation
-	3,  // 4: nwpd.GetAggregatedObservationsResponse.aggregatedObservations:type_name -> nwpd.AggregatedObservation
-	11, // 5: nwpd.AggregatedObservation.periodStart:type_name -> google.protobuf.Timestamp
-	11, // 6: nwpd.AggregatedObservation.periodEnd:type_name -> google.protobuf.Timestamp
-	8,  // 7: nwpd.AggregatedObservation.jobsOkCount:type_name -> nwpd.AggregatedObservation.JobsOkCountEntry
-	9,  // 8: nwpd.AggregatedObservation.jobsNotOkCount:type_name -> nwpd.AggregatedObservation.JobsNotOkCountEntry
-	10, // 9: nwpd.AggregatedObservation.meanOkDuration:type_name -> nwpd.AggregatedObservation.MeanOkDurationEntry
-	11, // 10: nwpd.Observation.timestamp:type_name -> google.protobuf.Timestamp
-	12, // 11: nwpd.Observation.duration:type_name -> google.protobuf.Duration
-	12, // 12: nwpd.Observation.period:type_name -> google.protobuf.Duration
-	12, // 13: nwpd.AggregatedObservation.MeanOkDurationEntry.value:type_name -> google.protobuf.Duration
-	0,  // 14: nwpd.AgentService.GetObservations:input_type -> nwpd.GetObservationsRequest
-	0,  // 15: nwpd.AgentService.GetAggregatedObservations:input_type -> nwpd.GetObservationsRequest
-	1,  // 16: nwpd.AgentService.GetObservations:output_type -> nwpd.GetObservationsResponse
-	2,  // 17: nwpd.AgentService.GetAggregatedObservations:output_type -> nwpd.GetAggregatedObservationsResponse
-	16, // [16:18] is the sub-list for method output_type
-	14, // [14:16] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	45, // 0: nwpd.GetObservationsRequest.start:type_name -> google.protobuf.Timestamp
+	45, // 1: nwpd.GetObservationsRequest.end:type_name -> google.protobuf.Timestamp
+	46, // 2: nwpd.GetObservationsRequest.aggregationWindow:type_name -> google.protobuf.Duration
+	32, // 3: nwpd.GetObservationsRequest.restrictToLabels:type_name -> nwpd.GetObservationsRequest.RestrictToLabelsEntry
+	33, // 4: nwpd.GetObservationsRequest.restrictToResultFields:type_name -> nwpd.GetObservationsRequest.RestrictToResultFieldsEntry
+	46, // 5: nwpd.GetObservationsRequest.minDuration:type_name -> google.protobuf.Duration
+	4,  // 6: nwpd.GetObservationsResponse.observations:type_name -> nwpd.Observation
+	3,  // 7: nwpd.GetAggregatedObservationsResponse.aggregatedObservations:type_name -> nwpd.AggregatedObservation
+	45, // 8: nwpd.AggregatedObservation.periodStart:type_name -> google.protobuf.Timestamp
+	45, // 9: nwpd.AggregatedObservation.periodEnd:type_name -> google.protobuf.Timestamp
+	34, // 10: nwpd.AggregatedObservation.jobsOkCount:type_name -> nwpd.AggregatedObservation.JobsOkCountEntry
+	35, // 11: nwpd.AggregatedObservation.jobsNotOkCount:type_name -> nwpd.AggregatedObservation.JobsNotOkCountEntry
+	36, // 12: nwpd.AggregatedObservation.meanOkDuration:type_name -> nwpd.AggregatedObservation.MeanOkDurationEntry
+	37, // 13: nwpd.AggregatedObservation.jobsStaleCount:type_name -> nwpd.AggregatedObservation.JobsStaleCountEntry
+	38, // 14: nwpd.AggregatedObservation.p50OkDuration:type_name -> nwpd.AggregatedObservation.P50OkDurationEntry
+	39, // 15: nwpd.AggregatedObservation.p95OkDuration:type_name -> nwpd.AggregatedObservation.P95OkDurationEntry
+	40, // 16: nwpd.AggregatedObservation.p99OkDuration:type_name -> nwpd.AggregatedObservation.P99OkDurationEntry
+	45, // 17: nwpd.Observation.timestamp:type_name -> google.protobuf.Timestamp
+	46, // 18: nwpd.Observation.duration:type_name -> google.protobuf.Duration
+	46, // 19: nwpd.Observation.period:type_name -> google.protobuf.Duration
+	41, // 20: nwpd.Observation.labels:type_name -> nwpd.Observation.LabelsEntry
+	42, // 21: nwpd.Observation.resultFields:type_name -> nwpd.Observation.ResultFieldsEntry
+	4,  // 22: nwpd.TriggerJobResponse.observations:type_name -> nwpd.Observation
+	12, // 23: nwpd.GetJobStatusResponse.jobs:type_name -> nwpd.JobStatus
+	11, // 24: nwpd.GetJobStatusResponse.localBlock:type_name -> nwpd.LocalBlockStatus
+	45, // 25: nwpd.GetConfigStatusResponse.lastApplied:type_name -> google.protobuf.Timestamp
+	45, // 26: nwpd.GetConfigStatusResponse.lastReload:type_name -> google.protobuf.Timestamp
+	45, // 27: nwpd.LocalBlockStatus.lastDiagnosis:type_name -> google.protobuf.Timestamp
+	46, // 28: nwpd.JobStatus.period:type_name -> google.protobuf.Duration
+	45, // 29: nwpd.JobStatus.lastRun:type_name -> google.protobuf.Timestamp
+	45, // 30: nwpd.JobStatus.nextRun:type_name -> google.protobuf.Timestamp
+	13, // 31: nwpd.JobStatus.backoffs:type_name -> nwpd.DestinationBackoff
+	45, // 32: nwpd.DestinationBackoff.until:type_name -> google.protobuf.Timestamp
+	45, // 33: nwpd.ListIncidentsRequest.start:type_name -> google.protobuf.Timestamp
+	16, // 34: nwpd.ListIncidentsResponse.incidents:type_name -> nwpd.Incident
+	45, // 35: nwpd.Incident.start:type_name -> google.protobuf.Timestamp
+	45, // 36: nwpd.Incident.end:type_name -> google.protobuf.Timestamp
+	45, // 37: nwpd.Incident.lastFailure:type_name -> google.protobuf.Timestamp
+	45, // 38: nwpd.GetSummaryResponse.periodStart:type_name -> google.protobuf.Timestamp
+	45, // 39: nwpd.GetSummaryResponse.periodEnd:type_name -> google.protobuf.Timestamp
+	19, // 40: nwpd.GetSummaryResponse.edges:type_name -> nwpd.FailingEdge
+	45, // 41: nwpd.GetFailuresSinceRequest.since:type_name -> google.protobuf.Timestamp
+	45, // 42: nwpd.GetFailuresSinceResponse.since:type_name -> google.protobuf.Timestamp
+	22, // 43: nwpd.GetFailuresSinceResponse.edges:type_name -> nwpd.EdgeFailures
+	45, // 44: nwpd.EdgeFailures.lastFailure:type_name -> google.protobuf.Timestamp
+	16, // 45: nwpd.IncidentSnapshot.open:type_name -> nwpd.Incident
+	16, // 46: nwpd.IncidentSnapshot.closed:type_name -> nwpd.Incident
+	45, // 47: nwpd.GetDailyRollupsRequest.start:type_name -> google.protobuf.Timestamp
+	45, // 48: nwpd.GetDailyRollupsRequest.end:type_name -> google.protobuf.Timestamp
+	26, // 49: nwpd.GetDailyRollupsResponse.rollups:type_name -> nwpd.DailyRollup
+	27, // 50: nwpd.DailyRollup.entries:type_name -> nwpd.RollupEntry
+	46, // 51: nwpd.RollupEntry.p50Duration:type_name -> google.protobuf.Duration
+	46, // 52: nwpd.RollupEntry.p90Duration:type_name -> google.protobuf.Duration
+	46, // 53: nwpd.RollupEntry.p99Duration:type_name -> google.protobuf.Duration
+	43, // 54: nwpd.IntObservation.labels:type_name -> nwpd.IntObservation.LabelsEntry
+	44, // 55: nwpd.IntObservation.resultFields:type_name -> nwpd.IntObservation.ResultFieldsEntry
+	45, // 56: nwpd.JobRunRecord.start:type_name -> google.protobuf.Timestamp
+	45, // 57: nwpd.JobRunRecord.end:type_name -> google.protobuf.Timestamp
+	46, // 58: nwpd.JobRunRecord.period:type_name -> google.protobuf.Duration
+	46, // 59: nwpd.JobRunRecord.delay:type_name -> google.protobuf.Duration
+	46, // 60: nwpd.AggregatedObservation.MeanOkDurationEntry.value:type_name -> google.protobuf.Duration
+	46, // 61: nwpd.AggregatedObservation.P50OkDurationEntry.value:type_name -> google.protobuf.Duration
+	46, // 62: nwpd.AggregatedObservation.P95OkDurationEntry.value:type_name -> google.protobuf.Duration
+	46, // 63: nwpd.AggregatedObservation.P99OkDurationEntry.value:type_name -> google.protobuf.Duration
+	0,  // 64: nwpd.AgentService.GetObservations:input_type -> nwpd.GetObservationsRequest
+	0,  // 65: nwpd.AgentService.GetAggregatedObservations:input_type -> nwpd.GetObservationsRequest
+	24, // 66: nwpd.AgentService.GetDailyRollups:input_type -> nwpd.GetDailyRollupsRequest
+	5,  // 67: nwpd.AgentService.TriggerJob:input_type -> nwpd.TriggerJobRequest
+	7,  // 68: nwpd.AgentService.GetJobStatus:input_type -> nwpd.GetJobStatusRequest
+	14, // 69: nwpd.AgentService.ListIncidents:input_type -> nwpd.ListIncidentsRequest
+	17, // 70: nwpd.AgentService.GetSummary:input_type -> nwpd.GetSummaryRequest
+	20, // 71: nwpd.AgentService.GetFailuresSince:input_type -> nwpd.GetFailuresSinceRequest
+	9,  // 72: nwpd.AgentService.GetConfigStatus:input_type -> nwpd.GetConfigStatusRequest
+	1,  // 73: nwpd.AgentService.GetObservations:output_type -> nwpd.GetObservationsResponse
+	2,  // 74: nwpd.AgentService.GetAggregatedObservations:output_type -> nwpd.GetAggregatedObservationsResponse
+	25, // 75: nwpd.AgentService.GetDailyRollups:output_type -> nwpd.GetDailyRollupsResponse
+	6,  // 76: nwpd.AgentService.TriggerJob:output_type -> nwpd.TriggerJobResponse
+	8,  // 77: nwpd.AgentService.GetJobStatus:output_type -> nwpd.GetJobStatusResponse
+	15, // 78: nwpd.AgentService.ListIncidents:output_type -> nwpd.ListIncidentsResponse
+	18, // 79: nwpd.AgentService.GetSummary:output_type -> nwpd.GetSummaryResponse
+	21, // 80: nwpd.AgentService.GetFailuresSince:output_type -> nwpd.GetFailuresSinceResponse
+	10, // 81: nwpd.AgentService.GetConfigStatus:output_type -> nwpd.GetConfigStatusResponse
+	73, // [73:82] is the sub-list for method output_type
+	64, // [64:73] is the sub-list for method input_type
+	64, // [64:64] is the sub-list for extension type_name
+	64, // [64:64] is the sub-list for extension extendee
+	0,  // [0:64] is the sub-list for field type_name
 }
 
 func init() { file_pkg_common_nwpd_nwpd_proto_init() }
@@ -888,7 +3581,7 @@ func file_pkg_common_nwpd_nwpd_proto_init() {
 			}
 		}
 		file_pkg_common_nwpd_nwpd_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*IntObservation); i {
+			switch v := v.(*TriggerJobRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -900,7 +3593,7 @@ func file_pkg_common_nwpd_nwpd_proto_init() {
 			}
 		}
 		file_pkg_common_nwpd_nwpd_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Int64Arrays); i {
+			switch v := v.(*TriggerJobResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -912,6 +3605,294 @@ func file_pkg_common_nwpd_nwpd_proto_init() {
 			}
 		}
 		file_pkg_common_nwpd_nwpd_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetJobStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetJobStatusResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetConfigStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetConfigStatusResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LocalBlockStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DestinationBackoff); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListIncidentsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListIncidentsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Incident); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSummaryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSummaryResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FailingEdge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFailuresSinceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFailuresSinceResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EdgeFailures); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IncidentSnapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDailyRollupsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDailyRollupsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DailyRollup); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RollupEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IntObservation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JobRunRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Int64Arrays); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_common_nwpd_nwpd_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*IntString); i {
 			case 0:
 				return &v.state
@@ -930,7 +3911,7 @@ func file_pkg_common_nwpd_nwpd_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_common_nwpd_nwpd_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   45,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -5,33 +5,22 @@ package nwpd
 
 import (
 	context "context"
+	json "encoding/json"
 	fmt "fmt"
-
-	http "net/http"
-
 	io "io"
-
-	json "encoding/json"
-
+	http "net/http"
 	strconv "strconv"
-
 	strings "strings"
 
-	protojson "google.golang.org/protobuf/encoding/protojson"
-
-	proto "google.golang.org/protobuf/proto"
-
 	twirp "github.com/twitchtv/twirp"
-
 	ctxsetters "github.com/twitchtv/twirp/ctxsetters"
+	protojson "google.golang.org/protobuf/encoding/protojson"
+	proto "google.golang.org/protobuf/proto"
 
 	bytes "bytes"
-
 	errors "errors"
-
-	path "path"
-
 	url "net/url"
+	path "path"
 )
 
 // Version compatibility assertion.
@@ -49,6 +38,20 @@ type AgentService interface {
 	GetObservations(context.Context, *GetObservationsRequest) (*GetObservationsResponse, error)
 
 	GetAggregatedObservations(context.Context, *GetObservationsRequest) (*GetAggregatedObservationsResponse, error)
+
+	GetDailyRollups(context.Context, *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error)
+
+	TriggerJob(context.Context, *TriggerJobRequest) (*TriggerJobResponse, error)
+
+	GetJobStatus(context.Context, *GetJobStatusRequest) (*GetJobStatusResponse, error)
+
+	ListIncidents(context.Context, *ListIncidentsRequest) (*ListIncidentsResponse, error)
+
+	GetSummary(context.Context, *GetSummaryRequest) (*GetSummaryResponse, error)
+
+	GetFailuresSince(context.Context, *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error)
+
+	GetConfigStatus(context.Context, *GetConfigStatusRequest) (*GetConfigStatusResponse, error)
 }
 
 // ============================
@@ -57,7 +60,7 @@ type AgentService interface {
 
 type agentServiceProtobufClient struct {
 	client      HTTPClient
-	urls        [2]string
+	urls        [9]string
 	interceptor twirp.Interceptor
 	opts        twirp.ClientOptions
 }
@@ -85,9 +88,16 @@ func NewAgentServiceProtobufClient(baseURL string, client HTTPClient, opts ...tw
 	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
 	serviceURL := sanitizeBaseURL(baseURL)
 	serviceURL += baseServicePath(pathPrefix, "nwpd", "AgentService")
-	urls := [2]string{
+	urls := [9]string{
 		serviceURL + "GetObservations",
 		serviceURL + "GetAggregatedObservations",
+		serviceURL + "GetDailyRollups",
+		serviceURL + "TriggerJob",
+		serviceURL + "GetJobStatus",
+		serviceURL + "ListIncidents",
+		serviceURL + "GetSummary",
+		serviceURL + "GetFailuresSince",
+		serviceURL + "GetConfigStatus",
 	}
 
 	return &agentServiceProtobufClient{
@@ -190,73 +200,210 @@ func (c *agentServiceProtobufClient) callGetAggregatedObservations(ctx context.C
 	return out, nil
 }
 
-// ========================
-// AgentService JSON Client
-// ========================
+func (c *agentServiceProtobufClient) GetDailyRollups(ctx context.Context, in *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetDailyRollups")
+	caller := c.callGetDailyRollups
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDailyRollupsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDailyRollupsRequest) when calling interceptor")
+					}
+					return c.callGetDailyRollups(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDailyRollupsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDailyRollupsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
 
-type agentServiceJSONClient struct {
-	client      HTTPClient
-	urls        [2]string
-	interceptor twirp.Interceptor
-	opts        twirp.ClientOptions
+func (c *agentServiceProtobufClient) callGetDailyRollups(ctx context.Context, in *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+	out := new(GetDailyRollupsResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[2], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
 }
 
-// NewAgentServiceJSONClient creates a JSON client that implements the AgentService interface.
-// It communicates using JSON and can be configured with a custom HTTPClient.
-func NewAgentServiceJSONClient(baseURL string, client HTTPClient, opts ...twirp.ClientOption) AgentService {
-	if c, ok := client.(*http.Client); ok {
-		client = withoutRedirects(c)
+func (c *agentServiceProtobufClient) TriggerJob(ctx context.Context, in *TriggerJobRequest) (*TriggerJobResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "TriggerJob")
+	caller := c.callTriggerJob
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *TriggerJobRequest) (*TriggerJobResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*TriggerJobRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*TriggerJobRequest) when calling interceptor")
+					}
+					return c.callTriggerJob(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*TriggerJobResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*TriggerJobResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
 	}
+	return caller(ctx, in)
+}
 
-	clientOpts := twirp.ClientOptions{}
-	for _, o := range opts {
-		o(&clientOpts)
+func (c *agentServiceProtobufClient) callTriggerJob(ctx context.Context, in *TriggerJobRequest) (*TriggerJobResponse, error) {
+	out := new(TriggerJobResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[3], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
 	}
 
-	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
-	literalURLs := false
-	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
-	var pathPrefix string
-	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
-		pathPrefix = "/twirp" // default prefix
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceProtobufClient) GetJobStatus(ctx context.Context, in *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetJobStatus")
+	caller := c.callGetJobStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetJobStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetJobStatusRequest) when calling interceptor")
+					}
+					return c.callGetJobStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetJobStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetJobStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceProtobufClient) callGetJobStatus(ctx context.Context, in *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+	out := new(GetJobStatusResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[4], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
 	}
 
-	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
-	serviceURL := sanitizeBaseURL(baseURL)
-	serviceURL += baseServicePath(pathPrefix, "nwpd", "AgentService")
-	urls := [2]string{
-		serviceURL + "GetObservations",
-		serviceURL + "GetAggregatedObservations",
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceProtobufClient) ListIncidents(ctx context.Context, in *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "ListIncidents")
+	caller := c.callListIncidents
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListIncidentsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListIncidentsRequest) when calling interceptor")
+					}
+					return c.callListIncidents(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListIncidentsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListIncidentsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
 	}
+	return caller(ctx, in)
+}
 
-	return &agentServiceJSONClient{
-		client:      client,
-		urls:        urls,
-		interceptor: twirp.ChainInterceptors(clientOpts.Interceptors...),
-		opts:        clientOpts,
+func (c *agentServiceProtobufClient) callListIncidents(ctx context.Context, in *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+	out := new(ListIncidentsResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[5], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
 	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
 }
 
-func (c *agentServiceJSONClient) GetObservations(ctx context.Context, in *GetObservationsRequest) (*GetObservationsResponse, error) {
+func (c *agentServiceProtobufClient) GetSummary(ctx context.Context, in *GetSummaryRequest) (*GetSummaryResponse, error) {
 	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
 	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
-	ctx = ctxsetters.WithMethodName(ctx, "GetObservations")
-	caller := c.callGetObservations
+	ctx = ctxsetters.WithMethodName(ctx, "GetSummary")
+	caller := c.callGetSummary
 	if c.interceptor != nil {
-		caller = func(ctx context.Context, req *GetObservationsRequest) (*GetObservationsResponse, error) {
+		caller = func(ctx context.Context, req *GetSummaryRequest) (*GetSummaryResponse, error) {
 			resp, err := c.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*GetObservationsRequest)
+					typedReq, ok := req.(*GetSummaryRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetSummaryRequest) when calling interceptor")
 					}
-					return c.callGetObservations(ctx, typedReq)
+					return c.callGetSummary(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*GetObservationsResponse)
+				typedResp, ok := resp.(*GetSummaryResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*GetObservationsResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSummaryResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -266,9 +413,55 @@ func (c *agentServiceJSONClient) GetObservations(ctx context.Context, in *GetObs
 	return caller(ctx, in)
 }
 
-func (c *agentServiceJSONClient) callGetObservations(ctx context.Context, in *GetObservationsRequest) (*GetObservationsResponse, error) {
-	out := new(GetObservationsResponse)
-	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[0], in, out)
+func (c *agentServiceProtobufClient) callGetSummary(ctx context.Context, in *GetSummaryRequest) (*GetSummaryResponse, error) {
+	out := new(GetSummaryResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[6], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceProtobufClient) GetFailuresSince(ctx context.Context, in *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetFailuresSince")
+	caller := c.callGetFailuresSince
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetFailuresSinceRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetFailuresSinceRequest) when calling interceptor")
+					}
+					return c.callGetFailuresSince(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetFailuresSinceResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetFailuresSinceResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceProtobufClient) callGetFailuresSince(ctx context.Context, in *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
+	out := new(GetFailuresSinceResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[7], in, out)
 	if err != nil {
 		twerr, ok := err.(twirp.Error)
 		if !ok {
@@ -278,168 +471,1917 @@ func (c *agentServiceJSONClient) callGetObservations(ctx context.Context, in *Ge
 		return nil, err
 	}
 
-	callClientResponseReceived(ctx, c.opts.Hooks)
-
-	return out, nil
-}
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceProtobufClient) GetConfigStatus(ctx context.Context, in *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetConfigStatus")
+	caller := c.callGetConfigStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetConfigStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetConfigStatusRequest) when calling interceptor")
+					}
+					return c.callGetConfigStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetConfigStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetConfigStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceProtobufClient) callGetConfigStatus(ctx context.Context, in *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
+	out := new(GetConfigStatusResponse)
+	ctx, err := doProtobufRequest(ctx, c.client, c.opts.Hooks, c.urls[8], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+// ========================
+// AgentService JSON Client
+// ========================
+
+type agentServiceJSONClient struct {
+	client      HTTPClient
+	urls        [9]string
+	interceptor twirp.Interceptor
+	opts        twirp.ClientOptions
+}
+
+// NewAgentServiceJSONClient creates a JSON client that implements the AgentService interface.
+// It communicates using JSON and can be configured with a custom HTTPClient.
+func NewAgentServiceJSONClient(baseURL string, client HTTPClient, opts ...twirp.ClientOption) AgentService {
+	if c, ok := client.(*http.Client); ok {
+		client = withoutRedirects(c)
+	}
+
+	clientOpts := twirp.ClientOptions{}
+	for _, o := range opts {
+		o(&clientOpts)
+	}
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	literalURLs := false
+	_ = clientOpts.ReadOpt("literalURLs", &literalURLs)
+	var pathPrefix string
+	if ok := clientOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	// Build method URLs: <baseURL>[<prefix>]/<package>.<Service>/<Method>
+	serviceURL := sanitizeBaseURL(baseURL)
+	serviceURL += baseServicePath(pathPrefix, "nwpd", "AgentService")
+	urls := [9]string{
+		serviceURL + "GetObservations",
+		serviceURL + "GetAggregatedObservations",
+		serviceURL + "GetDailyRollups",
+		serviceURL + "TriggerJob",
+		serviceURL + "GetJobStatus",
+		serviceURL + "ListIncidents",
+		serviceURL + "GetSummary",
+		serviceURL + "GetFailuresSince",
+		serviceURL + "GetConfigStatus",
+	}
+
+	return &agentServiceJSONClient{
+		client:      client,
+		urls:        urls,
+		interceptor: twirp.ChainInterceptors(clientOpts.Interceptors...),
+		opts:        clientOpts,
+	}
+}
+
+func (c *agentServiceJSONClient) GetObservations(ctx context.Context, in *GetObservationsRequest) (*GetObservationsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetObservations")
+	caller := c.callGetObservations
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetObservationsRequest) (*GetObservationsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetObservationsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+					}
+					return c.callGetObservations(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetObservationsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetObservationsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetObservations(ctx context.Context, in *GetObservationsRequest) (*GetObservationsResponse, error) {
+	out := new(GetObservationsResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[0], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) GetAggregatedObservations(ctx context.Context, in *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetAggregatedObservations")
+	caller := c.callGetAggregatedObservations
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetObservationsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+					}
+					return c.callGetAggregatedObservations(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetAggregatedObservationsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetAggregatedObservationsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetAggregatedObservations(ctx context.Context, in *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+	out := new(GetAggregatedObservationsResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[1], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) GetDailyRollups(ctx context.Context, in *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetDailyRollups")
+	caller := c.callGetDailyRollups
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDailyRollupsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDailyRollupsRequest) when calling interceptor")
+					}
+					return c.callGetDailyRollups(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDailyRollupsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDailyRollupsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetDailyRollups(ctx context.Context, in *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+	out := new(GetDailyRollupsResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[2], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) TriggerJob(ctx context.Context, in *TriggerJobRequest) (*TriggerJobResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "TriggerJob")
+	caller := c.callTriggerJob
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *TriggerJobRequest) (*TriggerJobResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*TriggerJobRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*TriggerJobRequest) when calling interceptor")
+					}
+					return c.callTriggerJob(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*TriggerJobResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*TriggerJobResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callTriggerJob(ctx context.Context, in *TriggerJobRequest) (*TriggerJobResponse, error) {
+	out := new(TriggerJobResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[3], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) GetJobStatus(ctx context.Context, in *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetJobStatus")
+	caller := c.callGetJobStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetJobStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetJobStatusRequest) when calling interceptor")
+					}
+					return c.callGetJobStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetJobStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetJobStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetJobStatus(ctx context.Context, in *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+	out := new(GetJobStatusResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[4], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) ListIncidents(ctx context.Context, in *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "ListIncidents")
+	caller := c.callListIncidents
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListIncidentsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListIncidentsRequest) when calling interceptor")
+					}
+					return c.callListIncidents(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListIncidentsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListIncidentsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callListIncidents(ctx context.Context, in *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+	out := new(ListIncidentsResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[5], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) GetSummary(ctx context.Context, in *GetSummaryRequest) (*GetSummaryResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetSummary")
+	caller := c.callGetSummary
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetSummaryRequest) (*GetSummaryResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetSummaryRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetSummaryRequest) when calling interceptor")
+					}
+					return c.callGetSummary(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetSummaryResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSummaryResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetSummary(ctx context.Context, in *GetSummaryRequest) (*GetSummaryResponse, error) {
+	out := new(GetSummaryResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[6], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) GetFailuresSince(ctx context.Context, in *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetFailuresSince")
+	caller := c.callGetFailuresSince
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetFailuresSinceRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetFailuresSinceRequest) when calling interceptor")
+					}
+					return c.callGetFailuresSince(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetFailuresSinceResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetFailuresSinceResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetFailuresSince(ctx context.Context, in *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
+	out := new(GetFailuresSinceResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[7], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+func (c *agentServiceJSONClient) GetConfigStatus(ctx context.Context, in *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithMethodName(ctx, "GetConfigStatus")
+	caller := c.callGetConfigStatus
+	if c.interceptor != nil {
+		caller = func(ctx context.Context, req *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
+			resp, err := c.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetConfigStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetConfigStatusRequest) when calling interceptor")
+					}
+					return c.callGetConfigStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetConfigStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetConfigStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+	return caller(ctx, in)
+}
+
+func (c *agentServiceJSONClient) callGetConfigStatus(ctx context.Context, in *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
+	out := new(GetConfigStatusResponse)
+	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[8], in, out)
+	if err != nil {
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			twerr = twirp.InternalErrorWith(err)
+		}
+		callClientError(ctx, c.opts.Hooks, twerr)
+		return nil, err
+	}
+
+	callClientResponseReceived(ctx, c.opts.Hooks)
+
+	return out, nil
+}
+
+// ===========================
+// AgentService Server Handler
+// ===========================
+
+type agentServiceServer struct {
+	AgentService
+	interceptor      twirp.Interceptor
+	hooks            *twirp.ServerHooks
+	pathPrefix       string // prefix for routing
+	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
+	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
+}
+
+// NewAgentServiceServer builds a TwirpServer that can be used as an http.Handler to handle
+// HTTP requests that are routed to the right method in the provided svc implementation.
+// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
+func NewAgentServiceServer(svc AgentService, opts ...interface{}) TwirpServer {
+	serverOpts := newServerOpts(opts)
+
+	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
+	jsonSkipDefaults := false
+	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
+	jsonCamelCase := false
+	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
+	var pathPrefix string
+	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
+		pathPrefix = "/twirp" // default prefix
+	}
+
+	return &agentServiceServer{
+		AgentService:     svc,
+		hooks:            serverOpts.Hooks,
+		interceptor:      twirp.ChainInterceptors(serverOpts.Interceptors...),
+		pathPrefix:       pathPrefix,
+		jsonSkipDefaults: jsonSkipDefaults,
+		jsonCamelCase:    jsonCamelCase,
+	}
+}
+
+// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
+// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
+func (s *agentServiceServer) writeError(ctx context.Context, resp http.ResponseWriter, err error) {
+	writeError(ctx, resp, err, s.hooks)
+}
+
+// handleRequestBodyError is used to handle error when the twirp server cannot read request
+func (s *agentServiceServer) handleRequestBodyError(ctx context.Context, resp http.ResponseWriter, msg string, err error) {
+	if context.Canceled == ctx.Err() {
+		s.writeError(ctx, resp, twirp.NewError(twirp.Canceled, "failed to read request: context canceled"))
+		return
+	}
+	if context.DeadlineExceeded == ctx.Err() {
+		s.writeError(ctx, resp, twirp.NewError(twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+		return
+	}
+	s.writeError(ctx, resp, twirp.WrapError(malformedRequestError(msg), err))
+}
+
+// AgentServicePathPrefix is a convenience constant that may identify URL paths.
+// Should be used with caution, it only matches routes generated by Twirp Go clients,
+// with the default "/twirp" prefix and default CamelCase service and method names.
+// More info: https://twitchtv.github.io/twirp/docs/routing.html
+const AgentServicePathPrefix = "/twirp/nwpd.AgentService/"
+
+func (s *agentServiceServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
+	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
+	ctx = ctxsetters.WithResponseWriter(ctx, resp)
+
+	var err error
+	ctx, err = callRequestReceived(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	if req.Method != "POST" {
+		msg := fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
+	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
+	if pkgService != "nwpd.AgentService" {
+		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+	if prefix != s.pathPrefix {
+		msg := fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+
+	switch method {
+	case "GetObservations":
+		s.serveGetObservations(ctx, resp, req)
+		return
+	case "GetAggregatedObservations":
+		s.serveGetAggregatedObservations(ctx, resp, req)
+		return
+	case "GetDailyRollups":
+		s.serveGetDailyRollups(ctx, resp, req)
+		return
+	case "TriggerJob":
+		s.serveTriggerJob(ctx, resp, req)
+		return
+	case "GetJobStatus":
+		s.serveGetJobStatus(ctx, resp, req)
+		return
+	case "ListIncidents":
+		s.serveListIncidents(ctx, resp, req)
+		return
+	case "GetSummary":
+		s.serveGetSummary(ctx, resp, req)
+		return
+	case "GetFailuresSince":
+		s.serveGetFailuresSince(ctx, resp, req)
+		return
+	case "GetConfigStatus":
+		s.serveGetConfigStatus(ctx, resp, req)
+		return
+	default:
+		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
+		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+		return
+	}
+}
+
+func (s *agentServiceServer) serveGetObservations(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetObservationsJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetObservationsProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveGetObservationsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetObservations")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetObservationsRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.AgentService.GetObservations
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetObservationsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetObservationsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+					}
+					return s.AgentService.GetObservations(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetObservationsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetObservationsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetObservationsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetObservationsResponse and nil error while calling GetObservations. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetObservationsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetObservations")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetObservationsRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.AgentService.GetObservations
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetObservationsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetObservationsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+					}
+					return s.AgentService.GetObservations(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetObservationsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetObservationsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetObservationsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetObservationsResponse and nil error while calling GetObservations. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetAggregatedObservations(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetAggregatedObservationsJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetAggregatedObservationsProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveGetAggregatedObservationsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetAggregatedObservations")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetObservationsRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.AgentService.GetAggregatedObservations
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetObservationsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+					}
+					return s.AgentService.GetAggregatedObservations(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetAggregatedObservationsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetAggregatedObservationsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetAggregatedObservationsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetAggregatedObservationsResponse and nil error while calling GetAggregatedObservations. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetAggregatedObservationsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetAggregatedObservations")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetObservationsRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.AgentService.GetAggregatedObservations
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetObservationsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+					}
+					return s.AgentService.GetAggregatedObservations(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetAggregatedObservationsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetAggregatedObservationsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetAggregatedObservationsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetAggregatedObservationsResponse and nil error while calling GetAggregatedObservations. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetDailyRollups(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetDailyRollupsJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetDailyRollupsProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveGetDailyRollupsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetDailyRollups")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetDailyRollupsRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.AgentService.GetDailyRollups
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDailyRollupsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDailyRollupsRequest) when calling interceptor")
+					}
+					return s.AgentService.GetDailyRollups(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDailyRollupsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDailyRollupsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetDailyRollupsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetDailyRollupsResponse and nil error while calling GetDailyRollups. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetDailyRollupsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetDailyRollups")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetDailyRollupsRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.AgentService.GetDailyRollups
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetDailyRollupsRequest) (*GetDailyRollupsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetDailyRollupsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetDailyRollupsRequest) when calling interceptor")
+					}
+					return s.AgentService.GetDailyRollups(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetDailyRollupsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetDailyRollupsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetDailyRollupsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetDailyRollupsResponse and nil error while calling GetDailyRollups. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveTriggerJob(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveTriggerJobJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveTriggerJobProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveTriggerJobJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "TriggerJob")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(TriggerJobRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.AgentService.TriggerJob
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *TriggerJobRequest) (*TriggerJobResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*TriggerJobRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*TriggerJobRequest) when calling interceptor")
+					}
+					return s.AgentService.TriggerJob(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*TriggerJobResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*TriggerJobResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *TriggerJobResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *TriggerJobResponse and nil error while calling TriggerJob. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveTriggerJobProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "TriggerJob")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(TriggerJobRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.AgentService.TriggerJob
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *TriggerJobRequest) (*TriggerJobResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*TriggerJobRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*TriggerJobRequest) when calling interceptor")
+					}
+					return s.AgentService.TriggerJob(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*TriggerJobResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*TriggerJobResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *TriggerJobResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *TriggerJobResponse and nil error while calling TriggerJob. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetJobStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetJobStatusJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetJobStatusProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveGetJobStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetJobStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetJobStatusRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.AgentService.GetJobStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetJobStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetJobStatusRequest) when calling interceptor")
+					}
+					return s.AgentService.GetJobStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetJobStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetJobStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetJobStatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetJobStatusResponse and nil error while calling GetJobStatus. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetJobStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetJobStatus")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(GetJobStatusRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.AgentService.GetJobStatus
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetJobStatusRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetJobStatusRequest) when calling interceptor")
+					}
+					return s.AgentService.GetJobStatus(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetJobStatusResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetJobStatusResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *GetJobStatusResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetJobStatusResponse and nil error while calling GetJobStatus. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveListIncidents(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveListIncidentsJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveListIncidentsProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveListIncidentsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListIncidents")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(ListIncidentsRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+
+	handler := s.AgentService.ListIncidents
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListIncidentsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListIncidentsRequest) when calling interceptor")
+					}
+					return s.AgentService.ListIncidents(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListIncidentsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListIncidentsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListIncidentsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListIncidentsResponse and nil error while calling ListIncidents. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveListIncidentsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "ListIncidents")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
+		return
+	}
+	reqContent := new(ListIncidentsRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
+		return
+	}
+
+	handler := s.AgentService.ListIncidents
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *ListIncidentsRequest) (*ListIncidentsResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*ListIncidentsRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*ListIncidentsRequest) when calling interceptor")
+					}
+					return s.AgentService.ListIncidents(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*ListIncidentsResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*ListIncidentsResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
+
+	// Call service method
+	var respContent *ListIncidentsResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *ListIncidentsResponse and nil error while calling ListIncidents. nil responses are not supported"))
+		return
+	}
+
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
+		return
+	}
+
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
+	}
+	callResponseSent(ctx, s.hooks)
+}
+
+func (s *agentServiceServer) serveGetSummary(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	header := req.Header.Get("Content-Type")
+	i := strings.Index(header, ";")
+	if i == -1 {
+		i = len(header)
+	}
+	switch strings.TrimSpace(strings.ToLower(header[:i])) {
+	case "application/json":
+		s.serveGetSummaryJSON(ctx, resp, req)
+	case "application/protobuf":
+		s.serveGetSummaryProtobuf(ctx, resp, req)
+	default:
+		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
+		twerr := badRouteError(msg, req.Method, req.URL.Path)
+		s.writeError(ctx, resp, twerr)
+	}
+}
+
+func (s *agentServiceServer) serveGetSummaryJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetSummary")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
+
+	d := json.NewDecoder(req.Body)
+	rawReqBody := json.RawMessage{}
+	if err := d.Decode(&rawReqBody); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
+	reqContent := new(GetSummaryRequest)
+	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
+		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
+		return
+	}
 
-func (c *agentServiceJSONClient) GetAggregatedObservations(ctx context.Context, in *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
-	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
-	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
-	ctx = ctxsetters.WithMethodName(ctx, "GetAggregatedObservations")
-	caller := c.callGetAggregatedObservations
-	if c.interceptor != nil {
-		caller = func(ctx context.Context, req *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
-			resp, err := c.interceptor(
+	handler := s.AgentService.GetSummary
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetSummaryRequest) (*GetSummaryResponse, error) {
+			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*GetObservationsRequest)
+					typedReq, ok := req.(*GetSummaryRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetSummaryRequest) when calling interceptor")
 					}
-					return c.callGetAggregatedObservations(ctx, typedReq)
+					return s.AgentService.GetSummary(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*GetAggregatedObservationsResponse)
+				typedResp, ok := resp.(*GetSummaryResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*GetAggregatedObservationsResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSummaryResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
 			return nil, err
 		}
 	}
-	return caller(ctx, in)
-}
 
-func (c *agentServiceJSONClient) callGetAggregatedObservations(ctx context.Context, in *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
-	out := new(GetAggregatedObservationsResponse)
-	ctx, err := doJSONRequest(ctx, c.client, c.opts.Hooks, c.urls[1], in, out)
+	// Call service method
+	var respContent *GetSummaryResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
+
 	if err != nil {
-		twerr, ok := err.(twirp.Error)
-		if !ok {
-			twerr = twirp.InternalErrorWith(err)
-		}
-		callClientError(ctx, c.opts.Hooks, twerr)
-		return nil, err
+		s.writeError(ctx, resp, err)
+		return
+	}
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetSummaryResponse and nil error while calling GetSummary. nil responses are not supported"))
+		return
 	}
 
-	callClientResponseReceived(ctx, c.opts.Hooks)
-
-	return out, nil
-}
-
-// ===========================
-// AgentService Server Handler
-// ===========================
-
-type agentServiceServer struct {
-	AgentService
-	interceptor      twirp.Interceptor
-	hooks            *twirp.ServerHooks
-	pathPrefix       string // prefix for routing
-	jsonSkipDefaults bool   // do not include unpopulated fields (default values) in the response
-	jsonCamelCase    bool   // JSON fields are serialized as lowerCamelCase rather than keeping the original proto names
-}
-
-// NewAgentServiceServer builds a TwirpServer that can be used as an http.Handler to handle
-// HTTP requests that are routed to the right method in the provided svc implementation.
-// The opts are twirp.ServerOption modifiers, for example twirp.WithServerHooks(hooks).
-func NewAgentServiceServer(svc AgentService, opts ...interface{}) TwirpServer {
-	serverOpts := newServerOpts(opts)
+	ctx = callResponsePrepared(ctx, s.hooks)
 
-	// Using ReadOpt allows backwards and forwards compatibility with new options in the future
-	jsonSkipDefaults := false
-	_ = serverOpts.ReadOpt("jsonSkipDefaults", &jsonSkipDefaults)
-	jsonCamelCase := false
-	_ = serverOpts.ReadOpt("jsonCamelCase", &jsonCamelCase)
-	var pathPrefix string
-	if ok := serverOpts.ReadOpt("pathPrefix", &pathPrefix); !ok {
-		pathPrefix = "/twirp" // default prefix
+	marshaler := &protojson.MarshalOptions{UseProtoNames: !s.jsonCamelCase, EmitUnpopulated: !s.jsonSkipDefaults}
+	respBytes, err := marshaler.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal json response"))
+		return
 	}
 
-	return &agentServiceServer{
-		AgentService:     svc,
-		hooks:            serverOpts.Hooks,
-		interceptor:      twirp.ChainInterceptors(serverOpts.Interceptors...),
-		pathPrefix:       pathPrefix,
-		jsonSkipDefaults: jsonSkipDefaults,
-		jsonCamelCase:    jsonCamelCase,
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
 	}
+	callResponseSent(ctx, s.hooks)
 }
 
-// writeError writes an HTTP response with a valid Twirp error format, and triggers hooks.
-// If err is not a twirp.Error, it will get wrapped with twirp.InternalErrorWith(err)
-func (s *agentServiceServer) writeError(ctx context.Context, resp http.ResponseWriter, err error) {
-	writeError(ctx, resp, err, s.hooks)
-}
+func (s *agentServiceServer) serveGetSummaryProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+	var err error
+	ctx = ctxsetters.WithMethodName(ctx, "GetSummary")
+	ctx, err = callRequestRouted(ctx, s.hooks)
+	if err != nil {
+		s.writeError(ctx, resp, err)
+		return
+	}
 
-// handleRequestBodyError is used to handle error when the twirp server cannot read request
-func (s *agentServiceServer) handleRequestBodyError(ctx context.Context, resp http.ResponseWriter, msg string, err error) {
-	if context.Canceled == ctx.Err() {
-		s.writeError(ctx, resp, twirp.NewError(twirp.Canceled, "failed to read request: context canceled"))
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	if context.DeadlineExceeded == ctx.Err() {
-		s.writeError(ctx, resp, twirp.NewError(twirp.DeadlineExceeded, "failed to read request: deadline exceeded"))
+	reqContent := new(GetSummaryRequest)
+	if err = proto.Unmarshal(buf, reqContent); err != nil {
+		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
-	s.writeError(ctx, resp, twirp.WrapError(malformedRequestError(msg), err))
-}
 
-// AgentServicePathPrefix is a convenience constant that may identify URL paths.
-// Should be used with caution, it only matches routes generated by Twirp Go clients,
-// with the default "/twirp" prefix and default CamelCase service and method names.
-// More info: https://twitchtv.github.io/twirp/docs/routing.html
-const AgentServicePathPrefix = "/twirp/nwpd.AgentService/"
+	handler := s.AgentService.GetSummary
+	if s.interceptor != nil {
+		handler = func(ctx context.Context, req *GetSummaryRequest) (*GetSummaryResponse, error) {
+			resp, err := s.interceptor(
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					typedReq, ok := req.(*GetSummaryRequest)
+					if !ok {
+						return nil, twirp.InternalError("failed type assertion req.(*GetSummaryRequest) when calling interceptor")
+					}
+					return s.AgentService.GetSummary(ctx, typedReq)
+				},
+			)(ctx, req)
+			if resp != nil {
+				typedResp, ok := resp.(*GetSummaryResponse)
+				if !ok {
+					return nil, twirp.InternalError("failed type assertion resp.(*GetSummaryResponse) when calling interceptor")
+				}
+				return typedResp, err
+			}
+			return nil, err
+		}
+	}
 
-func (s *agentServiceServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
-	ctx := req.Context()
-	ctx = ctxsetters.WithPackageName(ctx, "nwpd")
-	ctx = ctxsetters.WithServiceName(ctx, "AgentService")
-	ctx = ctxsetters.WithResponseWriter(ctx, resp)
+	// Call service method
+	var respContent *GetSummaryResponse
+	func() {
+		defer ensurePanicResponses(ctx, resp, s.hooks)
+		respContent, err = handler(ctx, reqContent)
+	}()
 
-	var err error
-	ctx, err = callRequestReceived(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
 		return
 	}
-
-	if req.Method != "POST" {
-		msg := fmt.Sprintf("unsupported method %q (only POST is allowed)", req.Method)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+	if respContent == nil {
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetSummaryResponse and nil error while calling GetSummary. nil responses are not supported"))
 		return
 	}
 
-	// Verify path format: [<prefix>]/<package>.<Service>/<Method>
-	prefix, pkgService, method := parseTwirpPath(req.URL.Path)
-	if pkgService != "nwpd.AgentService" {
-		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
-		return
-	}
-	if prefix != s.pathPrefix {
-		msg := fmt.Sprintf("invalid path prefix %q, expected %q, on path %q", prefix, s.pathPrefix, req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
+	ctx = callResponsePrepared(ctx, s.hooks)
+
+	respBytes, err := proto.Marshal(respContent)
+	if err != nil {
+		s.writeError(ctx, resp, wrapInternal(err, "failed to marshal proto response"))
 		return
 	}
 
-	switch method {
-	case "GetObservations":
-		s.serveGetObservations(ctx, resp, req)
-		return
-	case "GetAggregatedObservations":
-		s.serveGetAggregatedObservations(ctx, resp, req)
-		return
-	default:
-		msg := fmt.Sprintf("no handler for path %q", req.URL.Path)
-		s.writeError(ctx, resp, badRouteError(msg, req.Method, req.URL.Path))
-		return
+	ctx = ctxsetters.WithStatusCode(ctx, http.StatusOK)
+	resp.Header().Set("Content-Type", "application/protobuf")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))
+	resp.WriteHeader(http.StatusOK)
+	if n, err := resp.Write(respBytes); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())
+		twerr := twirp.NewError(twirp.Unknown, msg)
+		ctx = callError(ctx, s.hooks, twerr)
 	}
+	callResponseSent(ctx, s.hooks)
 }
 
-func (s *agentServiceServer) serveGetObservations(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *agentServiceServer) serveGetFailuresSince(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	header := req.Header.Get("Content-Type")
 	i := strings.Index(header, ";")
 	if i == -1 {
@@ -447,9 +2389,9 @@ func (s *agentServiceServer) serveGetObservations(ctx context.Context, resp http
 	}
 	switch strings.TrimSpace(strings.ToLower(header[:i])) {
 	case "application/json":
-		s.serveGetObservationsJSON(ctx, resp, req)
+		s.serveGetFailuresSinceJSON(ctx, resp, req)
 	case "application/protobuf":
-		s.serveGetObservationsProtobuf(ctx, resp, req)
+		s.serveGetFailuresSinceProtobuf(ctx, resp, req)
 	default:
 		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
 		twerr := badRouteError(msg, req.Method, req.URL.Path)
@@ -457,9 +2399,9 @@ func (s *agentServiceServer) serveGetObservations(ctx context.Context, resp http
 	}
 }
 
-func (s *agentServiceServer) serveGetObservationsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *agentServiceServer) serveGetFailuresSinceJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "GetObservations")
+	ctx = ctxsetters.WithMethodName(ctx, "GetFailuresSince")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -472,29 +2414,29 @@ func (s *agentServiceServer) serveGetObservationsJSON(ctx context.Context, resp
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
-	reqContent := new(GetObservationsRequest)
+	reqContent := new(GetFailuresSinceRequest)
 	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
 	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
 
-	handler := s.AgentService.GetObservations
+	handler := s.AgentService.GetFailuresSince
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetObservationsResponse, error) {
+		handler = func(ctx context.Context, req *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*GetObservationsRequest)
+					typedReq, ok := req.(*GetFailuresSinceRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetFailuresSinceRequest) when calling interceptor")
 					}
-					return s.AgentService.GetObservations(ctx, typedReq)
+					return s.AgentService.GetFailuresSince(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*GetObservationsResponse)
+				typedResp, ok := resp.(*GetFailuresSinceResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*GetObservationsResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetFailuresSinceResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -503,7 +2445,7 @@ func (s *agentServiceServer) serveGetObservationsJSON(ctx context.Context, resp
 	}
 
 	// Call service method
-	var respContent *GetObservationsResponse
+	var respContent *GetFailuresSinceResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -514,7 +2456,7 @@ func (s *agentServiceServer) serveGetObservationsJSON(ctx context.Context, resp
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetObservationsResponse and nil error while calling GetObservations. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetFailuresSinceResponse and nil error while calling GetFailuresSince. nil responses are not supported"))
 		return
 	}
 
@@ -540,9 +2482,9 @@ func (s *agentServiceServer) serveGetObservationsJSON(ctx context.Context, resp
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *agentServiceServer) serveGetObservationsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *agentServiceServer) serveGetFailuresSinceProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "GetObservations")
+	ctx = ctxsetters.WithMethodName(ctx, "GetFailuresSince")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -554,28 +2496,28 @@ func (s *agentServiceServer) serveGetObservationsProtobuf(ctx context.Context, r
 		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	reqContent := new(GetObservationsRequest)
+	reqContent := new(GetFailuresSinceRequest)
 	if err = proto.Unmarshal(buf, reqContent); err != nil {
 		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
 
-	handler := s.AgentService.GetObservations
+	handler := s.AgentService.GetFailuresSince
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetObservationsResponse, error) {
+		handler = func(ctx context.Context, req *GetFailuresSinceRequest) (*GetFailuresSinceResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*GetObservationsRequest)
+					typedReq, ok := req.(*GetFailuresSinceRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetFailuresSinceRequest) when calling interceptor")
 					}
-					return s.AgentService.GetObservations(ctx, typedReq)
+					return s.AgentService.GetFailuresSince(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*GetObservationsResponse)
+				typedResp, ok := resp.(*GetFailuresSinceResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*GetObservationsResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetFailuresSinceResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -584,7 +2526,7 @@ func (s *agentServiceServer) serveGetObservationsProtobuf(ctx context.Context, r
 	}
 
 	// Call service method
-	var respContent *GetObservationsResponse
+	var respContent *GetFailuresSinceResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -595,7 +2537,7 @@ func (s *agentServiceServer) serveGetObservationsProtobuf(ctx context.Context, r
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetObservationsResponse and nil error while calling GetObservations. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetFailuresSinceResponse and nil error while calling GetFailuresSince. nil responses are not supported"))
 		return
 	}
 
@@ -619,7 +2561,7 @@ func (s *agentServiceServer) serveGetObservationsProtobuf(ctx context.Context, r
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *agentServiceServer) serveGetAggregatedObservations(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *agentServiceServer) serveGetConfigStatus(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	header := req.Header.Get("Content-Type")
 	i := strings.Index(header, ";")
 	if i == -1 {
@@ -627,9 +2569,9 @@ func (s *agentServiceServer) serveGetAggregatedObservations(ctx context.Context,
 	}
 	switch strings.TrimSpace(strings.ToLower(header[:i])) {
 	case "application/json":
-		s.serveGetAggregatedObservationsJSON(ctx, resp, req)
+		s.serveGetConfigStatusJSON(ctx, resp, req)
 	case "application/protobuf":
-		s.serveGetAggregatedObservationsProtobuf(ctx, resp, req)
+		s.serveGetConfigStatusProtobuf(ctx, resp, req)
 	default:
 		msg := fmt.Sprintf("unexpected Content-Type: %q", req.Header.Get("Content-Type"))
 		twerr := badRouteError(msg, req.Method, req.URL.Path)
@@ -637,9 +2579,9 @@ func (s *agentServiceServer) serveGetAggregatedObservations(ctx context.Context,
 	}
 }
 
-func (s *agentServiceServer) serveGetAggregatedObservationsJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *agentServiceServer) serveGetConfigStatusJSON(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "GetAggregatedObservations")
+	ctx = ctxsetters.WithMethodName(ctx, "GetConfigStatus")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -652,29 +2594,29 @@ func (s *agentServiceServer) serveGetAggregatedObservationsJSON(ctx context.Cont
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
-	reqContent := new(GetObservationsRequest)
+	reqContent := new(GetConfigStatusRequest)
 	unmarshaler := protojson.UnmarshalOptions{DiscardUnknown: true}
 	if err = unmarshaler.Unmarshal(rawReqBody, reqContent); err != nil {
 		s.handleRequestBodyError(ctx, resp, "the json request could not be decoded", err)
 		return
 	}
 
-	handler := s.AgentService.GetAggregatedObservations
+	handler := s.AgentService.GetConfigStatus
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+		handler = func(ctx context.Context, req *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*GetObservationsRequest)
+					typedReq, ok := req.(*GetConfigStatusRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetConfigStatusRequest) when calling interceptor")
 					}
-					return s.AgentService.GetAggregatedObservations(ctx, typedReq)
+					return s.AgentService.GetConfigStatus(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*GetAggregatedObservationsResponse)
+				typedResp, ok := resp.(*GetConfigStatusResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*GetAggregatedObservationsResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetConfigStatusResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -683,7 +2625,7 @@ func (s *agentServiceServer) serveGetAggregatedObservationsJSON(ctx context.Cont
 	}
 
 	// Call service method
-	var respContent *GetAggregatedObservationsResponse
+	var respContent *GetConfigStatusResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -694,7 +2636,7 @@ func (s *agentServiceServer) serveGetAggregatedObservationsJSON(ctx context.Cont
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetAggregatedObservationsResponse and nil error while calling GetAggregatedObservations. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetConfigStatusResponse and nil error while calling GetConfigStatus. nil responses are not supported"))
 		return
 	}
 
@@ -720,9 +2662,9 @@ func (s *agentServiceServer) serveGetAggregatedObservationsJSON(ctx context.Cont
 	callResponseSent(ctx, s.hooks)
 }
 
-func (s *agentServiceServer) serveGetAggregatedObservationsProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
+func (s *agentServiceServer) serveGetConfigStatusProtobuf(ctx context.Context, resp http.ResponseWriter, req *http.Request) {
 	var err error
-	ctx = ctxsetters.WithMethodName(ctx, "GetAggregatedObservations")
+	ctx = ctxsetters.WithMethodName(ctx, "GetConfigStatus")
 	ctx, err = callRequestRouted(ctx, s.hooks)
 	if err != nil {
 		s.writeError(ctx, resp, err)
@@ -734,28 +2676,28 @@ func (s *agentServiceServer) serveGetAggregatedObservationsProtobuf(ctx context.
 		s.handleRequestBodyError(ctx, resp, "failed to read request body", err)
 		return
 	}
-	reqContent := new(GetObservationsRequest)
+	reqContent := new(GetConfigStatusRequest)
 	if err = proto.Unmarshal(buf, reqContent); err != nil {
 		s.writeError(ctx, resp, malformedRequestError("the protobuf request could not be decoded"))
 		return
 	}
 
-	handler := s.AgentService.GetAggregatedObservations
+	handler := s.AgentService.GetConfigStatus
 	if s.interceptor != nil {
-		handler = func(ctx context.Context, req *GetObservationsRequest) (*GetAggregatedObservationsResponse, error) {
+		handler = func(ctx context.Context, req *GetConfigStatusRequest) (*GetConfigStatusResponse, error) {
 			resp, err := s.interceptor(
 				func(ctx context.Context, req interface{}) (interface{}, error) {
-					typedReq, ok := req.(*GetObservationsRequest)
+					typedReq, ok := req.(*GetConfigStatusRequest)
 					if !ok {
-						return nil, twirp.InternalError("failed type assertion req.(*GetObservationsRequest) when calling interceptor")
+						return nil, twirp.InternalError("failed type assertion req.(*GetConfigStatusRequest) when calling interceptor")
 					}
-					return s.AgentService.GetAggregatedObservations(ctx, typedReq)
+					return s.AgentService.GetConfigStatus(ctx, typedReq)
 				},
 			)(ctx, req)
 			if resp != nil {
-				typedResp, ok := resp.(*GetAggregatedObservationsResponse)
+				typedResp, ok := resp.(*GetConfigStatusResponse)
 				if !ok {
-					return nil, twirp.InternalError("failed type assertion resp.(*GetAggregatedObservationsResponse) when calling interceptor")
+					return nil, twirp.InternalError("failed type assertion resp.(*GetConfigStatusResponse) when calling interceptor")
 				}
 				return typedResp, err
 			}
@@ -764,7 +2706,7 @@ func (s *agentServiceServer) serveGetAggregatedObservationsProtobuf(ctx context.
 	}
 
 	// Call service method
-	var respContent *GetAggregatedObservationsResponse
+	var respContent *GetConfigStatusResponse
 	func() {
 		defer ensurePanicResponses(ctx, resp, s.hooks)
 		respContent, err = handler(ctx, reqContent)
@@ -775,7 +2717,7 @@ func (s *agentServiceServer) serveGetAggregatedObservationsProtobuf(ctx context.
 		return
 	}
 	if respContent == nil {
-		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetAggregatedObservationsResponse and nil error while calling GetAggregatedObservations. nil responses are not supported"))
+		s.writeError(ctx, resp, twirp.InternalError("received a nil *GetConfigStatusResponse and nil error while calling GetConfigStatus. nil responses are not supported"))
 		return
 	}
 
@@ -1380,58 +3322,171 @@ func callClientError(ctx context.Context, h *twirp.ClientHooks, err twirp.Error)
 }
 
 var twirpFileDescriptor0 = []byte{
-	// 834 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xe1, 0x8e, 0xdb, 0x44,
-	0x10, 0xae, 0xe3, 0x73, 0x2e, 0x99, 0x9c, 0x8e, 0xeb, 0x5e, 0x39, 0x5c, 0x03, 0x25, 0x18, 0x09,
-	0x22, 0xd4, 0xda, 0xe5, 0x4a, 0xd1, 0x09, 0x55, 0x95, 0x02, 0x57, 0x1d, 0x39, 0xa9, 0x4d, 0xb5,
-	0xa9, 0x54, 0x09, 0xf1, 0xc7, 0x89, 0xb7, 0xc6, 0x8d, 0xbd, 0x1b, 0x76, 0xd7, 0x77, 0xe4, 0x11,
-	0x78, 0x10, 0x24, 0x9e, 0x84, 0x67, 0xe0, 0x71, 0x90, 0x77, 0x9d, 0xc4, 0x71, 0x9c, 0x04, 0xfe,
-	0x44, 0x3b, 0x33, 0xdf, 0x7c, 0xbb, 0x33, 0xfb, 0xed, 0x38, 0xe0, 0xcc, 0xa6, 0x91, 0x3f, 0x61,
-	0x69, 0xca, 0xa8, 0x4f, 0x6f, 0x67, 0xa1, 0xfa, 0xf1, 0x66, 0x9c, 0x49, 0x86, 0x0e, 0xf2, 0xb5,
-	0xf3, 0x59, 0xc4, 0x58, 0x94, 0x10, 0x5f, 0xf9, 0xc6, 0xd9, 0x3b, 0x5f, 0xc6, 0x29, 0x11, 0x32,
-	0x48, 0x67, 0x1a, 0xe6, 0x3c, 0xa8, 0x02, 0xc2, 0x8c, 0x07, 0x32, 0x66, 0x54, 0xc7, 0xdd, 0x3f,
-	0x4c, 0x38, 0xbb, 0x22, 0x72, 0x38, 0x16, 0x84, 0xdf, 0xa8, 0x80, 0xc0, 0xe4, 0xb7, 0x8c, 0x08,
-	0x89, 0x1e, 0x83, 0x25, 0x64, 0xc0, 0xa5, 0x6d, 0x74, 0x8d, 0x5e, 0xe7, 0xdc, 0xf1, 0x34, 0x95,
-	0xb7, 0xa0, 0xf2, 0xde, 0x2c, 0xf6, 0xc2, 0x1a, 0x88, 0x1e, 0x82, 0x49, 0x68, 0x68, 0x37, 0xf6,
-	0xe2, 0x73, 0x18, 0xba, 0x07, 0x56, 0x12, 0xa7, 0xb1, 0xb4, 0xcd, 0xae, 0xd1, 0xb3, 0xb0, 0x36,
-	0xd0, 0xd7, 0x70, 0xc2, 0x89, 0x90, 0x3c, 0x9e, 0xc8, 0x37, 0xec, 0x9a, 0x8d, 0x07, 0x97, 0xc2,
-	0x3e, 0xe8, 0x9a, 0xbd, 0x36, 0xde, 0xf0, 0x23, 0x0f, 0xd0, 0xca, 0x37, 0xe2, 0x93, 0x9f, 0x98,
-	0x90, 0xc2, 0xb6, 0x14, 0xba, 0x26, 0x82, 0x1e, 0xc3, 0xe9, 0xca, 0x7b, 0x49, 0x84, 0xd4, 0x09,
-	0x4d, 0x95, 0x50, 0x17, 0x42, 0x57, 0x70, 0x37, 0x88, 0x22, 0x4e, 0x22, 0xd5, 0x9a, 0xb7, 0x31,
-	0x0d, 0xd9, 0xad, 0x7d, 0xa8, 0xea, 0xbb, 0xbf, 0x51, 0xdf, 0x65, 0xd1, 0x5a, 0xbc, 0x99, 0x83,
-	0x5c, 0x38, 0x7a, 0x17, 0xc4, 0x49, 0xc6, 0x89, 0x18, 0xd2, 0x64, 0x6e, 0xb7, 0xba, 0x46, 0xaf,
-	0x85, 0xd7, 0x7c, 0xee, 0x6b, 0xf8, 0x68, 0xe3, 0x2a, 0xc4, 0x8c, 0x51, 0x41, 0xd0, 0x53, 0x38,
-	0x62, 0x25, 0xbf, 0x6d, 0x74, 0xcd, 0x5e, 0xe7, 0xfc, 0xae, 0xa7, 0x04, 0x51, 0xca, 0xc0, 0x6b,
-	0x30, 0xf7, 0x77, 0xf8, 0xfc, 0x8a, 0xc8, 0x7e, 0x71, 0x1a, 0x12, 0xd6, 0x72, 0x8f, 0xe0, 0x2c,
-	0xa8, 0x45, 0x14, 0xbb, 0x7c, 0xac, 0x77, 0xa9, 0x65, 0xc1, 0x5b, 0x52, 0xdd, 0xbf, 0x2c, 0xf8,
-	0xb0, 0x36, 0x03, 0xd9, 0x70, 0x28, 0xf4, 0x85, 0x28, 0x61, 0xb5, 0xf1, 0xc2, 0x44, 0x0e, 0xb4,
-	0xc2, 0xa2, 0xf3, 0x4a, 0x43, 0x6d, 0xbc, 0xb4, 0xd1, 0x33, 0xe8, 0xcc, 0x08, 0x8f, 0x59, 0x38,
-	0x52, 0x92, 0x34, 0xf7, 0x4a, 0xac, 0x0c, 0x47, 0x17, 0xd0, 0xd6, 0xe6, 0x0b, 0x1a, 0xda, 0x07,
-	0x7b, 0x73, 0x57, 0x60, 0xf4, 0x0a, 0x3a, 0xef, 0xd9, 0x58, 0x0c, 0xa7, 0x3f, 0xb2, 0x8c, 0x4a,
-	0xa5, 0xad, 0xce, 0xf9, 0xc3, 0x1d, 0x1d, 0xf1, 0xae, 0x57, 0xf0, 0x17, 0x54, 0xf2, 0x39, 0x2e,
-	0x13, 0xa0, 0xb7, 0x70, 0x9c, 0x9b, 0xaf, 0x98, 0x5c, 0x50, 0x36, 0x15, 0xa5, 0xbf, 0x8f, 0x72,
-	0x95, 0xa1, 0x59, 0x2b, 0x34, 0x39, 0x71, 0x4a, 0x02, 0x3a, 0x9c, 0x2e, 0x54, 0x68, 0x1f, 0xee,
-	0x27, 0x7e, 0xb9, 0x96, 0x51, 0x10, 0xaf, 0xd3, 0x38, 0xcf, 0xe1, 0xa4, 0x5a, 0x12, 0x3a, 0x01,
-	0x73, 0x4a, 0xe6, 0xc5, 0xfd, 0xe5, 0xcb, 0xfc, 0x31, 0xdf, 0x04, 0x49, 0x46, 0xd4, 0xc5, 0x59,
-	0x58, 0x1b, 0xdf, 0x37, 0x2e, 0x0c, 0xa7, 0x0f, 0xa7, 0x35, 0xe7, 0xff, 0x5f, 0x14, 0xbf, 0xc0,
-	0x69, 0xcd, 0x49, 0x6b, 0x28, 0xfc, 0x32, 0xc5, 0xce, 0x27, 0xba, 0x62, 0x77, 0xff, 0x6c, 0x40,
-	0xa7, 0x2c, 0xd0, 0x7b, 0x60, 0xbd, 0xcf, 0xe7, 0x4b, 0x41, 0xac, 0x8d, 0xb2, 0x6c, 0x1b, 0xdb,
-	0x65, 0x6b, 0x56, 0x64, 0x7b, 0x01, 0xed, 0xe5, 0x44, 0xfe, 0x2f, 0xc2, 0x5b, 0x82, 0xd1, 0x53,
-	0x68, 0x2d, 0x46, 0xb5, 0x6d, 0xed, 0xab, 0x66, 0x09, 0x45, 0x67, 0xd0, 0xe4, 0x44, 0x64, 0x49,
-	0xae, 0xab, 0xfc, 0x28, 0x85, 0x85, 0x8e, 0xa1, 0xc1, 0xa6, 0x6a, 0x72, 0xb5, 0x70, 0x83, 0x4d,
-	0xd1, 0x37, 0xd0, 0xd4, 0x22, 0x57, 0x93, 0x68, 0x27, 0x79, 0x01, 0x74, 0xff, 0x31, 0xe0, 0x78,
-	0x40, 0x65, 0xa5, 0x55, 0xd7, 0xcb, 0x56, 0x99, 0x58, 0x1b, 0xd5, 0x56, 0x99, 0xdb, 0x5b, 0x65,
-	0x96, 0x5a, 0xf5, 0x00, 0x20, 0xaf, 0xfe, 0x65, 0x9c, 0x24, 0xb1, 0x50, 0xbd, 0x32, 0x71, 0xc9,
-	0x83, 0xbe, 0x84, 0xe3, 0x45, 0x95, 0x05, 0xc6, 0x52, 0x3a, 0xa9, 0x78, 0x8b, 0x4a, 0x9b, 0xcb,
-	0x4a, 0x5d, 0x38, 0xd2, 0x05, 0x14, 0x59, 0x87, 0x2a, 0x6b, 0xcd, 0xe7, 0x7e, 0x01, 0x9d, 0x01,
-	0x95, 0xdf, 0x7d, 0xdb, 0xe7, 0x3c, 0x98, 0x8b, 0xbc, 0xac, 0x20, 0x5f, 0xa9, 0x01, 0x68, 0x62,
-	0x6d, 0xb8, 0x4f, 0xa0, 0x3d, 0xa0, 0x72, 0x24, 0x79, 0x4c, 0xa3, 0xb2, 0xf6, 0xcc, 0x1a, 0xf9,
-	0xb6, 0x0b, 0x81, 0x9d, 0xff, 0x6d, 0xc0, 0x51, 0x3f, 0x22, 0x54, 0x8e, 0x08, 0xbf, 0x89, 0x27,
-	0x04, 0xbd, 0x86, 0x0f, 0x2a, 0x43, 0x1e, 0x7d, 0xa2, 0x9f, 0x68, 0xfd, 0x67, 0xd8, 0xf9, 0x74,
-	0x4b, 0x54, 0x4f, 0x6f, 0xf7, 0x0e, 0x0a, 0xe1, 0xfe, 0xd6, 0x21, 0xbf, 0x87, 0xfb, 0xab, 0x65,
-	0x74, 0xf7, 0x37, 0xc2, 0xbd, 0xf3, 0xc3, 0xf3, 0x9f, 0x9f, 0x45, 0xb1, 0xfc, 0x35, 0x1b, 0x7b,
-	0x13, 0x96, 0xfa, 0x51, 0xc0, 0x43, 0x42, 0x09, 0xf7, 0x29, 0x91, 0xb7, 0x8c, 0x4f, 0x1f, 0xcd,
-	0x38, 0x1b, 0x27, 0x24, 0x7d, 0x14, 0x12, 0x49, 0x26, 0x92, 0x71, 0xbf, 0xf2, 0xd7, 0x65, 0xdc,
-	0x54, 0xc2, 0x7a, 0xf2, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x28, 0xf2, 0x96, 0x1c, 0xd4, 0x08,
-	0x00, 0x00,
+	// 2652 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5a, 0x5b, 0x6f, 0x1b, 0xc7,
+	0x15, 0x0e, 0xb9, 0x24, 0x45, 0x1e, 0x52, 0xb7, 0xb1, 0x2d, 0xaf, 0xe9, 0x4b, 0xd5, 0x75, 0xe1,
+	0xa8, 0xad, 0x23, 0xb9, 0x8e, 0x15, 0x58, 0xa9, 0x91, 0x46, 0xb6, 0x64, 0x55, 0xaa, 0x63, 0x19,
+	0x2b, 0xa3, 0x01, 0x92, 0x22, 0xc0, 0x92, 0x3b, 0xa2, 0xd7, 0x5c, 0xce, 0xb0, 0xbb, 0x43, 0xd9,
+	0x7a, 0xe9, 0x43, 0x1e, 0xfb, 0x5c, 0xa0, 0x4f, 0xfd, 0x03, 0x7d, 0xe8, 0x43, 0xff, 0x41, 0xf3,
+	0x5e, 0xa0, 0x40, 0x81, 0x16, 0xed, 0xaf, 0x29, 0xe6, 0xb2, 0xbb, 0xb3, 0x37, 0x91, 0x8c, 0x93,
+	0xbe, 0x08, 0x3c, 0x67, 0xce, 0x9c, 0xb9, 0x9d, 0xf3, 0x9d, 0xcb, 0x0a, 0xba, 0xe3, 0xe1, 0x60,
+	0xab, 0x4f, 0x47, 0x23, 0x4a, 0xb6, 0xc8, 0x9b, 0xb1, 0x2b, 0xfe, 0x6c, 0x8e, 0x03, 0xca, 0x28,
+	0xaa, 0xf1, 0xdf, 0xdd, 0x1f, 0x0c, 0x28, 0x1d, 0xf8, 0x78, 0x4b, 0xf0, 0x7a, 0x93, 0xd3, 0x2d,
+	0xe6, 0x8d, 0x70, 0xc8, 0x9c, 0xd1, 0x58, 0x8a, 0x75, 0x6f, 0x65, 0x05, 0xdc, 0x49, 0xe0, 0x30,
+	0x8f, 0x12, 0x39, 0x6e, 0x7d, 0xd3, 0x82, 0xb5, 0x03, 0xcc, 0x8e, 0x7b, 0x21, 0x0e, 0xce, 0xc4,
+	0x40, 0x68, 0xe3, 0xdf, 0x4e, 0x70, 0xc8, 0xd0, 0x3d, 0xa8, 0x87, 0xcc, 0x09, 0x98, 0x59, 0x59,
+	0xaf, 0x6c, 0xb4, 0xef, 0x77, 0x37, 0xa5, 0xaa, 0xcd, 0x48, 0xd5, 0xe6, 0xcb, 0x68, 0x2d, 0x5b,
+	0x0a, 0xa2, 0xbb, 0x60, 0x60, 0xe2, 0x9a, 0xd5, 0xa9, 0xf2, 0x5c, 0x0c, 0x5d, 0x86, 0xba, 0xef,
+	0x8d, 0x3c, 0x66, 0x1a, 0xeb, 0x95, 0x8d, 0xba, 0x2d, 0x09, 0xf4, 0x13, 0x58, 0x09, 0x70, 0xc8,
+	0x02, 0xaf, 0xcf, 0x5e, 0xd2, 0x23, 0xda, 0x3b, 0xdc, 0x0b, 0xcd, 0xda, 0xba, 0xb1, 0xd1, 0xb2,
+	0x73, 0x7c, 0xb4, 0x09, 0x28, 0xe1, 0x9d, 0x04, 0xfd, 0x5f, 0xd2, 0x90, 0x85, 0x66, 0x5d, 0x48,
+	0x17, 0x8c, 0xa0, 0x7b, 0x70, 0x29, 0xe1, 0xee, 0xe1, 0x90, 0xc9, 0x09, 0x0d, 0x31, 0xa1, 0x68,
+	0x08, 0x1d, 0xc0, 0xaa, 0x33, 0x18, 0x04, 0x78, 0x20, 0xae, 0xe6, 0x73, 0x8f, 0xb8, 0xf4, 0x8d,
+	0xb9, 0x20, 0xce, 0x77, 0x2d, 0x77, 0xbe, 0x3d, 0x75, 0xb5, 0x76, 0x7e, 0x0e, 0xb2, 0xa0, 0x73,
+	0xea, 0x78, 0xfe, 0x24, 0xc0, 0xe1, 0x31, 0xf1, 0xcf, 0xcd, 0xe6, 0x7a, 0x65, 0xa3, 0x69, 0xa7,
+	0x78, 0xfc, 0x38, 0x1e, 0xe9, 0xfb, 0x13, 0x17, 0x3f, 0xa7, 0x7b, 0x0e, 0x73, 0xf6, 0xdd, 0x01,
+	0x0e, 0xcd, 0x96, 0x90, 0x2c, 0x18, 0x41, 0x5f, 0xe9, 0x57, 0xf5, 0xcc, 0xe9, 0x61, 0x3f, 0x34,
+	0x61, 0xdd, 0xd8, 0x68, 0xdf, 0xbf, 0xbf, 0x29, 0x2c, 0xa5, 0xf8, 0x61, 0x37, 0xed, 0xcc, 0xa4,
+	0x7d, 0xc2, 0x82, 0x73, 0x3b, 0xa7, 0x0b, 0xad, 0x41, 0xe3, 0xd4, 0xf3, 0x19, 0x0e, 0xcc, 0xf6,
+	0x7a, 0x65, 0xa3, 0x65, 0x2b, 0x0a, 0x8d, 0x61, 0x2d, 0x91, 0xb5, 0x71, 0x38, 0xf1, 0xd9, 0x53,
+	0x0f, 0xfb, 0x6e, 0x68, 0x76, 0xc4, 0xea, 0x0f, 0x67, 0x5c, 0x5d, 0x9f, 0x2a, 0xf7, 0x50, 0xa2,
+	0x17, 0xdd, 0x02, 0x78, 0xcd, 0x9f, 0xdc, 0xc6, 0x03, 0xfc, 0xd6, 0x5c, 0x14, 0xbb, 0xd1, 0x38,
+	0xfc, 0x76, 0x43, 0xf9, 0xc8, 0x52, 0x62, 0x49, 0x48, 0xa4, 0x78, 0xe8, 0x47, 0xb0, 0xe8, 0xaa,
+	0x77, 0x95, 0x42, 0xcb, 0x42, 0x28, 0xcd, 0x44, 0xeb, 0xd0, 0x8e, 0x1e, 0x0f, 0x3f, 0x3e, 0x37,
+	0x57, 0x84, 0x8c, 0xce, 0x42, 0x37, 0xa0, 0x35, 0x76, 0x06, 0xf8, 0x25, 0x1d, 0x62, 0x62, 0xae,
+	0x8a, 0xf1, 0x84, 0xc1, 0xef, 0x2c, 0xa4, 0x01, 0x7b, 0x7c, 0x6e, 0x22, 0x79, 0x67, 0x92, 0x42,
+	0x77, 0x60, 0x89, 0xff, 0xda, 0xc3, 0x61, 0x1f, 0x13, 0xd7, 0x23, 0x03, 0xf3, 0x92, 0x78, 0xd7,
+	0x0c, 0x97, 0xef, 0x32, 0x10, 0x27, 0x7f, 0xe1, 0x30, 0x86, 0x03, 0x62, 0x5e, 0x96, 0xbb, 0x4c,
+	0x31, 0xd1, 0xcf, 0xa1, 0x3d, 0xf2, 0x48, 0x64, 0x6f, 0xe6, 0x95, 0x69, 0x06, 0xa9, 0x4b, 0xa3,
+	0x4f, 0xe1, 0x3a, 0x25, 0xfe, 0xf9, 0x53, 0x69, 0x7a, 0x27, 0x2c, 0xc0, 0xce, 0x30, 0x3c, 0x3e,
+	0xdd, 0x65, 0xcf, 0xb0, 0x13, 0x32, 0x73, 0x4d, 0x78, 0xe3, 0x45, 0x22, 0xdd, 0x27, 0x70, 0xa5,
+	0xd0, 0x86, 0xd0, 0x0a, 0x18, 0x43, 0x7c, 0x2e, 0x00, 0xa3, 0x65, 0xf3, 0x9f, 0xdc, 0xc9, 0xcf,
+	0x1c, 0x7f, 0x82, 0x05, 0x28, 0xb4, 0x6c, 0x49, 0x7c, 0x5c, 0x7d, 0x58, 0xe9, 0x1e, 0xc2, 0xf5,
+	0x0b, 0x4c, 0x61, 0x1e, 0x55, 0xd6, 0x19, 0x5c, 0xcd, 0x19, 0x5b, 0x38, 0xa6, 0x24, 0xc4, 0x68,
+	0x1b, 0x3a, 0x54, 0xe3, 0x9b, 0x15, 0x61, 0xa1, 0xab, 0xd2, 0x42, 0xb5, 0x19, 0x76, 0x4a, 0x8c,
+	0x3f, 0x03, 0xc1, 0x6f, 0xd9, 0x8b, 0xf8, 0xa1, 0xe5, 0x9a, 0x69, 0xa6, 0xf5, 0x16, 0x7e, 0x78,
+	0x80, 0xd9, 0x6e, 0x64, 0x1c, 0x6e, 0xe1, 0x0e, 0x4e, 0x60, 0xcd, 0x29, 0x94, 0x50, 0x7b, 0xb9,
+	0x2e, 0xf7, 0x52, 0xa8, 0xc5, 0x2e, 0x99, 0x6a, 0xfd, 0xbb, 0x0d, 0x57, 0x0a, 0x67, 0x20, 0x13,
+	0x16, 0x94, 0xd9, 0xab, 0xbb, 0x8b, 0x48, 0xd4, 0x85, 0x66, 0x64, 0xeb, 0xea, 0x38, 0x31, 0x8d,
+	0x1e, 0x41, 0x7b, 0x8c, 0x03, 0x8f, 0xba, 0x27, 0x02, 0xf1, 0x8d, 0xa9, 0x08, 0xae, 0x8b, 0xa3,
+	0x87, 0xd0, 0x92, 0xe4, 0x3e, 0x71, 0xcd, 0xda, 0xd4, 0xb9, 0x89, 0x30, 0x7a, 0x0e, 0xed, 0xd7,
+	0xb4, 0x17, 0x1e, 0x0f, 0x9f, 0xd0, 0x09, 0x61, 0x02, 0xba, 0xdb, 0xf7, 0xef, 0x5e, 0x70, 0x23,
+	0x9b, 0x47, 0x89, 0xb8, 0xc4, 0x0c, 0x5d, 0x01, 0xfa, 0x1c, 0x96, 0x38, 0xf9, 0x9c, 0xb2, 0x48,
+	0x65, 0x43, 0xa8, 0xdc, 0x9a, 0xa6, 0x32, 0x99, 0x21, 0xb5, 0x66, 0xd4, 0x70, 0xc5, 0x23, 0xec,
+	0x90, 0xe3, 0x61, 0xec, 0x74, 0x0b, 0xd3, 0x15, 0x7f, 0x96, 0x9a, 0xa1, 0x14, 0xa7, 0xd5, 0x70,
+	0xc0, 0x20, 0x02, 0xd3, 0x55, 0x48, 0x50, 0x14, 0x8f, 0x83, 0x84, 0xb2, 0x5f, 0x3b, 0xbe, 0xe7,
+	0x1e, 0x92, 0x17, 0xe2, 0xc2, 0x54, 0x28, 0xc8, 0xf1, 0xa3, 0x53, 0x9f, 0x30, 0xc7, 0xc7, 0xf2,
+	0xd4, 0x30, 0xdb, 0xa9, 0x93, 0x19, 0xda, 0xa9, 0x13, 0x26, 0x7a, 0x09, 0x8b, 0xe3, 0xed, 0x7b,
+	0xda, 0xa1, 0xdb, 0x42, 0xef, 0xe6, 0x45, 0x7a, 0x5f, 0xe8, 0x13, 0xa4, 0xda, 0xb4, 0x12, 0xa1,
+	0x75, 0x67, 0x5b, 0xd3, 0xda, 0x99, 0x41, 0xeb, 0xce, 0x76, 0x5e, 0xeb, 0xce, 0x76, 0x56, 0xeb,
+	0x8e, 0xa6, 0x75, 0x71, 0x16, 0xad, 0x3b, 0x05, 0x5a, 0x35, 0x9e, 0x72, 0xa7, 0x2f, 0x28, 0xc1,
+	0x2a, 0xa8, 0x44, 0x64, 0xe4, 0x4e, 0x62, 0x68, 0x39, 0x71, 0x27, 0x4e, 0x77, 0x3f, 0x81, 0x95,
+	0xac, 0x9d, 0x4e, 0x03, 0xb4, 0xba, 0x8e, 0x8d, 0xbb, 0x70, 0xa9, 0xc0, 0x28, 0xe7, 0x52, 0xf1,
+	0x1b, 0xb8, 0x54, 0x60, 0x7e, 0x05, 0x2a, 0xb6, 0x74, 0x15, 0x17, 0x46, 0x91, 0xfc, 0x06, 0x33,
+	0xf6, 0x33, 0xd7, 0x06, 0xbf, 0x04, 0x94, 0x37, 0x95, 0xef, 0x6a, 0x7f, 0x5c, 0xf9, 0xce, 0xf6,
+	0xf7, 0xa9, 0x7c, 0xe7, 0xfb, 0x51, 0x6e, 0xfd, 0xa9, 0x0e, 0x6d, 0x1d, 0xcf, 0x2f, 0x43, 0x5d,
+	0x24, 0x3a, 0x4a, 0xb1, 0x24, 0x74, 0x94, 0xaf, 0x96, 0xa3, 0xbc, 0x91, 0x41, 0xf9, 0x87, 0xd0,
+	0x8a, 0xeb, 0x83, 0x59, 0x70, 0x3a, 0x16, 0x46, 0xdb, 0xd0, 0x8c, 0x0a, 0x07, 0xb3, 0x3e, 0xed,
+	0x34, 0x4d, 0x57, 0x03, 0x37, 0x99, 0xb8, 0x98, 0x0d, 0x99, 0x0d, 0x49, 0x0a, 0x2d, 0x41, 0x95,
+	0x0e, 0x45, 0x1e, 0xdd, 0xb4, 0xab, 0x74, 0x88, 0x7e, 0x06, 0x0d, 0x19, 0x13, 0xcc, 0xe6, 0x34,
+	0xe5, 0x4a, 0x10, 0x6d, 0x43, 0xc3, 0x97, 0x29, 0x6f, 0x4b, 0xf8, 0xf9, 0xcd, 0x5c, 0x48, 0xdf,
+	0xd4, 0xb3, 0x5b, 0x25, 0xcc, 0x03, 0x7b, 0xc8, 0x8d, 0x76, 0x9f, 0xb8, 0x63, 0xea, 0x09, 0xa4,
+	0xe4, 0x9b, 0x48, 0x33, 0x79, 0xbe, 0xe9, 0x91, 0xbe, 0xe7, 0x62, 0xc2, 0x0e, 0xf7, 0x54, 0xf6,
+	0xab, 0x71, 0xd0, 0x01, 0x74, 0x82, 0x7c, 0xde, 0x7b, 0x3b, 0xbf, 0x85, 0x7c, 0x8a, 0x9b, 0x9a,
+	0xa8, 0xc3, 0xcb, 0x62, 0x39, 0xbc, 0x2c, 0x65, 0xe0, 0x65, 0x07, 0xda, 0xdf, 0x36, 0xeb, 0xfa,
+	0x05, 0xac, 0xbe, 0x5b, 0xae, 0xf5, 0x25, 0xac, 0xbe, 0x0c, 0xbc, 0xc1, 0x00, 0x07, 0x47, 0xb4,
+	0x17, 0x95, 0x8a, 0xc5, 0x46, 0x5a, 0x52, 0x6e, 0x55, 0x4b, 0xcb, 0x2d, 0xeb, 0x57, 0x80, 0x74,
+	0xe5, 0xef, 0x94, 0xc3, 0x59, 0x57, 0xe0, 0xd2, 0x01, 0x66, 0x47, 0xb4, 0x77, 0xc2, 0x1c, 0x36,
+	0x89, 0xea, 0x0f, 0xeb, 0x6f, 0x15, 0xb8, 0x9c, 0xe6, 0xab, 0x65, 0x6e, 0x43, 0x8d, 0x87, 0x3f,
+	0xa5, 0x7e, 0x59, 0xaa, 0x4f, 0xc4, 0xc4, 0x20, 0xaf, 0x0f, 0x30, 0x39, 0xf3, 0x02, 0x4a, 0x46,
+	0x98, 0x44, 0xce, 0xa7, 0xb3, 0x78, 0xe0, 0x76, 0xbd, 0xd0, 0xe9, 0xf9, 0xd8, 0x7d, 0x8a, 0x1d,
+	0xc6, 0xab, 0x3b, 0xd3, 0x90, 0x05, 0x6c, 0x96, 0x8f, 0x3e, 0x02, 0xf0, 0x69, 0xdf, 0xf1, 0x1f,
+	0xfb, 0xb4, 0x3f, 0x54, 0x1e, 0xb9, 0x26, 0x17, 0x7e, 0x16, 0xf3, 0xd5, 0xfa, 0x9a, 0xa4, 0x65,
+	0x8a, 0xa2, 0xfd, 0x09, 0x25, 0xa7, 0xde, 0x20, 0x7d, 0xba, 0x3f, 0x56, 0xe1, 0x6a, 0x6e, 0x48,
+	0x1d, 0xb0, 0x0b, 0xcd, 0x00, 0x9f, 0x79, 0x21, 0x77, 0x62, 0xfe, 0x50, 0x86, 0x1d, 0xd3, 0x3c,
+	0x01, 0xf4, 0x9d, 0x90, 0xed, 0x8e, 0xc7, 0xbe, 0x87, 0x67, 0x29, 0xe1, 0x75, 0x71, 0xf4, 0x31,
+	0x00, 0x27, 0x6d, 0xec, 0x53, 0xc7, 0x9d, 0x21, 0x7b, 0xd4, 0xa4, 0xd1, 0x06, 0x2c, 0x27, 0xd4,
+	0x7e, 0x10, 0xd0, 0x40, 0x5c, 0x44, 0xcb, 0xce, 0xb2, 0xd1, 0x23, 0xb8, 0xd6, 0xe7, 0x07, 0xe9,
+	0x4f, 0x98, 0x77, 0x86, 0xe5, 0x88, 0x2a, 0x51, 0x42, 0x81, 0x4a, 0x75, 0xbb, 0x5c, 0xc0, 0xfa,
+	0x7d, 0x05, 0x56, 0xb2, 0x97, 0xca, 0x8b, 0xb9, 0x70, 0x12, 0x8e, 0x71, 0x9f, 0x61, 0x57, 0xdc,
+	0x49, 0xd3, 0x4e, 0x18, 0xe8, 0x53, 0x58, 0xe4, 0x7b, 0xd8, 0xf3, 0x9c, 0x01, 0xa1, 0xa1, 0x17,
+	0xce, 0x70, 0x2d, 0xe9, 0x09, 0x12, 0x00, 0x9d, 0x90, 0x12, 0x85, 0xc5, 0x8a, 0xb2, 0xfe, 0x51,
+	0x83, 0x56, 0x6c, 0x5a, 0x25, 0xee, 0x83, 0xa0, 0xe6, 0x04, 0x83, 0xc8, 0x5f, 0xc4, 0x6f, 0x0d,
+	0x28, 0x8d, 0x59, 0x81, 0x72, 0x1d, 0xda, 0x2e, 0x0e, 0xfb, 0x81, 0x37, 0xe6, 0x6c, 0x75, 0xb7,
+	0x3a, 0x8b, 0x83, 0x50, 0x30, 0x21, 0x84, 0x17, 0xa5, 0x75, 0x71, 0x05, 0x11, 0x89, 0x1e, 0xc0,
+	0x82, 0x78, 0x84, 0x09, 0x31, 0x1b, 0x53, 0x8f, 0x1e, 0x89, 0xf2, 0x59, 0xbc, 0x4e, 0xe2, 0xb3,
+	0x16, 0xa6, 0xcf, 0x52, 0xa2, 0xfc, 0x29, 0x94, 0x82, 0xe3, 0xa1, 0x08, 0x03, 0x75, 0x3b, 0x61,
+	0x70, 0xdc, 0x56, 0x04, 0x7f, 0x50, 0x2c, 0x73, 0xe1, 0xba, 0x9d, 0x66, 0xf2, 0xb3, 0x72, 0x86,
+	0x7a, 0x73, 0x81, 0xed, 0x2d, 0x5b, 0x67, 0x71, 0x4c, 0xd2, 0x4c, 0x24, 0xb6, 0x9e, 0xb6, 0xd0,
+	0x56, 0x34, 0x24, 0x20, 0x7a, 0xe8, 0x8d, 0xc7, 0xd8, 0x35, 0x3b, 0xf2, 0x76, 0x14, 0xc9, 0xa3,
+	0x04, 0xff, 0x69, 0xcb, 0x07, 0x56, 0x5d, 0x89, 0x84, 0x23, 0x20, 0x5c, 0x79, 0xbc, 0x80, 0xf0,
+	0xa6, 0x1d, 0xd3, 0xe8, 0x01, 0x34, 0x7b, 0x4e, 0x7f, 0x48, 0x4f, 0x4f, 0x43, 0x73, 0x59, 0x00,
+	0x8e, 0x29, 0xfd, 0x9e, 0x83, 0xa1, 0x47, 0xc4, 0x13, 0x3e, 0x96, 0x02, 0x76, 0x2c, 0x29, 0x34,
+	0xe2, 0x41, 0xe0, 0xb8, 0xd8, 0x35, 0x57, 0x94, 0x46, 0x45, 0x5b, 0xbf, 0x03, 0x94, 0x9f, 0x9b,
+	0x4a, 0x07, 0x2a, 0x99, 0x74, 0xa0, 0x0b, 0xcd, 0xa8, 0xff, 0xa4, 0xd2, 0xb3, 0x98, 0xe6, 0xcd,
+	0xbf, 0x09, 0x61, 0x9e, 0x3f, 0x83, 0x33, 0x4b, 0x41, 0xeb, 0x9b, 0x0a, 0x5c, 0x7e, 0xe6, 0x85,
+	0xec, 0x50, 0x85, 0xc9, 0x77, 0xe8, 0x23, 0x76, 0xa1, 0x49, 0xc7, 0x98, 0x88, 0x46, 0x59, 0x55,
+	0x1e, 0x33, 0xa2, 0x0b, 0xfb, 0x83, 0x46, 0x49, 0x7f, 0xb0, 0x24, 0x00, 0xd5, 0xca, 0x03, 0xd0,
+	0x3e, 0x5c, 0xc9, 0x9c, 0x41, 0x61, 0xe7, 0x5d, 0x68, 0x45, 0xf1, 0x3f, 0x8a, 0x10, 0x4b, 0xf2,
+	0xc1, 0x22, 0x59, 0x3b, 0x11, 0xb0, 0xfe, 0x62, 0x40, 0x33, 0xe2, 0x67, 0x92, 0x89, 0x4a, 0x2e,
+	0x99, 0x88, 0xbd, 0xbf, 0x5a, 0x92, 0xe1, 0x19, 0xe5, 0x19, 0x5e, 0x2d, 0xf3, 0xa4, 0xf1, 0x5d,
+	0xd7, 0xe7, 0xec, 0xd9, 0x36, 0x66, 0xeb, 0xd9, 0x3e, 0x4a, 0x3b, 0xd8, 0xc2, 0x6c, 0x61, 0x22,
+	0x72, 0xbe, 0x75, 0x68, 0x9f, 0x0a, 0x47, 0x95, 0x45, 0xaa, 0x74, 0x72, 0x9d, 0xc5, 0x4f, 0x4d,
+	0x55, 0xe1, 0x2e, 0x1d, 0x3c, 0x22, 0x79, 0x73, 0xf4, 0xd4, 0x0b, 0x62, 0x5d, 0xca, 0xe9, 0xa4,
+	0x87, 0x17, 0x8c, 0xa0, 0xbb, 0xb0, 0xea, 0x3b, 0x19, 0xa6, 0xca, 0xe4, 0xf2, 0x03, 0xd6, 0x8f,
+	0x61, 0xf5, 0x00, 0xb3, 0x93, 0xc9, 0x68, 0xe4, 0x04, 0xe7, 0x5a, 0x56, 0x23, 0x1b, 0xd4, 0x15,
+	0xad, 0x41, 0x6d, 0xfd, 0xb3, 0x02, 0x48, 0x97, 0x55, 0x06, 0x92, 0xe9, 0xa0, 0x54, 0xde, 0xa1,
+	0x83, 0x52, 0x9d, 0xa7, 0x83, 0x72, 0x03, 0x5a, 0x23, 0x8f, 0x3c, 0x79, 0x85, 0xfb, 0xc3, 0x50,
+	0x75, 0xd2, 0x13, 0x06, 0x7a, 0x1f, 0xea, 0x58, 0x74, 0x91, 0x6b, 0x7a, 0xce, 0xc4, 0xcf, 0xee,
+	0x91, 0x01, 0xef, 0x22, 0xdb, 0x72, 0xdc, 0xfa, 0x7b, 0x05, 0xda, 0x1a, 0xfb, 0x5b, 0xb6, 0x91,
+	0xd6, 0xa0, 0xd1, 0xd7, 0x77, 0xa2, 0xa8, 0x14, 0xd2, 0xd4, 0x32, 0x48, 0x93, 0x74, 0xc6, 0x6d,
+	0x8e, 0x5c, 0xc2, 0x72, 0x2b, 0x76, 0x8a, 0xc7, 0xf5, 0xbe, 0x96, 0xae, 0x2e, 0x7b, 0xf5, 0x8a,
+	0x12, 0xe6, 0x42, 0x06, 0x94, 0x47, 0x2e, 0x59, 0x4c, 0x44, 0xa4, 0xf5, 0x9f, 0x8a, 0xc8, 0x83,
+	0x22, 0x14, 0x3f, 0xf1, 0x48, 0x1f, 0xeb, 0x80, 0xc4, 0xe9, 0x99, 0x00, 0x89, 0x0b, 0x16, 0x82,
+	0x4e, 0x75, 0xae, 0x8f, 0x12, 0xc6, 0xbc, 0x1f, 0x25, 0x2e, 0x00, 0xa9, 0x3f, 0x54, 0xc0, 0xcc,
+	0x9f, 0x4d, 0xd9, 0xe1, 0xfc, 0x87, 0xdb, 0x88, 0x6c, 0xa4, 0x2a, 0x6c, 0x04, 0x49, 0x1b, 0xe1,
+	0x56, 0x10, 0xad, 0xa0, 0x8c, 0x84, 0xdb, 0x1a, 0x0b, 0x26, 0xa4, 0xef, 0xf0, 0x6c, 0xc9, 0x90,
+	0xd9, 0x52, 0xcc, 0xb0, 0xbe, 0xae, 0x42, 0x47, 0x9f, 0xf5, 0x9d, 0x96, 0xae, 0x17, 0x59, 0x50,
+	0x06, 0x94, 0xea, 0xf3, 0x81, 0x52, 0x21, 0x50, 0x34, 0x4a, 0x80, 0x22, 0x03, 0xe6, 0x0b, 0x59,
+	0x30, 0xb7, 0xbe, 0x82, 0x95, 0x08, 0xf8, 0x4f, 0x88, 0x33, 0x0e, 0x5f, 0x51, 0x86, 0x2c, 0xa8,
+	0xf1, 0xf0, 0x55, 0x12, 0x36, 0xc4, 0x18, 0xba, 0x03, 0x8d, 0xbe, 0x4f, 0x43, 0x91, 0x7a, 0x17,
+	0x49, 0xa9, 0x51, 0xeb, 0xad, 0xc8, 0xfc, 0xf7, 0x1c, 0xcf, 0x3f, 0xb7, 0xa9, 0xef, 0x4f, 0xc6,
+	0xff, 0xaf, 0xcf, 0x75, 0xd6, 0x53, 0xb8, 0x9a, 0x5b, 0x59, 0xd9, 0xdc, 0x4f, 0x61, 0x21, 0x90,
+	0xac, 0x74, 0x6d, 0xa6, 0x09, 0xdb, 0x91, 0x84, 0xf5, 0x75, 0x05, 0xda, 0xda, 0x00, 0x4f, 0x73,
+	0x5d, 0x87, 0x61, 0x65, 0x24, 0xe2, 0xf7, 0x05, 0x36, 0x62, 0xc2, 0xc2, 0xc8, 0x0b, 0x43, 0xee,
+	0xf1, 0xd2, 0x00, 0x23, 0x92, 0x6f, 0x02, 0x13, 0x16, 0x78, 0x59, 0xb0, 0x93, 0xcb, 0xc8, 0xe2,
+	0x3b, 0x92, 0xb0, 0xfe, 0x5a, 0x85, 0xb6, 0x36, 0x50, 0x62, 0xaa, 0x37, 0xa0, 0xc5, 0x0d, 0xf0,
+	0x89, 0xef, 0x84, 0xa1, 0xda, 0x48, 0xc2, 0xd0, 0x63, 0x95, 0x91, 0x8e, 0x55, 0xb7, 0x00, 0x48,
+	0xd2, 0x81, 0x96, 0xe6, 0xaa, 0x71, 0xf8, 0xe7, 0x9b, 0xf1, 0xf6, 0xbd, 0xbd, 0x99, 0x1b, 0x2a,
+	0xba, 0xb4, 0x98, 0xbc, 0x93, 0x4c, 0x6e, 0x4c, 0x9f, 0xbc, 0x93, 0x99, 0xbc, 0xa3, 0xf5, 0xb0,
+	0xa7, 0x4f, 0x8e, 0xa5, 0xad, 0x7f, 0xd5, 0x60, 0xe9, 0x90, 0xb0, 0x4c, 0x77, 0xea, 0x28, 0xbe,
+	0x37, 0xc3, 0x96, 0x44, 0xf6, 0xf9, 0x8c, 0x72, 0x17, 0x37, 0x34, 0x17, 0xbf, 0x05, 0xc0, 0x1b,
+	0x4e, 0x9f, 0x79, 0xbe, 0xef, 0x49, 0x27, 0x37, 0x6c, 0x8d, 0xc3, 0x3f, 0xa1, 0x45, 0x8d, 0x25,
+	0x25, 0x23, 0x6b, 0xbe, 0x0c, 0x57, 0x35, 0x97, 0x1a, 0x71, 0x73, 0xc9, 0x82, 0x8e, 0x0c, 0x97,
+	0x6a, 0xd6, 0x82, 0x98, 0x95, 0xe2, 0xa1, 0x87, 0x71, 0x37, 0xa9, 0x29, 0x6c, 0x67, 0x3d, 0x72,
+	0x3f, 0x36, 0x77, 0x43, 0xa9, 0x35, 0xbd, 0xa1, 0x04, 0xf2, 0x6c, 0x09, 0x07, 0x1d, 0x65, 0x1a,
+	0x4a, 0xb2, 0xcf, 0x7e, 0xa7, 0x70, 0x17, 0x73, 0xf4, 0x94, 0x3a, 0xf1, 0xed, 0xe7, 0x7a, 0x4a,
+	0x8b, 0xc9, 0xed, 0x4f, 0xe9, 0x29, 0x19, 0x05, 0x2d, 0x21, 0x63, 0x9e, 0x9e, 0x92, 0x31, 0xad,
+	0xa7, 0xf4, 0x67, 0x03, 0x3a, 0xbc, 0xe1, 0x33, 0x21, 0x36, 0xee, 0xd3, 0xc0, 0xe5, 0x98, 0x30,
+	0xf4, 0x88, 0x1b, 0x61, 0x02, 0xff, 0x3d, 0x77, 0x9a, 0x1c, 0xe3, 0x61, 0x6d, 0x4e, 0x3c, 0xac,
+	0xcf, 0x96, 0x0a, 0x27, 0xa5, 0x78, 0x63, 0xd6, 0x52, 0x7c, 0x0b, 0xea, 0x2e, 0xf6, 0x9d, 0xf3,
+	0xe9, 0x7e, 0x27, 0xe5, 0x22, 0x00, 0x92, 0x19, 0x41, 0x53, 0x64, 0x04, 0x09, 0x43, 0x74, 0x9a,
+	0x22, 0xe2, 0x78, 0xe4, 0x31, 0x86, 0xe3, 0x4f, 0x44, 0x59, 0x3e, 0xcf, 0x32, 0xdc, 0x80, 0xf2,
+	0xb2, 0x35, 0xf5, 0x09, 0x12, 0x64, 0xdd, 0x5b, 0x30, 0xa4, 0xb5, 0x2e, 0xda, 0xa9, 0xd6, 0xc5,
+	0x6d, 0x68, 0x1f, 0x12, 0xf6, 0xd1, 0x83, 0xdd, 0x20, 0x70, 0xce, 0x45, 0x90, 0x77, 0xf8, 0x2f,
+	0x81, 0xfc, 0x86, 0x2d, 0x09, 0xeb, 0x43, 0x68, 0x1d, 0x12, 0x76, 0xc2, 0x02, 0x8e, 0xcc, 0x33,
+	0x9a, 0xc2, 0xfd, 0xff, 0xd6, 0xa1, 0xb3, 0x3b, 0xe0, 0x91, 0x13, 0x07, 0x67, 0x5e, 0x1f, 0xa3,
+	0x17, 0xb0, 0x9c, 0xf9, 0xae, 0x8b, 0x6e, 0x5c, 0xf4, 0xbf, 0x05, 0xdd, 0x9b, 0x25, 0xa3, 0x32,
+	0x4e, 0x59, 0xef, 0x21, 0x17, 0xae, 0x95, 0x7e, 0xb1, 0x9d, 0xa2, 0xfb, 0xfd, 0x78, 0xf4, 0xe2,
+	0x0f, 0xbe, 0xd6, 0x7b, 0x6a, 0xdf, 0x7a, 0xa8, 0xd4, 0x74, 0x17, 0xc4, 0xee, 0xee, 0xcd, 0x92,
+	0xd1, 0x58, 0xe3, 0x2e, 0x40, 0xd2, 0x18, 0x45, 0x57, 0xa5, 0x78, 0xae, 0x0f, 0xdb, 0x35, 0xf3,
+	0x03, 0xb1, 0x8a, 0x03, 0xe8, 0xe8, 0x6d, 0x4f, 0x74, 0x2d, 0x5e, 0x33, 0xdb, 0x22, 0xed, 0x76,
+	0x8b, 0x86, 0x62, 0x45, 0x47, 0xb0, 0x98, 0xaa, 0x91, 0x91, 0x12, 0x2f, 0x2a, 0xfe, 0xbb, 0xd7,
+	0x0b, 0xc7, 0xf4, 0x73, 0x25, 0xb5, 0x54, 0x74, 0xae, 0x5c, 0x25, 0xd6, 0x35, 0xf3, 0x03, 0xb1,
+	0x8a, 0x13, 0x58, 0xc9, 0x26, 0xc3, 0x28, 0xb9, 0xcf, 0xa2, 0x02, 0xa0, 0x7b, 0xab, 0x6c, 0x38,
+	0xf3, 0x82, 0x7a, 0x17, 0x55, 0x7b, 0xc1, 0x82, 0xbe, 0x6b, 0xf7, 0x66, 0xc9, 0x68, 0xa4, 0xf1,
+	0xf1, 0x27, 0x5f, 0x3c, 0x1a, 0x78, 0xec, 0xd5, 0xa4, 0xb7, 0xd9, 0xa7, 0xa3, 0xad, 0x81, 0x13,
+	0xb8, 0x98, 0xe0, 0x60, 0x8b, 0x60, 0xf6, 0x86, 0x06, 0xc3, 0x0f, 0xc6, 0x01, 0xed, 0xf9, 0x78,
+	0xf4, 0x81, 0x8b, 0x19, 0xee, 0x33, 0x1a, 0x6c, 0x65, 0xfe, 0xf5, 0xab, 0xd7, 0x10, 0x20, 0xf1,
+	0xe1, 0xff, 0x06, 0x00, 0x33, 0xce, 0x82, 0x6f, 0x14, 0x26, 0x00, 0x00,
 }
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package nwpd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Cursor is a position in the order of listed observations, i.e. by timestamp, job ID, source host, and destination host.
+// As it is based on the timestamp, it stays valid if record files are rotated.
+type Cursor struct {
+	// TimeNanos is the timestamp in nanoseconds since the Unix epoch.
+	TimeNanos int64  `json:"t"`
+	JobID     string `json:"j,omitempty"`
+	SrcHost   string `json:"s,omitempty"`
+	DestHost  string `json:"d,omitempty"`
+	// Skip is the number of listed observations at exactly this position.
+	Skip int `json:"n,omitempty"`
+}
+
+// CursorOf returns the position of the observation.
+func CursorOf(obs *Observation) Cursor {
+	return Cursor{
+		TimeNanos: obs.Timestamp.AsTime().UnixNano(),
+		JobID:     obs.JobID,
+		SrcHost:   obs.SrcHost,
+		DestHost:  obs.DestHost,
+	}
+}
+
+// Compare returns -1 if the observation is positioned before the cursor, 0 if at the cursor, and +1 if after it.
+func (c Cursor) Compare(obs *Observation) int {
+	return comparePositions(CursorOf(obs), c)
+}
+
+func comparePositions(a, b Cursor) int {
+	switch {
+	case a.TimeNanos < b.TimeNanos:
+		return -1
+	case a.TimeNanos > b.TimeNanos:
+		return 1
+	}
+	if cmp := strings.Compare(a.JobID, b.JobID); cmp != 0 {
+		return cmp
+	}
+	if cmp := strings.Compare(a.SrcHost, b.SrcHost); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a.DestHost, b.DestHost)
+}
+
+// NextCursor returns the cursor after the last observation of a page listed after the given cursor (nil for the first page).
+func NextCursor(page Observations, after *Cursor) Cursor {
+	if len(page) == 0 {
+		if after != nil {
+			return *after
+		}
+		return Cursor{}
+	}
+	next := CursorOf(page[len(page)-1])
+	for i := len(page) - 1; i >= 0 && comparePositions(CursorOf(page[i]), next) == 0; i-- {
+		next.Skip++
+	}
+	if after != nil && comparePositions(*after, next) == 0 {
+		next.Skip += after.Skip
+	}
+	return next
+}
+
+// Token encodes the cursor as opaque page token.
+func (c Cursor) Token() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParsePageToken decodes a page token returned as `nextPageToken`.
+func ParsePageToken(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+	if c.Skip < 0 {
+		return nil, fmt.Errorf("invalid page token: negative skip")
+	}
+	return c, nil
+}
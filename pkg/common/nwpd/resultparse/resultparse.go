@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package resultparse defines the grammar of the human-readable result of an observation (`Observation.Result`)
+// and provides typed parsers for the results of each runner.
+//
+// The runners format their results with the types of this package, so that the grammar and the parsers cannot drift apart.
+// A result has the form
+//
+//	result  = [ "error: " ] text [ " (attempt " N ")" | " (" N " attempts)" ]
+//	text    = [ reason ": " ] pairs
+//	pairs   = pair { " " pair }
+//	pair    = key "=" value
+//	key     = letter { letter | digit }
+//	value   = token | quoted
+//
+// where a token is a non-empty string without spaces and double quotes, and quoted is a Go string literal.
+// Keys may be repeated (e.g. `redirect`). The first key identifies the runner. Results not matching the grammar
+// (e.g. errors of the operating system or results of older agents) are parsed as Generic.
+package resultparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const errorPrefix = "error: "
+
+// retrySuffix matches the number of attempts appended to the result of a retried check.
+var retrySuffix = regexp.MustCompile(` \((?:attempt (\d+)|(\d+) attempts)\)$`)
+
+// Pair is a key/value pair of a result.
+type Pair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// String formats the pair as `key=value`. The value is quoted if needed.
+func (p Pair) String() string {
+	return (&formatter{}).add(p.Key, p.Value).String()
+}
+
+// Common contains the parts of the result common to all runners.
+type Common struct {
+	// Raw is the unparsed result.
+	Raw string
+	// Failed is true for the result of a failed check.
+	Failed bool
+	// Reason is the reason given before the key/value pairs of a failed check, e.g. `unexpected status`.
+	Reason string
+	// Attempts is the number of attempts of a retried check, 0 if the check has not been retried.
+	Attempts int
+	// Pairs are the key/value pairs in the order of the result.
+	Pairs []Pair
+}
+
+// Get returns the value of the first pair with the given key.
+func (c *Common) Get(key string) (string, bool) {
+	for _, p := range c.Pairs {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// All returns the values of all pairs with the given key.
+func (c *Common) All(key string) []string {
+	var values []string
+	for _, p := range c.Pairs {
+		if p.Key == key {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
+
+func (c *Common) common() *Common {
+	return c
+}
+
+// Result is a parsed result. The concrete type is one of the result types of the runners or Generic.
+type Result interface {
+	common() *Common
+}
+
+// CommonOf returns the parts of the result common to all runners.
+func CommonOf(r Result) *Common {
+	return r.common()
+}
+
+// Generic is a result not matching the grammar of any runner. Only the common fields without pairs are set.
+type Generic struct {
+	Common
+	// Text is the result without error prefix and attempts suffix.
+	Text string
+}
+
+// parsers maps the first key of the pairs to the parser of the runner result.
+var parsers = map[string]func(c *Common) (Result, error){
+	keyTCPState:            parseTCP,
+	keyTCPPod:              parseTCP,
+	keyHTTPStatus:          parseHTTPSGet,
+	keyHTTPRedirect:        parseHTTPSGet,
+	keyGRPCServingStatus:   parseGRPCHealth,
+	keyGRPCHTTPStatus:      parseGRPCHealth,
+	keyGRPCStatus:          parseGRPCHealth,
+	keyNSLookupAddresses:   parseNSLookup,
+	keyNSLookupMissing:     parseNSLookup,
+	keyPingRTT:             parsePing,
+	keyPingLostAfter:       parsePing,
+	keyMTUProbePathMTU:     parseMTUProbe,
+	keyARPPingNeighbor:     parseARPPing,
+	keyPacketTrainReceived: parsePacketTrain,
+	keyPacketTrainSupport:  parsePacketTrain,
+}
+
+// Parse parses the result of an observation. It never fails, results not matching the grammar are returned as *Generic.
+func Parse(result string) Result {
+	c := Common{Raw: result}
+	text := result
+	if strings.HasPrefix(text, errorPrefix) {
+		c.Failed = true
+		text = text[len(errorPrefix):]
+	}
+	if m := retrySuffix.FindStringSubmatchIndex(text); m != nil {
+		var n string
+		if m[2] >= 0 {
+			n = text[m[2]:m[3]]
+		} else {
+			n = text[m[4]:m[5]]
+		}
+		c.Attempts, _ = strconv.Atoi(n)
+		text = text[:m[0]]
+	}
+
+	generic := func() Result {
+		return &Generic{Common: Common{Raw: c.Raw, Failed: c.Failed, Attempts: c.Attempts}, Text: text}
+	}
+	pairs, err := parsePairs(text)
+	// the reason may contain ": " itself, e.g. if it is an error message
+	for offset := 0; err != nil; {
+		i := strings.Index(text[offset:], ": ")
+		if i < 0 {
+			return generic()
+		}
+		offset += i + 2
+		if pairs, err = parsePairs(text[offset:]); err == nil {
+			c.Reason = text[:offset-2]
+		}
+	}
+	parser := parsers[pairs[0].Key]
+	if parser == nil {
+		return generic()
+	}
+	c.Pairs = pairs
+	r, err := parser(&c)
+	if err != nil {
+		return generic()
+	}
+	return r
+}
+
+// parsePairs parses a non-empty space separated list of key/value pairs.
+func parsePairs(text string) ([]Pair, error) {
+	var pairs []Pair
+	for text != "" {
+		key, rest, found := strings.Cut(text, "=")
+		if !found || !isKey(key) {
+			return nil, fmt.Errorf("key expected")
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = strconv.Unquote(quoted); err != nil {
+				return nil, err
+			}
+			rest = rest[len(quoted):]
+		} else {
+			end := strings.IndexAny(rest, ` "`)
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("value expected for %s", key)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		pairs = append(pairs, Pair{Key: key, Value: value})
+		if rest != "" {
+			if !strings.HasPrefix(rest, " ") {
+				return nil, fmt.Errorf("separator expected after %s", key)
+			}
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("pair expected")
+			}
+		}
+		text = rest
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pairs")
+	}
+	return pairs, nil
+}
+
+func isKey(s string) bool {
+	for i, r := range s {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// formatter formats the text of a result, i.e. the optional reason and the key/value pairs.
+type formatter struct {
+	sb strings.Builder
+}
+
+func newFormatter(reason string) *formatter {
+	f := &formatter{}
+	if reason != "" {
+		f.sb.WriteString(reason)
+		f.sb.WriteString(": ")
+	}
+	return f
+}
+
+// add appends the pair. The value is quoted if needed.
+func (f *formatter) add(key string, value any) *formatter {
+	s := fmt.Sprint(value)
+	return f.write(key, s, s == "" || strings.ContainsAny(s, ` "`) || strconv.Quote(s) != `"`+s+`"`)
+}
+
+// addQuoted appends the pair with quoted value, used for free text like messages.
+func (f *formatter) addQuoted(key, value string) *formatter {
+	return f.write(key, value, true)
+}
+
+func (f *formatter) write(key, value string, quote bool) *formatter {
+	if f.sb.Len() > 0 && !strings.HasSuffix(f.sb.String(), ": ") {
+		f.sb.WriteByte(' ')
+	}
+	f.sb.WriteString(key)
+	f.sb.WriteByte('=')
+	if quote {
+		value = strconv.Quote(value)
+	}
+	f.sb.WriteString(value)
+	return f
+}
+
+// addIf appends the pair if the condition is true.
+func (f *formatter) addIf(cond bool, key string, value any) *formatter {
+	if cond {
+		f.add(key, value)
+	}
+	return f
+}
+
+func (f *formatter) String() string {
+	return f.sb.String()
+}
+
+// requiredInt returns the integer value of the key.
+func requiredInt(c *Common, key string) (int, error) {
+	s, ok := c.Get(key)
+	if !ok {
+		return 0, fmt.Errorf("missing %s", key)
+	}
+	return strconv.Atoi(s)
+}
+
+// optionalInt returns the integer value of the key or 0 if not set.
+func optionalInt(c *Common, key string) (int, error) {
+	if _, ok := c.Get(key); !ok {
+		return 0, nil
+	}
+	return requiredInt(c, key)
+}
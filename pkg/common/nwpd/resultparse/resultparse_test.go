@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resultparse
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type formattable interface {
+	Result
+	Text() string
+}
+
+var _ = Describe("resultparse", func() {
+	DescribeTable("parses the results of the runners",
+		func(raw string, expected Result) {
+			r := Parse(raw)
+			c := CommonOf(r)
+			Expect(c.Raw).To(Equal(raw))
+			// the pairs are checked by the golden text
+			c.Raw = ""
+			c.Pairs = nil
+			Expect(r).To(Equal(expected))
+		},
+		Entry("tcp", "state=connected", &TCP{State: TCPStateConnected}),
+		Entry("stale pod endpoint", `error: stale endpoint: pod=pod1 expectedUID=uid-old uid=""`,
+			&TCP{Common: Common{Failed: true, Reason: "stale endpoint"}, Pod: "pod1", ExpectedUID: "uid-old"}),
+		Entry("https", `status=200 body="done" final=https://example.com/b redirect=https://example.com/a redirect=https://example.com/b`,
+			&HTTPSGet{Status: 200, Body: "done", Final: "https://example.com/b", Redirects: []string{"https://example.com/a", "https://example.com/b"}}),
+		Entry("https unexpected status", `error: unexpected status: status=503 body="not \"ready\"" (3 attempts)`,
+			&HTTPSGet{Common: Common{Failed: true, Reason: "unexpected status", Attempts: 3}, Status: 503, Body: `not "ready"`}),
+		Entry("https failure after redirects", `error: Get "https://example.com/a": stopped after 1 redirects: redirect=https://example.com/a`,
+			&HTTPSGet{Common: Common{Failed: true, Reason: `Get "https://example.com/a": stopped after 1 redirects`}, Redirects: []string{"https://example.com/a"}}),
+		Entry("grpc", "servingStatus=SERVING (attempt 2)", &GRPCHealth{Common: Common{Attempts: 2}, ServingStatus: "SERVING"}),
+		Entry("grpc error", `error: grpc error: grpcStatus=5 message="unknown service"`,
+			&GRPCHealth{Common: Common{Failed: true, Reason: "grpc error"}, GRPCStatus: "5", Message: "unknown service"}),
+		Entry("grpc HTTP status", "error: unexpected HTTP status: httpStatus=503",
+			&GRPCHealth{Common: Common{Failed: true, Reason: "unexpected HTTP status"}, HTTPStatus: 503}),
+		Entry("nslookup", "addresses=10.0.0.1,10.0.0.2", &NSLookup{Addresses: []string{"10.0.0.1", "10.0.0.2"}}),
+		Entry("nslookup missing IPs", "error: expected IPs not found: missing=10.0.0.3 addresses=10.0.0.1",
+			&NSLookup{Common: Common{Failed: true, Reason: "expected IPs not found"}, Addresses: []string{"10.0.0.1"}, Missing: []string{"10.0.0.3"}}),
+		Entry("ping", "rtt=1.5ms ttl=64 size=24 from=10.0.0.1 seq=0 duplicate=true",
+			&Ping{RTT: 1500 * time.Microsecond, TTL: 64, Size: 24, From: "10.0.0.1", Duplicate: true}),
+		Entry("tcp with source", "state=connected src=10.0.0.5", &TCP{State: TCPStateConnected, Source: "10.0.0.5"}),
+		Entry("tcp with banner", `state=connected banner="+PONG"`, &TCP{State: TCPStateConnected, Banner: "+PONG"}),
+		Entry("tcp unexpected response", `error: unexpected response: state=connected banner="-ERR unknown" failure=unexpectedResponse src=10.0.0.5`,
+			&TCP{Common: Common{Failed: true, Reason: "unexpected response"}, State: TCPStateConnected, Banner: "-ERR unknown", Failure: "unexpectedResponse",
+				Source: "10.0.0.5"}),
+		Entry("tcp connect timeout", "error: dial tcp 10.0.0.1:443: i/o timeout: state=notConnected failure=connectTimeout (3 attempts)",
+			&TCP{Common: Common{Failed: true, Reason: "dial tcp 10.0.0.1:443: i/o timeout", Attempts: 3}, State: TCPStateNotConnected, Failure: "connectTimeout"}),
+		Entry("tcp with marking", "state=connected dscp=46", &TCP{State: TCPStateConnected, DSCP: 46}),
+		Entry("ping with source", "error: ping lost: lostAfter=1s src=fd00::5",
+			&Ping{Common: Common{Failed: true, Reason: "ping lost"}, LostAfter: time.Second, Source: "fd00::5"}),
+		Entry("ping lost", "error: ping lost: lostAfter=1s", &Ping{Common: Common{Failed: true, Reason: "ping lost"}, LostAfter: time.Second}),
+		Entry("mtu", "pathMTU=1500", &MTUProbe{PathMTU: 1500}),
+		Entry("mtu below minimum", "error: below minimum: pathMTU=1400 minMTU=1450",
+			&MTUProbe{Common: Common{Failed: true, Reason: "below minimum"}, PathMTU: 1400, MinMTU: 1450}),
+		Entry("arp ping", "neighbor=10.0.0.1 mac=aa:bb:cc:dd:ee:ff rtt=120µs iface=eth0 source=arp",
+			&ARPPing{Neighbor: "10.0.0.1", MAC: "aa:bb:cc:dd:ee:ff", RTT: 120 * time.Microsecond, Interface: "eth0", Source: NeighborSourceARP}),
+		Entry("arp ping lost", "error: no ARP reply: neighbor=10.0.0.1 iface=eth0 lostAfter=1s source=arp",
+			&ARPPing{Common: Common{Failed: true, Reason: "no ARP reply"}, Neighbor: "10.0.0.1", Interface: "eth0", LostAfter: time.Second, Source: NeighborSourceARP}),
+		Entry("packet train", "received=19 sent=20 loss=5.0 reordered=1 jitter=120µs",
+			&PacketTrain{Received: 19, Sent: 20, Loss: 5, Reordered: 1, Jitter: 120 * time.Microsecond}),
+		Entry("packet train unsupported", "packetTrain=unsupported", &PacketTrain{Unsupported: true}),
+		Entry("packet train loss", "error: loss above maximum: received=15 sent=20 loss=25.0 reordered=0 jitter=1ms maxLoss=0",
+			&PacketTrain{Common: Common{Failed: true, Reason: "loss above maximum"}, Received: 15, Sent: 20, Loss: 25, Jitter: time.Millisecond}),
+	)
+
+	DescribeTable("parses legacy and free-form results as generic",
+		func(raw, text string, failed bool, attempts int) {
+			r := Parse(raw)
+			Expect(r).To(Equal(&Generic{Common: Common{Raw: raw, Failed: failed, Attempts: attempts}, Text: text}))
+		},
+		Entry("empty", "", "", false, 0),
+		Entry("ok", "ok", "ok", false, 0),
+		Entry("operating system error", "error: dial tcp 10.0.0.1:443: connect: connection refused",
+			"dial tcp 10.0.0.1:443: connect: connection refused", true, 0),
+		Entry("legacy tcp", "connected (attempt 2)", "connected", false, 2),
+		Entry("legacy https", `503 Service Unavailable body="not ready"`, `503 Service Unavailable body="not ready"`, false, 0),
+		Entry("legacy mtu", "error: path MTU 1400 below minimum 1450 (2 attempts)", "path MTU 1400 below minimum 1450", true, 2),
+		Entry("legacy ping", "24 bytes from 10.0.0.1: icmp_seq=0 time=1ms\n", "24 bytes from 10.0.0.1: icmp_seq=0 time=1ms\n", false, 0),
+		Entry("unknown key", "foo=bar", "foo=bar", false, 0),
+		Entry("invalid value", "error: below minimum: pathMTU=large", "below minimum: pathMTU=large", true, 0),
+		Entry("invalid marking", "state=connected dscp=ef", "state=connected dscp=ef", false, 0),
+		Entry("unterminated quote", `status=200 body="done`, `status=200 body="done`, false, 0),
+		Entry("missing separator", `status=200 body="done"final=x`, `status=200 body="done"final=x`, false, 0),
+	)
+
+	DescribeTable("formats parsable results",
+		func(r formattable, golden string) {
+			Expect(r.Text()).To(Equal(golden))
+			parsed := Parse(golden)
+			Expect(parsed).To(BeAssignableToTypeOf(r))
+			Expect(parsed.(formattable).Text()).To(Equal(golden))
+		},
+		Entry("tcp", &TCP{State: TCPStateConnected}, "state=connected"),
+		Entry("tcp without response", &TCP{Common: Common{Reason: "no response"}, State: TCPStateConnected, Failure: "readTimeout", DSCP: 46},
+			"no response: state=connected failure=readTimeout dscp=46"),
+		Entry("stale pod endpoint", &TCP{Common: Common{Reason: "stale endpoint"}, Pod: "pod1", ExpectedUID: "a", UID: "b c"},
+			`stale endpoint: pod=pod1 expectedUID=a uid="b c"`),
+		Entry("https", &HTTPSGet{Status: 302, Body: "moved", Location: "/a?x=1"}, `status=302 body="moved" location=/a?x=1`),
+		Entry("grpc", &GRPCHealth{Common: Common{Reason: "not serving"}, ServingStatus: "NOT_SERVING"}, "not serving: servingStatus=NOT_SERVING"),
+		Entry("grpc error without message", &GRPCHealth{Common: Common{Reason: "grpc error"}, GRPCStatus: "14"}, `grpc error: grpcStatus=14 message=""`),
+		Entry("nslookup", &NSLookup{Addresses: []string{"::1"}}, "addresses=::1"),
+		Entry("ping", &Ping{RTT: time.Millisecond, TTL: 63, Size: 24, From: "10.0.0.2", Seq: 1}, "rtt=1ms ttl=63 size=24 from=10.0.0.2 seq=1"),
+		Entry("ping with source", &Ping{RTT: time.Millisecond, TTL: 63, Size: 24, From: "10.0.0.2", Source: "10.0.0.5"},
+			"rtt=1ms ttl=63 size=24 from=10.0.0.2 seq=0 src=10.0.0.5"),
+		Entry("mtu", &MTUProbe{PathMTU: 9001}, "pathMTU=9001"),
+		Entry("arp ping", &ARPPing{Neighbor: "10.0.0.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "eth0", Source: NeighborSourceKernel},
+			"neighbor=10.0.0.1 mac=aa:bb:cc:dd:ee:ff iface=eth0 source=kernel"),
+		Entry("packet train", &PacketTrain{Common: Common{Reason: "loss above maximum"}, Received: 9, Sent: 10, Loss: 10, Jitter: 2 * time.Millisecond, MaxLoss: 2.5},
+			"loss above maximum: received=9 sent=10 loss=10.0 reordered=0 jitter=2ms maxLoss=2.5"),
+		Entry("packet train with source", &PacketTrain{Received: 10, Sent: 10, Source: "10.0.0.5"},
+			"received=10 sent=10 loss=0.0 reordered=0 jitter=0s src=10.0.0.5"),
+		Entry("ping with marking", &Ping{Common: Common{Reason: "ping lost"}, LostAfter: time.Second, Source: "10.0.0.5", DSCP: 34},
+			"ping lost: lostAfter=1s src=10.0.0.5 dscp=34"),
+	)
+
+	It("keeps repeated keys in order", func() {
+		c := CommonOf(Parse("status=200 redirect=a redirect=b"))
+		Expect(c.Pairs).To(Equal([]Pair{{"status", "200"}, {"redirect", "a"}, {"redirect", "b"}}))
+		Expect(c.All("redirect")).To(Equal([]string{"a", "b"}))
+		value, ok := c.Get("redirect")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("a"))
+		Expect(Pair{"body", "x y"}.String()).To(Equal(`body="x y"`))
+	})
+})
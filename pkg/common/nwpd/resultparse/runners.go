@@ -0,0 +1,464 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resultparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	keyTCPState            = "state"
+	keyTCPPod              = "pod"
+	keyTCPBanner           = "banner"
+	keyTCPFailure          = "failure"
+	keyHTTPStatus          = "status"
+	keyHTTPRedirect        = "redirect"
+	keyGRPCServingStatus   = "servingStatus"
+	keyGRPCHTTPStatus      = "httpStatus"
+	keyGRPCStatus          = "grpcStatus"
+	keyNSLookupAddresses   = "addresses"
+	keyNSLookupMissing     = "missing"
+	keyPingRTT             = "rtt"
+	keyPingLostAfter       = "lostAfter"
+	keyMTUProbePathMTU     = "pathMTU"
+	keyARPPingNeighbor     = "neighbor"
+	keyPacketTrainReceived = "received"
+	keyPacketTrainSupport  = "packetTrain"
+	// keySource is the optional local address of the probe, it is never the first key.
+	keySource = "src"
+	// keyDSCP is the optional DSCP marking of the probe packets, it is never the first key.
+	keyDSCP = "dscp"
+)
+
+const (
+	// TCPStateConnected is the state of a successful TCP connection.
+	TCPStateConnected = "connected"
+	// TCPStateNotConnected is the state of a TCP connection which could not be established.
+	TCPStateNotConnected = "notConnected"
+)
+
+// TCP is the result of the runners `checkTCPPort` and `checkPodIdentity`, e.g.
+//
+//	state=connected
+//	state=connected src=10.0.0.5 dscp=46
+//	state=connected banner="SSH-2.0-OpenSSH_9.6"
+//	unexpected response: state=connected banner="HTTP/1.1 400 Bad Request" failure=unexpectedResponse
+//	dial tcp 10.0.0.1:443: i/o timeout: state=notConnected failure=connectTimeout
+//	stale endpoint: pod=nwpd-agent-pod-net-abcde expectedUID=1234 uid=5678
+type TCP struct {
+	Common
+	State string
+	// Pod, ExpectedUID, and UID are only set for a stale pod endpoint.
+	Pod         string
+	ExpectedUID string
+	UID         string
+	// Banner is a snippet of the response, if the job checks the response.
+	Banner string
+	// Failure is the category of a failed check, e.g. connectTimeout or readTimeout.
+	Failure string
+	// Source is the local address the probe is bound to, if the job selects it.
+	Source string
+	// DSCP is the marking of the probe packets, if the job selects it.
+	DSCP int
+}
+
+// Text formats the result.
+func (r *TCP) Text() string {
+	f := newFormatter(r.Reason)
+	if r.Pod != "" {
+		f.add(keyTCPPod, r.Pod).add("expectedUID", r.ExpectedUID).add("uid", r.UID)
+	} else {
+		f.add(keyTCPState, r.State)
+		if r.Banner != "" {
+			f.addQuoted(keyTCPBanner, r.Banner)
+		}
+		f.addIf(r.Failure != "", keyTCPFailure, r.Failure)
+	}
+	return f.addProbeSocket(r.Source, r.DSCP).String()
+}
+
+func parseTCP(c *Common) (Result, error) {
+	var err error
+	r := &TCP{Common: *c}
+	r.State, _ = c.Get(keyTCPState)
+	r.Pod, _ = c.Get(keyTCPPod)
+	r.ExpectedUID, _ = c.Get("expectedUID")
+	r.UID, _ = c.Get("uid")
+	r.Banner, _ = c.Get(keyTCPBanner)
+	r.Failure, _ = c.Get(keyTCPFailure)
+	if r.Source, r.DSCP, err = probeSocketOf(c); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// HTTPSGet is the result of the runner `checkHTTPSGet`, e.g.
+//
+//	status=200 body="ok" final=https://example.com/ redirect=https://example.com/
+//	unexpected status: status=503 body="unavailable"
+type HTTPSGet struct {
+	Common
+	// Status is the HTTP status code, 0 if the request failed after redirects.
+	Status int
+	// Body is a snippet of the response body.
+	Body string
+	// Final is the URL of the final request if the request has been redirected.
+	Final string
+	// Redirects are the URLs of the redirected requests.
+	Redirects []string
+	// Location is the redirect location if redirects are not allowed.
+	Location string
+}
+
+// Text formats the result.
+func (r *HTTPSGet) Text() string {
+	f := newFormatter(r.Reason)
+	f.addIf(r.Status != 0, keyHTTPStatus, r.Status)
+	if r.Body != "" {
+		f.addQuoted("body", r.Body)
+	}
+	f.addIf(r.Final != "", "final", r.Final)
+	for _, redirect := range r.Redirects {
+		f.add(keyHTTPRedirect, redirect)
+	}
+	f.addIf(r.Location != "", "location", r.Location)
+	return f.String()
+}
+
+func parseHTTPSGet(c *Common) (Result, error) {
+	var err error
+	r := &HTTPSGet{Common: *c}
+	if r.Status, err = optionalInt(c, keyHTTPStatus); err != nil {
+		return nil, err
+	}
+	r.Body, _ = c.Get("body")
+	r.Final, _ = c.Get("final")
+	r.Redirects = c.All(keyHTTPRedirect)
+	r.Location, _ = c.Get("location")
+	return r, nil
+}
+
+// GRPCHealth is the result of the runner `checkGRPCHealth`, e.g.
+//
+//	servingStatus=SERVING
+//	grpc error: grpcStatus=5 message="unknown service"
+type GRPCHealth struct {
+	Common
+	// ServingStatus is the serving status of the health check response.
+	ServingStatus string
+	// HTTPStatus is the HTTP status code if it is not 200.
+	HTTPStatus int
+	// GRPCStatus is the gRPC status code if it is not 0.
+	GRPCStatus string
+	// Message is the gRPC status message.
+	Message string
+}
+
+// Text formats the result.
+func (r *GRPCHealth) Text() string {
+	f := newFormatter(r.Reason)
+	switch {
+	case r.HTTPStatus != 0:
+		f.add(keyGRPCHTTPStatus, r.HTTPStatus)
+	case r.GRPCStatus != "":
+		f.add(keyGRPCStatus, r.GRPCStatus).addQuoted("message", r.Message)
+	default:
+		f.add(keyGRPCServingStatus, r.ServingStatus)
+	}
+	return f.String()
+}
+
+func parseGRPCHealth(c *Common) (Result, error) {
+	var err error
+	r := &GRPCHealth{Common: *c}
+	r.ServingStatus, _ = c.Get(keyGRPCServingStatus)
+	if r.HTTPStatus, err = optionalInt(c, keyGRPCHTTPStatus); err != nil {
+		return nil, err
+	}
+	r.GRPCStatus, _ = c.Get(keyGRPCStatus)
+	r.Message, _ = c.Get("message")
+	return r, nil
+}
+
+// NSLookup is the result of the runner `nslookup`, e.g.
+//
+//	addresses=10.0.0.1,10.0.0.2
+//	expected IPs not found: missing=10.0.0.3 addresses=10.0.0.1,10.0.0.2
+type NSLookup struct {
+	Common
+	// Addresses are the IP addresses of the answer.
+	Addresses []string
+	// Missing are the expected IP addresses not found in the answer.
+	Missing []string
+}
+
+// Text formats the result.
+func (r *NSLookup) Text() string {
+	f := newFormatter(r.Reason)
+	f.addIf(len(r.Missing) > 0, keyNSLookupMissing, strings.Join(r.Missing, ","))
+	return f.add(keyNSLookupAddresses, strings.Join(r.Addresses, ",")).String()
+}
+
+func parseNSLookup(c *Common) (Result, error) {
+	r := &NSLookup{Common: *c}
+	r.Addresses = splitList(c, keyNSLookupAddresses)
+	r.Missing = splitList(c, keyNSLookupMissing)
+	return r, nil
+}
+
+func splitList(c *Common, key string) []string {
+	s, _ := c.Get(key)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// Ping is the result of the runner `pingHost`, e.g.
+//
+//	rtt=1.234ms ttl=64 size=24 from=10.0.0.1 seq=0
+//	ping lost: lostAfter=1s
+type Ping struct {
+	Common
+	RTT  time.Duration
+	TTL  int
+	Size int
+	From string
+	Seq  int
+	// Duplicate is true if the reply has been received more than once.
+	Duplicate bool
+	// LostAfter is the timeout if no reply has been received.
+	LostAfter time.Duration
+	// Source is the local address the probe is bound to, if the job selects it.
+	Source string
+	// DSCP is the marking of the probe packets, if the job selects it.
+	DSCP int
+}
+
+// Text formats the result.
+func (r *Ping) Text() string {
+	f := newFormatter(r.Reason)
+	if r.LostAfter != 0 {
+		return f.add(keyPingLostAfter, r.LostAfter).addProbeSocket(r.Source, r.DSCP).String()
+	}
+	f.add(keyPingRTT, r.RTT).add("ttl", r.TTL).add("size", r.Size).add("from", r.From).add("seq", r.Seq)
+	return f.addIf(r.Duplicate, "duplicate", true).addProbeSocket(r.Source, r.DSCP).String()
+}
+
+func parsePing(c *Common) (Result, error) {
+	var err error
+	r := &Ping{Common: *c}
+	if r.Source, r.DSCP, err = probeSocketOf(c); err != nil {
+		return nil, err
+	}
+	if r.LostAfter, err = optionalDuration(c, keyPingLostAfter); err != nil {
+		return nil, err
+	}
+	if r.LostAfter != 0 {
+		return r, nil
+	}
+	if r.RTT, err = optionalDuration(c, keyPingRTT); err != nil {
+		return nil, err
+	}
+	if r.TTL, err = optionalInt(c, "ttl"); err != nil {
+		return nil, err
+	}
+	if r.Size, err = optionalInt(c, "size"); err != nil {
+		return nil, err
+	}
+	if r.Seq, err = optionalInt(c, "seq"); err != nil {
+		return nil, err
+	}
+	r.From, _ = c.Get("from")
+	if s, ok := c.Get("duplicate"); ok {
+		if r.Duplicate, err = strconv.ParseBool(s); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// MTUProbe is the result of the runner `mtuProbe`, e.g.
+//
+//	pathMTU=1500
+//	below minimum: pathMTU=1400 minMTU=1450
+type MTUProbe struct {
+	Common
+	PathMTU int
+	// MinMTU is the configured minimum if the path MTU is below.
+	MinMTU int
+}
+
+// Text formats the result.
+func (r *MTUProbe) Text() string {
+	f := newFormatter(r.Reason)
+	return f.add(keyMTUProbePathMTU, r.PathMTU).addIf(r.MinMTU != 0, "minMTU", r.MinMTU).String()
+}
+
+func parseMTUProbe(c *Common) (Result, error) {
+	var err error
+	r := &MTUProbe{Common: *c}
+	if r.PathMTU, err = requiredInt(c, keyMTUProbePathMTU); err != nil {
+		return nil, err
+	}
+	if r.MinMTU, err = optionalInt(c, "minMTU"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Sources of the MAC address of a neighbor.
+const (
+	// NeighborSourceARP is the source if an ARP request has been sent and answered.
+	NeighborSourceARP = "arp"
+	// NeighborSourceKernel is the source if the entry of the neighbor table of the kernel has been read.
+	NeighborSourceKernel = "kernel"
+)
+
+// ARPPing is the result of the runner `arpPing`, e.g.
+//
+//	neighbor=10.0.0.1 mac=aa:bb:cc:dd:ee:ff rtt=120µs iface=eth0 source=arp
+//	no ARP reply: neighbor=10.0.0.1 iface=eth0 lostAfter=1s source=arp
+type ARPPing struct {
+	Common
+	// Neighbor is the IP address of the gateway or peer.
+	Neighbor string
+	// MAC is the resolved MAC address if successful.
+	MAC string
+	// RTT is only set for an answered ARP request.
+	RTT       time.Duration
+	Interface string
+	// LostAfter is the timeout if the MAC address has not been resolved.
+	LostAfter time.Duration
+	// Source is NeighborSourceARP or NeighborSourceKernel.
+	Source string
+}
+
+// Text formats the result.
+func (r *ARPPing) Text() string {
+	f := newFormatter(r.Reason)
+	f.add(keyARPPingNeighbor, r.Neighbor).addIf(r.MAC != "", "mac", r.MAC).addIf(r.RTT != 0, "rtt", r.RTT)
+	f.add("iface", r.Interface).addIf(r.LostAfter != 0, "lostAfter", r.LostAfter)
+	return f.add("source", r.Source).String()
+}
+
+func parseARPPing(c *Common) (Result, error) {
+	var err error
+	r := &ARPPing{Common: *c}
+	r.Neighbor, _ = c.Get(keyARPPingNeighbor)
+	r.MAC, _ = c.Get("mac")
+	if r.RTT, err = optionalDuration(c, "rtt"); err != nil {
+		return nil, err
+	}
+	r.Interface, _ = c.Get("iface")
+	if r.LostAfter, err = optionalDuration(c, "lostAfter"); err != nil {
+		return nil, err
+	}
+	r.Source, _ = c.Get("source")
+	return r, nil
+}
+
+// PacketTrainUnsupported is the value of the key `packetTrain` if the peer does not support packet trains.
+const PacketTrainUnsupported = "unsupported"
+
+// PacketTrain is the result of the runner `packetTrain`, e.g.
+//
+//	received=19 sent=20 loss=5.0 reordered=0 jitter=120µs
+//	loss above maximum: received=15 sent=20 loss=25.0 reordered=1 jitter=1.5ms maxLoss=10
+//	packetTrain=unsupported
+type PacketTrain struct {
+	Common
+	// Unsupported is true if the peer does not support packet trains.
+	Unsupported bool
+	Received    int
+	Sent        int
+	// Loss is the packet loss in percent.
+	Loss      float64
+	Reordered int
+	Jitter    time.Duration
+	// MaxLoss is the configured maximum if the loss is above.
+	MaxLoss float64
+	// Source is the local address the packets are sent from, if the job selects it.
+	Source string
+	// DSCP is the marking of the packets, if the job selects it.
+	DSCP int
+}
+
+// Text formats the result.
+func (r *PacketTrain) Text() string {
+	f := newFormatter(r.Reason)
+	if r.Unsupported {
+		return f.add(keyPacketTrainSupport, PacketTrainUnsupported).String()
+	}
+	f.add(keyPacketTrainReceived, r.Received).add("sent", r.Sent).add("loss", strconv.FormatFloat(r.Loss, 'f', 1, 64))
+	f.add("reordered", r.Reordered).add("jitter", r.Jitter)
+	f.addIf(r.MaxLoss != 0 || r.Reason != "", "maxLoss", strconv.FormatFloat(r.MaxLoss, 'g', -1, 64))
+	return f.addProbeSocket(r.Source, r.DSCP).String()
+}
+
+func parsePacketTrain(c *Common) (Result, error) {
+	var err error
+	r := &PacketTrain{Common: *c}
+	if s, ok := c.Get(keyPacketTrainSupport); ok {
+		if s != PacketTrainUnsupported {
+			return nil, fmt.Errorf("unknown packet train support %s", s)
+		}
+		r.Unsupported = true
+		return r, nil
+	}
+	if r.Received, err = requiredInt(c, keyPacketTrainReceived); err != nil {
+		return nil, err
+	}
+	if r.Sent, err = requiredInt(c, "sent"); err != nil {
+		return nil, err
+	}
+	if r.Loss, err = optionalFloat(c, "loss"); err != nil {
+		return nil, err
+	}
+	if r.Reordered, err = optionalInt(c, "reordered"); err != nil {
+		return nil, err
+	}
+	if r.Jitter, err = optionalDuration(c, "jitter"); err != nil {
+		return nil, err
+	}
+	if r.MaxLoss, err = optionalFloat(c, "maxLoss"); err != nil {
+		return nil, err
+	}
+	if r.Source, r.DSCP, err = probeSocketOf(c); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// addProbeSocket adds the optional source address and DSCP marking of a probe.
+func (f *formatter) addProbeSocket(source string, dscp int) *formatter {
+	return f.addIf(source != "", keySource, source).addIf(dscp != 0, keyDSCP, dscp)
+}
+
+// probeSocketOf returns the optional source address and DSCP marking of a probe.
+func probeSocketOf(c *Common) (string, int, error) {
+	source, _ := c.Get(keySource)
+	dscp, err := optionalInt(c, keyDSCP)
+	return source, dscp, err
+}
+
+func optionalDuration(c *Common, key string) (time.Duration, error) {
+	s, ok := c.Get(key)
+	if !ok {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func optionalFloat(c *Common, key string) (float64, error) {
+	s, ok := c.Get(key)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -5,10 +5,79 @@
 package nwpd
 
 import (
+	"cmp"
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
+const (
+	// AggregateByHost aggregates the observations per pair of source and destination host.
+	AggregateByHost = "host"
+	// AggregateByZone aggregates the observations per pair of source and destination zone.
+	AggregateByZone = "zone"
+	// AggregateByJob aggregates the observations per job over all edges.
+	AggregateByJob = "job"
+	// AggregateBySrcHost aggregates the observations per source host over all destinations.
+	AggregateBySrcHost = "srcHost"
+	// AggregateByDestHost aggregates the observations per destination host over all sources.
+	AggregateByDestHost = "destHost"
+	// AggregateAll is the value of the host fields not used for grouping the aggregated observations.
+	AggregateAll = "*"
+)
+
+// AggregateByFields are the supported groupings of the aggregated observations.
+var AggregateByFields = []string{AggregateByHost, AggregateByJob, AggregateBySrcHost, AggregateByDestHost, AggregateByZone}
+
+const (
+	// SortByTimestamp sorts the listed observations by timestamp.
+	SortByTimestamp = "timestamp"
+	// SortByDuration sorts the listed observations by duration of the check.
+	SortByDuration = "duration"
+	// SortByJobID sorts the listed observations by job ID.
+	SortByJobID = "jobID"
+)
+
+// SortFields are the fields supported for sorting the listed observations.
+var SortFields = []string{SortByTimestamp, SortByDuration, SortByJobID}
+
+// meshJobIDSeparator separates the job ID and the peer in the job ID of observations of mesh jobs.
+const meshJobIDSeparator = "/"
+
+// MeshJobID returns the job ID of the observations of a mesh job for the peer.
+func MeshJobID(jobID, peer string) string {
+	return jobID + meshJobIDSeparator + peer
+}
+
+// SplitMeshJobID returns the job ID of the job and the peer of a job ID created by MeshJobID.
+// For other job IDs, the job ID itself and an empty peer are returned.
+func SplitMeshJobID(jobID string) (string, string) {
+	job, peer, _ := strings.Cut(jobID, meshJobIDSeparator)
+	return job, peer
+}
+
+// FormatObservation returns the observation in the line format of 'list obs' followed by the result.
+func FormatObservation(obs *Observation) string {
+	dur := ""
+	if obs.Duration != nil {
+		dur = fmt.Sprintf(" duration=%dms", obs.Duration.AsDuration().Milliseconds())
+	}
+	status := "ok"
+	switch {
+	case obs.StaleEndpoint:
+		status = "stale"
+	case !obs.Ok:
+		status = "failed"
+	}
+	result := ""
+	if obs.Result != "" {
+		result = fmt.Sprintf(" result=%q", obs.Result)
+	}
+	return fmt.Sprintf("%s src=%s dest=%s jobid=%s%s status=%s%s", obs.Timestamp.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
+		obs.SrcHost, obs.DestHost, obs.JobID, dur, status, result)
+}
+
 type ObservationListener interface {
 	Add(obs *Observation)
 }
@@ -20,7 +89,87 @@ type ListObservationsOptions struct {
 	FilterJobIDs    []string
 	FilterSrcHosts  []string
 	FilterDestHosts []string
-	FailuresOnly    bool
+	// FilterJobIDRegex if set, only observations with a job ID matching this regular expression are listed.
+	FilterJobIDRegex string
+	// FilterSrcHostRegex if set, only observations with a source host matching this regular expression are listed.
+	FilterSrcHostRegex string
+	// FilterDestHostRegex if set, only observations with a destination host matching this regular expression are listed.
+	FilterDestHostRegex string
+	// FilterLabels if set, only observations having all of these labels are listed.
+	FilterLabels map[string]string
+	// FilterResultFields if set, only observations having all of these result field values are listed.
+	FilterResultFields map[string]string
+	// FilterResultRegex if set, only observations with a result matching this regular expression are listed.
+	// Observations without result text, i.e. read from the record files, are matched against their result fields.
+	FilterResultRegex string
+	// MinDuration if set, only observations with a longer duration are listed.
+	MinDuration time.Duration
+	// MinFailureStreak if set, only failed observations being part of a run of at least this number of
+	// consecutive failures of the same job and edge are listed.
+	MinFailureStreak int
+	// Filter if set, only observations accepted by this function are listed.
+	Filter       func(obs *Observation) bool
+	FailuresOnly bool
+	// After if set, only observations positioned after this cursor are listed.
+	// It is only supported for the default order by timestamp ascending.
+	After *Cursor
+	// SortBy is the field to sort the observations by before applying the limit (default SortByTimestamp).
+	SortBy string
+	// SortDescending if true, the observations are sorted in descending order.
+	SortDescending bool
+}
+
+// IsDefaultOrder returns true if the observations are listed by timestamp ascending.
+func (o ListObservationsOptions) IsDefaultOrder() bool {
+	return (o.SortBy == "" || o.SortBy == SortByTimestamp) && !o.SortDescending
+}
+
+// ObservationOrder returns the comparison function for sorting observations by the given field.
+// Observations with equal values are ordered by their position, i.e. by timestamp, job ID, source host,
+// and destination host ascending. Observations without duration are sorted as zero duration.
+func ObservationOrder(sortBy string, descending bool) (func(a, b *Observation) int, error) {
+	var compare func(a, b *Observation) int
+	switch sortBy {
+	case "", SortByTimestamp:
+		compare = func(a, b *Observation) int {
+			return cmp.Compare(a.Timestamp.AsTime().UnixNano(), b.Timestamp.AsTime().UnixNano())
+		}
+	case SortByDuration:
+		compare = func(a, b *Observation) int {
+			return cmp.Compare(int64(a.Duration.AsDuration()), int64(b.Duration.AsDuration()))
+		}
+	case SortByJobID:
+		compare = func(a, b *Observation) int {
+			return strings.Compare(a.JobID, b.JobID)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported field %q (allowed: %s)", sortBy, strings.Join(SortFields, ", "))
+	}
+	return func(a, b *Observation) int {
+		c := compare(a, b)
+		if descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+		return comparePositions(CursorOf(a), CursorOf(b))
+	}, nil
+}
+
+// InvalidFilterError is returned by ListObservations if a filter option is invalid.
+type InvalidFilterError struct {
+	// Field is the name of the invalid option, matching the field of the GetObservationsRequest.
+	Field string
+	Err   error
+}
+
+func (e *InvalidFilterError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Err)
+}
+
+func (e *InvalidFilterError) Unwrap() error {
+	return e.Err
 }
 
 type ObservationWriter interface {
@@ -28,6 +177,17 @@ type ObservationWriter interface {
 	Run()
 	Stop()
 	ListObservations(options ListObservationsOptions) (Observations, error)
+	// IterateObservations calls the visitor for the observations matching the options ordered by timestamp ascending,
+	// until the visitor returns stop or an error. If the limit is set, at most this number of observations is visited.
+	// Only the default order is supported.
+	IterateObservations(options ListObservationsOptions, visitor func(obs *Observation) (stop bool, err error)) error
+	// AddJobRun persists the record of a job run or event together with the observations.
+	AddJobRun(record *JobRunRecord)
+	// SetMaxDiskUsage sets the maximum total size of the persisted observations in bytes (0 for no limit).
+	SetMaxDiskUsage(maxBytes int64)
+	// SetWriteBatching sets the interval for persisting the batched observations and the number of observations
+	// persisted before the interval has elapsed.
+	SetWriteBatching(flushInterval time.Duration, batchSize int)
 }
 
 type Observations []*Observation
@@ -38,10 +198,9 @@ func (o Observations) Len() int {
 	return len(o)
 }
 
+// Less orders the observations by timestamp, job ID, source host, and destination host.
 func (o Observations) Less(i, j int) bool {
-	ti := o[i].Timestamp.AsTime()
-	tj := o[j].Timestamp.AsTime()
-	return ti.Before(tj)
+	return comparePositions(CursorOf(o[i]), CursorOf(o[j])) < 0
 }
 
 func (o Observations) Swap(i, j int) {
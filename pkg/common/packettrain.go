@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// PathPacketTrain is the HTTP path of an agent returning the report of a received UDP packet train.
+	PathPacketTrain = "/packettrain"
+	// MaxPacketTrainPackets is the maximum number of packets of a single packet train.
+	MaxPacketTrainPackets = 1000
+	// MinPacketTrainPacketSize is the minimum size of a packet train packet (the size of the header).
+	MinPacketTrainPacketSize = packetTrainHeaderSize
+	// MaxPacketTrainPacketSize is the maximum size of a packet train packet, small enough to avoid fragmentation.
+	MaxPacketTrainPacketSize = 1200
+
+	packetTrainMagic      = 0x6e777074 // "nwpt"
+	packetTrainHeaderSize = 20
+)
+
+// PacketTrainPacket is the header of a single UDP packet of a packet train.
+type PacketTrainPacket struct {
+	// TrainID identifies the packet train. It is chosen randomly by the sender.
+	TrainID uint64
+	// Seq is the sequence number of the packet, starting with 0.
+	Seq uint32
+	// Count is the total number of packets of the train.
+	Count uint32
+}
+
+// Encode writes the header to the beginning of the buffer, which must have at least MinPacketTrainPacketSize bytes.
+func (p PacketTrainPacket) Encode(buf []byte) {
+	binary.BigEndian.PutUint32(buf[0:4], packetTrainMagic)
+	binary.BigEndian.PutUint64(buf[4:12], p.TrainID)
+	binary.BigEndian.PutUint32(buf[12:16], p.Seq)
+	binary.BigEndian.PutUint32(buf[16:20], p.Count)
+}
+
+// DecodePacketTrainPacket parses the header of a received packet train packet.
+func DecodePacketTrainPacket(buf []byte) (PacketTrainPacket, error) {
+	if len(buf) < packetTrainHeaderSize || binary.BigEndian.Uint32(buf[0:4]) != packetTrainMagic {
+		return PacketTrainPacket{}, fmt.Errorf("no packet train packet")
+	}
+	p := PacketTrainPacket{
+		TrainID: binary.BigEndian.Uint64(buf[4:12]),
+		Seq:     binary.BigEndian.Uint32(buf[12:16]),
+		Count:   binary.BigEndian.Uint32(buf[16:20]),
+	}
+	if p.Count == 0 || p.Count > MaxPacketTrainPackets || p.Seq >= p.Count {
+		return PacketTrainPacket{}, fmt.Errorf("invalid packet train packet %d/%d", p.Seq, p.Count)
+	}
+	return p, nil
+}
+
+// PacketTrainReport is the report of the receiver about the packets of a train in the order of their arrival.
+type PacketTrainReport struct {
+	// Seqs are the sequence numbers of the received packets in the order of arrival. Duplicates are ignored.
+	Seqs []uint32 `json:"seqs"`
+	// ArrivalMicros are the arrival times of the received packets in microseconds relative to the arrival of the first packet.
+	ArrivalMicros []int64 `json:"arrivalMicros"`
+}
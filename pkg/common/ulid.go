@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// crockfordBase32 is the alphabet of ULIDs.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a universally unique lexicographically sortable identifier (26 characters) for the given time.
+// It consists of the milliseconds since the epoch (48 bits) followed by 80 random bits.
+func NewULID(t time.Time) string {
+	var data [16]byte
+	ms := uint64(t.UnixMilli()) // #nosec G115 -- times before the epoch are not used
+	for i := 5; i >= 0; i-- {
+		data[i] = byte(ms)
+		ms >>= 8
+	}
+	_, _ = rand.Read(data[6:])
+
+	// 128 bits are encoded as 26 characters of 5 bits each, the first character only holding 3 bits
+	var id [26]byte
+	var acc uint32
+	bits := 2 // pad the 128 bits to 130 bits at the front
+	pos := 0
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			id[pos] = crockfordBase32[(acc>>uint(bits))&0x1f]
+			pos++
+		}
+	}
+	return string(id[:])
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/common"
@@ -76,7 +77,7 @@ func (c *nodePodController) ListAgentPods() ([]*corev1.Pod, error) {
 	// remember known pods
 	podIPs := map[string]string{}
 	for _, pod := range pods {
-		if pod.Status.Phase == corev1.PodRunning {
+		if isKnownPod(pod) {
 			podIPs[pod.Name] = pod.Status.PodIP
 		}
 	}
@@ -110,20 +111,35 @@ func (c *nodePodController) OnAdd(obj interface{}, _ bool) {
 	}
 }
 
-func (c *nodePodController) OnUpdate(_, newObj interface{}) {
+func (c *nodePodController) OnUpdate(oldObj, newObj interface{}) {
 	if c.isRelevant(newObj) {
+		if newNode, ok := newObj.(*corev1.Node); ok {
+			if oldNode, ok := oldObj.(*corev1.Node); ok && !reflect.DeepEqual(oldNode.Labels, newNode.Labels) {
+				// node labels are needed for job node selectors
+				c.hasUpdates.Store(true)
+			}
+		}
 		if newPod, ok := newObj.(*corev1.Pod); ok {
-			if newPod.Status.Phase == corev1.PodRunning {
-				podIPs := c.knownPodIPs.Load().(map[string]string)
-				if podIPs[newPod.Name] != newPod.Status.PodIP {
-					// either new, yet unknown running agent pod or in very rare edge cases the PodIP has changed (e.g. after node reboot)
+			podIPs := c.knownPodIPs.Load().(map[string]string)
+			ip, known := podIPs[newPod.Name]
+			if isKnownPod(newPod) {
+				if !known || ip != newPod.Status.PodIP {
+					// either new, yet unknown ready agent pod or in very rare edge cases the PodIP has changed (e.g. after node reboot)
 					c.hasUpdates.Store(true)
 				}
+			} else if known {
+				// known agent pod is not ready anymore
+				c.hasUpdates.Store(true)
 			}
 		}
 	}
 }
 
+// isKnownPod returns true if the agent pod is added to the pod endpoints of the cluster config.
+func isKnownPod(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && deploy.IsPodReady(pod)
+}
+
 func (c *nodePodController) OnDelete(obj interface{}) {
 	if c.isRelevant(obj) {
 		if node, ok := obj.(*corev1.Node); ok {
@@ -251,11 +267,15 @@ func (w *watch) Start(ctx context.Context) error {
 			w.log.Errorf("unmarshal configmap %s/%s failed: %s", common.NamespaceKubeSystem, common.NameClusterConfigMap, err)
 			continue
 		}
-		cfg, err = deploy.BuildClusterConfig(w.log, nodes, pods, internalAPIServer, apiServer)
+		packetTrainPort, services := cfg.PacketTrainPort, cfg.Services
+		cfg, err = deploy.BuildClusterConfig(w.log, nodes, pods, internalAPIServer, apiServer, nil)
 		if err != nil {
 			w.log.Errorf("building cluster config failed: %w", err)
 			continue
 		}
+		// the packet train port and the probed services are set on deployment
+		cfg.PacketTrainPort = packetTrainPort
+		cfg.Services = services
 		cfgBytes, err := yaml.Marshal(cfg)
 		if err != nil {
 			w.log.Errorf("marshal configmap %s/%s failed: %s", common.NamespaceKubeSystem, common.NameClusterConfigMap, err)
@@ -274,6 +294,24 @@ func (w *watch) Start(ctx context.Context) error {
 			w.log.Info("unchanged")
 			w.lastLoop.Store(last.UnixMilli())
 		}
+
+		acm, err := configmaps.Get(ctx, common.NameAgentConfigMap, metav1.GetOptions{})
+		if err != nil {
+			w.log.Errorf("loading configmap %s/%s failed: %s", common.NamespaceKubeSystem, common.NameAgentConfigMap, err)
+			continue
+		}
+		rescaled, err := deploy.RescaleAgentConfigMap(acm, cfg.NodeCount)
+		if err != nil {
+			w.log.Errorf("applying scaling policy to configmap %s/%s failed: %s", common.NamespaceKubeSystem, common.NameAgentConfigMap, err)
+			continue
+		}
+		if rescaled {
+			if _, err := configmaps.Update(ctx, acm, metav1.UpdateOptions{}); err != nil {
+				w.log.Errorf("updating configmap %s/%s failed: %s", common.NamespaceKubeSystem, common.NameAgentConfigMap, err)
+				continue
+			}
+			w.log.Infof("updated configmap %s/%s for scaling policy with %d nodes", common.NamespaceKubeSystem, common.NameAgentConfigMap, cfg.NodeCount)
+		}
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	_ "embed"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -41,6 +42,8 @@ type AgentDeployConfig struct {
 	DefaultSeccompProfileEnabled bool
 	// PingEnabled if ping checks are enabled (needs NET_ADMIN capabilities).
 	PingEnabled bool
+	// PacketTrainEnabled if the agents should listen for UDP packet trains and measure the one-way delivery to their peers.
+	PacketTrainEnabled bool
 	// IgnoreAPIServerEndpoint if the check of the API server endpoint should be ignored.
 	IgnoreAPIServerEndpoint bool
 	// PriorityClassName is the priority class name used for the daemon sets.
@@ -60,6 +63,14 @@ type AgentDeployConfig struct {
 	DisableAutomountServiceAccountTokenForAgents bool
 	// MaxPeerNodes if != 0 restricts number of peer nodes used as destinations for checks (nodes are selected randomly, but stable in this case).
 	MaxPeerNodes int
+	// ScalingPolicy if set, scales the job periods and the destination sampling with the number of nodes.
+	ScalingPolicy *config.ScalingPolicy
+	// APITokenSecret if set, is the key of a secret in format `<namespace>/<name>#<key>` with the API token required for the agent service.
+	APITokenSecret string
+	// SecretRefs are the secrets referenced by the agent configuration. The agents are granted read access to exactly these secrets.
+	SecretRefs []config.SecretRef
+	// ProbeServices are the services in format `<namespace>/<name>[:<port>]` whose cluster IP is probed from all nodes.
+	ProbeServices []string
 }
 
 // NetworkProblemDetectorAgent returns K8s resources to be created.
@@ -97,12 +108,15 @@ func (ac *AgentDeployConfig) AddOptionFlags(flags *pflag.FlagSet) {
 	flags.DurationVar(&ac.DefaultPeriod, "default-period", 5*time.Second, "default period for jobs")
 	flags.BoolVar(&ac.DefaultSeccompProfileEnabled, "default-seccomp-profile", false, "if seccomp profile should be defaulted to RuntimeDefault for network-problem-detector pods")
 	flags.BoolVar(&ac.PingEnabled, "enable-ping", false, "if ICMP pings should be used in addition to TCP connection checks")
+	flags.BoolVar(&ac.PacketTrainEnabled, "enable-packet-train", false, fmt.Sprintf("if UDP packet trains should be used for measuring one-way loss, reordering and jitter (UDP port %d)", common.PacketTrainPort))
 	flags.BoolVar(&ac.K8sExporterEnabled, "enable-k8s-exporter", false, "if node conditions and events should be updated/created")
 	flags.DurationVar(&ac.K8sExporterHeartbeat, "k8s-exporter-heartbeat", 3*time.Minute, "period for updating the node conditions by the K8s exporter")
 	flags.Float64Var(&ac.K8sExporterMinFailingPeerNodeShare, "k8s-exporter-min-failing-peer-node-share", 0.2, "if > 0, report node conditions only if checks for minimum share of destination peer nodes are failing. Valid range: [0.0,1.0]")
 	flags.BoolVar(&ac.IgnoreAPIServerEndpoint, "ignore-gardener-kube-api-server", false, "if true, does not try to lookup kube api-server of Gardener control plane")
 	flags.StringVar(&ac.PriorityClassName, "priority-class", "", "priority class name")
 	flags.IntVar(&ac.MaxPeerNodes, "max-peer-nodes", 0, "if != 0 restricts number of peer nodes used as check destinations")
+	flags.StringVar(&ac.APITokenSecret, "api-token-secret", "", "if set, the agent service requires the API token of the secret key in format '<namespace>/<name>#<key>'")
+	flags.StringSliceVar(&ac.ProbeServices, "probe-services", nil, "services in format '<namespace>/<name>[:<port>]' whose cluster IP is probed from all nodes")
 }
 
 func (ac *AgentDeployConfig) buildService(hostnetwork bool) (*corev1.Service, error) {
@@ -149,6 +163,24 @@ func (ac *AgentDeployConfig) getNetworkConfig(hostnetwork bool) (name string, po
 	return
 }
 
+func (ac *AgentDeployConfig) containerPorts(portHTTP int32) []corev1.ContainerPort {
+	ports := []corev1.ContainerPort{
+		{
+			Name:          "metrics",
+			ContainerPort: portHTTP,
+			Protocol:      "TCP",
+		},
+	}
+	if ac.PacketTrainEnabled {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          "packet-train",
+			ContainerPort: common.PacketTrainPort,
+			Protocol:      "UDP",
+		})
+	}
+	return ports
+}
+
 func (ac *AgentDeployConfig) buildDaemonSet(serviceAccountName string, hostNetwork bool) (*appsv1.DaemonSet, error) {
 	var (
 		requestCPU, _          = resource.ParseQuantity("10m")
@@ -170,7 +202,7 @@ func (ac *AgentDeployConfig) buildDaemonSet(serviceAccountName string, hostNetwo
 	}
 	var automountServiceAccountToken *bool
 	if !ac.DisableAutomountServiceAccountTokenForAgents {
-		automountServiceAccountToken = ptr.To(ac.K8sExporterEnabled)
+		automountServiceAccountToken = ptr.To(ac.K8sExporterEnabled || len(ac.SecretRefs) > 0)
 	}
 
 	typ := corev1.HostPathDirectoryOrCreate
@@ -251,21 +283,41 @@ func (ac *AgentDeployConfig) buildDaemonSet(serviceAccountName string, hostNetwo
 									},
 								},
 							},
+							{
+								Name: common.EnvPodName,
+								ValueFrom: &corev1.EnvVarSource{
+									FieldRef: &corev1.ObjectFieldSelector{
+										FieldPath: "metadata.name",
+									},
+								},
+							},
+							{
+								Name: common.EnvPodUID,
+								ValueFrom: &corev1.EnvVarSource{
+									FieldRef: &corev1.ObjectFieldSelector{
+										FieldPath: "metadata.uid",
+									},
+								},
+							},
 						},
 						LivenessProbe: &corev1.Probe{
 							ProbeHandler: corev1.ProbeHandler{
-								TCPSocket: &corev1.TCPSocketAction{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: common.PathHealthz,
 									Port: intstr.FromInt(int(portHTTP)),
 								},
 							},
 						},
-						Ports: []corev1.ContainerPort{
-							{
-								Name:          "metrics",
-								ContainerPort: portHTTP,
-								Protocol:      "TCP",
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: common.PathReadyz,
+									Port: intstr.FromInt(int(portHTTP)),
+								},
 							},
+							PeriodSeconds: 30,
 						},
+						Ports: ac.containerPorts(portHTTP),
 						Resources: corev1.ResourceRequirements{
 							Requests: corev1.ResourceList{
 								corev1.ResourceCPU:    requestCPU,
@@ -551,9 +603,73 @@ func (ac *AgentDeployConfig) buildSecurityObjects() (serviceAccountName string,
 		retErr = err
 		objects = append(objects, cr, crb, sa)
 	}
+	if len(ac.SecretRefs) > 0 {
+		if serviceAccountName == "" {
+			serviceAccountName = common.ApplicationName
+			objects = append(objects, buildServiceAccount(serviceAccountName))
+		}
+		objects = append(objects, ac.buildSecretReaderRoles(serviceAccountName)...)
+	}
 	return
 }
 
+// buildSecretReaderRoles returns a role and role binding for each namespace of the referenced secrets,
+// which allow reading exactly the referenced secrets.
+func (ac *AgentDeployConfig) buildSecretReaderRoles(serviceAccountName string) []Object {
+	names := map[string]common.StringSet{}
+	for _, ref := range ac.SecretRefs {
+		if names[ref.Namespace] == nil {
+			names[ref.Namespace] = common.StringSet{}
+		}
+		names[ref.Namespace].Add(ref.Name)
+	}
+	namespaces := make([]string, 0, len(names))
+	for ns := range names {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	roleName := "gardener.cloud:" + common.ApplicationName + ":secrets"
+	var objects []Object
+	for _, ns := range namespaces {
+		resourceNames := names[ns].ToSortedArray()
+		role := &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      roleName,
+				Namespace: ns,
+			},
+			Rules: []rbacv1.PolicyRule{
+				{
+					APIGroups:     []string{""},
+					Resources:     []string{"secrets"},
+					ResourceNames: resourceNames,
+					Verbs:         []string{"get"},
+				},
+			},
+		}
+		roleBinding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      roleName,
+				Namespace: ns,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: "rbac.authorization.k8s.io",
+				Kind:     "Role",
+				Name:     roleName,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      "ServiceAccount",
+					Name:      serviceAccountName,
+					Namespace: common.NamespaceKubeSystem,
+				},
+			},
+		}
+		objects = append(objects, role, roleBinding)
+	}
+	return objects
+}
+
 func (ac *AgentDeployConfig) buildK8sExporterClusterRole(serviceAccountName string) (*rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding, *corev1.ServiceAccount, error) {
 	roleName := "gardener.cloud:kube-system:" + common.ApplicationName
 	rules := ac.buildK8sExporterClusterRoleRules()
@@ -584,20 +700,24 @@ func (ac *AgentDeployConfig) createClusterRuleAndServiceAccount(serviceAccountNa
 			},
 		},
 	}
-	serviceAccount := &corev1.ServiceAccount{
+	return clusterRole, clusterRoleBinding, buildServiceAccount(serviceAccountName), nil
+}
+
+func buildServiceAccount(serviceAccountName string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceAccountName,
 			Namespace: common.NamespaceKubeSystem,
 		},
 		AutomountServiceAccountToken: ptr.To(false),
 	}
-
-	return clusterRole, clusterRoleBinding, serviceAccount, nil
 }
 
 func (ac *AgentDeployConfig) BuildAgentConfig() (*config.AgentConfig, error) {
 	periodXL := fmt.Sprintf("%ds", imin(60, imax(1, int(ac.DefaultPeriod/time.Second))*2))
 	cfg := config.AgentConfig{
+		// the pod discovery and the kube-apiserver checks only need the cluster config, not in-cluster access
+		Environment:     config.EnvironmentKubernetes,
 		OutputDir:       common.PathOutputDir,
 		RetentionHours:  24,
 		LogObservations: false,
@@ -707,8 +827,46 @@ func (ac *AgentDeployConfig) BuildAgentConfig() (*config.AgentConfig, error) {
 				Args:  []string{"pingHost"},
 			})
 	}
+	if ac.PacketTrainEnabled {
+		cfg.HostNetwork.Jobs = append(cfg.HostNetwork.Jobs,
+			config.Job{
+				JobID: "udp-n2n",
+				Args:  []string{"udpPacketTrain", "--node-http-port", fmt.Sprintf("%d", common.HostNetPodHTTPPort), "--scale-period"},
+			})
+		cfg.PodNetwork.Jobs = append(cfg.PodNetwork.Jobs,
+			config.Job{
+				JobID: "udp-p2p",
+				Args:  []string{"udpPacketTrain", "--endpoints-of-pod-ds", "--scale-period"},
+			})
+	}
+
+	if len(ac.ProbeServices) > 0 {
+		for _, ref := range ac.ProbeServices {
+			if _, _, _, err := ParseServiceRef(ref); err != nil {
+				return nil, err
+			}
+		}
+		cfg.HostNetwork.Jobs = append(cfg.HostNetwork.Jobs,
+			config.Job{
+				JobID: "tcp-n2svc",
+				Args:  []string{"checkTCPPort", "--services", "--scale-period"},
+			})
+		cfg.PodNetwork.Jobs = append(cfg.PodNetwork.Jobs,
+			config.Job{
+				JobID: "tcp-p2svc",
+				Args:  []string{"checkTCPPort", "--services", "--scale-period"},
+			})
+	}
 
 	cfg.MaxPeerNodes = ac.MaxPeerNodes
+	cfg.ScalingPolicy = ac.ScalingPolicy
+	if ac.APITokenSecret != "" {
+		ref, err := config.ParseSecretRef(config.SecretRefPrefix + ac.APITokenSecret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API token secret %s, expected format <namespace>/<name>#<key>", ac.APITokenSecret)
+		}
+		cfg.APIToken = ref.String()
+	}
 
 	return &cfg, nil
 }
@@ -7,12 +7,14 @@ package deploy_test
 import (
 	"time"
 
+	"github.com/gardener/network-problem-detector/pkg/common/config"
 	"github.com/gardener/network-problem-detector/pkg/deploy"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 )
 
 var _ = Describe("Add default seccomp profile when enabled", func() {
@@ -60,3 +62,91 @@ var _ = Describe("Add default seccomp profile when enabled", func() {
 		Expect(ds.Spec.Template.Spec.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
 	})
 })
+
+var _ = Describe("Read access to referenced secrets", func() {
+	It("grants access to exactly the referenced secrets", func() {
+		deployConfig := &deploy.AgentDeployConfig{
+			Image:         "image:tag",
+			DefaultPeriod: 16 * time.Second,
+			SecretRefs: []config.SecretRef{
+				{Namespace: "monitoring", Name: "remote-write", Key: "password"},
+				{Namespace: "monitoring", Name: "remote-write", Key: "username"},
+				{Namespace: "monitoring", Name: "webhook", Key: "token"},
+				{Namespace: "registry", Name: "auth", Key: "token"},
+			},
+		}
+		objs, err := deploy.NetworkProblemDetectorAgent(deployConfig)
+		Expect(err).To(BeNil())
+		roles := map[string]*rbacv1.Role{}
+		var bindings []*rbacv1.RoleBinding
+		var sa *corev1.ServiceAccount
+		var ds *appsv1.DaemonSet
+		for _, obj := range objs {
+			switch v := obj.(type) {
+			case *rbacv1.Role:
+				roles[v.Namespace] = v
+			case *rbacv1.RoleBinding:
+				bindings = append(bindings, v)
+			case *corev1.ServiceAccount:
+				sa = v
+			case *appsv1.DaemonSet:
+				ds = v
+			}
+		}
+
+		Expect(roles).To(HaveLen(2))
+		Expect(roles["monitoring"].Rules).To(Equal([]rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{"remote-write", "webhook"},
+			Verbs:         []string{"get"},
+		}}))
+		Expect(roles["registry"].Rules[0].ResourceNames).To(Equal([]string{"auth"}))
+		Expect(bindings).To(HaveLen(2))
+		for _, b := range bindings {
+			Expect(b.RoleRef.Name).To(Equal(roles[b.Namespace].Name))
+			Expect(b.Subjects[0].Name).To(Equal(sa.Name))
+			Expect(b.Subjects[0].Namespace).To(Equal(sa.Namespace))
+		}
+		Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(sa.Name))
+		Expect(*ds.Spec.Template.Spec.AutomountServiceAccountToken).To(BeTrue())
+	})
+
+	It("references the API token secret in the agent configuration", func() {
+		deployConfig := &deploy.AgentDeployConfig{Image: "image:tag", DefaultPeriod: 16 * time.Second, APITokenSecret: "kube-system/nwpd-api-token#token"}
+		cfg, err := deployConfig.BuildAgentConfig()
+		Expect(err).To(BeNil())
+		Expect(cfg.APIToken).To(Equal("secretRef:kube-system/nwpd-api-token#token"))
+		Expect(cfg.SecretRefs()).To(Equal([]config.SecretRef{{Namespace: "kube-system", Name: "nwpd-api-token", Key: "token"}}))
+
+		deployConfig.APITokenSecret = "nwpd-api-token"
+		_, err = deployConfig.BuildAgentConfig()
+		Expect(err).To(MatchError("invalid API token secret nwpd-api-token, expected format <namespace>/<name>#<key>"))
+	})
+
+	It("adds the service probe jobs if services are selected", func() {
+		deployConfig := &deploy.AgentDeployConfig{Image: "image:tag", DefaultPeriod: 16 * time.Second}
+		cfg, err := deployConfig.BuildAgentConfig()
+		Expect(err).To(BeNil())
+		Expect(cfg.PodNetwork.Jobs).NotTo(ContainElement(HaveField("JobID", "tcp-p2svc")))
+
+		deployConfig.ProbeServices = []string{"kube-system/kube-dns:dns-tcp"}
+		cfg, err = deployConfig.BuildAgentConfig()
+		Expect(err).To(BeNil())
+		Expect(cfg.HostNetwork.Jobs).To(ContainElement(config.Job{JobID: "tcp-n2svc", Args: []string{"checkTCPPort", "--services", "--scale-period"}}))
+		Expect(cfg.PodNetwork.Jobs).To(ContainElement(config.Job{JobID: "tcp-p2svc", Args: []string{"checkTCPPort", "--services", "--scale-period"}}))
+
+		deployConfig.ProbeServices = []string{"kube-dns"}
+		_, err = deployConfig.BuildAgentConfig()
+		Expect(err).To(MatchError("invalid service kube-dns, expected format <namespace>/<name>[:<port>]"))
+	})
+
+	It("creates no roles without references", func() {
+		objs, err := deploy.NetworkProblemDetectorAgent(&deploy.AgentDeployConfig{Image: "image:tag"})
+		Expect(err).To(BeNil())
+		for _, obj := range objs {
+			Expect(obj).NotTo(BeAssignableToTypeOf(&rbacv1.Role{}))
+			Expect(obj).NotTo(BeAssignableToTypeOf(&corev1.ServiceAccount{}))
+		}
+	})
+})
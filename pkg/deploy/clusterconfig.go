@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gardener/network-problem-detector/pkg/common"
@@ -17,12 +18,20 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+// ProbedService is a service selected for probing its cluster IP by the agents.
+type ProbedService struct {
+	Service *corev1.Service
+	// Port is the name or number of the probed port, the first TCP port of the service if empty.
+	Port string
+}
+
 func BuildClusterConfig(
 	log logrus.FieldLogger,
 	nodes []*corev1.Node,
 	agentPods []*corev1.Pod,
 	internalKubeAPIServer,
 	kubeAPIServer *config.Endpoint,
+	services []ProbedService,
 ) (*config.ClusterConfig, error) {
 	clusterConfig := &config.ClusterConfig{
 		InternalKubeAPIServer: internalKubeAPIServer,
@@ -32,15 +41,23 @@ func BuildClusterConfig(
 	nodeNames := common.StringSet{}
 	for _, n := range nodes {
 		hostname := ""
-		ip := ""
+		var internalIPs, externalIPs, externalDNSNames []string
 		for _, addr := range n.Status.Addresses {
 			switch addr.Type {
 			case "Hostname":
 				hostname = addr.Address
 			case "InternalIP":
-				ip = addr.Address
+				internalIPs = append(internalIPs, addr.Address)
+			case corev1.NodeExternalIP:
+				externalIPs = append(externalIPs, addr.Address)
+			case corev1.NodeExternalDNS:
+				externalDNSNames = append(externalDNSNames, addr.Address)
 			}
 		}
+		// the order of the addresses is not stable on all providers
+		ip := selectAddress(internalIPs)
+		externalIP := selectAddress(externalIPs)
+		externalDNS := selectAddress(externalDNSNames)
 		if ip == "" {
 			log.Infof("ignore node %s without internalIP", n.Name)
 			continue
@@ -49,8 +66,12 @@ func BuildClusterConfig(
 			hostname = n.Name
 		}
 		clusterConfig.Nodes = append(clusterConfig.Nodes, config.Node{
-			Hostname:   hostname,
-			InternalIP: ip,
+			Hostname:    hostname,
+			InternalIP:  ip,
+			ExternalIP:  externalIP,
+			ExternalDNS: externalDNS,
+			Labels:      n.Labels,
+			Zone:        n.Labels[corev1.LabelTopologyZone],
 		})
 		nodeNames.Add(hostname)
 	}
@@ -59,14 +80,35 @@ func BuildClusterConfig(
 		if p.Status.Phase != corev1.PodRunning || !nodeNames.Contains(p.Spec.NodeName) {
 			continue
 		}
+		if p.Status.PodIP == "" || !IsPodReady(p) {
+			// still initializing, e.g. right after a rollout
+			log.Debugf("ignore agent pod %s: not ready", p.Name)
+			continue
+		}
 		clusterConfig.PodEndpoints = append(clusterConfig.PodEndpoints, config.PodEndpoint{
 			Nodename: p.Spec.NodeName,
 			Podname:  p.Name,
 			PodIP:    p.Status.PodIP,
 			Port:     common.PodNetPodHTTPPort,
+			PodUID:   string(p.UID),
 		})
 	}
 
+	probed := common.StringSet{}
+	for _, ps := range services {
+		ep, err := serviceEndpointOf(ps)
+		if err != nil {
+			log.Infof("ignore service: %s", err)
+			continue
+		}
+		if probed.Contains(ep.DestHost()) {
+			log.Infof("ignore port %d of service %s/%s: only a single port is probed per service", ep.Port, ep.Namespace, ep.Name)
+			continue
+		}
+		probed.Add(ep.DestHost())
+		clusterConfig.Services = append(clusterConfig.Services, ep)
+	}
+
 	sort.Slice(clusterConfig.Nodes, func(i, j int) bool {
 		return strings.Compare(clusterConfig.Nodes[i].Hostname, clusterConfig.Nodes[j].Hostname) < 0
 	})
@@ -78,10 +120,91 @@ func BuildClusterConfig(
 		return cmp < 0
 	})
 
+	sort.Slice(clusterConfig.Services, func(i, j int) bool {
+		return strings.Compare(clusterConfig.Services[i].DestHost(), clusterConfig.Services[j].DestHost()) < 0
+	})
+
 	clusterConfig.NodeCount = len(clusterConfig.Nodes)
 	return clusterConfig, nil
 }
 
+// serviceEndpointOf returns the cluster IP and the selected TCP port of the service.
+func serviceEndpointOf(ps ProbedService) (config.ServiceEndpoint, error) {
+	svc := ps.Service
+	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		return config.ServiceEndpoint{}, fmt.Errorf("service %s/%s has no cluster IP", svc.Namespace, svc.Name)
+	}
+	for _, port := range svc.Spec.Ports {
+		if port.Protocol != corev1.ProtocolTCP {
+			continue
+		}
+		if ps.Port == "" || ps.Port == port.Name || ps.Port == strconv.Itoa(int(port.Port)) {
+			return config.ServiceEndpoint{
+				Namespace: svc.Namespace,
+				Name:      svc.Name,
+				ClusterIP: svc.Spec.ClusterIP,
+				Port:      int(port.Port),
+			}, nil
+		}
+	}
+	if ps.Port == "" {
+		return config.ServiceEndpoint{}, fmt.Errorf("service %s/%s has no TCP port", svc.Namespace, svc.Name)
+	}
+	return config.ServiceEndpoint{}, fmt.Errorf("service %s/%s has no TCP port %s", svc.Namespace, svc.Name, ps.Port)
+}
+
+// ParseServiceRef parses a service reference in format `<namespace>/<name>[:<port>]`, where the port is the name or number of
+// a port of the service.
+func ParseServiceRef(value string) (namespace, name, port string, err error) {
+	ref, port, _ := strings.Cut(value, ":")
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") || (strings.Contains(value, ":") && port == "") {
+		return "", "", "", fmt.Errorf("invalid service %s, expected format <namespace>/<name>[:<port>]", value)
+	}
+	return namespace, name, port, nil
+}
+
+// selectAddress returns the same address independent of the order of the addresses, or an empty string if there is none.
+// IPv4 addresses are preferred over IPv6 addresses, otherwise the lowest address is selected.
+func selectAddress(addresses []string) string {
+	selected := ""
+	for _, addr := range addresses {
+		if selected == "" || compareAddresses(addr, selected) < 0 {
+			selected = addr
+		}
+	}
+	return selected
+}
+
+// compareAddresses orders IPv4 before IPv6 addresses before other values like DNS names, and lexically otherwise.
+func compareAddresses(a, b string) int {
+	rank := func(s string) int {
+		ip := net.ParseIP(s)
+		switch {
+		case ip == nil:
+			return 2
+		case ip.To4() != nil:
+			return 0
+		default:
+			return 1
+		}
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	return strings.Compare(a, b)
+}
+
+// IsPodReady returns true if the pod has the condition `Ready` with status `True`.
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func GetAPIServerEndpointFromShootInfo(shootInfo *corev1.ConfigMap) (*config.Endpoint, error) {
 	domain, ok := shootInfo.Data["domain"]
 	if !ok {
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package deploy
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("BuildClusterConfig", func() {
+	newNode := func(name string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+			},
+		}
+	}
+
+	It("carries the zone of the nodes", func() {
+		nodes := []*corev1.Node{
+			newNode("node1", map[string]string{corev1.LabelTopologyZone: "zone-a"}),
+			newNode("node2", nil),
+		}
+		clusterConfig, err := BuildClusterConfig(logrus.NewEntry(logrus.StandardLogger()), nodes, nil, nil, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(clusterConfig.Nodes).To(HaveLen(2))
+		Expect(clusterConfig.Nodes[0].Zone).To(Equal("zone-a"))
+		Expect(clusterConfig.Nodes[1].Zone).To(BeEmpty())
+		Expect(clusterConfig.NodeZones()).To(Equal(map[string]string{"node1": "zone-a", "node2": config.UnknownZone}))
+	})
+
+	It("skips agent pods which are not ready", func() {
+		newPod := func(name, ip string, ready corev1.ConditionStatus) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       corev1.PodSpec{NodeName: "node1"},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					PodIP:      ip,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+				},
+			}
+		}
+		pods := []*corev1.Pod{
+			newPod("ready", "10.128.0.1", corev1.ConditionTrue),
+			newPod("not-ready", "10.128.0.2", corev1.ConditionFalse),
+			newPod("no-ip", "", corev1.ConditionTrue),
+			{ObjectMeta: metav1.ObjectMeta{Name: "no-condition"}, Spec: corev1.PodSpec{NodeName: "node1"},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.128.0.3"}},
+		}
+		clusterConfig, err := BuildClusterConfig(logrus.NewEntry(logrus.StandardLogger()), []*corev1.Node{newNode("node1", nil)}, pods, nil, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(clusterConfig.PodEndpoints).To(HaveLen(1))
+		Expect(clusterConfig.PodEndpoints[0].Podname).To(Equal("ready"))
+	})
+
+	It("selects the internal IP independent of the order of the addresses", func() {
+		addresses := []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "fd00::1"},
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.5"},
+			{Type: corev1.NodeHostName, Address: "node1"},
+			{Type: corev1.NodeInternalIP, Address: "10.0.0.12"},
+			{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+			{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+		}
+		rnd := rand.New(rand.NewSource(1)) // #nosec G404 -- reproducible test data
+		for range 10 {
+			node := newNode("node1", nil)
+			node.Status.Addresses = slices.Clone(addresses)
+			rnd.Shuffle(len(node.Status.Addresses), func(i, j int) {
+				node.Status.Addresses[i], node.Status.Addresses[j] = node.Status.Addresses[j], node.Status.Addresses[i]
+			})
+			clusterConfig, err := BuildClusterConfig(logrus.NewEntry(logrus.StandardLogger()), []*corev1.Node{node}, nil, nil, nil, nil)
+			Expect(err).To(BeNil())
+			Expect(clusterConfig.Nodes).To(HaveLen(1))
+			Expect(clusterConfig.Nodes[0].InternalIP).To(Equal("10.0.0.12"), "addresses %v", node.Status.Addresses)
+			Expect(clusterConfig.Nodes[0].ExternalIP).To(Equal("1.2.3.4"))
+		}
+	})
+
+	It("prefers IPv4 addresses", func() {
+		Expect(selectAddress([]string{"fd00::1", "10.0.0.1"})).To(Equal("10.0.0.1"))
+		Expect(selectAddress([]string{"fd00::2", "fd00::1"})).To(Equal("fd00::1"))
+		Expect(selectAddress(nil)).To(BeEmpty())
+	})
+
+	It("collects the external addresses of the nodes", func() {
+		node1 := newNode("node1", nil)
+		node1.Status.Addresses = append(node1.Status.Addresses,
+			corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+			corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+			corev1.NodeAddress{Type: corev1.NodeExternalDNS, Address: "node1.example.com"},
+		)
+		nodes := []*corev1.Node{node1, newNode("node2", nil)}
+		clusterConfig, err := BuildClusterConfig(logrus.NewEntry(logrus.StandardLogger()), nodes, nil, nil, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(clusterConfig.Nodes).To(HaveLen(2))
+		Expect(clusterConfig.Nodes[0].ExternalIP).To(Equal("1.2.3.4"))
+		Expect(clusterConfig.Nodes[0].ExternalDNS).To(Equal("node1.example.com"))
+		Expect(clusterConfig.Nodes[1].ExternalIP).To(BeEmpty())
+		Expect(clusterConfig.Nodes[1].ExternalDNS).To(BeEmpty())
+	})
+
+	It("includes the selected ports of the probed services", func() {
+		newService := func(namespace, name, clusterIP string, ports ...corev1.ServicePort) *corev1.Service {
+			return &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+				Spec:       corev1.ServiceSpec{ClusterIP: clusterIP, Ports: ports},
+			}
+		}
+		dns := corev1.ServicePort{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53}
+		dnsTCP := corev1.ServicePort{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53}
+		metrics := corev1.ServicePort{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9153}
+		services := []ProbedService{
+			{Service: newService("kube-system", "kube-dns", "100.64.0.10", dns, dnsTCP, metrics), Port: "metrics"},
+			{Service: newService("default", "web", "100.64.1.1", metrics)},
+			{Service: newService("default", "web", "100.64.1.1", metrics), Port: "9153"},
+			{Service: newService("default", "headless", corev1.ClusterIPNone, metrics)},
+			{Service: newService("default", "udp-only", "100.64.1.2", dns)},
+			{Service: newService("default", "other-port", "100.64.1.3", metrics), Port: "8080"},
+		}
+		clusterConfig, err := BuildClusterConfig(logrus.NewEntry(logrus.StandardLogger()), nil, nil, nil, nil, services)
+		Expect(err).To(BeNil())
+		Expect(clusterConfig.Services).To(Equal([]config.ServiceEndpoint{
+			{Namespace: "kube-system", Name: "kube-dns", ClusterIP: "100.64.0.10", Port: 9153},
+			{Namespace: "default", Name: "web", ClusterIP: "100.64.1.1", Port: 9153},
+		}))
+		Expect(clusterConfig.Services[0].DestHost()).To(Equal("kube-dns.kube-system.svc"))
+	})
+
+	It("parses service references", func() {
+		namespace, name, port, err := ParseServiceRef("kube-system/kube-dns:dns-tcp")
+		Expect(err).To(BeNil())
+		Expect([]string{namespace, name, port}).To(Equal([]string{"kube-system", "kube-dns", "dns-tcp"}))
+		_, _, port, err = ParseServiceRef("default/web")
+		Expect(err).To(BeNil())
+		Expect(port).To(BeEmpty())
+		for _, invalid := range []string{"web", "/web", "default/", "default/web:", "a/b/c"} {
+			_, _, _, err = ParseServiceRef(invalid)
+			Expect(err).To(MatchError(ContainSubstring("invalid service")), invalid)
+		}
+	})
+})
@@ -16,6 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/gardener/network-problem-detector/pkg/agent"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
 	"github.com/gardener/network-problem-detector/pkg/common"
 	"github.com/gardener/network-problem-detector/pkg/common/config"
 )
@@ -23,7 +25,11 @@ import (
 type deployCommand struct {
 	common.ClientsetBase
 	delete            bool
+	scalingPolicyFile string
 	agentDeployConfig AgentDeployConfig
+	standaloneConfig  StandaloneConfig
+	outputDir         string
+	network           string
 }
 
 func CreateDeployCmd(imageTag string) *cobra.Command {
@@ -44,6 +50,7 @@ func CreateDeployCmd(imageTag string) *cobra.Command {
 		RunE:    dc.deployAgentAllDaemonsets,
 	}
 	agentCmd.Flags().BoolVar(&dc.delete, "delete", false, "if true, the daemonsets are deleted.")
+	agentCmd.Flags().StringVar(&dc.scalingPolicyFile, "scaling-policy", "", "file with scaling policy for job periods and destination sampling depending on the number of nodes.")
 
 	controllerCmd := &cobra.Command{
 		Use:     "controller",
@@ -60,17 +67,62 @@ func CreateDeployCmd(imageTag string) *cobra.Command {
 		Short:   "prints default configuration for nwpd-agent daemon sets.",
 		RunE:    dc.printDefaultConfig,
 	}
+	printConfigCmd.Flags().StringVar(&dc.network, "network", "", "if set to 'host' or 'pod', the aggregation settings of the daemon set in this network are resolved and shown in its network section.")
+
+	renderCmd := &cobra.Command{
+		Use:   "render",
+		Short: "renders files for installations outside of Kubernetes",
+	}
+	systemdUnitCmd := &cobra.Command{
+		Use:   "systemd-unit",
+		Short: "renders systemd unit, configuration, and directory layout of a standalone agent",
+		Long: `renders the systemd unit, the agent and cluster configuration with checks of external targets only, and the directory layout of a standalone agent.
+Without '--output-dir', the files are printed only.`,
+		Args: cobra.NoArgs,
+		RunE: dc.renderSystemdUnit,
+	}
+	dc.standaloneConfig.AddFlags(systemdUnitCmd.Flags())
+	systemdUnitCmd.Flags().StringVar(&dc.outputDir, "output-dir", "", "root directory to write the files to (e.g. '/' or a staging directory for packaging).")
+	renderCmd.AddCommand(systemdUnitCmd)
 
 	cmd.AddCommand(agentCmd)
 	cmd.AddCommand(controllerCmd)
 	cmd.AddCommand(printConfigCmd)
+	cmd.AddCommand(renderCmd)
 	return cmd
 }
 
+func (dc *deployCommand) renderSystemdUnit(_ *cobra.Command, _ []string) error {
+	files, err := dc.standaloneConfig.RenderStandaloneInstall()
+	if err != nil {
+		return err
+	}
+	if dc.outputDir != "" {
+		if err := WriteStandaloneFiles(dc.outputDir, files); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			fmt.Printf("# directory %s (mode %o)\n", f.Path, f.Mode)
+			continue
+		}
+		fmt.Printf("# file %s (mode %o)\n%s\n", f.Path, f.Mode, f.Content)
+	}
+	return nil
+}
+
 func (dc *deployCommand) setup() error {
 	if err := dc.SetupClientSet(); err != nil {
 		return err
 	}
+	if dc.scalingPolicyFile != "" {
+		policy, err := LoadScalingPolicy(dc.scalingPolicyFile)
+		if err != nil {
+			return err
+		}
+		dc.agentDeployConfig.ScalingPolicy = policy
+	}
 	return nil
 }
 
@@ -84,6 +136,11 @@ func (dc *deployCommand) printDefaultConfig(_ *cobra.Command, _ []string) error
 	if err != nil {
 		return err
 	}
+	if dc.network != "" {
+		if err := resolveAggregationConfig(cfg, dc.network); err != nil {
+			return err
+		}
+	}
 
 	data, err := json.MarshalIndent(cfg, "", "    ")
 	if err != nil {
@@ -93,6 +150,28 @@ func (dc *deployCommand) printDefaultConfig(_ *cobra.Command, _ []string) error
 	return nil
 }
 
+// resolveAggregationConfig sets the effective aggregation settings of the daemon set in the given network
+// in its network section.
+func resolveAggregationConfig(cfg *config.AgentConfig, network string) error {
+	var networkCfg *config.NetworkConfig
+	switch network {
+	case "host":
+		networkCfg = cfg.HostNetwork
+	case "pod":
+		networkCfg = cfg.PodNetwork
+	default:
+		return fmt.Errorf("invalid network: %s (allowed 'host', 'pod')", network)
+	}
+	aggrCfg, err := agent.EffectiveAggregationConfig(cfg, network == "host")
+	if err != nil {
+		return err
+	}
+	if networkCfg != nil {
+		networkCfg.AggregationConfig = *aggrCfg
+	}
+	return nil
+}
+
 func (dc *deployCommand) deployAgentAllDaemonsets(_ *cobra.Command, _ []string) error {
 	log := logrus.WithField("cmd", "deploy-agent")
 	err := dc.deployAgent(log, false, dc.buildAgentConfigMap, dc.buildClusterConfigMap)
@@ -164,9 +243,13 @@ func (dc *deployCommand) deployAgent(log logrus.FieldLogger, hostnetwork bool,
 		return fmt.Errorf("error building config map: %s", err)
 	}
 
+	ac.SecretRefs, err = secretRefsOf(acm)
+	if err != nil {
+		return err
+	}
 	var serviceAccountName string
 	var objects []Object
-	serviceAccountName, objects, err = dc.agentDeployConfig.buildSecurityObjects()
+	serviceAccountName, objects, err = ac.buildSecurityObjects()
 	if err != nil {
 		return err
 	}
@@ -227,7 +310,16 @@ func (dc *deployCommand) deleteDaemonSet(log logrus.FieldLogger, name string) er
 
 func (dc *deployCommand) deleteSecurityObjects(log logrus.FieldLogger) error {
 	ctx := context.Background()
-	_, objects, err := dc.agentDeployConfig.buildSecurityObjects()
+	acm, err := dc.buildAgentConfigMap(log)
+	if err != nil {
+		return err
+	}
+	ac := dc.agentDeployConfig
+	ac.SecretRefs, err = secretRefsOf(acm)
+	if err != nil {
+		return err
+	}
+	_, objects, err := ac.buildSecurityObjects()
 	if err != nil {
 		return err
 	}
@@ -239,14 +331,36 @@ func (dc *deployCommand) deleteSecurityObjects(log logrus.FieldLogger) error {
 	return nil
 }
 
-func (dc *deployCommand) buildAgentConfigMap(_ logrus.FieldLogger) (*corev1.ConfigMap, error) {
+func (dc *deployCommand) buildAgentConfigMap(log logrus.FieldLogger) (*corev1.ConfigMap, error) {
 	agentConfig, err := dc.agentDeployConfig.BuildAgentConfig()
 	if err != nil {
 		return nil, err
 	}
+	if agentConfig.ScalingPolicy != nil {
+		nodes, err := dc.nodes()
+		if err != nil {
+			return nil, err
+		}
+		clusterConfig, err := BuildClusterConfig(log, nodes, nil, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := runners.ApplyScalingPolicy(agentConfig, clusterConfig.NodeCount); err != nil {
+			return nil, err
+		}
+	}
 	return BuildAgentConfigMap(agentConfig)
 }
 
+// secretRefsOf returns the secrets referenced by the agent configuration of the config map.
+func secretRefsOf(acm *corev1.ConfigMap) ([]config.SecretRef, error) {
+	agentConfig, err := config.ParseAgentConfig([]byte(acm.Data[common.AgentConfigFilename]))
+	if err != nil {
+		return nil, err
+	}
+	return agentConfig.SecretRefs(), nil
+}
+
 func (dc *deployCommand) buildClusterConfigMap(log logrus.FieldLogger) (*corev1.ConfigMap, error) {
 	ctx := context.Background()
 	svc, err := dc.Clientset.CoreV1().Services(common.NamespaceDefault).Get(ctx, common.NameKubernetesService, metav1.GetOptions{})
@@ -278,13 +392,39 @@ func (dc *deployCommand) buildClusterConfigMap(log logrus.FieldLogger) (*corev1.
 		return nil, err
 	}
 
-	clusterConfig, err := BuildClusterConfig(log, nodes, agentPods, internalAPIServer, apiServer)
+	services, err := dc.probedServices()
+	if err != nil {
+		return nil, err
+	}
+
+	clusterConfig, err := BuildClusterConfig(log, nodes, agentPods, internalAPIServer, apiServer, services)
 	if err != nil {
 		return nil, err
 	}
+	if dc.agentDeployConfig.PacketTrainEnabled {
+		clusterConfig.PacketTrainPort = common.PacketTrainPort
+	}
 	return BuildClusterConfigMap(clusterConfig)
 }
 
+// probedServices returns the services selected for probing with their ports.
+func (dc *deployCommand) probedServices() ([]ProbedService, error) {
+	ctx := context.Background()
+	var services []ProbedService
+	for _, ref := range dc.agentDeployConfig.ProbeServices {
+		namespace, name, port, err := ParseServiceRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		svc, err := dc.Clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting service %s/%s: %w", namespace, name, err)
+		}
+		services = append(services, ProbedService{Service: svc, Port: port})
+	}
+	return services, nil
+}
+
 func (dc *deployCommand) nodes() ([]*corev1.Node, error) {
 	ctx := context.Background()
 	nodeList, err := dc.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
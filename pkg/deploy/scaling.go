@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package deploy
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadScalingPolicy loads a scaling policy from a YAML or JSON file.
+func LoadScalingPolicy(filename string) (*config.ScalingPolicy, error) {
+	data, err := os.ReadFile(filename) // #nosec G304 -- file provided by user
+	if err != nil {
+		return nil, err
+	}
+	policy := &config.ScalingPolicy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("unmarshalling scaling policy %s failed: %s", filename, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// RescaleAgentConfigMap renders the scaling policy of the agent config map again, if the number of nodes
+// has crossed a threshold of the policy since it was rendered last. It returns true if the config map has been modified.
+func RescaleAgentConfigMap(cm *corev1.ConfigMap, nodeCount int) (bool, error) {
+	cfg, err := config.ParseAgentConfig([]byte(cm.Data[common.AgentConfigFilename]))
+	if err != nil {
+		return false, err
+	}
+	if !runners.NeedsRescaling(cfg, nodeCount) {
+		return false, nil
+	}
+	if err := runners.ApplyScalingPolicy(cfg, nodeCount); err != nil {
+		return false, err
+	}
+	cfgBytes, err := yaml.Marshal(cfg)
+	if err != nil {
+		return false, err
+	}
+	cm.Data[common.AgentConfigFilename] = string(cfgBytes)
+	return true, nil
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package deploy_test
+
+import (
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/deploy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("scaling policy", func() {
+	var cm *corev1.ConfigMap
+
+	BeforeEach(func() {
+		deployConfig := &deploy.AgentDeployConfig{
+			DefaultPeriod:           10 * time.Second,
+			PingEnabled:             true,
+			IgnoreAPIServerEndpoint: true,
+			ScalingPolicy: &config.ScalingPolicy{
+				Rules: []config.ScalingRule{
+					{AboveNodes: 200, JobTypes: []string{"pingHost"}, PeriodMultiplier: 2, SampleRatio: 0.5},
+				},
+			},
+		}
+		cfg, err := deployConfig.BuildAgentConfig()
+		Expect(err).To(BeNil())
+		Expect(runners.ApplyScalingPolicy(cfg, 50)).To(Succeed())
+		cm, err = deploy.BuildAgentConfigMap(cfg)
+		Expect(err).To(BeNil())
+	})
+
+	pingArgs := func() []string {
+		cfg, err := config.ParseAgentConfig([]byte(cm.Data[common.AgentConfigFilename]))
+		Expect(err).To(BeNil())
+		for _, job := range cfg.PodNetwork.Jobs {
+			if job.Args[0] == "pingHost" {
+				return job.Args
+			}
+		}
+		Fail("missing ping job")
+		return nil
+	}
+
+	It("regenerates the agent config if the node count crosses a threshold", func() {
+		original := pingArgs()
+
+		rescaled, err := deploy.RescaleAgentConfigMap(cm, 150)
+		Expect(err).To(BeNil())
+		Expect(rescaled).To(BeFalse())
+
+		rescaled, err = deploy.RescaleAgentConfigMap(cm, 300)
+		Expect(err).To(BeNil())
+		Expect(rescaled).To(BeTrue())
+		Expect(pingArgs()).To(Equal(append(append([]string{}, original...), "--period", "20s", "--max-peers", "150")))
+
+		// same level, the generated config is kept
+		rescaled, err = deploy.RescaleAgentConfigMap(cm, 400)
+		Expect(err).To(BeNil())
+		Expect(rescaled).To(BeFalse())
+
+		rescaled, err = deploy.RescaleAgentConfigMap(cm, 200)
+		Expect(err).To(BeNil())
+		Expect(rescaled).To(BeTrue())
+		Expect(pingArgs()).To(Equal(original))
+	})
+
+	It("ignores agent configs without policy", func() {
+		cfg, err := (&deploy.AgentDeployConfig{DefaultPeriod: 10 * time.Second, IgnoreAPIServerEndpoint: true}).BuildAgentConfig()
+		Expect(err).To(BeNil())
+		cm, err = deploy.BuildAgentConfigMap(cfg)
+		Expect(err).To(BeNil())
+		rescaled, err := deploy.RescaleAgentConfigMap(cm, 1000)
+		Expect(err).To(BeNil())
+		Expect(rescaled).To(BeFalse())
+	})
+})
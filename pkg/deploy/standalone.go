@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+)
+
+const (
+	// NameSystemdUnit is the name of the systemd unit of a standalone agent.
+	NameSystemdUnit = "nwpd-agent.service"
+	// PathSystemdUnitDir is the directory of the systemd unit files.
+	PathSystemdUnitDir = "/etc/systemd/system"
+)
+
+// StandaloneConfig is the configuration for rendering a standalone installation of the agent outside of Kubernetes.
+type StandaloneConfig struct {
+	// BinaryPath is the path of the installed `nwpdcli` binary.
+	BinaryPath string
+	// ConfigDir is the directory of the agent and the cluster configuration.
+	ConfigDir string
+	// HTTPPort is the port of the metrics http server.
+	HTTPPort int
+	// DefaultPeriod is the default period of the jobs.
+	DefaultPeriod time.Duration
+	// HTTPSEndpoints are the external endpoints checked with HTTPS GET requests in format <hostname>[:<port>].
+	HTTPSEndpoints []string
+	// DNSNames are the external DNS names checked with lookups.
+	DNSNames []string
+}
+
+// AddFlags adds the flags of the standalone configuration.
+func (sc *StandaloneConfig) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&sc.BinaryPath, "binary", "/usr/local/bin/nwpdcli", "path of the installed nwpdcli binary.")
+	flags.StringVar(&sc.ConfigDir, "config-dir", "/etc/nwpd", "directory of the agent and cluster configuration.")
+	flags.IntVar(&sc.HTTPPort, "http-port", common.HostNetPodHTTPPort, "port of the metrics http server (0 to disable).")
+	flags.DurationVar(&sc.DefaultPeriod, "default-period", 10*time.Second, "default period for jobs.")
+	flags.StringSliceVar(&sc.HTTPSEndpoints, "https-endpoints", nil, "external endpoints in format <hostname>[:<port>] checked with HTTPS GET requests.")
+	flags.StringSliceVar(&sc.DNSNames, "dns-names", []string{"europe-docker.pkg.dev."}, "external DNS names checked with lookups.")
+}
+
+// StandaloneFile is a file or directory of a standalone installation.
+type StandaloneFile struct {
+	// Path is the absolute path of the file or directory.
+	Path string
+	// Mode is the file mode.
+	Mode os.FileMode
+	// Content is the file content, nil for a directory.
+	Content []byte
+}
+
+// IsDir returns true if the entry is a directory.
+func (f StandaloneFile) IsDir() bool {
+	return f.Content == nil
+}
+
+var systemdUnitTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description=Network Problem Detector agent (standalone)
+Documentation=https://github.com/gardener/network-problem-detector
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart={{ .BinaryPath }} run-agent --environment {{ .Environment }} --hostNetwork --config {{ .AgentConfig }} --cluster-config {{ .ClusterConfig }}
+Restart=always
+RestartSec=5s
+DynamicUser=yes
+# observations, rollups and reports are written to {{ .LogDir }}
+LogsDirectory={{ .LogDirName }}
+# needed for the pingHost job
+AmbientCapabilities=CAP_NET_RAW
+CapabilityBoundingSet=CAP_NET_RAW
+NoNewPrivileges=yes
+ProtectSystem=strict
+ProtectHome=yes
+PrivateTmp=yes
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// Validate validates the standalone configuration.
+func (sc *StandaloneConfig) Validate() error {
+	if !filepath.IsAbs(sc.BinaryPath) {
+		return fmt.Errorf("invalid binary path %q, must be absolute", sc.BinaryPath)
+	}
+	if !filepath.IsAbs(sc.ConfigDir) {
+		return fmt.Errorf("invalid config dir %q, must be absolute", sc.ConfigDir)
+	}
+	if sc.HTTPPort < 0 || sc.HTTPPort > 65535 {
+		return fmt.Errorf("invalid http port %d", sc.HTTPPort)
+	}
+	if sc.DefaultPeriod < time.Second {
+		return fmt.Errorf("invalid default period %s, must be >= 1s", sc.DefaultPeriod)
+	}
+	return nil
+}
+
+// BuildStandaloneAgentConfig builds the agent configuration of a standalone agent with checks of external targets only.
+func (sc *StandaloneConfig) BuildStandaloneAgentConfig() *config.AgentConfig {
+	networkCfg := &config.NetworkConfig{
+		DataFilePrefix: "nwpd-agent",
+		HTTPPort:       sc.HTTPPort,
+		DefaultPeriod:  metav1.Duration{Duration: sc.DefaultPeriod},
+	}
+	if len(sc.DNSNames) > 0 {
+		networkCfg.Jobs = append(networkCfg.Jobs, config.Job{
+			JobID: "nslookup-ext",
+			Args:  append([]string{"nslookup", "--names"}, sc.DNSNames...),
+		})
+	}
+	if len(sc.HTTPSEndpoints) > 0 {
+		networkCfg.Jobs = append(networkCfg.Jobs, config.Job{
+			JobID: "https-ext",
+			Args:  append([]string{"checkHTTPSGet", "--endpoints"}, sc.HTTPSEndpoints...),
+		})
+	}
+	return &config.AgentConfig{
+		Environment:    config.EnvironmentStandalone,
+		OutputDir:      common.PathOutputDir,
+		RetentionHours: 24,
+		HostNetwork:    networkCfg,
+	}
+}
+
+// RenderStandaloneInstall renders the systemd unit, the configuration files, and the directory layout of a standalone agent.
+func (sc *StandaloneConfig) RenderStandaloneInstall() ([]StandaloneFile, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	agentConfigFile := filepath.Join(sc.ConfigDir, common.AgentConfigFilename)
+	clusterConfigFile := filepath.Join(sc.ConfigDir, common.ClusterConfigFilename)
+
+	agentConfig, err := yaml.Marshal(sc.BuildStandaloneAgentConfig())
+	if err != nil {
+		return nil, err
+	}
+	// the cluster configuration is static, as there are no nodes and pods to discover
+	clusterConfig, err := yaml.Marshal(&config.ClusterConfig{})
+	if err != nil {
+		return nil, err
+	}
+	unit := &bytes.Buffer{}
+	err = systemdUnitTemplate.Execute(unit, map[string]string{
+		"BinaryPath":    sc.BinaryPath,
+		"Environment":   config.EnvironmentStandalone,
+		"AgentConfig":   agentConfigFile,
+		"ClusterConfig": clusterConfigFile,
+		"LogDir":        common.PathLogDir,
+		"LogDirName":    filepath.Base(common.PathLogDir),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []StandaloneFile{
+		{Path: sc.ConfigDir, Mode: 0o755},
+		{Path: agentConfigFile, Mode: 0o644, Content: agentConfig},
+		{Path: clusterConfigFile, Mode: 0o644, Content: clusterConfig},
+		{Path: common.PathLogDir, Mode: 0o750},
+		{Path: filepath.Join(PathSystemdUnitDir, NameSystemdUnit), Mode: 0o644, Content: unit.Bytes()},
+	}, nil
+}
+
+// WriteStandaloneFiles writes the files below the given root directory (e.g. `/` or a staging directory for packaging).
+func WriteStandaloneFiles(root string, files []StandaloneFile) error {
+	for _, f := range files {
+		path := filepath.Join(root, f.Path)
+		if f.IsDir() {
+			if err := os.MkdirAll(path, f.Mode); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { // #nosec G301 -- standard directories
+			return err
+		}
+		if err := os.WriteFile(path, f.Content, f.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
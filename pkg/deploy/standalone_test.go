@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package deploy_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/config"
+	"github.com/gardener/network-problem-detector/pkg/deploy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("standalone install", func() {
+	var sc *deploy.StandaloneConfig
+
+	BeforeEach(func() {
+		sc = &deploy.StandaloneConfig{
+			BinaryPath:     "/usr/local/bin/nwpdcli",
+			ConfigDir:      "/etc/nwpd",
+			HTTPPort:       12996,
+			DefaultPeriod:  10 * time.Second,
+			HTTPSEndpoints: []string{"example.com:8443"},
+			DNSNames:       []string{"example.com."},
+		}
+	})
+
+	It("renders the systemd unit and the configuration", func() {
+		files, err := sc.RenderStandaloneInstall()
+		Expect(err).To(BeNil())
+		contents := map[string]string{}
+		for _, f := range files {
+			contents[f.Path] = string(f.Content)
+		}
+		Expect(contents).To(HaveKey("/etc/nwpd"))
+		Expect(contents).To(HaveKey("/var/log/nwpd"))
+		Expect(contents["/etc/systemd/system/nwpd-agent.service"]).To(ContainSubstring(
+			"ExecStart=/usr/local/bin/nwpdcli run-agent --environment standalone --hostNetwork --config /etc/nwpd/agent-config.yaml --cluster-config /etc/nwpd/cluster-config.yaml\n"))
+
+		agentConfig, err := config.ParseAgentConfig([]byte(contents["/etc/nwpd/agent-config.yaml"]))
+		Expect(err).To(BeNil())
+		Expect(agentConfig.Environment).To(Equal(config.EnvironmentStandalone))
+		Expect(agentConfig.PodNetwork).To(BeNil())
+		Expect(agentConfig.HostNetwork.HTTPPort).To(Equal(12996))
+		Expect(agentConfig.HostNetwork.Jobs).To(HaveLen(2))
+		Expect(agentConfig.HostNetwork.Jobs[1].Args).To(Equal([]string{"checkHTTPSGet", "--endpoints", "example.com:8443"}))
+
+		clusterConfig, err := config.ParseClusterConfig([]byte(contents["/etc/nwpd/cluster-config.yaml"]))
+		Expect(err).To(BeNil())
+		Expect(clusterConfig.Nodes).To(BeEmpty())
+	})
+
+	It("writes the files below the root directory", func() {
+		files, err := sc.RenderStandaloneInstall()
+		Expect(err).To(BeNil())
+		root := GinkgoT().TempDir()
+		Expect(deploy.WriteStandaloneFiles(root, files)).To(Succeed())
+		Expect(filepath.Join(root, "/etc/systemd/system/nwpd-agent.service")).To(BeARegularFile())
+		Expect(filepath.Join(root, "/etc/nwpd/agent-config.yaml")).To(BeARegularFile())
+		info, err := os.Stat(filepath.Join(root, "/var/log/nwpd"))
+		Expect(err).To(BeNil())
+		Expect(info.IsDir()).To(BeTrue())
+	})
+
+	It("rejects relative paths", func() {
+		sc.BinaryPath = "nwpdcli"
+		_, err := sc.RenderStandaloneInstall()
+		Expect(err).To(MatchError(ContainSubstring("invalid binary path")))
+	})
+})
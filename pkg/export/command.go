@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/runners"
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/filter"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type exportCommand struct {
+	kubeconfig string
+	targetPort int
+	tls        agentclient.TLSOptions
+	token      agentclient.TokenOptions
+	since      time.Duration
+	start      string
+	end        string
+	format     string
+	directory  string
+	limit      int
+	jobIDs     []string
+	srcHosts   []string
+	destHosts  []string
+	labels     map[string]string
+	fields     map[string]string
+	filter     string
+	failedOnly bool
+	output     string
+}
+
+func CreateExportCmd() *cobra.Command {
+	ec := &exportCommand{}
+	cmd := &cobra.Command{
+		Use:   "export [<podname>]",
+		Short: "export observations of an agent as newline-delimited JSON or CSV",
+		Long: `export observations from an agent using 'kubectl port-forward' and HTTP or from the record files in the input directory
+(either downloaded with collect or directly on the node)`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: ec.export,
+	}
+	cmd.Flags().StringVar(&ec.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified.")
+	cmd.Flags().IntVar(&ec.targetPort, "targetPort", 0, "target pod port")
+	ec.tls.AddFlags(cmd.Flags())
+	ec.token.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&ec.directory, "input", "", "database directory to export the stored observations from instead of an agent pod.")
+	cmd.Flags().StringVar(&ec.format, "format", db.ExportFormatJSON, "output format ("+strings.Join(db.ExportFormats, ", ")+")")
+	cmd.Flags().DurationVar(&ec.since, "since", 10*time.Minute, "export observations since given time period (0 for all stored observations).")
+	cmd.Flags().StringVar(&ec.start, "start", "", "start timestamp in RFC 3339 format (e.g. '2022-01-23T23:49:11Z'), overrides --since")
+	cmd.Flags().StringVar(&ec.end, "end", "", "end timestamp in RFC 3339 format (now if not specified)")
+	cmd.Flags().IntVar(&ec.limit, "limit", 10000, "maximum number of observations to export (0 for no limit).")
+	cmd.Flags().StringArrayVar(&ec.jobIDs, "job", nil, "jobID(s) to filter")
+	cmd.Flags().StringArrayVar(&ec.srcHosts, "src", nil, "source host(s) to filter")
+	cmd.Flags().StringArrayVar(&ec.destHosts, "dest", nil, "destination host(s) to filter")
+	cmd.Flags().StringToStringVar(&ec.labels, "label", nil, "job label(s) to filter in format <key>=<value>")
+	cmd.Flags().StringToStringVar(&ec.fields, "result-field", nil, "result field value(s) to filter in format <name>=<value>")
+	cmd.Flags().StringVar(&ec.filter, "filter", "", "filter expression evaluated on the agent, e.g. '!ok && destHost in 10.250.3.0/24 && duration > 2s' (fields: "+strings.Join(filter.Fields, ", ")+", labels.<name>, fields.<name>)")
+	cmd.Flags().BoolVar(&ec.failedOnly, "failed-only", false, "only failures")
+	cmd.Flags().StringVarP(&ec.output, "output", "o", "", "output file (stdout if not specified)")
+	return cmd
+}
+
+// timeRange returns the start and end of the exported observations. A zero time means no limit.
+func (ec *exportCommand) timeRange() (time.Time, time.Time, error) {
+	var start, end time.Time
+	if ec.since > 0 {
+		start = time.Now().Add(-ec.since)
+	}
+	if ec.start != "" {
+		t, err := time.Parse(time.RFC3339, ec.start)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid start: %s", err)
+		}
+		start = t
+	}
+	if ec.end != "" {
+		t, err := time.Parse(time.RFC3339, ec.end)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid end: %s", err)
+		}
+		end = t
+	}
+	if !end.IsZero() && !start.Before(end) {
+		return start, end, fmt.Errorf("start %s must be before end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return start, end, nil
+}
+
+func (ec *exportCommand) export(_ *cobra.Command, args []string) error {
+	log := logrus.WithField("cmd", "export")
+
+	var expr *filter.Expression
+	if ec.filter != "" {
+		var err error
+		if expr, err = filter.Parse(ec.filter); err != nil {
+			return fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+	if (len(args) == 0) == (ec.directory == "") {
+		return fmt.Errorf("either an agent pod or --input must be specified")
+	}
+	if _, err := db.NewEncoder(io.Discard, ec.format, nil); err != nil {
+		return err
+	}
+	start, end, err := ec.timeRange()
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if ec.output != "" {
+		f, err := os.Create(ec.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if ec.directory != "" {
+		options := db.ExportOptions{
+			ListObservationsOptions: nwpd.ListObservationsOptions{
+				Start:              start,
+				End:                end,
+				Limit:              ec.limit,
+				FilterJobIDs:       ec.jobIDs,
+				FilterSrcHosts:     ec.srcHosts,
+				FilterDestHosts:    ec.destHosts,
+				FilterLabels:       ec.labels,
+				FilterResultFields: ec.fields,
+				FailuresOnly:       ec.failedOnly,
+			},
+			Directory:    ec.directory,
+			ResultFields: runners.ResultFieldNames,
+			Log:          log,
+		}
+		if expr != nil {
+			options.Filter = expr.Match
+		}
+		n, err := db.Export(out, ec.format, options)
+		if err != nil {
+			return err
+		}
+		log.Infof("exported %d observations", n)
+		return nil
+	}
+	return ec.exportFromAgent(log, args[0], start, end, out)
+}
+
+// exportFromAgent streams the observations exported by the agent pod to out.
+func (ec *exportCommand) exportFromAgent(log logrus.FieldLogger, podname string, start, end time.Time, out io.Writer) error {
+	request := &nwpd.GetObservationsRequest{
+		Start:                  timestamppb.New(start),
+		Limit:                  int32(ec.limit), // #nosec G115 -- limit is small
+		RestrictToJobIDs:       ec.jobIDs,
+		RestrictToSrcHosts:     ec.srcHosts,
+		RestrictToDestHosts:    ec.destHosts,
+		RestrictToLabels:       ec.labels,
+		RestrictToResultFields: ec.fields,
+		Filter:                 ec.filter,
+		FailuresOnly:           ec.failedOnly,
+	}
+	if !end.IsZero() {
+		request.End = timestamppb.New(end)
+	}
+	body, err := protojson.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	pf, err := agentclient.StartPortForward(log, ec.kubeconfig, podname, ec.targetPort, &ec.tls, &ec.token)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	exportURL := pf.BaseURL() + common.PathExportObservations + "?format=" + url.QueryEscape(ec.format)
+	resp, err := pf.HTTPClient().Post(exportURL, "application/json", bytes.NewReader(body)) // #nosec G107 -- local port forward
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+		return fmt.Errorf("export failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	n, err := io.Copy(out, resp.Body)
+	if err != nil {
+		return err
+	}
+	log.Infof("exported %d bytes", n)
+	return nil
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type jobsCommand struct {
+	kubeconfig string
+	targetPort int
+	tls        agentclient.TLSOptions
+	token      agentclient.TokenOptions
+	agent      string
+}
+
+func CreateJobsCmd() *cobra.Command {
+	jc := &jobsCommand{}
+	cmd := &cobra.Command{
+		Use:   "jobs --agent <podname>",
+		Short: "show the status of the jobs of an agent",
+		Long:  `show the parsed jobs of an agent with their last run using 'kubectl port-forward' and HTTP'`,
+		Args:  cobra.NoArgs,
+		RunE:  jc.jobs,
+	}
+	cmd.Flags().StringVar(&jc.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified.")
+	cmd.Flags().IntVar(&jc.targetPort, "targetPort", 0, "target pod port")
+	jc.tls.AddFlags(cmd.Flags())
+	jc.token.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&jc.agent, "agent", "", "name of the agent pod")
+	_ = cmd.MarkFlagRequired("agent")
+	return cmd
+}
+
+func (jc *jobsCommand) jobs(_ *cobra.Command, _ []string) error {
+	log := logrus.WithField("cmd", "jobs")
+
+	pf, err := agentclient.StartPortForward(log, jc.kubeconfig, jc.agent, jc.targetPort, &jc.tls, &jc.token)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	response, err := pf.Client().GetJobStatus(context.Background(), &nwpd.GetJobStatusRequest{})
+	if err != nil {
+		return err
+	}
+	if err := printJobStatus(os.Stdout, response.Jobs, time.Now()); err != nil {
+		return err
+	}
+	printEnvironment(os.Stdout, response)
+	printLocalBlock(os.Stdout, response.LocalBlock)
+	configStatus, err := pf.Client().GetConfigStatus(context.Background(), &nwpd.GetConfigStatusRequest{})
+	if err != nil {
+		return err
+	}
+	printConfigStatus(os.Stdout, configStatus, time.Now())
+	return nil
+}
+
+// printConfigStatus prints the revision of the applied configuration and the error of the last reload if it has failed.
+func printConfigStatus(out io.Writer, status *nwpd.GetConfigStatusResponse, now time.Time) {
+	applied := "-"
+	if status.LastApplied != nil {
+		applied = now.Sub(status.LastApplied.AsTime()).Truncate(time.Second).String() + " ago"
+	}
+	fmt.Fprintf(out, "config revision: %d (applied %s)\n", status.Revision, applied)
+	if status.LastReloadError != "" {
+		fmt.Fprintf(out, "config reload: FAILED %d times: %s\n", status.ConsecutiveReloadFailures, status.LastReloadError)
+	}
+}
+
+// printEnvironment prints the environment of the agent and the features disabled because of it.
+func printEnvironment(out io.Writer, response *nwpd.GetJobStatusResponse) {
+	if response.Environment == "" {
+		return
+	}
+	fmt.Fprintf(out, "environment: %s\n", response.Environment)
+	for _, feature := range response.DisabledFeatures {
+		fmt.Fprintf(out, "disabled: %s\n", feature)
+	}
+}
+
+// printLocalBlock prints the result of the last local block diagnosis if the detection is enabled.
+func printLocalBlock(out io.Writer, status *nwpd.LocalBlockStatus) {
+	switch {
+	case status == nil:
+		return
+	case status.LastDiagnosis == nil:
+		fmt.Fprintln(out, "local block: not diagnosed")
+	case status.Suspected:
+		fmt.Fprintf(out, "local block: SUSPECTED at %s: %s\n", status.LastDiagnosis.AsTime().Format(time.RFC3339), status.Reason)
+	default:
+		fmt.Fprintf(out, "local block: not found at %s: %s\n", status.LastDiagnosis.AsTime().Format(time.RFC3339), status.Reason)
+	}
+}
+
+func printJobStatus(out io.Writer, jobs []*nwpd.JobStatus, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOBID\tSTATUS\tPERIOD\tLAST RUN\tOK\tFAILED\tCONSECUTIVE FAILURES\tARGS")
+	for _, job := range jobs {
+		status := "scheduled"
+		switch {
+		case job.Disabled:
+			status = "disabled"
+		case job.Skipped && job.Degraded:
+			status = "degraded: " + job.SkipReason
+		case job.Skipped:
+			status = "skipped: " + job.SkipReason
+		case job.Running:
+			status = "running"
+		}
+		period, lastRun := "-", "-"
+		if job.Period != nil {
+			period = job.Period.AsDuration().String()
+		}
+		if job.LastRun != nil {
+			lastRun = now.Sub(job.LastRun.AsTime()).Truncate(time.Second).String() + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n", job.JobID, status, period, lastRun,
+			job.LastRunOk, job.LastRunFailed, job.ConsecutiveFailures, strings.Join(job.Args, " "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		switch {
+		case !job.Skipped && job.Degraded:
+			fmt.Fprintf(out, "job %s: degraded, running with previous secrets: %s\n", job.JobID, job.SkipReason)
+		case !job.Skipped && job.SkipReason != "":
+			fmt.Fprintf(out, "job %s: new configuration skipped: %s\n", job.JobID, job.SkipReason)
+		}
+		if job.LastFailure != "" {
+			fmt.Fprintf(out, "job %s: last failure: %s\n", job.JobID, job.LastFailure)
+		}
+		for _, b := range job.Backoffs {
+			delay := "probed again on next turn"
+			if d := b.Until.AsTime().Sub(now); d > 0 {
+				delay = "skipped for " + d.Truncate(time.Second).String()
+			}
+			fmt.Fprintf(out, "job %s: destination %s backed off after %d failures, %s\n", job.JobID, b.DestHost, b.Failures, delay)
+		}
+	}
+	return nil
+}
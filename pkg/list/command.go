@@ -5,18 +5,16 @@
 package list
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"net"
-	"net/http"
-	"os"
-	"os/exec"
+	"regexp"
+	"slices"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/filter"
 	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
 
 	"github.com/sirupsen/logrus"
@@ -26,34 +24,66 @@ import (
 )
 
 type listCommand struct {
-	kubeconfig string
-	targetPort int
-	since      time.Duration
-	limit      int
-	jobIDs     []string
-	srcHosts   []string
-	destHosts  []string
-	failedOnly bool
-	window     time.Duration
+	kubeconfig  string
+	targetPort  int
+	tls         agentclient.TLSOptions
+	token       agentclient.TokenOptions
+	since       time.Duration
+	limit       int
+	jobIDs      []string
+	srcHosts    []string
+	destHosts   []string
+	jobRegex    string
+	srcRegex    string
+	destRegex   string
+	resultRegex string
+	minDuration time.Duration
+	minStreak   int
+	labels      map[string]string
+	fields      map[string]string
+	filter      string
+	failedOnly  bool
+	window      time.Duration
+	noData      bool
+	by          string
+	pageToken   string
+	sortBy      string
+	descending  bool
 }
 
 func CreateListCmd() *cobra.Command {
 	lc := &listCommand{}
 	cmd := &cobra.Command{
-		Use:   "list (observation|obs|aggregated|aggr) <podname>",
+		Use:   "list (observation|obs|aggregated|aggr|failures) <podname>",
 		Short: "collect observations or aggregations from an agent",
 		Long:  `collect observations from an agent using 'kubectl port-forward' and HTTP'`,
 		RunE:  lc.list,
 	}
 	cmd.Flags().StringVar(&lc.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified.")
 	cmd.Flags().IntVar(&lc.targetPort, "targetPort", 0, "target pod port")
+	lc.tls.AddFlags(cmd.Flags())
+	lc.token.AddFlags(cmd.Flags())
 	cmd.Flags().DurationVar(&lc.since, "since", 10*time.Minute, "list observations since given time period.")
 	cmd.Flags().IntVar(&lc.limit, "limit", 10000, "maximum number of observations to retrieve.")
+	cmd.Flags().StringVar(&lc.pageToken, "page-token", "", "continue listing with the page token logged by the previous call (only for observations)")
+	cmd.Flags().StringVar(&lc.sortBy, "sort-by", nwpd.SortByTimestamp, "field to sort the observations by before applying the limit ("+strings.Join(nwpd.SortFields, ", ")+") (only for observations)")
+	cmd.Flags().BoolVar(&lc.descending, "desc", false, "sort in descending order, e.g. '--sort-by duration --desc --limit 20' for the slowest checks (only for observations)")
 	cmd.Flags().StringArrayVar(&lc.jobIDs, "job", nil, "jobID(s) to filter")
 	cmd.Flags().StringArrayVar(&lc.srcHosts, "src", nil, "sourc host(s) to filter")
 	cmd.Flags().StringArrayVar(&lc.destHosts, "dest", nil, "destination host(s) to filter")
+	cmd.Flags().StringVar(&lc.jobRegex, "job-regex", "", "regular expression for jobIDs to filter, e.g. '^tcp-n2'")
+	cmd.Flags().StringVar(&lc.srcRegex, "src-regex", "", "regular expression for source hosts to filter")
+	cmd.Flags().StringVar(&lc.destRegex, "dest-regex", "", "regular expression for destination hosts to filter, e.g. '^10\\.0\\.'")
+	cmd.Flags().StringVar(&lc.resultRegex, "result-regex", "", "regular expression for results to filter, e.g. 'i/o timeout' (matched against the result fields if the result has not been persisted)")
+	cmd.Flags().DurationVar(&lc.minDuration, "min-duration", 0, "only observations slower than this duration, e.g. '500ms'")
+	cmd.Flags().IntVar(&lc.minStreak, "failure-streak", 0, "only failures being part of a run of at least this number of consecutive failures of the same job and edge")
+	cmd.Flags().StringToStringVar(&lc.labels, "label", nil, "job label(s) to filter in format <key>=<value>")
+	cmd.Flags().StringToStringVar(&lc.fields, "result-field", nil, "result field value(s) to filter in format <name>=<value>")
+	cmd.Flags().StringVar(&lc.filter, "filter", "", "filter expression evaluated on the agent, e.g. '!ok && destHost in 10.250.3.0/24 && duration > 2s' (fields: "+strings.Join(filter.Fields, ", ")+", labels.<name>, fields.<name>)")
 	cmd.Flags().BoolVar(&lc.failedOnly, "failed-only", false, "only failures")
 	cmd.Flags().DurationVar(&lc.window, "window", 1*time.Minute, "aggregation window (only for aggregated observations)")
+	cmd.Flags().StringVar(&lc.by, "aggregate-by", nwpd.AggregateByHost, "grouping of the aggregated observations ("+strings.Join(nwpd.AggregateByFields, ", ")+"), 'host' for node pairs (only for aggregated observations)")
+	cmd.Flags().BoolVar(&lc.noData, "include-no-data", false, "include valid edges without observations (only for aggregated observations)")
 	return cmd
 }
 
@@ -64,71 +94,74 @@ func (lc *listCommand) list(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("missing kind or pod name: %s", strings.Join(args, " "))
 	}
 
-	var aggr bool
+	var aggr, failures bool
 	switch args[0] {
 	case "aggr", "aggregated":
 		aggr = true
 	case "obs", "observation":
 		aggr = false
+	case "failures":
+		failures = true
 	default:
-		return fmt.Errorf("invalid kind: %s (allowed 'observation', 'obs', 'aggregated', 'aggr')", args[0])
+		return fmt.Errorf("invalid kind: %s (allowed 'observation', 'obs', 'aggregated', 'aggr', 'failures')", args[0])
 	}
 
-	podname := args[1]
-	port := 18007
-	for !lc.checkPortAvailable(port) {
-		port++
-	}
-	targetPort := lc.targetPort
-	if targetPort == 0 {
-		if strings.HasPrefix(podname, common.NameDaemonSetAgentHostNet) {
-			targetPort = common.HostNetPodHTTPPort
-		} else {
-			targetPort = common.PodNetPodHTTPPort
+	if lc.filter != "" {
+		if _, err := filter.Parse(lc.filter); err != nil {
+			return fmt.Errorf("invalid filter: %s", err)
 		}
 	}
-
-	kubeconfigOpt := ""
-	if lc.kubeconfig != "" {
-		kubeconfigOpt = " --kubeconfig=" + lc.kubeconfig
+	if !slices.Contains(nwpd.SortFields, lc.sortBy) {
+		return fmt.Errorf("invalid sort-by: %s (allowed %s)", lc.sortBy, strings.Join(nwpd.SortFields, ", "))
+	}
+	if !slices.Contains(nwpd.AggregateByFields, lc.by) {
+		return fmt.Errorf("invalid aggregate-by: %s (allowed %s)", lc.by, strings.Join(nwpd.AggregateByFields, ", "))
+	}
+	for name, expr := range map[string]string{"job-regex": lc.jobRegex, "src-regex": lc.srcRegex, "dest-regex": lc.destRegex, "result-regex": lc.resultRegex} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
 	}
 
-	log.Infof("Loading observations from pod %s", podname)
-	cmdline := fmt.Sprintf("kubectl %s -n kube-system  port-forward %s %d:%d", kubeconfigOpt, podname, port, targetPort)
-	var stderr bytes.Buffer
-	cmd := exec.Command("sh", "-c", cmdline)              //  #nosec G204 -- only used in interactive shell
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true} // create process group for child processes
-	cmd.Stderr = &stderr
-	cmd.Env = os.Environ()
-	err := cmd.Start()
+	pf, err := agentclient.StartPortForward(log, lc.kubeconfig, args[1], lc.targetPort, &lc.tls, &lc.token)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
-	}()
+	defer pf.Close()
 
-	client := nwpd.NewAgentServiceProtobufClient(fmt.Sprintf("http://localhost:%d", port), &http.Client{})
+	client := pf.Client()
+	if failures {
+		return lc.listFailures(log, client)
+	}
 	request := &nwpd.GetObservationsRequest{
-		Start:               timestamppb.New(time.Now().Add(-lc.since)),
-		Limit:               int32(lc.limit),
-		RestrictToJobIDs:    lc.jobIDs,
-		RestrictToSrcHosts:  lc.srcHosts,
-		RestrictToDestHosts: lc.destHosts,
-		FailuresOnly:        lc.failedOnly,
-		AggregationWindow:   durationpb.New(lc.window),
+		Start:                       timestamppb.New(time.Now().Add(-lc.since)),
+		Limit:                       int32(lc.limit),
+		RestrictToJobIDs:            lc.jobIDs,
+		RestrictToSrcHosts:          lc.srcHosts,
+		RestrictToDestHosts:         lc.destHosts,
+		RestrictToLabels:            lc.labels,
+		RestrictToResultFields:      lc.fields,
+		JobIDRegex:                  lc.jobRegex,
+		SrcHostRegex:                lc.srcRegex,
+		DestHostRegex:               lc.destRegex,
+		ResultPattern:               lc.resultRegex,
+		OnlyFailureStreaksOfAtLeast: int32(lc.minStreak),
+		Filter:                      lc.filter,
+		FailuresOnly:                lc.failedOnly,
+		AggregationWindow:           durationpb.New(lc.window),
+		IncludeNoDataEdges:          lc.noData,
+		AggregateBy:                 lc.by,
+		PageToken:                   lc.pageToken,
 	}
-
-	for i := 0; i < 20; i++ {
-		if !lc.checkPortAvailable(port) {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
+	if lc.minDuration > 0 {
+		request.MinDuration = durationpb.New(lc.minDuration)
 	}
 
 	if aggr {
 		return lc.listAggregatedObservations(log, client, request)
 	}
+	request.SortBy = lc.sortBy
+	request.SortDescending = lc.descending
 	return lc.listObservations(log, client, request)
 }
 
@@ -144,13 +177,46 @@ func (lc *listCommand) listObservations(log logrus.FieldLogger, client nwpd.Agen
 			dur = fmt.Sprintf(" duration=%dms", obs.Duration.AsDuration().Milliseconds())
 		}
 		status := "ok"
-		if !obs.Ok {
+		switch {
+		case obs.StaleEndpoint:
+			status = "stale"
+		case !obs.Ok:
 			status = "failed"
 		}
 		fmt.Printf("%s src=%s dest=%s jobid=%s%s status=%s\n", obs.Timestamp.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
 			obs.SrcHost, obs.DestHost, obs.JobID, dur, status)
 	}
 	log.Infof("%d observations", len(response.Observations))
+	if response.NextPageToken != "" {
+		log.Infof("more observations available, continue with --page-token %s", response.NextPageToken)
+	}
+
+	return nil
+}
+
+func (lc *listCommand) listFailures(log logrus.FieldLogger, client nwpd.AgentService) error {
+	ctx := context.Background()
+	response, err := client.GetFailuresSince(ctx, &nwpd.GetFailuresSinceRequest{
+		Since:               timestamppb.New(time.Now().Add(-lc.since)),
+		RestrictToJobIDs:    lc.jobIDs,
+		RestrictToSrcHosts:  lc.srcHosts,
+		RestrictToDestHosts: lc.destHosts,
+	})
+	if err != nil {
+		return err
+	}
+	for _, ef := range response.Edges {
+		incident := ""
+		if ef.IncidentID != "" {
+			incident = " incident=" + ef.IncidentID
+		}
+		fmt.Printf("%s src=%s dest=%s jobid=%s failures=%d%s reason=%q\n", ef.LastFailure.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
+			ef.SrcHost, ef.DestHost, ef.JobID, ef.Failures, incident, ef.LastFailureReason)
+	}
+	log.Infof("%d failing edges since %s", len(response.Edges), response.Since.AsTime().UTC().Format(time.RFC3339))
+	if response.Truncated {
+		log.Infof("too many failures, only the most recent ones are counted")
+	}
 
 	return nil
 }
@@ -161,7 +227,24 @@ func (lc *listCommand) listAggregatedObservations(log logrus.FieldLogger, client
 	if err != nil {
 		return err
 	}
+	if len(response.AggregatedObservations) > 0 {
+		fmt.Printf("# aggregated by %s in windows of %s\n", request.AggregateBy, request.AggregationWindow.AsDuration())
+	}
 	for _, ao := range response.AggregatedObservations {
+		edge := fmt.Sprintf("src=%s dest=%s", ao.SrcHost, ao.DestHost)
+		if request.AggregateBy == nwpd.AggregateByZone {
+			edge = fmt.Sprintf("srcZone=%s destZone=%s", ao.SrcZone, ao.DestZone)
+		}
+		if ao.NoData {
+			validity := ""
+			if ao.NotValidInPeriod {
+				validity = " (not valid in period)"
+			}
+			window := ao.PeriodEnd.AsTime().Sub(ao.PeriodStart.AsTime())
+			fmt.Printf("%s %s %s no data%s\n", ao.PeriodStart.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
+				window, edge, validity)
+			continue
+		}
 		jobIDs := common.StringSet{}
 		for k := range ao.JobsOkCount {
 			jobIDs.Add(k)
@@ -169,6 +252,9 @@ func (lc *listCommand) listAggregatedObservations(log logrus.FieldLogger, client
 		for k := range ao.JobsNotOkCount {
 			jobIDs.Add(k)
 		}
+		for k := range ao.JobsStaleCount {
+			jobIDs.Add(k)
+		}
 		for jobID := range jobIDs {
 			okCount := ao.JobsOkCount[jobID]
 			notOkCount := ao.JobsNotOkCount[jobID]
@@ -176,21 +262,20 @@ func (lc *listCommand) listAggregatedObservations(log logrus.FieldLogger, client
 			if ao.MeanOkDuration[jobID] != nil {
 				dur = fmt.Sprintf(" meanDuration=%dms", ao.MeanOkDuration[jobID].AsDuration().Milliseconds())
 			}
+			if ao.P95OkDuration[jobID] != nil {
+				dur += fmt.Sprintf(" p50=%dms p95=%dms p99=%dms", ao.P50OkDuration[jobID].AsDuration().Milliseconds(),
+					ao.P95OkDuration[jobID].AsDuration().Milliseconds(), ao.P99OkDuration[jobID].AsDuration().Milliseconds())
+			}
+			stale := ""
+			if count := ao.JobsStaleCount[jobID]; count > 0 {
+				stale = fmt.Sprintf(" stale=%d", count)
+			}
 			window := ao.PeriodEnd.AsTime().Sub(ao.PeriodStart.AsTime())
-			fmt.Printf("%s %s src=%s dest=%s jobid=%s%s ok=%d failures=%d\n", ao.PeriodStart.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
-				window, ao.SrcHost, ao.DestHost, jobID, dur, okCount, notOkCount)
+			fmt.Printf("%s %s %s jobid=%s%s ok=%d failures=%d%s\n", ao.PeriodStart.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
+				window, edge, jobID, dur, okCount, notOkCount, stale)
 		}
 	}
 	log.Infof("%d aggregated observations", len(response.AggregatedObservations))
 
 	return nil
 }
-
-func (lc *listCommand) checkPortAvailable(port int) bool {
-	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-	if err != nil {
-		return false
-	}
-	_ = ln.Close()
-	return true
-}
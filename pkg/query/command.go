@@ -5,12 +5,15 @@
 package query
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/common/filter"
 	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
 
 	"github.com/spf13/cobra"
 )
@@ -20,6 +23,7 @@ type queryCommand struct {
 	src        string
 	dest       string
 	jobID      string
+	filter     string
 	minutes    int
 	failedOnly bool
 	exactMatch bool
@@ -37,14 +41,24 @@ func CreateQueryCmd() *cobra.Command {
 	cmd.Flags().StringVar(&qc.src, "src", "", "filter by source.")
 	cmd.Flags().StringVar(&qc.dest, "dest", "", "filter by dest.")
 	cmd.Flags().StringVar(&qc.jobID, "job", "", "filter by job ID.")
+	cmd.Flags().StringVar(&qc.filter, "filter", "", "filter expression, e.g. '!ok && destHost in 10.250.3.0/24 && duration > 2s' (fields: "+strings.Join(filter.Fields, ", ")+", labels.<name>, fields.<name>)")
 	cmd.Flags().BoolVar(&qc.failedOnly, "failed-only", false, "if only failed checks should be printed.")
 	cmd.Flags().BoolVar(&qc.exactMatch, "match-exact", false, "if filter expressions must match full names.")
 	cmd.Flags().IntVar(&qc.minutes, "minutes", 0, "restrict to given last minutes.")
+	cmd.AddCommand(createIncidentsCmd())
 
 	return cmd
 }
 
 func (qc *queryCommand) query(_ *cobra.Command, _ []string) error {
+	var expr *filter.Expression
+	if qc.filter != "" {
+		var err error
+		if expr, err = filter.Parse(qc.filter); err != nil {
+			return fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+
 	filenames, err := db.GetAnyRecordFiles(qc.directory, true)
 	if err != nil {
 		return err
@@ -82,6 +96,9 @@ func (qc *queryCommand) query(_ *cobra.Command, _ []string) error {
 			if qc.jobID != "" && !match(obs.JobID, qc.jobID) {
 				return nil
 			}
+			if expr != nil && !expr.Match(obs) {
+				return nil
+			}
 			if count == 0 {
 				fmt.Printf("[")
 			} else {
@@ -93,7 +110,23 @@ func (qc *queryCommand) query(_ *cobra.Command, _ []string) error {
 			if obs.Duration != nil {
 				dur = fmt.Sprintf(`,"duration": "%dms"`, obs.Duration.AsDuration().Milliseconds())
 			}
-			fmt.Printf("{%q: %q, %q: %q, %q: %q, %q: %q%s, %q: %t}", "time", t, "src", obs.SrcHost, "dest", obs.DestHost, "jobID", obs.JobID, dur, "ok", obs.Ok)
+			extra := ""
+			if obs.IncidentID != "" {
+				extra = fmt.Sprintf(`, "incidentID": %q`, obs.IncidentID)
+			}
+			result, err := resultJSON(obs.Result)
+			if err != nil {
+				return err
+			}
+			extra += result
+			if len(obs.ResultFields) > 0 {
+				fields, err := json.Marshal(obs.ResultFields)
+				if err != nil {
+					return err
+				}
+				extra += fmt.Sprintf(`, "resultFields": %s`, fields)
+			}
+			fmt.Printf("{%q: %q, %q: %q, %q: %q, %q: %q%s, %q: %t%s}", "time", t, "src", obs.SrcHost, "dest", obs.DestHost, "jobID", obs.JobID, dur, "ok", obs.Ok, extra)
 			return nil
 		}); err != nil {
 			return err
@@ -106,3 +139,25 @@ func (qc *queryCommand) query(_ *cobra.Command, _ []string) error {
 	}
 	return nil
 }
+
+// resultJSON returns the JSON fields of the result. The reason and key/value pairs are added
+// for results matching the result grammar.
+func resultJSON(result string) (string, error) {
+	if result == "" {
+		return "", nil
+	}
+	s := fmt.Sprintf(`, "result": %q`, result)
+	r := resultparse.Parse(result)
+	if _, ok := r.(*resultparse.Generic); ok {
+		return s, nil
+	}
+	c := resultparse.CommonOf(r)
+	if c.Reason != "" {
+		s += fmt.Sprintf(`, "resultReason": %q`, c.Reason)
+	}
+	pairs, err := json.Marshal(c.Pairs)
+	if err != nil {
+		return "", err
+	}
+	return s + fmt.Sprintf(`, "resultPairs": %s`, pairs), nil
+}
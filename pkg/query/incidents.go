@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type incidentsCommand struct {
+	directory  string
+	kubeconfig string
+	targetPort int
+	tls        agentclient.TLSOptions
+	token      agentclient.TokenOptions
+	agent      string
+	jobID      string
+	dest       string
+	openOnly   bool
+	minutes    int
+}
+
+func createIncidentsCmd() *cobra.Command {
+	ic := &incidentsCommand{}
+	cmd := &cobra.Command{
+		Use:   "incidents",
+		Short: "query incidents",
+		Long: `query the open and recently closed incidents from the stored incident files in the input directory (downloaded with collect or directly on the node)
+or from a running agent using 'kubectl port-forward' and HTTP`,
+		Args: cobra.NoArgs,
+		RunE: ic.query,
+	}
+	cmd.Flags().StringVar(&ic.directory, "input", "collected-observations", "database directory to load the collected incident files.")
+	cmd.Flags().StringVar(&ic.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified (only used with --agent).")
+	cmd.Flags().IntVar(&ic.targetPort, "targetPort", 0, "target pod port (only used with --agent)")
+	ic.tls.AddFlags(cmd.Flags())
+	ic.token.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&ic.agent, "agent", "", "name of the agent pod to query instead of the input directory")
+	cmd.Flags().StringVar(&ic.jobID, "job", "", "filter by job ID.")
+	cmd.Flags().StringVar(&ic.dest, "dest", "", "filter by dest.")
+	cmd.Flags().BoolVar(&ic.openOnly, "open-only", false, "if only open incidents should be printed.")
+	cmd.Flags().IntVar(&ic.minutes, "minutes", 0, "restrict to incidents open in the given last minutes.")
+	return cmd
+}
+
+func (ic *incidentsCommand) query(_ *cobra.Command, _ []string) error {
+	request := &nwpd.ListIncidentsRequest{OpenOnly: ic.openOnly}
+	if ic.minutes > 0 {
+		request.Start = timestamppb.New(time.Now().Add(-time.Duration(ic.minutes) * time.Minute))
+	}
+	if ic.jobID != "" {
+		request.RestrictToJobIDs = []string{ic.jobID}
+	}
+	if ic.dest != "" {
+		request.RestrictToDestHosts = []string{ic.dest}
+	}
+
+	var (
+		incidents []*nwpd.Incident
+		err       error
+	)
+	if ic.agent != "" {
+		incidents, err = ic.queryAgent(request)
+	} else {
+		incidents, err = ic.queryDirectory(request)
+	}
+	if err != nil {
+		return err
+	}
+
+	items := make([]string, 0, len(incidents))
+	for _, inc := range incidents {
+		data, err := protojson.Marshal(inc)
+		if err != nil {
+			return err
+		}
+		items = append(items, string(data))
+	}
+	fmt.Printf("[%s]\n", strings.Join(items, ",\n"))
+	return nil
+}
+
+func (ic *incidentsCommand) queryAgent(request *nwpd.ListIncidentsRequest) ([]*nwpd.Incident, error) {
+	pf, err := agentclient.StartPortForward(logrus.WithField("cmd", "query"), ic.kubeconfig, ic.agent, ic.targetPort, &ic.tls, &ic.token)
+	if err != nil {
+		return nil, err
+	}
+	defer pf.Close()
+
+	response, err := pf.Client().ListIncidents(context.Background(), request)
+	if err != nil {
+		return nil, err
+	}
+	return response.Incidents, nil
+}
+
+func (ic *incidentsCommand) queryDirectory(request *nwpd.ListIncidentsRequest) ([]*nwpd.Incident, error) {
+	filenames, err := db.GetAnyIncidentFiles(ic.directory, true)
+	if err != nil {
+		return nil, err
+	}
+	var incidents []*nwpd.Incident
+	for _, filename := range filenames {
+		snapshot, err := db.ReadIncidentSnapshot(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, inc := range append(snapshot.Open, snapshot.Closed...) {
+			if db.MatchIncident(request, inc) {
+				incidents = append(incidents, inc)
+			}
+		}
+	}
+	db.SortIncidents(incidents)
+	return incidents, nil
+}
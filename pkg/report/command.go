@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type trendCommand struct {
+	kubeconfig string
+	targetPort int
+	tls        agentclient.TLSOptions
+	token      agentclient.TokenOptions
+	months     int
+}
+
+func CreateReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "reports based on long-term data of an agent",
+	}
+	cmd.AddCommand(createTrendCmd())
+	cmd.AddCommand(createGapsCmd())
+	return cmd
+}
+
+func createTrendCmd() *cobra.Command {
+	tc := &trendCommand{}
+	cmd := &cobra.Command{
+		Use:   "trend <podname>",
+		Short: "show daily availability and latency trend of an agent",
+		Long:  `show daily rollups of an agent using 'kubectl port-forward' and HTTP'`,
+		Args:  cobra.ExactArgs(1),
+		RunE:  tc.trend,
+	}
+	cmd.Flags().StringVar(&tc.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified.")
+	cmd.Flags().IntVar(&tc.targetPort, "targetPort", 0, "target pod port")
+	tc.tls.AddFlags(cmd.Flags())
+	tc.token.AddFlags(cmd.Flags())
+	cmd.Flags().IntVar(&tc.months, "months", 1, "number of months to show.")
+	return cmd
+}
+
+func (tc *trendCommand) trend(_ *cobra.Command, args []string) error {
+	log := logrus.WithField("cmd", "report-trend")
+
+	pf, err := agentclient.StartPortForward(log, tc.kubeconfig, args[0], tc.targetPort, &tc.tls, &tc.token)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	now := time.Now()
+	request := &nwpd.GetDailyRollupsRequest{
+		Start: timestamppb.New(now.AddDate(0, -tc.months, 0)),
+		End:   timestamppb.New(now),
+	}
+	response, err := pf.Client().GetDailyRollups(context.Background(), request)
+	if err != nil {
+		return err
+	}
+
+	for _, rollup := range response.Rollups {
+		if rollup.Missing {
+			fmt.Printf("%s src=%s missing\n", rollup.Date, rollup.SrcHost)
+			continue
+		}
+		for _, entry := range rollup.Entries {
+			total := entry.OkCount + entry.NotOkCount
+			availability := 0.0
+			if total > 0 {
+				availability = 100 * float64(entry.OkCount) / float64(total)
+			}
+			fmt.Printf("%s src=%s jobid=%s dest=%s availability=%.3f%% checks=%d%s\n", rollup.Date, rollup.SrcHost,
+				entry.JobID, entry.DestClass, availability, total, formatPercentiles(entry))
+		}
+	}
+	log.Infof("%d days", len(response.Rollups))
+	return nil
+}
+
+func formatPercentiles(entry *nwpd.RollupEntry) string {
+	if entry.P50Duration == nil {
+		return ""
+	}
+	return fmt.Sprintf(" p50=%dms p90=%dms p99=%dms", entry.P50Duration.AsDuration().Milliseconds(),
+		entry.P90Duration.AsDuration().Milliseconds(), entry.P99Duration.AsDuration().Milliseconds())
+}
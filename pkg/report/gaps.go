@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/agent/db"
+	"github.com/gardener/network-problem-detector/pkg/agent/gaps"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/spf13/cobra"
+)
+
+type gapsCommand struct {
+	directory        string
+	factor           float64
+	gracePeriod      time.Duration
+	maintenance      []string
+	limit            int
+	includeExplained bool
+}
+
+func createGapsCmd() *cobra.Command {
+	gc := &gapsCommand{}
+	cmd := &cobra.Command{
+		Use:   "gaps",
+		Short: "show the largest unexplained gaps between observations of collected data",
+		Long: `detects gaps between consecutive observations of an edge exceeding a multiple of the job period in the collected observations
+and classifies them using the job run records as cancelled, loadShedding, sampling or unknown.
+Gaps overlapping a maintenance window or ending in the grace period after an agent start are explained.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return gc.gaps(os.Stdout)
+		},
+	}
+	cmd.Flags().StringVar(&gc.directory, "input", "collected-observations", "database directory to load the collected observations.")
+	cmd.Flags().Float64Var(&gc.factor, "factor", gaps.DefaultFactor, "multiple of the job period the time between two observations must exceed.")
+	cmd.Flags().DurationVar(&gc.gracePeriod, "grace-period", gaps.DefaultGracePeriod, "time after an agent start in which ending gaps are explained.")
+	cmd.Flags().StringArrayVar(&gc.maintenance, "maintenance", nil,
+		"maintenance window as '<start>/<end>[=<reason>]' with RFC3339 timestamps (e.g. '2022-01-23T23:00:00Z/2022-01-24T01:00:00Z=upgrade'), can be repeated.")
+	cmd.Flags().IntVar(&gc.limit, "limit", 20, "maximum number of listed gaps.")
+	cmd.Flags().BoolVar(&gc.includeExplained, "include-explained", false, "list the explained gaps, too.")
+	return cmd
+}
+
+// parseMaintenanceWindow parses a maintenance window of the form `<start>/<end>[=<reason>]`.
+func parseMaintenanceWindow(value string) (gaps.Window, error) {
+	window := gaps.Window{}
+	timestamps, reason, _ := strings.Cut(value, "=")
+	start, end, ok := strings.Cut(timestamps, "/")
+	if !ok {
+		return window, fmt.Errorf("invalid maintenance window %q, must be '<start>/<end>[=<reason>]'", value)
+	}
+	var err error
+	if window.Start, err = time.Parse(time.RFC3339, start); err != nil {
+		return window, fmt.Errorf("invalid maintenance window start: %s", err)
+	}
+	if window.End, err = time.Parse(time.RFC3339, end); err != nil {
+		return window, fmt.Errorf("invalid maintenance window end: %s", err)
+	}
+	if !window.End.After(window.Start) {
+		return window, fmt.Errorf("invalid maintenance window %q, end must be after start", value)
+	}
+	window.Reason = reason
+	return window, nil
+}
+
+func (gc *gapsCommand) gaps(out io.Writer) error {
+	if gc.factor < 1.5 {
+		return fmt.Errorf("invalid factor, must be >= 1.5")
+	}
+	options := gaps.Options{Factor: gc.factor, GracePeriod: gc.gracePeriod}
+	for _, value := range gc.maintenance {
+		window, err := parseMaintenanceWindow(value)
+		if err != nil {
+			return err
+		}
+		options.MaintenanceWindows = append(options.MaintenanceWindows, window)
+	}
+
+	filenames, err := db.GetAnyRecordFiles(gc.directory, true)
+	if err != nil {
+		return err
+	}
+	// the record files are named by time, so that the observations of each edge are visited in time order
+	sort.Strings(filenames)
+
+	detector := gaps.NewDetector(options)
+	// the job run records are needed for the classification and read first
+	runs := 0
+	for _, filename := range filenames {
+		err := db.IterateRecordFileWithJobRuns(filename, func(_ *nwpd.Observation) error {
+			return nil
+		}, func(record *nwpd.JobRunRecord) error {
+			detector.AddJobRun(record)
+			runs++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("reading %s failed: %w", filename, err)
+		}
+	}
+	count := 0
+	for _, filename := range filenames {
+		err := db.IterateRecordFile(filename, func(obs *nwpd.Observation) error {
+			detector.Add(obs)
+			count++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("reading %s failed: %w", filename, err)
+		}
+	}
+	detected := detector.ClassifyAll()
+	gaps.SortByDuration(detected)
+
+	fmt.Fprintf(out, "%d gaps in %d observations and %d job run records of %d files\n", len(detected), count, runs, len(filenames))
+	counts := map[string]int{}
+	for _, g := range detected {
+		counts[g.Category]++
+	}
+	for _, category := range gaps.Categories {
+		if counts[category] == 0 {
+			continue
+		}
+		label := ""
+		if gaps.IsExplained(category) {
+			label = " (explained)"
+		}
+		fmt.Fprintf(out, "  %s: %d%s\n", category, counts[category], label)
+	}
+
+	title := "largest unexplained gaps:"
+	if gc.includeExplained {
+		title = "largest gaps:"
+	}
+	listed := 0
+	for _, g := range detected {
+		if listed >= gc.limit {
+			break
+		}
+		if g.Explained() && !gc.includeExplained {
+			continue
+		}
+		if listed == 0 {
+			fmt.Fprintln(out, title)
+		}
+		listed++
+		label := ""
+		if g.Explained() {
+			label = " [explained]"
+		}
+		fmt.Fprintf(out, "  %d. %s%s\n", listed, g, label)
+	}
+	return nil
+}
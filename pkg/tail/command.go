@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gardener/network-problem-detector/pkg/common"
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/filter"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type tailCommand struct {
+	kubeconfig  string
+	targetPort  int
+	tls         agentclient.TLSOptions
+	token       agentclient.TokenOptions
+	agent       string
+	limit       int
+	jobIDs      []string
+	srcHosts    []string
+	destHosts   []string
+	jobRegex    string
+	srcRegex    string
+	destRegex   string
+	resultRegex string
+	minDuration time.Duration
+	labels      map[string]string
+	fields      map[string]string
+	filter      string
+	failedOnly  bool
+	output      string
+}
+
+func CreateTailCmd() *cobra.Command {
+	tc := &tailCommand{}
+	cmd := &cobra.Command{
+		Use:   "tail --agent <podname>",
+		Short: "follow the observations of an agent as they happen",
+		Long: `follow the observations processed by an agent from now on using 'kubectl port-forward' and HTTP until interrupted.
+If the client cannot keep up, the agent drops observations for it and the number of dropped observations is logged at the end.`,
+		Args: cobra.NoArgs,
+		RunE: tc.tail,
+	}
+	cmd.Flags().StringVar(&tc.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified.")
+	cmd.Flags().IntVar(&tc.targetPort, "targetPort", 0, "target pod port")
+	tc.tls.AddFlags(cmd.Flags())
+	tc.token.AddFlags(cmd.Flags())
+	cmd.Flags().StringVar(&tc.agent, "agent", "", "name of the agent pod")
+	cmd.Flags().IntVar(&tc.limit, "limit", 0, "stop after this number of observations (0 for no limit).")
+	cmd.Flags().StringArrayVar(&tc.jobIDs, "job", nil, "jobID(s) to filter")
+	cmd.Flags().StringArrayVar(&tc.srcHosts, "src", nil, "source host(s) to filter")
+	cmd.Flags().StringArrayVar(&tc.destHosts, "dest", nil, "destination host(s) to filter")
+	cmd.Flags().StringVar(&tc.jobRegex, "job-regex", "", "regular expression for jobIDs to filter, e.g. '^tcp-n2'")
+	cmd.Flags().StringVar(&tc.srcRegex, "src-regex", "", "regular expression for source hosts to filter")
+	cmd.Flags().StringVar(&tc.destRegex, "dest-regex", "", "regular expression for destination hosts to filter, e.g. '^10\\.0\\.'")
+	cmd.Flags().StringVar(&tc.resultRegex, "result-regex", "", "regular expression for results to filter, e.g. 'i/o timeout'")
+	cmd.Flags().DurationVar(&tc.minDuration, "min-duration", 0, "only observations slower than this duration, e.g. '500ms'")
+	cmd.Flags().StringToStringVar(&tc.labels, "label", nil, "job label(s) to filter in format <key>=<value>")
+	cmd.Flags().StringToStringVar(&tc.fields, "result-field", nil, "result field value(s) to filter in format <name>=<value>")
+	cmd.Flags().StringVar(&tc.filter, "filter", "", "filter expression evaluated on the agent, e.g. '!ok && destHost in 10.250.3.0/24 && duration > 2s' (fields: "+strings.Join(filter.Fields, ", ")+", labels.<name>, fields.<name>)")
+	cmd.Flags().BoolVar(&tc.failedOnly, "failed-only", false, "only failures")
+	cmd.Flags().StringVarP(&tc.output, "output", "o", outputText, "output format (text, json)")
+	_ = cmd.MarkFlagRequired("agent")
+	return cmd
+}
+
+func (tc *tailCommand) tail(_ *cobra.Command, _ []string) error {
+	log := logrus.WithField("cmd", "tail")
+
+	if tc.output != outputText && tc.output != outputJSON {
+		return fmt.Errorf("invalid output: %s (allowed %s, %s)", tc.output, outputText, outputJSON)
+	}
+	if tc.filter != "" {
+		if _, err := filter.Parse(tc.filter); err != nil {
+			return fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+	for name, expr := range map[string]string{"job-regex": tc.jobRegex, "src-regex": tc.srcRegex, "dest-regex": tc.destRegex, "result-regex": tc.resultRegex} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+	}
+	request := &nwpd.GetObservationsRequest{
+		Limit:                  int32(tc.limit), // #nosec G115 -- limit is small
+		RestrictToJobIDs:       tc.jobIDs,
+		RestrictToSrcHosts:     tc.srcHosts,
+		RestrictToDestHosts:    tc.destHosts,
+		RestrictToLabels:       tc.labels,
+		RestrictToResultFields: tc.fields,
+		JobIDRegex:             tc.jobRegex,
+		SrcHostRegex:           tc.srcRegex,
+		DestHostRegex:          tc.destRegex,
+		ResultPattern:          tc.resultRegex,
+		Filter:                 tc.filter,
+		FailuresOnly:           tc.failedOnly,
+	}
+	if tc.minDuration > 0 {
+		request.MinDuration = durationpb.New(tc.minDuration)
+	}
+	body, err := protojson.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	pf, err := agentclient.StartPortForward(log, tc.kubeconfig, tc.agent, tc.targetPort, &tc.tls, &tc.token)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pf.BaseURL()+common.PathWatchObservations, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := pf.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+		return fmt.Errorf("tail failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	count, err := tc.print(resp.Body, os.Stdout)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		log.Infof("%d observations", count)
+		return nil
+	}
+	log.Infof("%d observations, %s observations dropped by the agent", count, resp.Trailer.Get(common.HeaderDroppedObservations))
+	return nil
+}
+
+// print writes the streamed observations to out and returns their number.
+func (tc *tailCommand) print(in io.Reader, out io.Writer) (int, error) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	count := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		count++
+		if tc.output == outputJSON {
+			if _, err := fmt.Fprintf(out, "%s\n", line); err != nil {
+				return count, err
+			}
+			continue
+		}
+		obs := &nwpd.Observation{}
+		if err := protojson.Unmarshal(line, obs); err != nil {
+			return count, fmt.Errorf("invalid observation: %w", err)
+		}
+		if _, err := fmt.Fprintln(out, nwpd.FormatObservation(obs)); err != nil {
+			return count, err
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return count, err
+	}
+	return count, nil
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gardener/network-problem-detector/pkg/common/agentclient"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd"
+	"github.com/gardener/network-problem-detector/pkg/common/nwpd/resultparse"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type triggerCommand struct {
+	kubeconfig string
+	targetPort int
+	tls        agentclient.TLSOptions
+	token      agentclient.TokenOptions
+	destHosts  []string
+}
+
+func CreateTriggerCmd() *cobra.Command {
+	tc := &triggerCommand{}
+	cmd := &cobra.Command{
+		Use:   "trigger <podname> <jobID>",
+		Short: "run a job of an agent immediately",
+		Long:  `run a job of an agent immediately for all or selected destinations using 'kubectl port-forward' and HTTP'`,
+		Args:  cobra.ExactArgs(2),
+		RunE:  tc.trigger,
+	}
+	cmd.Flags().StringVar(&tc.kubeconfig, "kubeconfig", "", "kubeconfig for shoot cluster, uses KUBECONFIG if not specified.")
+	cmd.Flags().IntVar(&tc.targetPort, "targetPort", 0, "target pod port")
+	tc.tls.AddFlags(cmd.Flags())
+	tc.token.AddFlags(cmd.Flags())
+	cmd.Flags().StringArrayVar(&tc.destHosts, "dest", nil, "destination host(s) to probe (all destinations if not specified)")
+	return cmd
+}
+
+func (tc *triggerCommand) trigger(_ *cobra.Command, args []string) error {
+	log := logrus.WithField("cmd", "trigger")
+
+	pf, err := agentclient.StartPortForward(log, tc.kubeconfig, args[0], tc.targetPort, &tc.tls, &tc.token)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	request := &nwpd.TriggerJobRequest{
+		JobID:               args[1],
+		RestrictToDestHosts: tc.destHosts,
+	}
+	response, err := pf.Client().TriggerJob(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	for _, obs := range response.Observations {
+		dur := ""
+		if obs.Duration != nil {
+			dur = fmt.Sprintf(" duration=%dms", obs.Duration.AsDuration().Milliseconds())
+		}
+		status := "ok"
+		switch {
+		case obs.StaleEndpoint:
+			status = "stale"
+		case !obs.Ok:
+			status = "failed"
+		}
+		fmt.Printf("%s src=%s dest=%s jobid=%s%s status=%s%s\n", obs.Timestamp.AsTime().UTC().Format("2006-01-02T15:04:05.000Z"),
+			obs.SrcHost, obs.DestHost, obs.JobID, dur, status, formatResult(obs.Result))
+	}
+	log.Infof("%d observations", len(response.Observations))
+	return nil
+}
+
+// formatResult formats the result with the key/value pairs prefixed by `result.`.
+// Results not matching the result grammar are printed quoted as `result`.
+func formatResult(result string) string {
+	r := resultparse.Parse(result)
+	if _, ok := r.(*resultparse.Generic); ok {
+		return fmt.Sprintf(" result=%q", result)
+	}
+	c := resultparse.CommonOf(r)
+	sb := strings.Builder{}
+	if c.Reason != "" {
+		fmt.Fprintf(&sb, " reason=%q", c.Reason)
+	}
+	for _, p := range c.Pairs {
+		sb.WriteString(" result.")
+		sb.WriteString(p.String())
+	}
+	if c.Attempts > 0 {
+		fmt.Fprintf(&sb, " attempts=%d", c.Attempts)
+	}
+	return sb.String()
+}